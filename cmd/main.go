@@ -1,17 +1,42 @@
 package main
 
 import (
+    "context"
+    "crypto/rand"
     "database/sql"
+    "encoding/hex"
     "encoding/json"
     "fmt"
     "log"
     "os"
     "time"
 
+    "example.com/m/internal/bulkpayments"
+    "example.com/m/internal/cards"
+    "example.com/m/internal/clearing"
+    "example.com/m/internal/config"
+    "example.com/m/internal/credit"
     "example.com/m/internal/database"
+    "example.com/m/internal/deposits"
+    "example.com/m/internal/fx"
     "example.com/m/internal/handlers"
+    "example.com/m/internal/identity"
+    "example.com/m/internal/holds"
+    "example.com/m/internal/interbank"
+    "example.com/m/internal/jobs"
+    "example.com/m/internal/ledger"
+    "example.com/m/internal/lifecycle"
+    "example.com/m/internal/limits"
+    "example.com/m/internal/mandates"
     "example.com/m/internal/middleware"
+    "example.com/m/internal/proxies"
+    "example.com/m/internal/qrpayments"
     "example.com/m/internal/repository"
+    "example.com/m/internal/restrictions"
+    "example.com/m/internal/standingorders"
+    "example.com/m/internal/statements"
+    "example.com/m/internal/storage"
+    "example.com/m/internal/transfers"
     "github.com/gofiber/fiber/v2"
     "github.com/gofiber/fiber/v2/middleware/cors"
     "github.com/gofiber/fiber/v2/middleware/logger"
@@ -21,6 +46,15 @@ import (
 // Database connection
 var db *sql.DB
 
+// Application configuration
+var appConfig = config.Default()
+
+// Statement worker, created by setupApp and started by main
+var statementWorker *statements.Worker
+
+// Connection to the interbank switch, shared by the clearing jobs and handlers
+var interbankConnector interbank.Connector
+
 // Customer คือโมเดลข้อมูลลูกค้าธนาคาร
 type Customer struct {
     ID           string    `json:"id"`
@@ -58,6 +92,265 @@ func setupDatabase() (*sql.DB, error) {
     return db, nil
 }
 
+// loadConfig reads the application configuration file
+func loadConfig() (*config.Config, error) {
+    path := os.Getenv("APP_CONFIG_PATH")
+    if path == "" {
+        path = "config/app.json"
+    }
+    return config.Load(path)
+}
+
+// newInterestAccrualJob builds the savings interest end-of-day batch
+func newInterestAccrualJob() *jobs.InterestAccrualJob {
+    ledgerRepo := repository.NewPostgresLedgerRepository(db)
+    return jobs.NewInterestAccrualJob(
+        repository.NewPostgresAccountRepository(db),
+        repository.NewPostgresProductRepository(db),
+        ledgerRepo,
+        repository.NewPostgresInterestRepository(db),
+        ledger.NewService(ledgerRepo),
+        appConfig.Interest,
+    )
+}
+
+// newAccountFeeJob builds the monthly account fee batch
+func newAccountFeeJob() *jobs.AccountFeeJob {
+    ledgerRepo := repository.NewPostgresLedgerRepository(db)
+    return jobs.NewAccountFeeJob(
+        repository.NewPostgresAccountRepository(db),
+        repository.NewPostgresProductRepository(db),
+        ledgerRepo,
+        ledger.NewService(ledgerRepo),
+    )
+}
+
+// newDepositService builds the fixed deposit service
+func newDepositService() *deposits.Service {
+    return deposits.NewService(
+        repository.NewPostgresAccountRepository(db),
+        repository.NewPostgresProductRepository(db),
+        repository.NewPostgresFixedDepositRepository(db),
+        ledger.NewService(repository.NewPostgresLedgerRepository(db)),
+        appConfig.Interest.WithholdingTaxRate,
+    )
+}
+
+// startJobs schedules the daily batch jobs
+func startJobs(ctx context.Context) error {
+    delinquencyJob := jobs.NewDelinquencyJob(repository.NewPostgresLoanAccountRepository(db), appConfig.Delinquency)
+    if err := jobs.StartDaily(ctx, delinquencyJob, appConfig.Delinquency.RunAt); err != nil {
+        return err
+    }
+    if err := jobs.StartDaily(ctx, newInterestAccrualJob(), appConfig.Interest.RunAt); err != nil {
+        return err
+    }
+    if err := jobs.StartDaily(ctx, newAccountFeeJob(), appConfig.Fees.RunAt); err != nil {
+        return err
+    }
+    maturityJob := jobs.NewFixedDepositMaturityJob(repository.NewPostgresFixedDepositRepository(db), newDepositService())
+    if err := jobs.StartDaily(ctx, maturityJob, appConfig.FixedDeposits.RunAt); err != nil {
+        return err
+    }
+    sweepInterval := time.Duration(appConfig.Holds.SweepIntervalSeconds) * time.Second
+    holdExpiryJob := jobs.NewHoldExpiryJob(newHoldService())
+    if err := jobs.StartEvery(ctx, holdExpiryJob, sweepInterval); err != nil {
+        return err
+    }
+    restrictionExpiryJob := jobs.NewRestrictionExpiryJob(newRestrictionService())
+    if err := jobs.StartEvery(ctx, restrictionExpiryJob, sweepInterval); err != nil {
+        return err
+    }
+    standingOrderInterval := time.Duration(appConfig.StandingOrders.RunIntervalSeconds) * time.Second
+    if err := jobs.StartEvery(ctx, jobs.NewStandingOrderJob(newStandingOrderService()), standingOrderInterval); err != nil {
+        return err
+    }
+    bulkPaymentInterval := time.Duration(appConfig.BulkPayments.RunIntervalSeconds) * time.Second
+    if err := jobs.StartEvery(ctx, jobs.NewBulkPaymentJob(newBulkPaymentService()), bulkPaymentInterval); err != nil {
+        return err
+    }
+    pollInterval := time.Duration(appConfig.Interbank.PollIntervalSeconds) * time.Second
+    if err := jobs.StartEvery(ctx, jobs.NewInterbankPollJob(newClearingService()), pollInterval); err != nil {
+        return err
+    }
+    if err := jobs.StartDaily(ctx, jobs.NewInterbankReconciliationJob(newClearingService()), appConfig.Interbank.ReconcileAt); err != nil {
+        return err
+    }
+    if err := jobs.StartEvery(ctx, jobs.NewProxyInquiryJob(newProxyService()), pollInterval); err != nil {
+        return err
+    }
+    return jobs.StartDaily(ctx, jobs.NewDormancyJob(newDormancyService()), appConfig.Dormancy.RunAt)
+}
+
+// newTransferService builds the transfer service with its limit checks
+func newTransferService() *transfers.Service {
+    accountRepo := repository.NewPostgresAccountRepository(db)
+    ledgerRepo := repository.NewPostgresLedgerRepository(db)
+    valuer := fx.NewValuer(repository.NewPostgresFXRepository(db))
+    limitService := limits.NewService(repository.NewPostgresLimitRepository(db), newIdentityVerifier(), valuer, appConfig.Limits)
+    return transfers.NewService(accountRepo, repository.NewPostgresProductRepository(db), ledgerRepo, ledger.NewService(ledgerRepo), limitService)
+}
+
+// newMandateService builds the service that applies the signing rules of joint accounts
+func newMandateService() *mandates.Service {
+    return mandates.NewService(
+        repository.NewPostgresAccountRepository(db),
+        repository.NewPostgresPendingTransferRepository(db),
+        newTransferService(),
+        appConfig.JointAccounts,
+    )
+}
+
+// newStandingOrderService builds the standing order service
+func newStandingOrderService() *standingorders.Service {
+    return standingorders.NewService(
+        repository.NewPostgresStandingOrderRepository(db),
+        repository.NewPostgresAccountRepository(db),
+        repository.NewPostgresNotificationRepository(db),
+        newTransferService(),
+        appConfig.StandingOrders,
+    )
+}
+
+// newBulkPaymentService builds the service that pays CSV files of payments
+func newBulkPaymentService() *bulkpayments.Service {
+    return bulkpayments.NewService(
+        repository.NewPostgresBulkPaymentRepository(db),
+        repository.NewPostgresAccountRepository(db),
+        repository.NewPostgresNotificationRepository(db),
+        newTransferService(),
+        appConfig.BulkPayments,
+    )
+}
+
+// newClearingService builds the service that sends transfers to other banks
+func newClearingService() *clearing.Service {
+    return clearing.NewService(
+        repository.NewPostgresInterbankRepository(db),
+        repository.NewPostgresAccountRepository(db),
+        repository.NewPostgresLedgerRepository(db),
+        newTransferService(),
+        interbankConnector,
+        appConfig.Interbank,
+    )
+}
+
+// newProxyService builds the service that keeps the proxy registry and pays proxies
+func newProxyService() *proxies.Service {
+    return proxies.NewService(
+        repository.NewPostgresProxyRepository(db),
+        repository.NewPostgresAccountRepository(db),
+        database.NewCustomerRepository(db),
+        newTransferService(),
+        interbank.NewSimulator(appConfig.Interbank),
+        appConfig.Proxy,
+        appConfig.Interbank.BankCode,
+    )
+}
+
+// newDormancyService builds the dormant account service
+func newDormancyService() *lifecycle.DormancyService {
+    return lifecycle.NewDormancyService(
+        repository.NewPostgresDormancyRepository(db),
+        newIdentityVerifier(),
+        appConfig.Dormancy,
+    )
+}
+
+// newIdentityVerifier builds the identity check used to step up customer operations
+func newIdentityVerifier() *identity.Verifier {
+    return identity.NewVerifier(repository.NewPostgresIdentityRepository(db), database.NewCustomerRepository(db), appConfig.Identity)
+}
+
+// newRestrictionService builds the account restriction service
+func newRestrictionService() *restrictions.Service {
+    return restrictions.NewService(
+        repository.NewPostgresRestrictionRepository(db),
+        repository.NewPostgresAccountRepository(db),
+    )
+}
+
+// newHoldService builds the account hold service
+func newHoldService() *holds.Service {
+    return holds.NewService(
+        repository.NewPostgresHoldRepository(db),
+        ledger.NewService(repository.NewPostgresLedgerRepository(db)),
+    )
+}
+
+// newBlobStorage returns the storage used for uploaded files
+func newBlobStorage() storage.BlobStorage {
+    dir := os.Getenv("STORAGE_DIR")
+    if dir == "" {
+        dir = "data/storage"
+    }
+    return storage.NewLocalStorage(dir)
+}
+
+// newStatementWorker builds the background worker that renders account statements
+func newStatementWorker(store storage.BlobStorage) *statements.Worker {
+    var pdf *statements.PDFRenderer
+    if appConfig.Statements.FontPath == "" {
+        log.Println("PDF statements are disabled: statements.font_path is not set")
+    } else {
+        var err error
+        pdf, err = statements.NewPDFRenderer(appConfig.Statements.FontPath)
+        if err != nil {
+            log.Printf("PDF statements are unavailable: %v", err)
+        }
+    }
+    return statements.NewWorker(
+        repository.NewPostgresAccountRepository(db),
+        repository.NewPostgresLedgerRepository(db),
+        repository.NewPostgresStatementRepository(db),
+        store,
+        pdf,
+    )
+}
+
+// newStatementSigner returns the signer for statement download links. Without
+// STATEMENT_URL_SECRET a random secret is used, so links do not survive a restart.
+func newStatementSigner() *statements.URLSigner {
+    secret := []byte(os.Getenv("STATEMENT_URL_SECRET"))
+    if len(secret) == 0 {
+        secret = make([]byte, 32)
+        if _, err := rand.Read(secret); err != nil {
+            log.Fatalf("Failed to generate statement URL secret: %v", err)
+        }
+    }
+    ttl := time.Duration(appConfig.Statements.DownloadURLTTLSeconds) * time.Second
+    return statements.NewURLSigner(secret, ttl)
+}
+
+// newCardVault returns the vault that encrypts card numbers. CARD_ENCRYPTION_KEY
+// is 32 bytes in hex and must be set: card numbers sealed under one key cannot
+// be read or found under another.
+func newCardVault() *cards.Vault {
+    key, err := hex.DecodeString(os.Getenv("CARD_ENCRYPTION_KEY"))
+    if err != nil || len(key) == 0 {
+        log.Fatal("CARD_ENCRYPTION_KEY must be set to a hex encoded 32 byte key")
+    }
+    vault, err := cards.NewVault(key)
+    if err != nil {
+        log.Fatalf("Invalid CARD_ENCRYPTION_KEY: %v", err)
+    }
+    return vault
+}
+
+// newCreditEngine builds the loan scoring engine from the credit policy file,
+// falling back to the built-in policy when the file is not present
+func newCreditEngine() credit.Engine {
+    path := os.Getenv("CREDIT_POLICY_PATH")
+    if path == "" {
+        path = "config/credit_policy.json"
+    }
+    if _, err := os.Stat(path); err != nil {
+        log.Printf("Credit policy %s not found, using built-in policy", path)
+        return credit.NewRuleEngine(credit.NewStaticPolicySource(credit.DefaultPolicy()))
+    }
+    return credit.NewRuleEngine(credit.NewFilePolicySource(path))
+}
+
 // UpdateContactRequest represents the request body for updating contact information
 type UpdateContactRequest struct {
     Phone string `json:"phone"`
@@ -117,6 +410,8 @@ func setupStaffRoutes(app *fiber.App) {
 // setupApp configures and returns a Fiber app instance
 func setupApp() *fiber.App {
     app := fiber.New(fiber.Config{
+        // Allow multipart uploads of loan documents plus form overhead
+        BodyLimit: 2 * handlers.MaxLoanDocumentSize,
         ErrorHandler: func(c *fiber.Ctx, err error) error {
             code := fiber.StatusInternalServerError
             if e, ok := err.(*fiber.Error); ok {
@@ -176,10 +471,228 @@ func setupApp() *fiber.App {
 
     // Loan feature
     loanRepo := repository.NewPostgresLoanRepository(db)
-    loanHandler := handlers.NewLoanHandler(loanRepo)
+    loanHandler := handlers.NewLoanHandler(loanRepo, newCreditEngine())
     api := app.Group("/api/v1")
     loans := api.Group("/loans")
     loans.Post("/personal/apply", middleware.JWTMiddleware(), loanHandler.ApplyForPersonalLoan)
+    loans.Post("/applications/:applicationId/resubmit", middleware.JWTMiddleware(), loanHandler.ResubmitLoanApplication)
+
+    // Loan documents
+    blobStorage := newBlobStorage()
+    loanDocumentRepo := repository.NewPostgresLoanDocumentRepository(db)
+    loanDocumentHandler := handlers.NewLoanDocumentHandler(loanRepo, loanDocumentRepo, blobStorage)
+    loans.Post("/applications/:applicationId/documents", middleware.JWTMiddleware(), loanDocumentHandler.UploadLoanDocument)
+
+    // Accounts
+    accountRepo := repository.NewPostgresAccountRepository(db)
+    productRepo := repository.NewPostgresProductRepository(db)
+    ledgerService := ledger.NewService(repository.NewPostgresLedgerRepository(db))
+    fixedDepositRepo := repository.NewPostgresFixedDepositRepository(db)
+    accountHandler := handlers.NewAccountHandler(accountRepo, productRepo, fixedDepositRepo, ledgerService, newDepositService(), newMandateService())
+    accounts := api.Group("/accounts", middleware.JWTMiddleware())
+    accounts.Post("/savings", accountHandler.OpenSavingsAccount)
+    accounts.Post("/fixed-deposits", accountHandler.OpenFixedDeposit)
+    accounts.Get("/fixed-deposits/:accountId", accountHandler.GetFixedDeposit)
+    accounts.Get("/:accountId/balance", accountHandler.GetAccountBalance)
+    accounts.Post("/fixed-deposits/:accountId/withdraw", accountHandler.WithdrawFixedDeposit)
+    accounts.Put("/:accountId/nickname", accountHandler.UpdateAccountNickname)
+    accounts.Get("/:accountId/preferences", accountHandler.GetAccountPreferences)
+    accounts.Put("/:accountId/preferences", accountHandler.UpdateAccountPreferences)
+    app.Get("/customers/me/accounts", middleware.JWTMiddleware(), accountHandler.ListCustomerAccounts)
+
+    // Transaction history and statements
+    statementWorker = newStatementWorker(blobStorage)
+    statementHandler := handlers.NewStatementHandler(
+        accountRepo,
+        repository.NewPostgresLedgerRepository(db),
+        repository.NewPostgresStatementRepository(db),
+        blobStorage,
+        statementWorker,
+        newStatementSigner(),
+        appConfig.Statements,
+    )
+    accounts.Get("/:accountId/transactions", statementHandler.GetTransactionHistory)
+    accounts.Post("/:accountId/statements/request", statementHandler.RequestStatement)
+    accounts.Get("/:accountId/statements/:statementId", statementHandler.GetStatement)
+    api.Get("/statements/:statementId/download", statementHandler.DownloadStatement)
+
+    // Transfers and withdrawals
+    fxRepo := repository.NewPostgresFXRepository(db)
+    limitService := limits.NewService(repository.NewPostgresLimitRepository(db), newIdentityVerifier(), fx.NewValuer(fxRepo), appConfig.Limits)
+    fxService := fx.NewService(fxRepo, accountRepo, ledgerService, limitService, appConfig.FX)
+    transferService := transfers.NewService(accountRepo, productRepo, repository.NewPostgresLedgerRepository(db), ledgerService, limitService)
+    mandateService := mandates.NewService(accountRepo, repository.NewPostgresPendingTransferRepository(db), transferService, appConfig.JointAccounts)
+    transferHandler := handlers.NewTransferHandler(accountRepo, transferService, mandateService)
+    accounts.Post("/:accountId/transfer", transferHandler.Transfer)
+    accounts.Post("/:accountId/withdraw", transferHandler.Withdraw)
+
+    // Transaction limits
+    limitHandler := handlers.NewLimitHandler(limitService)
+    app.Get("/customers/me/limits", middleware.JWTMiddleware(), limitHandler.GetLimits)
+    app.Put("/customers/me/limits", middleware.JWTMiddleware(), limitHandler.UpdateLimit)
+
+    // Joint accounts and co-owner approvals
+    mandateHandler := handlers.NewMandateHandler(accountRepo, mandateService)
+    accounts.Get("/:accountId/mandates", mandateHandler.ListMandates)
+    accounts.Get("/:accountId/pending-transfers", mandateHandler.ListPendingTransfers)
+    accounts.Post("/:accountId/pending-transfers/:pendingId/approve", mandateHandler.ApprovePendingTransfer)
+    accounts.Post("/:accountId/pending-transfers/:pendingId/reject", mandateHandler.RejectPendingTransfer)
+
+    // Foreign currency conversions
+    fxHandler := handlers.NewFXHandler(accountRepo, fxService, mandateService)
+    api.Get("/fx/rates", middleware.JWTMiddleware(), fxHandler.GetRates)
+    accounts.Post("/:accountId/fx-quotes", fxHandler.RequestQuote)
+    accounts.Post("/:accountId/fx-quotes/:quoteId/execute", fxHandler.ExecuteQuote)
+
+    // Standing orders and customer notifications
+    standingOrderService := standingorders.NewService(
+        repository.NewPostgresStandingOrderRepository(db),
+        accountRepo,
+        repository.NewPostgresNotificationRepository(db),
+        transferService,
+        appConfig.StandingOrders,
+    )
+    standingOrderHandler := handlers.NewStandingOrderHandler(accountRepo, standingOrderService, mandateService)
+    accounts.Post("/:accountId/standing-orders", standingOrderHandler.CreateStandingOrder)
+    accounts.Get("/:accountId/standing-orders", standingOrderHandler.ListStandingOrders)
+    accounts.Get("/:accountId/standing-orders/:orderId", standingOrderHandler.GetStandingOrder)
+    accounts.Post("/:accountId/standing-orders/:orderId/pause", standingOrderHandler.PauseStandingOrder)
+    accounts.Post("/:accountId/standing-orders/:orderId/resume", standingOrderHandler.ResumeStandingOrder)
+    accounts.Post("/:accountId/standing-orders/:orderId/cancel", standingOrderHandler.CancelStandingOrder)
+    notificationHandler := handlers.NewNotificationHandler(repository.NewPostgresNotificationRepository(db))
+    app.Get("/customers/me/notifications", middleware.JWTMiddleware(), notificationHandler.ListNotifications)
+
+    // Proxy registry and transfers to mobile numbers and national IDs
+    proxyService := newProxyService()
+    proxyHandler := handlers.NewProxyHandler(accountRepo, proxyService, mandateService)
+    accounts.Post("/:accountId/proxies", proxyHandler.RegisterProxy)
+    accounts.Get("/:accountId/proxies", proxyHandler.ListProxies)
+    accounts.Post("/:accountId/proxies/:proxyId/deregister", proxyHandler.DeregisterProxy)
+    accounts.Post("/:accountId/proxy-transfers", proxyHandler.ResolveProxyTransfer)
+    accounts.Post("/:accountId/proxy-transfers/:transferId/confirm", proxyHandler.ConfirmProxyTransfer)
+
+    // Thai QR codes
+    qrService := qrpayments.NewService(transferService, repository.NewPostgresProxyRepository(db), accountRepo, appConfig.Interbank.BankCode)
+    qrPaymentHandler := handlers.NewQRPaymentHandler(accountRepo, qrService, transferService, mandateService)
+    accounts.Post("/:accountId/qr-codes", qrPaymentHandler.GenerateQRCode)
+    accounts.Post("/:accountId/qr-payments", qrPaymentHandler.PayQRCode)
+
+    // Interbank transfers and ledger transaction status
+    interbankRepo := repository.NewPostgresInterbankRepository(db)
+    interbankHandler := handlers.NewInterbankHandler(accountRepo, newClearingService(), mandateService)
+    accounts.Post("/:accountId/interbank-transfers", interbankHandler.SendInterbankTransfer)
+    accounts.Get("/:accountId/interbank-transfers/:transferId", interbankHandler.GetInterbankTransfer)
+    transactionHandler := handlers.NewTransactionHandler(accountRepo, repository.NewPostgresLedgerRepository(db), interbankRepo)
+    api.Get("/transactions/:transactionId", middleware.JWTMiddleware(), transactionHandler.GetTransaction)
+
+    // Bulk payments uploaded as CSV files
+    bulkPaymentHandler := handlers.NewBulkPaymentHandler(accountRepo, newBulkPaymentService(), mandateService)
+    accounts.Post("/:accountId/bulk-payments", bulkPaymentHandler.UploadBulkPayment)
+    accounts.Get("/:accountId/bulk-payments/:bulkPaymentId", bulkPaymentHandler.GetBulkPayment)
+    accounts.Post("/:accountId/bulk-payments/:bulkPaymentId/confirm", bulkPaymentHandler.ConfirmBulkPayment)
+    accounts.Post("/:accountId/bulk-payments/:bulkPaymentId/cancel", bulkPaymentHandler.CancelBulkPayment)
+    accounts.Get("/:accountId/bulk-payments/:bulkPaymentId/result", bulkPaymentHandler.DownloadBulkPaymentResult)
+
+    // Debit cards
+    cardService := cards.NewService(
+        repository.NewPostgresCardRepository(db),
+        accountRepo,
+        database.NewCustomerRepository(db),
+        repository.NewPostgresNotificationRepository(db),
+        cards.LogMailer{},
+        newCardVault(),
+        appConfig.Cards,
+    )
+    cardHandler := handlers.NewCardHandler(accountRepo, cardService, mandateService)
+    accounts.Post("/:accountId/cards/request-debit", cardHandler.RequestDebitCard)
+    customerCards := api.Group("/cards", middleware.JWTMiddleware())
+    customerCards.Put("/:last4/activate", cardHandler.ActivateCard)
+    customerCards.Put("/:last4/block", cardHandler.BlockCard)
+    customerCards.Put("/:last4/unblock", cardHandler.UnblockCard)
+    customerCards.Get("/:last4/controls", cardHandler.GetCardControls)
+    customerCards.Put("/:last4/controls", cardHandler.UpdateCardControls)
+
+    // Dormant accounts
+    dormancyRepo := repository.NewPostgresDormancyRepository(db)
+    dormancyHandler := handlers.NewDormancyHandler(accountRepo, dormancyRepo, newDormancyService())
+    accounts.Post("/:accountId/reactivate", dormancyHandler.ReactivateAccount)
+
+    // Staff API routes
+    loanAccountRepo := repository.NewPostgresLoanAccountRepository(db)
+    loanAccountHandler := handlers.NewLoanAccountHandler(loanRepo, loanAccountRepo, accountRepo, appConfig.Loans)
+    staffAPI := api.Group("/staff", middleware.StaffAuthMiddleware())
+    staffAPI.Get("/loans/applications/:applicationId", loanHandler.GetLoanApplicationDetails)
+    staffAPI.Put("/loans/applications/:applicationId/status", loanAccountHandler.UpdateLoanApplicationStatus)
+    staffAPI.Get("/loans/delinquency-report", loanAccountHandler.GetDelinquencyReport)
+
+    // Staff account closure
+    closureService := lifecycle.NewClosureService(
+        accountRepo,
+        productRepo,
+        loanAccountRepo,
+        fixedDepositRepo,
+        repository.NewPostgresRestrictionRepository(db),
+        repository.NewPostgresCardRepository(db),
+        newInterestAccrualJob(),
+        ledgerService,
+    )
+    accountClosureHandler := handlers.NewAccountClosureHandler(closureService)
+    staffAPI.Delete("/accounts/:accountId", accountClosureHandler.CloseAccount)
+
+    // Staff cash deposits at a branch counter
+    staffAPI.Post("/accounts/:accountId/deposits", accountHandler.DepositCash)
+
+    // Staff account restrictions (maker-checker)
+    restrictionHandler := handlers.NewRestrictionHandler(repository.NewPostgresRestrictionRepository(db), newRestrictionService())
+    staffAPI.Post("/accounts/:accountId/restrictions", restrictionHandler.RequestRestriction)
+    staffAPI.Get("/accounts/:accountId/restrictions", restrictionHandler.ListAccountRestrictions)
+    staffAPI.Get("/restrictions/pending", restrictionHandler.ListPendingRestrictions)
+    staffAPI.Post("/restrictions/:restrictionId/approve", restrictionHandler.ApproveRestriction)
+    staffAPI.Post("/restrictions/:restrictionId/reject", restrictionHandler.RejectRestriction)
+    staffAPI.Post("/restrictions/:restrictionId/lift", restrictionHandler.LiftRestriction)
+    staffAPI.Get("/restrictions/:restrictionId/audit", restrictionHandler.GetRestrictionAudit)
+
+    // Staff dormancy report and reactivation
+    staffAPI.Get("/accounts/dormancy-report", dormancyHandler.GetDormancyReport)
+    staffAPI.Get("/accounts/:accountId/dormancy", dormancyHandler.GetDormancyHistory)
+    staffAPI.Post("/accounts/:accountId/reactivate", dormancyHandler.StaffReactivateAccount)
+
+    // Staff account holders and signing rules
+    staffAPI.Post("/accounts/:accountId/mandates", mandateHandler.AddMandate)
+    staffAPI.Delete("/accounts/:accountId/mandates/:customerId", mandateHandler.RemoveMandate)
+    staffAPI.Put("/accounts/:accountId/signing-rule", mandateHandler.UpdateSigningRule)
+
+    // Staff customer tiers
+    staffAPI.Put("/customers/:customerId/tier", limitHandler.UpdateCustomerTier)
+
+    // Staff card search and reports
+    staffAPI.Get("/cards/search", cardHandler.SearchCards)
+    staffAPI.Put("/cards/:last4/report-lost-stolen", cardHandler.ReportCardLostStolen)
+
+    // Staff exchange rate uploads
+    staffAPI.Post("/fx/rates", fxHandler.UploadRates)
+
+    // Staff account holds
+    holdHandler := handlers.NewHoldHandler(accountRepo, repository.NewPostgresHoldRepository(db), newHoldService())
+    staffAPI.Post("/accounts/:accountId/holds", holdHandler.PlaceHold)
+    staffAPI.Get("/accounts/:accountId/holds", holdHandler.ListHolds)
+    staffAPI.Post("/holds/:holdId/release", holdHandler.ReleaseHold)
+    staffAPI.Post("/holds/:holdId/capture", holdHandler.CaptureHold)
+
+    // Staff product catalog
+    productHandler := handlers.NewProductHandler(productRepo)
+    staffAPI.Post("/products", productHandler.CreateProduct)
+    staffAPI.Get("/products", productHandler.ListProducts)
+    staffAPI.Get("/products/:code", productHandler.GetProduct)
+    staffAPI.Get("/products/:code/versions", productHandler.GetProductVersions)
+    staffAPI.Put("/products/:code", productHandler.UpdateProduct)
+    staffAPI.Delete("/products/:code", productHandler.RetireProduct)
+
+    // Staff batch operations
+    batchHandler := handlers.NewBatchHandler(newInterestAccrualJob())
+    staffAPI.Post("/batch/interest-accrual/replay", batchHandler.ReplayInterestAccrual)
+    staffAPI.Get("/loans/applications/:applicationId/documents", loanDocumentHandler.ListLoanDocuments)
+    staffAPI.Get("/loans/applications/:applicationId/documents/:documentId", loanDocumentHandler.DownloadLoanDocument)
 
     // Staff routes
     setupStaffRoutes(app)
@@ -189,6 +702,12 @@ func setupApp() *fiber.App {
 
 func main() {
     var err error
+    appConfig, err = loadConfig()
+    if err != nil {
+        log.Printf("Failed to load configuration: %v", err)
+        os.Exit(1)
+    }
+
     db, err = setupDatabase()
     if err != nil {
         log.Printf("Failed to connect to database: %v", err)
@@ -196,7 +715,21 @@ func main() {
     }
     defer db.Close()
 
+    interbankConnector, err = interbank.NewConnector(appConfig.Interbank)
+    if err != nil {
+        log.Printf("Failed to connect to the interbank switch: %v", err)
+        os.Exit(1)
+    }
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    if err = startJobs(ctx); err != nil {
+        log.Printf("Failed to start jobs: %v", err)
+        os.Exit(1)
+    }
+
     app := setupApp()
+    statementWorker.Start(ctx)
     log.Println("Starting server on port 3000...")
     app.Listen(":3000")
 }
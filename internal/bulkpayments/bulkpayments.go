@@ -0,0 +1,483 @@
+package bulkpayments
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/transfers"
+	"github.com/google/uuid"
+)
+
+// maxReferenceLength is the longest reference accepted in a row
+const maxReferenceLength = 100
+
+// columns are the leading columns of a bulk payment file; description is optional
+var columns = []string{"account_number", "amount", "reference", "description"}
+
+var (
+	// ErrInvalidHeader is returned when the file does not start with the expected header
+	ErrInvalidHeader = errors.New("the header must be account_number,amount,reference with an optional description column")
+	// ErrInvalidFile is returned when the file cannot be read as CSV
+	ErrInvalidFile = errors.New("the file is not a valid CSV file")
+	// ErrEmptyFile is returned when the file has no payment rows
+	ErrEmptyFile = errors.New("the file has no payments")
+	// ErrTooManyRows is returned when the file has more rows than allowed
+	ErrTooManyRows = errors.New("the file has too many payments")
+	// ErrBulkPaymentNotFound is returned when the file does not exist for the account
+	ErrBulkPaymentNotFound = errors.New("bulk payment not found")
+	// ErrNotAwaitingConfirmation is returned when confirming or cancelling a file that was already confirmed or cancelled
+	ErrNotAwaitingConfirmation = errors.New("bulk payment is not awaiting confirmation")
+	// ErrNoValidRows is returned when confirming a file in which every row is invalid
+	ErrNoValidRows = errors.New("bulk payment has no valid payments to make")
+	// ErrNotCompleted is returned when the result file is requested before the file was executed
+	ErrNotCompleted = errors.New("bulk payment has not finished; the result file is available once it completes")
+	// ErrAccountNotFound is returned when the account of a file no longer exists
+	ErrAccountNotFound = errors.New("account not found")
+)
+
+// Transferrer finds destination accounts and posts transfers between them
+type Transferrer interface {
+	FindDestination(ctx context.Context, accountNumber string) (*models.Account, error)
+	Transfer(ctx context.Context, input transfers.Input) (*models.TransferResult, error)
+}
+
+// Service validates CSV files of payments from one account and, once the
+// customer confirms them, pays every valid row in the background. Each row
+// is an ordinary transfer, so the ledger checks holds and available balance
+// and the customer's transaction limits apply to every payment. A row posts
+// with a reference made of the file and its line, so it is never paid twice.
+type Service struct {
+	repo          repository.BulkPaymentRepository
+	accountRepo   repository.AccountRepository
+	notifications repository.NotificationRepository
+	transfers     Transferrer
+	cfg           config.BulkPaymentConfig
+	now           func() time.Time
+}
+
+// NewService creates a new bulk payment Service
+func NewService(repo repository.BulkPaymentRepository, accountRepo repository.AccountRepository, notifications repository.NotificationRepository, transferrer Transferrer, cfg config.BulkPaymentConfig) *Service {
+	return &Service{
+		repo:          repo,
+		accountRepo:   accountRepo,
+		notifications: notifications,
+		transfers:     transferrer,
+		cfg:           cfg,
+		now:           time.Now,
+	}
+}
+
+// Upload validates every row of a file and records it for the customer to
+// review. Rows that fail validation are kept with their error and are not
+// paid; nothing moves until the file is confirmed.
+func (s *Service) Upload(ctx context.Context, from *models.Account, customerID uuid.UUID, fileName string, file io.Reader) (*models.BulkPaymentDetails, error) {
+	records, err := readRecords(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, ErrEmptyFile
+	}
+	if s.cfg.MaxRows > 0 && len(records) > s.cfg.MaxRows {
+		return nil, fmt.Errorf("%w: at most %d are allowed", ErrTooManyRows, s.cfg.MaxRows)
+	}
+
+	now := s.now()
+	batch := &models.BulkPayment{
+		ID:               uuid.New(),
+		AccountID:        from.ID,
+		CustomerID:       customerID,
+		FileName:         strings.TrimSpace(fileName),
+		Status:           models.BulkPaymentAwaitingConfirmation,
+		RowCount:         len(records),
+		AvailableBalance: from.Available(),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+
+	destinations := map[string]*models.Account{}
+	references := map[string]int{}
+	rows := make([]*models.BulkPaymentRow, 0, len(records))
+	for _, record := range records {
+		row := &models.BulkPaymentRow{
+			ID:              uuid.New(),
+			BulkPaymentID:   batch.ID,
+			LineNumber:      record.line,
+			ToAccountNumber: record.field(0),
+			Reference:       record.field(2),
+			Description:     record.field(3),
+			Status:          models.BulkRowValid,
+		}
+		if err := s.validate(ctx, from, row, record.field(1), destinations, references); err != nil {
+			row.Status = models.BulkRowInvalid
+			row.Error = err.Error()
+			batch.InvalidCount++
+		} else {
+			batch.ValidCount++
+			batch.TotalAmount += row.Amount
+		}
+		rows = append(rows, row)
+	}
+	batch.TotalAmount = from.CurrencyOrBase().Round(batch.TotalAmount)
+
+	if err := s.repo.CreateBulkPayment(ctx, batch, rows); err != nil {
+		return nil, err
+	}
+	return &models.BulkPaymentDetails{BulkPayment: *batch, Rows: rows}, nil
+}
+
+// validate checks one row and fills in its amount and destination
+func (s *Service) validate(ctx context.Context, from *models.Account, row *models.BulkPaymentRow, amount string, destinations map[string]*models.Account, references map[string]int) error {
+	if row.ToAccountNumber == "" {
+		return errors.New("account_number is required")
+	}
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return errors.New("amount is not a number")
+	}
+	row.Amount = value
+	if row.Reference == "" {
+		return errors.New("reference is required")
+	}
+	if len(row.Reference) > maxReferenceLength {
+		return fmt.Errorf("reference must not be longer than %d characters", maxReferenceLength)
+	}
+	if line, ok := references[row.Reference]; ok {
+		return fmt.Errorf("reference is already used on line %d", line)
+	}
+	references[row.Reference] = row.LineNumber
+
+	to, ok := destinations[row.ToAccountNumber]
+	if !ok {
+		to, err = s.transfers.FindDestination(ctx, row.ToAccountNumber)
+		if err != nil && !errors.Is(err, transfers.ErrDestinationNotFound) {
+			return err
+		}
+		destinations[row.ToAccountNumber] = to
+	}
+	if to == nil {
+		return transfers.ErrDestinationNotFound
+	}
+	if err := transfers.Validate(transfers.Input{From: from, To: to, Amount: row.Amount}); err != nil {
+		return err
+	}
+	row.ToAccountID = &to.ID
+	return nil
+}
+
+// Get returns a file of the account with its rows
+func (s *Service) Get(ctx context.Context, accountID, bulkPaymentID uuid.UUID) (*models.BulkPaymentDetails, error) {
+	batch, err := s.bulkPayment(ctx, accountID, bulkPaymentID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.repo.GetBulkPaymentRows(ctx, batch.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.BulkPaymentDetails{BulkPayment: *batch, Rows: rows}, nil
+}
+
+// Confirm queues a validated file for execution
+func (s *Service) Confirm(ctx context.Context, accountID, bulkPaymentID uuid.UUID) (*models.BulkPayment, error) {
+	batch, err := s.bulkPayment(ctx, accountID, bulkPaymentID)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Status != models.BulkPaymentAwaitingConfirmation {
+		return nil, ErrNotAwaitingConfirmation
+	}
+	if batch.ValidCount == 0 {
+		return nil, ErrNoValidRows
+	}
+
+	now := s.now()
+	batch.Status = models.BulkPaymentQueued
+	batch.ConfirmedAt = &now
+	return s.save(ctx, batch, models.BulkPaymentAwaitingConfirmation)
+}
+
+// Cancel drops a file that was not confirmed
+func (s *Service) Cancel(ctx context.Context, accountID, bulkPaymentID uuid.UUID) (*models.BulkPayment, error) {
+	batch, err := s.bulkPayment(ctx, accountID, bulkPaymentID)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Status != models.BulkPaymentAwaitingConfirmation {
+		return nil, ErrNotAwaitingConfirmation
+	}
+
+	batch.Status = models.BulkPaymentCancelled
+	return s.save(ctx, batch, models.BulkPaymentAwaitingConfirmation)
+}
+
+// ResultFile returns the name and CSV content of the result of an executed
+// file: every row with its status, error and transaction
+func (s *Service) ResultFile(ctx context.Context, accountID, bulkPaymentID uuid.UUID) (string, []byte, error) {
+	details, err := s.Get(ctx, accountID, bulkPaymentID)
+	if err != nil {
+		return "", nil, err
+	}
+	if details.Status != models.BulkPaymentCompleted {
+		return "", nil, ErrNotCompleted
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"line", "account_number", "amount", "reference", "description", "status", "error", "transaction_id"})
+	for _, row := range details.Rows {
+		transactionID := ""
+		if row.TransactionID != nil {
+			transactionID = row.TransactionID.String()
+		}
+		writer.Write([]string{
+			strconv.Itoa(row.LineNumber),
+			row.ToAccountNumber,
+			strconv.FormatFloat(row.Amount, 'f', 2, 64),
+			row.Reference,
+			row.Description,
+			string(row.Status),
+			row.Error,
+			transactionID,
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("bulk_payment_%s_result.csv", details.ID), buf.Bytes(), nil
+}
+
+// RunDue executes the confirmed files and returns how many were completed.
+// A file interrupted by a restart is picked up again; rows already paid
+// are not paid twice. A file that fails is logged and tried again on the
+// next run; the other files still run, and the errors are returned together.
+func (s *Service) RunDue(ctx context.Context) (int, error) {
+	batches, err := s.repo.GetRunnableBulkPayments(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	completed := 0
+	var errs []error
+	for _, batch := range batches {
+		ok, err := s.execute(ctx, batch)
+		if err != nil {
+			log.Printf("failed to execute bulk payment %s: %v", batch.ID, err)
+			errs = append(errs, fmt.Errorf("bulk payment %s: %w", batch.ID, err))
+			continue
+		}
+		if ok {
+			completed++
+		}
+	}
+	return completed, errors.Join(errs...)
+}
+
+// execute pays the valid rows of a file and records its totals. It
+// reports false when another run already took the file.
+func (s *Service) execute(ctx context.Context, batch *models.BulkPayment) (bool, error) {
+	if batch.Status == models.BulkPaymentQueued {
+		batch.Status = models.BulkPaymentProcessing
+		batch.UpdatedAt = s.now()
+		claimed, err := s.repo.UpdateBulkPaymentStatus(ctx, batch, models.BulkPaymentQueued)
+		if err != nil || !claimed {
+			return false, err
+		}
+	}
+
+	from, err := s.accountRepo.GetAccountByID(ctx, batch.AccountID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := s.repo.GetBulkPaymentRows(ctx, batch.ID)
+	if err != nil {
+		return false, err
+	}
+
+	batch.PaidCount, batch.FailedCount, batch.PaidAmount = 0, 0, 0
+	for _, row := range rows {
+		if row.Status == models.BulkRowValid {
+			if err := s.pay(ctx, batch, from, row); err != nil {
+				// The row stays valid and is tried again on the next run
+				return false, err
+			}
+		}
+		switch row.Status {
+		case models.BulkRowPaid:
+			batch.PaidCount++
+			batch.PaidAmount += row.Amount
+		case models.BulkRowFailed:
+			batch.FailedCount++
+		}
+	}
+
+	now := s.now()
+	batch.Status = models.BulkPaymentCompleted
+	if from != nil {
+		batch.PaidAmount = from.CurrencyOrBase().Round(batch.PaidAmount)
+	}
+	batch.CompletedAt = &now
+	batch.UpdatedAt = now
+	if err := s.repo.CompleteBulkPayment(ctx, batch); err != nil {
+		return false, err
+	}
+
+	s.notify(ctx, batch)
+	return true, nil
+}
+
+// pay posts one row. A refused payment marks the row failed; any other
+// error is returned without recording the row.
+func (s *Service) pay(ctx context.Context, batch *models.BulkPayment, from *models.Account, row *models.BulkPaymentRow) error {
+	result, err := s.transfer(ctx, batch, from, row)
+	if err != nil && !transfers.IsRefused(err) && !errors.Is(err, ErrAccountNotFound) {
+		return err
+	}
+
+	now := s.now()
+	row.ProcessedAt = &now
+	switch {
+	case err == nil || errors.Is(err, transfers.ErrAlreadyProcessed):
+		// An already processed reference was posted before a restart
+		row.Status = models.BulkRowPaid
+		if result != nil {
+			row.TransactionID = &result.TransactionID
+		}
+	default:
+		row.Status = models.BulkRowFailed
+		row.Error = err.Error()
+	}
+	return s.repo.UpdateBulkPaymentRow(ctx, row)
+}
+
+// transfer posts the payment of a row. The reference makes it idempotent.
+func (s *Service) transfer(ctx context.Context, batch *models.BulkPayment, from *models.Account, row *models.BulkPaymentRow) (*models.TransferResult, error) {
+	if from == nil {
+		return nil, ErrAccountNotFound
+	}
+	to, err := s.accountRepo.GetAccountByID(ctx, *row.ToAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if to == nil {
+		return nil, transfers.ErrDestinationNotFound
+	}
+
+	description := row.Description
+	if description == "" {
+		description = fmt.Sprintf("Bulk payment %s to %s", row.Reference, row.ToAccountNumber)
+	}
+	return s.transfers.Transfer(ctx, transfers.Input{
+		From:        from,
+		To:          to,
+		Amount:      row.Amount,
+		Description: description,
+		Reference:   fmt.Sprintf("bulk:%s:%d", batch.ID, row.LineNumber),
+		CustomerID:  batch.CustomerID,
+		Channel:     models.ChannelMobile,
+	})
+}
+
+func (s *Service) notify(ctx context.Context, batch *models.BulkPayment) {
+	message := fmt.Sprintf("Your bulk payment file %s was executed: %d payment(s) of %.2f in total were paid",
+		batch.FileName, batch.PaidCount, batch.PaidAmount)
+	if batch.FailedCount > 0 {
+		message += fmt.Sprintf(" and %d failed. Download the result file to see why", batch.FailedCount)
+	}
+	err := s.notifications.CreateNotification(ctx, &models.Notification{
+		ID:         uuid.New(),
+		CustomerID: batch.CustomerID,
+		Type:       models.NotificationBulkPaymentCompleted,
+		Title:      "Bulk payment completed",
+		Message:    message + ".",
+		CreatedAt:  s.now(),
+	})
+	if err != nil {
+		log.Printf("failed to notify customer %s about bulk payment %s: %v", batch.CustomerID, batch.ID, err)
+	}
+}
+
+func (s *Service) bulkPayment(ctx context.Context, accountID, bulkPaymentID uuid.UUID) (*models.BulkPayment, error) {
+	batch, err := s.repo.GetBulkPayment(ctx, bulkPaymentID)
+	if err != nil {
+		return nil, err
+	}
+	if batch == nil || batch.AccountID != accountID {
+		return nil, ErrBulkPaymentNotFound
+	}
+	return batch, nil
+}
+
+func (s *Service) save(ctx context.Context, batch *models.BulkPayment, from models.BulkPaymentStatus) (*models.BulkPayment, error) {
+	batch.UpdatedAt = s.now()
+	updated, err := s.repo.UpdateBulkPaymentStatus(ctx, batch, from)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrNotAwaitingConfirmation
+	}
+	return batch, nil
+}
+
+// record is one data row of the file with its line number
+type record struct {
+	line   int
+	fields []string
+}
+
+func (r record) field(i int) string {
+	if i >= len(r.fields) {
+		return ""
+	}
+	return strings.TrimSpace(r.fields[i])
+}
+
+// readRecords checks the header of the file and returns its data rows
+func readRecords(file io.Reader) ([]record, error) {
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, ErrEmptyFile
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	if len(header) < 3 || len(header) > len(columns) {
+		return nil, ErrInvalidHeader
+	}
+	for i, name := range header {
+		if strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))) != columns[i] {
+			return nil, ErrInvalidHeader
+		}
+	}
+
+	var records []record
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			// A csv.ParseError names the line
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, record{line: line, fields: fields})
+	}
+}
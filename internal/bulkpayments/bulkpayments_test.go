@@ -0,0 +1,309 @@
+package bulkpayments
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/limits"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/repository/repotest"
+	"example.com/m/internal/transfers"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubBulkPaymentRepository keeps files and rows in memory
+type stubBulkPaymentRepository struct {
+	repository.BulkPaymentRepository
+	batches map[uuid.UUID]models.BulkPayment
+	rows    map[uuid.UUID][]*models.BulkPaymentRow
+}
+
+func (r *stubBulkPaymentRepository) CreateBulkPayment(ctx context.Context, batch *models.BulkPayment, rows []*models.BulkPaymentRow) error {
+	r.batches[batch.ID] = *batch
+	for _, row := range rows {
+		copied := *row
+		r.rows[batch.ID] = append(r.rows[batch.ID], &copied)
+	}
+	return nil
+}
+
+func (r *stubBulkPaymentRepository) GetBulkPayment(ctx context.Context, id uuid.UUID) (*models.BulkPayment, error) {
+	batch, ok := r.batches[id]
+	if !ok {
+		return nil, nil
+	}
+	return &batch, nil
+}
+
+func (r *stubBulkPaymentRepository) GetBulkPaymentRows(ctx context.Context, id uuid.UUID) ([]*models.BulkPaymentRow, error) {
+	rows := []*models.BulkPaymentRow{}
+	for _, row := range r.rows[id] {
+		copied := *row
+		rows = append(rows, &copied)
+	}
+	return rows, nil
+}
+
+func (r *stubBulkPaymentRepository) UpdateBulkPaymentStatus(ctx context.Context, batch *models.BulkPayment, from models.BulkPaymentStatus) (bool, error) {
+	if r.batches[batch.ID].Status != from {
+		return false, nil
+	}
+	r.batches[batch.ID] = *batch
+	return true, nil
+}
+
+func (r *stubBulkPaymentRepository) GetRunnableBulkPayments(ctx context.Context) ([]*models.BulkPayment, error) {
+	batches := []*models.BulkPayment{}
+	for _, batch := range r.batches {
+		if batch.Status == models.BulkPaymentQueued || batch.Status == models.BulkPaymentProcessing {
+			batch := batch
+			batches = append(batches, &batch)
+		}
+	}
+	return batches, nil
+}
+
+func (r *stubBulkPaymentRepository) UpdateBulkPaymentRow(ctx context.Context, row *models.BulkPaymentRow) error {
+	for i, stored := range r.rows[row.BulkPaymentID] {
+		if stored.ID == row.ID {
+			copied := *row
+			r.rows[row.BulkPaymentID][i] = &copied
+		}
+	}
+	return nil
+}
+
+func (r *stubBulkPaymentRepository) CompleteBulkPayment(ctx context.Context, batch *models.BulkPayment) error {
+	r.batches[batch.ID] = *batch
+	return nil
+}
+
+// stubTransferrer posts transfers until the balance runs out and refuses
+// payments over the limit
+type stubTransferrer struct {
+	accounts   *repotest.Accounts
+	balance    float64
+	limit      float64
+	references map[string]bool
+	inputs     []transfers.Input
+}
+
+func (t *stubTransferrer) FindDestination(ctx context.Context, accountNumber string) (*models.Account, error) {
+	for _, account := range t.accounts.Accounts {
+		if account.AccountNumber == accountNumber {
+			return account, nil
+		}
+	}
+	return nil, transfers.ErrDestinationNotFound
+}
+
+func (t *stubTransferrer) Transfer(ctx context.Context, input transfers.Input) (*models.TransferResult, error) {
+	if t.references[input.Reference] {
+		return nil, transfers.ErrAlreadyProcessed
+	}
+	if input.Amount > t.limit {
+		return nil, &limits.LimitError{Code: limits.CodePerTransaction}
+	}
+	if input.Amount > t.balance {
+		return nil, ledger.ErrInsufficientFunds
+	}
+	t.balance -= input.Amount
+	t.references[input.Reference] = true
+	t.inputs = append(t.inputs, input)
+	return &models.TransferResult{TransactionID: uuid.New(), Amount: input.Amount}, nil
+}
+
+type fixture struct {
+	service       *Service
+	repo          *stubBulkPaymentRepository
+	transferrer   *stubTransferrer
+	notifications *repotest.Notifications
+	payer         *models.Account
+	customerID    uuid.UUID
+}
+
+func newFixture() *fixture {
+	customerID := uuid.New()
+	payer := repotest.CustomerAccount(customerID, "100-1-00001-1", 10000)
+	payer.AccountType = models.AccountTypeCurrent
+	dollars := repotest.Account("100-1-00004-1", 0)
+	dollars.Currency = models.CurrencyUSD
+	accounts := &repotest.Accounts{Accounts: []*models.Account{
+		payer, repotest.Account("100-1-00002-1", 0), repotest.Account("100-1-00003-1", 0), dollars,
+	}}
+	f := &fixture{
+		repo:          &stubBulkPaymentRepository{batches: map[uuid.UUID]models.BulkPayment{}, rows: map[uuid.UUID][]*models.BulkPaymentRow{}},
+		transferrer:   &stubTransferrer{accounts: accounts, balance: 10000, limit: 5000, references: map[string]bool{}},
+		notifications: &repotest.Notifications{},
+		payer:         payer,
+		customerID:    customerID,
+	}
+	f.service = NewService(f.repo, accounts, f.notifications, f.transferrer, config.BulkPaymentConfig{MaxRows: 10})
+	return f
+}
+
+func (f *fixture) upload(t *testing.T, file string) *models.BulkPaymentDetails {
+	t.Helper()
+	preview, err := f.service.Upload(context.Background(), f.payer, f.customerID, "payroll.csv", strings.NewReader(file))
+	require.NoError(t, err)
+	return preview
+}
+
+func TestUploadValidatesEveryRow(t *testing.T) {
+	f := newFixture()
+
+	preview := f.upload(t, "account_number,amount,reference,description\n"+
+		"100-1-00002-1,1500.50,SAL-001,October salary\n"+
+		"100-1-00003-1,abc,SAL-002,\n"+
+		"100-1-99999-1,100,SAL-003,\n"+
+		"100-1-00003-1,200,SAL-001,\n"+
+		"100-1-00001-1,200,SAL-005,\n"+
+		"100-1-00004-1,200,SAL-006,\n"+
+		"100-1-00003-1,0.005,SAL-007,\n"+
+		"100-1-00003-1,2000,SAL-008\n")
+
+	assert.Equal(t, models.BulkPaymentAwaitingConfirmation, preview.Status)
+	assert.Equal(t, 8, preview.RowCount)
+	assert.Equal(t, 2, preview.ValidCount)
+	assert.Equal(t, 6, preview.InvalidCount)
+	assert.Equal(t, 3500.50, preview.TotalAmount)
+	assert.Equal(t, 10000.0, preview.AvailableBalance)
+
+	errorsByLine := map[int]string{}
+	for _, row := range preview.Rows {
+		errorsByLine[row.LineNumber] = row.Error
+	}
+	assert.Empty(t, errorsByLine[2])
+	assert.Equal(t, "amount is not a number", errorsByLine[3])
+	assert.Equal(t, transfers.ErrDestinationNotFound.Error(), errorsByLine[4])
+	assert.Equal(t, "reference is already used on line 2", errorsByLine[5])
+	assert.Equal(t, transfers.ErrSameAccount.Error(), errorsByLine[6])
+	assert.Equal(t, transfers.ErrCurrencyMismatch.Error(), errorsByLine[7])
+	assert.Equal(t, transfers.ErrInvalidAmount.Error(), errorsByLine[8])
+	assert.Empty(t, errorsByLine[9])
+	assert.Empty(t, f.transferrer.inputs, "nothing is paid before the file is confirmed")
+}
+
+func TestUploadRejectsMalformedFiles(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+
+	_, err := f.service.Upload(ctx, f.payer, f.customerID, "a.csv", strings.NewReader("name,amount\nx,1\n"))
+	assert.ErrorIs(t, err, ErrInvalidHeader)
+
+	_, err = f.service.Upload(ctx, f.payer, f.customerID, "a.csv", strings.NewReader("account_number,amount,reference\n"))
+	assert.ErrorIs(t, err, ErrEmptyFile)
+
+	_, err = f.service.Upload(ctx, f.payer, f.customerID, "a.csv", strings.NewReader("account_number,amount,reference\n\"100-1,1,x\n"))
+	assert.ErrorIs(t, err, ErrInvalidFile)
+
+	file := "account_number,amount,reference\n" + strings.Repeat("100-1-00002-1,1,x\n", 11)
+	_, err = f.service.Upload(ctx, f.payer, f.customerID, "a.csv", strings.NewReader(file))
+	assert.ErrorIs(t, err, ErrTooManyRows)
+}
+
+func TestConfirmedFileIsPaidWithLimitsAndBalance(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+
+	preview := f.upload(t, "account_number,amount,reference\n"+
+		"100-1-00002-1,4000,SAL-001\n"+
+		"100-1-00003-1,6000,SAL-002\n"+
+		"100-1-00003-1,5000,SAL-003\n"+
+		"100-1-00002-1,2000,SAL-004\n"+
+		"100-1-99999-1,100,SAL-005\n")
+
+	_, _, err := f.service.ResultFile(ctx, f.payer.ID, preview.ID)
+	assert.ErrorIs(t, err, ErrNotCompleted)
+
+	completed, err := f.service.RunDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, completed, "unconfirmed files are not run")
+
+	batch, err := f.service.Confirm(ctx, f.payer.ID, preview.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.BulkPaymentQueued, batch.Status)
+
+	completed, err = f.service.RunDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, completed)
+
+	details, err := f.service.Get(ctx, f.payer.ID, preview.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.BulkPaymentCompleted, details.Status)
+	assert.Equal(t, 2, details.PaidCount)
+	assert.Equal(t, 2, details.FailedCount)
+	assert.Equal(t, 9000.0, details.PaidAmount)
+
+	statuses := map[string]models.BulkPaymentRowStatus{}
+	for _, row := range details.Rows {
+		statuses[row.Reference] = row.Status
+	}
+	assert.Equal(t, models.BulkRowPaid, statuses["SAL-001"])
+	assert.Equal(t, models.BulkRowFailed, statuses["SAL-002"], "over the per-transaction limit")
+	assert.Equal(t, models.BulkRowPaid, statuses["SAL-003"])
+	assert.Equal(t, models.BulkRowFailed, statuses["SAL-004"], "insufficient funds")
+	assert.Equal(t, models.BulkRowInvalid, statuses["SAL-005"])
+
+	for _, input := range f.transferrer.inputs {
+		assert.Equal(t, f.customerID, input.CustomerID)
+	}
+	require.Len(t, f.notifications.Notifications, 1)
+	assert.Equal(t, models.NotificationBulkPaymentCompleted, f.notifications.Notifications[0].Type)
+
+	name, content, err := f.service.ResultFile(ctx, f.payer.ID, preview.ID)
+	require.NoError(t, err)
+	assert.Contains(t, name, preview.ID.String())
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 6)
+	assert.Equal(t, "line,account_number,amount,reference,description,status,error,transaction_id", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "2,100-1-00002-1,4000.00,SAL-001,,paid,,"))
+	assert.True(t, strings.HasPrefix(lines[4], "5,100-1-00002-1,2000.00,SAL-004,,failed,"+ledger.ErrInsufficientFunds.Error()))
+
+	_, err = f.service.Confirm(ctx, f.payer.ID, preview.ID)
+	assert.ErrorIs(t, err, ErrNotAwaitingConfirmation)
+}
+
+func TestInterruptedFileDoesNotPayRowsTwice(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+
+	preview := f.upload(t, "account_number,amount,reference\n100-1-00002-1,1000,SAL-001\n100-1-00003-1,1000,SAL-002\n")
+	_, err := f.service.Confirm(ctx, f.payer.ID, preview.ID)
+	require.NoError(t, err)
+
+	// The first row was posted, but the server stopped before it was recorded
+	f.transferrer.references[fmt.Sprintf("bulk:%s:2", preview.ID)] = true
+	batch := f.repo.batches[preview.ID]
+	batch.Status = models.BulkPaymentProcessing
+	f.repo.batches[preview.ID] = batch
+
+	completed, err := f.service.RunDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, completed)
+	assert.Len(t, f.transferrer.inputs, 1)
+	assert.Equal(t, 2, f.repo.batches[preview.ID].PaidCount)
+}
+
+func TestCancelAndConfirmWithoutValidRows(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+
+	preview := f.upload(t, "account_number,amount,reference\n100-1-99999-1,100,X\n")
+	_, err := f.service.Confirm(ctx, f.payer.ID, preview.ID)
+	assert.ErrorIs(t, err, ErrNoValidRows)
+
+	batch, err := f.service.Cancel(ctx, f.payer.ID, preview.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.BulkPaymentCancelled, batch.Status)
+
+	_, err = f.service.Get(ctx, uuid.New(), preview.ID)
+	assert.ErrorIs(t, err, ErrBulkPaymentNotFound)
+}
@@ -0,0 +1,446 @@
+package cardauth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/holds"
+	"example.com/m/internal/jobs"
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/limits"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/google/uuid"
+)
+
+// ISO 8583 response codes given to authorization requests
+const (
+	CodeApproved          = "00"
+	CodeInvalidCard       = "14"
+	CodeLostCard          = "41"
+	CodeInsufficientFunds = "51"
+	CodeExpiredCard       = "54"
+	CodeIncorrectPIN      = "55"
+	CodeNotPermitted      = "57"
+	CodeExceedsLimit      = "61"
+	CodeRestrictedCard    = "62"
+	CodeCVVFailed         = "82"
+)
+
+var (
+	// ErrInvalidMessage is returned for a message the network should not have sent
+	ErrInvalidMessage = errors.New("invalid card network message")
+	// ErrAuthorizationNotFound is returned when a clearing message matches no authorization
+	ErrAuthorizationNotFound = errors.New("no authorization has this retrieval reference number")
+	// ErrNotApproved is returned when a clearing message is for a declined authorization
+	ErrNotApproved = errors.New("authorization was declined and cannot be cleared")
+	// ErrAlreadyCleared is returned when an authorization receives a second clearing message
+	ErrAlreadyCleared = errors.New("authorization is already cleared")
+	// ErrHoldNotActive is returned when the hold of an authorization ended before it cleared
+	ErrHoldNotActive = errors.New("the hold of the authorization was released or expired before clearing")
+)
+
+// Cards finds cards by their full number and checks their secrets and the
+// controls set on them by their customer
+type Cards interface {
+	FindByPAN(ctx context.Context, pan string) (*models.Card, error)
+	CheckCVV(card *models.Card, cvv string) bool
+	ControlsOf(ctx context.Context, card *models.Card) (*models.CardControls, error)
+}
+
+// Holds reserves and posts the funds of approved authorizations
+type Holds interface {
+	Place(ctx context.Context, hold *models.Hold) error
+	Release(ctx context.Context, id uuid.UUID) (*models.Hold, error)
+	Capture(ctx context.Context, id uuid.UUID, amount float64, creditAccountID uuid.UUID, txnType models.LedgerTransactionType, description string) (*models.LedgerTransaction, error)
+}
+
+// Limiter counts ATM withdrawals against the customer's transaction limits
+type Limiter interface {
+	Reserve(ctx context.Context, payment limits.Payment) (*limits.Reservation, error)
+	Release(ctx context.Context, reservation *limits.Reservation) error
+}
+
+// Service answers authorization requests from the card network. An
+// approved authorization holds its amount on the card's account; its
+// clearing message, received later, captures the hold into the card
+// settlement account.
+type Service struct {
+	cards       Cards
+	repo        repository.CardAuthorizationRepository
+	accountRepo repository.AccountRepository
+	holds       Holds
+	limits      Limiter
+	cfg         config.CardConfig
+	now         func() time.Time
+}
+
+// NewService creates a new card authorization Service
+func NewService(cards Cards, repo repository.CardAuthorizationRepository, accountRepo repository.AccountRepository, holdService Holds, limiter Limiter, cfg config.CardConfig) *Service {
+	return &Service{
+		cards:       cards,
+		repo:        repo,
+		accountRepo: accountRepo,
+		holds:       holdService,
+		limits:      limiter,
+		cfg:         cfg,
+		now:         time.Now,
+	}
+}
+
+// decline is the reason an authorization request is refused
+type decline struct {
+	code   string
+	reason string
+}
+
+// usage is the daily usage reserved for an authorization; it is released
+// if the authorization is not approved
+type usage struct {
+	cardID uuid.UUID
+	atm    bool
+	date   time.Time
+	amount float64
+	limits *limits.Reservation
+}
+
+// Authorize answers an authorization request. Approved and declined
+// requests are both recorded; a request sent again with the same retrieval
+// reference number gets the first answer.
+func (s *Service) Authorize(ctx context.Context, req models.CardAuthorizationRequest) (*models.CardAuthorization, error) {
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.GetCardAuthorizationByRRN(ctx, req.RRN)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	now := s.now()
+	authorization := &models.CardAuthorization{
+		ID:               uuid.New(),
+		RRN:              req.RRN,
+		Amount:           req.Amount,
+		MerchantCategory: req.MerchantCategory,
+		MerchantName:     strings.TrimSpace(req.MerchantName),
+		Country:          req.Country,
+		Channel:          req.Channel,
+		Status:           models.CardAuthorizationDeclined,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+
+	card, err := s.cards.FindByPAN(ctx, req.PAN)
+	if err != nil {
+		return nil, err
+	}
+	if card != nil {
+		authorization.CardID = &card.ID
+		authorization.AccountID = &card.AccountID
+	}
+
+	controls, refused, err := s.check(ctx, card, req, now)
+	if err != nil {
+		return nil, err
+	}
+	var reserved *usage
+	if refused == nil {
+		reserved, refused, err = s.reserve(ctx, card, controls, req, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if refused == nil {
+		refused, err = s.placeHold(ctx, authorization, now)
+		if err != nil {
+			s.release(ctx, reserved, authorization.RRN)
+			return nil, err
+		}
+	}
+
+	if refused != nil {
+		s.release(ctx, reserved, authorization.RRN)
+		reserved = nil
+		authorization.ResponseCode = refused.code
+		authorization.DeclineReason = refused.reason
+	} else {
+		code, err := approvalCode()
+		if err != nil {
+			s.releaseHold(ctx, authorization)
+			s.release(ctx, reserved, authorization.RRN)
+			return nil, err
+		}
+		authorization.Status = models.CardAuthorizationApproved
+		authorization.ResponseCode = CodeApproved
+		authorization.ApprovalCode = code
+	}
+
+	if err := s.repo.CreateCardAuthorization(ctx, authorization); err != nil {
+		s.releaseHold(ctx, authorization)
+		s.release(ctx, reserved, authorization.RRN)
+		return nil, err
+	}
+	return authorization, nil
+}
+
+// check refuses a request the card cannot make or its controls forbid. It
+// returns the card's controls and no decline when only the daily limits
+// and the account balance are left to check.
+func (s *Service) check(ctx context.Context, card *models.Card, req models.CardAuthorizationRequest, now time.Time) (*models.CardControls, *decline, error) {
+	switch {
+	case card == nil:
+		return nil, &decline{CodeInvalidCard, "unknown card number"}, nil
+	case card.Status == models.CardLostStolen:
+		return nil, &decline{CodeLostCard, "card was reported lost or stolen"}, nil
+	case card.Status == models.CardBlocked:
+		return nil, &decline{CodeRestrictedCard, "card is blocked"}, nil
+	case card.Status != models.CardActive:
+		return nil, &decline{CodeNotPermitted, "card is not activated"}, nil
+	case card.Expired(now):
+		return nil, &decline{CodeExpiredCard, "card has expired"}, nil
+	case req.Expiry != "" && req.Expiry != fmt.Sprintf("%02d%02d", card.ExpiryMonth, card.ExpiryYear%100):
+		return nil, &decline{CodeExpiredCard, "expiry date does not match the card"}, nil
+	case req.PINResult == models.PINFailed:
+		return nil, &decline{CodeIncorrectPIN, "incorrect PIN"}, nil
+	case req.Channel == models.CardChannelATM && req.PINResult != models.PINVerified:
+		return nil, &decline{CodeIncorrectPIN, "ATM withdrawals need the PIN"}, nil
+	case req.CVV != "" && !s.cards.CheckCVV(card, req.CVV):
+		return nil, &decline{CodeCVVFailed, "incorrect CVV"}, nil
+	case req.Channel == models.CardChannelEcommerce && req.CVV == "":
+		return nil, &decline{CodeCVVFailed, "online purchases need the CVV"}, nil
+	}
+
+	controls, err := s.cards.ControlsOf(ctx, card)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case req.Channel == models.CardChannelEcommerce && !controls.EcommerceEnabled:
+		return nil, &decline{CodeNotPermitted, "online purchases are switched off on this card"}, nil
+	case req.Contactless && !controls.ContactlessEnabled:
+		return nil, &decline{CodeNotPermitted, "contactless payments are switched off on this card"}, nil
+	case req.Country != s.cfg.HomeCountry && !controls.InternationalEnabled:
+		return nil, &decline{CodeNotPermitted, "use abroad is switched off on this card"}, nil
+	case controls.BlocksMerchantCategory(req.MerchantCategory):
+		return nil, &decline{CodeNotPermitted, "merchant category " + req.MerchantCategory + " is blocked on this card"}, nil
+	}
+	return controls, nil, nil
+}
+
+// reserve counts the request against the card's daily limit for ATM
+// withdrawals or for purchases, and an ATM withdrawal also against the
+// customer's ATM withdrawal limit. The card's own limit applies unless
+// the bank's limit was lowered below it. Each count is a single upsert, so
+// concurrent requests cannot pass a limit together.
+func (s *Service) reserve(ctx context.Context, card *models.Card, controls *models.CardControls, req models.CardAuthorizationRequest, now time.Time) (*usage, *decline, error) {
+	atm := req.Channel == models.CardChannelATM
+	limit, kind := math.Min(controls.POSDailyLimit, s.cfg.POSDailyLimit), "purchase"
+	if atm {
+		limit, kind = math.Min(controls.ATMDailyLimit, s.cfg.ATMDailyLimit), "ATM"
+	}
+
+	date := jobs.BusinessDate(now)
+	reserved, used, err := s.repo.ReserveCardUsage(ctx, card.ID, atm, date, req.Amount, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !reserved {
+		return nil, &decline{CodeExceedsLimit, fmt.Sprintf("daily %s limit of %.2f exceeded; %.2f left today", kind, limit, limit-used)}, nil
+	}
+	reservation := &usage{cardID: card.ID, atm: atm, date: date, amount: req.Amount}
+	if !atm {
+		return reservation, nil, nil
+	}
+
+	reservation.limits, err = s.reserveATMLimit(ctx, card, req.Amount)
+	if err != nil {
+		s.release(ctx, reservation, req.RRN)
+		if errors.Is(err, limits.ErrLimitExceeded) {
+			return nil, &decline{CodeExceedsLimit, err.Error()}, nil
+		}
+		return nil, nil, err
+	}
+	return reservation, nil, nil
+}
+
+// reserveATMLimit counts an ATM withdrawal, in the currency of the card's
+// account, against the withdrawal limit of the customer's ATM channel
+func (s *Service) reserveATMLimit(ctx context.Context, card *models.Card, amount float64) (*limits.Reservation, error) {
+	account, err := s.accountRepo.GetAccountByID(ctx, card.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, fmt.Errorf("account %s of card %s is missing", card.AccountID, card.ID)
+	}
+	return s.limits.Reserve(ctx, limits.Payment{
+		CustomerID: card.CustomerID,
+		Channel:    models.ChannelATM,
+		Type:       models.LimitWithdrawal,
+		Amount:     models.Money{Amount: amount, Currency: account.Currency},
+	})
+}
+
+// release gives back the usage reserved for an authorization that was not approved
+func (s *Service) release(ctx context.Context, reserved *usage, rrn string) {
+	if reserved == nil {
+		return
+	}
+	if err := s.repo.ReleaseCardUsage(ctx, reserved.cardID, reserved.atm, reserved.date, reserved.amount); err != nil {
+		log.Printf("failed to release card limit usage of authorization %s: %v", rrn, err)
+	}
+	if err := s.limits.Release(ctx, reserved.limits); err != nil {
+		log.Printf("failed to release ATM limit usage of authorization %s: %v", rrn, err)
+	}
+}
+
+// placeHold holds the amount on the card's account, which must be able to
+// pay it
+func (s *Service) placeHold(ctx context.Context, authorization *models.CardAuthorization, now time.Time) (*decline, error) {
+	hold := &models.Hold{
+		AccountID: *authorization.AccountID,
+		Amount:    authorization.Amount,
+		Reason:    "Card authorization " + authorization.RRN + " at " + merchant(authorization),
+		Source:    models.HoldSourceCardAuthorization,
+		Reference: authorization.ID.String(),
+		ExpiresAt: now.AddDate(0, 0, s.cfg.AuthorizationHoldDays),
+	}
+
+	err := s.holds.Place(ctx, hold)
+	switch {
+	case err == nil:
+		authorization.HoldID = &hold.ID
+		return nil, nil
+	case errors.Is(err, ledger.ErrInsufficientFunds):
+		return &decline{CodeInsufficientFunds, "insufficient funds"}, nil
+	case errors.Is(err, ledger.ErrAccountDormant),
+		errors.Is(err, ledger.ErrAccountNotActive),
+		errors.Is(err, ledger.ErrAccountRestricted),
+		errors.Is(err, holds.ErrNotCustomerAccount):
+		return &decline{CodeRestrictedCard, "the card's account cannot make payments"}, nil
+	}
+	return nil, err
+}
+
+// releaseHold gives back the hold of an authorization that could not be recorded
+func (s *Service) releaseHold(ctx context.Context, authorization *models.CardAuthorization) {
+	if authorization.HoldID == nil {
+		return
+	}
+	if _, err := s.holds.Release(ctx, *authorization.HoldID); err != nil {
+		log.Printf("failed to release hold %s of unrecorded card authorization %s: %v", *authorization.HoldID, authorization.RRN, err)
+	}
+}
+
+// Clear captures the hold of an approved authorization for the cleared
+// amount, which is posted to the card settlement account
+func (s *Service) Clear(ctx context.Context, req models.CardClearingRequest) (*models.CardAuthorization, error) {
+	if req.RRN == "" {
+		return nil, fmt.Errorf("%w: rrn is required", ErrInvalidMessage)
+	}
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be greater than zero", ErrInvalidMessage)
+	}
+
+	authorization, err := s.repo.GetCardAuthorizationByRRN(ctx, req.RRN)
+	if err != nil {
+		return nil, err
+	}
+	if authorization == nil {
+		return nil, ErrAuthorizationNotFound
+	}
+	switch authorization.Status {
+	case models.CardAuthorizationCleared:
+		return nil, ErrAlreadyCleared
+	case models.CardAuthorizationDeclined:
+		return nil, ErrNotApproved
+	}
+
+	settlement, err := repository.GetInternalAccount(ctx, s.accountRepo, models.GLCardSettlement)
+	if err != nil {
+		return nil, err
+	}
+
+	description := "Card purchase at " + merchant(authorization)
+	if authorization.Channel == models.CardChannelATM {
+		description = "ATM withdrawal at " + merchant(authorization)
+	}
+	txn, err := s.holds.Capture(ctx, *authorization.HoldID, req.Amount, settlement.ID, models.LedgerCardTransaction, description)
+	if errors.Is(err, repository.ErrHoldNotActive) {
+		return nil, ErrHoldNotActive
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	authorization.Status = models.CardAuthorizationCleared
+	authorization.ClearedAmount = &req.Amount
+	authorization.TransactionID = &txn.ID
+	authorization.ClearedAt = &now
+	authorization.UpdatedAt = now
+	saved, err := s.repo.ClearCardAuthorization(ctx, authorization)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, ErrAlreadyCleared
+	}
+	return authorization, nil
+}
+
+// merchant names the merchant of an authorization, or its category when
+// the network sent no name
+func merchant(authorization *models.CardAuthorization) string {
+	if authorization.MerchantName != "" {
+		return authorization.MerchantName
+	}
+	return "merchant category " + authorization.MerchantCategory
+}
+
+// validateRequest checks the fields of an authorization request
+func validateRequest(req models.CardAuthorizationRequest) error {
+	switch {
+	case req.RRN == "" || len(req.RRN) > 12:
+		return fmt.Errorf("%w: rrn must be 1 to 12 characters", ErrInvalidMessage)
+	case len(req.PAN) < 12 || len(req.PAN) > 19 || !models.IsDigits(req.PAN):
+		return fmt.Errorf("%w: pan must be 12 to 19 digits", ErrInvalidMessage)
+	case req.Amount <= 0:
+		return fmt.Errorf("%w: amount must be greater than zero", ErrInvalidMessage)
+	case len(req.MerchantCategory) != 4 || !models.IsDigits(req.MerchantCategory):
+		return fmt.Errorf("%w: merchant_category must be a four-digit code", ErrInvalidMessage)
+	case len(req.Country) != 2 || strings.Trim(req.Country, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "":
+		return fmt.Errorf("%w: country must be a two-letter ISO code", ErrInvalidMessage)
+	case !req.Channel.Valid():
+		return fmt.Errorf("%w: channel must be pos, atm or ecommerce", ErrInvalidMessage)
+	case req.Contactless && req.Channel != models.CardChannelPOS:
+		return fmt.Errorf("%w: only pos transactions can be contactless", ErrInvalidMessage)
+	case req.PINResult != models.PINNotEntered && req.PINResult != models.PINVerified && req.PINResult != models.PINFailed:
+		return fmt.Errorf("%w: pin_result must be verified or failed", ErrInvalidMessage)
+	case req.Expiry != "" && (len(req.Expiry) != 4 || !models.IsDigits(req.Expiry)):
+		return fmt.Errorf("%w: expiry must be MMYY", ErrInvalidMessage)
+	case req.CVV != "" && (len(req.CVV) < 3 || len(req.CVV) > 4 || !models.IsDigits(req.CVV)):
+		return fmt.Errorf("%w: cvv must be 3 or 4 digits", ErrInvalidMessage)
+	}
+	return nil
+}
+
+// approvalCode returns a random six-digit approval code
+func approvalCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
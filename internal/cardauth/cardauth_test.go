@@ -0,0 +1,341 @@
+package cardauth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/limits"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/repository/repotest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubCards finds cards by number in memory; every card has CVV 123 and,
+// unless controls are set for it, the default controls
+type stubCards struct {
+	cards    map[string]*models.Card
+	controls map[uuid.UUID]*models.CardControls
+}
+
+func (c *stubCards) FindByPAN(ctx context.Context, pan string) (*models.Card, error) {
+	return c.cards[pan], nil
+}
+
+func (c *stubCards) CheckCVV(card *models.Card, cvv string) bool {
+	return cvv == "123"
+}
+
+func (c *stubCards) ControlsOf(ctx context.Context, card *models.Card) (*models.CardControls, error) {
+	if controls, ok := c.controls[card.ID]; ok {
+		return controls, nil
+	}
+	return &models.CardControls{
+		CardID: card.ID, ATMDailyLimit: 20000, POSDailyLimit: 100000,
+		EcommerceEnabled: true, ContactlessEnabled: true, InternationalEnabled: true,
+	}, nil
+}
+
+// stubAuthorizationRepository keeps authorizations and card usage in memory
+type stubAuthorizationRepository struct {
+	authorizations []*models.CardAuthorization
+	usage          map[string]float64
+}
+
+func (r *stubAuthorizationRepository) CreateCardAuthorization(ctx context.Context, authorization *models.CardAuthorization) error {
+	stored := *authorization
+	r.authorizations = append(r.authorizations, &stored)
+	return nil
+}
+
+func (r *stubAuthorizationRepository) GetCardAuthorizationByRRN(ctx context.Context, rrn string) (*models.CardAuthorization, error) {
+	for _, authorization := range r.authorizations {
+		if authorization.RRN == rrn {
+			stored := *authorization
+			return &stored, nil
+		}
+	}
+	return nil, nil
+}
+
+func usageKey(cardID uuid.UUID, atm bool, date time.Time) string {
+	return fmt.Sprintf("%s/%t/%s", cardID, atm, date.Format("2006-01-02"))
+}
+
+func (r *stubAuthorizationRepository) ReserveCardUsage(ctx context.Context, cardID uuid.UUID, atm bool, date time.Time, amount, limit float64) (bool, float64, error) {
+	key := usageKey(cardID, atm, date)
+	if r.usage[key]+amount > limit {
+		return false, r.usage[key], nil
+	}
+	r.usage[key] += amount
+	return true, r.usage[key], nil
+}
+
+func (r *stubAuthorizationRepository) ReleaseCardUsage(ctx context.Context, cardID uuid.UUID, atm bool, date time.Time, amount float64) error {
+	r.usage[usageKey(cardID, atm, date)] -= amount
+	return nil
+}
+
+func (r *stubAuthorizationRepository) ClearCardAuthorization(ctx context.Context, authorization *models.CardAuthorization) (bool, error) {
+	for i, stored := range r.authorizations {
+		if stored.ID == authorization.ID && stored.Status == models.CardAuthorizationApproved {
+			cleared := *authorization
+			r.authorizations[i] = &cleared
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// stubHolds holds and posts funds on in-memory accounts
+type stubHolds struct {
+	accounts *repotest.Accounts
+	holds    map[uuid.UUID]*models.Hold
+}
+
+func (h *stubHolds) account(id uuid.UUID) *models.Account {
+	account, _ := h.accounts.GetAccountByID(context.Background(), id)
+	return account
+}
+
+func (h *stubHolds) Place(ctx context.Context, hold *models.Hold) error {
+	account := h.account(hold.AccountID)
+	if account.Available() < hold.Amount {
+		return ledger.ErrInsufficientFunds
+	}
+	hold.ID = uuid.New()
+	hold.Status = models.HoldActive
+	account.HeldAmount += hold.Amount
+	h.holds[hold.ID] = hold
+	return nil
+}
+
+func (h *stubHolds) Release(ctx context.Context, id uuid.UUID) (*models.Hold, error) {
+	hold := h.holds[id]
+	hold.Status = models.HoldReleased
+	h.account(hold.AccountID).HeldAmount -= hold.Amount
+	return hold, nil
+}
+
+func (h *stubHolds) Capture(ctx context.Context, id uuid.UUID, amount float64, creditAccountID uuid.UUID, txnType models.LedgerTransactionType, description string) (*models.LedgerTransaction, error) {
+	hold := h.holds[id]
+	if hold.Status != models.HoldActive {
+		return nil, repository.ErrHoldNotActive
+	}
+	hold.Status = models.HoldCaptured
+	account := h.account(hold.AccountID)
+	account.HeldAmount -= hold.Amount
+	account.Balance -= amount
+	h.account(creditAccountID).Balance += amount
+	return &models.LedgerTransaction{ID: uuid.New(), Type: txnType, Description: description}, nil
+}
+
+// stubLimiter counts ATM withdrawals against a single daily limit
+type stubLimiter struct {
+	daily float64
+	used  float64
+}
+
+func (l *stubLimiter) Reserve(ctx context.Context, payment limits.Payment) (*limits.Reservation, error) {
+	if l.used+payment.Amount.Amount > l.daily {
+		return nil, &limits.LimitError{Code: limits.CodeDaily, Limit: models.TransactionLimit{
+			Channel: payment.Channel, Type: payment.Type, Daily: l.daily, RemainingToday: l.daily - l.used,
+		}}
+	}
+	l.used += payment.Amount.Amount
+	return &limits.Reservation{}, nil
+}
+
+func (l *stubLimiter) Release(ctx context.Context, reservation *limits.Reservation) error {
+	return nil
+}
+
+type fixture struct {
+	service    *Service
+	cards      *stubCards
+	repo       *stubAuthorizationRepository
+	holds      *stubHolds
+	limiter    *stubLimiter
+	account    *models.Account
+	settlement *models.Account
+}
+
+func newFixture() *fixture {
+	account := repotest.Account("1234567890", 50000)
+	settlement := repotest.InternalAccount(models.GLCardSettlement)
+	accounts := &repotest.Accounts{Accounts: []*models.Account{account, settlement}}
+	cards := &stubCards{cards: map[string]*models.Card{
+		"4289990000000011": {ID: uuid.New(), AccountID: account.ID, Status: models.CardActive, ExpiryMonth: 3, ExpiryYear: 2031},
+		"4289990000000029": {ID: uuid.New(), AccountID: account.ID, Status: models.CardBlocked, ExpiryMonth: 3, ExpiryYear: 2031},
+	}, controls: map[uuid.UUID]*models.CardControls{}}
+	repo := &stubAuthorizationRepository{usage: map[string]float64{}}
+	holdService := &stubHolds{accounts: accounts, holds: map[uuid.UUID]*models.Hold{}}
+	limiter := &stubLimiter{daily: 40000}
+
+	service := NewService(cards, repo, accounts, holdService, limiter, config.Default().Cards)
+	service.now = func() time.Time { return time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC) }
+	return &fixture{service: service, cards: cards, repo: repo, holds: holdService, limiter: limiter, account: account, settlement: settlement}
+}
+
+func TestReplayAuthorizationsAndClearing(t *testing.T) {
+	f := newFixture()
+	file, err := os.Open("testdata/replay.jsonl")
+	require.NoError(t, err)
+	defer file.Close()
+
+	results, err := f.service.Replay(context.Background(), file)
+	require.NoError(t, err)
+	require.Len(t, results, 14)
+
+	expected := []struct {
+		rrn  string
+		code string
+	}{
+		{"000000000001", CodeApproved},
+		{"000000000002", CodeCVVFailed},
+		{"000000000003", CodeIncorrectPIN},
+		{"000000000004", CodeApproved},
+		{"000000000005", CodeExceedsLimit},
+		{"000000000006", CodeInsufficientFunds},
+		{"000000000007", CodeApproved},
+		{"000000000008", CodeRestrictedCard},
+		{"000000000009", CodeInvalidCard},
+	}
+	for i, want := range expected {
+		assert.Equal(t, want.rrn, results[i].RRN)
+		assert.Equal(t, want.code, results[i].ResponseCode, "rrn %s: %s", want.rrn, results[i].DeclineReason)
+		assert.Empty(t, results[i].Error)
+	}
+
+	assert.Equal(t, models.CardAuthorizationCleared, results[9].Status, "cleared for more than authorized after a tip")
+	assert.Equal(t, models.CardAuthorizationCleared, results[10].Status)
+	assert.Equal(t, ErrNotApproved.Error(), results[11].Error)
+	assert.Equal(t, ErrAlreadyCleared.Error(), results[12].Error)
+	assert.Equal(t, ErrAuthorizationNotFound.Error(), results[13].Error)
+
+	// The ATM withdrawal is still held; the two cleared purchases are posted
+	assert.InDelta(t, 50000-1650-650, f.account.Balance, 0.001)
+	assert.InDelta(t, 15000, f.account.HeldAmount, 0.001)
+	assert.InDelta(t, 2300, f.settlement.Balance, 0.001)
+}
+
+func TestAuthorizationRetransmissionGetsFirstAnswer(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+	req := models.CardAuthorizationRequest{
+		RRN: "000000000001", PAN: "4289990000000011", Amount: 1000,
+		MerchantCategory: "5411", Country: "TH", Channel: models.CardChannelPOS,
+	}
+
+	first, err := f.service.Authorize(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, models.CardAuthorizationApproved, first.Status)
+	assert.Len(t, first.ApprovalCode, 6)
+
+	second, err := f.service.Authorize(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, second.ID)
+	assert.Len(t, f.holds.holds, 1, "the amount is held once")
+	assert.InDelta(t, 1000, f.account.HeldAmount, 0.001)
+}
+
+func TestAuthorizationDeclinesExpiredCard(t *testing.T) {
+	f := newFixture()
+	f.service.now = func() time.Time { return time.Date(2031, 4, 1, 0, 0, 0, 0, time.UTC) }
+
+	authorization, err := f.service.Authorize(context.Background(), models.CardAuthorizationRequest{
+		RRN: "000000000001", PAN: "4289990000000011", Amount: 100,
+		MerchantCategory: "5411", Country: "TH", Channel: models.CardChannelPOS,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, CodeExpiredCard, authorization.ResponseCode)
+	assert.Nil(t, authorization.HoldID)
+}
+
+func TestAuthorizationRejectsInvalidMessage(t *testing.T) {
+	f := newFixture()
+	_, err := f.service.Authorize(context.Background(), models.CardAuthorizationRequest{
+		RRN: "000000000001", PAN: "4289990000000011", Amount: 100,
+		MerchantCategory: "54", Country: "TH", Channel: models.CardChannelPOS,
+	})
+	assert.ErrorIs(t, err, ErrInvalidMessage)
+}
+
+func TestCardControlsAreEnforced(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+	card := f.cards.cards["4289990000000011"]
+	f.cards.controls[card.ID] = &models.CardControls{
+		CardID: card.ID, ATMDailyLimit: 2000, POSDailyLimit: 100000,
+		EcommerceEnabled: false, ContactlessEnabled: false, InternationalEnabled: false,
+		BlockedMerchantCategories: []string{"7995"},
+	}
+
+	requests := []struct {
+		req    models.CardAuthorizationRequest
+		code   string
+		reason string
+	}{
+		{models.CardAuthorizationRequest{Channel: models.CardChannelEcommerce, MerchantCategory: "5732", Country: "TH", CVV: "123"}, CodeNotPermitted, "online"},
+		{models.CardAuthorizationRequest{Channel: models.CardChannelPOS, MerchantCategory: "5411", Country: "TH", Contactless: true}, CodeNotPermitted, "contactless"},
+		{models.CardAuthorizationRequest{Channel: models.CardChannelPOS, MerchantCategory: "5411", Country: "JP"}, CodeNotPermitted, "abroad"},
+		{models.CardAuthorizationRequest{Channel: models.CardChannelPOS, MerchantCategory: "7995", Country: "TH"}, CodeNotPermitted, "7995"},
+		{models.CardAuthorizationRequest{Channel: models.CardChannelATM, MerchantCategory: "6011", Country: "TH", PINResult: models.PINVerified}, CodeExceedsLimit, "2000.00"},
+		{models.CardAuthorizationRequest{Channel: models.CardChannelPOS, MerchantCategory: "5411", Country: "TH"}, CodeApproved, ""},
+	}
+	for i, tc := range requests {
+		tc.req.RRN = fmt.Sprintf("%012d", i+1)
+		tc.req.PAN = "4289990000000011"
+		tc.req.Amount = 2500
+		authorization, err := f.service.Authorize(ctx, tc.req)
+		require.NoError(t, err)
+		assert.Equal(t, tc.code, authorization.ResponseCode, authorization.DeclineReason)
+		assert.Contains(t, authorization.DeclineReason, tc.reason)
+	}
+}
+
+func TestATMWithdrawalsCountAgainstCustomerLimits(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+	card := f.cards.cards["4289990000000011"]
+	today := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	f.limiter.daily = 10000
+	atm := func(rrn string, amount float64) *models.CardAuthorization {
+		authorization, err := f.service.Authorize(ctx, models.CardAuthorizationRequest{
+			RRN: rrn, PAN: "4289990000000011", Amount: amount,
+			MerchantCategory: "6011", Country: "TH", Channel: models.CardChannelATM, PINResult: models.PINVerified,
+		})
+		require.NoError(t, err)
+		return authorization
+	}
+
+	authorization := atm("000000000001", 12000)
+	assert.Equal(t, CodeExceedsLimit, authorization.ResponseCode)
+	assert.Contains(t, authorization.DeclineReason, "daily limit for atm withdrawals")
+	assert.Zero(t, f.repo.usage[usageKey(card.ID, true, today)], "the card's usage is given back")
+
+	authorization = atm("000000000002", 8000)
+	assert.Equal(t, CodeApproved, authorization.ResponseCode)
+	assert.Equal(t, 8000.0, f.limiter.used)
+	assert.Equal(t, 8000.0, f.repo.usage[usageKey(card.ID, true, today)])
+}
+
+func TestDeclinedAuthorizationReleasesCardUsage(t *testing.T) {
+	f := newFixture()
+	card := f.cards.cards["4289990000000011"]
+
+	authorization, err := f.service.Authorize(context.Background(), models.CardAuthorizationRequest{
+		RRN: "000000000001", PAN: "4289990000000011", Amount: 60000,
+		MerchantCategory: "5311", Country: "TH", Channel: models.CardChannelPOS,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, CodeInsufficientFunds, authorization.ResponseCode)
+	assert.Zero(t, f.repo.usage[usageKey(card.ID, false, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC))])
+}
@@ -0,0 +1,90 @@
+package cardauth
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"example.com/m/internal/models"
+)
+
+// Message type indicators of the messages in a replay file
+const (
+	// MTIAuthorization is an authorization request
+	MTIAuthorization = "0100"
+	// MTIClearing is a clearing message, of which only rrn and amount are read
+	MTIClearing = "1240"
+)
+
+// Message is one line of a replay file
+type Message struct {
+	MTI string `json:"mti"`
+	models.CardAuthorizationRequest
+}
+
+// ReplayResult is the answer to one message of a replay file
+type ReplayResult struct {
+	Line          int                            `json:"line"`
+	MTI           string                         `json:"mti"`
+	RRN           string                         `json:"rrn"`
+	Status        models.CardAuthorizationStatus `json:"status,omitempty"`
+	ResponseCode  string                         `json:"response_code,omitempty"`
+	DeclineReason string                         `json:"decline_reason,omitempty"`
+	Error         string                         `json:"error,omitempty"`
+}
+
+// Replay sends the messages of a file to the service in order, as the card
+// network would, so that authorizations and their clearing can be run end
+// to end without the network. The file has one JSON message per line;
+// blank lines and lines starting with # are skipped. A message the service
+// rejects is reported in its result and does not stop the replay.
+func (s *Service) Replay(ctx context.Context, r io.Reader) ([]ReplayResult, error) {
+	results := []ReplayResult{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		var message Message
+		if err := json.Unmarshal([]byte(text), &message); err != nil {
+			results = append(results, ReplayResult{Line: line, Error: fmt.Sprintf("invalid JSON: %v", err)})
+			continue
+		}
+		results = append(results, s.replayMessage(ctx, line, message))
+	}
+	if err := scanner.Err(); err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+func (s *Service) replayMessage(ctx context.Context, line int, message Message) ReplayResult {
+	result := ReplayResult{Line: line, MTI: message.MTI, RRN: message.RRN}
+
+	var authorization *models.CardAuthorization
+	var err error
+	switch message.MTI {
+	case MTIAuthorization:
+		authorization, err = s.Authorize(ctx, message.CardAuthorizationRequest)
+	case MTIClearing:
+		authorization, err = s.Clear(ctx, models.CardClearingRequest{RRN: message.RRN, Amount: message.Amount})
+	default:
+		err = fmt.Errorf("%w: mti must be %s or %s", ErrInvalidMessage, MTIAuthorization, MTIClearing)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Status = authorization.Status
+	result.ResponseCode = authorization.ResponseCode
+	result.DeclineReason = authorization.DeclineReason
+	return result
+}
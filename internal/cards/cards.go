@@ -0,0 +1,258 @@
+package cards
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/database"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// cvvLength is the number of digits of the card verification value
+	cvvLength = 3
+	// activationCodeLength is the number of digits of the code mailed with a card
+	activationCodeLength = 6
+	// panScope is the hash scope of card numbers, which are looked up across all cards
+	panScope = "pan"
+)
+
+var (
+	// ErrAccountNotEligible is returned when a card cannot be linked to the account
+	ErrAccountNotEligible = errors.New("debit cards can only be linked to active savings or current accounts")
+	// ErrCardChanged is returned when the card was changed by another request at the same time
+	ErrCardChanged = errors.New("card was changed by another request; try again")
+)
+
+// Service issues payment cards and moves them through their lifecycle
+type Service struct {
+	repo          repository.CardRepository
+	accountRepo   repository.AccountRepository
+	customerRepo  database.CustomerRepositoryInterface
+	notifications repository.NotificationRepository
+	mailer        Mailer
+	vault         *Vault
+	cfg           config.CardConfig
+	now           func() time.Time
+}
+
+// NewService creates a new card Service
+func NewService(repo repository.CardRepository, accountRepo repository.AccountRepository, customerRepo database.CustomerRepositoryInterface, notifications repository.NotificationRepository, mailer Mailer, vault *Vault, cfg config.CardConfig) *Service {
+	return &Service{
+		repo:          repo,
+		accountRepo:   accountRepo,
+		customerRepo:  customerRepo,
+		notifications: notifications,
+		mailer:        mailer,
+		vault:         vault,
+		cfg:           cfg,
+		now:           time.Now,
+	}
+}
+
+// RequestDebit issues a debit card on the account. A replacement offered
+// for a lost or stolen card on the account is issued instead of a new
+// card. The card number is returned once; only its last four digits stay
+// readable afterwards. The CVV and activation code are mailed to the holder.
+func (s *Service) RequestDebit(ctx context.Context, account *models.Account, customerID uuid.UUID) (*models.CardIssue, error) {
+	if account.Status != models.AccountStatusActive ||
+		(account.AccountType != models.AccountTypeSavings && account.AccountType != models.AccountTypeCurrent) {
+		return nil, ErrAccountNotEligible
+	}
+
+	requested, err := s.repo.GetRequestedCard(ctx, account.ID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if requested != nil {
+		return s.issueRequested(ctx, requested)
+	}
+
+	card := s.newCard(account.ID, customerID)
+	secrets, err := s.generate(card)
+	if err != nil {
+		return nil, err
+	}
+	issue := &models.CardIssue{Card: card}
+
+	// Retry a few times in the unlikely case a generated number is taken
+	for attempt := 0; attempt < 3; attempt++ {
+		if err = s.assignNumber(issue); err != nil {
+			break
+		}
+		err = s.repo.CreateCard(ctx, card)
+		if !errors.Is(err, repository.ErrDuplicateCardNumber) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mail(ctx, issue, secrets); err != nil {
+		return nil, err
+	}
+	return issue, nil
+}
+
+// issueRequested sends out a card that already has a number, such as a replacement
+func (s *Service) issueRequested(ctx context.Context, card *models.Card) (*models.CardIssue, error) {
+	pan, err := s.vault.Open(card.PANEncrypted)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := s.generate(card)
+	if err != nil {
+		return nil, err
+	}
+	issue := &models.CardIssue{Card: card, PAN: pan}
+
+	saved, err := s.repo.UpdateCard(ctx, card, models.CardRequested)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, ErrCardChanged
+	}
+	if err := s.mail(ctx, issue, secrets); err != nil {
+		return nil, err
+	}
+	return issue, nil
+}
+
+// cardSecrets are the values of a new card that are only ever stored hashed
+type cardSecrets struct {
+	cvv            string
+	activationCode string
+}
+
+// mail sends the issued card with its CVV, and its activation code in a separate letter
+func (s *Service) mail(ctx context.Context, issue *models.CardIssue, secrets *cardSecrets) error {
+	if err := s.mailer.SendCard(ctx, issue.Card, issue.PAN, secrets.cvv); err != nil {
+		return fmt.Errorf("card %s was issued but could not be mailed: %w", issue.ID, err)
+	}
+	if err := s.mailer.SendActivationCode(ctx, issue.Card, secrets.activationCode); err != nil {
+		return fmt.Errorf("activation code of card %s could not be mailed: %w", issue.ID, err)
+	}
+	return nil
+}
+
+// newCard returns a requested debit card on the account, valid for the configured years
+func (s *Service) newCard(accountID, customerID uuid.UUID) *models.Card {
+	now := s.now()
+	expiry := now.AddDate(s.cfg.ValidityYears, 0, 0)
+	return &models.Card{
+		ID:          uuid.New(),
+		AccountID:   accountID,
+		CustomerID:  customerID,
+		CardType:    models.CardTypeDebit,
+		Status:      models.CardRequested,
+		ExpiryMonth: int(expiry.Month()),
+		ExpiryYear:  expiry.Year(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// generate creates the CVV and activation code of a card and marks it issued
+func (s *Service) generate(card *models.Card) (*cardSecrets, error) {
+	cvv, err := randomDigits(cvvLength)
+	if err != nil {
+		return nil, err
+	}
+	code, err := randomDigits(activationCodeLength)
+	if err != nil {
+		return nil, err
+	}
+
+	issuedAt := s.now()
+	card.CVVHash = s.vault.Hash(cvvScope(card.ID), cvv)
+	card.ActivationCodeHash = s.vault.Hash(activationScope(card.ID), code)
+	card.ActivationAttempts = 0
+	card.Status = models.CardIssued
+	card.IssuedAt = &issuedAt
+	card.UpdatedAt = issuedAt
+	return &cardSecrets{cvv: cvv, activationCode: code}, nil
+}
+
+// assignNumber gives the card a new number under the configured BIN
+func (s *Service) assignNumber(issue *models.CardIssue) error {
+	pan, err := generatePAN(s.cfg.BIN, s.cfg.PANLength)
+	if err != nil {
+		return err
+	}
+	sealed, err := s.vault.Seal(pan)
+	if err != nil {
+		return err
+	}
+
+	issue.PAN = pan
+	issue.Last4 = pan[len(pan)-4:]
+	issue.PANEncrypted = sealed
+	issue.PANHash = s.vault.Hash(panScope, pan)
+	return nil
+}
+
+// cvvScope and activationScope are the hash scopes of a card's secrets
+func cvvScope(cardID uuid.UUID) string {
+	return "cvv:" + cardID.String()
+}
+
+func activationScope(cardID uuid.UUID) string {
+	return "activation:" + cardID.String()
+}
+
+// generatePAN returns a random card number of length digits that starts
+// with bin and ends with its Luhn check digit
+func generatePAN(bin string, length int) (string, error) {
+	body, err := randomDigits(length - len(bin) - 1)
+	if err != nil {
+		return "", err
+	}
+	return bin + body + string(luhnDigit(bin+body)), nil
+}
+
+// luhnDigit returns the check digit that makes number followed by it pass the Luhn check
+func luhnDigit(number string) byte {
+	sum := 0
+	for i := len(number) - 1; i >= 0; i-- {
+		digit := int(number[i] - '0')
+		// Starting from the digit next to the check digit, every other digit is doubled
+		if (len(number)-i)%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// ValidLuhn reports whether a card number passes the Luhn check
+func ValidLuhn(number string) bool {
+	if len(number) < 2 || strings.Trim(number, "0123456789") != "" {
+		return false
+	}
+	return luhnDigit(number[:len(number)-1]) == number[len(number)-1]
+}
+
+// randomDigits returns n random decimal digits
+func randomDigits(n int) (string, error) {
+	digits := make([]byte, n)
+	for i := range digits {
+		d, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		digits[i] = byte('0' + d.Int64())
+	}
+	return string(digits), nil
+}
@@ -0,0 +1,395 @@
+package cards
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/repository/repotest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubCardRepository keeps cards in memory
+type stubCardRepository struct {
+	repository.CardRepository
+	cards    []*models.Card
+	audit    []*models.CardAuditEntry
+	controls map[uuid.UUID]*models.CardControls
+	// taken makes the next inserts fail as if the number were in use
+	taken int
+}
+
+func (r *stubCardRepository) CreateCard(ctx context.Context, card *models.Card) error {
+	if r.taken > 0 {
+		r.taken--
+		return repository.ErrDuplicateCardNumber
+	}
+	stored := *card
+	r.cards = append(r.cards, &stored)
+	return nil
+}
+
+func (r *stubCardRepository) FindCardsByLast4(ctx context.Context, last4 string, customerID *uuid.UUID) ([]*models.Card, error) {
+	matches := []*models.Card{}
+	for _, card := range r.cards {
+		if card.Last4 == last4 && card.Status != models.CardRequested && (customerID == nil || card.CustomerID == *customerID) {
+			stored := *card
+			matches = append(matches, &stored)
+		}
+	}
+	return matches, nil
+}
+
+func (r *stubCardRepository) GetCardByPANHash(ctx context.Context, panHash string) (*models.Card, error) {
+	for _, card := range r.cards {
+		if card.PANHash == panHash {
+			return card, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *stubCardRepository) GetRequestedCard(ctx context.Context, accountID, customerID uuid.UUID) (*models.Card, error) {
+	for _, card := range r.cards {
+		if card.AccountID == accountID && card.CustomerID == customerID && card.Status == models.CardRequested {
+			stored := *card
+			return &stored, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *stubCardRepository) UpdateCard(ctx context.Context, card *models.Card, from models.CardStatus) (bool, error) {
+	for i, existing := range r.cards {
+		if existing.ID == card.ID {
+			if existing.Status != from {
+				return false, nil
+			}
+			stored := *card
+			r.cards[i] = &stored
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *stubCardRepository) UseActivationAttempt(ctx context.Context, cardID uuid.UUID, max int) (int, bool, error) {
+	for _, card := range r.cards {
+		if card.ID == cardID && card.Status == models.CardIssued && card.ActivationAttempts < max {
+			card.ActivationAttempts++
+			return card.ActivationAttempts, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+func (r *stubCardRepository) SearchCards(ctx context.Context, filter models.CardSearchFilter, limit int) ([]*models.Card, error) {
+	cards := []*models.Card{}
+	for _, card := range r.cards {
+		if card.Last4 == filter.PANLast4 && (filter.Status == "" || card.Status == filter.Status) && len(cards) < limit {
+			cards = append(cards, card)
+		}
+	}
+	return cards, nil
+}
+
+func (r *stubCardRepository) CreateCardAudit(ctx context.Context, entry *models.CardAuditEntry) error {
+	r.audit = append(r.audit, entry)
+	return nil
+}
+
+func (r *stubCardRepository) GetCardControls(ctx context.Context, cardID uuid.UUID) (*models.CardControls, error) {
+	if controls, ok := r.controls[cardID]; ok {
+		stored := *controls
+		return &stored, nil
+	}
+	return nil, nil
+}
+
+func (r *stubCardRepository) SaveCardControls(ctx context.Context, controls *models.CardControls, audit *models.CardAuditEntry) error {
+	if r.controls == nil {
+		r.controls = map[uuid.UUID]*models.CardControls{}
+	}
+	stored := *controls
+	r.controls[controls.CardID] = &stored
+	r.audit = append(r.audit, audit)
+	return nil
+}
+
+// stubMailer keeps the secrets mailed with each card by card ID
+type stubMailer struct {
+	cvv  map[uuid.UUID]string
+	code map[uuid.UUID]string
+}
+
+func (m *stubMailer) SendCard(ctx context.Context, card *models.Card, pan, cvv string) error {
+	m.cvv[card.ID] = cvv
+	return nil
+}
+
+func (m *stubMailer) SendActivationCode(ctx context.Context, card *models.Card, code string) error {
+	m.code[card.ID] = code
+	return nil
+}
+
+// activationCode returns the code mailed with the card
+func activationCode(service *Service, cardID uuid.UUID) string {
+	return service.mailer.(*stubMailer).code[cardID]
+}
+
+func newTestService(t *testing.T, repo *stubCardRepository) (*Service, *Vault) {
+	vault, err := NewVault([]byte(strings.Repeat("k", 32)))
+	require.NoError(t, err)
+	service := NewService(repo, &repotest.Accounts{}, repotest.NewCustomers(),
+		&repotest.Notifications{}, &stubMailer{cvv: map[uuid.UUID]string{}, code: map[uuid.UUID]string{}}, vault, config.Default().Cards)
+	service.now = func() time.Time { return time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC) }
+	return service, vault
+}
+
+func TestLuhn(t *testing.T) {
+	assert.True(t, ValidLuhn("4111111111111111"))
+	assert.True(t, ValidLuhn("79927398713"))
+	assert.False(t, ValidLuhn("4111111111111112"))
+	assert.False(t, ValidLuhn("41111111111111a1"))
+}
+
+func TestRequestDebitIssuesCard(t *testing.T) {
+	repo := &stubCardRepository{taken: 1}
+	service, vault := newTestService(t, repo)
+	account := &models.Account{ID: uuid.New(), AccountType: models.AccountTypeSavings, Status: models.AccountStatusActive}
+	customerID := uuid.New()
+
+	issue, err := service.RequestDebit(context.Background(), account, customerID)
+	require.NoError(t, err)
+	assert.Len(t, issue.PAN, 16)
+	assert.True(t, strings.HasPrefix(issue.PAN, "428999"))
+	assert.True(t, ValidLuhn(issue.PAN))
+	mailer := service.mailer.(*stubMailer)
+	cvv, code := mailer.cvv[issue.ID], mailer.code[issue.ID]
+	assert.Len(t, cvv, 3, "the CVV is mailed with the card")
+	assert.Len(t, code, 6, "the activation code is mailed separately")
+
+	require.Len(t, repo.cards, 1, "a taken number is replaced by a new one")
+	card := repo.cards[0]
+	assert.Equal(t, models.CardIssued, card.Status)
+	assert.Equal(t, issue.PAN[12:], card.Last4)
+	assert.Equal(t, 3, card.ExpiryMonth)
+	assert.Equal(t, 2031, card.ExpiryYear)
+	assert.NotContains(t, string(card.PANEncrypted), issue.PAN)
+	pan, err := vault.Open(card.PANEncrypted)
+	require.NoError(t, err)
+	assert.Equal(t, issue.PAN, pan)
+	assert.True(t, vault.Matches(card.CVVHash, cvvScope(card.ID), cvv))
+	assert.True(t, vault.Matches(card.ActivationCodeHash, activationScope(card.ID), code))
+	assert.False(t, vault.Matches(card.CVVHash, cvvScope(uuid.New()), cvv))
+
+	body, err := json.Marshal(issue)
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), `"cvv"`)
+	assert.NotContains(t, string(body), `"activation_code"`)
+}
+
+func TestRequestDebitRefusesIneligibleAccounts(t *testing.T) {
+	service, _ := newTestService(t, &stubCardRepository{})
+	for _, account := range []*models.Account{
+		{ID: uuid.New(), AccountType: models.AccountTypeFixedDeposit, Status: models.AccountStatusActive},
+		{ID: uuid.New(), AccountType: models.AccountTypeSavings, Status: models.AccountStatusDormant},
+	} {
+		_, err := service.RequestDebit(context.Background(), account, uuid.New())
+		assert.ErrorIs(t, err, ErrAccountNotEligible)
+	}
+}
+
+func TestCardExpired(t *testing.T) {
+	card := &models.Card{ExpiryMonth: 12, ExpiryYear: 2030}
+	assert.False(t, card.Expired(time.Date(2030, 12, 31, 23, 59, 0, 0, time.UTC)))
+	assert.True(t, card.Expired(time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestCardLifecycle(t *testing.T) {
+	repo := &stubCardRepository{}
+	service, _ := newTestService(t, repo)
+	ctx := context.Background()
+	account := &models.Account{ID: uuid.New(), AccountType: models.AccountTypeSavings, Status: models.AccountStatusActive}
+	customerID, otherCustomer := uuid.New(), uuid.New()
+	issue, err := service.RequestDebit(ctx, account, customerID)
+	require.NoError(t, err)
+	ref := CardRef{Last4: issue.Last4, CustomerID: &customerID}
+
+	code := activationCode(service, issue.ID)
+	_, err = service.Activate(ctx, CardRef{Last4: issue.Last4, CustomerID: &otherCustomer}, code)
+	assert.ErrorIs(t, err, ErrCardNotFound, "other customers' cards are not found")
+	_, err = service.Block(ctx, ref)
+	assert.ErrorIs(t, err, ErrInvalidCardStatus, "an issued card must be activated first")
+
+	card, err := service.Activate(ctx, ref, code)
+	require.NoError(t, err)
+	assert.Equal(t, models.CardActive, card.Status)
+	card, err = service.Block(ctx, ref)
+	require.NoError(t, err)
+	assert.Equal(t, models.CardBlocked, card.Status)
+	card, err = service.Unblock(ctx, ref)
+	require.NoError(t, err)
+	assert.Equal(t, models.CardActive, card.Status)
+}
+
+func TestActivationAttemptLimit(t *testing.T) {
+	repo := &stubCardRepository{}
+	service, _ := newTestService(t, repo)
+	ctx := context.Background()
+	account := &models.Account{ID: uuid.New(), AccountType: models.AccountTypeCurrent, Status: models.AccountStatusActive}
+	customerID := uuid.New()
+	issue, err := service.RequestDebit(ctx, account, customerID)
+	require.NoError(t, err)
+	ref := CardRef{Last4: issue.Last4, CustomerID: &customerID}
+
+	code := activationCode(service, issue.ID)
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	for attempt := 1; attempt < service.cfg.MaxActivationAttempts; attempt++ {
+		_, err = service.Activate(ctx, ref, wrong)
+		assert.ErrorIs(t, err, ErrInvalidActivationCode)
+	}
+	_, err = service.Activate(ctx, ref, wrong)
+	assert.ErrorIs(t, err, ErrActivationLocked)
+	_, err = service.Activate(ctx, ref, code)
+	assert.ErrorIs(t, err, ErrActivationLocked, "the right code no longer helps")
+}
+
+func TestAmbiguousLast4(t *testing.T) {
+	repo := &stubCardRepository{}
+	service, _ := newTestService(t, repo)
+	customerID := uuid.New()
+	first := &models.Card{ID: uuid.New(), CustomerID: customerID, Last4: "1234", Status: models.CardActive}
+	second := &models.Card{ID: uuid.New(), CustomerID: customerID, Last4: "1234", Status: models.CardIssued}
+	repo.cards = []*models.Card{first, second}
+
+	_, err := service.Find(context.Background(), CardRef{Last4: "1234", CustomerID: &customerID})
+	var ambiguous *AmbiguousCardError
+	require.ErrorAs(t, err, &ambiguous)
+	assert.Len(t, ambiguous.Cards, 2)
+	assert.ErrorIs(t, err, ErrAmbiguousCard)
+
+	card, err := service.Find(context.Background(), CardRef{Last4: "1234", CustomerID: &customerID, CardID: &second.ID})
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, card.ID)
+}
+
+func TestLostStolenCardIsReplaced(t *testing.T) {
+	repo := &stubCardRepository{}
+	service, vault := newTestService(t, repo)
+	notifications := service.notifications.(*repotest.Notifications)
+	ctx := context.Background()
+	account := &models.Account{ID: uuid.New(), AccountType: models.AccountTypeSavings, Status: models.AccountStatusActive}
+	customerID, staffID := uuid.New(), uuid.New()
+	issue, err := service.RequestDebit(ctx, account, customerID)
+	require.NoError(t, err)
+	ref := CardRef{Last4: issue.Last4, CustomerID: &customerID}
+	_, err = service.Activate(ctx, ref, activationCode(service, issue.ID))
+	require.NoError(t, err)
+	_, err = service.Block(ctx, ref)
+	require.NoError(t, err)
+
+	report, err := service.ReportLostStolen(ctx, CardRef{Last4: issue.Last4}, staffID)
+	require.NoError(t, err)
+	assert.Equal(t, models.CardLostStolen, report.Card.Status)
+	assert.Equal(t, staffID, *report.Card.LostStolenBy)
+	assert.Equal(t, models.CardRequested, report.Replacement.Status)
+	assert.Equal(t, issue.ID, *report.Replacement.ReplacesCardID)
+	require.Len(t, notifications.Notifications, 1)
+	assert.Equal(t, models.NotificationCardReplacementOffered, notifications.Notifications[0].Type)
+
+	_, err = service.Unblock(ctx, ref)
+	assert.ErrorIs(t, err, ErrCardLostStolen)
+	_, err = service.ReportLostStolen(ctx, CardRef{Last4: issue.Last4}, staffID)
+	assert.ErrorIs(t, err, ErrCardLostStolen)
+
+	replacement, err := service.RequestDebit(ctx, account, customerID)
+	require.NoError(t, err)
+	assert.Equal(t, report.Replacement.ID, replacement.ID, "the offered replacement is issued")
+	assert.Equal(t, models.CardIssued, replacement.Status)
+	assert.True(t, ValidLuhn(replacement.PAN))
+	assert.Equal(t, replacement.PAN[12:], replacement.Last4)
+	assert.True(t, vault.Matches(replacement.CVVHash, cvvScope(replacement.ID), service.mailer.(*stubMailer).cvv[replacement.ID]))
+	assert.Len(t, repo.cards, 2)
+}
+
+func TestSearchMasksOwnerAndExpiry(t *testing.T) {
+	repo := &stubCardRepository{}
+	service, _ := newTestService(t, repo)
+	ctx := context.Background()
+	account := &models.Account{ID: uuid.New(), AccountNumber: "1234567890", AccountType: models.AccountTypeSavings, Status: models.AccountStatusActive}
+	service.accountRepo.(*repotest.Accounts).Accounts = []*models.Account{account}
+	customerID, staffID := uuid.New(), uuid.New()
+	service.customerRepo.(*repotest.Customers).Customers[customerID.String()] = &models.Customer{FirstName: "Somchai", LastName: "Jaidee"}
+	issue, err := service.RequestDebit(ctx, account, customerID)
+	require.NoError(t, err)
+
+	results, err := service.Search(ctx, models.CardSearchFilter{PANLast4: issue.Last4, Status: models.CardIssued}, staffID)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, issue.ID, results[0].CardID)
+	assert.Equal(t, "1234567890", results[0].AccountNumber)
+	assert.Equal(t, models.MaskName("Somchai", "Jaidee"), results[0].OwnerName)
+	assert.Equal(t, "**/31", results[0].PartialExpiry)
+
+	require.Len(t, repo.audit, 1, "every search is audited")
+	assert.Equal(t, auditSearch, repo.audit[0].Action)
+	assert.Equal(t, staffID, repo.audit[0].ActorID)
+	assert.Contains(t, repo.audit[0].Details, "panLast4="+issue.Last4)
+	assert.Contains(t, repo.audit[0].Details, "1 results")
+}
+
+func TestUpdateControls(t *testing.T) {
+	repo := &stubCardRepository{}
+	service, _ := newTestService(t, repo)
+	ctx := context.Background()
+	account := &models.Account{ID: uuid.New(), AccountType: models.AccountTypeSavings, Status: models.AccountStatusActive}
+	customerID := uuid.New()
+	issue, err := service.RequestDebit(ctx, account, customerID)
+	require.NoError(t, err)
+	ref := CardRef{Last4: issue.Last4, CustomerID: &customerID}
+
+	controls, err := service.Controls(ctx, ref)
+	require.NoError(t, err)
+	assert.Equal(t, 20000.0, controls.ATMDailyLimit, "the bank's limits are the defaults")
+	assert.True(t, controls.InternationalEnabled)
+
+	atm, ecommerce := 5000.0, false
+	categories := []string{"7995", " 5813", "7995"}
+	controls, err = service.UpdateControls(ctx, ref, models.CardControlsRequest{
+		ATMDailyLimit: &atm, EcommerceEnabled: &ecommerce, BlockedMerchantCategories: &categories,
+	}, customerID)
+	require.NoError(t, err)
+	assert.Equal(t, 5000.0, controls.ATMDailyLimit)
+	assert.Equal(t, 100000.0, controls.POSDailyLimit, "fields not sent are unchanged")
+	assert.False(t, controls.EcommerceEnabled)
+	assert.Equal(t, []string{"5813", "7995"}, controls.BlockedMerchantCategories)
+
+	require.Len(t, repo.audit, 1, "every change is audited")
+	assert.Equal(t, auditControlsUpdated, repo.audit[0].Action)
+	assert.Equal(t, issue.ID, *repo.audit[0].CardID)
+	assert.Equal(t, customerID, repo.audit[0].ActorID)
+	assert.Equal(t, "atm_daily_limit 20000.00 -> 5000.00; ecommerce_enabled true -> false; "+
+		"blocked_merchant_categories [] -> [5813,7995]", repo.audit[0].Details)
+
+	_, err = service.UpdateControls(ctx, ref, models.CardControlsRequest{ATMDailyLimit: &atm}, customerID)
+	require.NoError(t, err)
+	assert.Len(t, repo.audit, 1, "an update that changes nothing is not audited")
+
+	tooHigh := 20000.01
+	_, err = service.UpdateControls(ctx, ref, models.CardControlsRequest{ATMDailyLimit: &tooHigh}, customerID)
+	assert.ErrorIs(t, err, ErrInvalidControls)
+	invalid := []string{"59"}
+	_, err = service.UpdateControls(ctx, ref, models.CardControlsRequest{BlockedMerchantCategories: &invalid}, customerID)
+	assert.ErrorIs(t, err, ErrInvalidControls)
+}
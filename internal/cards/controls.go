@@ -0,0 +1,159 @@
+package cards
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidControls is returned for card controls outside what the bank allows
+var ErrInvalidControls = errors.New("invalid card controls")
+
+// auditControlsUpdated is the audit action of a change to the controls of a card
+const auditControlsUpdated = "controls_updated"
+
+// Controls returns the controls of the card matching ref
+func (s *Service) Controls(ctx context.Context, ref CardRef) (*models.CardControls, error) {
+	card, err := s.Find(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return s.ControlsOf(ctx, card)
+}
+
+// ControlsOf returns the controls saved for a card, or the defaults when
+// none were saved: the bank's daily limits with every kind of use allowed
+func (s *Service) ControlsOf(ctx context.Context, card *models.Card) (*models.CardControls, error) {
+	controls, err := s.repo.GetCardControls(ctx, card.ID)
+	if err != nil || controls != nil {
+		return controls, err
+	}
+	return &models.CardControls{
+		CardID:                    card.ID,
+		ATMDailyLimit:             s.cfg.ATMDailyLimit,
+		POSDailyLimit:             s.cfg.POSDailyLimit,
+		EcommerceEnabled:          true,
+		ContactlessEnabled:        true,
+		InternationalEnabled:      true,
+		BlockedMerchantCategories: []string{},
+	}, nil
+}
+
+// UpdateControls applies a partial update to the controls of the card
+// matching ref. The change is saved together with an entry in the card
+// audit trail naming actorID and every control that changed.
+func (s *Service) UpdateControls(ctx context.Context, ref CardRef, req models.CardControlsRequest, actorID uuid.UUID) (*models.CardControls, error) {
+	card, err := s.Find(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if card.Status == models.CardLostStolen {
+		return nil, ErrCardLostStolen
+	}
+	controls, err := s.ControlsOf(ctx, card)
+	if err != nil {
+		return nil, err
+	}
+	before := *controls
+
+	if req.ATMDailyLimit != nil {
+		if err := checkDailyLimit("atm_daily_limit", *req.ATMDailyLimit, s.cfg.ATMDailyLimit); err != nil {
+			return nil, err
+		}
+		controls.ATMDailyLimit = *req.ATMDailyLimit
+	}
+	if req.POSDailyLimit != nil {
+		if err := checkDailyLimit("pos_daily_limit", *req.POSDailyLimit, s.cfg.POSDailyLimit); err != nil {
+			return nil, err
+		}
+		controls.POSDailyLimit = *req.POSDailyLimit
+	}
+	if req.EcommerceEnabled != nil {
+		controls.EcommerceEnabled = *req.EcommerceEnabled
+	}
+	if req.ContactlessEnabled != nil {
+		controls.ContactlessEnabled = *req.ContactlessEnabled
+	}
+	if req.InternationalEnabled != nil {
+		controls.InternationalEnabled = *req.InternationalEnabled
+	}
+	if req.BlockedMerchantCategories != nil {
+		categories, err := normalizeCategories(*req.BlockedMerchantCategories)
+		if err != nil {
+			return nil, err
+		}
+		controls.BlockedMerchantCategories = categories
+	}
+
+	changes := describeChanges(&before, controls)
+	if changes == "" {
+		return controls, nil
+	}
+
+	now := s.now()
+	controls.UpdatedAt = now
+	audit := &models.CardAuditEntry{
+		ID:        uuid.New(),
+		CardID:    &card.ID,
+		Action:    auditControlsUpdated,
+		ActorID:   actorID,
+		Details:   changes,
+		CreatedAt: now,
+	}
+	if err := s.repo.SaveCardControls(ctx, controls, audit); err != nil {
+		return nil, err
+	}
+	return controls, nil
+}
+
+// checkDailyLimit refuses a daily limit below zero or above the bank's
+func checkDailyLimit(field string, limit, max float64) error {
+	if limit < 0 || limit > max {
+		return fmt.Errorf("%w: %s must be between 0 and %.2f", ErrInvalidControls, field, max)
+	}
+	return nil
+}
+
+// normalizeCategories checks that every merchant category code has four
+// digits and returns the codes sorted, without repeats
+func normalizeCategories(categories []string) ([]string, error) {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, category := range categories {
+		category = strings.TrimSpace(category)
+		if len(category) != 4 || strings.Trim(category, "0123456789") != "" {
+			return nil, fmt.Errorf("%w: %q is not a four-digit merchant category code", ErrInvalidControls, category)
+		}
+		if !seen[category] {
+			seen[category] = true
+			normalized = append(normalized, category)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// describeChanges lists the controls that differ, as "field old -> new"
+func describeChanges(before, after *models.CardControls) string {
+	changes := []string{}
+	add := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, fmt.Sprintf("%s %s -> %s", field, from, to))
+		}
+	}
+	add("atm_daily_limit", fmt.Sprintf("%.2f", before.ATMDailyLimit), fmt.Sprintf("%.2f", after.ATMDailyLimit))
+	add("pos_daily_limit", fmt.Sprintf("%.2f", before.POSDailyLimit), fmt.Sprintf("%.2f", after.POSDailyLimit))
+	add("ecommerce_enabled", strconv.FormatBool(before.EcommerceEnabled), strconv.FormatBool(after.EcommerceEnabled))
+	add("contactless_enabled", strconv.FormatBool(before.ContactlessEnabled), strconv.FormatBool(after.ContactlessEnabled))
+	add("international_enabled", strconv.FormatBool(before.InternationalEnabled), strconv.FormatBool(after.InternationalEnabled))
+	add("blocked_merchant_categories",
+		"["+strings.Join(before.BlockedMerchantCategories, ",")+"]",
+		"["+strings.Join(after.BlockedMerchantCategories, ",")+"]")
+	return strings.Join(changes, "; ")
+}
@@ -0,0 +1,260 @@
+package cards
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrCardNotFound is returned when no card matches the last four digits or card ID
+	ErrCardNotFound = errors.New("card not found")
+	// ErrAmbiguousCard is matched by AmbiguousCardError
+	ErrAmbiguousCard = errors.New("more than one card ends in these digits")
+	// ErrInvalidCardStatus is returned when the card cannot make the change in its current status
+	ErrInvalidCardStatus = errors.New("card cannot be changed in its current status")
+	// ErrCardLostStolen is returned for any change to a card reported lost or stolen
+	ErrCardLostStolen = errors.New("card was reported lost or stolen and can never be used again; use its replacement")
+	// ErrCardExpired is returned when activating a card past its expiry
+	ErrCardExpired = errors.New("card has expired")
+	// ErrInvalidActivationCode is returned for a wrong activation code
+	ErrInvalidActivationCode = errors.New("activation code is incorrect")
+	// ErrActivationLocked is returned once too many wrong activation codes were entered
+	ErrActivationLocked = errors.New("too many incorrect activation codes; contact the bank for a replacement card")
+)
+
+// AmbiguousCardError is returned when several cards end in the same four
+// digits. Cards lists them for the caller to choose one by ID.
+type AmbiguousCardError struct {
+	Cards []*models.Card
+}
+
+func (e *AmbiguousCardError) Error() string {
+	return fmt.Sprintf("%d cards end in %s; choose one with cardId", len(e.Cards), e.Cards[0].Last4)
+}
+
+// Is makes errors.Is(err, ErrAmbiguousCard) match any AmbiguousCardError
+func (e *AmbiguousCardError) Is(target error) bool {
+	return target == ErrAmbiguousCard
+}
+
+// CardRef identifies a card by its last four digits. CustomerID limits the
+// search to one customer's cards; CardID picks one card when several match.
+type CardRef struct {
+	Last4      string
+	CustomerID *uuid.UUID
+	CardID     *uuid.UUID
+}
+
+// Find returns the one card matching ref
+func (s *Service) Find(ctx context.Context, ref CardRef) (*models.Card, error) {
+	matches, err := s.repo.FindCardsByLast4(ctx, ref.Last4, ref.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	if ref.CardID != nil {
+		for _, card := range matches {
+			if card.ID == *ref.CardID {
+				return card, nil
+			}
+		}
+		return nil, ErrCardNotFound
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, ErrCardNotFound
+	case 1:
+		return matches[0], nil
+	}
+	return nil, &AmbiguousCardError{Cards: matches}
+}
+
+// FindByPAN returns the card with the full card number, or nil if no card has it
+func (s *Service) FindByPAN(ctx context.Context, pan string) (*models.Card, error) {
+	return s.repo.GetCardByPANHash(ctx, s.vault.Hash(panScope, pan))
+}
+
+// CheckCVV reports whether cvv is the verification value of the card
+func (s *Service) CheckCVV(card *models.Card, cvv string) bool {
+	return s.vault.Matches(card.CVVHash, cvvScope(card.ID), cvv)
+}
+
+// Activate checks the activation code mailed with an issued card and makes
+// the card usable. Every code tried uses up one of the configured number of
+// attempts before it is checked, so guesses made in parallel are counted
+// too; once they are used up the card can no longer be activated.
+func (s *Service) Activate(ctx context.Context, ref CardRef, code string) (*models.Card, error) {
+	card, err := s.Find(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTransition(card, models.CardActive); err != nil {
+		return nil, err
+	}
+	if card.Status != models.CardIssued {
+		return nil, fmt.Errorf("%w: card is %s", ErrInvalidCardStatus, card.Status)
+	}
+	if card.ActivationAttempts >= s.cfg.MaxActivationAttempts {
+		return nil, ErrActivationLocked
+	}
+	now := s.now()
+	if card.Expired(now) {
+		return nil, ErrCardExpired
+	}
+
+	attempts, ok, err := s.repo.UseActivationAttempt(ctx, card.ID, s.cfg.MaxActivationAttempts)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrActivationLocked
+	}
+	card.ActivationAttempts = attempts
+
+	if !s.vault.Matches(card.ActivationCodeHash, activationScope(card.ID), code) {
+		if attempts >= s.cfg.MaxActivationAttempts {
+			return nil, ErrActivationLocked
+		}
+		return nil, fmt.Errorf("%w; %d attempts left", ErrInvalidActivationCode, s.cfg.MaxActivationAttempts-attempts)
+	}
+
+	card.Status = models.CardActive
+	card.ActivatedAt = &now
+	card.UpdatedAt = now
+	if err := s.save(ctx, card, models.CardIssued); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// Block stops an active card from being used until it is unblocked
+func (s *Service) Block(ctx context.Context, ref CardRef) (*models.Card, error) {
+	return s.move(ctx, ref, models.CardActive, models.CardBlocked)
+}
+
+// Unblock makes a blocked card usable again. A card reported lost or
+// stolen is never unblocked.
+func (s *Service) Unblock(ctx context.Context, ref CardRef) (*models.Card, error) {
+	return s.move(ctx, ref, models.CardBlocked, models.CardActive)
+}
+
+// ReportLostStolen blocks a card for good and offers the customer a
+// replacement on the same account, which they receive by requesting a
+// debit card on that account
+func (s *Service) ReportLostStolen(ctx context.Context, ref CardRef, staffID uuid.UUID) (*models.CardLostStolenReport, error) {
+	card, err := s.Find(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTransition(card, models.CardLostStolen); err != nil {
+		return nil, err
+	}
+
+	from := card.Status
+	now := s.now()
+	card.Status = models.CardLostStolen
+	card.LostStolenAt = &now
+	card.LostStolenBy = &staffID
+	card.UpdatedAt = now
+	if err := s.save(ctx, card, from); err != nil {
+		return nil, err
+	}
+
+	replacement, err := s.offerReplacement(ctx, card)
+	if err != nil {
+		return nil, err
+	}
+	return &models.CardLostStolenReport{
+		Card:        card,
+		Replacement: replacement,
+	}, nil
+}
+
+// offerReplacement creates a requested card with a new number in place of
+// a lost or stolen one and tells the customer
+func (s *Service) offerReplacement(ctx context.Context, lost *models.Card) (*models.Card, error) {
+	replacement := s.newCard(lost.AccountID, lost.CustomerID)
+	replacement.CardType = lost.CardType
+	replacement.ReplacesCardID = &lost.ID
+	issue := &models.CardIssue{Card: replacement}
+
+	var err error
+	// Retry a few times in the unlikely case a generated number is taken
+	for attempt := 0; attempt < 3; attempt++ {
+		if err = s.assignNumber(issue); err != nil {
+			break
+		}
+		err = s.repo.CreateCard(ctx, replacement)
+		if !errors.Is(err, repository.ErrDuplicateCardNumber) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	notification := &models.Notification{
+		ID:         uuid.New(),
+		CustomerID: lost.CustomerID,
+		Type:       models.NotificationCardReplacementOffered,
+		Title:      "Replacement card ready",
+		Message: fmt.Sprintf("Your card ending %s was reported lost or stolen and can no longer be used. "+
+			"Request a debit card on the same account to receive its replacement.", lost.Last4),
+		CreatedAt: s.now(),
+	}
+	// The report stands even if the customer cannot be told about the replacement
+	if err := s.notifications.CreateNotification(ctx, notification); err != nil {
+		log.Printf("failed to notify customer %s of replacement card %s: %v", lost.CustomerID, replacement.ID, err)
+	}
+	return replacement, nil
+}
+
+// move changes the status of a card that is in status from
+func (s *Service) move(ctx context.Context, ref CardRef, from, to models.CardStatus) (*models.Card, error) {
+	card, err := s.Find(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTransition(card, to); err != nil {
+		return nil, err
+	}
+	if card.Status != from {
+		return nil, fmt.Errorf("%w: card is %s", ErrInvalidCardStatus, card.Status)
+	}
+
+	card.Status = to
+	card.UpdatedAt = s.now()
+	if err := s.save(ctx, card, from); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// save writes a card that is expected to still be in status from
+func (s *Service) save(ctx context.Context, card *models.Card, from models.CardStatus) error {
+	saved, err := s.repo.UpdateCard(ctx, card, from)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		return ErrCardChanged
+	}
+	return nil
+}
+
+// checkTransition refuses a change the card lifecycle does not allow
+func checkTransition(card *models.Card, to models.CardStatus) error {
+	if card.Status == models.CardLostStolen {
+		return ErrCardLostStolen
+	}
+	if !card.Status.CanTransition(to) {
+		return fmt.Errorf("%w: card is %s", ErrInvalidCardStatus, card.Status)
+	}
+	return nil
+}
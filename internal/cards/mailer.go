@@ -0,0 +1,32 @@
+package cards
+
+import (
+	"context"
+	"log"
+
+	"example.com/m/internal/models"
+)
+
+// Mailer sends a newly issued card to its holder. The CVV is printed on
+// the card and the activation code travels in a separate letter, so
+// neither is ever returned by the API.
+type Mailer interface {
+	SendCard(ctx context.Context, card *models.Card, pan, cvv string) error
+	SendActivationCode(ctx context.Context, card *models.Card, code string) error
+}
+
+// LogMailer stands in for the card bureau until one is connected. It logs
+// that a card and its letter were sent and drops their contents.
+type LogMailer struct{}
+
+// SendCard logs that the card was sent
+func (LogMailer) SendCard(ctx context.Context, card *models.Card, pan, cvv string) error {
+	log.Printf("card mailer: card ending %s sent to customer %s", card.Last4, card.CustomerID)
+	return nil
+}
+
+// SendActivationCode logs that the activation letter was sent
+func (LogMailer) SendActivationCode(ctx context.Context, card *models.Card, code string) error {
+	log.Printf("card mailer: activation code for card ending %s sent to customer %s", card.Last4, card.CustomerID)
+	return nil
+}
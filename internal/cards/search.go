@@ -0,0 +1,93 @@
+package cards
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+)
+
+// maxSearchResults caps the cards returned by one staff search
+const maxSearchResults = 50
+
+// auditSearch is the audit action of a staff card search
+const auditSearch = "search"
+
+// Search finds cards by their last four digits for a staff member. Every
+// search is written to the card audit trail before any result is returned.
+func (s *Service) Search(ctx context.Context, filter models.CardSearchFilter, staffID uuid.UUID) ([]*models.CardSearchResult, error) {
+	cards, err := s.repo.SearchCards(ctx, filter, maxSearchResults)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.CreateCardAudit(ctx, &models.CardAuditEntry{
+		ID:        uuid.New(),
+		Action:    auditSearch,
+		ActorID:   staffID,
+		Details:   fmt.Sprintf("%s; %d results", describeFilter(filter), len(cards)),
+		CreatedAt: s.now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	owners := map[uuid.UUID]string{}
+	accounts := map[uuid.UUID]string{}
+	results := make([]*models.CardSearchResult, 0, len(cards))
+	for _, card := range cards {
+		owner, ok := owners[card.CustomerID]
+		if !ok {
+			customer, err := s.customerRepo.GetByID(card.CustomerID.String())
+			if err != nil {
+				return nil, err
+			}
+			owner = models.MaskName(customer.FirstName, customer.LastName)
+			owners[card.CustomerID] = owner
+		}
+		accountNumber, ok := accounts[card.AccountID]
+		if !ok {
+			account, err := s.accountRepo.GetAccountByID(ctx, card.AccountID)
+			if err != nil {
+				return nil, err
+			}
+			if account != nil {
+				accountNumber = account.AccountNumber
+			}
+			accounts[card.AccountID] = accountNumber
+		}
+
+		results = append(results, &models.CardSearchResult{
+			CardID:        card.ID,
+			CardType:      card.CardType,
+			Last4:         card.Last4,
+			Status:        card.Status,
+			AccountID:     card.AccountID,
+			AccountNumber: accountNumber,
+			OwnerName:     owner,
+			PartialExpiry: fmt.Sprintf("**/%02d", card.ExpiryYear%100),
+			IssuedAt:      card.IssuedAt,
+		})
+	}
+	return results, nil
+}
+
+// describeFilter writes the filters of a search for the audit trail
+func describeFilter(filter models.CardSearchFilter) string {
+	parts := []string{"panLast4=" + filter.PANLast4}
+	if filter.CustomerID != nil {
+		parts = append(parts, "customerId="+filter.CustomerID.String())
+	}
+	if filter.Status != "" {
+		parts = append(parts, "status="+string(filter.Status))
+	}
+	if filter.IssuedFrom != nil {
+		parts = append(parts, "issuedFrom="+filter.IssuedFrom.Format("2006-01-02"))
+	}
+	if filter.IssuedTo != nil {
+		parts = append(parts, "issuedTo="+filter.IssuedTo.Format("2006-01-02"))
+	}
+	return strings.Join(parts, " ")
+}
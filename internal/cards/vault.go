@@ -0,0 +1,79 @@
+package cards
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidKey is returned when the vault key is not 32 bytes
+var ErrInvalidKey = errors.New("card vault key must be 32 bytes")
+
+// Vault protects card secrets. Card numbers are sealed with AES-GCM so they
+// can be read back; CVVs, activation codes and card numbers used as lookup
+// keys are hashed with an HMAC so they can only be compared.
+type Vault struct {
+	aead    cipher.AEAD
+	hashKey []byte
+}
+
+// NewVault creates a Vault from a 32-byte key
+func NewVault(key []byte) (*Vault, error) {
+	if len(key) != 32 {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// The hash key is derived so the two uses of the key stay separate
+	hashKey := sha256.Sum256(append([]byte("card-hash:"), key...))
+	return &Vault{
+		aead:    aead,
+		hashKey: hashKey[:],
+	}, nil
+}
+
+// Seal encrypts a card number; the random nonce is stored in front of the ciphertext
+func (v *Vault) Seal(pan string) ([]byte, error) {
+	nonce := make([]byte, v.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return v.aead.Seal(nonce, nonce, []byte(pan), nil), nil
+}
+
+// Open decrypts a card number sealed by Seal
+func (v *Vault) Open(sealed []byte) (string, error) {
+	size := v.aead.NonceSize()
+	if len(sealed) < size {
+		return "", fmt.Errorf("sealed card number is too short")
+	}
+	pan, err := v.aead.Open(nil, sealed[:size], sealed[size:], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(pan), nil
+}
+
+// Hash returns the keyed hash of a secret. The scope, such as the card ID,
+// keeps equal secrets of different cards from having equal hashes.
+func (v *Vault) Hash(scope, secret string) string {
+	mac := hmac.New(sha256.New, v.hashKey)
+	fmt.Fprintf(mac, "%s:%s", scope, secret)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Matches reports whether secret has the hash, in constant time
+func (v *Vault) Matches(hash, scope, secret string) bool {
+	return hmac.Equal([]byte(hash), []byte(v.Hash(scope, secret)))
+}
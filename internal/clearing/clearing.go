@@ -0,0 +1,402 @@
+package clearing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/interbank"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/transfers"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidBank is returned when the bank code is not three digits
+	ErrInvalidBank = errors.New("to_bank_code must be a three-digit bank code")
+	// ErrOwnBank is returned for a transfer to this bank, which is an internal transfer
+	ErrOwnBank = errors.New("the account is with this bank; use an internal transfer")
+	// ErrInvalidAccountNumber is returned when the account number is not 10 to 15 digits
+	ErrInvalidAccountNumber = errors.New("to_account_number must be 10 to 15 digits")
+	// ErrTransferNotFound is returned when the transfer does not exist for the account
+	ErrTransferNotFound = errors.New("interbank transfer not found")
+)
+
+// noResponseReason is the return reason of transfers the switch never answered
+const noResponseReason = "no response from the switch"
+
+// Transferrer posts transfers between accounts
+type Transferrer interface {
+	Transfer(ctx context.Context, input transfers.Input) (*models.TransferResult, error)
+}
+
+// Service sends transfers to other banks through the switch. The customer
+// is debited into the clearing account by a pending transaction and the
+// instruction is sent; when the switch answers, the funds move on to the
+// settlement account or back to the customer and the debit becomes settled
+// or returned. Reconciliation returns what the switch never answered.
+type Service struct {
+	repo        repository.InterbankRepository
+	accountRepo repository.AccountRepository
+	ledgerRepo  repository.LedgerRepository
+	transfers   Transferrer
+	connector   interbank.Connector
+	cfg         config.InterbankConfig
+	now         func() time.Time
+}
+
+// NewService creates a new clearing Service
+func NewService(repo repository.InterbankRepository, accountRepo repository.AccountRepository, ledgerRepo repository.LedgerRepository, transferrer Transferrer, connector interbank.Connector, cfg config.InterbankConfig) *Service {
+	return &Service{
+		repo:        repo,
+		accountRepo: accountRepo,
+		ledgerRepo:  ledgerRepo,
+		transfers:   transferrer,
+		connector:   connector,
+		cfg:         cfg,
+		now:         time.Now,
+	}
+}
+
+// Send debits the account into clearing and sends the instruction to the
+// switch. The transfer is returned pending; if the switch cannot be reached
+// the instruction is sent again by the next poll.
+func (s *Service) Send(ctx context.Context, from *models.Account, customerID uuid.UUID, req models.InterbankTransferRequest) (*models.InterbankTransfer, error) {
+	bankCode := strings.TrimSpace(req.ToBankCode)
+	if !models.IsDigits(bankCode) || len(bankCode) != 3 {
+		return nil, ErrInvalidBank
+	}
+	if bankCode == s.cfg.BankCode {
+		return nil, ErrOwnBank
+	}
+	accountNumber := strings.NewReplacer("-", "", " ", "").Replace(req.ToAccountNumber)
+	if !models.IsDigits(accountNumber) || len(accountNumber) < 10 || len(accountNumber) > 15 {
+		return nil, ErrInvalidAccountNumber
+	}
+	if from.Currency != "" && from.Currency != models.BaseCurrency {
+		return nil, transfers.ErrCurrencyMismatch
+	}
+
+	clearingAccount, err := repository.GetInternalAccount(ctx, s.accountRepo, models.GLInterbankClearing)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer := &models.InterbankTransfer{
+		ID:              uuid.New(),
+		CustomerID:      customerID,
+		FromAccountID:   from.ID,
+		ToBankCode:      bankCode,
+		ToAccountNumber: accountNumber,
+		Amount:          req.Amount,
+		Description:     strings.TrimSpace(req.Description),
+		Status:          models.LedgerPending,
+	}
+	reference := strings.TrimSpace(req.Reference)
+	if reference == "" {
+		reference = transfer.ID.String()
+	}
+	description := transfer.Description
+	if description == "" {
+		description = fmt.Sprintf("Transfer to %s account %s", bankCode, accountNumber)
+	}
+
+	result, err := s.transfers.Transfer(ctx, transfers.Input{
+		From:        from,
+		To:          clearingAccount,
+		Amount:      req.Amount,
+		Description: description,
+		Reference:   reference,
+		Type:        models.LedgerInterbankTransfer,
+		CustomerID:  customerID,
+		Channel:     models.ChannelMobile,
+		Status:      models.LedgerPending,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	transfer.TransactionID = result.TransactionID
+	transfer.CreatedAt = now
+	transfer.UpdatedAt = now
+	if err := s.repo.CreateInterbankTransfer(ctx, transfer); err != nil {
+		// The debit stays in clearing and is reversed by reconciliation
+		return nil, err
+	}
+
+	if err := s.submit(ctx, from.AccountNumber, transfer); err != nil {
+		log.Printf("failed to send interbank transfer %s, will retry: %v", transfer.ID, err)
+	}
+	return transfer, nil
+}
+
+// Get returns a transfer made from the account
+func (s *Service) Get(ctx context.Context, accountID, transferID uuid.UUID) (*models.InterbankTransfer, error) {
+	transfer, err := s.repo.GetInterbankTransfer(ctx, transferID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer == nil || transfer.FromAccountID != accountID {
+		return nil, ErrTransferNotFound
+	}
+	return transfer, nil
+}
+
+// Poll sends the instructions that could not be sent before and applies the
+// responses of the switch. It returns the number of transfers resolved.
+func (s *Service) Poll(ctx context.Context) (int, error) {
+	unsent, err := s.repo.GetUnsubmittedInterbankTransfers(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, transfer := range unsent {
+		from, err := s.accountRepo.GetAccountByID(ctx, transfer.FromAccountID)
+		if err != nil {
+			return 0, err
+		}
+		if from == nil {
+			continue
+		}
+		if err := s.submit(ctx, from.AccountNumber, transfer); err != nil {
+			log.Printf("failed to send interbank transfer %s, will retry: %v", transfer.ID, err)
+		}
+	}
+
+	responses, err := s.connector.Responses(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	resolved := 0
+	for _, response := range responses {
+		applied, err := s.apply(ctx, response)
+		if err != nil {
+			// Not acknowledged, so the response is applied again on the next poll
+			log.Printf("failed to apply interbank response %s: %v", response.Reference, err)
+			continue
+		}
+		if applied {
+			resolved++
+		}
+		if err := s.connector.Acknowledge(ctx, response); err != nil {
+			return resolved, err
+		}
+	}
+	return resolved, nil
+}
+
+// Reconcile clears the debits left pending in the clearing account for
+// longer than the configured wait. Transfers the switch never answered are
+// returned to the customer, and debits with no transfer, left by a failure
+// while the transfer was being made, are reversed. It returns the number
+// of items cleared.
+func (s *Service) Reconcile(ctx context.Context) (int, error) {
+	clearingAccount, err := repository.GetInternalAccount(ctx, s.accountRepo, models.GLInterbankClearing)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := s.now().Add(-time.Duration(s.cfg.ReturnAfterMinutes) * time.Minute)
+	pending, err := s.ledgerRepo.GetPendingTransactions(ctx, clearingAccount.ID, cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	cleared := 0
+	for _, txn := range pending {
+		transfer, err := s.repo.GetInterbankTransferByTransaction(ctx, txn.ID)
+		if err != nil {
+			return cleared, err
+		}
+
+		switch {
+		case transfer == nil:
+			err = s.reverseOrphan(ctx, clearingAccount, txn)
+		case transfer.Status == models.LedgerPending:
+			_, err = s.returnTransfer(ctx, transfer, noResponseReason)
+		default:
+			// Resolved, but the debit was not updated before a failure
+			_, err = s.ledgerRepo.UpdateTransactionStatus(ctx, txn.ID, models.LedgerPending, transfer.Status)
+		}
+		if err != nil {
+			return cleared, err
+		}
+		cleared++
+	}
+	return cleared, nil
+}
+
+// submit sends the instruction for a transfer and records when it was sent
+func (s *Service) submit(ctx context.Context, fromAccountNumber string, transfer *models.InterbankTransfer) error {
+	err := s.connector.Submit(ctx, interbank.Instruction{
+		Reference:         transfer.ID.String(),
+		FromBankCode:      s.cfg.BankCode,
+		FromAccountNumber: fromAccountNumber,
+		ToBankCode:        transfer.ToBankCode,
+		ToAccountNumber:   transfer.ToAccountNumber,
+		Amount:            transfer.Amount,
+		CreatedAt:         transfer.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	if err := s.repo.MarkInterbankTransferSubmitted(ctx, transfer.ID, now); err != nil {
+		return err
+	}
+	transfer.SubmittedAt = &now
+	transfer.UpdatedAt = now
+	return nil
+}
+
+// apply settles or returns the transfer a response is about and reports
+// whether it was still pending
+func (s *Service) apply(ctx context.Context, response interbank.Response) (bool, error) {
+	id, err := uuid.Parse(response.Reference)
+	if err != nil {
+		log.Printf("ignoring interbank response with unknown reference %q", response.Reference)
+		return false, nil
+	}
+	transfer, err := s.repo.GetInterbankTransfer(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if transfer == nil {
+		log.Printf("ignoring interbank response with unknown reference %q", response.Reference)
+		return false, nil
+	}
+
+	if transfer.Status != models.LedgerPending {
+		if transfer.Status == models.LedgerReturned && response.Outcome == interbank.OutcomeSettled {
+			// The customer was refunded but the other bank paid: recover from the other bank
+			log.Printf("interbank transfer %s was settled after it was returned; recover the funds from bank %s",
+				transfer.ID, transfer.ToBankCode)
+		}
+		return false, nil
+	}
+
+	switch response.Outcome {
+	case interbank.OutcomeSettled:
+		return s.settleTransfer(ctx, transfer)
+	case interbank.OutcomeReturned:
+		reason := response.Reason
+		if reason == "" {
+			reason = "returned by the receiving bank"
+		}
+		return s.returnTransfer(ctx, transfer, reason)
+	}
+	log.Printf("ignoring interbank response %s with unknown outcome %q", response.Reference, response.Outcome)
+	return false, nil
+}
+
+// settleTransfer moves the funds of a transfer from clearing to the settlement account
+func (s *Service) settleTransfer(ctx context.Context, transfer *models.InterbankTransfer) (bool, error) {
+	clearingAccount, err := repository.GetInternalAccount(ctx, s.accountRepo, models.GLInterbankClearing)
+	if err != nil {
+		return false, err
+	}
+	settlement, err := repository.GetInternalAccount(ctx, s.accountRepo, models.GLInterbankSettlement)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := s.transfers.Transfer(ctx, transfers.Input{
+		From:        clearingAccount,
+		To:          settlement,
+		Amount:      transfer.Amount,
+		Description: fmt.Sprintf("Settlement of transfer to %s account %s", transfer.ToBankCode, transfer.ToAccountNumber),
+		Reference:   "interbank-settle:" + transfer.ID.String(),
+		Type:        models.LedgerInterbankSettlement,
+	})
+	if err != nil && !errors.Is(err, transfers.ErrAlreadyProcessed) {
+		return false, err
+	}
+	return s.resolve(ctx, transfer, models.LedgerSettled, "", result)
+}
+
+// returnTransfer moves the funds of a transfer from clearing back to the customer
+func (s *Service) returnTransfer(ctx context.Context, transfer *models.InterbankTransfer, reason string) (bool, error) {
+	clearingAccount, err := repository.GetInternalAccount(ctx, s.accountRepo, models.GLInterbankClearing)
+	if err != nil {
+		return false, err
+	}
+	from, err := s.accountRepo.GetAccountByID(ctx, transfer.FromAccountID)
+	if err != nil {
+		return false, err
+	}
+	if from == nil {
+		return false, transfers.ErrDestinationNotFound
+	}
+
+	result, err := s.transfers.Transfer(ctx, transfers.Input{
+		From:        clearingAccount,
+		To:          from,
+		Amount:      transfer.Amount,
+		Description: fmt.Sprintf("Return of transfer to %s account %s: %s", transfer.ToBankCode, transfer.ToAccountNumber, reason),
+		Reference:   "interbank-return:" + transfer.ID.String(),
+		Type:        models.LedgerInterbankRefund,
+	})
+	if err != nil && !errors.Is(err, transfers.ErrAlreadyProcessed) {
+		return false, err
+	}
+	return s.resolve(ctx, transfer, models.LedgerReturned, reason, result)
+}
+
+// resolve marks the debit and the transfer with their final status
+func (s *Service) resolve(ctx context.Context, transfer *models.InterbankTransfer, status models.LedgerTransactionStatus, reason string, result *models.TransferResult) (bool, error) {
+	if _, err := s.ledgerRepo.UpdateTransactionStatus(ctx, transfer.TransactionID, models.LedgerPending, status); err != nil {
+		return false, err
+	}
+
+	now := s.now()
+	transfer.Status = status
+	transfer.ReturnReason = reason
+	transfer.ResolvedAt = &now
+	transfer.UpdatedAt = now
+	if result != nil {
+		transfer.ResolutionTransactionID = &result.TransactionID
+	}
+	return s.repo.ResolveInterbankTransfer(ctx, transfer)
+}
+
+// reverseOrphan returns a clearing debit that has no transfer to the account it came from
+func (s *Service) reverseOrphan(ctx context.Context, clearingAccount *models.Account, txn *models.LedgerTransaction) error {
+	var debit *models.LedgerEntry
+	for i, entry := range txn.Entries {
+		if entry.AccountID != clearingAccount.ID && entry.Direction == models.EntryDebit {
+			debit = &txn.Entries[i]
+			break
+		}
+	}
+	if debit == nil {
+		return fmt.Errorf("pending transaction %s has no debit to reverse", txn.ID)
+	}
+	from, err := s.accountRepo.GetAccountByID(ctx, debit.AccountID)
+	if err != nil {
+		return err
+	}
+	if from == nil {
+		return fmt.Errorf("account %s of pending transaction %s not found", debit.AccountID, txn.ID)
+	}
+
+	_, err = s.transfers.Transfer(ctx, transfers.Input{
+		From:        clearingAccount,
+		To:          from,
+		Amount:      debit.Amount,
+		Description: "Reversal of incomplete interbank transfer",
+		Reference:   "interbank-reverse:" + txn.ID.String(),
+		Type:        models.LedgerInterbankRefund,
+	})
+	if err != nil && !errors.Is(err, transfers.ErrAlreadyProcessed) {
+		return err
+	}
+	_, err = s.ledgerRepo.UpdateTransactionStatus(ctx, txn.ID, models.LedgerPending, models.LedgerReturned)
+	return err
+}
@@ -0,0 +1,285 @@
+package clearing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/interbank"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/repository/repotest"
+	"example.com/m/internal/transfers"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubInterbankRepository keeps transfers in memory
+type stubInterbankRepository struct {
+	repository.InterbankRepository
+	transfers map[uuid.UUID]models.InterbankTransfer
+}
+
+func (r *stubInterbankRepository) CreateInterbankTransfer(ctx context.Context, transfer *models.InterbankTransfer) error {
+	r.transfers[transfer.ID] = *transfer
+	return nil
+}
+
+func (r *stubInterbankRepository) GetInterbankTransfer(ctx context.Context, id uuid.UUID) (*models.InterbankTransfer, error) {
+	transfer, ok := r.transfers[id]
+	if !ok {
+		return nil, nil
+	}
+	return &transfer, nil
+}
+
+func (r *stubInterbankRepository) GetInterbankTransferByTransaction(ctx context.Context, transactionID uuid.UUID) (*models.InterbankTransfer, error) {
+	for _, transfer := range r.transfers {
+		if transfer.TransactionID == transactionID {
+			return &transfer, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *stubInterbankRepository) GetUnsubmittedInterbankTransfers(ctx context.Context) ([]*models.InterbankTransfer, error) {
+	unsent := []*models.InterbankTransfer{}
+	for _, transfer := range r.transfers {
+		if transfer.Status == models.LedgerPending && transfer.SubmittedAt == nil {
+			transfer := transfer
+			unsent = append(unsent, &transfer)
+		}
+	}
+	return unsent, nil
+}
+
+func (r *stubInterbankRepository) MarkInterbankTransferSubmitted(ctx context.Context, id uuid.UUID, at time.Time) error {
+	transfer := r.transfers[id]
+	transfer.SubmittedAt = &at
+	r.transfers[id] = transfer
+	return nil
+}
+
+func (r *stubInterbankRepository) ResolveInterbankTransfer(ctx context.Context, transfer *models.InterbankTransfer) (bool, error) {
+	if r.transfers[transfer.ID].Status != models.LedgerPending {
+		return false, nil
+	}
+	r.transfers[transfer.ID] = *transfer
+	return true, nil
+}
+
+// stubLedger records the transactions posted through it and their status
+type stubLedger struct {
+	repository.LedgerRepository
+	transactions map[uuid.UUID]*models.LedgerTransaction
+	inputs       []transfers.Input
+}
+
+func (l *stubLedger) Transfer(ctx context.Context, input transfers.Input) (*models.TransferResult, error) {
+	for _, posted := range l.inputs {
+		if posted.Reference == input.Reference {
+			return nil, transfers.ErrAlreadyProcessed
+		}
+	}
+	l.inputs = append(l.inputs, input)
+
+	status := input.Status
+	if status == "" {
+		status = models.LedgerSettled
+	}
+	txn := &models.LedgerTransaction{
+		ID:        uuid.New(),
+		Reference: input.Reference,
+		Type:      input.Type,
+		Status:    status,
+		CreatedAt: time.Now(),
+		Entries: []models.LedgerEntry{
+			{AccountID: input.From.ID, Direction: models.EntryDebit, Amount: input.Amount},
+			{AccountID: input.To.ID, Direction: models.EntryCredit, Amount: input.Amount},
+		},
+	}
+	l.transactions[txn.ID] = txn
+	return &models.TransferResult{TransactionID: txn.ID, Amount: input.Amount}, nil
+}
+
+func (l *stubLedger) GetPendingTransactions(ctx context.Context, accountID uuid.UUID, before time.Time) ([]*models.LedgerTransaction, error) {
+	pending := []*models.LedgerTransaction{}
+	for _, txn := range l.transactions {
+		if txn.Status == models.LedgerPending && txn.CreatedAt.Before(before) {
+			pending = append(pending, txn)
+		}
+	}
+	return pending, nil
+}
+
+func (l *stubLedger) UpdateTransactionStatus(ctx context.Context, id uuid.UUID, from, to models.LedgerTransactionStatus) (bool, error) {
+	txn, ok := l.transactions[id]
+	if !ok || txn.Status != from {
+		return false, nil
+	}
+	txn.Status = to
+	return true, nil
+}
+
+// failingConnector cannot reach the switch
+type failingConnector struct {
+	interbank.Connector
+}
+
+func (c *failingConnector) Submit(ctx context.Context, instruction interbank.Instruction) error {
+	return errors.New("switch unavailable")
+}
+
+type fixture struct {
+	service    *Service
+	repo       *stubInterbankRepository
+	ledger     *stubLedger
+	payer      *models.Account
+	clearing   *models.Account
+	settlement *models.Account
+	customerID uuid.UUID
+}
+
+func newFixture(connector interbank.Connector) *fixture {
+	customerID := uuid.New()
+	f := &fixture{
+		repo:       &stubInterbankRepository{transfers: map[uuid.UUID]models.InterbankTransfer{}},
+		ledger:     &stubLedger{transactions: map[uuid.UUID]*models.LedgerTransaction{}},
+		payer:      repotest.CustomerAccount(customerID, "100-1-00001-1", 0),
+		clearing:   repotest.InternalAccount(models.GLInterbankClearing),
+		settlement: repotest.InternalAccount(models.GLInterbankSettlement),
+		customerID: customerID,
+	}
+	accounts := &repotest.Accounts{Accounts: []*models.Account{f.payer, f.clearing, f.settlement}}
+	cfg := config.Default().Interbank
+	if connector == nil {
+		connector = interbank.NewMemoryConnector(cfg)
+	}
+	f.service = NewService(f.repo, accounts, f.ledger, f.ledger, connector, cfg)
+	return f
+}
+
+func TestSendValidatesRecipient(t *testing.T) {
+	f := newFixture(nil)
+	ctx := context.Background()
+
+	_, err := f.service.Send(ctx, f.payer, f.customerID, models.InterbankTransferRequest{ToBankCode: "99", ToAccountNumber: "1234567890", Amount: 100})
+	assert.ErrorIs(t, err, ErrInvalidBank)
+
+	_, err = f.service.Send(ctx, f.payer, f.customerID, models.InterbankTransferRequest{ToBankCode: "099", ToAccountNumber: "1234567890", Amount: 100})
+	assert.ErrorIs(t, err, ErrOwnBank)
+
+	_, err = f.service.Send(ctx, f.payer, f.customerID, models.InterbankTransferRequest{ToBankCode: "002", ToAccountNumber: "12-34", Amount: 100})
+	assert.ErrorIs(t, err, ErrInvalidAccountNumber)
+
+	assert.Empty(t, f.ledger.inputs)
+}
+
+func TestSettledAndReturnedTransfers(t *testing.T) {
+	tests := []struct {
+		name          string
+		bankCode      string
+		accountNumber string
+		status        models.LedgerTransactionStatus
+		resolvedTo    func(f *fixture) uuid.UUID
+	}{
+		{"settled", "002", "123-456-7890", models.LedgerSettled, func(f *fixture) uuid.UUID { return f.settlement.ID }},
+		{"rejected by the bank", "014", "3456789012", models.LedgerReturned, func(f *fixture) uuid.UUID { return f.payer.ID }},
+		{"unknown account", "002", "9999999999", models.LedgerReturned, func(f *fixture) uuid.UUID { return f.payer.ID }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(nil)
+			ctx := context.Background()
+
+			transfer, err := f.service.Send(ctx, f.payer, f.customerID, models.InterbankTransferRequest{ToBankCode: tt.bankCode, ToAccountNumber: tt.accountNumber, Amount: 500})
+			require.NoError(t, err)
+			assert.Equal(t, models.LedgerPending, transfer.Status)
+			assert.NotNil(t, transfer.SubmittedAt)
+			require.Len(t, f.ledger.inputs, 1)
+			assert.Equal(t, f.clearing.ID, f.ledger.inputs[0].To.ID)
+			assert.Equal(t, models.LedgerPending, f.ledger.transactions[transfer.TransactionID].Status)
+
+			resolved, err := f.service.Poll(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 1, resolved)
+
+			stored := f.repo.transfers[transfer.ID]
+			assert.Equal(t, tt.status, stored.Status)
+			assert.Equal(t, tt.status, f.ledger.transactions[transfer.TransactionID].Status)
+			require.Len(t, f.ledger.inputs, 2)
+			assert.Equal(t, f.clearing.ID, f.ledger.inputs[1].From.ID)
+			assert.Equal(t, tt.resolvedTo(f), f.ledger.inputs[1].To.ID)
+			require.NotNil(t, stored.ResolutionTransactionID)
+			if tt.status == models.LedgerReturned {
+				assert.NotEmpty(t, stored.ReturnReason)
+			}
+
+			// The response was acknowledged
+			resolved, err = f.service.Poll(ctx)
+			require.NoError(t, err)
+			assert.Zero(t, resolved)
+		})
+	}
+}
+
+func TestUnsentInstructionIsSentByPoll(t *testing.T) {
+	f := newFixture(&failingConnector{})
+	ctx := context.Background()
+
+	transfer, err := f.service.Send(ctx, f.payer, f.customerID, models.InterbankTransferRequest{ToBankCode: "002", ToAccountNumber: "1234567890", Amount: 100})
+	require.NoError(t, err)
+	assert.Nil(t, transfer.SubmittedAt)
+
+	f.service.connector = interbank.NewMemoryConnector(config.Default().Interbank)
+	resolved, err := f.service.Poll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, resolved)
+	assert.Equal(t, models.LedgerSettled, f.repo.transfers[transfer.ID].Status)
+}
+
+func TestReconcileReturnsUnansweredAndOrphanedDebits(t *testing.T) {
+	f := newFixture(nil)
+	ctx := context.Background()
+
+	// The switch never answers transfers to this account
+	unanswered, err := f.service.Send(ctx, f.payer, f.customerID, models.InterbankTransferRequest{ToBankCode: "004", ToAccountNumber: "2345678901", Amount: 300})
+	require.NoError(t, err)
+
+	// A debit whose transfer was never recorded
+	orphan, err := f.ledger.Transfer(ctx, transfers.Input{From: f.payer, To: f.clearing, Amount: 75, Reference: "lost", Status: models.LedgerPending})
+	require.NoError(t, err)
+
+	resolved, err := f.service.Poll(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, resolved)
+
+	// Nothing has waited long enough yet
+	cleared, err := f.service.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, cleared)
+
+	f.service.now = func() time.Time { return time.Now().Add(5 * time.Hour) }
+	cleared, err = f.service.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, cleared)
+
+	stored := f.repo.transfers[unanswered.ID]
+	assert.Equal(t, models.LedgerReturned, stored.Status)
+	assert.Equal(t, noResponseReason, stored.ReturnReason)
+	assert.Equal(t, models.LedgerReturned, f.ledger.transactions[unanswered.TransactionID].Status)
+	assert.Equal(t, models.LedgerReturned, f.ledger.transactions[orphan.TransactionID].Status)
+
+	refunds := f.ledger.inputs[len(f.ledger.inputs)-2:]
+	for _, refund := range refunds {
+		assert.Equal(t, f.clearing.ID, refund.From.ID)
+		assert.Equal(t, f.payer.ID, refund.To.ID)
+	}
+
+	cleared, err = f.service.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, cleared)
+}
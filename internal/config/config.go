@@ -0,0 +1,341 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Config holds the tunable business settings of the application.
+// Values missing from the config file keep their defaults.
+type Config struct {
+	Loans          LoanConfig          `json:"loans"`
+	Delinquency    DelinquencyConfig   `json:"delinquency"`
+	Interest       InterestConfig      `json:"interest"`
+	FixedDeposits  FixedDepositConfig  `json:"fixed_deposits"`
+	Fees           FeeConfig           `json:"fees"`
+	Statements     StatementConfig     `json:"statements"`
+	Holds          HoldConfig          `json:"holds"`
+	Dormancy       DormancyConfig      `json:"dormancy"`
+	Identity       IdentityConfig      `json:"identity"`
+	JointAccounts  JointAccountConfig  `json:"joint_accounts"`
+	FX             FXConfig            `json:"fx"`
+	Limits         LimitConfig         `json:"limits"`
+	StandingOrders StandingOrderConfig `json:"standing_orders"`
+	Proxy          ProxyConfig         `json:"proxy"`
+	Interbank      InterbankConfig     `json:"interbank"`
+	BulkPayments   BulkPaymentConfig   `json:"bulk_payments"`
+	Cards          CardConfig          `json:"cards"`
+}
+
+// LoanConfig holds the terms used when an approved application is booked as a loan
+type LoanConfig struct {
+	AnnualRate  float64 `json:"annual_rate"`
+	TenorMonths int     `json:"tenor_months"`
+}
+
+// DelinquencyConfig holds the late-payment rules applied by the daily delinquency job
+type DelinquencyConfig struct {
+	// GraceDays is the number of days after the due date before charges apply
+	GraceDays int `json:"grace_days"`
+	// LateFee is charged once per overdue installment
+	LateFee float64 `json:"late_fee"`
+	// PenaltyAnnualRate is accrued daily (actual/365) on the overdue amount
+	PenaltyAnnualRate float64 `json:"penalty_annual_rate"`
+	// RunAt is the local time of day ("15:04") the job runs
+	RunAt string `json:"run_at"`
+}
+
+// InterestConfig holds the savings interest accrual settings
+type InterestConfig struct {
+	// Capitalization is how often accrued interest is posted: monthly,
+	// quarterly, semi_annual or annual, always on the last day of the month
+	Capitalization string `json:"capitalization"`
+	// WithholdingTaxRate is deducted from interest when it is posted
+	WithholdingTaxRate float64 `json:"withholding_tax_rate"`
+	// RunAt is the local time of day ("15:04") the end-of-day batch runs
+	RunAt string `json:"run_at"`
+}
+
+// FixedDepositConfig holds the fixed deposit maturity batch settings
+type FixedDepositConfig struct {
+	// RunAt is the local time of day ("15:04") the maturity batch runs
+	RunAt string `json:"run_at"`
+}
+
+// FeeConfig holds the monthly account fee batch settings
+type FeeConfig struct {
+	// RunAt is the local time of day ("15:04") the fee batch runs; fees are
+	// charged on the last day of the month only
+	RunAt string `json:"run_at"`
+}
+
+// StatementConfig holds the account statement settings
+type StatementConfig struct {
+	// FontPath is a TrueType font with Thai glyphs used to render PDF statements;
+	// when empty, statements are rendered as CSV only
+	FontPath string `json:"font_path"`
+	// DownloadURLTTLSeconds is how long a signed download link stays valid
+	DownloadURLTTLSeconds int `json:"download_url_ttl_seconds"`
+	// MaxDays is the longest period a statement or history query may cover
+	MaxDays int `json:"max_days"`
+}
+
+// HoldConfig holds the account hold settings
+type HoldConfig struct {
+	// SweepIntervalSeconds is how often expired holds are released
+	SweepIntervalSeconds int `json:"sweep_interval_seconds"`
+}
+
+// IdentityConfig holds the limits on identity checks customers make by
+// re-entering their identity details
+type IdentityConfig struct {
+	// MaxAttempts is how many checks a customer can make within LockoutMinutes
+	// before they are refused; a successful check resets the count
+	MaxAttempts int `json:"max_attempts"`
+	// LockoutMinutes is how long the count runs from a customer's first check
+	LockoutMinutes int `json:"lockout_minutes"`
+}
+
+// DormancyConfig holds the rules for marking inactive accounts dormant
+type DormancyConfig struct {
+	// InactiveDays is how long an account may go without customer-initiated activity before it becomes dormant
+	InactiveDays int `json:"inactive_days"`
+	// WarningDays is how far ahead the staff report lists accounts approaching dormancy
+	WarningDays int `json:"warning_days"`
+	// MaxVerificationAttempts is how many failed in-app re-verifications are allowed before a staff step is required
+	MaxVerificationAttempts int `json:"max_verification_attempts"`
+	// RunAt is the local time of day ("15:04") the dormancy job runs
+	RunAt string `json:"run_at"`
+}
+
+// JointAccountConfig holds the settings for accounts with several holders
+type JointAccountConfig struct {
+	// ApprovalTTLHours is how long co-owners have to confirm a transfer from an all-must-sign account
+	ApprovalTTLHours int `json:"approval_ttl_hours"`
+}
+
+// FXConfig holds the currency conversion settings
+type FXConfig struct {
+	// QuoteTTLSeconds is how long a conversion quote can be executed at its rate
+	QuoteTTLSeconds int `json:"quote_ttl_seconds"`
+	// MaxSpread is the largest buy or sell spread, as a fraction of the mid rate, staff may upload
+	MaxSpread float64 `json:"max_spread"`
+}
+
+// LimitConfig holds the transaction limits of each customer tier
+type LimitConfig struct {
+	// Rules are matched on tier, channel and type; a rule with an empty tier
+	// applies to every tier without a rule of its own. Payments with no
+	// matching rule are not limited.
+	Rules []LimitRule `json:"rules"`
+}
+
+// LimitRule is the per-transaction and daily limit, in the base currency,
+// of one kind of payment through one channel
+type LimitRule struct {
+	Tier           string  `json:"tier,omitempty"`
+	Channel        string  `json:"channel"`
+	Type           string  `json:"type"`
+	PerTransaction float64 `json:"per_transaction"`
+	Daily          float64 `json:"daily"`
+}
+
+// StandingOrderConfig holds the scheduled transfer settings
+type StandingOrderConfig struct {
+	// RunIntervalSeconds is how often the scheduler looks for due orders
+	RunIntervalSeconds int `json:"run_interval_seconds"`
+	// MaxAttempts is how many times a run that failed for insufficient funds is tried before it is skipped
+	MaxAttempts int `json:"max_attempts"`
+	// RetryIntervalMinutes is how long the scheduler waits before trying a failed run again
+	RetryIntervalMinutes int `json:"retry_interval_minutes"`
+}
+
+// ProxyConfig holds the settings of transfers to mobile numbers and national IDs
+type ProxyConfig struct {
+	// ConfirmTTLSeconds is how long a resolved recipient can be confirmed before the proxy must be looked up again
+	ConfirmTTLSeconds int `json:"confirm_ttl_seconds"`
+}
+
+// BulkPaymentConfig holds the settings of payments uploaded as a CSV file
+type BulkPaymentConfig struct {
+	// MaxRows is the largest number of payments accepted in one file
+	MaxRows int `json:"max_rows"`
+	// RunIntervalSeconds is how often confirmed files are picked up for execution
+	RunIntervalSeconds int `json:"run_interval_seconds"`
+}
+
+// CardConfig holds the settings of card issuing
+type CardConfig struct {
+	// BIN is the issuer prefix every card number starts with
+	BIN string `json:"bin"`
+	// PANLength is the number of digits of a card number, including the check digit
+	PANLength int `json:"pan_length"`
+	// ValidityYears is how long a new card is valid, to the end of its expiry month
+	ValidityYears int `json:"validity_years"`
+	// MaxActivationAttempts is how many wrong activation codes lock a card out of activation
+	MaxActivationAttempts int `json:"max_activation_attempts"`
+	// ATMDailyLimit and POSDailyLimit cap what one card may withdraw at ATMs
+	// and spend on purchases, in store or online, per calendar day. They are
+	// the defaults of the card's own limits and the most these can be set to.
+	ATMDailyLimit float64 `json:"atm_daily_limit"`
+	POSDailyLimit float64 `json:"pos_daily_limit"`
+	// AuthorizationHoldDays is how long an approved authorization holds funds while waiting for clearing
+	AuthorizationHoldDays int `json:"authorization_hold_days"`
+	// HomeCountry is the two-letter ISO code of the country where card use is not international
+	HomeCountry string `json:"home_country"`
+}
+
+// InterbankConfig holds the settings of the connection to the interbank switch
+type InterbankConfig struct {
+	// BankCode identifies this bank on the switch
+	BankCode string `json:"bank_code"`
+	// Connector is how outbound transfers reach the switch: memory, an
+	// in-process fake answering from Simulator, or file, which exchanges
+	// instruction and response files through OutboxDir and InboxDir
+	Connector string `json:"connector"`
+	OutboxDir string `json:"outbox_dir"`
+	InboxDir  string `json:"inbox_dir"`
+	// PollIntervalSeconds is how often unsent instructions are sent and responses applied
+	PollIntervalSeconds int `json:"poll_interval_seconds"`
+	// ReturnAfterMinutes is how long a transfer may wait for the switch before reconciliation returns it
+	ReturnAfterMinutes int `json:"return_after_minutes"`
+	// ReconcileAt is the local time of day ("15:04") the clearing account is reconciled
+	ReconcileAt string `json:"reconcile_at"`
+	// Simulator lists the proxies of other banks known to the local switch stand-in
+	Simulator []SimulatedProxy `json:"simulator"`
+}
+
+// SimulatedProxy is a proxy registered at another bank, with the outcome the
+// local switch stand-in gives to transfers sent to it
+type SimulatedProxy struct {
+	ProxyType     string `json:"proxy_type"`
+	ProxyValue    string `json:"proxy_value"`
+	BankCode      string `json:"bank_code"`
+	AccountNumber string `json:"account_number"`
+	FirstName     string `json:"first_name"`
+	LastName      string `json:"last_name"`
+	// Outcome is success, timeout or reject
+	Outcome string `json:"outcome"`
+}
+
+// Default returns the built-in configuration
+func Default() *Config {
+	return &Config{
+		Loans: LoanConfig{
+			AnnualRate:  0.18,
+			TenorMonths: 36,
+		},
+		Delinquency: DelinquencyConfig{
+			GraceDays:         3,
+			LateFee:           100,
+			PenaltyAnnualRate: 0.03,
+			RunAt:             "01:00",
+		},
+		Interest: InterestConfig{
+			Capitalization:     "monthly",
+			WithholdingTaxRate: 0.15,
+			RunAt:              "23:30",
+		},
+		FixedDeposits: FixedDepositConfig{
+			RunAt: "00:30",
+		},
+		Fees: FeeConfig{
+			RunAt: "23:45",
+		},
+		Statements: StatementConfig{
+			FontPath:              "",
+			DownloadURLTTLSeconds: 300,
+			MaxDays:               366,
+		},
+		Holds: HoldConfig{
+			SweepIntervalSeconds: 60,
+		},
+		Dormancy: DormancyConfig{
+			InactiveDays:            365,
+			WarningDays:             30,
+			MaxVerificationAttempts: 3,
+			RunAt:                   "02:00",
+		},
+		Identity: IdentityConfig{
+			MaxAttempts:    5,
+			LockoutMinutes: 1440,
+		},
+		JointAccounts: JointAccountConfig{
+			ApprovalTTLHours: 72,
+		},
+		FX: FXConfig{
+			QuoteTTLSeconds: 60,
+			MaxSpread:       0.05,
+		},
+		Limits: LimitConfig{
+			Rules: []LimitRule{
+				{Channel: "mobile", Type: "transfer", PerTransaction: 50000, Daily: 200000},
+				{Channel: "mobile", Type: "withdrawal", PerTransaction: 20000, Daily: 50000},
+				{Channel: "atm", Type: "transfer", PerTransaction: 50000, Daily: 100000},
+				{Channel: "atm", Type: "withdrawal", PerTransaction: 20000, Daily: 40000},
+				{Channel: "branch", Type: "transfer", PerTransaction: 2000000, Daily: 5000000},
+				{Channel: "branch", Type: "withdrawal", PerTransaction: 2000000, Daily: 5000000},
+				{Tier: "premium", Channel: "mobile", Type: "transfer", PerTransaction: 500000, Daily: 1000000},
+				{Tier: "premium", Channel: "atm", Type: "withdrawal", PerTransaction: 50000, Daily: 100000},
+				{Tier: "private", Channel: "mobile", Type: "transfer", PerTransaction: 2000000, Daily: 5000000},
+				{Tier: "private", Channel: "atm", Type: "withdrawal", PerTransaction: 100000, Daily: 200000},
+			},
+		},
+		StandingOrders: StandingOrderConfig{
+			RunIntervalSeconds:   300,
+			MaxAttempts:          3,
+			RetryIntervalMinutes: 120,
+		},
+		Proxy: ProxyConfig{
+			ConfirmTTLSeconds: 120,
+		},
+		Interbank: InterbankConfig{
+			BankCode:            "099",
+			Connector:           "memory",
+			OutboxDir:           "data/interbank/outbox",
+			InboxDir:            "data/interbank/inbox",
+			PollIntervalSeconds: 30,
+			ReturnAfterMinutes:  240,
+			ReconcileAt:         "22:00",
+			Simulator: []SimulatedProxy{
+				{ProxyType: "mobile", ProxyValue: "0811111111", BankCode: "002", AccountNumber: "1234567890", FirstName: "Somchai", LastName: "Jaidee", Outcome: "success"},
+				{ProxyType: "mobile", ProxyValue: "0822222222", BankCode: "004", AccountNumber: "2345678901", FirstName: "Suda", LastName: "Rakthai", Outcome: "timeout"},
+				{ProxyType: "national_id", ProxyValue: "1101700203450", BankCode: "014", AccountNumber: "3456789012", FirstName: "Anan", LastName: "Wongsa", Outcome: "reject"},
+			},
+		},
+		BulkPayments: BulkPaymentConfig{
+			MaxRows:            2000,
+			RunIntervalSeconds: 30,
+		},
+		Cards: CardConfig{
+			BIN:                   "428999",
+			PANLength:             16,
+			ValidityYears:         5,
+			MaxActivationAttempts: 5,
+			ATMDailyLimit:         20000,
+			POSDailyLimit:         100000,
+			AuthorizationHoldDays: 7,
+			HomeCountry:           "TH",
+		},
+	}
+}
+
+// Load reads the configuration file at path on top of the defaults.
+// A missing file is not an error; the defaults are returned instead.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,212 @@
+package credit
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"example.com/m/internal/models"
+)
+
+// Input contains the data the scoring engine needs to assess an application
+type Input struct {
+	AmountRequested float64
+	IncomeDetails   models.IncomeDetails
+	// ExistingLoanAmounts are the principal amounts of the customer's
+	// existing loan obligations
+	ExistingLoanAmounts []float64
+}
+
+// Engine scores a loan application and returns a credit assessment
+type Engine interface {
+	Assess(input Input) (*models.CreditAssessment, error)
+}
+
+// RuleEngine is the default Engine that scores applications using a Policy
+type RuleEngine struct {
+	source PolicySource
+	now    func() time.Time
+}
+
+// NewRuleEngine creates a RuleEngine that reads rules from source
+func NewRuleEngine(source PolicySource) *RuleEngine {
+	return &RuleEngine{
+		source: source,
+		now:    time.Now,
+	}
+}
+
+// Assess computes the debt-service ratio, score, risk grade and
+// recommended maximum amount for the given input
+func (e *RuleEngine) Assess(input Input) (*models.CreditAssessment, error) {
+	policy, err := e.source.Policy()
+	if err != nil {
+		return nil, err
+	}
+
+	income := input.IncomeDetails.MonthlyIncome + input.IncomeDetails.AdditionalIncome*policy.AdditionalIncomeWeight
+	if income <= 0 {
+		return nil, fmt.Errorf("monthly income must be greater than zero")
+	}
+
+	existing := 0.0
+	for _, amount := range input.ExistingLoanAmounts {
+		existing += Installment(amount, policy.ReferenceAnnualRate, policy.ReferenceTenorMonths)
+	}
+	proposed := Installment(input.AmountRequested, policy.ReferenceAnnualRate, policy.ReferenceTenorMonths)
+	dsr := (existing + proposed) / income
+
+	score := policy.BaseScore
+	reasons := []string{}
+
+	dsrPoints, dsrMatched := ratioPoints(policy.DebtServiceRatioRules, dsr)
+	score += dsrPoints
+	if dsrMatched {
+		reasons = append(reasons, fmt.Sprintf("Debt-service ratio %.0f%% (%+d points)", dsr*100, dsrPoints))
+	} else {
+		reasons = append(reasons, fmt.Sprintf("Debt-service ratio %.0f%% is above all policy bands", dsr*100))
+	}
+
+	empPoints := employmentPoints(policy.EmploymentYearsRules, input.IncomeDetails.EmploymentYears)
+	score += empPoints
+	reasons = append(reasons, fmt.Sprintf("Employment of %d years (%+d points)", input.IncomeDetails.EmploymentYears, empPoints))
+
+	source := strings.ToLower(strings.TrimSpace(input.IncomeDetails.IncomeSource))
+	srcPoints, ok := policy.IncomeSourcePoints[source]
+	if !ok {
+		srcPoints = policy.DefaultSourcePoints
+	}
+	score += srcPoints
+	reasons = append(reasons, fmt.Sprintf("Income source %q (%+d points)", input.IncomeDetails.IncomeSource, srcPoints))
+
+	if len(input.ExistingLoanAmounts) > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d existing loan obligation(s) totalling %.2f per month", len(input.ExistingLoanAmounts), existing))
+	}
+
+	grade := gradeFor(policy.Grades, score)
+	if dsr > policy.MaxDebtServiceRatio {
+		grade = sortedGrades(policy.Grades)[len(policy.Grades)-1]
+		reasons = append(reasons, fmt.Sprintf("Debt-service ratio exceeds the policy maximum of %.0f%%", policy.MaxDebtServiceRatio*100))
+	}
+
+	// Largest amount whose installment still fits within the maximum ratio
+	headroom := math.Max(policy.MaxDebtServiceRatio*income-existing, 0)
+	maxAmount := PrincipalFor(headroom, policy.ReferenceAnnualRate, policy.ReferenceTenorMonths)
+	if policy.MaxIncomeMultiple > 0 {
+		maxAmount = math.Min(maxAmount, policy.MaxIncomeMultiple*income)
+	}
+	maxAmount = math.Floor(maxAmount*grade.LimitFactor/1000) * 1000
+
+	if input.AmountRequested > maxAmount {
+		reasons = append(reasons, fmt.Sprintf("Requested amount exceeds the recommended maximum of %.2f", maxAmount))
+	}
+
+	return &models.CreditAssessment{
+		PolicyVersion:        policy.Version,
+		RiskGrade:            models.RiskGrade(grade.Grade),
+		Score:                score,
+		MonthlyIncome:        round2(income),
+		ExistingObligations:  round2(existing),
+		ProposedInstallment:  round2(proposed),
+		DebtServiceRatio:     math.Round(dsr*10000) / 10000,
+		RecommendedMaxAmount: maxAmount,
+		Reasons:              reasons,
+		AssessedAt:           e.now(),
+	}, nil
+}
+
+// Installment returns the monthly payment for an amortizing loan
+func Installment(principal, annualRate float64, months int) float64 {
+	if months <= 0 || principal <= 0 {
+		return 0
+	}
+	r := annualRate / 12
+	if r == 0 {
+		return principal / float64(months)
+	}
+	return principal * r / (1 - math.Pow(1+r, -float64(months)))
+}
+
+// PrincipalFor returns the principal that can be repaid with the given
+// monthly payment. It is the inverse of Installment.
+func PrincipalFor(payment, annualRate float64, months int) float64 {
+	if months <= 0 || payment <= 0 {
+		return 0
+	}
+	r := annualRate / 12
+	if r == 0 {
+		return payment * float64(months)
+	}
+	return payment * (1 - math.Pow(1+r, -float64(months))) / r
+}
+
+func ratioPoints(rules []RatioRule, dsr float64) (int, bool) {
+	sorted := append([]RatioRule(nil), rules...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].MaxRatio < sorted[j].MaxRatio })
+	for _, rule := range sorted {
+		if dsr <= rule.MaxRatio {
+			return rule.Points, true
+		}
+	}
+	return 0, false
+}
+
+func employmentPoints(rules []EmploymentRule, years int) int {
+	best, points := -1, 0
+	for _, rule := range rules {
+		if years >= rule.MinYears && rule.MinYears > best {
+			best, points = rule.MinYears, rule.Points
+		}
+	}
+	return points
+}
+
+// sortedGrades returns the grades ordered from the best (highest MinScore) to the worst
+func sortedGrades(grades []GradeRule) []GradeRule {
+	sorted := append([]GradeRule(nil), grades...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].MinScore > sorted[j].MinScore })
+	return sorted
+}
+
+func gradeFor(grades []GradeRule, score int) GradeRule {
+	sorted := sortedGrades(grades)
+	for _, grade := range sorted {
+		if score >= grade.MinScore {
+			return grade
+		}
+	}
+	return sorted[len(sorted)-1]
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// AmortizationSchedule splits a loan into equal monthly installments. The
+// first installment is due one month after start; the last one absorbs rounding.
+func AmortizationSchedule(principal, annualRate float64, months int, start time.Time) []models.LoanInstallment {
+	payment := round2(Installment(principal, annualRate, months))
+	r := annualRate / 12
+	remaining := principal
+
+	schedule := make([]models.LoanInstallment, 0, months)
+	for n := 1; n <= months; n++ {
+		interest := round2(remaining * r)
+		principalDue := round2(payment - interest)
+		if n == months {
+			principalDue = round2(remaining)
+		}
+		remaining -= principalDue
+
+		schedule = append(schedule, models.LoanInstallment{
+			InstallmentNumber: n,
+			DueDate:           start.AddDate(0, n, 0),
+			PrincipalDue:      principalDue,
+			InterestDue:       interest,
+			Status:            models.InstallmentPending,
+		})
+	}
+	return schedule
+}
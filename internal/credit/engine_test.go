@@ -0,0 +1,122 @@
+package credit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstallmentRoundTrip(t *testing.T) {
+	payment := Installment(100000, 0.18, 48)
+	assert.InDelta(t, 2937.49, payment, 0.01)
+	assert.InDelta(t, 100000, PrincipalFor(payment, 0.18, 48), 0.01)
+	assert.Equal(t, 1000.0, Installment(12000, 0, 12))
+}
+
+func TestRuleEngineAssess(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         Input
+		expectedGrade models.RiskGrade
+	}{
+		{
+			name: "Strong salaried applicant",
+			input: Input{
+				AmountRequested: 100000,
+				IncomeDetails: models.IncomeDetails{
+					MonthlyIncome:   50000,
+					EmploymentYears: 6,
+					IncomeSource:    "Salary",
+				},
+			},
+			expectedGrade: "A",
+		},
+		{
+			name: "Existing obligations push ratio over the limit",
+			input: Input{
+				AmountRequested: 200000,
+				IncomeDetails: models.IncomeDetails{
+					MonthlyIncome:   15000,
+					EmploymentYears: 6,
+					IncomeSource:    "salary",
+				},
+				ExistingLoanAmounts: []float64{150000},
+			},
+			expectedGrade: "E",
+		},
+		{
+			name: "Short employment with unknown income source",
+			input: Input{
+				AmountRequested: 50000,
+				IncomeDetails: models.IncomeDetails{
+					MonthlyIncome:    20000,
+					AdditionalIncome: 4000,
+					EmploymentYears:  0,
+					IncomeSource:     "other",
+				},
+			},
+			expectedGrade: "C",
+		},
+	}
+
+	engine := NewRuleEngine(NewStaticPolicySource(DefaultPolicy()))
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assessment, err := engine.Assess(tc.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedGrade, assessment.RiskGrade)
+			assert.Equal(t, "default", assessment.PolicyVersion)
+			assert.NotEmpty(t, assessment.Reasons)
+			if tc.expectedGrade == "E" {
+				assert.Equal(t, 0.0, assessment.RecommendedMaxAmount)
+			} else {
+				assert.Greater(t, assessment.RecommendedMaxAmount, 0.0)
+			}
+		})
+	}
+}
+
+func TestFilePolicySourceReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	write := func(version string, modTime time.Time) {
+		data := `{"version":"` + version + `","reference_tenor_months":12,"max_debt_service_ratio":0.5,
+			"grades":[{"grade":"A","min_score":0,"limit_factor":1}]}`
+		assert.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+		assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	source := NewFilePolicySource(path)
+	write("v1", time.Now().Add(-time.Hour))
+	policy, err := source.Policy()
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", policy.Version)
+
+	write("v2", time.Now())
+	policy, err = source.Policy()
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", policy.Version)
+
+	assert.NoError(t, os.WriteFile(path, []byte("not json"), 0o644))
+	policy, err = source.Policy()
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", policy.Version)
+}
+
+func TestAmortizationSchedule(t *testing.T) {
+	start := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	schedule := AmortizationSchedule(12000, 0.12, 12, start)
+
+	assert.Len(t, schedule, 12)
+	assert.Equal(t, time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC), schedule[0].DueDate)
+	assert.Equal(t, 120.0, schedule[0].InterestDue)
+
+	total := 0.0
+	for _, installment := range schedule {
+		total += installment.PrincipalDue
+	}
+	assert.InDelta(t, 12000, total, 0.001)
+}
@@ -0,0 +1,199 @@
+package credit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Policy holds the credit rules used by the scoring engine.
+// It is loaded from a versioned JSON file so credit policy can change
+// without redeploying the service.
+type Policy struct {
+	Version string `json:"version"`
+
+	// BaseScore is the starting score before any rule is applied
+	BaseScore int `json:"base_score"`
+
+	// AdditionalIncomeWeight is the share of additional income counted as stable income
+	AdditionalIncomeWeight float64 `json:"additional_income_weight"`
+
+	// ReferenceAnnualRate and ReferenceTenorMonths are used to turn loan amounts
+	// into monthly installments when computing the debt-service ratio
+	ReferenceAnnualRate  float64 `json:"reference_annual_rate"`
+	ReferenceTenorMonths int     `json:"reference_tenor_months"`
+
+	// MaxDebtServiceRatio is the highest ratio of obligations to income the bank accepts
+	MaxDebtServiceRatio float64 `json:"max_debt_service_ratio"`
+
+	// MaxIncomeMultiple caps the recommended amount as a multiple of monthly income
+	MaxIncomeMultiple float64 `json:"max_income_multiple"`
+
+	DebtServiceRatioRules []RatioRule      `json:"debt_service_ratio_rules"`
+	EmploymentYearsRules  []EmploymentRule `json:"employment_years_rules"`
+	IncomeSourcePoints    map[string]int   `json:"income_source_points"`
+	DefaultSourcePoints   int              `json:"default_source_points"`
+	Grades                []GradeRule      `json:"grades"`
+}
+
+// RatioRule awards points when the debt-service ratio is at or below MaxRatio
+type RatioRule struct {
+	MaxRatio float64 `json:"max_ratio"`
+	Points   int     `json:"points"`
+}
+
+// EmploymentRule awards points when employment years are at least MinYears
+type EmploymentRule struct {
+	MinYears int `json:"min_years"`
+	Points   int `json:"points"`
+}
+
+// GradeRule maps a minimum score to a risk grade. LimitFactor scales the
+// recommended maximum amount for applicants in that grade.
+type GradeRule struct {
+	Grade       string  `json:"grade"`
+	MinScore    int     `json:"min_score"`
+	LimitFactor float64 `json:"limit_factor"`
+}
+
+// Validate checks that the policy can be used for scoring
+func (p *Policy) Validate() error {
+	if p.Version == "" {
+		return errors.New("credit policy version is required")
+	}
+	if p.ReferenceTenorMonths <= 0 {
+		return errors.New("reference_tenor_months must be greater than zero")
+	}
+	if p.MaxDebtServiceRatio <= 0 {
+		return errors.New("max_debt_service_ratio must be greater than zero")
+	}
+	if len(p.Grades) == 0 {
+		return errors.New("at least one grade must be defined")
+	}
+	return nil
+}
+
+// PolicySource provides the credit policy currently in force
+type PolicySource interface {
+	Policy() (*Policy, error)
+}
+
+// StaticPolicySource always returns the same policy
+type StaticPolicySource struct {
+	policy *Policy
+}
+
+// NewStaticPolicySource creates a PolicySource for a fixed policy
+func NewStaticPolicySource(policy *Policy) *StaticPolicySource {
+	return &StaticPolicySource{policy: policy}
+}
+
+// Policy returns the fixed policy
+func (s *StaticPolicySource) Policy() (*Policy, error) {
+	return s.policy, nil
+}
+
+// FilePolicySource loads the policy from a JSON file and reloads it
+// whenever the file modification time changes
+type FilePolicySource struct {
+	path    string
+	mu      sync.Mutex
+	policy  *Policy
+	modTime time.Time
+}
+
+// NewFilePolicySource creates a PolicySource backed by the file at path
+func NewFilePolicySource(path string) *FilePolicySource {
+	return &FilePolicySource{path: path}
+}
+
+// Policy returns the latest policy from disk. If the file cannot be read
+// after a policy was loaded, the previously loaded policy keeps being used.
+func (s *FilePolicySource) Policy() (*Policy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		if s.policy != nil {
+			return s.policy, nil
+		}
+		return nil, fmt.Errorf("failed to stat credit policy: %w", err)
+	}
+
+	if s.policy != nil && info.ModTime().Equal(s.modTime) {
+		return s.policy, nil
+	}
+
+	policy, err := LoadPolicy(s.path)
+	if err != nil {
+		if s.policy != nil {
+			return s.policy, nil
+		}
+		return nil, err
+	}
+
+	s.policy = policy
+	s.modTime = info.ModTime()
+	return s.policy, nil
+}
+
+// LoadPolicy reads and validates a policy file
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credit policy: %w", err)
+	}
+
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse credit policy: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// DefaultPolicy returns the built-in policy used when no policy file is configured
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Version:                "default",
+		BaseScore:              500,
+		AdditionalIncomeWeight: 0.5,
+		ReferenceAnnualRate:    0.18,
+		ReferenceTenorMonths:   48,
+		MaxDebtServiceRatio:    0.6,
+		MaxIncomeMultiple:      5,
+		DebtServiceRatioRules: []RatioRule{
+			{MaxRatio: 0.3, Points: 150},
+			{MaxRatio: 0.4, Points: 100},
+			{MaxRatio: 0.5, Points: 50},
+			{MaxRatio: 0.6, Points: 0},
+		},
+		EmploymentYearsRules: []EmploymentRule{
+			{MinYears: 5, Points: 100},
+			{MinYears: 3, Points: 70},
+			{MinYears: 1, Points: 30},
+		},
+		IncomeSourcePoints: map[string]int{
+			"salary":        100,
+			"government":    120,
+			"business":      50,
+			"self_employed": 30,
+			"freelance":     20,
+		},
+		DefaultSourcePoints: 0,
+		Grades: []GradeRule{
+			{Grade: "A", MinScore: 800, LimitFactor: 1.0},
+			{Grade: "B", MinScore: 700, LimitFactor: 0.8},
+			{Grade: "C", MinScore: 600, LimitFactor: 0.6},
+			{Grade: "D", MinScore: 500, LimitFactor: 0.3},
+			{Grade: "E", MinScore: 0, LimitFactor: 0},
+		},
+	}
+}
@@ -3,6 +3,8 @@ package database
 import (
 	"database/sql"
 	"log"
+
+	"example.com/m/internal/models"
 )
 
 // InitDatabase initializes all required database tables
@@ -13,6 +15,132 @@ func InitDatabase(db *sql.DB) error {
 		return err
 	}
 
+	// Initialize loan_documents table
+	err = createLoanDocumentsTable(db)
+	if err != nil {
+		return err
+	}
+
+	// Initialize loans, installments and delinquency tables
+	err = createLoanAccountTables(db)
+	if err != nil {
+		return err
+	}
+
+	// Initialize accounts and ledger tables
+	err = createAccountTables(db)
+	if err != nil {
+		return err
+	}
+
+	// Initialize account_products table
+	err = createAccountProductsTable(db)
+	if err != nil {
+		return err
+	}
+
+	// Initialize account_preferences table
+	err = createAccountPreferencesTable(db)
+	if err != nil {
+		return err
+	}
+
+	// Initialize account_statements table
+	err = createAccountStatementsTable(db)
+	if err != nil {
+		return err
+	}
+
+	// Initialize account_holds table
+	err = createAccountHoldsTable(db)
+	if err != nil {
+		return err
+	}
+
+	// Initialize account_restrictions table
+	err = createAccountRestrictionsTable(db)
+	if err != nil {
+		return err
+	}
+
+	// Initialize account_dormancy_events table
+	err = createAccountDormancyEventsTable(db)
+	if err != nil {
+		return err
+	}
+
+	// Initialize pending_transfers table
+	err = createPendingTransfersTable(db)
+	if err != nil {
+		return err
+	}
+
+	// Initialize fx_rates and fx_quotes tables
+	err = createFXTables(db)
+	if err != nil {
+		return err
+	}
+
+	// Initialize customer_tiers, customer_limits and transaction_limit_usage tables
+	err = createTransactionLimitTables(db)
+	if err != nil {
+		return err
+	}
+
+	// Initialize identity_verification_attempts table
+	err = createIdentityVerificationTable(db)
+	if err != nil {
+		return err
+	}
+
+	// Initialize standing_orders and customer_notifications tables
+	err = createStandingOrderTables(db)
+	if err != nil {
+		return err
+	}
+
+	// Initialize proxy_registrations and proxy_transfers tables
+	err = createProxyTables(db)
+	if err != nil {
+		return err
+	}
+
+	// Initialize interbank_transfers table
+	err = createInterbankTransfersTable(db)
+	if err != nil {
+		return err
+	}
+
+	// Initialize bulk_payments and bulk_payment_rows tables
+	err = createBulkPaymentTables(db)
+	if err != nil {
+		return err
+	}
+
+	// Initialize cards, card_audit and card_controls tables
+	err = createCardsTable(db)
+	if err != nil {
+		return err
+	}
+
+	// Initialize card_authorizations and card_limit_usage tables
+	err = createCardAuthorizationsTable(db)
+	if err != nil {
+		return err
+	}
+
+	// Initialize interest_accruals table
+	err = createInterestAccrualsTable(db)
+	if err != nil {
+		return err
+	}
+
+	// Initialize fixed_deposits table
+	err = createFixedDepositsTable(db)
+	if err != nil {
+		return err
+	}
+
 	log.Println("Database tables initialized successfully")
 	return nil
 }
@@ -31,6 +159,7 @@ func createLoanApplicationsTable(db *sql.DB) error {
 		updated_at TIMESTAMP NOT NULL,
 		status_reason TEXT
 	);
+	ALTER TABLE loan_applications ADD COLUMN IF NOT EXISTS credit_assessment JSONB;
 	`
 	_, err := db.Exec(query)
 	if err != nil {
@@ -40,3 +169,893 @@ func createLoanApplicationsTable(db *sql.DB) error {
 	log.Println("Loan applications table initialized")
 	return nil
 }
+
+// createLoanDocumentsTable creates the loan_documents table if it doesn't exist
+func createLoanDocumentsTable(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS loan_documents (
+		id UUID PRIMARY KEY,
+		application_id UUID NOT NULL REFERENCES loan_applications(id),
+		customer_id UUID NOT NULL,
+		document_type VARCHAR(30) NOT NULL,
+		file_name TEXT NOT NULL,
+		content_type VARCHAR(100) NOT NULL,
+		size_bytes BIGINT NOT NULL,
+		checksum_sha256 CHAR(64) NOT NULL,
+		storage_key TEXT NOT NULL,
+		uploaded_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_loan_documents_application ON loan_documents(application_id);
+	`
+	_, err := db.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Loan documents table initialized")
+	return nil
+}
+
+// createLoanAccountTables creates the tables for booked loans, their installments,
+// late charges and delinquency job runs if they don't exist
+func createLoanAccountTables(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS loans (
+		id UUID PRIMARY KEY,
+		application_id UUID NOT NULL UNIQUE REFERENCES loan_applications(id),
+		customer_id UUID NOT NULL,
+		principal DECIMAL(15, 2) NOT NULL,
+		annual_rate DECIMAL(7, 4) NOT NULL,
+		tenor_months INT NOT NULL,
+		outstanding_principal DECIMAL(15, 2) NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		days_past_due INT NOT NULL DEFAULT 0,
+		aging_bucket VARCHAR(10) NOT NULL DEFAULT 'current',
+		disbursed_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS loan_installments (
+		id UUID PRIMARY KEY,
+		loan_id UUID NOT NULL REFERENCES loans(id),
+		installment_number INT NOT NULL,
+		due_date DATE NOT NULL,
+		principal_due DECIMAL(15, 2) NOT NULL,
+		interest_due DECIMAL(15, 2) NOT NULL,
+		fees_due DECIMAL(15, 2) NOT NULL DEFAULT 0,
+		penalty_due DECIMAL(15, 2) NOT NULL DEFAULT 0,
+		amount_paid DECIMAL(15, 2) NOT NULL DEFAULT 0,
+		status VARCHAR(20) NOT NULL,
+		days_past_due INT NOT NULL DEFAULT 0,
+		UNIQUE (loan_id, installment_number)
+	);
+	CREATE INDEX IF NOT EXISTS idx_loan_installments_due ON loan_installments(status, due_date);
+	CREATE TABLE IF NOT EXISTS loan_charges (
+		id UUID PRIMARY KEY,
+		loan_id UUID NOT NULL REFERENCES loans(id),
+		installment_id UUID NOT NULL REFERENCES loan_installments(id),
+		charge_type VARCHAR(30) NOT NULL,
+		amount DECIMAL(15, 2) NOT NULL,
+		business_date DATE NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS uq_loan_charges_late_fee
+		ON loan_charges(installment_id) WHERE charge_type = 'late_fee';
+	CREATE UNIQUE INDEX IF NOT EXISTS uq_loan_charges_penalty
+		ON loan_charges(installment_id, business_date) WHERE charge_type = 'penalty_interest';
+	CREATE TABLE IF NOT EXISTS delinquency_runs (
+		business_date DATE PRIMARY KEY,
+		installments_processed INT NOT NULL,
+		completed_at TIMESTAMP NOT NULL
+	);
+	`
+	_, err := db.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Loan account tables initialized")
+	return nil
+}
+
+// createAccountTables creates the accounts and ledger tables if they don't
+// exist and seeds the internal ledger accounts
+func createAccountTables(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS accounts (
+		id UUID PRIMARY KEY,
+		account_number VARCHAR(30) NOT NULL UNIQUE,
+		customer_id UUID,
+		account_type VARCHAR(20) NOT NULL,
+		product_code VARCHAR(30),
+		balance DECIMAL(15, 2) NOT NULL DEFAULT 0,
+		status VARCHAR(20) NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_accounts_customer ON accounts(customer_id);
+	CREATE TABLE IF NOT EXISTS ledger_transactions (
+		id UUID PRIMARY KEY,
+		reference VARCHAR(200) NOT NULL UNIQUE,
+		type VARCHAR(40) NOT NULL,
+		description TEXT,
+		business_date DATE NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS ledger_entries (
+		id UUID PRIMARY KEY,
+		seq BIGSERIAL,
+		transaction_id UUID NOT NULL REFERENCES ledger_transactions(id),
+		account_id UUID NOT NULL REFERENCES accounts(id),
+		direction VARCHAR(6) NOT NULL,
+		amount DECIMAL(15, 2) NOT NULL,
+		balance_after DECIMAL(15, 2) NOT NULL,
+		business_date DATE NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_ledger_entries_account ON ledger_entries(account_id, business_date);
+	INSERT INTO accounts (id, account_number, account_type, balance, status, created_at, updated_at)
+	VALUES
+		(gen_random_uuid(), 'GL-INTEREST-EXPENSE', 'internal', 0, 'active', NOW(), NOW()),
+		(gen_random_uuid(), 'GL-WHT-PAYABLE', 'internal', 0, 'active', NOW(), NOW()),
+		(gen_random_uuid(), 'GL-CASH', 'internal', 0, 'active', NOW(), NOW()),
+		(gen_random_uuid(), 'GL-SUSPENSE', 'internal', 0, 'active', NOW(), NOW())
+	ON CONFLICT (account_number) DO NOTHING;
+	ALTER TABLE accounts ADD COLUMN IF NOT EXISTS product_version INT;
+	ALTER TABLE accounts ADD COLUMN IF NOT EXISTS held_amount DECIMAL(15, 2) NOT NULL DEFAULT 0;
+	ALTER TABLE accounts ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP;
+	ALTER TABLE accounts ADD COLUMN IF NOT EXISTS closed_by UUID;
+	ALTER TABLE accounts ADD COLUMN IF NOT EXISTS closure_reason TEXT;
+	ALTER TABLE accounts ADD COLUMN IF NOT EXISTS last_activity_at TIMESTAMP;
+	UPDATE accounts SET last_activity_at = created_at WHERE last_activity_at IS NULL;
+	ALTER TABLE accounts ALTER COLUMN last_activity_at SET DEFAULT NOW();
+	ALTER TABLE accounts ALTER COLUMN last_activity_at SET NOT NULL;
+	ALTER TABLE accounts ADD COLUMN IF NOT EXISTS dormant_since TIMESTAMP;
+	ALTER TABLE accounts ADD COLUMN IF NOT EXISTS signing_rule VARCHAR(30) NOT NULL DEFAULT 'either_or_survivor';
+	ALTER TABLE accounts ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'THB';
+	ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'THB';
+	ALTER TABLE ledger_transactions ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'settled';
+	CREATE TABLE IF NOT EXISTS account_mandates (
+		account_id UUID NOT NULL REFERENCES accounts(id),
+		customer_id UUID NOT NULL,
+		role VARCHAR(30) NOT NULL,
+		created_by UUID,
+		created_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (account_id, customer_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_account_mandates_customer ON account_mandates(customer_id);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_account_mandates_primary ON account_mandates(account_id) WHERE role = 'primary';
+	INSERT INTO account_mandates (account_id, customer_id, role, created_at)
+	SELECT id, customer_id, 'primary', created_at FROM accounts WHERE customer_id IS NOT NULL
+	ON CONFLICT DO NOTHING;
+	CREATE INDEX IF NOT EXISTS idx_accounts_last_activity ON accounts(status, last_activity_at);
+	ALTER TABLE loans ADD COLUMN IF NOT EXISTS repayment_account_id UUID REFERENCES accounts(id);
+	CREATE INDEX IF NOT EXISTS idx_loans_repayment_account ON loans(repayment_account_id);
+	`
+	_, err := db.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Account and ledger tables initialized")
+	return nil
+}
+
+// createInterestAccrualsTable creates the interest_accruals table if it doesn't exist
+func createInterestAccrualsTable(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS interest_accruals (
+		id UUID PRIMARY KEY,
+		account_id UUID NOT NULL REFERENCES accounts(id),
+		accrual_date DATE NOT NULL,
+		balance DECIMAL(15, 2) NOT NULL,
+		amount DECIMAL(18, 6) NOT NULL,
+		posted_reference VARCHAR(200),
+		created_at TIMESTAMP NOT NULL,
+		UNIQUE (account_id, accrual_date)
+	);
+	`
+	_, err := db.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Interest accruals table initialized")
+	return nil
+}
+
+// createAccountProductsTable creates the account_products table if it doesn't
+// exist and seeds the standard savings product
+func createAccountProductsTable(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS account_products (
+		id UUID PRIMARY KEY,
+		code VARCHAR(30) NOT NULL,
+		version INT NOT NULL,
+		name TEXT NOT NULL,
+		category VARCHAR(20) NOT NULL,
+		min_opening_balance DECIMAL(15, 2) NOT NULL,
+		min_balance DECIMAL(15, 2) NOT NULL,
+		fees JSONB NOT NULL,
+		interest_tiers JSONB NOT NULL,
+		withdrawal_limits JSONB NOT NULL,
+		eligibility JSONB NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		created_by UUID,
+		created_at TIMESTAMP NOT NULL,
+		UNIQUE (code, version)
+	);
+	ALTER TABLE account_products ADD COLUMN IF NOT EXISTS term_rates JSONB NOT NULL DEFAULT '[]';
+	ALTER TABLE account_products ADD COLUMN IF NOT EXISTS early_withdrawal_rate DECIMAL(7, 4) NOT NULL DEFAULT 0;
+	INSERT INTO account_products (
+		id, code, version, name, category, min_opening_balance, min_balance, fees,
+		interest_tiers, withdrawal_limits, eligibility, status, created_at
+	) VALUES (
+		gen_random_uuid(), 'SAVINGS', 1, 'Standard Savings', 'savings', 500, 0,
+		'{"monthly_maintenance_fee": 0, "below_minimum_balance_fee": 0, "early_closure_fee": 0, "early_closure_months": 0}',
+		'[{"min_balance": 0, "annual_rate": 0.0025}, {"min_balance": 1000000, "annual_rate": 0.005}]',
+		'{"max_withdrawals_per_month": 0, "daily_withdrawal_limit": 0}',
+		'{"max_accounts_per_customer": 5}',
+		'active', NOW()
+	) ON CONFLICT (code, version) DO NOTHING;
+	INSERT INTO account_products (
+		id, code, version, name, category, min_opening_balance, min_balance, fees,
+		interest_tiers, withdrawal_limits, eligibility, term_rates, early_withdrawal_rate, status, created_at
+	) VALUES (
+		gen_random_uuid(), 'FIXED_DEPOSIT', 1, 'Fixed Deposit', 'fixed_deposit', 1000, 0,
+		'{"monthly_maintenance_fee": 0, "below_minimum_balance_fee": 0, "early_closure_fee": 0, "early_closure_months": 0}',
+		'[]',
+		'{"max_withdrawals_per_month": 0, "daily_withdrawal_limit": 0}',
+		'{"max_accounts_per_customer": 0}',
+		'[{"term_months": 3, "annual_rate": 0.009}, {"term_months": 6, "annual_rate": 0.011},
+		  {"term_months": 12, "annual_rate": 0.015}, {"term_months": 24, "annual_rate": 0.018}]',
+		0.0025, 'active', NOW()
+	) ON CONFLICT (code, version) DO NOTHING;
+	`
+	_, err := db.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Account products table initialized")
+	return nil
+}
+
+// createFixedDepositsTable creates the fixed_deposits table if it doesn't exist
+func createFixedDepositsTable(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS fixed_deposits (
+		account_id UUID PRIMARY KEY REFERENCES accounts(id),
+		principal DECIMAL(15, 2) NOT NULL,
+		term_months INT NOT NULL,
+		annual_rate DECIMAL(7, 4) NOT NULL,
+		start_date DATE NOT NULL,
+		maturity_date DATE NOT NULL,
+		maturity_instruction VARCHAR(30) NOT NULL,
+		linked_account_id UUID NOT NULL REFERENCES accounts(id),
+		status VARCHAR(20) NOT NULL,
+		renewals INT NOT NULL DEFAULT 0,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_fixed_deposits_maturity ON fixed_deposits(status, maturity_date);
+	`
+	_, err := db.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Fixed deposits table initialized")
+	return nil
+}
+
+// createAccountPreferencesTable creates the account_preferences table if it doesn't exist
+func createAccountPreferencesTable(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS account_preferences (
+		account_id UUID NOT NULL REFERENCES accounts(id),
+		customer_id UUID NOT NULL,
+		nickname VARCHAR(120) NOT NULL DEFAULT '',
+		display_order INT,
+		hide_from_dashboard BOOLEAN NOT NULL DEFAULT FALSE,
+		default_incoming BOOLEAN NOT NULL DEFAULT FALSE,
+		updated_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (account_id, customer_id)
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_account_preferences_default_incoming
+		ON account_preferences(customer_id) WHERE default_incoming;
+	`
+	_, err := db.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Account preferences table initialized")
+	return nil
+}
+
+// createAccountStatementsTable creates the account_statements table if it doesn't exist
+func createAccountStatementsTable(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS account_statements (
+		id UUID PRIMARY KEY,
+		account_id UUID NOT NULL REFERENCES accounts(id),
+		customer_id UUID NOT NULL,
+		from_date DATE NOT NULL,
+		to_date DATE NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		opening_balance DECIMAL(15, 2),
+		closing_balance DECIMAL(15, 2),
+		pdf_key VARCHAR(255) NOT NULL DEFAULT '',
+		csv_key VARCHAR(255) NOT NULL DEFAULT '',
+		failure_reason TEXT NOT NULL DEFAULT '',
+		requested_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		completed_at TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_account_statements_status ON account_statements(status, requested_at);
+	`
+	_, err := db.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Account statements table initialized")
+	return nil
+}
+
+// createAccountHoldsTable creates the account_holds table if it doesn't exist
+func createAccountHoldsTable(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS account_holds (
+		id UUID PRIMARY KEY,
+		account_id UUID NOT NULL REFERENCES accounts(id),
+		amount DECIMAL(15, 2) NOT NULL,
+		reason TEXT NOT NULL,
+		source VARCHAR(30) NOT NULL,
+		reference VARCHAR(200),
+		status VARCHAR(20) NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		created_by UUID,
+		captured_transaction_id UUID REFERENCES ledger_transactions(id),
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_account_holds_account ON account_holds(account_id, status);
+	CREATE INDEX IF NOT EXISTS idx_account_holds_expiry ON account_holds(expires_at) WHERE status = 'active';
+	`
+	_, err := db.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Account holds table initialized")
+	return nil
+}
+
+// createAccountRestrictionsTable creates the account_restrictions and restriction_audit tables if they don't exist
+func createAccountRestrictionsTable(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS account_restrictions (
+		id UUID PRIMARY KEY,
+		account_id UUID NOT NULL REFERENCES accounts(id),
+		type VARCHAR(20) NOT NULL,
+		amount DECIMAL(15, 2) NOT NULL DEFAULT 0,
+		reference_number VARCHAR(100) NOT NULL,
+		reason TEXT NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		requested_by UUID NOT NULL,
+		approved_by UUID,
+		lift_requested_by UUID,
+		lift_reason TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_account_restrictions_account ON account_restrictions(account_id, status);
+
+	CREATE TABLE IF NOT EXISTS restriction_audit (
+		seq BIGSERIAL,
+		id UUID PRIMARY KEY,
+		restriction_id UUID NOT NULL REFERENCES account_restrictions(id),
+		action VARCHAR(30) NOT NULL,
+		from_status VARCHAR(20) NOT NULL DEFAULT '',
+		to_status VARCHAR(20) NOT NULL,
+		actor_id UUID,
+		comment TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_restriction_audit_restriction ON restriction_audit(restriction_id, seq);
+	`
+	_, err := db.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Account restrictions tables initialized")
+	return nil
+}
+
+// createAccountDormancyEventsTable creates the account_dormancy_events table if it doesn't exist
+func createAccountDormancyEventsTable(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS account_dormancy_events (
+		id UUID PRIMARY KEY,
+		account_id UUID NOT NULL REFERENCES accounts(id),
+		event VARCHAR(30) NOT NULL,
+		method VARCHAR(30) NOT NULL,
+		actor_id UUID,
+		reason TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_account_dormancy_events_account ON account_dormancy_events(account_id, event, created_at);
+	`
+	_, err := db.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Account dormancy events table initialized")
+	return nil
+}
+
+// createPendingTransfersTable creates the pending_transfers and transfer_approvals tables if they don't exist
+func createPendingTransfersTable(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS pending_transfers (
+		id UUID PRIMARY KEY,
+		account_id UUID NOT NULL REFERENCES accounts(id),
+		to_account_id UUID NOT NULL REFERENCES accounts(id),
+		to_account_number VARCHAR(30) NOT NULL,
+		amount DECIMAL(15, 2) NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		initiated_by UUID NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		transaction_id UUID,
+		failure_reason TEXT NOT NULL DEFAULT '',
+		expires_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_pending_transfers_account ON pending_transfers(account_id, created_at);
+	CREATE TABLE IF NOT EXISTS transfer_approvals (
+		pending_transfer_id UUID NOT NULL REFERENCES pending_transfers(id),
+		customer_id UUID NOT NULL,
+		decision VARCHAR(20) NOT NULL,
+		decided_at TIMESTAMP,
+		PRIMARY KEY (pending_transfer_id, customer_id)
+	);
+	`
+	_, err := db.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Pending transfers tables initialized")
+	return nil
+}
+
+// createFXTables creates the fx_rates and fx_quotes tables if they don't
+// exist and seeds the internal ledger accounts kept for every currency
+func createFXTables(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS fx_rates (
+		id UUID PRIMARY KEY,
+		currency VARCHAR(3) NOT NULL,
+		mid_rate DECIMAL(15, 6) NOT NULL,
+		buy_spread DECIMAL(7, 6) NOT NULL,
+		sell_spread DECIMAL(7, 6) NOT NULL,
+		effective_at TIMESTAMP NOT NULL,
+		uploaded_by UUID NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_fx_rates_currency ON fx_rates(currency, effective_at DESC);
+	CREATE TABLE IF NOT EXISTS fx_quotes (
+		id UUID PRIMARY KEY,
+		customer_id UUID NOT NULL,
+		from_account_id UUID NOT NULL REFERENCES accounts(id),
+		to_account_id UUID NOT NULL REFERENCES accounts(id),
+		from_amount DECIMAL(15, 2) NOT NULL,
+		from_currency VARCHAR(3) NOT NULL,
+		to_amount DECIMAL(15, 2) NOT NULL,
+		to_currency VARCHAR(3) NOT NULL,
+		rate DECIMAL(20, 10) NOT NULL,
+		from_base_value DECIMAL(15, 2) NOT NULL,
+		to_base_value DECIMAL(15, 2) NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		status VARCHAR(20) NOT NULL,
+		transaction_id UUID,
+		expires_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_fx_quotes_from_account ON fx_quotes(from_account_id, created_at);
+	`
+	if _, err := db.Exec(query); err != nil {
+		return err
+	}
+
+	seed := `
+	INSERT INTO accounts (id, account_number, account_type, currency, balance, status, created_at, updated_at)
+	VALUES (gen_random_uuid(), $1, 'internal', $2, 0, 'active', NOW(), NOW())
+	ON CONFLICT (account_number) DO NOTHING
+	`
+	for _, currency := range models.Currencies {
+		for _, number := range models.CurrencyGLAccounts {
+			if _, err := db.Exec(seed, models.CurrencyAccount(number, currency), currency); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := db.Exec(seed, models.GLFXGainLoss, models.BaseCurrency); err != nil {
+		return err
+	}
+
+	log.Println("FX tables initialized")
+	return nil
+}
+
+// createTransactionLimitTables creates the customer_tiers, customer_limits and
+// transaction_limit_usage tables if they don't exist
+func createTransactionLimitTables(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS customer_tiers (
+		customer_id UUID PRIMARY KEY,
+		tier VARCHAR(20) NOT NULL,
+		updated_by UUID NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS customer_limits (
+		customer_id UUID NOT NULL,
+		channel VARCHAR(20) NOT NULL,
+		limit_type VARCHAR(20) NOT NULL,
+		per_transaction DECIMAL(15, 2) NOT NULL,
+		daily DECIMAL(15, 2) NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (customer_id, channel, limit_type)
+	);
+	CREATE TABLE IF NOT EXISTS transaction_limit_usage (
+		customer_id UUID NOT NULL,
+		channel VARCHAR(20) NOT NULL,
+		limit_type VARCHAR(20) NOT NULL,
+		usage_date DATE NOT NULL,
+		amount DECIMAL(15, 2) NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (customer_id, channel, limit_type, usage_date)
+	);
+	`
+	if _, err := db.Exec(query); err != nil {
+		return err
+	}
+
+	log.Println("Transaction limit tables initialized")
+	return nil
+}
+
+// createIdentityVerificationTable creates the identity_verification_attempts
+// table if it doesn't exist
+func createIdentityVerificationTable(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS identity_verification_attempts (
+		customer_id UUID PRIMARY KEY,
+		attempts INT NOT NULL,
+		window_started_at TIMESTAMP NOT NULL
+	);
+	`
+	if _, err := db.Exec(query); err != nil {
+		return err
+	}
+
+	log.Println("Identity verification table initialized")
+	return nil
+}
+
+// createStandingOrderTables creates the standing_orders, standing_order_executions
+// and customer_notifications tables if they don't exist
+func createStandingOrderTables(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS standing_orders (
+		id UUID PRIMARY KEY,
+		account_id UUID NOT NULL REFERENCES accounts(id),
+		customer_id UUID NOT NULL,
+		to_account_id UUID NOT NULL REFERENCES accounts(id),
+		to_account_number VARCHAR(20) NOT NULL,
+		amount DECIMAL(15, 2) NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		frequency VARCHAR(20) NOT NULL,
+		day_of_month INT NOT NULL DEFAULT 0,
+		start_date DATE NOT NULL,
+		end_date DATE,
+		next_run_date DATE,
+		failed_attempts INT NOT NULL DEFAULT 0,
+		retry_at TIMESTAMP,
+		status VARCHAR(20) NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_standing_orders_account ON standing_orders(account_id);
+	CREATE INDEX IF NOT EXISTS idx_standing_orders_due ON standing_orders(next_run_date) WHERE status = 'active';
+	CREATE TABLE IF NOT EXISTS standing_order_executions (
+		id UUID PRIMARY KEY,
+		order_id UUID NOT NULL REFERENCES standing_orders(id),
+		run_date DATE NOT NULL,
+		attempt INT NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		transaction_id UUID,
+		failure_reason TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL,
+		UNIQUE (order_id, run_date, attempt)
+	);
+	CREATE TABLE IF NOT EXISTS customer_notifications (
+		id UUID PRIMARY KEY,
+		customer_id UUID NOT NULL,
+		type VARCHAR(50) NOT NULL,
+		title VARCHAR(200) NOT NULL,
+		message TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_customer_notifications_customer ON customer_notifications(customer_id, created_at DESC);
+	`
+	if _, err := db.Exec(query); err != nil {
+		return err
+	}
+
+	log.Println("Standing order tables initialized")
+	return nil
+}
+
+// createProxyTables creates the proxy_registrations and proxy_transfers tables
+// if they don't exist and seeds the interbank settlement account
+func createProxyTables(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS proxy_registrations (
+		id UUID PRIMARY KEY,
+		proxy_type VARCHAR(20) NOT NULL,
+		proxy_value VARCHAR(20) NOT NULL,
+		account_id UUID NOT NULL REFERENCES accounts(id),
+		customer_id UUID NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		consented_at TIMESTAMP NOT NULL,
+		deregistered_at TIMESTAMP,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_proxy_registrations_active ON proxy_registrations(proxy_type, proxy_value) WHERE status = 'active';
+	CREATE INDEX IF NOT EXISTS idx_proxy_registrations_account ON proxy_registrations(account_id);
+	CREATE TABLE IF NOT EXISTS proxy_transfers (
+		id UUID PRIMARY KEY,
+		customer_id UUID NOT NULL,
+		from_account_id UUID NOT NULL REFERENCES accounts(id),
+		proxy_type VARCHAR(20) NOT NULL,
+		proxy_value VARCHAR(20) NOT NULL,
+		bank_code VARCHAR(10) NOT NULL,
+		recipient_name VARCHAR(200) NOT NULL,
+		to_account_id UUID REFERENCES accounts(id),
+		to_account_number VARCHAR(30) NOT NULL,
+		amount DECIMAL(15, 2) NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		status VARCHAR(30) NOT NULL,
+		transaction_id UUID,
+		failure_reason TEXT NOT NULL DEFAULT '',
+		expires_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_proxy_transfers_from_account ON proxy_transfers(from_account_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_proxy_transfers_pending ON proxy_transfers(created_at) WHERE status = 'pending';
+	`
+	if _, err := db.Exec(query); err != nil {
+		return err
+	}
+
+	seed := `
+	INSERT INTO accounts (id, account_number, account_type, currency, balance, status, created_at, updated_at)
+	VALUES (gen_random_uuid(), $1, 'internal', $2, 0, 'active', NOW(), NOW())
+	ON CONFLICT (account_number) DO NOTHING
+	`
+	if _, err := db.Exec(seed, models.GLInterbankSettlement, models.BaseCurrency); err != nil {
+		return err
+	}
+
+	log.Println("Proxy tables initialized")
+	return nil
+}
+
+// createInterbankTransfersTable creates the interbank_transfers table if it
+// doesn't exist and seeds the interbank clearing account
+func createInterbankTransfersTable(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS interbank_transfers (
+		id UUID PRIMARY KEY,
+		customer_id UUID NOT NULL,
+		from_account_id UUID NOT NULL REFERENCES accounts(id),
+		to_bank_code VARCHAR(10) NOT NULL,
+		to_account_number VARCHAR(30) NOT NULL,
+		amount DECIMAL(15, 2) NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		status VARCHAR(20) NOT NULL,
+		transaction_id UUID NOT NULL UNIQUE REFERENCES ledger_transactions(id),
+		resolution_transaction_id UUID REFERENCES ledger_transactions(id),
+		return_reason TEXT NOT NULL DEFAULT '',
+		submitted_at TIMESTAMP,
+		resolved_at TIMESTAMP,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_interbank_transfers_unsubmitted ON interbank_transfers(created_at) WHERE status = 'pending' AND submitted_at IS NULL;
+	CREATE INDEX IF NOT EXISTS idx_interbank_transfers_from_account ON interbank_transfers(from_account_id, created_at);
+	`
+	if _, err := db.Exec(query); err != nil {
+		return err
+	}
+
+	seed := `
+	INSERT INTO accounts (id, account_number, account_type, currency, balance, status, created_at, updated_at)
+	VALUES (gen_random_uuid(), $1, 'internal', $2, 0, 'active', NOW(), NOW())
+	ON CONFLICT (account_number) DO NOTHING
+	`
+	if _, err := db.Exec(seed, models.GLInterbankClearing, models.BaseCurrency); err != nil {
+		return err
+	}
+
+	log.Println("Interbank transfers table initialized")
+	return nil
+}
+
+// createBulkPaymentTables creates the bulk_payments and bulk_payment_rows
+// tables if they don't exist
+func createBulkPaymentTables(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS bulk_payments (
+		id UUID PRIMARY KEY,
+		account_id UUID NOT NULL REFERENCES accounts(id),
+		customer_id UUID NOT NULL,
+		file_name VARCHAR(255) NOT NULL DEFAULT '',
+		status VARCHAR(30) NOT NULL,
+		row_count INT NOT NULL,
+		valid_count INT NOT NULL,
+		invalid_count INT NOT NULL,
+		total_amount DECIMAL(15, 2) NOT NULL,
+		paid_count INT NOT NULL DEFAULT 0,
+		failed_count INT NOT NULL DEFAULT 0,
+		paid_amount DECIMAL(15, 2) NOT NULL DEFAULT 0,
+		available_balance DECIMAL(15, 2) NOT NULL,
+		confirmed_at TIMESTAMP,
+		completed_at TIMESTAMP,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_bulk_payments_runnable ON bulk_payments(confirmed_at) WHERE status IN ('queued', 'processing');
+	CREATE TABLE IF NOT EXISTS bulk_payment_rows (
+		id UUID PRIMARY KEY,
+		bulk_payment_id UUID NOT NULL REFERENCES bulk_payments(id),
+		line_number INT NOT NULL,
+		to_account_number VARCHAR(50) NOT NULL,
+		to_account_id UUID REFERENCES accounts(id),
+		amount DECIMAL(15, 2) NOT NULL,
+		reference VARCHAR(100) NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		status VARCHAR(20) NOT NULL,
+		error TEXT NOT NULL DEFAULT '',
+		transaction_id UUID,
+		processed_at TIMESTAMP,
+		UNIQUE (bulk_payment_id, line_number)
+	);
+	`
+	if _, err := db.Exec(query); err != nil {
+		return err
+	}
+
+	log.Println("Bulk payment tables initialized")
+	return nil
+}
+
+// createCardsTable creates the cards, card_audit and card_controls tables if
+// they don't exist
+func createCardsTable(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS cards (
+		id UUID PRIMARY KEY,
+		account_id UUID NOT NULL REFERENCES accounts(id),
+		customer_id UUID NOT NULL,
+		card_type VARCHAR(20) NOT NULL,
+		last4 CHAR(4) NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		pan_encrypted BYTEA NOT NULL,
+		pan_hash VARCHAR(64) NOT NULL UNIQUE,
+		expiry_month INT NOT NULL,
+		expiry_year INT NOT NULL,
+		cvv_hash VARCHAR(64) NOT NULL,
+		activation_code_hash VARCHAR(64) NOT NULL,
+		issued_at TIMESTAMP,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	ALTER TABLE cards ADD COLUMN IF NOT EXISTS activation_attempts INT NOT NULL DEFAULT 0;
+	ALTER TABLE cards ADD COLUMN IF NOT EXISTS activated_at TIMESTAMP;
+	ALTER TABLE cards ADD COLUMN IF NOT EXISTS lost_stolen_at TIMESTAMP;
+	ALTER TABLE cards ADD COLUMN IF NOT EXISTS lost_stolen_by UUID;
+	ALTER TABLE cards ADD COLUMN IF NOT EXISTS replaces_card_id UUID REFERENCES cards(id);
+	CREATE INDEX IF NOT EXISTS idx_cards_customer_last4 ON cards(customer_id, last4);
+	CREATE INDEX IF NOT EXISTS idx_cards_last4 ON cards(last4);
+	CREATE INDEX IF NOT EXISTS idx_cards_account ON cards(account_id);
+	CREATE TABLE IF NOT EXISTS card_audit (
+		seq BIGSERIAL,
+		id UUID PRIMARY KEY,
+		card_id UUID REFERENCES cards(id),
+		action VARCHAR(30) NOT NULL,
+		actor_id UUID NOT NULL,
+		details TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_card_audit_card ON card_audit(card_id, seq);
+	CREATE TABLE IF NOT EXISTS card_controls (
+		card_id UUID PRIMARY KEY REFERENCES cards(id),
+		atm_daily_limit DECIMAL(15, 2) NOT NULL,
+		pos_daily_limit DECIMAL(15, 2) NOT NULL,
+		ecommerce_enabled BOOLEAN NOT NULL,
+		contactless_enabled BOOLEAN NOT NULL,
+		international_enabled BOOLEAN NOT NULL,
+		blocked_merchant_categories JSONB NOT NULL DEFAULT '[]',
+		updated_at TIMESTAMP NOT NULL
+	);
+	`
+	if _, err := db.Exec(query); err != nil {
+		return err
+	}
+
+	log.Println("Cards tables initialized")
+	return nil
+}
+
+// createCardAuthorizationsTable creates the card_authorizations and
+// card_limit_usage tables if they don't exist and seeds the card settlement
+// account
+func createCardAuthorizationsTable(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS card_authorizations (
+		id UUID PRIMARY KEY,
+		rrn VARCHAR(12) NOT NULL UNIQUE,
+		card_id UUID REFERENCES cards(id),
+		account_id UUID REFERENCES accounts(id),
+		amount DECIMAL(15, 2) NOT NULL,
+		merchant_category VARCHAR(4) NOT NULL,
+		merchant_name VARCHAR(100) NOT NULL DEFAULT '',
+		country VARCHAR(2) NOT NULL,
+		channel VARCHAR(20) NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		response_code VARCHAR(2) NOT NULL,
+		decline_reason TEXT NOT NULL DEFAULT '',
+		approval_code VARCHAR(6) NOT NULL DEFAULT '',
+		hold_id UUID REFERENCES account_holds(id),
+		cleared_amount DECIMAL(15, 2),
+		transaction_id UUID REFERENCES ledger_transactions(id),
+		cleared_at TIMESTAMP,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_card_authorizations_card ON card_authorizations(card_id, created_at);
+	CREATE TABLE IF NOT EXISTS card_limit_usage (
+		card_id UUID NOT NULL REFERENCES cards(id),
+		atm BOOLEAN NOT NULL,
+		usage_date DATE NOT NULL,
+		amount DECIMAL(15, 2) NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (card_id, atm, usage_date)
+	);
+	`
+	if _, err := db.Exec(query); err != nil {
+		return err
+	}
+
+	seed := `
+	INSERT INTO accounts (id, account_number, account_type, currency, balance, status, created_at, updated_at)
+	VALUES (gen_random_uuid(), $1, 'internal', $2, 0, 'active', NOW(), NOW())
+	ON CONFLICT (account_number) DO NOTHING
+	`
+	if _, err := db.Exec(seed, models.GLCardSettlement, models.BaseCurrency); err != nil {
+		return err
+	}
+
+	log.Println("Card authorizations table initialized")
+	return nil
+}
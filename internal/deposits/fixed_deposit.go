@@ -0,0 +1,256 @@
+package deposits
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/google/uuid"
+)
+
+// ErrNotActive is returned when a fixed deposit is no longer running its term
+var ErrNotActive = errors.New("fixed deposit is not active")
+
+// daysInYear is the day count basis for fixed deposit interest (actual/365)
+const daysInYear = 365
+
+// Service handles placement, maturity and early withdrawal of fixed deposits
+type Service struct {
+	accountRepo        repository.AccountRepository
+	productRepo        repository.ProductRepository
+	depositRepo        repository.FixedDepositRepository
+	ledger             *ledger.Service
+	withholdingTaxRate float64
+}
+
+// NewService creates a new fixed deposit Service
+func NewService(
+	accountRepo repository.AccountRepository,
+	productRepo repository.ProductRepository,
+	depositRepo repository.FixedDepositRepository,
+	ledgerService *ledger.Service,
+	withholdingTaxRate float64,
+) *Service {
+	return &Service{
+		accountRepo:        accountRepo,
+		productRepo:        productRepo,
+		depositRepo:        depositRepo,
+		ledger:             ledgerService,
+		withholdingTaxRate: withholdingTaxRate,
+	}
+}
+
+// Interest returns the simple interest on principal between two dates using actual/365
+func Interest(principal, annualRate float64, from, to time.Time) float64 {
+	days := math.Round(to.Sub(from).Hours() / 24)
+	if days <= 0 {
+		return 0
+	}
+	return round2(principal * annualRate * days / daysInYear)
+}
+
+// Place moves the principal from the funding account into the new fixed
+// deposit account and records the term with the rate locked at opening.
+// Both are written in one database transaction, so a failed placement leaves
+// the new account empty and the funding account untouched.
+func (s *Service) Place(ctx context.Context, account *models.Account, funding *models.Account, request models.OpenFixedDepositRequest, annualRate float64) (*models.FixedDeposit, error) {
+	now := time.Now()
+	start := businessDate(now)
+
+	linked := funding.ID
+	if request.LinkedAccountID != nil {
+		linked = *request.LinkedAccountID
+	}
+
+	deposit := &models.FixedDeposit{
+		AccountID:           account.ID,
+		Principal:           request.Amount,
+		TermMonths:          request.TermMonths,
+		AnnualRate:          annualRate,
+		StartDate:           start,
+		MaturityDate:        start.AddDate(0, request.TermMonths, 0),
+		MaturityInstruction: request.MaturityInstruction,
+		LinkedAccountID:     linked,
+		Status:              models.FixedDepositActive,
+		UpdatedAt:           now,
+	}
+
+	err := s.ledger.Post(ctx, &models.LedgerTransaction{
+		Reference:         "fd-placement:" + account.ID.String(),
+		Type:              models.LedgerFixedDepositPlacement,
+		Description:       fmt.Sprintf("Fixed deposit %s placement", account.AccountNumber),
+		OpensFixedDeposit: deposit,
+		Entries: []models.LedgerEntry{
+			ledger.Debit(funding.ID, request.Amount),
+			ledger.Credit(account.ID, request.Amount),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deposit, nil
+}
+
+// Mature processes a deposit that reached its maturity date according to its
+// maturity instruction. Ledger references are derived from the maturity date,
+// so processing the same maturity twice does not post twice.
+func (s *Service) Mature(ctx context.Context, deposit *models.FixedDeposit) error {
+	if deposit.Status != models.FixedDepositActive {
+		return ErrNotActive
+	}
+
+	account, err := s.accountRepo.GetAccountByID(ctx, deposit.AccountID)
+	if err != nil {
+		return err
+	}
+	if account == nil {
+		return repository.ErrAccountNotFound
+	}
+
+	key := fmt.Sprintf("%s:%s", deposit.AccountID, deposit.MaturityDate.Format("2006-01-02"))
+	gross := Interest(deposit.Principal, deposit.AnnualRate, deposit.StartDate, deposit.MaturityDate)
+
+	// Interest is paid to the linked account for payout_interest and stays
+	// in the deposit otherwise
+	interestTo := deposit.AccountID
+	if deposit.MaturityInstruction == models.MaturityPayoutInterest {
+		interestTo = deposit.LinkedAccountID
+	}
+	net, err := s.postInterest(ctx, "fd-interest:"+key, interestTo, gross, deposit.MaturityDate)
+	if err != nil {
+		return err
+	}
+
+	newPrincipal := deposit.Principal
+	if interestTo == deposit.AccountID {
+		newPrincipal = round2(deposit.Principal + net)
+	}
+
+	rate, renewable := s.renewalRate(ctx, account, deposit.TermMonths)
+	if deposit.MaturityInstruction == models.MaturityTransferToSavings || !renewable {
+		return s.payOut(ctx, "fd-payout:"+key, deposit, newPrincipal, models.FixedDepositMatured)
+	}
+
+	deposit.Principal = newPrincipal
+	deposit.AnnualRate = rate
+	deposit.StartDate = deposit.MaturityDate
+	deposit.MaturityDate = deposit.MaturityDate.AddDate(0, deposit.TermMonths, 0)
+	deposit.Renewals++
+	deposit.UpdatedAt = time.Now()
+	return s.depositRepo.UpdateFixedDeposit(ctx, deposit)
+}
+
+// WithdrawEarly closes a deposit before maturity. Interest for the days held
+// is paid at the product's early withdrawal rate instead of the locked rate.
+func (s *Service) WithdrawEarly(ctx context.Context, account *models.Account, deposit *models.FixedDeposit, asOf time.Time) error {
+	if deposit.Status != models.FixedDepositActive {
+		return ErrNotActive
+	}
+
+	product, err := s.productRepo.GetProductVersion(ctx, account.ProductCode, account.ProductVersion)
+	if err != nil {
+		return err
+	}
+	penaltyRate := 0.0
+	if product != nil {
+		penaltyRate = product.EarlyWithdrawalRate
+	}
+
+	asOf = businessDate(asOf)
+	key := fmt.Sprintf("%s:%s", deposit.AccountID, deposit.StartDate.Format("2006-01-02"))
+	gross := Interest(deposit.Principal, penaltyRate, deposit.StartDate, asOf)
+	net, err := s.postInterest(ctx, "fd-early-interest:"+key, deposit.AccountID, gross, asOf)
+	if err != nil {
+		return err
+	}
+
+	return s.payOut(ctx, "fd-early-payout:"+key, deposit, round2(deposit.Principal+net), models.FixedDepositWithdrawn)
+}
+
+// postInterest credits gross interest to an account and deducts withholding
+// tax in the same ledger transaction. It returns the net interest.
+func (s *Service) postInterest(ctx context.Context, reference string, accountID uuid.UUID, gross float64, date time.Time) (float64, error) {
+	if gross <= 0 {
+		return 0, nil
+	}
+
+	expense, err := repository.GetInternalAccount(ctx, s.accountRepo, models.GLInterestExpense)
+	if err != nil {
+		return 0, err
+	}
+
+	txn := &models.LedgerTransaction{
+		Reference:    reference,
+		Type:         models.LedgerFixedDepositInterest,
+		Description:  "Fixed deposit interest",
+		BusinessDate: date,
+		Entries: []models.LedgerEntry{
+			ledger.Debit(expense.ID, gross),
+			ledger.Credit(accountID, gross),
+		},
+	}
+
+	tax := round2(gross * s.withholdingTaxRate)
+	if tax > 0 {
+		payable, err := repository.GetInternalAccount(ctx, s.accountRepo, models.GLWithholdingTaxPayable)
+		if err != nil {
+			return 0, err
+		}
+		txn.Entries = append(txn.Entries, ledger.Debit(accountID, tax), ledger.Credit(payable.ID, tax))
+	}
+
+	if err := s.ledger.Post(ctx, txn); err != nil && !errors.Is(err, repository.ErrDuplicateReference) {
+		return 0, err
+	}
+	return round2(gross - tax), nil
+}
+
+// payOut transfers amount from the deposit to its linked account and closes the deposit
+func (s *Service) payOut(ctx context.Context, reference string, deposit *models.FixedDeposit, amount float64, status models.FixedDepositStatus) error {
+	if amount > 0 {
+		err := s.ledger.Post(ctx, &models.LedgerTransaction{
+			Reference:   reference,
+			Type:        models.LedgerFixedDepositPayout,
+			Description: "Fixed deposit payout",
+			Entries: []models.LedgerEntry{
+				ledger.Debit(deposit.AccountID, amount),
+				ledger.Credit(deposit.LinkedAccountID, amount),
+			},
+		})
+		if err != nil && !errors.Is(err, repository.ErrDuplicateReference) {
+			return err
+		}
+	}
+
+	if err := s.accountRepo.UpdateAccountStatus(ctx, deposit.AccountID, models.AccountStatusClosed); err != nil {
+		return err
+	}
+
+	deposit.Status = status
+	deposit.UpdatedAt = time.Now()
+	return s.depositRepo.UpdateFixedDeposit(ctx, deposit)
+}
+
+// renewalRate returns the current rate for the term on the latest version of
+// the account's product. A deposit cannot renew when the product was retired
+// or no longer offers the term.
+func (s *Service) renewalRate(ctx context.Context, account *models.Account, termMonths int) (float64, bool) {
+	product, err := s.productRepo.GetLatestProduct(ctx, account.ProductCode)
+	if err != nil || product == nil || product.Status != models.ProductStatusActive {
+		return 0, false
+	}
+	return product.RateForTerm(termMonths)
+}
+
+func businessDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
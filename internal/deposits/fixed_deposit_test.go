@@ -0,0 +1,239 @@
+package deposits
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/repository/repotest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubAccountRepository updates the status of the in-memory accounts
+type stubAccountRepository struct {
+	repotest.Accounts
+}
+
+func (r *stubAccountRepository) UpdateAccountStatus(ctx context.Context, id uuid.UUID, status models.AccountStatus) error {
+	account, _ := r.GetAccountByID(ctx, id)
+	account.Status = status
+	return nil
+}
+
+// stubProductRepository returns one fixed deposit product
+type stubProductRepository struct {
+	repository.ProductRepository
+	product *models.AccountProduct
+}
+
+func (r *stubProductRepository) GetLatestProduct(ctx context.Context, code string) (*models.AccountProduct, error) {
+	return r.product, nil
+}
+
+func (r *stubProductRepository) GetProductVersion(ctx context.Context, code string, version int) (*models.AccountProduct, error) {
+	return r.product, nil
+}
+
+// stubFixedDepositRepository keeps the last saved copy of each deposit
+type stubFixedDepositRepository struct {
+	repository.FixedDepositRepository
+	deposits map[uuid.UUID]models.FixedDeposit
+}
+
+func (r *stubFixedDepositRepository) UpdateFixedDeposit(ctx context.Context, deposit *models.FixedDeposit) error {
+	r.deposits[deposit.AccountID] = *deposit
+	return nil
+}
+
+// stubLedgerRepository applies postings to the accounts in memory and
+// saves the deposits they open
+type stubLedgerRepository struct {
+	repository.LedgerRepository
+	accounts map[uuid.UUID]*models.Account
+	deposits *stubFixedDepositRepository
+	posted   map[string]*models.LedgerTransaction
+}
+
+func (r *stubLedgerRepository) PostTransaction(ctx context.Context, txn *models.LedgerTransaction, validate repository.PostingValidator) error {
+	if r.posted[txn.Reference] != nil {
+		return repository.ErrDuplicateReference
+	}
+	if err := validate(r.accounts, txn); err != nil {
+		return err
+	}
+	for _, entry := range txn.Entries {
+		r.accounts[entry.AccountID].Balance += entry.SignedAmount()
+	}
+	if txn.OpensFixedDeposit != nil {
+		r.deposits.deposits[txn.OpensFixedDeposit.AccountID] = *txn.OpensFixedDeposit
+	}
+	r.posted[txn.Reference] = txn
+	return nil
+}
+
+type fixture struct {
+	service  *Service
+	products *stubProductRepository
+	deposits *stubFixedDepositRepository
+	savings  *models.Account
+	deposit  *models.Account
+	expense  *models.Account
+	tax      *models.Account
+}
+
+func newFixture() *fixture {
+	f := &fixture{
+		products: &stubProductRepository{product: &models.AccountProduct{
+			Code:                "FD",
+			Version:             1,
+			Category:            models.AccountTypeFixedDeposit,
+			TermRates:           []models.TermRate{{TermMonths: 3, AnnualRate: 0.01}, {TermMonths: 12, AnnualRate: 0.015}},
+			EarlyWithdrawalRate: 0.0025,
+			Status:              models.ProductStatusActive,
+		}},
+		deposits: &stubFixedDepositRepository{deposits: map[uuid.UUID]models.FixedDeposit{}},
+		savings:  repotest.Account("1000000001", 150000),
+		deposit:  repotest.Account("1000000002", 0),
+		expense:  repotest.InternalAccount(models.GLInterestExpense),
+		tax:      repotest.InternalAccount(models.GLWithholdingTaxPayable),
+	}
+	f.deposit.AccountType, f.deposit.ProductCode, f.deposit.ProductVersion = models.AccountTypeFixedDeposit, "FD", 1
+	all := []*models.Account{f.savings, f.deposit, f.expense, f.tax}
+	ledgerRepo := &stubLedgerRepository{accounts: repotest.ByID(all...), deposits: f.deposits, posted: map[string]*models.LedgerTransaction{}}
+	accounts := &stubAccountRepository{repotest.Accounts{Accounts: all}}
+	f.service = NewService(accounts, f.products, f.deposits, ledger.NewService(ledgerRepo), 0.15)
+	return f
+}
+
+// placed returns a 3 month deposit of 100,000 at 0.9% running from 15 January
+func (f *fixture) placed(instruction models.MaturityInstruction) *models.FixedDeposit {
+	f.savings.Balance -= 100000
+	f.deposit.Balance = 100000
+	start := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	return &models.FixedDeposit{
+		AccountID:           f.deposit.ID,
+		Principal:           100000,
+		TermMonths:          3,
+		AnnualRate:          0.009,
+		StartDate:           start,
+		MaturityDate:        start.AddDate(0, 3, 0),
+		MaturityInstruction: instruction,
+		LinkedAccountID:     f.savings.ID,
+		Status:              models.FixedDepositActive,
+	}
+}
+
+func TestInterest(t *testing.T) {
+	start := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	// 100,000 at 1.5% for 365 days
+	assert.Equal(t, 1500.0, Interest(100000, 0.015, start, start.AddDate(1, 0, 0)))
+	// 3 months from 15 January is 90 days
+	assert.Equal(t, 221.92, Interest(100000, 0.009, start, start.AddDate(0, 3, 0)))
+	assert.Equal(t, 0.0, Interest(100000, 0.015, start, start))
+	assert.Equal(t, 0.0, Interest(100000, 0.015, start, start.AddDate(0, 0, -1)))
+}
+
+func TestPlaceMovesPrincipalAndLocksRate(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+
+	request := models.OpenFixedDepositRequest{Amount: 100000, TermMonths: 12, MaturityInstruction: models.MaturityAutoRenew, FundingAccountID: f.savings.ID}
+	deposit, err := f.service.Place(ctx, f.deposit, f.savings, request, 0.015)
+	require.NoError(t, err)
+	assert.Equal(t, 50000.0, f.savings.Balance)
+	assert.Equal(t, 100000.0, f.deposit.Balance)
+	assert.Equal(t, 0.015, deposit.AnnualRate)
+	assert.Equal(t, deposit.StartDate.AddDate(1, 0, 0), deposit.MaturityDate)
+	assert.Equal(t, f.savings.ID, deposit.LinkedAccountID, "interest and payouts go back to the funding account by default")
+	assert.Equal(t, *deposit, f.deposits.deposits[f.deposit.ID])
+
+	// Placing the same deposit account again posts nothing
+	_, err = f.service.Place(ctx, f.deposit, f.savings, request, 0.015)
+	assert.ErrorIs(t, err, repository.ErrDuplicateReference)
+	assert.Equal(t, 50000.0, f.savings.Balance)
+}
+
+func TestPlaceNeedsFundsInFundingAccount(t *testing.T) {
+	f := newFixture()
+
+	request := models.OpenFixedDepositRequest{Amount: 200000, TermMonths: 12, MaturityInstruction: models.MaturityAutoRenew, FundingAccountID: f.savings.ID}
+	_, err := f.service.Place(context.Background(), f.deposit, f.savings, request, 0.015)
+	assert.ErrorIs(t, err, ledger.ErrInsufficientFunds)
+	assert.Empty(t, f.deposits.deposits)
+}
+
+func TestMatureRenewsWithInterestAtCurrentRate(t *testing.T) {
+	f := newFixture()
+	deposit := f.placed(models.MaturityAutoRenew)
+	maturity := deposit.MaturityDate
+
+	require.NoError(t, f.service.Mature(context.Background(), deposit))
+	// 221.92 gross less 33.29 withholding tax stays in the deposit
+	assert.Equal(t, 100188.63, deposit.Principal)
+	assert.Equal(t, 100188.63, f.deposit.Balance)
+	assert.Equal(t, 33.29, f.tax.Balance)
+	assert.Equal(t, -221.92, f.expense.Balance)
+	assert.Equal(t, 0.01, deposit.AnnualRate, "the renewal takes the product's current rate")
+	assert.Equal(t, maturity, deposit.StartDate)
+	assert.Equal(t, maturity.AddDate(0, 3, 0), deposit.MaturityDate)
+	assert.Equal(t, 1, deposit.Renewals)
+	assert.Equal(t, models.FixedDepositActive, f.deposits.deposits[f.deposit.ID].Status)
+}
+
+func TestMaturePaysOutInterestAndRenewsPrincipal(t *testing.T) {
+	f := newFixture()
+	deposit := f.placed(models.MaturityPayoutInterest)
+
+	require.NoError(t, f.service.Mature(context.Background(), deposit))
+	assert.Equal(t, 100000.0, deposit.Principal)
+	assert.Equal(t, 100000.0, f.deposit.Balance)
+	assert.Equal(t, 50188.63, f.savings.Balance)
+	assert.Equal(t, models.FixedDepositActive, deposit.Status)
+}
+
+func TestMatureTransfersToSavings(t *testing.T) {
+	f := newFixture()
+	deposit := f.placed(models.MaturityTransferToSavings)
+	ctx := context.Background()
+
+	require.NoError(t, f.service.Mature(ctx, deposit))
+	assert.Equal(t, models.FixedDepositMatured, deposit.Status)
+	assert.Equal(t, 0.0, f.deposit.Balance)
+	assert.Equal(t, 150188.63, f.savings.Balance)
+	assert.Equal(t, models.AccountStatusClosed, f.deposit.Status)
+
+	assert.ErrorIs(t, f.service.Mature(ctx, deposit), ErrNotActive)
+}
+
+func TestMaturePaysOutWhenTermIsNoLongerOffered(t *testing.T) {
+	f := newFixture()
+	f.products.product.TermRates = f.products.product.TermRates[1:]
+	deposit := f.placed(models.MaturityAutoRenew)
+
+	require.NoError(t, f.service.Mature(context.Background(), deposit))
+	assert.Equal(t, models.FixedDepositMatured, deposit.Status)
+	assert.Equal(t, 150188.63, f.savings.Balance)
+}
+
+func TestWithdrawEarlyPaysPenaltyRate(t *testing.T) {
+	f := newFixture()
+	deposit := f.placed(models.MaturityAutoRenew)
+	ctx := context.Background()
+
+	// 73 days at the 0.25% early withdrawal rate instead of the locked 0.9%
+	asOf := deposit.StartDate.AddDate(0, 0, 73).Add(15 * time.Hour)
+	require.NoError(t, f.service.WithdrawEarly(ctx, f.deposit, deposit, asOf))
+	assert.Equal(t, 7.5, f.tax.Balance)
+	assert.Equal(t, 150042.5, f.savings.Balance)
+	assert.Equal(t, 0.0, f.deposit.Balance)
+	assert.Equal(t, models.FixedDepositWithdrawn, deposit.Status)
+	assert.Equal(t, models.AccountStatusClosed, f.deposit.Status)
+
+	assert.ErrorIs(t, f.service.WithdrawEarly(ctx, f.deposit, deposit, asOf), ErrNotActive)
+}
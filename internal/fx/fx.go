@@ -0,0 +1,379 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/limits"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNoRates is returned when an upload contains no rates
+	ErrNoRates = errors.New("at least one rate is required")
+	// ErrInvalidRate is returned for an upload row with an unknown currency, a non-positive rate or a spread out of range
+	ErrInvalidRate = errors.New("invalid rate")
+	// ErrInvalidAmount is returned when the amount is not positive or has more decimals than its currency
+	ErrInvalidAmount = errors.New("amount must be greater than zero with no more decimals than the currency allows")
+	// ErrAmountTooSmall is returned when the converted amount rounds to zero
+	ErrAmountTooSmall = errors.New("amount is too small to convert")
+	// ErrSameCurrency is returned when both accounts are in the same currency, which is a plain transfer
+	ErrSameCurrency = errors.New("accounts are in the same currency; make a transfer instead")
+	// ErrRateUnavailable is returned when there is no rate for a currency of the conversion
+	ErrRateUnavailable = errors.New("no exchange rate is available for this currency")
+	// ErrDestinationNotFound is returned when the destination account does not exist
+	ErrDestinationNotFound = errors.New("destination account not found")
+	// ErrQuoteNotFound is returned when the quote does not exist for the account
+	ErrQuoteNotFound = errors.New("quote not found")
+	// ErrQuoteExpired is returned when the quote was not executed in time
+	ErrQuoteExpired = errors.New("quote has expired; request a new quote")
+	// ErrQuoteUsed is returned when the quote was already executed
+	ErrQuoteUsed = errors.New("quote was already executed")
+)
+
+// Limiter counts conversions against the customer's transaction limits
+type Limiter interface {
+	Reserve(ctx context.Context, payment limits.Payment) (*limits.Reservation, error)
+	Release(ctx context.Context, reservation *limits.Reservation) error
+}
+
+// Service keeps the rate table and converts money between accounts held in
+// different currencies. A conversion is quoted first and executed at the
+// quoted rate while the quote is valid.
+type Service struct {
+	repo        repository.FXRepository
+	accountRepo repository.AccountRepository
+	ledger      *ledger.Service
+	limits      Limiter
+	cfg         config.FXConfig
+	now         func() time.Time
+}
+
+// NewService creates a new FX Service
+func NewService(repo repository.FXRepository, accountRepo repository.AccountRepository, ledgerService *ledger.Service, limiter Limiter, cfg config.FXConfig) *Service {
+	return &Service{
+		repo:        repo,
+		accountRepo: accountRepo,
+		ledger:      ledgerService,
+		limits:      limiter,
+		cfg:         cfg,
+		now:         time.Now,
+	}
+}
+
+// UploadRates validates and saves a staff rate table upload
+func (s *Service) UploadRates(ctx context.Context, req models.FXRateUploadRequest, staffID uuid.UUID) ([]*models.FXRate, error) {
+	if len(req.Rates) == 0 {
+		return nil, ErrNoRates
+	}
+
+	now := s.now()
+	effectiveAt := now
+	if req.EffectiveAt != nil {
+		effectiveAt = *req.EffectiveAt
+	}
+
+	seen := map[models.Currency]bool{}
+	rates := make([]*models.FXRate, 0, len(req.Rates))
+	for i, input := range req.Rates {
+		currency, ok := models.ParseCurrency(input.Currency)
+		if !ok || currency == models.BaseCurrency || input.Currency == "" {
+			return nil, fmt.Errorf("%w: row %d: unsupported currency %q", ErrInvalidRate, i+1, input.Currency)
+		}
+		if seen[currency] {
+			return nil, fmt.Errorf("%w: row %d: %s appears more than once", ErrInvalidRate, i+1, currency)
+		}
+		seen[currency] = true
+		if input.MidRate <= 0 {
+			return nil, fmt.Errorf("%w: row %d: mid_rate must be greater than zero", ErrInvalidRate, i+1)
+		}
+		if input.BuySpread < 0 || input.BuySpread > s.cfg.MaxSpread || input.SellSpread < 0 || input.SellSpread > s.cfg.MaxSpread {
+			return nil, fmt.Errorf("%w: row %d: spreads must be between 0 and %g", ErrInvalidRate, i+1, s.cfg.MaxSpread)
+		}
+
+		rate := &models.FXRate{
+			ID:          uuid.New(),
+			Currency:    currency,
+			MidRate:     input.MidRate,
+			BuySpread:   input.BuySpread,
+			SellSpread:  input.SellSpread,
+			EffectiveAt: effectiveAt,
+			UploadedBy:  staffID,
+			CreatedAt:   now,
+		}
+		rate.ApplySpreads()
+		rates = append(rates, rate)
+	}
+
+	if err := s.repo.SaveRates(ctx, rates); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// CurrentRates returns the rate of every currency in effect now
+func (s *Service) CurrentRates(ctx context.Context) ([]*models.FXRate, error) {
+	return s.repo.GetCurrentRates(ctx, s.now())
+}
+
+// Valuer values money in the base currency from the rate table. The limit
+// service uses it to value payments, so it stands apart from Service, which
+// in turn counts conversions against those limits.
+type Valuer struct {
+	repo repository.FXRepository
+	now  func() time.Time
+}
+
+// NewValuer creates a Valuer that reads the rate table from repo
+func NewValuer(repo repository.FXRepository) *Valuer {
+	return &Valuer{
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+// BaseValue values money in the base currency at the current mid rate
+func (v *Valuer) BaseValue(ctx context.Context, money models.Money) (float64, error) {
+	if money.Currency == "" || money.Currency == models.BaseCurrency {
+		return money.Amount, nil
+	}
+
+	rates, err := v.repo.GetCurrentRates(ctx, v.now())
+	if err != nil {
+		return 0, err
+	}
+	for _, rate := range rates {
+		if rate.Currency == money.Currency {
+			return models.BaseCurrency.Round(money.Amount * rate.MidRate), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s", ErrRateUnavailable, money.Currency)
+}
+
+// Conversion is the result of pricing an amount in another currency
+type Conversion struct {
+	To   models.Money
+	Rate float64
+	// FromBaseValue and ToBaseValue are both legs at the mid rate in the base currency
+	FromBaseValue float64
+	ToBaseValue   float64
+}
+
+// Convert prices amount of currency from in currency to. The bank buys the
+// source currency at its buy rate and sells the target currency at its sell
+// rate, crossing through the base currency when neither is the base.
+func Convert(amount float64, from, to models.Currency, rates map[models.Currency]*models.FXRate) (*Conversion, error) {
+	if !from.ValidAmount(amount) {
+		return nil, ErrInvalidAmount
+	}
+	if from == to {
+		return nil, ErrSameCurrency
+	}
+
+	base := models.BaseCurrency
+	clientBase, fromMid := amount, amount
+	if from != base {
+		rate, ok := rates[from]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrRateUnavailable, from)
+		}
+		clientBase = amount * rate.BuyRate
+		fromMid = amount * rate.MidRate
+	}
+
+	converted := &Conversion{FromBaseValue: base.Round(fromMid)}
+	if to == base {
+		converted.To = models.Money{Amount: base.Round(clientBase), Currency: to}
+		converted.ToBaseValue = converted.To.Amount
+	} else {
+		rate, ok := rates[to]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrRateUnavailable, to)
+		}
+		converted.To = models.Money{Amount: to.Round(clientBase / rate.SellRate), Currency: to}
+		converted.ToBaseValue = base.Round(converted.To.Amount * rate.MidRate)
+	}
+	if converted.To.Amount <= 0 {
+		return nil, ErrAmountTooSmall
+	}
+	converted.Rate = math.Round(converted.To.Amount/amount*1e10) / 1e10
+	return converted, nil
+}
+
+// Quote prices a conversion from one of the customer's accounts to an
+// account in another currency. The quote can be executed until it expires.
+func (s *Service) Quote(ctx context.Context, from *models.Account, req models.FXQuoteRequest, customerID uuid.UUID) (*models.FXQuote, error) {
+	to, err := s.accountRepo.GetAccountByNumber(ctx, strings.TrimSpace(req.ToAccountNumber))
+	if err != nil {
+		return nil, err
+	}
+	if to == nil || to.IsInternal() {
+		return nil, ErrDestinationNotFound
+	}
+
+	list, err := s.CurrentRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rates := map[models.Currency]*models.FXRate{}
+	for _, rate := range list {
+		rates[rate.Currency] = rate
+	}
+
+	converted, err := Convert(req.Amount, from.Currency, to.Currency, rates)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	quote := &models.FXQuote{
+		ID:            uuid.New(),
+		CustomerID:    customerID,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		From:          models.Money{Amount: req.Amount, Currency: from.Currency},
+		To:            converted.To,
+		Rate:          converted.Rate,
+		FromBaseValue: converted.FromBaseValue,
+		ToBaseValue:   converted.ToBaseValue,
+		Description:   strings.TrimSpace(req.Description),
+		Status:        models.FXQuoteOpen,
+		ExpiresAt:     now.Add(time.Duration(s.cfg.QuoteTTLSeconds) * time.Second),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.repo.CreateQuote(ctx, quote); err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
+
+// Execute posts the conversion of an open quote on the account. The quote
+// ID is the ledger reference, so a quote can be executed only once. The
+// converted amount is counted against the transfer limit of the customer who
+// asked for the quote, at its mid-rate value in the base currency.
+func (s *Service) Execute(ctx context.Context, from *models.Account, quoteID uuid.UUID) (*models.FXQuote, error) {
+	quote, err := s.repo.GetQuote(ctx, quoteID)
+	if err != nil {
+		return nil, err
+	}
+	if quote == nil || quote.FromAccountID != from.ID {
+		return nil, ErrQuoteNotFound
+	}
+	if quote.Status == models.FXQuoteExecuted {
+		return nil, ErrQuoteUsed
+	}
+
+	now := s.now()
+	if quote.Status == models.FXQuoteExpired || !quote.ExpiresAt.After(now) {
+		quote.Status = models.FXQuoteExpired
+		quote.UpdatedAt = now
+		if _, err := s.repo.UpdateQuoteStatus(ctx, quote, models.FXQuoteOpen); err != nil {
+			return nil, err
+		}
+		return nil, ErrQuoteExpired
+	}
+
+	txn, err := s.conversionTransaction(ctx, quote)
+	if err != nil {
+		return nil, err
+	}
+	reservation, err := s.limits.Reserve(ctx, limits.Payment{
+		CustomerID: quote.CustomerID,
+		Channel:    models.ChannelMobile,
+		Type:       models.LimitTransfer,
+		Amount:     models.Money{Amount: quote.FromBaseValue, Currency: models.BaseCurrency},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ledger.Post(ctx, txn); err != nil {
+		if releaseErr := s.limits.Release(ctx, reservation); releaseErr != nil {
+			log.Printf("failed to release limit usage for %s: %v", txn.Reference, releaseErr)
+		}
+		if errors.Is(err, repository.ErrDuplicateReference) {
+			return nil, ErrQuoteUsed
+		}
+		return nil, err
+	}
+
+	quote.Status = models.FXQuoteExecuted
+	quote.TransactionID = &txn.ID
+	quote.UpdatedAt = s.now()
+	if _, err := s.repo.UpdateQuoteStatus(ctx, quote, models.FXQuoteOpen); err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
+
+// conversionTransaction builds the ledger transaction of a quote. Each
+// foreign currency leg is balanced against the bank's position account in
+// that currency; the base currency leg carries the mid-rate value of the
+// positions and books the difference to the FX gain/loss account, so the
+// transaction balances in every currency.
+func (s *Service) conversionTransaction(ctx context.Context, quote *models.FXQuote) (*models.LedgerTransaction, error) {
+	base := models.BaseCurrency
+	entries := []models.LedgerEntry{ledger.DebitIn(quote.FromAccountID, quote.From)}
+	positionBase := 0.0
+
+	if quote.From.Currency != base {
+		position, err := repository.GetInternalAccount(ctx, s.accountRepo, models.CurrencyAccount(models.GLFXPosition, quote.From.Currency))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, ledger.CreditIn(position.ID, quote.From))
+		positionBase -= quote.FromBaseValue
+	}
+	if quote.To.Currency != base {
+		position, err := repository.GetInternalAccount(ctx, s.accountRepo, models.CurrencyAccount(models.GLFXPosition, quote.To.Currency))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, ledger.DebitIn(position.ID, quote.To))
+		positionBase += quote.ToBaseValue
+	}
+	entries = append(entries, ledger.CreditIn(quote.ToAccountID, quote.To))
+
+	if amount := base.Round(positionBase); amount != 0 {
+		position, err := repository.GetInternalAccount(ctx, s.accountRepo, models.GLFXPosition)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, signedEntry(position.ID, amount))
+	}
+	if gain := base.Round(quote.FromBaseValue - quote.ToBaseValue); gain != 0 {
+		account, err := repository.GetInternalAccount(ctx, s.accountRepo, models.GLFXGainLoss)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, signedEntry(account.ID, gain))
+	}
+
+	description := quote.Description
+	if description == "" {
+		description = fmt.Sprintf("Conversion %.2f %s to %.2f %s", quote.From.Amount, quote.From.Currency, quote.To.Amount, quote.To.Currency)
+	}
+	return &models.LedgerTransaction{
+		Reference:   "fx:" + quote.ID.String(),
+		Type:        models.LedgerFXConversion,
+		Description: description,
+		Entries:     entries,
+	}, nil
+}
+
+// signedEntry returns a base currency credit for a positive amount and a debit for a negative one
+func signedEntry(accountID uuid.UUID, amount float64) models.LedgerEntry {
+	if amount < 0 {
+		return ledger.DebitIn(accountID, models.Money{Amount: -amount, Currency: models.BaseCurrency})
+	}
+	return ledger.CreditIn(accountID, models.Money{Amount: amount, Currency: models.BaseCurrency})
+}
@@ -0,0 +1,226 @@
+package fx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/limits"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/repository/repotest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRates() map[models.Currency]*models.FXRate {
+	rates := map[models.Currency]*models.FXRate{
+		models.CurrencyUSD: {Currency: models.CurrencyUSD, MidRate: 35.00, BuySpread: 0.01, SellSpread: 0.01},
+		models.CurrencyJPY: {Currency: models.CurrencyJPY, MidRate: 0.25, BuySpread: 0.02, SellSpread: 0.02},
+	}
+	for _, rate := range rates {
+		rate.ApplySpreads()
+	}
+	return rates
+}
+
+func TestConvert(t *testing.T) {
+	rates := testRates()
+
+	// The bank buys USD below mid: 100 USD at 34.65
+	converted, err := Convert(100, models.CurrencyUSD, models.CurrencyTHB, rates)
+	require.NoError(t, err)
+	assert.Equal(t, models.Money{Amount: 3465, Currency: models.CurrencyTHB}, converted.To)
+	assert.Equal(t, 3500.0, converted.FromBaseValue)
+	assert.Equal(t, 3465.0, converted.ToBaseValue)
+
+	// The bank sells USD above mid: 3,535 THB buys 100 USD at 35.35
+	converted, err = Convert(3535, models.CurrencyTHB, models.CurrencyUSD, rates)
+	require.NoError(t, err)
+	assert.Equal(t, models.Money{Amount: 100, Currency: models.CurrencyUSD}, converted.To)
+	assert.Equal(t, 3535.0, converted.FromBaseValue)
+	assert.Equal(t, 3500.0, converted.ToBaseValue)
+
+	// Cross rates go through THB and JPY has no minor unit
+	converted, err = Convert(10, models.CurrencyUSD, models.CurrencyJPY, rates)
+	require.NoError(t, err)
+	assert.Equal(t, models.Money{Amount: 1359, Currency: models.CurrencyJPY}, converted.To) // 346.5 / 0.255
+
+	_, err = Convert(1.5, models.CurrencyJPY, models.CurrencyTHB, rates)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+	_, err = Convert(100, models.CurrencyEUR, models.CurrencyTHB, rates)
+	assert.ErrorIs(t, err, ErrRateUnavailable)
+	_, err = Convert(100, models.CurrencyUSD, models.CurrencyUSD, rates)
+	assert.ErrorIs(t, err, ErrSameCurrency)
+}
+
+// stubFXRepository keeps quotes in memory
+type stubFXRepository struct {
+	repository.FXRepository
+	rates  []*models.FXRate
+	quotes map[uuid.UUID]models.FXQuote
+}
+
+func (r *stubFXRepository) GetCurrentRates(ctx context.Context, at time.Time) ([]*models.FXRate, error) {
+	return r.rates, nil
+}
+
+func (r *stubFXRepository) CreateQuote(ctx context.Context, quote *models.FXQuote) error {
+	r.quotes[quote.ID] = *quote
+	return nil
+}
+
+func (r *stubFXRepository) GetQuote(ctx context.Context, id uuid.UUID) (*models.FXQuote, error) {
+	quote, ok := r.quotes[id]
+	if !ok {
+		return nil, nil
+	}
+	return &quote, nil
+}
+
+func (r *stubFXRepository) UpdateQuoteStatus(ctx context.Context, quote *models.FXQuote, from models.FXQuoteStatus) (bool, error) {
+	if r.quotes[quote.ID].Status != from {
+		return false, nil
+	}
+	r.quotes[quote.ID] = *quote
+	return true, nil
+}
+
+// stubLimiter records the payments it reserves and refuses those above max
+type stubLimiter struct {
+	max      float64
+	reserved []limits.Payment
+	released int
+}
+
+func (l *stubLimiter) Reserve(ctx context.Context, payment limits.Payment) (*limits.Reservation, error) {
+	if payment.Amount.Amount > l.max {
+		return nil, limits.ErrLimitExceeded
+	}
+	l.reserved = append(l.reserved, payment)
+	return &limits.Reservation{}, nil
+}
+
+func (l *stubLimiter) Release(ctx context.Context, reservation *limits.Reservation) error {
+	l.released++
+	return nil
+}
+
+// stubLedgerRepository validates postings against in-memory accounts and
+// rejects a reference posted twice
+type stubLedgerRepository struct {
+	repository.LedgerRepository
+	accounts map[uuid.UUID]*models.Account
+	posted   map[string]*models.LedgerTransaction
+}
+
+func (r *stubLedgerRepository) PostTransaction(ctx context.Context, txn *models.LedgerTransaction, validate repository.PostingValidator) error {
+	if r.posted[txn.Reference] != nil {
+		return repository.ErrDuplicateReference
+	}
+	if err := validate(r.accounts, txn); err != nil {
+		return err
+	}
+	for _, entry := range txn.Entries {
+		r.accounts[entry.AccountID].Balance += entry.SignedAmount()
+	}
+	r.posted[txn.Reference] = txn
+	return nil
+}
+
+func TestQuoteAndExecuteBalancesPerCurrency(t *testing.T) {
+	newAccount := func(number string, accountType models.AccountType, currency models.Currency, balance float64) *models.Account {
+		return &models.Account{ID: uuid.New(), AccountNumber: number, AccountType: accountType, Currency: currency, Balance: balance, Status: models.AccountStatusActive}
+	}
+	usd := newAccount("1000000001", models.AccountTypeSavings, models.CurrencyUSD, 500)
+	thb := newAccount("1000000002", models.AccountTypeSavings, models.CurrencyTHB, 0)
+	positionUSD := newAccount(models.CurrencyAccount(models.GLFXPosition, models.CurrencyUSD), models.AccountTypeInternal, models.CurrencyUSD, 0)
+	positionTHB := newAccount(models.GLFXPosition, models.AccountTypeInternal, models.CurrencyTHB, 0)
+	gainLoss := newAccount(models.GLFXGainLoss, models.AccountTypeInternal, models.CurrencyTHB, 0)
+	all := []*models.Account{usd, thb, positionUSD, positionTHB, gainLoss}
+
+	ledgerRepo := &stubLedgerRepository{accounts: map[uuid.UUID]*models.Account{}, posted: map[string]*models.LedgerTransaction{}}
+	for _, account := range all {
+		ledgerRepo.accounts[account.ID] = account
+	}
+	rates := testRates()
+	fxRepo := &stubFXRepository{rates: []*models.FXRate{rates[models.CurrencyUSD]}, quotes: map[uuid.UUID]models.FXQuote{}}
+	limiter := &stubLimiter{max: 5000}
+	service := NewService(fxRepo, &repotest.Accounts{Accounts: all}, ledger.NewService(ledgerRepo), limiter, config.FXConfig{QuoteTTLSeconds: 60})
+	now := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	customerID := uuid.New()
+	quote, err := service.Quote(ctx, usd, models.FXQuoteRequest{ToAccountNumber: thb.AccountNumber, Amount: 100}, customerID)
+	require.NoError(t, err)
+	assert.Equal(t, 3465.0, quote.To.Amount)
+	assert.Equal(t, models.FXQuoteOpen, quote.Status)
+
+	quote, err = service.Execute(ctx, usd, quote.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.FXQuoteExecuted, quote.Status)
+	assert.Equal(t, 400.0, usd.Balance)
+	assert.Equal(t, 3465.0, thb.Balance)
+	assert.Equal(t, 100.0, positionUSD.Balance)
+	assert.Equal(t, -3500.0, positionTHB.Balance)
+	assert.Equal(t, 35.0, gainLoss.Balance, "the spread is booked as FX gain")
+	require.Len(t, limiter.reserved, 1)
+	assert.Equal(t, customerID, limiter.reserved[0].CustomerID)
+	assert.Equal(t, models.LimitTransfer, limiter.reserved[0].Type)
+	assert.Equal(t, models.Money{Amount: 3500, Currency: models.CurrencyTHB}, limiter.reserved[0].Amount, "counted at the mid-rate value of the source")
+
+	_, err = service.Execute(ctx, usd, quote.ID)
+	assert.ErrorIs(t, err, ErrQuoteUsed)
+
+	overLimit, err := service.Quote(ctx, usd, models.FXQuoteRequest{ToAccountNumber: thb.AccountNumber, Amount: 200}, customerID)
+	require.NoError(t, err)
+	_, err = service.Execute(ctx, usd, overLimit.ID)
+	assert.ErrorIs(t, err, limits.ErrLimitExceeded)
+	assert.Equal(t, models.FXQuoteOpen, fxRepo.quotes[overLimit.ID].Status)
+	assert.Equal(t, 400.0, usd.Balance)
+
+	insufficient, err := service.Quote(ctx, usd, models.FXQuoteRequest{ToAccountNumber: thb.AccountNumber, Amount: 100}, customerID)
+	require.NoError(t, err)
+	usd.Balance = 50
+	_, err = service.Execute(ctx, usd, insufficient.ID)
+	assert.ErrorIs(t, err, ledger.ErrInsufficientFunds)
+	assert.Equal(t, 1, limiter.released, "a conversion the ledger refuses gives its limit usage back")
+	usd.Balance = 400
+
+	expired, err := service.Quote(ctx, usd, models.FXQuoteRequest{ToAccountNumber: thb.AccountNumber, Amount: 10}, uuid.New())
+	require.NoError(t, err)
+	now = now.Add(61 * time.Second)
+	_, err = service.Execute(ctx, usd, expired.ID)
+	assert.ErrorIs(t, err, ErrQuoteExpired)
+	assert.Equal(t, models.FXQuoteExpired, fxRepo.quotes[expired.ID].Status)
+	assert.Equal(t, 400.0, usd.Balance)
+}
+
+func TestLedgerRefusesEntryInAnotherCurrency(t *testing.T) {
+	usd := &models.Account{ID: uuid.New(), AccountNumber: "1000000001", AccountType: models.AccountTypeSavings, Currency: models.CurrencyUSD, Balance: 500, Status: models.AccountStatusActive}
+	thb := &models.Account{ID: uuid.New(), AccountNumber: "1000000002", AccountType: models.AccountTypeSavings, Currency: models.CurrencyTHB, Status: models.AccountStatusActive}
+	ledgerRepo := &stubLedgerRepository{accounts: map[uuid.UUID]*models.Account{usd.ID: usd, thb.ID: thb}, posted: map[string]*models.LedgerTransaction{}}
+	service := ledger.NewService(ledgerRepo)
+
+	// A plain transfer between currencies does not balance per currency
+	err := service.Post(context.Background(), &models.LedgerTransaction{
+		Reference: "transfer:mixed",
+		Type:      models.LedgerTransfer,
+		Entries:   []models.LedgerEntry{ledger.Debit(usd.ID, 100), ledger.Credit(thb.ID, 100)},
+	})
+	assert.ErrorIs(t, err, ledger.ErrUnbalanced)
+
+	err = service.Post(context.Background(), &models.LedgerTransaction{
+		Reference: "transfer:labelled",
+		Type:      models.LedgerTransfer,
+		Entries: []models.LedgerEntry{
+			ledger.DebitIn(usd.ID, models.Money{Amount: 100, Currency: models.CurrencyTHB}),
+			ledger.Credit(thb.ID, 100),
+		},
+	})
+	assert.ErrorIs(t, err, ledger.ErrCurrencyMismatch)
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/lifecycle"
+	"example.com/m/internal/middleware"
+	"example.com/m/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// AccountClosureHandler contains staff handlers for closing accounts
+type AccountClosureHandler struct {
+	closure *lifecycle.ClosureService
+}
+
+// NewAccountClosureHandler creates a new AccountClosureHandler
+func NewAccountClosureHandler(closure *lifecycle.ClosureService) *AccountClosureHandler {
+	return &AccountClosureHandler{
+		closure: closure,
+	}
+}
+
+// CloseAccount closes a customer account, optionally moving the remaining balance to another account
+// Endpoint: DELETE /staff/accounts/:accountId
+func (h *AccountClosureHandler) CloseAccount(c *fiber.Ctx) error {
+	accountID, err := uuid.Parse(c.Params("accountId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid account ID format",
+		})
+	}
+
+	var request models.CloseAccountRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request format",
+			})
+		}
+	}
+
+	staffID, err := middleware.GetStaffIDFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Staff authentication required",
+		})
+	}
+
+	closure, err := h.closure.Close(c.Context(), accountID, request, staffID)
+	if err != nil {
+		if handled, response := restrictedError(c, err); handled {
+			return response
+		}
+		switch {
+		case errors.Is(err, lifecycle.ErrAccountNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Account not found",
+			})
+		case errors.Is(err, lifecycle.ErrAlreadyClosed):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case errors.Is(err, lifecycle.ErrNotCustomerAccount),
+			errors.Is(err, lifecycle.ErrBalanceNotSettled),
+			errors.Is(err, lifecycle.ErrRepaymentSource),
+			errors.Is(err, lifecycle.ErrActiveHolds),
+			errors.Is(err, lifecycle.ErrRestricted),
+			errors.Is(err, lifecycle.ErrOpenCards),
+			errors.Is(err, lifecycle.ErrActiveFixedDeposit),
+			errors.Is(err, lifecycle.ErrInvalidTransferTarget),
+			errors.Is(err, ledger.ErrAccountNotActive),
+			errors.Is(err, ledger.ErrInsufficientFunds):
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		log.Printf("failed to close account %s: %v", accountID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to close account",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(closure)
+}
@@ -0,0 +1,525 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"example.com/m/internal/deposits"
+	"example.com/m/internal/holds"
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/mandates"
+	"example.com/m/internal/middleware"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// AccountHandler contains handlers for customer account endpoints
+type AccountHandler struct {
+	accountRepo repository.AccountRepository
+	productRepo repository.ProductRepository
+	depositRepo repository.FixedDepositRepository
+	ledger      *ledger.Service
+	deposits    *deposits.Service
+	mandates    *mandates.Service
+}
+
+// NewAccountHandler creates a new AccountHandler
+func NewAccountHandler(
+	accountRepo repository.AccountRepository,
+	productRepo repository.ProductRepository,
+	depositRepo repository.FixedDepositRepository,
+	ledgerService *ledger.Service,
+	depositService *deposits.Service,
+	mandateService *mandates.Service,
+) *AccountHandler {
+	return &AccountHandler{
+		accountRepo: accountRepo,
+		productRepo: productRepo,
+		depositRepo: depositRepo,
+		ledger:      ledgerService,
+		deposits:    depositService,
+		mandates:    mandateService,
+	}
+}
+
+// OpenSavingsAccount opens a savings account on a catalog product
+// Endpoint: POST /accounts/savings
+func (h *AccountHandler) OpenSavingsAccount(c *fiber.Ctx) error {
+	var request models.OpenAccountRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+	if request.ProductCode == "" {
+		request.ProductCode = "SAVINGS"
+	}
+
+	account, _, err := h.openAccount(c, models.AccountTypeSavings, request)
+	if account == nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(account)
+}
+
+// DepositCash credits cash taken at a branch counter to a customer account.
+// An empty account must be funded with at least the minimum opening balance
+// of its product.
+// Endpoint: POST /staff/accounts/:accountId/deposits
+func (h *AccountHandler) DepositCash(c *fiber.Ctx) error {
+	staffID, err := middleware.GetStaffIDFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Staff identity is required",
+		})
+	}
+	accountID, err := uuid.Parse(c.Params("accountId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid account ID format",
+		})
+	}
+
+	var request models.CashDepositRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+	if len(request.Reference) > maxClientReferenceLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Reference is too long",
+		})
+	}
+
+	account, err := h.accountRepo.GetAccountByID(c.Context(), accountID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve account",
+		})
+	}
+	if account == nil || account.IsInternal() || account.AccountType == models.AccountTypeFixedDeposit {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Account not found",
+		})
+	}
+	if !account.Currency.ValidAmount(request.Amount) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Amount must be greater than zero with at most %d decimals in %s", account.Currency.Decimals(), account.Currency),
+		})
+	}
+
+	if account.Balance == 0 {
+		product, err := h.productRepo.GetProductVersion(c.Context(), account.ProductCode, account.ProductVersion)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to retrieve product",
+			})
+		}
+		if product != nil {
+			if err := product.CheckFirstDeposit(request.Amount); err != nil {
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+		}
+	}
+
+	number := models.CurrencyAccount(models.GLCash, account.Currency)
+	cash, err := h.accountRepo.GetAccountByNumber(c.Context(), number)
+	if err != nil || cash == nil {
+		log.Printf("internal account %s is unavailable: %v", number, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to post the deposit",
+		})
+	}
+
+	reference := strings.TrimSpace(request.Reference)
+	if reference == "" {
+		reference = uuid.NewString()
+	}
+	txn := &models.LedgerTransaction{
+		Reference:   fmt.Sprintf("deposit:%s:%s", account.ID, reference),
+		Type:        models.LedgerCashDeposit,
+		Description: fmt.Sprintf("Cash deposit taken by staff %s", staffID),
+		Entries: []models.LedgerEntry{
+			ledger.Debit(cash.ID, request.Amount),
+			ledger.Credit(account.ID, request.Amount),
+		},
+	}
+	if err := h.ledger.Post(c.Context(), txn); err != nil {
+		if errors.Is(err, repository.ErrDuplicateReference) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "A deposit with this reference was already posted",
+			})
+		}
+		return postingError(c, err)
+	}
+
+	account, err = h.accountRepo.GetAccountByID(c.Context(), account.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve account",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(account)
+}
+
+// OpenFixedDeposit places a fixed deposit funded from one of the customer's savings accounts
+// Endpoint: POST /accounts/fixed-deposits
+func (h *AccountHandler) OpenFixedDeposit(c *fiber.Ctx) error {
+	var request models.OpenFixedDepositRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+	if request.ProductCode == "" {
+		request.ProductCode = "FIXED_DEPOSIT"
+	}
+	if request.MaturityInstruction == "" {
+		request.MaturityInstruction = models.MaturityTransferToSavings
+	}
+	if !models.IsValidFixedDepositTerm(request.TermMonths) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Term must be one of %v months", models.FixedDepositTerms),
+		})
+	}
+	if !request.MaturityInstruction.IsValid() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid maturity instruction",
+		})
+	}
+
+	funding, err := customerAccount(c, h.accountRepo, request.FundingAccountID)
+	if funding == nil {
+		return err
+	}
+	if funding.AccountType != models.AccountTypeSavings || funding.Status != models.AccountStatusActive {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": "Funding account must be an active savings account",
+		})
+	}
+	if funding.Currency != models.BaseCurrency {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": "Fixed deposits are only offered in THB",
+		})
+	}
+	// The deposit is held by the caller alone, so funding it from an account
+	// that needs co-signers would let them withdraw it early without one
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return err
+	}
+	if err := h.mandates.CheckSoleSignature(c.Context(), funding, customerID); err != nil {
+		return mandateError(c, err)
+	}
+	if request.LinkedAccountID != nil && *request.LinkedAccountID != funding.ID {
+		linked, err := customerAccount(c, h.accountRepo, *request.LinkedAccountID)
+		if linked == nil {
+			return err
+		}
+		if linked.AccountType != models.AccountTypeSavings || linked.Status != models.AccountStatusActive {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error": "Linked account must be an active savings account",
+			})
+		}
+	}
+
+	account, product, err := h.openAccount(c, models.AccountTypeFixedDeposit, models.OpenAccountRequest{
+		ProductCode: request.ProductCode,
+	})
+	if account == nil {
+		return err
+	}
+	if err := product.CheckFirstDeposit(request.Amount); err != nil {
+		h.closeUnfunded(c, account)
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	rate, ok := product.RateForTerm(request.TermMonths)
+	if !ok {
+		h.closeUnfunded(c, account)
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": fmt.Sprintf("Product %s does not offer a %d month term", product.Code, request.TermMonths),
+		})
+	}
+
+	deposit, err := h.deposits.Place(c.Context(), account, funding, request, rate)
+	if err != nil {
+		h.closeUnfunded(c, account)
+		if handled, response := restrictedError(c, err); handled {
+			return response
+		}
+		if errors.Is(err, ledger.ErrInsufficientFunds) || errors.Is(err, ledger.ErrAccountNotActive) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		log.Printf("fixed deposit placement failed for account %s: %v", account.AccountNumber, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to place fixed deposit",
+		})
+	}
+	account.Balance = request.Amount
+
+	return c.Status(fiber.StatusCreated).JSON(models.FixedDepositResponse{
+		Account:      account,
+		FixedDeposit: deposit,
+	})
+}
+
+// GetFixedDeposit returns a fixed deposit account with its term details
+// Endpoint: GET /accounts/fixed-deposits/:accountId
+func (h *AccountHandler) GetFixedDeposit(c *fiber.Ctx) error {
+	account, deposit, err := h.customerFixedDeposit(c)
+	if deposit == nil {
+		return err
+	}
+
+	return c.JSON(models.FixedDepositResponse{
+		Account:      account,
+		FixedDeposit: deposit,
+	})
+}
+
+// WithdrawFixedDeposit closes a fixed deposit before maturity at the early withdrawal rate
+// Endpoint: POST /accounts/fixed-deposits/:accountId/withdraw
+func (h *AccountHandler) WithdrawFixedDeposit(c *fiber.Ctx) error {
+	account, deposit, err := h.customerFixedDeposit(c)
+	if deposit == nil {
+		return err
+	}
+
+	if err := h.deposits.WithdrawEarly(c.Context(), account, deposit, time.Now()); err != nil {
+		if handled, response := restrictedError(c, err); handled {
+			return response
+		}
+		if errors.Is(err, deposits.ErrNotActive) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		log.Printf("early withdrawal failed for account %s: %v", account.AccountNumber, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to withdraw fixed deposit",
+		})
+	}
+
+	account, err = h.accountRepo.GetAccountByID(c.Context(), account.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve account",
+		})
+	}
+
+	return c.JSON(models.FixedDepositResponse{
+		Account:      account,
+		FixedDeposit: deposit,
+	})
+}
+
+// GetAccountBalance returns the ledger, held and available balance of one of the caller's accounts
+// Endpoint: GET /accounts/:accountId/balance
+func (h *AccountHandler) GetAccountBalance(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+
+	return c.JSON(holds.Balance(account))
+}
+
+// customerFixedDeposit loads the fixed deposit named by the accountId route
+// parameter. When it is missing or not owned by the caller, the error
+// response is written and a nil deposit is returned.
+func (h *AccountHandler) customerFixedDeposit(c *fiber.Ctx) (*models.Account, *models.FixedDeposit, error) {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return nil, nil, err
+	}
+
+	deposit, err := h.depositRepo.GetFixedDeposit(c.Context(), account.ID)
+	if err != nil {
+		return nil, nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve fixed deposit",
+		})
+	}
+	if deposit == nil {
+		return nil, nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Fixed deposit not found",
+		})
+	}
+
+	return account, deposit, nil
+}
+
+// customerAccountParam loads the caller's account named by the accountId
+// route parameter. On failure the error response is already written.
+func customerAccountParam(c *fiber.Ctx, accountRepo repository.AccountRepository) (*models.Account, error) {
+	accountID, err := uuid.Parse(c.Params("accountId"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid account ID format",
+		})
+	}
+	return customerAccount(c, accountRepo, accountID)
+}
+
+// customerAccount loads an account on which the authenticated customer holds
+// a mandate. When it is missing or the customer is not one of its holders,
+// the error response is written and a nil account is returned.
+func customerAccount(c *fiber.Ctx, accountRepo repository.AccountRepository, accountID uuid.UUID) (*models.Account, error) {
+	customerUUID, err := customerIDFromContext(c)
+	if err != nil {
+		return nil, err
+	}
+
+	mandate, err := accountRepo.GetMandate(c.Context(), accountID, customerUUID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve account",
+		})
+	}
+	// Accounts of other customers are reported as not found so their
+	// existence is not disclosed
+	if mandate == nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Account not found",
+		})
+	}
+
+	account, err := accountRepo.GetAccountByID(c.Context(), accountID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve account",
+		})
+	}
+	if account == nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Account not found",
+		})
+	}
+
+	return account, nil
+}
+
+// customerIDFromContext returns the authenticated customer's ID. On failure
+// the error response is already written.
+func customerIDFromContext(c *fiber.Ctx) (uuid.UUID, error) {
+	customerID, err := middleware.GetCustomerIDFromContext(c)
+	if err != nil {
+		return uuid.Nil, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+	customerUUID, err := uuid.Parse(customerID)
+	if err != nil {
+		return uuid.Nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid customer ID format",
+		})
+	}
+	return customerUUID, nil
+}
+
+// closeUnfunded closes an account that was opened but could not be funded
+func (h *AccountHandler) closeUnfunded(c *fiber.Ctx, account *models.Account) {
+	if err := h.accountRepo.UpdateAccountStatus(c.Context(), account.ID, models.AccountStatusClosed); err != nil {
+		log.Printf("failed to close unfunded account %s: %v", account.AccountNumber, err)
+	}
+}
+
+// openAccount validates the request against the product catalog and creates
+// the account on the current product version. Funding the account is left to
+// the caller. When the account cannot be opened, the error response is written
+// and a nil account is returned.
+func (h *AccountHandler) openAccount(c *fiber.Ctx, category models.AccountType, request models.OpenAccountRequest) (*models.Account, *models.AccountProduct, error) {
+	customerUUID, err := customerIDFromContext(c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	currency, ok := models.ParseCurrency(request.Currency)
+	if !ok {
+		return nil, nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Unsupported currency",
+		})
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(request.ProductCode))
+	product, err := h.productRepo.GetLatestProduct(c.Context(), code)
+	if err != nil {
+		return nil, nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve product",
+		})
+	}
+	if product == nil {
+		return nil, nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Product not found",
+		})
+	}
+
+	existing, err := h.accountRepo.CountCustomerAccounts(c.Context(), customerUUID, product.Code)
+	if err != nil {
+		return nil, nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to check existing accounts",
+		})
+	}
+	if err := product.CheckOpening(category, existing); err != nil {
+		return nil, nil, c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	now := time.Now()
+	account := &models.Account{
+		ID:             uuid.New(),
+		CustomerID:     &customerUUID,
+		AccountType:    category,
+		ProductCode:    product.Code,
+		ProductVersion: product.Version,
+		Currency:       currency,
+		Status:         models.AccountStatusActive,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	// Retry a few times in the unlikely case a generated number is taken
+	for attempt := 0; attempt < 3; attempt++ {
+		account.AccountNumber, err = generateAccountNumber()
+		if err != nil {
+			break
+		}
+		err = h.accountRepo.CreateAccount(c.Context(), account)
+		if !errors.Is(err, repository.ErrDuplicateAccountNumber) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to open account",
+		})
+	}
+
+	return account, product, nil
+}
+
+// generateAccountNumber returns a random 10-digit account number
+func generateAccountNumber() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(10_000_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%010d", n.Int64()), nil
+}
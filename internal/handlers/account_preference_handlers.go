@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListCustomerAccounts lists the caller's accounts in their preferred order.
+// Accounts hidden from the dashboard are left out unless includeHidden=true.
+// Endpoint: GET /customers/me/accounts
+func (h *AccountHandler) ListCustomerAccounts(c *fiber.Ctx) error {
+	customerUUID, err := customerIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	accounts, err := h.accountRepo.GetCustomerAccounts(c.Context(), customerUUID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve accounts",
+		})
+	}
+
+	if !c.QueryBool("includeHidden") {
+		visible := make([]*models.CustomerAccount, 0, len(accounts))
+		for _, account := range accounts {
+			if !account.HideFromDashboard {
+				visible = append(visible, account)
+			}
+		}
+		accounts = visible
+	}
+
+	return c.JSON(accounts)
+}
+
+// UpdateAccountNickname sets or clears the nickname of one of the caller's accounts
+// Endpoint: PUT /accounts/:accountId/nickname
+func (h *AccountHandler) UpdateAccountNickname(c *fiber.Ctx) error {
+	var request models.NicknameRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	return h.updatePreferences(c, models.AccountPreferencesRequest{Nickname: &request.Nickname})
+}
+
+// GetAccountPreferences returns the preferences of one of the caller's accounts
+// Endpoint: GET /accounts/:accountId/preferences
+func (h *AccountHandler) GetAccountPreferences(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+
+	preferences, err := h.accountPreferences(c, account)
+	if preferences == nil {
+		return err
+	}
+
+	return c.JSON(preferences)
+}
+
+// UpdateAccountPreferences updates the preferences of one of the caller's accounts
+// Endpoint: PUT /accounts/:accountId/preferences
+func (h *AccountHandler) UpdateAccountPreferences(c *fiber.Ctx) error {
+	var request models.AccountPreferencesRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	return h.updatePreferences(c, request)
+}
+
+// updatePreferences applies a partial preferences update to the account
+// named by the accountId route parameter and writes the saved preferences
+func (h *AccountHandler) updatePreferences(c *fiber.Ctx, request models.AccountPreferencesRequest) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+	if account.Status == models.AccountStatusClosed {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Account is closed",
+		})
+	}
+
+	preferences, err := h.accountPreferences(c, account)
+	if preferences == nil {
+		return err
+	}
+
+	if request.Nickname != nil {
+		nickname, err := models.NormalizeNickname(*request.Nickname)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		preferences.Nickname = nickname
+	}
+	if request.DisplayOrder != nil {
+		if *request.DisplayOrder < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Display order must not be negative",
+			})
+		}
+		preferences.DisplayOrder = request.DisplayOrder
+	}
+	if request.HideFromDashboard != nil {
+		preferences.HideFromDashboard = *request.HideFromDashboard
+	}
+	if request.DefaultIncoming != nil {
+		// Only accounts that can freely receive transfers can be the default
+		if *request.DefaultIncoming && account.AccountType != models.AccountTypeSavings && account.AccountType != models.AccountTypeCurrent {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error": "Only savings and current accounts can receive incoming transfers by default",
+			})
+		}
+		preferences.DefaultIncoming = *request.DefaultIncoming
+	}
+	preferences.UpdatedAt = time.Now()
+
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return err
+	}
+	if err := h.accountRepo.SaveAccountPreferences(c.Context(), customerID, preferences); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save account preferences",
+		})
+	}
+
+	return c.JSON(preferences)
+}
+
+// accountPreferences returns the caller's saved preferences for an account,
+// or the defaults when none were saved. On failure the error response is
+// already written and nil is returned.
+func (h *AccountHandler) accountPreferences(c *fiber.Ctx, account *models.Account) (*models.AccountPreferences, error) {
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences, err := h.accountRepo.GetAccountPreferences(c.Context(), account.ID, customerID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve account preferences",
+		})
+	}
+	if preferences == nil {
+		preferences = &models.AccountPreferences{AccountID: account.ID}
+	}
+	return preferences, nil
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"example.com/m/internal/jobs"
+	"example.com/m/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxReplayDays limits how many business dates a single replay request may cover
+const maxReplayDays = 366
+
+// BatchHandler contains staff handlers for operating end-of-day batches
+type BatchHandler struct {
+	interestJob *jobs.InterestAccrualJob
+}
+
+// NewBatchHandler creates a new BatchHandler
+func NewBatchHandler(interestJob *jobs.InterestAccrualJob) *BatchHandler {
+	return &BatchHandler{
+		interestJob: interestJob,
+	}
+}
+
+// ReplayInterestAccrual re-runs the interest accrual batch for a date range
+// Endpoint: POST /staff/batch/interest-accrual/replay
+func (h *BatchHandler) ReplayInterestAccrual(c *fiber.Ctx) error {
+	var request models.BatchReplayRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	from, to, err := parseDateRange(request.From, request.To)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if jobs.DaysBetween(from, to) >= maxReplayDays {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Date range must not exceed 366 days",
+		})
+	}
+
+	if err := h.interestJob.RunRange(context.Background(), from, to); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Interest accrual replay failed: " + err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Interest accrual replayed",
+		"from":    from.Format("2006-01-02"),
+		"to":      to.Format("2006-01-02"),
+	})
+}
+
+// parseDateRange parses two YYYY-MM-DD dates and checks that from is not after to
+func parseDateRange(fromValue, toValue string) (time.Time, time.Time, error) {
+	from, err := time.Parse("2006-01-02", fromValue)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("from must be a date in YYYY-MM-DD format")
+	}
+	to, err := time.Parse("2006-01-02", toValue)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("to must be a date in YYYY-MM-DD format")
+	}
+	if from.After(to) {
+		return time.Time{}, time.Time{}, errors.New("from must not be after to")
+	}
+	return from, to, nil
+}
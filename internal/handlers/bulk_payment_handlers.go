@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"example.com/m/internal/bulkpayments"
+	"example.com/m/internal/mandates"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// BulkPaymentHandler contains handlers for payments uploaded as a CSV file
+type BulkPaymentHandler struct {
+	accountRepo  repository.AccountRepository
+	bulkPayments *bulkpayments.Service
+	mandates     *mandates.Service
+}
+
+// NewBulkPaymentHandler creates a new BulkPaymentHandler
+func NewBulkPaymentHandler(accountRepo repository.AccountRepository, bulkPaymentService *bulkpayments.Service, mandateService *mandates.Service) *BulkPaymentHandler {
+	return &BulkPaymentHandler{
+		accountRepo:  accountRepo,
+		bulkPayments: bulkPaymentService,
+		mandates:     mandateService,
+	}
+}
+
+// UploadBulkPayment validates a CSV file of payments from one of the
+// caller's accounts and returns a preview with totals and row errors
+// Endpoint: POST /accounts/:accountId/bulk-payments (multipart form, field "file")
+func (h *BulkPaymentHandler) UploadBulkPayment(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A CSV file is required in the file field",
+		})
+	}
+
+	if err := h.mandates.CheckSoleSignature(c.Context(), account, customerID); err != nil {
+		return mandateError(c, err)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read the uploaded file",
+		})
+	}
+	defer file.Close()
+
+	preview, err := h.bulkPayments.Upload(c.Context(), account, customerID, fileHeader.Filename, file)
+	if err != nil {
+		return bulkPaymentError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(preview)
+}
+
+// GetBulkPayment returns a bulk payment file with the status of every row
+// Endpoint: GET /accounts/:accountId/bulk-payments/:bulkPaymentId
+func (h *BulkPaymentHandler) GetBulkPayment(c *fiber.Ctx) error {
+	account, bulkPaymentID, err := h.bulkPaymentParams(c)
+	if account == nil {
+		return err
+	}
+
+	details, err := h.bulkPayments.Get(c.Context(), account.ID, bulkPaymentID)
+	if err != nil {
+		return bulkPaymentError(c, err)
+	}
+
+	return c.JSON(details)
+}
+
+// ConfirmBulkPayment queues a validated file for execution. Its valid rows
+// are paid in the background.
+// Endpoint: POST /accounts/:accountId/bulk-payments/:bulkPaymentId/confirm
+func (h *BulkPaymentHandler) ConfirmBulkPayment(c *fiber.Ctx) error {
+	account, bulkPaymentID, err := h.bulkPaymentParams(c)
+	if account == nil {
+		return err
+	}
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.mandates.CheckSoleSignature(c.Context(), account, customerID); err != nil {
+		return mandateError(c, err)
+	}
+
+	batch, err := h.bulkPayments.Confirm(c.Context(), account.ID, bulkPaymentID)
+	if err != nil {
+		return bulkPaymentError(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(batch)
+}
+
+// CancelBulkPayment drops a file that was not confirmed
+// Endpoint: POST /accounts/:accountId/bulk-payments/:bulkPaymentId/cancel
+func (h *BulkPaymentHandler) CancelBulkPayment(c *fiber.Ctx) error {
+	account, bulkPaymentID, err := h.bulkPaymentParams(c)
+	if account == nil {
+		return err
+	}
+
+	batch, err := h.bulkPayments.Cancel(c.Context(), account.ID, bulkPaymentID)
+	if err != nil {
+		return bulkPaymentError(c, err)
+	}
+
+	return c.JSON(batch)
+}
+
+// DownloadBulkPaymentResult returns the result of an executed file as CSV
+// Endpoint: GET /accounts/:accountId/bulk-payments/:bulkPaymentId/result
+func (h *BulkPaymentHandler) DownloadBulkPaymentResult(c *fiber.Ctx) error {
+	account, bulkPaymentID, err := h.bulkPaymentParams(c)
+	if account == nil {
+		return err
+	}
+
+	fileName, content, err := h.bulkPayments.ResultFile(c.Context(), account.ID, bulkPaymentID)
+	if err != nil {
+		return bulkPaymentError(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", fileName))
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Send(content)
+}
+
+// bulkPaymentParams loads the caller's account and parses the file ID. When
+// either is invalid, the error response is written and a nil account is returned.
+func (h *BulkPaymentHandler) bulkPaymentParams(c *fiber.Ctx) (*models.Account, uuid.UUID, error) {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return nil, uuid.Nil, err
+	}
+	bulkPaymentID, err := uuid.Parse(c.Params("bulkPaymentId"))
+	if err != nil {
+		return nil, uuid.Nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid bulk payment ID format",
+		})
+	}
+	return account, bulkPaymentID, nil
+}
+
+// bulkPaymentError writes the response for an error returned by the bulk payment service
+func bulkPaymentError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, bulkpayments.ErrInvalidFile),
+		errors.Is(err, bulkpayments.ErrInvalidHeader),
+		errors.Is(err, bulkpayments.ErrEmptyFile),
+		errors.Is(err, bulkpayments.ErrTooManyRows):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, bulkpayments.ErrBulkPaymentNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, bulkpayments.ErrNotAwaitingConfirmation),
+		errors.Is(err, bulkpayments.ErrNoValidRows),
+		errors.Is(err, bulkpayments.ErrNotCompleted):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return postingError(c, err)
+}
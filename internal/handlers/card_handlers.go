@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"example.com/m/internal/cards"
+	"example.com/m/internal/mandates"
+	"example.com/m/internal/middleware"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// CardHandler contains handlers for payment cards
+type CardHandler struct {
+	accountRepo repository.AccountRepository
+	cards       *cards.Service
+	mandates    *mandates.Service
+}
+
+// NewCardHandler creates a new CardHandler
+func NewCardHandler(accountRepo repository.AccountRepository, cardService *cards.Service, mandateService *mandates.Service) *CardHandler {
+	return &CardHandler{
+		accountRepo: accountRepo,
+		cards:       cardService,
+		mandates:    mandateService,
+	}
+}
+
+// RequestDebitCard issues a debit card on one of the caller's accounts. The
+// card number is in this response only; the CVV and activation code are mailed.
+// Endpoint: POST /accounts/:accountId/cards/request-debit
+func (h *CardHandler) RequestDebitCard(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// A card spends on its holder's signature alone
+	if err := h.mandates.CheckSoleSignature(c.Context(), account, customerID); err != nil {
+		return mandateError(c, err)
+	}
+
+	issue, err := h.cards.RequestDebit(c.Context(), account, customerID)
+	if err != nil {
+		return cardError(c, err)
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(fiber.StatusCreated).JSON(issue)
+}
+
+// ActivateCard activates one of the caller's issued cards with the code
+// mailed with it. When several of the caller's cards end in the same
+// digits, ?cardId= picks one.
+// Endpoint: PUT /cards/:last4/activate
+func (h *CardHandler) ActivateCard(c *fiber.Ctx) error {
+	ref, err := customerCardRef(c)
+	if err != nil {
+		return err
+	}
+
+	var request models.CardActivationRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+	if strings.TrimSpace(request.ActivationCode) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "activation_code is required",
+		})
+	}
+
+	card, err := h.cards.Activate(c.Context(), ref, strings.TrimSpace(request.ActivationCode))
+	if err != nil {
+		return cardError(c, err)
+	}
+
+	return c.JSON(card)
+}
+
+// BlockCard blocks one of the caller's active cards until they unblock it
+// Endpoint: PUT /cards/:last4/block
+func (h *CardHandler) BlockCard(c *fiber.Ctx) error {
+	ref, err := customerCardRef(c)
+	if err != nil {
+		return err
+	}
+
+	card, err := h.cards.Block(c.Context(), ref)
+	if err != nil {
+		return cardError(c, err)
+	}
+
+	return c.JSON(card)
+}
+
+// UnblockCard makes one of the caller's blocked cards usable again
+// Endpoint: PUT /cards/:last4/unblock
+func (h *CardHandler) UnblockCard(c *fiber.Ctx) error {
+	ref, err := customerCardRef(c)
+	if err != nil {
+		return err
+	}
+
+	card, err := h.cards.Unblock(c.Context(), ref)
+	if err != nil {
+		return cardError(c, err)
+	}
+
+	return c.JSON(card)
+}
+
+// GetCardControls returns the limits and switches set on one of the caller's cards
+// Endpoint: GET /cards/:last4/controls
+func (h *CardHandler) GetCardControls(c *fiber.Ctx) error {
+	ref, err := customerCardRef(c)
+	if err != nil {
+		return err
+	}
+
+	controls, err := h.cards.Controls(c.Context(), ref)
+	if err != nil {
+		return cardError(c, err)
+	}
+
+	return c.JSON(controls)
+}
+
+// UpdateCardControls changes the limits and switches of one of the caller's
+// cards. Fields that are not sent are left unchanged. Every change is
+// recorded in the card audit trail.
+// Endpoint: PUT /cards/:last4/controls
+func (h *CardHandler) UpdateCardControls(c *fiber.Ctx) error {
+	ref, err := customerCardRef(c)
+	if err != nil {
+		return err
+	}
+
+	var request models.CardControlsRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	controls, err := h.cards.UpdateControls(c.Context(), ref, request, *ref.CustomerID)
+	if err != nil {
+		return cardError(c, err)
+	}
+
+	return c.JSON(controls)
+}
+
+// ReportCardLostStolen blocks a card for good on the customer's report and
+// offers them a replacement. ?customerId= narrows the search to one
+// customer and ?cardId= picks one card when several match.
+// Endpoint: PUT /staff/cards/:last4/report-lost-stolen
+func (h *CardHandler) ReportCardLostStolen(c *fiber.Ctx) error {
+	staffID, err := middleware.GetStaffIDFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Staff identity is required",
+		})
+	}
+	ref, err := cardRef(c)
+	if err != nil {
+		return err
+	}
+	if customerID := c.Query("customerId"); customerID != "" {
+		id, err := uuid.Parse(customerID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid customer ID format",
+			})
+		}
+		ref.CustomerID = &id
+	}
+
+	report, err := h.cards.ReportLostStolen(c.Context(), ref, staffID)
+	if err != nil {
+		return cardError(c, err)
+	}
+
+	return c.JSON(report)
+}
+
+// SearchCards finds cards by the last four digits of their number for a
+// staff member, optionally of one customer, in one status or issued within
+// a date range. Every search is recorded in the card audit trail.
+// Endpoint: GET /staff/cards/search?panLast4=&customerId=&status=&issuedFrom=YYYY-MM-DD&issuedTo=YYYY-MM-DD
+func (h *CardHandler) SearchCards(c *fiber.Ctx) error {
+	staffID, err := middleware.GetStaffIDFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Staff identity is required",
+		})
+	}
+
+	filter := models.CardSearchFilter{
+		PANLast4: c.Query("panLast4"),
+		Status:   models.CardStatus(c.Query("status")),
+	}
+	if len(filter.PANLast4) != 4 || strings.Trim(filter.PANLast4, "0123456789") != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "panLast4 must be the last 4 digits of the card number",
+		})
+	}
+	if customerID := c.Query("customerId"); customerID != "" {
+		id, err := uuid.Parse(customerID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid customer ID format",
+			})
+		}
+		filter.CustomerID = &id
+	}
+	switch filter.Status {
+	case "", models.CardRequested, models.CardIssued, models.CardActive, models.CardBlocked, models.CardLostStolen:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid card status",
+		})
+	}
+	if value := c.Query("issuedFrom"); value != "" {
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "issuedFrom must be a date in YYYY-MM-DD format",
+			})
+		}
+		filter.IssuedFrom = &date
+	}
+	if value := c.Query("issuedTo"); value != "" {
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "issuedTo must be a date in YYYY-MM-DD format",
+			})
+		}
+		filter.IssuedTo = &date
+	}
+	if filter.IssuedFrom != nil && filter.IssuedTo != nil && filter.IssuedFrom.After(*filter.IssuedTo) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "issuedFrom must not be after issuedTo",
+		})
+	}
+
+	results, err := h.cards.Search(c.Context(), filter, staffID)
+	if err != nil {
+		return cardError(c, err)
+	}
+
+	return c.JSON(results)
+}
+
+// customerCardRef identifies a card of the caller from the request
+func customerCardRef(c *fiber.Ctx) (cards.CardRef, error) {
+	ref, err := cardRef(c)
+	if err != nil {
+		return ref, err
+	}
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return ref, err
+	}
+	ref.CustomerID = &customerID
+	return ref, nil
+}
+
+// cardRef parses the last four digits and the optional card ID of a card
+// request. On failure the error response has already been written.
+func cardRef(c *fiber.Ctx) (cards.CardRef, error) {
+	ref := cards.CardRef{Last4: c.Params("last4")}
+	if len(ref.Last4) != 4 || strings.Trim(ref.Last4, "0123456789") != "" {
+		return ref, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "last4 must be the last 4 digits of the card number",
+		})
+	}
+	if cardID := c.Query("cardId"); cardID != "" {
+		id, err := uuid.Parse(cardID)
+		if err != nil {
+			return ref, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid card ID format",
+			})
+		}
+		ref.CardID = &id
+	}
+	return ref, nil
+}
+
+// cardError writes the response for an error returned by the card service
+func cardError(c *fiber.Ctx, err error) error {
+	var ambiguous *cards.AmbiguousCardError
+	if errors.As(err, &ambiguous) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": ambiguous.Error(),
+			"cards": ambiguous.Cards,
+		})
+	}
+
+	switch {
+	case errors.Is(err, cards.ErrAccountNotEligible),
+		errors.Is(err, cards.ErrInvalidActivationCode),
+		errors.Is(err, cards.ErrInvalidControls):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, cards.ErrCardNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, cards.ErrInvalidCardStatus),
+		errors.Is(err, cards.ErrCardLostStolen),
+		errors.Is(err, cards.ErrCardChanged):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, cards.ErrActivationLocked):
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, cards.ErrCardExpired):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	log.Printf("card request failed: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to process the request",
+	})
+}
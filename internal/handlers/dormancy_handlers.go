@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strconv"
+
+	"example.com/m/internal/identity"
+	"example.com/m/internal/lifecycle"
+	"example.com/m/internal/middleware"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// DormancyHandler contains handlers for dormant account reactivation and the dormancy report
+type DormancyHandler struct {
+	accountRepo  repository.AccountRepository
+	dormancyRepo repository.DormancyRepository
+	dormancy     *lifecycle.DormancyService
+}
+
+// NewDormancyHandler creates a new DormancyHandler
+func NewDormancyHandler(accountRepo repository.AccountRepository, dormancyRepo repository.DormancyRepository, dormancyService *lifecycle.DormancyService) *DormancyHandler {
+	return &DormancyHandler{
+		accountRepo:  accountRepo,
+		dormancyRepo: dormancyRepo,
+		dormancy:     dormancyService,
+	}
+}
+
+// ReactivateAccount lets a holder reactivate a dormant account by re-verifying their identity
+// Endpoint: POST /accounts/:accountId/reactivate
+func (h *DormancyHandler) ReactivateAccount(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+
+	var request models.IdentityVerificationRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+	if request.IDCardNumber == "" || request.PhoneNumber == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "id_card_number and phone_number are required",
+		})
+	}
+
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return err
+	}
+	if err := h.dormancy.ReactivateByVerification(c.Context(), account, customerID, request); err != nil {
+		return dormancyError(c, err)
+	}
+
+	return h.reactivated(c, account.ID)
+}
+
+// StaffReactivateAccount reactivates a dormant account on a staff member's decision
+// Endpoint: POST /staff/accounts/:accountId/reactivate
+func (h *DormancyHandler) StaffReactivateAccount(c *fiber.Ctx) error {
+	staffID, err := middleware.GetStaffIDFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Staff identity is required",
+		})
+	}
+
+	accountID, err := uuid.Parse(c.Params("accountId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid account ID format",
+		})
+	}
+
+	var request models.StaffReactivationRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	if err := h.dormancy.ReactivateByStaff(c.Context(), accountID, staffID, request.Reason); err != nil {
+		return dormancyError(c, err)
+	}
+
+	return h.reactivated(c, accountID)
+}
+
+// reactivated writes the account after a successful reactivation
+func (h *DormancyHandler) reactivated(c *fiber.Ctx, accountID uuid.UUID) error {
+	account, err := h.accountRepo.GetAccountByID(c.Context(), accountID)
+	if err != nil || account == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Account was reactivated but could not be reloaded",
+		})
+	}
+
+	return c.JSON(account)
+}
+
+// GetDormancyHistory returns the dormancy events of an account
+// Endpoint: GET /staff/accounts/:accountId/dormancy
+func (h *DormancyHandler) GetDormancyHistory(c *fiber.Ctx) error {
+	accountID, err := uuid.Parse(c.Params("accountId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid account ID format",
+		})
+	}
+
+	events, err := h.dormancyRepo.GetDormancyEvents(c.Context(), accountID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve dormancy history",
+		})
+	}
+
+	return c.JSON(events)
+}
+
+// GetDormancyReport lists accounts that become dormant soon so staff can contact the customers
+// Endpoint: GET /staff/accounts/dormancy-report?withinDays=
+func (h *DormancyHandler) GetDormancyReport(c *fiber.Ctx) error {
+	withinDays := 0
+	if value := c.Query("withinDays"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "withinDays must be a positive number",
+			})
+		}
+		withinDays = days
+	}
+
+	candidates, err := h.dormancy.Approaching(c.Context(), withinDays)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to build dormancy report",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"count":    len(candidates),
+		"accounts": candidates,
+	})
+}
+
+// dormancyError writes the response for an error returned by the dormancy service
+func dormancyError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, lifecycle.ErrReasonRequired):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, lifecycle.ErrVerificationFailed):
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, lifecycle.ErrVerificationLocked):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, identity.ErrLocked):
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, lifecycle.ErrNotDormant):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	log.Printf("dormancy operation failed: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to reactivate account",
+	})
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"example.com/m/internal/fx"
+	"example.com/m/internal/mandates"
+	"example.com/m/internal/middleware"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// fxRateColumns is the header of a rate table CSV upload
+var fxRateColumns = []string{"currency", "mid_rate", "buy_spread", "sell_spread"}
+
+// FXHandler contains handlers for exchange rates and currency conversions
+type FXHandler struct {
+	accountRepo repository.AccountRepository
+	fx          *fx.Service
+	mandates    *mandates.Service
+}
+
+// NewFXHandler creates a new FXHandler
+func NewFXHandler(accountRepo repository.AccountRepository, fxService *fx.Service, mandateService *mandates.Service) *FXHandler {
+	return &FXHandler{
+		accountRepo: accountRepo,
+		fx:          fxService,
+		mandates:    mandateService,
+	}
+}
+
+// GetRates returns the exchange rates in effect now
+// Endpoint: GET /fx/rates
+func (h *FXHandler) GetRates(c *fiber.Ctx) error {
+	rates, err := h.fx.CurrentRates(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve exchange rates",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"base_currency": models.BaseCurrency,
+		"rates":         rates,
+	})
+}
+
+// UploadRates replaces the rate table from a JSON body or a CSV file with
+// the columns currency, mid_rate, buy_spread and sell_spread
+// Endpoint: POST /staff/fx/rates
+func (h *FXHandler) UploadRates(c *fiber.Ctx) error {
+	staffID, err := middleware.GetStaffIDFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Staff identity is required",
+		})
+	}
+
+	var request models.FXRateUploadRequest
+	if file, err := c.FormFile("file"); err == nil {
+		reader, err := file.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Failed to read the uploaded file",
+			})
+		}
+		defer reader.Close()
+
+		request.Rates, err = parseRateCSV(reader)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	} else if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	rates, err := h.fx.UploadRates(c.Context(), request, staffID)
+	if err != nil {
+		return fxError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(rates)
+}
+
+// parseRateCSV reads the rows of a rate table CSV upload
+func parseRateCSV(r io.Reader) ([]models.FXRateInput, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("the file is empty")
+	}
+	for i, column := range fxRateColumns {
+		if i >= len(header) || strings.ToLower(strings.TrimSpace(header[i])) != column {
+			return nil, fmt.Errorf("the header must be %s", strings.Join(fxRateColumns, ","))
+		}
+	}
+
+	var rates []models.FXRateInput
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rates, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		var values [3]float64
+		for i := range values {
+			values[i], err = strconv.ParseFloat(strings.TrimSpace(record[i+1]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s is not a number", line, fxRateColumns[i+1])
+			}
+		}
+		rates = append(rates, models.FXRateInput{
+			Currency:   record[0],
+			MidRate:    values[0],
+			BuySpread:  values[1],
+			SellSpread: values[2],
+		})
+	}
+}
+
+// RequestQuote prices a conversion from one of the caller's accounts to an account in another currency
+// Endpoint: POST /accounts/:accountId/fx-quotes
+func (h *FXHandler) RequestQuote(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var request models.FXQuoteRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	if err := h.mandates.CheckSoleSignature(c.Context(), account, customerID); err != nil {
+		return mandateError(c, err)
+	}
+
+	quote, err := h.fx.Quote(c.Context(), account, request, customerID)
+	if err != nil {
+		return fxError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(quote)
+}
+
+// ExecuteQuote converts the funds at the quoted rate if the quote has not expired
+// Endpoint: POST /accounts/:accountId/fx-quotes/:quoteId/execute
+func (h *FXHandler) ExecuteQuote(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+
+	quoteID, err := uuid.Parse(c.Params("quoteId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid quote ID format",
+		})
+	}
+
+	quote, err := h.fx.Execute(c.Context(), account, quoteID)
+	if err != nil {
+		return fxError(c, err)
+	}
+
+	return c.JSON(quote)
+}
+
+// fxError writes the response for an error returned by the FX service,
+// falling back to postingError for a conversion the ledger refused
+func fxError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, fx.ErrNoRates),
+		errors.Is(err, fx.ErrInvalidRate),
+		errors.Is(err, fx.ErrInvalidAmount),
+		errors.Is(err, fx.ErrAmountTooSmall),
+		errors.Is(err, fx.ErrSameCurrency):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, fx.ErrDestinationNotFound), errors.Is(err, fx.ErrQuoteNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, fx.ErrQuoteExpired), errors.Is(err, fx.ErrQuoteUsed):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, fx.ErrRateUnavailable):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return postingError(c, err)
+}
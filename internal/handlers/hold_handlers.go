@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"example.com/m/internal/holds"
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/middleware"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// HoldHandler contains staff handlers for account holds
+type HoldHandler struct {
+	accountRepo repository.AccountRepository
+	holdRepo    repository.HoldRepository
+	holds       *holds.Service
+}
+
+// NewHoldHandler creates a new HoldHandler
+func NewHoldHandler(accountRepo repository.AccountRepository, holdRepo repository.HoldRepository, holdService *holds.Service) *HoldHandler {
+	return &HoldHandler{
+		accountRepo: accountRepo,
+		holdRepo:    holdRepo,
+		holds:       holdService,
+	}
+}
+
+// PlaceHold reserves funds on an account
+// Endpoint: POST /staff/accounts/:accountId/holds
+func (h *HoldHandler) PlaceHold(c *fiber.Ctx) error {
+	accountID, err := uuid.Parse(c.Params("accountId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid account ID format",
+		})
+	}
+
+	var request models.PlaceHoldRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+	if strings.TrimSpace(request.Reason) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Reason is required",
+		})
+	}
+
+	hold := &models.Hold{
+		AccountID: accountID,
+		Amount:    request.Amount,
+		Reason:    strings.TrimSpace(request.Reason),
+		Source:    models.HoldSourceStaff,
+		ExpiresAt: request.ExpiresAt,
+	}
+	if staffID, err := middleware.GetStaffIDFromContext(c); err == nil {
+		hold.CreatedBy = &staffID
+	}
+
+	if err := h.holds.Place(c.Context(), hold); err != nil {
+		return holdError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(hold)
+}
+
+// ListHolds lists the holds of an account, optionally filtered by status
+// Endpoint: GET /staff/accounts/:accountId/holds?status=
+func (h *HoldHandler) ListHolds(c *fiber.Ctx) error {
+	accountID, err := uuid.Parse(c.Params("accountId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid account ID format",
+		})
+	}
+
+	account, err := h.accountRepo.GetAccountByID(c.Context(), accountID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve account",
+		})
+	}
+	if account == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Account not found",
+		})
+	}
+
+	list, err := h.holdRepo.GetAccountHolds(c.Context(), accountID, models.HoldStatus(c.Query("status")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve holds",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"balance": holds.Balance(account),
+		"holds":   list,
+	})
+}
+
+// ReleaseHold releases a hold without posting it
+// Endpoint: POST /staff/holds/:holdId/release
+func (h *HoldHandler) ReleaseHold(c *fiber.Ctx) error {
+	holdID, err := uuid.Parse(c.Params("holdId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid hold ID format",
+		})
+	}
+
+	hold, err := h.holds.Release(c.Context(), holdID)
+	if err != nil {
+		return holdError(c, err)
+	}
+
+	return c.JSON(hold)
+}
+
+// CaptureHold posts the held funds and ends the hold
+// Endpoint: POST /staff/holds/:holdId/capture
+func (h *HoldHandler) CaptureHold(c *fiber.Ctx) error {
+	holdID, err := uuid.Parse(c.Params("holdId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid hold ID format",
+		})
+	}
+
+	var request models.CaptureHoldRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request format",
+			})
+		}
+	}
+
+	var creditAccountID uuid.UUID
+	if request.CreditAccountID != nil {
+		creditAccountID = *request.CreditAccountID
+	} else {
+		currency := models.BaseCurrency
+		if hold, err := h.holdRepo.GetHold(c.Context(), holdID); err == nil && hold != nil {
+			if account, err := h.accountRepo.GetAccountByID(c.Context(), hold.AccountID); err == nil && account != nil {
+				currency = account.Currency
+			}
+		}
+		suspense, err := h.accountRepo.GetAccountByNumber(c.Context(), models.CurrencyAccount(models.GLSuspense, currency))
+		if err != nil || suspense == nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Suspense account is not available",
+			})
+		}
+		creditAccountID = suspense.ID
+	}
+	description := request.Description
+	if description == "" {
+		description = fmt.Sprintf("Capture of hold %s", holdID)
+	}
+
+	txn, err := h.holds.Capture(c.Context(), holdID, request.Amount, creditAccountID, models.LedgerHoldCapture, description)
+	if err != nil {
+		return holdError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"hold_id":        holdID,
+		"status":         models.HoldCaptured,
+		"transaction_id": txn.ID,
+	})
+}
+
+// holdError writes the response for an error returned by the hold service
+func holdError(c *fiber.Ctx, err error) error {
+	if handled, response := restrictedError(c, err); handled {
+		return response
+	}
+
+	switch {
+	case errors.Is(err, holds.ErrInvalidAmount), errors.Is(err, holds.ErrInvalidExpiry):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, holds.ErrHoldNotFound), errors.Is(err, repository.ErrAccountNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, repository.ErrHoldNotActive):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, holds.ErrNotCustomerAccount),
+		errors.Is(err, ledger.ErrInsufficientFunds),
+		errors.Is(err, ledger.ErrAccountNotActive),
+		errors.Is(err, ledger.ErrAccountDormant):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	log.Printf("hold operation failed: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to process hold",
+	})
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+
+	"example.com/m/internal/clearing"
+	"example.com/m/internal/mandates"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// InterbankHandler contains handlers for transfers to accounts at other banks
+type InterbankHandler struct {
+	accountRepo repository.AccountRepository
+	clearing    *clearing.Service
+	mandates    *mandates.Service
+}
+
+// NewInterbankHandler creates a new InterbankHandler
+func NewInterbankHandler(accountRepo repository.AccountRepository, clearingService *clearing.Service, mandateService *mandates.Service) *InterbankHandler {
+	return &InterbankHandler{
+		accountRepo: accountRepo,
+		clearing:    clearingService,
+		mandates:    mandateService,
+	}
+}
+
+// SendInterbankTransfer debits one of the caller's accounts and sends the
+// transfer to the switch. The transfer is accepted as pending and settled
+// or returned when the switch answers.
+// Endpoint: POST /accounts/:accountId/interbank-transfers
+func (h *InterbankHandler) SendInterbankTransfer(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var request models.InterbankTransferRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	if err := h.mandates.CheckSoleSignature(c.Context(), account, customerID); err != nil {
+		return mandateError(c, err)
+	}
+
+	transfer, err := h.clearing.Send(c.Context(), account, customerID, request)
+	if err != nil {
+		return interbankError(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(transfer)
+}
+
+// GetInterbankTransfer returns a transfer to another bank with its current status
+// Endpoint: GET /accounts/:accountId/interbank-transfers/:transferId
+func (h *InterbankHandler) GetInterbankTransfer(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+	transferID, err := uuid.Parse(c.Params("transferId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid transfer ID format",
+		})
+	}
+
+	transfer, err := h.clearing.Get(c.Context(), account.ID, transferID)
+	if err != nil {
+		return interbankError(c, err)
+	}
+
+	return c.JSON(transfer)
+}
+
+// interbankError writes the response for an error returned by the clearing service
+func interbankError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, clearing.ErrInvalidBank),
+		errors.Is(err, clearing.ErrOwnBank),
+		errors.Is(err, clearing.ErrInvalidAccountNumber):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, clearing.ErrTransferNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return postingError(c, err)
+}
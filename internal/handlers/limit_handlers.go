@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+
+	"example.com/m/internal/identity"
+	"example.com/m/internal/limits"
+	"example.com/m/internal/middleware"
+	"example.com/m/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// LimitHandler contains handlers for customer transaction limits and tiers
+type LimitHandler struct {
+	limits *limits.Service
+}
+
+// NewLimitHandler creates a new LimitHandler
+func NewLimitHandler(limitService *limits.Service) *LimitHandler {
+	return &LimitHandler{
+		limits: limitService,
+	}
+}
+
+// GetLimits returns the caller's transaction limits and how much of each daily limit is left
+// Endpoint: GET /customers/me/limits
+func (h *LimitHandler) GetLimits(c *fiber.Ctx) error {
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	response, err := h.limits.Limits(c.Context(), customerID)
+	if err != nil {
+		return limitError(c, err)
+	}
+
+	return c.JSON(response)
+}
+
+// UpdateLimit changes one of the caller's limits. Raising a limit requires
+// the ID card and phone numbers on record in verification.
+// Endpoint: PUT /customers/me/limits
+func (h *LimitHandler) UpdateLimit(c *fiber.Ctx) error {
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var request models.LimitUpdateRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	limit, err := h.limits.SetLimit(c.Context(), customerID, request)
+	if err != nil {
+		return limitError(c, err)
+	}
+
+	return c.JSON(limit)
+}
+
+// UpdateCustomerTier assigns a customer to a tier, which sets their limits
+// Endpoint: PUT /staff/customers/:customerId/tier
+func (h *LimitHandler) UpdateCustomerTier(c *fiber.Ctx) error {
+	customerID, err := uuid.Parse(c.Params("customerId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid customer ID format",
+		})
+	}
+	staffID, err := middleware.GetStaffIDFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Staff identity is required",
+		})
+	}
+
+	var request models.CustomerTierRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	if err := h.limits.SetTier(c.Context(), customerID, request.Tier, staffID); err != nil {
+		return limitError(c, err)
+	}
+
+	response, err := h.limits.Limits(c.Context(), customerID)
+	if err != nil {
+		return limitError(c, err)
+	}
+	return c.JSON(response)
+}
+
+// limitError writes the response for an error returned by the limit service
+func limitError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, limits.ErrInvalidChannel),
+		errors.Is(err, limits.ErrInvalidType),
+		errors.Is(err, limits.ErrInvalidTier),
+		errors.Is(err, limits.ErrInvalidLimit),
+		errors.Is(err, limits.ErrNotLimited):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, limits.ErrAboveTierLimit):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, limits.ErrStepUpRequired):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+			"code":  "STEP_UP_REQUIRED",
+		})
+	case errors.Is(err, limits.ErrStepUpFailed):
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, identity.ErrLocked):
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	log.Printf("limit operation failed: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to process limits",
+	})
+}
+
+// limitExceededError writes the response for a payment refused by a
+// transaction limit, including the headroom left today. It reports false
+// when err is not a limit error.
+func limitExceededError(c *fiber.Ctx, err error) (bool, error) {
+	var exceeded *limits.LimitError
+	if !errors.As(err, &exceeded) {
+		return false, nil
+	}
+	return true, c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+		"error": exceeded.Error(),
+		"code":  exceeded.Code,
+		"limit": exceeded.Limit,
+	})
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/credit"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// LoanAccountHandler contains staff handlers for loan decisions and servicing
+type LoanAccountHandler struct {
+	loanRepo        repository.LoanRepository
+	loanAccountRepo repository.LoanAccountRepository
+	accountRepo     repository.AccountRepository
+	cfg             config.LoanConfig
+}
+
+// NewLoanAccountHandler creates a new LoanAccountHandler
+func NewLoanAccountHandler(
+	loanRepo repository.LoanRepository,
+	loanAccountRepo repository.LoanAccountRepository,
+	accountRepo repository.AccountRepository,
+	cfg config.LoanConfig,
+) *LoanAccountHandler {
+	return &LoanAccountHandler{
+		loanRepo:        loanRepo,
+		loanAccountRepo: loanAccountRepo,
+		accountRepo:     accountRepo,
+		cfg:             cfg,
+	}
+}
+
+// UpdateLoanApplicationStatus records the staff decision on a loan application.
+// Approving an application books the loan with its repayment schedule.
+// Endpoint: PUT /staff/loans/applications/:applicationId/status
+func (h *LoanAccountHandler) UpdateLoanApplicationStatus(c *fiber.Ctx) error {
+	applicationID, err := uuid.Parse(c.Params("applicationId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid application ID format",
+		})
+	}
+
+	var request models.LoanStatusUpdateRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	switch request.Status {
+	case models.LoanStatusApproved, models.LoanStatusRejected, models.LoanStatusMoreInfoRequired:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Status must be one of approved, rejected, more_info_required",
+		})
+	}
+	if request.Status != models.LoanStatusApproved && request.Reason == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Reason is required",
+		})
+	}
+
+	application, err := h.loanRepo.GetLoanApplicationByID(c.Context(), applicationID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve loan application",
+		})
+	}
+	if application == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Loan application not found",
+		})
+	}
+	if application.Status != models.LoanStatusPending {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Only pending applications can be decided",
+		})
+	}
+
+	if request.Status == models.LoanStatusApproved && request.RepaymentAccountID != nil {
+		account, err := h.accountRepo.GetAccountByID(c.Context(), *request.RepaymentAccountID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to retrieve repayment account",
+			})
+		}
+		var mandate *models.AccountMandate
+		if account != nil {
+			mandate, err = h.accountRepo.GetMandate(c.Context(), account.ID, application.CustomerID)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to retrieve repayment account",
+				})
+			}
+		}
+		if mandate == nil || mandate.Role == models.MandateSignatory ||
+			account.Status != models.AccountStatusActive || account.AccountType == models.AccountTypeFixedDeposit {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error": "Repayment account must be an active savings or current account of the applicant",
+			})
+		}
+	}
+
+	// An approval books the loan and updates the application together
+	var loan *models.Loan
+	if request.Status == models.LoanStatusApproved {
+		loan, err = h.bookLoan(c, application, request.RepaymentAccountID, request.Reason)
+		if errors.Is(err, repository.ErrApplicationNotPending) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Only pending applications can be decided",
+			})
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to book loan",
+			})
+		}
+	} else {
+		err = h.loanRepo.UpdateLoanApplicationStatus(c.Context(), application.ID, request.Status, request.Reason)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update loan application status",
+			})
+		}
+	}
+
+	response := fiber.Map{
+		"id":     application.ID,
+		"status": request.Status,
+		"reason": request.Reason,
+	}
+	if loan != nil {
+		response["loan"] = loan
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetDelinquencyReport returns loan counts and outstanding totals per aging bucket
+// Endpoint: GET /staff/loans/delinquency-report
+func (h *LoanAccountHandler) GetDelinquencyReport(c *fiber.Ctx) error {
+	report, err := h.loanAccountRepo.GetDelinquencyReport(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to build delinquency report",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(report)
+}
+
+// bookLoan creates the loan and its repayment schedule for an approved
+// application and marks the application approved
+func (h *LoanAccountHandler) bookLoan(c *fiber.Ctx, application *models.LoanApplication, repaymentAccountID *uuid.UUID, reason string) (*models.Loan, error) {
+	now := time.Now()
+	loan := &models.Loan{
+		ID:                   uuid.New(),
+		ApplicationID:        application.ID,
+		CustomerID:           application.CustomerID,
+		Principal:            application.AmountRequested,
+		AnnualRate:           h.cfg.AnnualRate,
+		TenorMonths:          h.cfg.TenorMonths,
+		OutstandingPrincipal: application.AmountRequested,
+		Status:               models.LoanActive,
+		AgingBucket:          models.AgingCurrent,
+		RepaymentAccountID:   repaymentAccountID,
+		DisbursedAt:          now,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	}
+
+	schedule := credit.AmortizationSchedule(loan.Principal, loan.AnnualRate, loan.TenorMonths, now)
+	installments := make([]*models.LoanInstallment, len(schedule))
+	for i := range schedule {
+		schedule[i].ID = uuid.New()
+		schedule[i].LoanID = loan.ID
+		installments[i] = &schedule[i]
+	}
+
+	if err := h.loanAccountRepo.CreateLoan(c.Context(), loan, installments, reason); err != nil {
+		return nil, err
+	}
+	return loan, nil
+}
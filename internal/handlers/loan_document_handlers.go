@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"example.com/m/internal/middleware"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// MaxLoanDocumentSize is the largest file accepted for a loan document (5 MB)
+const MaxLoanDocumentSize = 5 * 1024 * 1024
+
+// allowedDocumentTypes lists the MIME types accepted for loan documents,
+// detected from the file content rather than the client-supplied header
+var allowedDocumentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+}
+
+// LoanDocumentHandler contains handlers for loan document endpoints
+type LoanDocumentHandler struct {
+	loanRepo     repository.LoanRepository
+	documentRepo repository.LoanDocumentRepository
+	store        storage.BlobStorage
+}
+
+// NewLoanDocumentHandler creates a new LoanDocumentHandler
+func NewLoanDocumentHandler(loanRepo repository.LoanRepository, documentRepo repository.LoanDocumentRepository, store storage.BlobStorage) *LoanDocumentHandler {
+	return &LoanDocumentHandler{
+		loanRepo:     loanRepo,
+		documentRepo: documentRepo,
+		store:        store,
+	}
+}
+
+// UploadLoanDocument attaches a supporting document to the customer's loan application
+// Endpoint: POST /loans/applications/:applicationId/documents (multipart: file, document_type)
+func (h *LoanDocumentHandler) UploadLoanDocument(c *fiber.Ctx) error {
+	application, err := customerLoanApplication(c, h.loanRepo)
+	if application == nil {
+		return err
+	}
+
+	if application.Status != models.LoanStatusMoreInfoRequired {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Documents can only be uploaded when more information is required",
+		})
+	}
+
+	documentType := models.LoanDocumentType(c.FormValue("document_type"))
+	if !documentType.IsValid() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "document_type must be one of payslip, bank_statement, id_copy",
+		})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "File is required",
+		})
+	}
+	if fileHeader.Size > MaxLoanDocumentSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": fmt.Sprintf("File must not exceed %d bytes", MaxLoanDocumentSize),
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read uploaded file",
+		})
+	}
+	defer file.Close()
+
+	// Sniff the content type from the first bytes of the file
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read uploaded file",
+		})
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+	if !allowedDocumentTypes[contentType] {
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"error": "Only PDF, JPEG and PNG files are accepted",
+		})
+	}
+
+	document := &models.LoanDocument{
+		ID:            uuid.New(),
+		ApplicationID: application.ID,
+		CustomerID:    application.CustomerID,
+		DocumentType:  documentType,
+		FileName:      filepath.Base(fileHeader.Filename),
+		ContentType:   contentType,
+		UploadedAt:    time.Now(),
+	}
+	document.StorageKey = fmt.Sprintf("loan-documents/%s/%s", application.ID, document.ID)
+
+	hasher := sha256.New()
+	reader := io.TeeReader(io.MultiReader(bytes.NewReader(head), file), hasher)
+	size, err := h.store.Put(c.Context(), document.StorageKey, reader)
+	if err != nil {
+		log.Printf("failed to store loan document %s: %v", document.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to store document",
+		})
+	}
+	document.SizeBytes = size
+	document.ChecksumSHA256 = hex.EncodeToString(hasher.Sum(nil))
+
+	if err := h.documentRepo.CreateLoanDocument(c.Context(), document); err != nil {
+		_ = h.store.Delete(c.Context(), document.StorageKey)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save document",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(document)
+}
+
+// ListLoanDocuments returns the documents attached to a loan application
+// Endpoint: GET /staff/loans/applications/:applicationId/documents
+func (h *LoanDocumentHandler) ListLoanDocuments(c *fiber.Ctx) error {
+	applicationID, err := uuid.Parse(c.Params("applicationId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid application ID format",
+		})
+	}
+
+	documents, err := h.documentRepo.GetApplicationDocuments(c.Context(), applicationID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve documents",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(documents)
+}
+
+// DownloadLoanDocument streams the content of a loan document
+// Endpoint: GET /staff/loans/applications/:applicationId/documents/:documentId
+func (h *LoanDocumentHandler) DownloadLoanDocument(c *fiber.Ctx) error {
+	applicationID, err := uuid.Parse(c.Params("applicationId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid application ID format",
+		})
+	}
+	documentID, err := uuid.Parse(c.Params("documentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid document ID format",
+		})
+	}
+
+	document, err := h.documentRepo.GetLoanDocumentByID(c.Context(), documentID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve document",
+		})
+	}
+	if document == nil || document.ApplicationID != applicationID {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Document not found",
+		})
+	}
+
+	content, err := h.store.Open(c.Context(), document.StorageKey)
+	if err != nil {
+		log.Printf("failed to open loan document %s: %v", document.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to read document",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, document.ContentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", document.FileName))
+	c.Set("X-Checksum-SHA256", document.ChecksumSHA256)
+	return c.SendStream(content, int(document.SizeBytes))
+}
+
+// customerLoanApplication loads the application in the :applicationId param and
+// checks that it belongs to the authenticated customer. When the application
+// cannot be used, the error response is written and a nil application is returned.
+func customerLoanApplication(c *fiber.Ctx, loanRepo repository.LoanRepository) (*models.LoanApplication, error) {
+	customerID, err := middleware.GetCustomerIDFromContext(c)
+	if err != nil {
+		return nil, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	applicationID, err := uuid.Parse(c.Params("applicationId"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid application ID format",
+		})
+	}
+
+	application, err := loanRepo.GetLoanApplicationByID(c.Context(), applicationID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve loan application",
+		})
+	}
+	if application == nil || application.CustomerID.String() != customerID {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Loan application not found",
+		})
+	}
+
+	return application, nil
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubLoanRepository keeps loan applications in memory
+type stubLoanRepository struct {
+	repository.LoanRepository
+	applications map[uuid.UUID]*models.LoanApplication
+}
+
+func (r *stubLoanRepository) GetLoanApplicationByID(ctx context.Context, id uuid.UUID) (*models.LoanApplication, error) {
+	return r.applications[id], nil
+}
+
+func (r *stubLoanRepository) UpdateLoanApplicationStatus(ctx context.Context, id uuid.UUID, status models.LoanApplicationStatus, reason string) error {
+	r.applications[id].Status = status
+	return nil
+}
+
+// stubLoanDocumentRepository records the documents saved
+type stubLoanDocumentRepository struct {
+	repository.LoanDocumentRepository
+	documents []*models.LoanDocument
+}
+
+func (r *stubLoanDocumentRepository) CreateLoanDocument(ctx context.Context, document *models.LoanDocument) error {
+	r.documents = append(r.documents, document)
+	return nil
+}
+
+var pdfContent = []byte("%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\n")
+
+type loanDocumentFixture struct {
+	app         *fiber.App
+	loans       *stubLoanRepository
+	documents   *stubLoanDocumentRepository
+	store       *storage.LocalStorage
+	application *models.LoanApplication
+	customerID  uuid.UUID
+}
+
+func newLoanDocumentFixture(t *testing.T) *loanDocumentFixture {
+	customerID := uuid.New()
+	application := &models.LoanApplication{ID: uuid.New(), CustomerID: customerID, Status: models.LoanStatusMoreInfoRequired}
+	f := &loanDocumentFixture{
+		app:         fiber.New(fiber.Config{BodyLimit: 2 * MaxLoanDocumentSize}),
+		loans:       &stubLoanRepository{applications: map[uuid.UUID]*models.LoanApplication{application.ID: application}},
+		documents:   &stubLoanDocumentRepository{},
+		store:       storage.NewLocalStorage(t.TempDir()),
+		application: application,
+		customerID:  customerID,
+	}
+	documentHandler := NewLoanDocumentHandler(f.loans, f.documents, f.store)
+	loanHandler := NewLoanHandler(f.loans, nil)
+	f.app.Use(func(c *fiber.Ctx) error {
+		c.Locals("customerID", c.Get("X-Customer-ID"))
+		return c.Next()
+	})
+	f.app.Post("/loans/applications/:applicationId/documents", documentHandler.UploadLoanDocument)
+	f.app.Post("/loans/applications/:applicationId/resubmit", loanHandler.ResubmitLoanApplication)
+	return f
+}
+
+// upload posts a multipart document upload as the given customer
+func (f *loanDocumentFixture) upload(t *testing.T, customerID uuid.UUID, documentType, fileName string, content []byte) *http.Response {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	require.NoError(t, form.WriteField("document_type", documentType))
+	// The client-supplied content type must not be trusted
+	part, err := form.CreateFormFile("file", fileName)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/loans/applications/"+f.application.ID.String()+"/documents", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("X-Customer-ID", customerID.String())
+	resp, err := f.app.Test(req, -1)
+	require.NoError(t, err)
+	return resp
+}
+
+func (f *loanDocumentFixture) resubmit(t *testing.T) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/loans/applications/"+f.application.ID.String()+"/resubmit", nil)
+	req.Header.Set("X-Customer-ID", f.customerID.String())
+	resp, err := f.app.Test(req, -1)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestUploadLoanDocumentStoresFileAndChecksum(t *testing.T) {
+	f := newLoanDocumentFixture(t)
+
+	resp := f.upload(t, f.customerID, "payslip", "../../march.pdf", pdfContent)
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	var document models.LoanDocument
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&document))
+
+	sum := sha256.Sum256(pdfContent)
+	assert.Equal(t, hex.EncodeToString(sum[:]), document.ChecksumSHA256)
+	assert.Equal(t, "application/pdf", document.ContentType)
+	assert.Equal(t, "march.pdf", document.FileName)
+	assert.Equal(t, int64(len(pdfContent)), document.SizeBytes)
+
+	require.Len(t, f.documents.documents, 1)
+	stored, err := f.store.Open(context.Background(), f.documents.documents[0].StorageKey)
+	require.NoError(t, err)
+	defer stored.Close()
+	content, err := io.ReadAll(stored)
+	require.NoError(t, err)
+	assert.Equal(t, pdfContent, content)
+}
+
+func TestUploadLoanDocumentSniffsContentType(t *testing.T) {
+	f := newLoanDocumentFixture(t)
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	resp := f.upload(t, f.customerID, "id_copy", "id.png", png)
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+	// A script named like a PDF is refused on its content
+	resp = f.upload(t, f.customerID, "payslip", "payslip.pdf", []byte("#!/bin/sh\necho hello\n"))
+	assert.Equal(t, fiber.StatusUnsupportedMediaType, resp.StatusCode)
+	assert.Len(t, f.documents.documents, 1)
+}
+
+func TestUploadLoanDocumentLimits(t *testing.T) {
+	f := newLoanDocumentFixture(t)
+
+	large := append(append([]byte{}, pdfContent...), make([]byte, MaxLoanDocumentSize)...)
+	resp := f.upload(t, f.customerID, "bank_statement", "statement.pdf", large)
+	assert.Equal(t, fiber.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	exact := append(append([]byte{}, pdfContent...), make([]byte, MaxLoanDocumentSize-len(pdfContent))...)
+	resp = f.upload(t, f.customerID, "bank_statement", "statement.pdf", exact)
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+	resp = f.upload(t, f.customerID, "passport", "passport.pdf", pdfContent)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	resp = f.upload(t, uuid.New(), "payslip", "payslip.pdf", pdfContent)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode, "another customer's application is not found")
+	assert.Len(t, f.documents.documents, 1)
+}
+
+func TestResubmitLoanApplication(t *testing.T) {
+	f := newLoanDocumentFixture(t)
+
+	resp := f.upload(t, f.customerID, "payslip", "payslip.pdf", pdfContent)
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+	resp = f.resubmit(t)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, models.LoanStatusPending, f.application.Status)
+
+	// Once pending, the application takes no more documents and cannot be re-submitted again
+	resp = f.upload(t, f.customerID, "payslip", "payslip.pdf", pdfContent)
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	resp = f.resubmit(t)
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+}
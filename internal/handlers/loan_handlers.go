@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"log"
 	"time"
 
+	"example.com/m/internal/credit"
 	"example.com/m/internal/middleware"
 	"example.com/m/internal/models"
 	"example.com/m/internal/repository"
@@ -13,12 +15,14 @@ import (
 // LoanHandler contains handlers for loan application endpoints
 type LoanHandler struct {
 	loanRepo repository.LoanRepository
+	scorer   credit.Engine
 }
 
 // NewLoanHandler creates a new LoanHandler
-func NewLoanHandler(loanRepo repository.LoanRepository) *LoanHandler {
+func NewLoanHandler(loanRepo repository.LoanRepository, scorer credit.Engine) *LoanHandler {
 	return &LoanHandler{
 		loanRepo: loanRepo,
+		scorer:   scorer,
 	}
 }
 
@@ -89,6 +93,14 @@ func (h *LoanHandler) ApplyForPersonalLoan(c *fiber.Ctx) error {
 		UpdatedAt:       now,
 	}
 
+	// Run the credit pre-assessment. A scoring failure must not block the
+	// customer, so the application is stored without an assessment instead.
+	assessment, err := h.assessApplication(c, application)
+	if err != nil {
+		log.Printf("credit assessment failed for application %s: %v", application.ID, err)
+	}
+	application.CreditAssessment = assessment
+
 	// Save to database
 	err = h.loanRepo.CreateLoanApplication(c.Context(), application)
 	if err != nil {
@@ -107,3 +119,85 @@ func (h *LoanHandler) ApplyForPersonalLoan(c *fiber.Ctx) error {
 		Message:         "Your loan application has been submitted and is pending review",
 	})
 }
+
+// ResubmitLoanApplication moves an application that needed more information back to pending
+// Endpoint: POST /loans/applications/:applicationId/resubmit
+func (h *LoanHandler) ResubmitLoanApplication(c *fiber.Ctx) error {
+	application, err := customerLoanApplication(c, h.loanRepo)
+	if application == nil {
+		return err
+	}
+
+	if application.Status != models.LoanStatusMoreInfoRequired {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Only applications that require more information can be re-submitted",
+		})
+	}
+
+	err = h.loanRepo.UpdateLoanApplicationStatus(c.Context(), application.ID, models.LoanStatusPending, "")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to re-submit loan application",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.LoanApplicationResponse{
+		ID:              application.ID,
+		Status:          models.LoanStatusPending,
+		AmountRequested: application.AmountRequested,
+		Purpose:         application.Purpose,
+		CreatedAt:       application.CreatedAt,
+		Message:         "Your loan application has been re-submitted and is pending review",
+	})
+}
+
+// GetLoanApplicationDetails returns a loan application including its credit assessment
+// Endpoint: GET /staff/loans/applications/:applicationId
+func (h *LoanHandler) GetLoanApplicationDetails(c *fiber.Ctx) error {
+	applicationID, err := uuid.Parse(c.Params("applicationId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid application ID format",
+		})
+	}
+
+	application, err := h.loanRepo.GetLoanApplicationByID(c.Context(), applicationID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve loan application",
+		})
+	}
+	if application == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Loan application not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(application)
+}
+
+// assessApplication runs the scoring engine, counting the customer's
+// approved loans as existing obligations
+func (h *LoanHandler) assessApplication(c *fiber.Ctx, application *models.LoanApplication) (*models.CreditAssessment, error) {
+	if h.scorer == nil {
+		return nil, nil
+	}
+
+	existing, err := h.loanRepo.GetCustomerLoanApplications(c.Context(), application.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+
+	amounts := []float64{}
+	for _, loan := range existing {
+		if loan.Status == models.LoanStatusApproved {
+			amounts = append(amounts, loan.AmountRequested)
+		}
+	}
+
+	return h.scorer.Assess(credit.Input{
+		AmountRequested:     application.AmountRequested,
+		IncomeDetails:       application.IncomeDetails,
+		ExistingLoanAmounts: amounts,
+	})
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"example.com/m/internal/mandates"
+	"example.com/m/internal/middleware"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// MandateHandler contains handlers for account holders and co-owner transfer approvals
+type MandateHandler struct {
+	accountRepo repository.AccountRepository
+	mandates    *mandates.Service
+}
+
+// NewMandateHandler creates a new MandateHandler
+func NewMandateHandler(accountRepo repository.AccountRepository, mandateService *mandates.Service) *MandateHandler {
+	return &MandateHandler{
+		accountRepo: accountRepo,
+		mandates:    mandateService,
+	}
+}
+
+// ListMandates returns the holders of one of the caller's accounts
+// Endpoint: GET /accounts/:accountId/mandates
+func (h *MandateHandler) ListMandates(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+
+	list, err := h.accountRepo.GetMandates(c.Context(), account.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve account holders",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"signing_rule": account.SigningRule,
+		"holders":      list,
+	})
+}
+
+// ListPendingTransfers returns the transfers of the account that needed co-owner approval
+// Endpoint: GET /accounts/:accountId/pending-transfers
+func (h *MandateHandler) ListPendingTransfers(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+
+	list, err := h.mandates.ListTransfers(c.Context(), account.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve pending transfers",
+		})
+	}
+
+	return c.JSON(list)
+}
+
+// ApprovePendingTransfer confirms a pending transfer; the last confirmation moves the money
+// Endpoint: POST /accounts/:accountId/pending-transfers/:pendingId/approve
+func (h *MandateHandler) ApprovePendingTransfer(c *fiber.Ctx) error {
+	return h.decide(c, h.mandates.Approve)
+}
+
+// RejectPendingTransfer declines a pending transfer, which cancels it
+// Endpoint: POST /accounts/:accountId/pending-transfers/:pendingId/reject
+func (h *MandateHandler) RejectPendingTransfer(c *fiber.Ctx) error {
+	return h.decide(c, h.mandates.Reject)
+}
+
+// decide parses the account and pending transfer shared by the decision endpoints and applies action
+func (h *MandateHandler) decide(c *fiber.Ctx, action func(ctx context.Context, account *models.Account, pendingID, customerID uuid.UUID) (*models.PendingTransfer, error)) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	pendingID, err := uuid.Parse(c.Params("pendingId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid pending transfer ID format",
+		})
+	}
+
+	pending, err := action(c.Context(), account, pendingID, customerID)
+	if err != nil {
+		if pending != nil {
+			// Every holder approved but the transfer could not be posted
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error":            pending.FailureReason,
+				"pending_transfer": pending,
+			})
+		}
+		return mandateError(c, err)
+	}
+
+	return c.JSON(pending)
+}
+
+// AddMandate gives a customer a joint or signatory role on an account
+// Endpoint: POST /staff/accounts/:accountId/mandates
+func (h *MandateHandler) AddMandate(c *fiber.Ctx) error {
+	staffID, err := middleware.GetStaffIDFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Staff identity is required",
+		})
+	}
+
+	accountID, err := uuid.Parse(c.Params("accountId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid account ID format",
+		})
+	}
+
+	var request models.MandateRequest
+	if err := c.BodyParser(&request); err != nil || request.CustomerID == uuid.Nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "customer_id and role are required",
+		})
+	}
+
+	mandate, err := h.mandates.AddMandate(c.Context(), accountID, request, staffID)
+	if err != nil {
+		return mandateError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(mandate)
+}
+
+// RemoveMandate removes a joint holder or signatory from an account
+// Endpoint: DELETE /staff/accounts/:accountId/mandates/:customerId
+func (h *MandateHandler) RemoveMandate(c *fiber.Ctx) error {
+	accountID, err := uuid.Parse(c.Params("accountId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid account ID format",
+		})
+	}
+	customerID, err := uuid.Parse(c.Params("customerId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid customer ID format",
+		})
+	}
+
+	if err := h.mandates.RemoveMandate(c.Context(), accountID, customerID); err != nil {
+		return mandateError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// UpdateSigningRule changes who must sign payments from an account
+// Endpoint: PUT /staff/accounts/:accountId/signing-rule
+func (h *MandateHandler) UpdateSigningRule(c *fiber.Ctx) error {
+	accountID, err := uuid.Parse(c.Params("accountId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid account ID format",
+		})
+	}
+
+	var request models.SigningRuleRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	account, err := h.mandates.SetSigningRule(c.Context(), accountID, request.SigningRule)
+	if err != nil {
+		return mandateError(c, err)
+	}
+
+	return c.JSON(account)
+}
+
+// mandateError writes the response for an error returned by the mandate service
+func mandateError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, mandates.ErrInvalidRole), errors.Is(err, mandates.ErrInvalidSigningRule):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, mandates.ErrAccountNotFound),
+		errors.Is(err, mandates.ErrMandateNotFound),
+		errors.Is(err, mandates.ErrPendingNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, mandates.ErrNotApprover):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, mandates.ErrMandateExists),
+		errors.Is(err, mandates.ErrPrimaryHolder),
+		errors.Is(err, mandates.ErrNotPending):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, mandates.ErrNotCustomerAccount), errors.Is(err, mandates.ErrSoleSignatureNotAllowed):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	log.Printf("mandate operation failed: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to process the request",
+	})
+}
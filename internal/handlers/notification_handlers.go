@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"example.com/m/internal/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxNotifications is how many notifications the inbox endpoint returns
+const maxNotifications = 50
+
+// NotificationHandler contains handlers for the customer in-app inbox
+type NotificationHandler struct {
+	repo repository.NotificationRepository
+}
+
+// NewNotificationHandler creates a new NotificationHandler
+func NewNotificationHandler(repo repository.NotificationRepository) *NotificationHandler {
+	return &NotificationHandler{
+		repo: repo,
+	}
+}
+
+// ListNotifications returns the caller's latest notifications
+// Endpoint: GET /customers/me/notifications
+func (h *NotificationHandler) ListNotifications(c *fiber.Ctx) error {
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	notifications, err := h.repo.GetCustomerNotifications(c.Context(), customerID, maxNotifications)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve notifications",
+		})
+	}
+
+	return c.JSON(notifications)
+}
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/m/internal/middleware"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ProductHandler contains staff handlers for the account product catalog
+type ProductHandler struct {
+	productRepo repository.ProductRepository
+}
+
+// NewProductHandler creates a new ProductHandler
+func NewProductHandler(productRepo repository.ProductRepository) *ProductHandler {
+	return &ProductHandler{
+		productRepo: productRepo,
+	}
+}
+
+// CreateProduct adds a new product to the catalog as version 1
+// Endpoint: POST /staff/products
+func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
+	var request models.AccountProductRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+	request.Code = strings.ToUpper(strings.TrimSpace(request.Code))
+	if request.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Product code is required",
+		})
+	}
+
+	product, err := newProductVersion(c, request, 1)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := h.productRepo.CreateProductVersion(c.Context(), product); err != nil {
+		if errors.Is(err, repository.ErrProductExists) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Product code already exists",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create product",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(product)
+}
+
+// ListProducts returns the current version of every product
+// Endpoint: GET /staff/products
+func (h *ProductHandler) ListProducts(c *fiber.Ctx) error {
+	products, err := h.productRepo.ListLatestProducts(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve products",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(products)
+}
+
+// GetProduct returns the current version of a product, or the version given in ?version=
+// Endpoint: GET /staff/products/:code
+func (h *ProductHandler) GetProduct(c *fiber.Ctx) error {
+	code := strings.ToUpper(c.Params("code"))
+
+	var product *models.AccountProduct
+	var err error
+	if v := c.Query("version"); v != "" {
+		version, convErr := strconv.Atoi(v)
+		if convErr != nil || version < 1 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "version must be a positive number",
+			})
+		}
+		product, err = h.productRepo.GetProductVersion(c.Context(), code, version)
+	} else {
+		product, err = h.productRepo.GetLatestProduct(c.Context(), code)
+	}
+
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve product",
+		})
+	}
+	if product == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Product not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(product)
+}
+
+// GetProductVersions returns the version history of a product
+// Endpoint: GET /staff/products/:code/versions
+func (h *ProductHandler) GetProductVersions(c *fiber.Ctx) error {
+	versions, err := h.productRepo.GetProductVersions(c.Context(), strings.ToUpper(c.Params("code")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve product versions",
+		})
+	}
+	if len(versions) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Product not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(versions)
+}
+
+// UpdateProduct changes a product by creating a new version. Accounts opened
+// under earlier versions keep their terms.
+// Endpoint: PUT /staff/products/:code
+func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
+	current, err := h.productRepo.GetLatestProduct(c.Context(), strings.ToUpper(c.Params("code")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve product",
+		})
+	}
+	if current == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Product not found",
+		})
+	}
+	if current.Status == models.ProductStatusRetired {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Retired products cannot be changed",
+		})
+	}
+
+	var request models.AccountProductRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+	request.Code = current.Code
+	if request.Category == "" {
+		request.Category = current.Category
+	}
+	if request.Category != current.Category {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Product category cannot be changed",
+		})
+	}
+
+	product, err := newProductVersion(c, request, current.Version+1)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return h.saveVersion(c, product, fiber.StatusOK)
+}
+
+// RetireProduct closes a product to new accounts by creating a retired version
+// Endpoint: DELETE /staff/products/:code
+func (h *ProductHandler) RetireProduct(c *fiber.Ctx) error {
+	current, err := h.productRepo.GetLatestProduct(c.Context(), strings.ToUpper(c.Params("code")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve product",
+		})
+	}
+	if current == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Product not found",
+		})
+	}
+	if current.Status == models.ProductStatusRetired {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Product is already retired",
+		})
+	}
+
+	retired := *current
+	retired.ID = uuid.New()
+	retired.Version = current.Version + 1
+	retired.Status = models.ProductStatusRetired
+	retired.CreatedAt = time.Now()
+	if staffID, err := middleware.GetStaffIDFromContext(c); err == nil {
+		retired.CreatedBy = &staffID
+	}
+
+	return h.saveVersion(c, &retired, fiber.StatusOK)
+}
+
+func (h *ProductHandler) saveVersion(c *fiber.Ctx, product *models.AccountProduct, status int) error {
+	if err := h.productRepo.CreateProductVersion(c.Context(), product); err != nil {
+		if errors.Is(err, repository.ErrProductVersionConflict) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Product was changed by someone else, please retry",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save product",
+		})
+	}
+
+	return c.Status(status).JSON(product)
+}
+
+// newProductVersion validates a product request and builds the product version
+func newProductVersion(c *fiber.Ctx, request models.AccountProductRequest, version int) (*models.AccountProduct, error) {
+	switch request.Category {
+	case models.AccountTypeSavings, models.AccountTypeCurrent, models.AccountTypeFixedDeposit:
+	default:
+		return nil, errors.New("category must be one of savings, current, fixed_deposit")
+	}
+	if strings.TrimSpace(request.Name) == "" {
+		return nil, errors.New("product name is required")
+	}
+	if request.MinOpeningBalance < 0 || request.MinBalance < 0 {
+		return nil, errors.New("minimum balances must not be negative")
+	}
+	fees := request.Fees
+	if fees.MonthlyMaintenanceFee < 0 || fees.BelowMinimumBalanceFee < 0 || fees.EarlyClosureFee < 0 || fees.EarlyClosureMonths < 0 {
+		return nil, errors.New("fees must not be negative")
+	}
+	if request.WithdrawalLimits.MaxWithdrawalsPerMonth < 0 || request.WithdrawalLimits.DailyWithdrawalLimit < 0 {
+		return nil, errors.New("withdrawal limits must not be negative")
+	}
+	if request.Eligibility.MaxAccountsPerCustomer < 0 {
+		return nil, errors.New("max_accounts_per_customer must not be negative")
+	}
+
+	tiers := append([]models.InterestTier{}, request.InterestTiers...)
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinBalance < tiers[j].MinBalance })
+	for i, tier := range tiers {
+		if tier.AnnualRate < 0 || tier.AnnualRate > 1 {
+			return nil, errors.New("interest rates must be between 0 and 1")
+		}
+		if i > 0 && tier.MinBalance == tiers[i-1].MinBalance {
+			return nil, errors.New("interest tiers must have distinct minimum balances")
+		}
+	}
+	if len(tiers) > 0 && tiers[0].MinBalance != 0 {
+		return nil, errors.New("the first interest tier must start at a minimum balance of 0")
+	}
+
+	termRates := append([]models.TermRate{}, request.TermRates...)
+	sort.Slice(termRates, func(i, j int) bool { return termRates[i].TermMonths < termRates[j].TermMonths })
+	for i, rate := range termRates {
+		if !models.IsValidFixedDepositTerm(rate.TermMonths) {
+			return nil, errors.New("term_months must be one of 3, 6, 12, 24")
+		}
+		if i > 0 && rate.TermMonths == termRates[i-1].TermMonths {
+			return nil, errors.New("term rates must have distinct terms")
+		}
+		if rate.AnnualRate < 0 || rate.AnnualRate > 1 {
+			return nil, errors.New("interest rates must be between 0 and 1")
+		}
+	}
+	if request.Category == models.AccountTypeFixedDeposit && len(termRates) == 0 {
+		return nil, errors.New("fixed deposit products need at least one term rate")
+	}
+	if request.EarlyWithdrawalRate < 0 || request.EarlyWithdrawalRate > 1 {
+		return nil, errors.New("early_withdrawal_rate must be between 0 and 1")
+	}
+
+	product := &models.AccountProduct{
+		ID:                  uuid.New(),
+		Code:                request.Code,
+		Version:             version,
+		Name:                strings.TrimSpace(request.Name),
+		Category:            request.Category,
+		MinOpeningBalance:   request.MinOpeningBalance,
+		MinBalance:          request.MinBalance,
+		Fees:                fees,
+		InterestTiers:       tiers,
+		WithdrawalLimits:    request.WithdrawalLimits,
+		Eligibility:         request.Eligibility,
+		TermRates:           termRates,
+		EarlyWithdrawalRate: request.EarlyWithdrawalRate,
+		Status:              models.ProductStatusActive,
+		CreatedAt:           time.Now(),
+	}
+	if staffID, err := middleware.GetStaffIDFromContext(c); err == nil {
+		product.CreatedBy = &staffID
+	}
+	return product, nil
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"errors"
+
+	"example.com/m/internal/interbank"
+	"example.com/m/internal/mandates"
+	"example.com/m/internal/models"
+	"example.com/m/internal/proxies"
+	"example.com/m/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ProxyHandler contains handlers for the proxy registry and transfers to proxies
+type ProxyHandler struct {
+	accountRepo repository.AccountRepository
+	proxies     *proxies.Service
+	mandates    *mandates.Service
+}
+
+// NewProxyHandler creates a new ProxyHandler
+func NewProxyHandler(accountRepo repository.AccountRepository, proxyService *proxies.Service, mandateService *mandates.Service) *ProxyHandler {
+	return &ProxyHandler{
+		accountRepo: accountRepo,
+		proxies:     proxyService,
+		mandates:    mandateService,
+	}
+}
+
+// RegisterProxy links the caller's mobile number or national ID to one of their accounts
+// Endpoint: POST /accounts/:accountId/proxies
+func (h *ProxyHandler) RegisterProxy(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var request models.ProxyRegistrationRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	proxy, err := h.proxies.Register(c.Context(), account, customerID, request)
+	if err != nil {
+		return proxyError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(proxy)
+}
+
+// ListProxies returns the proxies registered to one of the caller's accounts
+// Endpoint: GET /accounts/:accountId/proxies
+func (h *ProxyHandler) ListProxies(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+
+	list, err := h.proxies.List(c.Context(), account.ID)
+	if err != nil {
+		return proxyError(c, err)
+	}
+
+	return c.JSON(list)
+}
+
+// DeregisterProxy removes a proxy from one of the caller's accounts
+// Endpoint: POST /accounts/:accountId/proxies/:proxyId/deregister
+func (h *ProxyHandler) DeregisterProxy(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+	proxyID, err := uuid.Parse(c.Params("proxyId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid proxy ID format",
+		})
+	}
+
+	var request models.ProxyDeregistrationRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	proxy, err := h.proxies.Deregister(c.Context(), account.ID, proxyID, request)
+	if err != nil {
+		return proxyError(c, err)
+	}
+
+	return c.JSON(proxy)
+}
+
+// ResolveProxyTransfer looks up the recipient of a proxy and returns their
+// masked name for the caller to confirm
+// Endpoint: POST /accounts/:accountId/proxy-transfers
+func (h *ProxyHandler) ResolveProxyTransfer(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var request models.ProxyTransferRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	if err := h.mandates.CheckSoleSignature(c.Context(), account, customerID); err != nil {
+		return mandateError(c, err)
+	}
+
+	transfer, err := h.proxies.Resolve(c.Context(), account, customerID, request)
+	if err != nil {
+		return proxyError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(transfer)
+}
+
+// ConfirmProxyTransfer pays a resolved transfer. Transfers to other banks
+// that the switch rejects or does not answer are refunded and returned
+// with their status.
+// Endpoint: POST /accounts/:accountId/proxy-transfers/:transferId/confirm
+func (h *ProxyHandler) ConfirmProxyTransfer(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+	transferID, err := uuid.Parse(c.Params("transferId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid transfer ID format",
+		})
+	}
+
+	transfer, err := h.proxies.Confirm(c.Context(), account, transferID)
+	if err != nil {
+		return proxyError(c, err)
+	}
+
+	return c.JSON(transfer)
+}
+
+// proxyError writes the response for an error returned by the proxy service
+func proxyError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, proxies.ErrConsentRequired),
+		errors.Is(err, proxies.ErrInvalidProxyType),
+		errors.Is(err, proxies.ErrInvalidProxyValue),
+		errors.Is(err, proxies.ErrAccountNotEligible):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, proxies.ErrNotOwnProxy):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, proxies.ErrProxyNotFound),
+		errors.Is(err, proxies.ErrTransferNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, proxies.ErrProxyTaken),
+		errors.Is(err, proxies.ErrAlreadyConfirmed),
+		errors.Is(err, proxies.ErrConfirmationExpired):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, interbank.ErrTimeout):
+		return c.Status(fiber.StatusGatewayTimeout).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return postingError(c, err)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+
+	"example.com/m/internal/mandates"
+	"example.com/m/internal/models"
+	"example.com/m/internal/qrpayments"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/thaiqr"
+	"example.com/m/internal/transfers"
+	"github.com/gofiber/fiber/v2"
+)
+
+// QRPaymentHandler contains handlers for Thai QR codes
+type QRPaymentHandler struct {
+	accountRepo repository.AccountRepository
+	qr          *qrpayments.Service
+	transfers   *transfers.Service
+	mandates    *mandates.Service
+}
+
+// NewQRPaymentHandler creates a new QRPaymentHandler
+func NewQRPaymentHandler(accountRepo repository.AccountRepository, qrService *qrpayments.Service, transferService *transfers.Service, mandateService *mandates.Service) *QRPaymentHandler {
+	return &QRPaymentHandler{
+		accountRepo: accountRepo,
+		qr:          qrService,
+		transfers:   transferService,
+		mandates:    mandateService,
+	}
+}
+
+// GenerateQRCode returns a Thai QR code that pays one of the caller's
+// accounts, as JSON with the payload and PNG image or, with ?format=png,
+// as the image alone
+// Endpoint: POST /accounts/:accountId/qr-codes
+func (h *QRPaymentHandler) GenerateQRCode(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+
+	var request models.QRCodeRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request format",
+			})
+		}
+	}
+
+	code, err := h.qr.Generate(c.Context(), account, request)
+	if err != nil {
+		return qrPaymentError(c, err)
+	}
+
+	if c.Query("format") == "png" {
+		c.Set(fiber.HeaderContentType, "image/png")
+		return c.Status(fiber.StatusCreated).Send(code.Image)
+	}
+	return c.Status(fiber.StatusCreated).JSON(code)
+}
+
+// PayQRCode pays a scanned Thai QR code from one of the caller's accounts.
+// From an account where all holders must sign it returns 202 with a
+// pending transfer, as for other transfers.
+// Endpoint: POST /accounts/:accountId/qr-payments
+func (h *QRPaymentHandler) PayQRCode(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+
+	var request models.QRPaymentRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+	if len(request.Reference) > maxClientReferenceLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Reference is too long",
+		})
+	}
+
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return err
+	}
+	input, err := h.qr.Resolve(c.Context(), account, customerID, request)
+	if err != nil {
+		return qrPaymentError(c, err)
+	}
+
+	return submitTransfer(c, h.transfers, h.mandates, input)
+}
+
+// qrPaymentError writes the response for an error returned by the QR payment service
+func qrPaymentError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, thaiqr.ErrInvalidPayload),
+		errors.Is(err, thaiqr.ErrChecksum),
+		errors.Is(err, thaiqr.ErrUnsupported),
+		errors.Is(err, qrpayments.ErrAccountNotEligible),
+		errors.Is(err, qrpayments.ErrInvalidReference),
+		errors.Is(err, qrpayments.ErrAmountRequired),
+		errors.Is(err, qrpayments.ErrAmountMismatch),
+		errors.Is(err, qrpayments.ErrOtherBank):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, qrpayments.ErrRecipientNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return postingError(c, err)
+}
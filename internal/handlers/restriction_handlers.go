@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/middleware"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/restrictions"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RestrictionHandler contains staff handlers for account freezes and garnishments
+type RestrictionHandler struct {
+	restrictionRepo repository.RestrictionRepository
+	restrictions    *restrictions.Service
+}
+
+// NewRestrictionHandler creates a new RestrictionHandler
+func NewRestrictionHandler(restrictionRepo repository.RestrictionRepository, restrictionService *restrictions.Service) *RestrictionHandler {
+	return &RestrictionHandler{
+		restrictionRepo: restrictionRepo,
+		restrictions:    restrictionService,
+	}
+}
+
+// RequestRestriction records a restriction awaiting approval by a second staff member
+// Endpoint: POST /staff/accounts/:accountId/restrictions
+func (h *RestrictionHandler) RequestRestriction(c *fiber.Ctx) error {
+	staffID, err := middleware.GetStaffIDFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Staff identity is required",
+		})
+	}
+
+	accountID, err := uuid.Parse(c.Params("accountId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid account ID format",
+		})
+	}
+
+	var request models.RestrictionRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	restriction, err := h.restrictions.Request(c.Context(), accountID, request, staffID)
+	if err != nil {
+		return restrictionError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(restriction)
+}
+
+// ListAccountRestrictions lists every restriction of an account with the ones in force
+// Endpoint: GET /staff/accounts/:accountId/restrictions
+func (h *RestrictionHandler) ListAccountRestrictions(c *fiber.Ctx) error {
+	accountID, err := uuid.Parse(c.Params("accountId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid account ID format",
+		})
+	}
+
+	list, err := h.restrictionRepo.GetAccountRestrictions(c.Context(), accountID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve restrictions",
+		})
+	}
+
+	now := time.Now()
+	account := &models.Account{ID: accountID}
+	for _, restriction := range list {
+		if restriction.InForce(now) {
+			account.Restrictions = append(account.Restrictions, restriction)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"restrictions":     list,
+		"in_force":         len(account.Restrictions),
+		"garnished_amount": account.GarnishedAmount(now),
+	})
+}
+
+// ListPendingRestrictions lists the restriction changes waiting for a checker
+// Endpoint: GET /staff/restrictions/pending
+func (h *RestrictionHandler) ListPendingRestrictions(c *fiber.Ctx) error {
+	list, err := h.restrictionRepo.GetRestrictionsByStatus(c.Context(), models.RestrictionPendingApproval, models.RestrictionLiftPending)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve restrictions",
+		})
+	}
+
+	return c.JSON(list)
+}
+
+// ApproveRestriction approves the pending change of a restriction
+// Endpoint: POST /staff/restrictions/:restrictionId/approve
+func (h *RestrictionHandler) ApproveRestriction(c *fiber.Ctx) error {
+	return h.decide(c, h.restrictions.Approve)
+}
+
+// RejectRestriction rejects the pending change of a restriction
+// Endpoint: POST /staff/restrictions/:restrictionId/reject
+func (h *RestrictionHandler) RejectRestriction(c *fiber.Ctx) error {
+	return h.decide(c, h.restrictions.Reject)
+}
+
+// LiftRestriction requests the removal of an active restriction
+// Endpoint: POST /staff/restrictions/:restrictionId/lift
+func (h *RestrictionHandler) LiftRestriction(c *fiber.Ctx) error {
+	return h.decide(c, h.restrictions.RequestLift)
+}
+
+// decide parses the staff member, restriction ID and reason shared by the
+// decision endpoints and applies action
+func (h *RestrictionHandler) decide(c *fiber.Ctx, action func(ctx context.Context, id, staffID uuid.UUID, reason string) (*models.Restriction, error)) error {
+	staffID, err := middleware.GetStaffIDFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Staff identity is required",
+		})
+	}
+
+	restrictionID, err := uuid.Parse(c.Params("restrictionId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid restriction ID format",
+		})
+	}
+
+	var request models.RestrictionDecisionRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request format",
+			})
+		}
+	}
+
+	restriction, err := action(c.Context(), restrictionID, staffID, request.Reason)
+	if err != nil {
+		return restrictionError(c, err)
+	}
+
+	return c.JSON(restriction)
+}
+
+// GetRestrictionAudit returns the audit trail of a restriction
+// Endpoint: GET /staff/restrictions/:restrictionId/audit
+func (h *RestrictionHandler) GetRestrictionAudit(c *fiber.Ctx) error {
+	restrictionID, err := uuid.Parse(c.Params("restrictionId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid restriction ID format",
+		})
+	}
+
+	restriction, err := h.restrictionRepo.GetRestriction(c.Context(), restrictionID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve restriction",
+		})
+	}
+	if restriction == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Restriction not found",
+		})
+	}
+
+	entries, err := h.restrictionRepo.GetRestrictionAudit(c.Context(), restrictionID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve audit trail",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"restriction": restriction,
+		"audit":       entries,
+	})
+}
+
+// restrictionError writes the response for an error returned by the restriction service
+func restrictionError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, restrictions.ErrInvalidType),
+		errors.Is(err, restrictions.ErrInvalidAmount),
+		errors.Is(err, restrictions.ErrReferenceRequired),
+		errors.Is(err, restrictions.ErrReasonRequired),
+		errors.Is(err, restrictions.ErrInvalidExpiry):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, restrictions.ErrAccountNotFound), errors.Is(err, restrictions.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, restrictions.ErrSameStaff):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, restrictions.ErrNothingToApprove),
+		errors.Is(err, restrictions.ErrNotLiftable),
+		errors.Is(err, restrictions.ErrConflict):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, restrictions.ErrNotCustomerAccount):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	log.Printf("restriction operation failed: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to process restriction",
+	})
+}
+
+// restrictedError writes the response for a posting refused by a restriction,
+// including its error code. It reports false when err is not a restriction.
+func restrictedError(c *fiber.Ctx, err error) (bool, error) {
+	var restricted *ledger.RestrictionError
+	if !errors.As(err, &restricted) {
+		return false, nil
+	}
+	return true, c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+		"error":            restricted.Error(),
+		"code":             restricted.Code,
+		"reference_number": restricted.ReferenceNumber,
+	})
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"example.com/m/internal/mandates"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/standingorders"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// StandingOrderHandler contains handlers for scheduled and recurring transfers
+type StandingOrderHandler struct {
+	accountRepo repository.AccountRepository
+	orders      *standingorders.Service
+	mandates    *mandates.Service
+}
+
+// NewStandingOrderHandler creates a new StandingOrderHandler
+func NewStandingOrderHandler(accountRepo repository.AccountRepository, orderService *standingorders.Service, mandateService *mandates.Service) *StandingOrderHandler {
+	return &StandingOrderHandler{
+		accountRepo: accountRepo,
+		orders:      orderService,
+		mandates:    mandateService,
+	}
+}
+
+// CreateStandingOrder schedules a future-dated or recurring transfer from one of the caller's accounts
+// Endpoint: POST /accounts/:accountId/standing-orders
+func (h *StandingOrderHandler) CreateStandingOrder(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var request models.StandingOrderRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	if err := h.mandates.CheckSoleSignature(c.Context(), account, customerID); err != nil {
+		return mandateError(c, err)
+	}
+
+	order, err := h.orders.Create(c.Context(), account, customerID, request)
+	if err != nil {
+		return standingOrderError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(order)
+}
+
+// ListStandingOrders returns the standing orders of one of the caller's accounts
+// Endpoint: GET /accounts/:accountId/standing-orders
+func (h *StandingOrderHandler) ListStandingOrders(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+
+	orders, err := h.orders.List(c.Context(), account.ID)
+	if err != nil {
+		return standingOrderError(c, err)
+	}
+
+	return c.JSON(orders)
+}
+
+// GetStandingOrder returns a standing order with its executions
+// Endpoint: GET /accounts/:accountId/standing-orders/:orderId
+func (h *StandingOrderHandler) GetStandingOrder(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+	orderID, err := uuid.Parse(c.Params("orderId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid standing order ID format",
+		})
+	}
+
+	order, err := h.orders.Get(c.Context(), account.ID, orderID)
+	if err != nil {
+		return standingOrderError(c, err)
+	}
+
+	return c.JSON(order)
+}
+
+// PauseStandingOrder stops a standing order from running until it is resumed
+// Endpoint: POST /accounts/:accountId/standing-orders/:orderId/pause
+func (h *StandingOrderHandler) PauseStandingOrder(c *fiber.Ctx) error {
+	return h.change(c, h.orders.Pause)
+}
+
+// ResumeStandingOrder makes a paused standing order run again from its next date
+// Endpoint: POST /accounts/:accountId/standing-orders/:orderId/resume
+func (h *StandingOrderHandler) ResumeStandingOrder(c *fiber.Ctx) error {
+	return h.change(c, h.orders.Resume)
+}
+
+// CancelStandingOrder stops a standing order for good
+// Endpoint: POST /accounts/:accountId/standing-orders/:orderId/cancel
+func (h *StandingOrderHandler) CancelStandingOrder(c *fiber.Ctx) error {
+	return h.change(c, h.orders.Cancel)
+}
+
+// change parses the account and order shared by the status endpoints and applies action
+func (h *StandingOrderHandler) change(c *fiber.Ctx, action func(ctx context.Context, accountID, orderID uuid.UUID) (*models.StandingOrder, error)) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+	orderID, err := uuid.Parse(c.Params("orderId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid standing order ID format",
+		})
+	}
+
+	order, err := action(c.Context(), account.ID, orderID)
+	if err != nil {
+		return standingOrderError(c, err)
+	}
+
+	return c.JSON(order)
+}
+
+// standingOrderError writes the response for an error returned by the standing order service
+func standingOrderError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, standingorders.ErrInvalidFrequency),
+		errors.Is(err, standingorders.ErrInvalidDayOfMonth),
+		errors.Is(err, standingorders.ErrInvalidDate),
+		errors.Is(err, standingorders.ErrStartInPast),
+		errors.Is(err, standingorders.ErrNoRuns):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, standingorders.ErrOrderNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, standingorders.ErrNotActive),
+		errors.Is(err, standingorders.ErrNotPaused),
+		errors.Is(err, standingorders.ErrFinished):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return postingError(c, err)
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/jobs"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/statements"
+	"example.com/m/internal/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// defaultHistoryDays is the period returned by the transaction history when no range is given
+const defaultHistoryDays = 30
+
+// StatementHandler contains handlers for transaction history and account statements
+type StatementHandler struct {
+	accountRepo   repository.AccountRepository
+	ledgerRepo    repository.LedgerRepository
+	statementRepo repository.StatementRepository
+	store         storage.BlobStorage
+	worker        *statements.Worker
+	signer        *statements.URLSigner
+	cfg           config.StatementConfig
+}
+
+// NewStatementHandler creates a new StatementHandler
+func NewStatementHandler(
+	accountRepo repository.AccountRepository,
+	ledgerRepo repository.LedgerRepository,
+	statementRepo repository.StatementRepository,
+	store storage.BlobStorage,
+	worker *statements.Worker,
+	signer *statements.URLSigner,
+	cfg config.StatementConfig,
+) *StatementHandler {
+	return &StatementHandler{
+		accountRepo:   accountRepo,
+		ledgerRepo:    ledgerRepo,
+		statementRepo: statementRepo,
+		store:         store,
+		worker:        worker,
+		signer:        signer,
+		cfg:           cfg,
+	}
+}
+
+// GetTransactionHistory lists the transactions on one of the caller's
+// accounts. Without from and to the last 30 days are returned.
+// Endpoint: GET /accounts/:accountId/transactions?from=YYYY-MM-DD&to=YYYY-MM-DD
+func (h *StatementHandler) GetTransactionHistory(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+
+	to := jobs.BusinessDate(time.Now())
+	from := to.AddDate(0, 0, -defaultHistoryDays+1)
+	if c.Query("from") != "" || c.Query("to") != "" {
+		from, to, err = parseDateRange(c.Query("from"), c.Query("to"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+	if jobs.DaysBetween(from, to) >= h.cfg.MaxDays {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Date range must not exceed %d days", h.cfg.MaxDays),
+		})
+	}
+
+	history, err := h.ledgerRepo.GetAccountHistory(c.Context(), account.ID, from, to)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve transactions",
+		})
+	}
+
+	return c.JSON(history)
+}
+
+// RequestStatement queues a statement of one of the caller's accounts for a date range
+// Endpoint: POST /accounts/:accountId/statements/request
+func (h *StatementHandler) RequestStatement(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+
+	var request models.StatementRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+
+	from, to, err := parseDateRange(request.From, request.To)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if to.After(jobs.BusinessDate(time.Now())) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "to must not be in the future",
+		})
+	}
+	if jobs.DaysBetween(from, to) >= h.cfg.MaxDays {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Date range must not exceed %d days", h.cfg.MaxDays),
+		})
+	}
+
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	statement := &models.Statement{
+		ID:          uuid.New(),
+		AccountID:   account.ID,
+		CustomerID:  customerID,
+		FromDate:    from,
+		ToDate:      to,
+		Status:      models.StatementPending,
+		RequestedAt: now,
+		UpdatedAt:   now,
+	}
+	if err := h.statementRepo.CreateStatement(c.Context(), statement); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to request statement",
+		})
+	}
+	h.worker.Enqueue(statement.ID)
+
+	return c.Status(fiber.StatusAccepted).JSON(models.StatementResponse{Statement: statement})
+}
+
+// GetStatement returns the status of a statement request. Once the statement
+// is ready the response includes short-lived download links.
+// Endpoint: GET /accounts/:accountId/statements/:statementId
+func (h *StatementHandler) GetStatement(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+
+	statementID, err := uuid.Parse(c.Params("statementId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid statement ID format",
+		})
+	}
+
+	statement, err := h.statementRepo.GetStatement(c.Context(), statementID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve statement",
+		})
+	}
+	if statement == nil || statement.AccountID != account.ID {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Statement not found",
+		})
+	}
+
+	response := models.StatementResponse{Statement: statement}
+	if statement.Status == models.StatementReady {
+		now := time.Now()
+		response.Downloads = []models.StatementDownload{}
+		if statement.PDFKey != "" {
+			response.Downloads = append(response.Downloads, h.signer.Sign(statement.ID, models.StatementFormatPDF, now))
+		}
+		response.Downloads = append(response.Downloads, h.signer.Sign(statement.ID, models.StatementFormatCSV, now))
+	}
+
+	return c.JSON(response)
+}
+
+// DownloadStatement streams a rendered statement file. The request is
+// authorized by the signed link returned from GetStatement, not by a token.
+// Endpoint: GET /statements/:statementId/download?format=&expires=&signature=
+func (h *StatementHandler) DownloadStatement(c *fiber.Ctx) error {
+	statementID, err := uuid.Parse(c.Params("statementId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid statement ID format",
+		})
+	}
+
+	format := models.StatementFormat(c.Query("format"))
+	if !format.IsValid() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "format must be pdf or csv",
+		})
+	}
+
+	err = h.signer.Verify(statementID, format, c.Query("expires"), c.Query("signature"), time.Now())
+	if errors.Is(err, statements.ErrLinkExpired) {
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	statement, err := h.statementRepo.GetStatement(c.Context(), statementID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve statement",
+		})
+	}
+	if statement == nil || statement.Status != models.StatementReady {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Statement not found",
+		})
+	}
+
+	key, contentType := statement.PDFKey, "application/pdf"
+	if format == models.StatementFormatCSV {
+		key, contentType = statement.CSVKey, "text/csv; charset=utf-8"
+	}
+	if key == "" {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "This statement is not available as " + string(format),
+		})
+	}
+
+	content, err := h.store.Open(c.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Statement file not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to read statement",
+		})
+	}
+
+	fileName := fmt.Sprintf("statement_%s_%s.%s",
+		statement.FromDate.Format("20060102"), statement.ToDate.Format("20060102"), format)
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", fileName))
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.SendStream(content)
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// TransactionHandler contains handlers for single ledger transactions
+type TransactionHandler struct {
+	accountRepo   repository.AccountRepository
+	ledgerRepo    repository.LedgerRepository
+	interbankRepo repository.InterbankRepository
+}
+
+// NewTransactionHandler creates a new TransactionHandler
+func NewTransactionHandler(accountRepo repository.AccountRepository, ledgerRepo repository.LedgerRepository, interbankRepo repository.InterbankRepository) *TransactionHandler {
+	return &TransactionHandler{
+		accountRepo:   accountRepo,
+		ledgerRepo:    ledgerRepo,
+		interbankRepo: interbankRepo,
+	}
+}
+
+// GetTransaction returns a ledger transaction with its status. Only the
+// entries on the caller's accounts are shown, and transactions that touch
+// none of them are reported as not found.
+// Endpoint: GET /transactions/:transactionId
+func (h *TransactionHandler) GetTransaction(c *fiber.Ctx) error {
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return err
+	}
+	transactionID, err := uuid.Parse(c.Params("transactionId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid transaction ID format",
+		})
+	}
+
+	txn, err := h.ledgerRepo.GetTransaction(c.Context(), transactionID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve transaction",
+		})
+	}
+
+	details := models.TransactionDetails{Entries: []models.LedgerEntry{}}
+	if txn != nil {
+		for _, entry := range txn.Entries {
+			mandate, err := h.accountRepo.GetMandate(c.Context(), entry.AccountID, customerID)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to retrieve transaction",
+				})
+			}
+			if mandate != nil {
+				details.Entries = append(details.Entries, entry)
+			}
+		}
+	}
+	if len(details.Entries) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Transaction not found",
+		})
+	}
+
+	details.ID = txn.ID
+	details.Reference = txn.Reference
+	details.Type = txn.Type
+	details.Description = txn.Description
+	details.Status = txn.Status
+	details.BusinessDate = txn.BusinessDate
+	details.CreatedAt = txn.CreatedAt
+
+	if txn.Type == models.LedgerInterbankTransfer {
+		transfer, err := h.interbankRepo.GetInterbankTransferByTransaction(c.Context(), txn.ID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to retrieve transaction",
+			})
+		}
+		details.Interbank = transfer
+	}
+
+	return c.JSON(details)
+}
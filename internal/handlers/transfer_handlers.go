@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/mandates"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/transfers"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxClientReferenceLength limits the idempotency key a client may send
+const maxClientReferenceLength = 100
+
+// TransferHandler contains handlers for customer transfers and withdrawals
+type TransferHandler struct {
+	accountRepo repository.AccountRepository
+	transfers   *transfers.Service
+	mandates    *mandates.Service
+}
+
+// NewTransferHandler creates a new TransferHandler
+func NewTransferHandler(accountRepo repository.AccountRepository, transferService *transfers.Service, mandateService *mandates.Service) *TransferHandler {
+	return &TransferHandler{
+		accountRepo: accountRepo,
+		transfers:   transferService,
+		mandates:    mandateService,
+	}
+}
+
+// Transfer moves funds from one of the caller's accounts to another account.
+// From an account where all holders must sign it returns 202 with a pending
+// transfer that moves the money once the other holders approve it.
+// Endpoint: POST /accounts/:accountId/transfer
+func (h *TransferHandler) Transfer(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+
+	var request models.TransferRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+	if len(request.Reference) > maxClientReferenceLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Reference is too long",
+		})
+	}
+
+	destination, err := h.transfers.FindDestination(c.Context(), request.ToAccountNumber)
+	if err != nil {
+		return postingError(c, err)
+	}
+
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return err
+	}
+	return submitTransfer(c, h.transfers, h.mandates, transfers.Input{
+		From:        account,
+		To:          destination,
+		Amount:      request.Amount,
+		Description: request.Description,
+		Reference:   request.Reference,
+		CustomerID:  customerID,
+		Channel:     models.ChannelMobile,
+	})
+}
+
+// submitTransfer posts a customer transfer, or records it for the other
+// holders to approve when the account needs all of them to sign
+func submitTransfer(c *fiber.Ctx, transferService *transfers.Service, mandateService *mandates.Service, input transfers.Input) error {
+	coSigners, err := mandateService.CoSigners(c.Context(), input.From, input.CustomerID)
+	if err != nil {
+		return mandateError(c, err)
+	}
+	if len(coSigners) > 0 {
+		pending, err := mandateService.RequestTransfer(c.Context(), input, input.CustomerID, coSigners)
+		if err != nil {
+			return postingError(c, err)
+		}
+		return c.Status(fiber.StatusAccepted).JSON(pending)
+	}
+
+	result, err := transferService.Transfer(c.Context(), input)
+	if err != nil {
+		return postingError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(result)
+}
+
+// Withdraw pays out cash from one of the caller's accounts
+// Endpoint: POST /accounts/:accountId/withdraw
+func (h *TransferHandler) Withdraw(c *fiber.Ctx) error {
+	account, err := customerAccountParam(c, h.accountRepo)
+	if account == nil {
+		return err
+	}
+
+	var request models.WithdrawalRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request format",
+		})
+	}
+	if len(request.Reference) > maxClientReferenceLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Reference is too long",
+		})
+	}
+
+	customerID, err := customerIDFromContext(c)
+	if err != nil {
+		return err
+	}
+	if err := h.mandates.CheckSoleSignature(c.Context(), account, customerID); err != nil {
+		return mandateError(c, err)
+	}
+
+	result, err := h.transfers.Withdraw(c.Context(), transfers.Withdrawal{
+		From:       account,
+		Amount:     request.Amount,
+		Reference:  request.Reference,
+		CustomerID: customerID,
+		Channel:    models.ChannelMobile,
+	})
+	if err != nil {
+		return postingError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(result)
+}
+
+// postingError writes the response for an error returned while moving funds
+func postingError(c *fiber.Ctx, err error) error {
+	if handled, response := restrictedError(c, err); handled {
+		return response
+	}
+	if handled, response := limitExceededError(c, err); handled {
+		return response
+	}
+
+	switch {
+	case errors.Is(err, transfers.ErrInvalidAmount),
+		errors.Is(err, transfers.ErrSameAccount),
+		errors.Is(err, transfers.ErrCurrencyMismatch),
+		errors.Is(err, ledger.ErrCurrencyMismatch):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, transfers.ErrDestinationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, transfers.ErrAlreadyProcessed):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, ledger.ErrInsufficientFunds),
+		errors.Is(err, ledger.ErrBelowMinimumBalance),
+		errors.Is(err, models.ErrBelowOpeningBalance),
+		errors.Is(err, ledger.ErrWithdrawalLimit),
+		errors.Is(err, ledger.ErrAccountNotActive),
+		errors.Is(err, ledger.ErrAccountDormant):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	log.Printf("posting failed: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to process the request",
+	})
+}
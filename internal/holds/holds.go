@@ -0,0 +1,155 @@
+package holds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidAmount is returned when a hold or capture amount is not positive
+	ErrInvalidAmount = errors.New("amount must be greater than zero")
+	// ErrInvalidExpiry is returned when a hold would already be expired
+	ErrInvalidExpiry = errors.New("expiry must be in the future")
+	// ErrNotCustomerAccount is returned when a hold is placed on an internal account
+	ErrNotCustomerAccount = errors.New("holds can only be placed on customer accounts")
+	// ErrHoldNotFound is returned when a hold does not exist
+	ErrHoldNotFound = errors.New("hold not found")
+)
+
+// Service places, releases and captures holds on customer accounts
+type Service struct {
+	repo   repository.HoldRepository
+	ledger *ledger.Service
+	now    func() time.Time
+}
+
+// NewService creates a new hold Service
+func NewService(repo repository.HoldRepository, ledgerService *ledger.Service) *Service {
+	return &Service{
+		repo:   repo,
+		ledger: ledgerService,
+		now:    time.Now,
+	}
+}
+
+// Place reserves hold.Amount on the account. The account must be active and
+// its available balance must cover the hold.
+func (s *Service) Place(ctx context.Context, hold *models.Hold) error {
+	if hold.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+	now := s.now()
+	if !hold.ExpiresAt.After(now) {
+		return ErrInvalidExpiry
+	}
+
+	hold.ID = uuid.New()
+	hold.Status = models.HoldActive
+	hold.CreatedAt = now
+	hold.UpdatedAt = now
+
+	return s.repo.PlaceHold(ctx, hold, checkAvailable)
+}
+
+// checkAvailable is the HoldValidator used for every hold
+func checkAvailable(account *models.Account, hold *models.Hold) error {
+	if account.IsInternal() {
+		return ErrNotCustomerAccount
+	}
+	if account.Status == models.AccountStatusDormant {
+		return fmt.Errorf("%w: %s", ledger.ErrAccountDormant, account.AccountNumber)
+	}
+	if account.Status != models.AccountStatusActive {
+		return fmt.Errorf("%w: %s", ledger.ErrAccountNotActive, account.AccountNumber)
+	}
+	if err := ledger.CheckDebit(account, hold.Amount, time.Now()); err != nil {
+		return err
+	}
+	if account.Available()-hold.Amount < -0.000001 {
+		return fmt.Errorf("%w: %s", ledger.ErrInsufficientFunds, account.AccountNumber)
+	}
+	return nil
+}
+
+// Release gives the held funds back to the available balance
+func (s *Service) Release(ctx context.Context, id uuid.UUID) (*models.Hold, error) {
+	return s.repo.ReleaseHold(ctx, id, models.HoldReleased)
+}
+
+// Capture posts held funds from the held account to creditAccountID and ends
+// the hold in the same ledger transaction. An amount of zero captures the
+// held amount; a different amount may be captured, for example when a card
+// transaction clears for more or less than it was authorized for.
+func (s *Service) Capture(ctx context.Context, id uuid.UUID, amount float64, creditAccountID uuid.UUID, txnType models.LedgerTransactionType, description string) (*models.LedgerTransaction, error) {
+	hold, err := s.repo.GetHold(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if hold == nil {
+		return nil, ErrHoldNotFound
+	}
+	if hold.Status != models.HoldActive {
+		return nil, repository.ErrHoldNotActive
+	}
+	if amount == 0 {
+		amount = hold.Amount
+	}
+	if amount < 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	txn := &models.LedgerTransaction{
+		Reference:      "hold-capture:" + hold.ID.String(),
+		Type:           txnType,
+		Description:    description,
+		CapturesHoldID: &hold.ID,
+		Entries: []models.LedgerEntry{
+			ledger.Debit(hold.AccountID, amount),
+			ledger.Credit(creditAccountID, amount),
+		},
+	}
+	if err := s.ledger.Post(ctx, txn); err != nil {
+		return nil, err
+	}
+	return txn, nil
+}
+
+// ExpireDue releases every active hold whose expiry is not after now and
+// returns how many holds were released
+func (s *Service) ExpireDue(ctx context.Context, now time.Time) (int, error) {
+	ids, err := s.repo.GetExpiredHoldIDs(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, id := range ids {
+		_, err := s.repo.ReleaseHold(ctx, id, models.HoldExpired)
+		if errors.Is(err, repository.ErrHoldNotActive) {
+			// Captured or released since it was listed
+			continue
+		}
+		if err != nil {
+			return released, fmt.Errorf("failed to expire hold %s: %w", id, err)
+		}
+		released++
+	}
+	return released, nil
+}
+
+// Balance returns the ledger, held and available balance of an account
+func Balance(account *models.Account) models.AccountBalance {
+	return models.AccountBalance{
+		AccountID:        account.ID,
+		LedgerBalance:    account.Balance,
+		HeldAmount:       account.HeldAmount,
+		AvailableBalance: account.Available(),
+	}
+}
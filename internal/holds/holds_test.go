@@ -0,0 +1,54 @@
+package holds
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubHoldRepository runs the validator against a fixed account
+type stubHoldRepository struct {
+	repository.HoldRepository
+	account *models.Account
+	placed  []*models.Hold
+}
+
+func (r *stubHoldRepository) PlaceHold(ctx context.Context, hold *models.Hold, validate repository.HoldValidator) error {
+	if err := validate(r.account, hold); err != nil {
+		return err
+	}
+	r.account.HeldAmount += hold.Amount
+	r.placed = append(r.placed, hold)
+	return nil
+}
+
+func TestPlaceHoldChecksAvailableBalance(t *testing.T) {
+	account := &models.Account{AccountNumber: "1234567890", AccountType: models.AccountTypeSavings, Status: models.AccountStatusActive, Balance: 1000}
+	repo := &stubHoldRepository{account: account}
+	service := NewService(repo, nil)
+	now := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	first := &models.Hold{AccountID: account.ID, Amount: 700, ExpiresAt: now.Add(time.Hour)}
+	require.NoError(t, service.Place(context.Background(), first))
+	assert.Equal(t, models.HoldActive, first.Status)
+	assert.Equal(t, 300.0, account.Available())
+
+	// Only 300 is left once the first hold is in place
+	err := service.Place(context.Background(), &models.Hold{AccountID: account.ID, Amount: 300.01, ExpiresAt: now.Add(time.Hour)})
+	assert.ErrorIs(t, err, ledger.ErrInsufficientFunds)
+	assert.NoError(t, service.Place(context.Background(), &models.Hold{AccountID: account.ID, Amount: 300, ExpiresAt: now.Add(time.Hour)}))
+
+	assert.ErrorIs(t, service.Place(context.Background(), &models.Hold{Amount: 0, ExpiresAt: now.Add(time.Hour)}), ErrInvalidAmount)
+	assert.ErrorIs(t, service.Place(context.Background(), &models.Hold{Amount: 1, ExpiresAt: now}), ErrInvalidExpiry)
+
+	account.Status = models.AccountStatusClosed
+	err = service.Place(context.Background(), &models.Hold{Amount: 1, ExpiresAt: now.Add(time.Hour)})
+	assert.ErrorIs(t, err, ledger.ErrAccountNotActive)
+}
@@ -0,0 +1,66 @@
+// Package identity checks the identity details customers re-enter to step
+// up an operation, such as raising a limit or reactivating a dormant account.
+package identity
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/database"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrMismatch is returned when the re-entered identity details do not match the ones on record
+	ErrMismatch = errors.New("identity details do not match our records")
+	// ErrLocked is returned when the customer used up their identity checks for now
+	ErrLocked = errors.New("too many identity checks; please try again later")
+)
+
+// Verifier checks re-entered identity details. Each customer gets
+// MaxAttempts checks per LockoutMinutes, across every operation that needs
+// one; a successful check gives them all back.
+type Verifier struct {
+	repo         repository.IdentityRepository
+	customerRepo database.CustomerRepositoryInterface
+	cfg          config.IdentityConfig
+	now          func() time.Time
+}
+
+// NewVerifier creates a new Verifier
+func NewVerifier(repo repository.IdentityRepository, customerRepo database.CustomerRepositoryInterface, cfg config.IdentityConfig) *Verifier {
+	return &Verifier{
+		repo:         repo,
+		customerRepo: customerRepo,
+		cfg:          cfg,
+		now:          time.Now,
+	}
+}
+
+// Verify checks the ID card and phone numbers a customer re-entered against
+// the ones on record. The attempt is counted before the details are
+// compared, so checks made in parallel cannot get past the limit.
+func (v *Verifier) Verify(ctx context.Context, customerID uuid.UUID, request models.IdentityVerificationRequest) error {
+	now := v.now()
+	window := time.Duration(v.cfg.LockoutMinutes) * time.Minute
+	allowed, err := v.repo.UseVerificationAttempt(ctx, customerID, now, now.Add(-window), v.cfg.MaxAttempts)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrLocked
+	}
+
+	customer, err := v.customerRepo.GetByID(customerID.String())
+	if err != nil {
+		return err
+	}
+	if !customer.MatchesIdentity(request) {
+		return ErrMismatch
+	}
+	return v.repo.ClearVerificationAttempts(ctx, customerID)
+}
@@ -0,0 +1,39 @@
+package identity
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository/repotest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyLimitsChecksPerCustomer(t *testing.T) {
+	customerID, otherID := uuid.New(), uuid.New()
+	customers := repotest.NewCustomers(repotest.Customer(customerID, "1100100123456", "0812345678"), repotest.Customer(otherID, "1100100123456", "0812345678"))
+	verifier := NewVerifier(&repotest.IdentityAttempts{}, customers, config.IdentityConfig{MaxAttempts: 2, LockoutMinutes: 60})
+	now := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	verifier.now = func() time.Time { return now }
+	ctx := context.Background()
+	right := models.IdentityVerificationRequest{IDCardNumber: "1100100123456", PhoneNumber: "0812345678"}
+	wrong := models.IdentityVerificationRequest{IDCardNumber: "1100100123456", PhoneNumber: "0899999999"}
+
+	// A successful check gives the customer their checks back
+	assert.ErrorIs(t, verifier.Verify(ctx, customerID, wrong), ErrMismatch)
+	require.NoError(t, verifier.Verify(ctx, customerID, right))
+	assert.ErrorIs(t, verifier.Verify(ctx, customerID, wrong), ErrMismatch)
+	assert.ErrorIs(t, verifier.Verify(ctx, customerID, wrong), ErrMismatch)
+	assert.ErrorIs(t, verifier.Verify(ctx, customerID, right), ErrLocked)
+
+	// Other customers are not affected
+	require.NoError(t, verifier.Verify(ctx, otherID, right))
+
+	// The lockout ends once the window is over
+	now = now.Add(61 * time.Minute)
+	require.NoError(t, verifier.Verify(ctx, customerID, right))
+}
@@ -0,0 +1,216 @@
+package interbank
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/m/internal/config"
+)
+
+// Outcome is the switch's answer to an instruction
+type Outcome string
+
+const (
+	// OutcomeSettled indicates the other bank credited its customer
+	OutcomeSettled Outcome = "settled"
+	// OutcomeReturned indicates the other bank refused the credit
+	OutcomeReturned Outcome = "returned"
+)
+
+// Instruction is an outbound credit transfer sent to the switch
+type Instruction struct {
+	// Reference identifies the transfer; sending it again replaces the first instruction
+	Reference         string    `json:"reference"`
+	FromBankCode      string    `json:"from_bank_code"`
+	FromAccountNumber string    `json:"from_account_number"`
+	ToBankCode        string    `json:"to_bank_code"`
+	ToAccountNumber   string    `json:"to_account_number"`
+	Amount            float64   `json:"amount"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// Response is the switch's answer to an instruction, received some time after it was sent
+type Response struct {
+	Reference string  `json:"reference"`
+	Outcome   Outcome `json:"outcome"`
+	Reason    string  `json:"reason,omitempty"`
+
+	// file is where the file connector read the response from
+	file string
+}
+
+// Connector sends instructions to the switch and collects its answers.
+// Responses are delivered until they are acknowledged, so one that was
+// received but not applied before a restart is delivered again.
+type Connector interface {
+	Submit(ctx context.Context, instruction Instruction) error
+	Responses(ctx context.Context) ([]Response, error)
+	Acknowledge(ctx context.Context, response Response) error
+}
+
+// NewConnector returns the connector named by cfg.Connector
+func NewConnector(cfg config.InterbankConfig) (Connector, error) {
+	switch cfg.Connector {
+	case "", "memory":
+		return NewMemoryConnector(cfg), nil
+	case "file":
+		return NewFileConnector(cfg.OutboxDir, cfg.InboxDir)
+	}
+	return nil, fmt.Errorf("unknown interbank connector %q", cfg.Connector)
+}
+
+// MemoryConnector is an in-memory fake of the switch. It answers every
+// instruction on the next poll with the outcome configured for the
+// recipient in the simulator: success settles, reject returns and timeout
+// never answers. Instructions to unknown accounts are returned.
+type MemoryConnector struct {
+	proxies []config.SimulatedProxy
+
+	mu        sync.Mutex
+	responses map[string]Response
+}
+
+// NewMemoryConnector creates an in-memory fake switch with the simulated accounts of cfg
+func NewMemoryConnector(cfg config.InterbankConfig) *MemoryConnector {
+	return &MemoryConnector{
+		proxies:   cfg.Simulator,
+		responses: map[string]Response{},
+	}
+}
+
+// Submit queues the answer to an instruction
+func (c *MemoryConnector) Submit(ctx context.Context, instruction Instruction) error {
+	response := Response{Reference: instruction.Reference, Outcome: OutcomeReturned, Reason: "account not found"}
+	if proxy := c.recipient(instruction); proxy != nil {
+		switch proxy.Outcome {
+		case "timeout":
+			return nil
+		case "reject":
+			response.Reason = "account cannot receive transfers"
+		default:
+			response = Response{Reference: instruction.Reference, Outcome: OutcomeSettled}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.responses[instruction.Reference] = response
+	return nil
+}
+
+func (c *MemoryConnector) recipient(instruction Instruction) *config.SimulatedProxy {
+	for i, proxy := range c.proxies {
+		if proxy.BankCode == instruction.ToBankCode && proxy.AccountNumber == instruction.ToAccountNumber {
+			return &c.proxies[i]
+		}
+	}
+	return nil
+}
+
+// Responses returns the answers not yet acknowledged
+func (c *MemoryConnector) Responses(ctx context.Context) ([]Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	responses := make([]Response, 0, len(c.responses))
+	for _, response := range c.responses {
+		responses = append(responses, response)
+	}
+	sort.Slice(responses, func(i, j int) bool { return responses[i].Reference < responses[j].Reference })
+	return responses, nil
+}
+
+// Acknowledge drops an answer that was applied
+func (c *MemoryConnector) Acknowledge(ctx context.Context, response Response) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.responses, response.Reference)
+	return nil
+}
+
+// FileConnector exchanges files with the switch: each instruction is written
+// as <reference>.json to the outbox directory, and the switch drops its
+// responses as JSON files into the inbox directory. Applied responses are
+// moved to the processed directory inside the inbox.
+type FileConnector struct {
+	outbox string
+	inbox  string
+}
+
+// NewFileConnector creates a file connector, making its directories if needed
+func NewFileConnector(outbox, inbox string) (*FileConnector, error) {
+	for _, dir := range []string{outbox, inbox, filepath.Join(inbox, "processed")} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create interbank directory: %w", err)
+		}
+	}
+	return &FileConnector{outbox: outbox, inbox: inbox}, nil
+}
+
+// Submit writes the instruction to the outbox. The file appears under its
+// final name only once it is complete.
+func (c *FileConnector) Submit(ctx context.Context, instruction Instruction) error {
+	data, err := json.MarshalIndent(instruction, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := filepath.Join(c.outbox, safeName(instruction.Reference)+".json")
+	if err := os.WriteFile(name+".tmp", data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(name+".tmp", name)
+}
+
+// Responses reads the response files in the inbox
+func (c *FileConnector) Responses(ctx context.Context) ([]Response, error) {
+	names, err := filepath.Glob(filepath.Join(c.inbox, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	responses := []Response{}
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		var response Response
+		if err := json.Unmarshal(data, &response); err != nil {
+			// Left in the inbox for operations to look at
+			log.Printf("skipping invalid interbank response file %s: %v", filepath.Base(name), err)
+			continue
+		}
+		if response.Reference == "" {
+			response.Reference = strings.TrimSuffix(filepath.Base(name), ".json")
+		}
+		response.file = name
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+// Acknowledge moves the response file to the processed directory
+func (c *FileConnector) Acknowledge(ctx context.Context, response Response) error {
+	if response.file == "" {
+		return nil
+	}
+	err := os.Rename(response.file, filepath.Join(c.inbox, "processed", filepath.Base(response.file)))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// safeName keeps a reference from escaping the connector directories
+func safeName(reference string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(reference)
+}
@@ -0,0 +1,110 @@
+package interbank
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/models"
+)
+
+var (
+	// ErrProxyNotFound is returned when no bank has the proxy registered
+	ErrProxyNotFound = errors.New("proxy is not registered at any bank")
+	// ErrTimeout is returned when the switch does not answer in time
+	ErrTimeout = errors.New("the interbank switch did not answer in time")
+	// ErrRejected is returned when the recipient bank refuses a transfer
+	ErrRejected = errors.New("the recipient bank rejected the transfer")
+)
+
+// RejectError is returned when the recipient bank refuses a transfer, with its reason
+type RejectError struct {
+	Reason string
+}
+
+func (e *RejectError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrRejected, e.Reason)
+}
+
+// Is makes errors.Is(err, ErrRejected) match a RejectError
+func (e *RejectError) Is(target error) bool {
+	return target == ErrRejected
+}
+
+// Recipient is the account a proxy resolves to at another bank
+type Recipient struct {
+	BankCode      string
+	AccountNumber string
+	FirstName     string
+	LastName      string
+}
+
+// Credit is a transfer sent through the switch to an account at another bank
+type Credit struct {
+	// Reference identifies the transfer on both sides; sending it twice credits once
+	Reference         string
+	FromBankCode      string
+	FromAccountNumber string
+	ToBankCode        string
+	ToAccountNumber   string
+	Amount            float64
+}
+
+// Switch is the connection to the interbank switch
+type Switch interface {
+	// Lookup resolves a proxy registered at another bank
+	Lookup(ctx context.Context, proxyType models.ProxyType, value string) (*Recipient, error)
+	// Send credits an account at another bank. It returns ErrTimeout when
+	// the switch does not answer and a RejectError when the bank refuses.
+	Send(ctx context.Context, credit Credit) error
+}
+
+// Simulator is a local stand-in for the interbank switch. It knows the
+// proxies listed in the configuration and gives transfers to each of them
+// its configured outcome, so every path of an outbound transfer can be tried
+// without a connection to the real switch.
+type Simulator struct {
+	proxies []config.SimulatedProxy
+}
+
+// NewSimulator creates a switch stand-in with the simulated proxies of cfg
+func NewSimulator(cfg config.InterbankConfig) *Simulator {
+	return &Simulator{
+		proxies: cfg.Simulator,
+	}
+}
+
+// Lookup resolves a simulated proxy
+func (s *Simulator) Lookup(ctx context.Context, proxyType models.ProxyType, value string) (*Recipient, error) {
+	for _, proxy := range s.proxies {
+		normalized, ok := models.NormalizeProxy(models.ProxyType(proxy.ProxyType), proxy.ProxyValue)
+		if !ok || models.ProxyType(proxy.ProxyType) != proxyType || normalized != value {
+			continue
+		}
+		return &Recipient{
+			BankCode:      proxy.BankCode,
+			AccountNumber: proxy.AccountNumber,
+			FirstName:     proxy.FirstName,
+			LastName:      proxy.LastName,
+		}, nil
+	}
+	return nil, ErrProxyNotFound
+}
+
+// Send gives the transfer the outcome configured for its recipient
+func (s *Simulator) Send(ctx context.Context, credit Credit) error {
+	for _, proxy := range s.proxies {
+		if proxy.BankCode != credit.ToBankCode || proxy.AccountNumber != credit.ToAccountNumber {
+			continue
+		}
+		switch proxy.Outcome {
+		case "timeout":
+			return ErrTimeout
+		case "reject":
+			return &RejectError{Reason: "account cannot receive transfers"}
+		}
+		return nil
+	}
+	return &RejectError{Reason: "account not found"}
+}
@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"example.com/m/internal/bulkpayments"
+)
+
+// BulkPaymentJob executes the bulk payment files customers have confirmed
+type BulkPaymentJob struct {
+	bulkPayments *bulkpayments.Service
+}
+
+// NewBulkPaymentJob creates a new BulkPaymentJob
+func NewBulkPaymentJob(bulkPaymentService *bulkpayments.Service) *BulkPaymentJob {
+	return &BulkPaymentJob{
+		bulkPayments: bulkPaymentService,
+	}
+}
+
+// Name returns the job name used in logs
+func (j *BulkPaymentJob) Name() string {
+	return "bulk payment executor"
+}
+
+// RunOnce pays the rows of every confirmed file
+func (j *BulkPaymentJob) RunOnce(ctx context.Context, now time.Time) error {
+	completed, err := j.bulkPayments.RunDue(ctx)
+	if completed > 0 {
+		log.Printf("%s completed %d bulk payment file(s)", j.Name(), completed)
+	}
+	return err
+}
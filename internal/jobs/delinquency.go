@@ -0,0 +1,101 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/google/uuid"
+)
+
+// DelinquencyJob marks overdue loan installments, applies late fees and
+// penalty interest, and classifies loans into aging buckets
+type DelinquencyJob struct {
+	repo repository.LoanAccountRepository
+	cfg  config.DelinquencyConfig
+}
+
+// NewDelinquencyJob creates a new DelinquencyJob
+func NewDelinquencyJob(repo repository.LoanAccountRepository, cfg config.DelinquencyConfig) *DelinquencyJob {
+	return &DelinquencyJob{
+		repo: repo,
+		cfg:  cfg,
+	}
+}
+
+// Name returns the job name used in logs
+func (j *DelinquencyJob) Name() string {
+	return "delinquency job"
+}
+
+// Run processes the given business date. Running it again for the same
+// date does not charge fees or penalty interest a second time.
+func (j *DelinquencyJob) Run(ctx context.Context, businessDate time.Time) error {
+	businessDate = BusinessDate(businessDate)
+
+	installments, err := j.repo.GetOverdueInstallments(ctx, businessDate)
+	if err != nil {
+		return fmt.Errorf("failed to load overdue installments: %w", err)
+	}
+
+	for _, installment := range installments {
+		installment.DaysPastDue = DaysBetween(installment.DueDate, businessDate)
+		charges := j.chargesFor(installment, businessDate)
+		if err := j.repo.ApplyInstallmentDelinquency(ctx, installment, charges); err != nil {
+			return fmt.Errorf("failed to update installment %s: %w", installment.ID, err)
+		}
+	}
+
+	loans, err := j.repo.GetActiveLoanDaysPastDue(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load loan aging: %w", err)
+	}
+	for loanID, dpd := range loans {
+		if err := j.repo.UpdateLoanAging(ctx, loanID, dpd, models.AgingBucketFor(dpd)); err != nil {
+			return fmt.Errorf("failed to update aging of loan %s: %w", loanID, err)
+		}
+	}
+
+	return j.repo.RecordDelinquencyRun(ctx, businessDate, len(installments))
+}
+
+// chargesFor returns the charges due on an overdue installment for the business date
+func (j *DelinquencyJob) chargesFor(installment *models.LoanInstallment, businessDate time.Time) []*models.LoanCharge {
+	if installment.DaysPastDue <= j.cfg.GraceDays {
+		return nil
+	}
+
+	now := time.Now()
+	charges := []*models.LoanCharge{}
+
+	if j.cfg.LateFee > 0 {
+		charges = append(charges, &models.LoanCharge{
+			ID:            uuid.New(),
+			LoanID:        installment.LoanID,
+			InstallmentID: installment.ID,
+			ChargeType:    models.LoanChargeLateFee,
+			Amount:        j.cfg.LateFee,
+			BusinessDate:  businessDate,
+			CreatedAt:     now,
+		})
+	}
+
+	penalty := math.Round(installment.OverdueAmount()*j.cfg.PenaltyAnnualRate/365*100) / 100
+	if penalty > 0 {
+		charges = append(charges, &models.LoanCharge{
+			ID:            uuid.New(),
+			LoanID:        installment.LoanID,
+			InstallmentID: installment.ID,
+			ChargeType:    models.LoanChargePenaltyInterest,
+			Amount:        penalty,
+			BusinessDate:  businessDate,
+			CreatedAt:     now,
+		})
+	}
+
+	return charges
+}
@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgingBucketFor(t *testing.T) {
+	assert.Equal(t, models.AgingCurrent, models.AgingBucketFor(0))
+	assert.Equal(t, models.Aging1To30, models.AgingBucketFor(30))
+	assert.Equal(t, models.Aging31To60, models.AgingBucketFor(31))
+	assert.Equal(t, models.Aging61To90, models.AgingBucketFor(90))
+	assert.Equal(t, models.AgingOver90, models.AgingBucketFor(91))
+}
+
+func TestDaysBetweenIgnoresTimeZone(t *testing.T) {
+	bangkok := time.FixedZone("ICT", 7*60*60)
+	due := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	asOf := time.Date(2026, 3, 11, 0, 30, 0, 0, bangkok)
+	assert.Equal(t, 10, DaysBetween(due, asOf))
+}
+
+func TestDelinquencyChargesFor(t *testing.T) {
+	job := NewDelinquencyJob(nil, config.DelinquencyConfig{GraceDays: 3, LateFee: 100, PenaltyAnnualRate: 0.0365})
+	businessDate := time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)
+
+	withinGrace := &models.LoanInstallment{PrincipalDue: 9000, InterestDue: 1000, DaysPastDue: 3}
+	assert.Empty(t, job.chargesFor(withinGrace, businessDate))
+
+	overdue := &models.LoanInstallment{PrincipalDue: 9000, InterestDue: 1000, AmountPaid: 0, DaysPastDue: 4}
+	charges := job.chargesFor(overdue, businessDate)
+	assert.Len(t, charges, 2)
+	assert.Equal(t, models.LoanChargeLateFee, charges[0].ChargeType)
+	assert.Equal(t, 100.0, charges[0].Amount)
+	assert.Equal(t, models.LoanChargePenaltyInterest, charges[1].ChargeType)
+	assert.Equal(t, 1.0, charges[1].Amount)
+	assert.Equal(t, businessDate, charges[1].BusinessDate)
+}
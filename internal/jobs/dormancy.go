@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"example.com/m/internal/lifecycle"
+)
+
+// DormancyJob is the daily batch that marks inactive accounts dormant
+type DormancyJob struct {
+	dormancy *lifecycle.DormancyService
+}
+
+// NewDormancyJob creates a new DormancyJob
+func NewDormancyJob(dormancyService *lifecycle.DormancyService) *DormancyJob {
+	return &DormancyJob{
+		dormancy: dormancyService,
+	}
+}
+
+// Name returns the job name used in logs
+func (j *DormancyJob) Name() string {
+	return "dormancy job"
+}
+
+// Run marks the accounts that reached the inactivity period by the business date dormant
+func (j *DormancyJob) Run(ctx context.Context, businessDate time.Time) error {
+	marked, err := j.dormancy.MarkDormant(ctx, BusinessDate(businessDate))
+	if marked > 0 {
+		log.Printf("%s marked %d account(s) dormant", j.Name(), marked)
+	}
+	return err
+}
@@ -0,0 +1,128 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+)
+
+// AccountFeeJob is the end-of-month batch that charges the monthly fees of
+// the account product to savings accounts
+type AccountFeeJob struct {
+	accountRepo repository.AccountRepository
+	productRepo repository.ProductRepository
+	ledgerRepo  repository.LedgerRepository
+	ledger      *ledger.Service
+}
+
+// NewAccountFeeJob creates a new AccountFeeJob
+func NewAccountFeeJob(
+	accountRepo repository.AccountRepository,
+	productRepo repository.ProductRepository,
+	ledgerRepo repository.LedgerRepository,
+	ledgerService *ledger.Service,
+) *AccountFeeJob {
+	return &AccountFeeJob{
+		accountRepo: accountRepo,
+		productRepo: productRepo,
+		ledgerRepo:  ledgerRepo,
+		ledger:      ledgerService,
+	}
+}
+
+// Name returns the job name used in logs
+func (j *AccountFeeJob) Name() string {
+	return "account fee job"
+}
+
+// Run charges the monthly maintenance fee, and the below-minimum-balance fee
+// when the month ended below the product's minimum balance, on the last day
+// of the month. Other days are skipped. Dormant accounts are not charged.
+// A fee is never charged twice for the same month and never overdraws the
+// account; what the available balance cannot cover is waived.
+func (j *AccountFeeJob) Run(ctx context.Context, businessDate time.Time) error {
+	businessDate = BusinessDate(businessDate)
+	if !IsCapitalizationDate(businessDate, "monthly") {
+		return nil
+	}
+
+	accounts, err := j.accountRepo.GetAccountsByType(ctx, models.AccountTypeSavings, models.AccountStatusActive)
+	if err != nil {
+		return fmt.Errorf("failed to load savings accounts: %w", err)
+	}
+
+	products := map[string]*models.AccountProduct{}
+	for _, account := range accounts {
+		key := fmt.Sprintf("%s@%d", account.ProductCode, account.ProductVersion)
+		product, ok := products[key]
+		if !ok {
+			product, err = j.productRepo.GetProductVersion(ctx, account.ProductCode, account.ProductVersion)
+			if err != nil {
+				return fmt.Errorf("failed to load product %s: %w", key, err)
+			}
+			products[key] = product
+		}
+		if product == nil {
+			continue
+		}
+
+		if err := j.charge(ctx, account, product, businessDate); err != nil {
+			return fmt.Errorf("failed to charge fees to %s: %w", account.AccountNumber, err)
+		}
+	}
+
+	return nil
+}
+
+// charge posts the fees of one month to one account
+func (j *AccountFeeJob) charge(ctx context.Context, account *models.Account, product *models.AccountProduct, businessDate time.Time) error {
+	fee := product.Fees.MonthlyMaintenanceFee
+	description := "Monthly maintenance fee"
+	if product.Fees.BelowMinimumBalanceFee > 0 {
+		balance, err := j.ledgerRepo.GetBalanceAsOf(ctx, account.ID, businessDate)
+		if err != nil {
+			return err
+		}
+		if balance < product.MinBalance {
+			fee += product.Fees.BelowMinimumBalanceFee
+			description = "Monthly maintenance and below minimum balance fee"
+		}
+	}
+
+	fee = account.Currency.Round(min(fee, account.Available()))
+	if fee <= 0 {
+		return nil
+	}
+	income, err := repository.GetInternalAccount(ctx, j.accountRepo, models.CurrencyAccount(models.GLFeeIncome, account.Currency))
+	if err != nil {
+		return err
+	}
+
+	err = j.ledger.Post(ctx, &models.LedgerTransaction{
+		Reference:    fmt.Sprintf("fee:%s:%s", account.ID, businessDate.Format("2006-01")),
+		Type:         models.LedgerAccountFee,
+		Description:  fmt.Sprintf("%s for %s", description, businessDate.Format("January 2006")),
+		BusinessDate: businessDate,
+		Entries: []models.LedgerEntry{
+			ledger.Debit(account.ID, fee),
+			ledger.Credit(income.ID, fee),
+		},
+	})
+	switch {
+	case err == nil, errors.Is(err, repository.ErrDuplicateReference):
+		return nil
+	case errors.Is(err, ledger.ErrAccountRestricted), errors.Is(err, ledger.ErrInsufficientFunds),
+		errors.Is(err, ledger.ErrAccountNotActive), errors.Is(err, ledger.ErrAccountDormant):
+		// Frozen accounts, and accounts that changed since they were loaded,
+		// are not charged this month
+		log.Printf("Fee not charged to %s: %v", account.AccountNumber, err)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,104 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/repository/repotest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubFeeAccounts lists the accounts in memory by type and status
+type stubFeeAccounts struct {
+	repotest.Accounts
+}
+
+func (r *stubFeeAccounts) GetAccountsByType(ctx context.Context, accountType models.AccountType, status models.AccountStatus) ([]*models.Account, error) {
+	accounts := []*models.Account{}
+	for _, account := range r.Accounts.Accounts {
+		if account.AccountType == accountType && account.Status == status {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts, nil
+}
+
+// stubProductRepository returns the product every account was opened under
+type stubProductRepository struct {
+	repository.ProductRepository
+	product *models.AccountProduct
+}
+
+func (r *stubProductRepository) GetProductVersion(ctx context.Context, code string, version int) (*models.AccountProduct, error) {
+	return r.product, nil
+}
+
+// stubLedgerRepository applies postings to the accounts in memory and
+// rejects duplicate references
+type stubLedgerRepository struct {
+	repository.LedgerRepository
+	accounts map[uuid.UUID]*models.Account
+	posted   []*models.LedgerTransaction
+}
+
+func (r *stubLedgerRepository) PostTransaction(ctx context.Context, txn *models.LedgerTransaction, validate repository.PostingValidator) error {
+	for _, posted := range r.posted {
+		if posted.Reference == txn.Reference {
+			return repository.ErrDuplicateReference
+		}
+	}
+	if err := validate(r.accounts, txn); err != nil {
+		return err
+	}
+	for _, entry := range txn.Entries {
+		r.accounts[entry.AccountID].Balance += entry.SignedAmount()
+	}
+	r.posted = append(r.posted, txn)
+	return nil
+}
+
+func (r *stubLedgerRepository) GetBalanceAsOf(ctx context.Context, accountID uuid.UUID, businessDate time.Time) (float64, error) {
+	return r.accounts[accountID].Balance, nil
+}
+
+func TestAccountFeeJob(t *testing.T) {
+	funded := repotest.Account("1000000001", 5000)
+	low := repotest.Account("1000000002", 500)
+	empty := repotest.Account("1000000003", 30)
+	dormant := repotest.Account("1000000004", 5000)
+	dormant.Status = models.AccountStatusDormant
+	for _, account := range []*models.Account{funded, low, empty, dormant} {
+		account.ProductCode, account.ProductVersion = "SAVINGS", 1
+	}
+	income := repotest.InternalAccount(models.GLFeeIncome)
+
+	all := []*models.Account{funded, low, empty, dormant, income}
+	ledgerRepo := &stubLedgerRepository{accounts: repotest.ByID(all...)}
+	product := &models.AccountProduct{Code: "SAVINGS", Version: 1, MinBalance: 1000, Fees: models.ProductFees{MonthlyMaintenanceFee: 20, BelowMinimumBalanceFee: 50}}
+	job := NewAccountFeeJob(&stubFeeAccounts{repotest.Accounts{Accounts: all}}, &stubProductRepository{product: product}, ledgerRepo, ledger.NewService(ledgerRepo))
+	ctx := context.Background()
+
+	require.NoError(t, job.Run(ctx, time.Date(2026, 4, 29, 0, 0, 0, 0, time.UTC)))
+	assert.Empty(t, ledgerRepo.posted, "fees are charged on the last day of the month only")
+
+	monthEnd := time.Date(2026, 4, 30, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, job.Run(ctx, monthEnd))
+	assert.Equal(t, 4980.0, funded.Balance)
+	assert.Equal(t, 430.0, low.Balance)
+	assert.Equal(t, 0.0, empty.Balance, "the fee never overdraws the account")
+	assert.Equal(t, 5000.0, dormant.Balance)
+	assert.Equal(t, 120.0, income.Balance)
+	require.Len(t, ledgerRepo.posted, 3)
+	assert.Equal(t, models.LedgerAccountFee, ledgerRepo.posted[0].Type)
+
+	// Re-running the month does not charge again
+	require.NoError(t, job.Run(ctx, monthEnd))
+	assert.Equal(t, 120.0, income.Balance)
+	assert.Len(t, ledgerRepo.posted, 3)
+}
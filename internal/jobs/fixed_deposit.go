@@ -0,0 +1,60 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"example.com/m/internal/deposits"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+)
+
+// FixedDepositMaturityJob processes fixed deposits that reach maturity
+// according to their maturity instruction
+type FixedDepositMaturityJob struct {
+	repo     repository.FixedDepositRepository
+	deposits *deposits.Service
+}
+
+// NewFixedDepositMaturityJob creates a new FixedDepositMaturityJob
+func NewFixedDepositMaturityJob(repo repository.FixedDepositRepository, depositService *deposits.Service) *FixedDepositMaturityJob {
+	return &FixedDepositMaturityJob{
+		repo:     repo,
+		deposits: depositService,
+	}
+}
+
+// Name returns the job name used in logs
+func (j *FixedDepositMaturityJob) Name() string {
+	return "fixed deposit maturity job"
+}
+
+// Run matures every active deposit due on or before the business date. A
+// deposit that missed several terms while the job was not running is rolled
+// forward until its maturity date is in the future.
+func (j *FixedDepositMaturityJob) Run(ctx context.Context, businessDate time.Time) error {
+	businessDate = BusinessDate(businessDate)
+
+	due, err := j.repo.GetDueFixedDeposits(ctx, businessDate)
+	if err != nil {
+		return fmt.Errorf("failed to load maturing fixed deposits: %w", err)
+	}
+
+	failed := 0
+	for _, deposit := range due {
+		for deposit.Status == models.FixedDepositActive && !deposit.MaturityDate.After(businessDate) {
+			if err := j.deposits.Mature(ctx, deposit); err != nil {
+				log.Printf("%s: account %s: %v", j.Name(), deposit.AccountID, err)
+				failed++
+				break
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d fixed deposit(s) could not be matured", failed)
+	}
+	return nil
+}
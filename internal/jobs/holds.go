@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"example.com/m/internal/holds"
+)
+
+// HoldExpiryJob releases holds whose expiry has passed
+type HoldExpiryJob struct {
+	holds *holds.Service
+}
+
+// NewHoldExpiryJob creates a new HoldExpiryJob
+func NewHoldExpiryJob(holdService *holds.Service) *HoldExpiryJob {
+	return &HoldExpiryJob{
+		holds: holdService,
+	}
+}
+
+// Name returns the job name used in logs
+func (j *HoldExpiryJob) Name() string {
+	return "hold expiry sweeper"
+}
+
+// RunOnce releases every hold that expired before now
+func (j *HoldExpiryJob) RunOnce(ctx context.Context, now time.Time) error {
+	released, err := j.holds.ExpireDue(ctx, now)
+	if released > 0 {
+		log.Printf("%s released %d expired hold(s)", j.Name(), released)
+	}
+	return err
+}
@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"example.com/m/internal/clearing"
+	"example.com/m/internal/proxies"
+)
+
+// InterbankPollJob sends pending interbank instructions and applies the switch's responses
+type InterbankPollJob struct {
+	clearing *clearing.Service
+}
+
+// NewInterbankPollJob creates a new InterbankPollJob
+func NewInterbankPollJob(clearingService *clearing.Service) *InterbankPollJob {
+	return &InterbankPollJob{
+		clearing: clearingService,
+	}
+}
+
+// Name returns the job name used in logs
+func (j *InterbankPollJob) Name() string {
+	return "interbank poller"
+}
+
+// RunOnce settles or returns the transfers the switch has answered
+func (j *InterbankPollJob) RunOnce(ctx context.Context, now time.Time) error {
+	resolved, err := j.clearing.Poll(ctx)
+	if resolved > 0 {
+		log.Printf("%s resolved %d interbank transfer(s)", j.Name(), resolved)
+	}
+	return err
+}
+
+// InterbankReconciliationJob is the daily batch that clears items left in the interbank clearing account
+type InterbankReconciliationJob struct {
+	clearing *clearing.Service
+}
+
+// NewInterbankReconciliationJob creates a new InterbankReconciliationJob
+func NewInterbankReconciliationJob(clearingService *clearing.Service) *InterbankReconciliationJob {
+	return &InterbankReconciliationJob{
+		clearing: clearingService,
+	}
+}
+
+// Name returns the job name used in logs
+func (j *InterbankReconciliationJob) Name() string {
+	return "interbank reconciliation"
+}
+
+// Run returns the transfers that have waited too long for the switch. The
+// wait is measured from the time the job runs, whatever the business date.
+func (j *InterbankReconciliationJob) Run(ctx context.Context, businessDate time.Time) error {
+	cleared, err := j.clearing.Reconcile(ctx)
+	if cleared > 0 {
+		log.Printf("%s cleared %d item(s) from suspense", j.Name(), cleared)
+	}
+	return err
+}
+
+// ProxyInquiryJob asks the switch again about proxy transfers it did not answer
+type ProxyInquiryJob struct {
+	proxies *proxies.Service
+}
+
+// NewProxyInquiryJob creates a new ProxyInquiryJob
+func NewProxyInquiryJob(proxyService *proxies.Service) *ProxyInquiryJob {
+	return &ProxyInquiryJob{
+		proxies: proxyService,
+	}
+}
+
+// Name returns the job name used in logs
+func (j *ProxyInquiryJob) Name() string {
+	return "proxy transfer inquiry"
+}
+
+// RunOnce completes or refunds the pending proxy transfers the switch now answers
+func (j *ProxyInquiryJob) RunOnce(ctx context.Context, now time.Time) error {
+	resolved, err := j.proxies.Inquire(ctx)
+	if resolved > 0 {
+		log.Printf("%s resolved %d proxy transfer(s)", j.Name(), resolved)
+	}
+	return err
+}
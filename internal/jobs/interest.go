@@ -0,0 +1,225 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/google/uuid"
+)
+
+// DaysInYear is the day count basis for interest (actual/365)
+const DaysInYear = 365
+
+// capitalizationMonths maps a capitalization schedule to the number of months between postings
+var capitalizationMonths = map[string]int{
+	"monthly":     1,
+	"quarterly":   3,
+	"semi_annual": 6,
+	"annual":      12,
+}
+
+// InterestAccrualJob is the end-of-day batch that accrues daily interest on
+// savings accounts and posts it to the ledger on capitalization dates
+type InterestAccrualJob struct {
+	accountRepo  repository.AccountRepository
+	productRepo  repository.ProductRepository
+	ledgerRepo   repository.LedgerRepository
+	interestRepo repository.InterestRepository
+	ledger       *ledger.Service
+	cfg          config.InterestConfig
+}
+
+// NewInterestAccrualJob creates a new InterestAccrualJob
+func NewInterestAccrualJob(
+	accountRepo repository.AccountRepository,
+	productRepo repository.ProductRepository,
+	ledgerRepo repository.LedgerRepository,
+	interestRepo repository.InterestRepository,
+	ledgerService *ledger.Service,
+	cfg config.InterestConfig,
+) *InterestAccrualJob {
+	return &InterestAccrualJob{
+		accountRepo:  accountRepo,
+		productRepo:  productRepo,
+		ledgerRepo:   ledgerRepo,
+		interestRepo: interestRepo,
+		ledger:       ledgerService,
+		cfg:          cfg,
+	}
+}
+
+// Name returns the job name used in logs
+func (j *InterestAccrualJob) Name() string {
+	return "interest accrual job"
+}
+
+// Run accrues interest for the business date and capitalizes it when the
+// date is on the capitalization schedule. Re-running a date recomputes
+// unposted accruals and never posts the same interest twice.
+func (j *InterestAccrualJob) Run(ctx context.Context, businessDate time.Time) error {
+	businessDate = BusinessDate(businessDate)
+
+	// Dormant accounts keep earning interest
+	accounts, err := j.accountRepo.GetAccountsByType(ctx, models.AccountTypeSavings, models.AccountStatusActive)
+	if err != nil {
+		return fmt.Errorf("failed to load savings accounts: %w", err)
+	}
+	dormant, err := j.accountRepo.GetAccountsByType(ctx, models.AccountTypeSavings, models.AccountStatusDormant)
+	if err != nil {
+		return fmt.Errorf("failed to load dormant savings accounts: %w", err)
+	}
+	accounts = append(accounts, dormant...)
+
+	capitalize := IsCapitalizationDate(businessDate, j.cfg.Capitalization)
+	products := map[string]*models.AccountProduct{}
+	for _, account := range accounts {
+		key := fmt.Sprintf("%s@%d", account.ProductCode, account.ProductVersion)
+		product, ok := products[key]
+		if !ok {
+			product, err = j.productRepo.GetProductVersion(ctx, account.ProductCode, account.ProductVersion)
+			if err != nil {
+				return fmt.Errorf("failed to load product %s: %w", key, err)
+			}
+			products[key] = product
+		}
+
+		if err := j.accrue(ctx, account, product, businessDate); err != nil {
+			return fmt.Errorf("failed to accrue interest for %s: %w", account.AccountNumber, err)
+		}
+		if capitalize {
+			if err := j.capitalize(ctx, account, businessDate, "interest"); err != nil {
+				return fmt.Errorf("failed to post interest for %s: %w", account.AccountNumber, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// RunRange replays the job for every business date from..to inclusive
+func (j *InterestAccrualJob) RunRange(ctx context.Context, from, to time.Time) error {
+	for date := BusinessDate(from); !date.After(BusinessDate(to)); date = date.AddDate(0, 0, 1) {
+		if err := j.Run(ctx, date); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// accrue stores one day of interest using the tiers of the product version
+// the account was opened under
+func (j *InterestAccrualJob) accrue(ctx context.Context, account *models.Account, product *models.AccountProduct, businessDate time.Time) error {
+	if product == nil || len(product.InterestTiers) == 0 {
+		return nil
+	}
+
+	balance, err := j.ledgerRepo.GetBalanceAsOf(ctx, account.ID, businessDate)
+	if err != nil {
+		return err
+	}
+
+	return j.interestRepo.SaveAccrual(ctx, &models.InterestAccrual{
+		ID:          uuid.New(),
+		AccountID:   account.ID,
+		AccrualDate: businessDate,
+		Balance:     balance,
+		Amount:      DailyInterest(balance, product.InterestTiers),
+		CreatedAt:   time.Now(),
+	})
+}
+
+// capitalize posts the unposted accruals up to the business date as one
+// ledger transaction: gross interest credited, withholding tax debited. The
+// reference is kind:account:date, so scheduled and off-schedule postings on
+// the same date never take each other for a duplicate.
+func (j *InterestAccrualJob) capitalize(ctx context.Context, account *models.Account, businessDate time.Time, kind string) error {
+	total, err := j.interestRepo.SumUnpostedAccruals(ctx, account.ID, businessDate)
+	if err != nil {
+		return err
+	}
+
+	reference := fmt.Sprintf("%s:%s:%s", kind, account.ID, businessDate.Format("2006-01-02"))
+	gross := account.Currency.Round(total)
+	if gross > 0 {
+		expense, err := repository.GetInternalAccount(ctx, j.accountRepo, models.CurrencyAccount(models.GLInterestExpense, account.Currency))
+		if err != nil {
+			return err
+		}
+
+		txn := &models.LedgerTransaction{
+			Reference:    reference,
+			Type:         models.LedgerInterestCapitalization,
+			Description:  fmt.Sprintf("Interest to %s", businessDate.Format("2006-01-02")),
+			BusinessDate: businessDate,
+			Entries: []models.LedgerEntry{
+				ledger.Debit(expense.ID, gross),
+				ledger.Credit(account.ID, gross),
+			},
+		}
+
+		tax := account.Currency.Round(gross * j.cfg.WithholdingTaxRate)
+		if tax > 0 {
+			payable, err := repository.GetInternalAccount(ctx, j.accountRepo, models.CurrencyAccount(models.GLWithholdingTaxPayable, account.Currency))
+			if err != nil {
+				return err
+			}
+			txn.Entries = append(txn.Entries, ledger.Debit(account.ID, tax), ledger.Credit(payable.ID, tax))
+		}
+
+		err = j.ledger.Post(ctx, txn)
+		if err != nil && !errors.Is(err, repository.ErrDuplicateReference) {
+			return err
+		}
+	}
+
+	return j.interestRepo.MarkAccrualsPosted(ctx, account.ID, businessDate, reference)
+}
+
+// PostAccruedInterest posts the interest accrued on an account so far, net
+// of withholding tax, outside the capitalization schedule. It is used when
+// an account is closed.
+func (j *InterestAccrualJob) PostAccruedInterest(ctx context.Context, account *models.Account, businessDate time.Time) error {
+	return j.capitalize(ctx, account, BusinessDate(businessDate), "interest-close")
+}
+
+// DailyInterest returns one day of interest on balance using actual/365.
+// Each tier's rate applies only to the part of the balance inside that tier.
+func DailyInterest(balance float64, tiers []models.InterestTier) float64 {
+	if balance <= 0 || len(tiers) == 0 {
+		return 0
+	}
+
+	sorted := append([]models.InterestTier(nil), tiers...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].MinBalance < sorted[j].MinBalance })
+
+	annual := 0.0
+	for i, tier := range sorted {
+		if balance <= tier.MinBalance {
+			break
+		}
+		upper := balance
+		if i+1 < len(sorted) && sorted[i+1].MinBalance < balance {
+			upper = sorted[i+1].MinBalance
+		}
+		annual += (upper - tier.MinBalance) * tier.AnnualRate
+	}
+	return annual / DaysInYear
+}
+
+// IsCapitalizationDate reports whether accrued interest is posted on date.
+// Postings happen on the last day of the month at the end of each period.
+func IsCapitalizationDate(date time.Time, schedule string) bool {
+	months, ok := capitalizationMonths[schedule]
+	if !ok {
+		months = 1
+	}
+	lastDayOfMonth := date.AddDate(0, 0, 1).Day() == 1
+	return lastDayOfMonth && int(date.Month())%months == 0
+}
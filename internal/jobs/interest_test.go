@@ -0,0 +1,103 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/repository/repotest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubInterestRepository keeps accruals in memory; saving a date again
+// replaces its accrual unless it was posted
+type stubInterestRepository struct {
+	repository.InterestRepository
+	accruals []*models.InterestAccrual
+}
+
+func (r *stubInterestRepository) SaveAccrual(ctx context.Context, accrual *models.InterestAccrual) error {
+	for i, existing := range r.accruals {
+		if existing.AccountID == accrual.AccountID && existing.AccrualDate.Equal(accrual.AccrualDate) {
+			if existing.PostedReference == nil {
+				r.accruals[i] = accrual
+			}
+			return nil
+		}
+	}
+	r.accruals = append(r.accruals, accrual)
+	return nil
+}
+
+func (r *stubInterestRepository) SumUnpostedAccruals(ctx context.Context, accountID uuid.UUID, upTo time.Time) (float64, error) {
+	total := 0.0
+	for _, accrual := range r.accruals {
+		if accrual.AccountID == accountID && accrual.PostedReference == nil && !accrual.AccrualDate.After(upTo) {
+			total += accrual.Amount
+		}
+	}
+	return total, nil
+}
+
+func (r *stubInterestRepository) MarkAccrualsPosted(ctx context.Context, accountID uuid.UUID, upTo time.Time, reference string) error {
+	for _, accrual := range r.accruals {
+		if accrual.AccountID == accountID && accrual.PostedReference == nil && !accrual.AccrualDate.After(upTo) {
+			accrual.PostedReference = &reference
+		}
+	}
+	return nil
+}
+
+func TestDailyInterestTiers(t *testing.T) {
+	tiers := []models.InterestTier{
+		{MinBalance: 1000000, AnnualRate: 0.01},
+		{MinBalance: 0, AnnualRate: 0.005},
+	}
+
+	assert.Equal(t, 0.0, DailyInterest(0, tiers))
+	assert.InDelta(t, 365000*0.005/365, DailyInterest(365000, tiers), 1e-9)
+	// 1,000,000 at 0.5% plus 460,000 at 1%
+	assert.InDelta(t, (5000+4600)/365.0, DailyInterest(1460000, tiers), 1e-9)
+}
+
+func TestIsCapitalizationDate(t *testing.T) {
+	assert.True(t, IsCapitalizationDate(time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC), "monthly"))
+	assert.False(t, IsCapitalizationDate(time.Date(2026, 2, 27, 0, 0, 0, 0, time.UTC), "monthly"))
+	assert.True(t, IsCapitalizationDate(time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC), "monthly"))
+	assert.False(t, IsCapitalizationDate(time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC), "semi_annual"))
+	assert.True(t, IsCapitalizationDate(time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC), "semi_annual"))
+	assert.True(t, IsCapitalizationDate(time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), "quarterly"))
+}
+
+func TestCapitalizationAfterClosureInterestOnSameDate(t *testing.T) {
+	account := &models.Account{ID: uuid.New(), AccountNumber: "1000000001", AccountType: models.AccountTypeSavings, Status: models.AccountStatusActive, Balance: 3650000, ProductCode: "SAVINGS", ProductVersion: 1}
+	expense := &models.Account{ID: uuid.New(), AccountNumber: models.GLInterestExpense, AccountType: models.AccountTypeInternal, Status: models.AccountStatusActive}
+	all := []*models.Account{account, expense}
+	ledgerRepo := &stubLedgerRepository{accounts: map[uuid.UUID]*models.Account{account.ID: account, expense.ID: expense}}
+	interestRepo := &stubInterestRepository{}
+	product := &models.AccountProduct{Code: "SAVINGS", Version: 1, InterestTiers: []models.InterestTier{{MinBalance: 0, AnnualRate: 0.01}}}
+	job := NewInterestAccrualJob(&stubFeeAccounts{repotest.Accounts{Accounts: all}}, &stubProductRepository{product: product}, ledgerRepo, interestRepo, ledger.NewService(ledgerRepo), config.InterestConfig{Capitalization: "monthly"})
+	ctx := context.Background()
+
+	require.NoError(t, job.Run(ctx, time.Date(2026, 4, 29, 0, 0, 0, 0, time.UTC)))
+	// A closure that failed after posting the interest accrued so far
+	monthEnd := time.Date(2026, 4, 30, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, job.PostAccruedInterest(ctx, account, monthEnd))
+	assert.Equal(t, 3650100.0, account.Balance)
+
+	// The end-of-month capitalization still pays the interest of the last day
+	require.NoError(t, job.Run(ctx, monthEnd))
+	require.Len(t, ledgerRepo.posted, 2)
+	assert.Equal(t, "interest-close:"+account.ID.String()+":2026-04-30", ledgerRepo.posted[0].Reference)
+	assert.Equal(t, "interest:"+account.ID.String()+":2026-04-30", ledgerRepo.posted[1].Reference)
+	assert.Equal(t, 3650200.0, account.Balance)
+	total, err := interestRepo.SumUnpostedAccruals(ctx, account.ID, monthEnd)
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, total)
+}
@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"example.com/m/internal/restrictions"
+)
+
+// RestrictionExpiryJob marks restrictions whose expiry has passed as expired
+type RestrictionExpiryJob struct {
+	restrictions *restrictions.Service
+}
+
+// NewRestrictionExpiryJob creates a new RestrictionExpiryJob
+func NewRestrictionExpiryJob(restrictionService *restrictions.Service) *RestrictionExpiryJob {
+	return &RestrictionExpiryJob{
+		restrictions: restrictionService,
+	}
+}
+
+// Name returns the job name used in logs
+func (j *RestrictionExpiryJob) Name() string {
+	return "restriction expiry sweeper"
+}
+
+// RunOnce expires every restriction that reached its expiry before now
+func (j *RestrictionExpiryJob) RunOnce(ctx context.Context, now time.Time) error {
+	expired, err := j.restrictions.ExpireDue(ctx, now)
+	if expired > 0 {
+		log.Printf("%s expired %d restriction(s)", j.Name(), expired)
+	}
+	return err
+}
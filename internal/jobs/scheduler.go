@@ -0,0 +1,95 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// DailyJob is a batch job that processes one business date per run.
+// Implementations must be safe to run more than once for the same date.
+type DailyJob interface {
+	Name() string
+	Run(ctx context.Context, businessDate time.Time) error
+}
+
+// StartDaily runs job every day at the given local time of day ("15:04")
+// until ctx is cancelled
+func StartDaily(ctx context.Context, job DailyJob, at string) error {
+	runAt, err := time.Parse("15:04", at)
+	if err != nil {
+		return fmt.Errorf("invalid run time %q for job %s: %w", at, job.Name(), err)
+	}
+
+	go func() {
+		for {
+			next := nextRun(time.Now(), runAt.Hour(), runAt.Minute())
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			businessDate := BusinessDate(time.Now())
+			log.Printf("Running %s for %s", job.Name(), businessDate.Format("2006-01-02"))
+			if err := job.Run(ctx, businessDate); err != nil {
+				log.Printf("%s failed for %s: %v", job.Name(), businessDate.Format("2006-01-02"), err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// IntervalJob is a job that runs repeatedly during the day, for example a sweeper
+type IntervalJob interface {
+	Name() string
+	RunOnce(ctx context.Context, now time.Time) error
+}
+
+// StartEvery runs job every interval until ctx is cancelled
+func StartEvery(ctx context.Context, job IntervalJob, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid interval %s for job %s", interval, job.Name())
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if err := job.RunOnce(ctx, now); err != nil {
+					log.Printf("%s failed: %v", job.Name(), err)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// BusinessDate returns the calendar date of t as midnight UTC, which is
+// how DATE columns are read back from the database
+func BusinessDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// DaysBetween returns the number of calendar days from a to b
+func DaysBetween(a, b time.Time) int {
+	return int(BusinessDate(b).Sub(BusinessDate(a)).Hours() / 24)
+}
+
+// nextRun returns the first time after now at hour:minute local time
+func nextRun(now time.Time, hour, minute int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"example.com/m/internal/standingorders"
+)
+
+// StandingOrderJob runs the standing orders that have fallen due
+type StandingOrderJob struct {
+	orders *standingorders.Service
+}
+
+// NewStandingOrderJob creates a new StandingOrderJob
+func NewStandingOrderJob(orderService *standingorders.Service) *StandingOrderJob {
+	return &StandingOrderJob{
+		orders: orderService,
+	}
+}
+
+// Name returns the job name used in logs
+func (j *StandingOrderJob) Name() string {
+	return "standing order scheduler"
+}
+
+// RunOnce runs every standing order due at now
+func (j *StandingOrderJob) RunOnce(ctx context.Context, now time.Time) error {
+	recorded, err := j.orders.RunDue(ctx, now)
+	if recorded > 0 {
+		log.Printf("%s ran %d standing order(s)", j.Name(), recorded)
+	}
+	return err
+}
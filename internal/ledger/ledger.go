@@ -0,0 +1,281 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrUnbalanced is returned when debits and credits of a transaction differ
+	ErrUnbalanced = errors.New("ledger transaction is not balanced")
+	// ErrInvalidAmount is returned when an entry amount is not positive
+	ErrInvalidAmount = errors.New("ledger entry amount must be greater than zero")
+	// ErrAccountNotActive is returned when a customer account cannot accept postings
+	ErrAccountNotActive = errors.New("account is not active")
+	// ErrInsufficientFunds is returned when a debit would overdraw a customer account
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrAccountDormant is returned when money would leave a dormant account
+	ErrAccountDormant = errors.New("account is dormant and must be reactivated before it can be used for payments")
+	// ErrAccountRestricted matches every RestrictionError
+	ErrAccountRestricted = errors.New("account is restricted")
+	// ErrCurrencyMismatch is returned when an entry is in a different currency than its account
+	ErrCurrencyMismatch = errors.New("entry currency does not match the account currency")
+	// ErrBelowMinimumBalance is returned when a withdrawal would leave less than the product's minimum balance
+	ErrBelowMinimumBalance = errors.New("withdrawal would leave less than the minimum balance of the account product")
+	// ErrWithdrawalLimit is returned when a withdrawal exceeds the withdrawal limits of the account product
+	ErrWithdrawalLimit = errors.New("withdrawal exceeds the withdrawal limits of the account product")
+)
+
+// RestrictionError is returned when a freeze or garnishment on an account
+// refuses a posting. Code is one of the models.ErrorCode* values.
+type RestrictionError struct {
+	Code            string
+	AccountNumber   string
+	ReferenceNumber string
+}
+
+func (e *RestrictionError) Error() string {
+	return fmt.Sprintf("account %s is restricted (%s, order %s)", e.AccountNumber, e.Code, e.ReferenceNumber)
+}
+
+// Is makes errors.Is(err, ErrAccountRestricted) match any RestrictionError
+func (e *RestrictionError) Is(target error) bool {
+	return target == ErrAccountRestricted
+}
+
+// Service posts balanced transactions to the ledger. Every balance change
+// goes through Post, so account rules are enforced in one place.
+type Service struct {
+	repo repository.LedgerRepository
+}
+
+// NewService creates a new ledger Service
+func NewService(repo repository.LedgerRepository) *Service {
+	return &Service{repo: repo}
+}
+
+// Post validates and posts a ledger transaction. IDs and timestamps that are
+// not set are filled in. Posting a reference that was already posted returns
+// repository.ErrDuplicateReference.
+func (s *Service) Post(ctx context.Context, txn *models.LedgerTransaction) error {
+	if len(txn.Entries) < 2 {
+		return ErrUnbalanced
+	}
+
+	for _, entry := range txn.Entries {
+		if entry.Amount <= 0 {
+			return ErrInvalidAmount
+		}
+	}
+	// Entries without a currency take the currency of their account, which
+	// is only known once the accounts are locked, so a transaction mixing
+	// both is checked by checkAccounts only
+	labelled := 0
+	for _, entry := range txn.Entries {
+		if entry.Currency != "" {
+			labelled++
+		}
+	}
+	if labelled == 0 || labelled == len(txn.Entries) {
+		if err := checkBalanced(txn.Entries); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	if txn.ID == uuid.Nil {
+		txn.ID = uuid.New()
+	}
+	if txn.CreatedAt.IsZero() {
+		txn.CreatedAt = now
+	}
+	if txn.BusinessDate.IsZero() {
+		txn.BusinessDate = now
+	}
+	if txn.Status == "" {
+		txn.Status = models.LedgerSettled
+	}
+	for i := range txn.Entries {
+		if txn.Entries[i].ID == uuid.Nil {
+			txn.Entries[i].ID = uuid.New()
+		}
+		txn.Entries[i].CreatedAt = txn.CreatedAt
+	}
+
+	return s.repo.PostTransaction(ctx, txn, checkAccounts)
+}
+
+// checkBalanced returns ErrUnbalanced unless the debits and credits of every
+// currency are equal
+func checkBalanced(entries []models.LedgerEntry) error {
+	net := map[models.Currency]float64{}
+	for _, entry := range entries {
+		net[entry.Currency] += entry.SignedAmount()
+	}
+	for currency, amount := range net {
+		if math.Abs(amount) > 0.000001 {
+			return fmt.Errorf("%w: net %s %.2f", ErrUnbalanced, currency, amount)
+		}
+	}
+	return nil
+}
+
+// checkAccounts enforces the rules for customer accounts touched by a posting.
+// Every entry must be in the currency of its account and the transaction
+// must balance per currency. Debits are checked against the available balance, so funds reserved by
+// holds cannot be spent, and every posting is checked against the
+// restrictions in force. Dormant accounts accept credits only. Internal
+// ledger accounts are allowed to go negative.
+func checkAccounts(accounts map[uuid.UUID]*models.Account, txn *models.LedgerTransaction) error {
+	for i := range txn.Entries {
+		entry := &txn.Entries[i]
+		account := accounts[entry.AccountID]
+		currency := account.Currency
+		if currency == "" {
+			currency = models.BaseCurrency
+		}
+		if entry.Currency == "" {
+			entry.Currency = currency
+		}
+		if entry.Currency != currency {
+			return fmt.Errorf("%w: %s is held in %s", ErrCurrencyMismatch, account.AccountNumber, currency)
+		}
+	}
+	if err := checkBalanced(txn.Entries); err != nil {
+		return err
+	}
+
+	net := map[uuid.UUID]float64{}
+	for _, entry := range txn.Entries {
+		net[entry.AccountID] += entry.SignedAmount()
+	}
+
+	now := time.Now()
+	for id, change := range net {
+		account := accounts[id]
+		if account.IsInternal() {
+			continue
+		}
+		if account.Status != models.AccountStatusActive && account.Status != models.AccountStatusDormant {
+			return fmt.Errorf("%w: %s", ErrAccountNotActive, account.AccountNumber)
+		}
+		if change < 0 {
+			// Closing a dormant account still moves its balance out
+			if account.Status == models.AccountStatusDormant && txn.Type != models.LedgerClosureTransfer {
+				return fmt.Errorf("%w: %s", ErrAccountDormant, account.AccountNumber)
+			}
+			if err := CheckDebit(account, -change, now); err != nil {
+				return err
+			}
+			if account.Available()+change < -0.000001 {
+				return fmt.Errorf("%w: %s", ErrInsufficientFunds, account.AccountNumber)
+			}
+			if txn.Type.Withdraws() {
+				if err := checkWithdrawal(account, -change); err != nil {
+					return err
+				}
+			}
+		}
+		if change > 0 && !isInterest(txn.Type) {
+			if err := CheckCredit(account, now); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkWithdrawal applies the withdrawal rules of the account product to a
+// withdrawal of amount
+func checkWithdrawal(account *models.Account, amount float64) error {
+	rules := account.WithdrawalRules
+	if rules == nil {
+		return nil
+	}
+	if account.Available()-amount < rules.MinBalance-0.000001 {
+		return fmt.Errorf("%w: %s must keep %.2f", ErrBelowMinimumBalance, account.AccountNumber, rules.MinBalance)
+	}
+	if monthly := rules.Limits.MaxWithdrawalsPerMonth; monthly > 0 && rules.WithdrawalsThisMonth >= monthly {
+		return fmt.Errorf("%w: at most %d withdrawals a month", ErrWithdrawalLimit, monthly)
+	}
+	if daily := rules.Limits.DailyWithdrawalLimit; daily > 0 && rules.WithdrawnToday+amount > daily+0.000001 {
+		return fmt.Errorf("%w: at most %.2f a day, %.2f already withdrawn", ErrWithdrawalLimit, daily, rules.WithdrawnToday)
+	}
+	return nil
+}
+
+// CheckDebit returns a RestrictionError when the restrictions in force on the
+// account forbid taking amount out of it. A garnishment refuses a debit that
+// would eat into the ring-fenced amount while the available balance could
+// otherwise cover it.
+func CheckDebit(account *models.Account, amount float64, now time.Time) error {
+	for _, restriction := range account.Restrictions {
+		if restriction.InForce(now) && restriction.Type.BlocksDebits() {
+			return restrictionError(account, restriction)
+		}
+	}
+
+	garnished := account.GarnishedAmount(now)
+	if garnished > 0 && account.Available()-amount >= -0.000001 && account.Available()-garnished-amount < -0.000001 {
+		for _, restriction := range account.Restrictions {
+			if restriction.Type == models.RestrictionGarnishment && restriction.InForce(now) {
+				return restrictionError(account, restriction)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckCredit returns a RestrictionError when the restrictions in force on
+// the account forbid paying money into it
+func CheckCredit(account *models.Account, now time.Time) error {
+	for _, restriction := range account.Restrictions {
+		if restriction.InForce(now) && restriction.Type.BlocksCredits() {
+			return restrictionError(account, restriction)
+		}
+	}
+	return nil
+}
+
+func restrictionError(account *models.Account, restriction *models.Restriction) error {
+	return &RestrictionError{
+		Code:            restriction.Type.ErrorCode(),
+		AccountNumber:   account.AccountNumber,
+		ReferenceNumber: restriction.ReferenceNumber,
+	}
+}
+
+// isInterest reports whether the transaction posts interest the bank owes,
+// which is credited even to accounts frozen for credits
+func isInterest(txnType models.LedgerTransactionType) bool {
+	return txnType == models.LedgerInterestCapitalization || txnType == models.LedgerFixedDepositInterest
+}
+
+// Debit returns a debit entry for the account in the account's currency
+func Debit(accountID uuid.UUID, amount float64) models.LedgerEntry {
+	return models.LedgerEntry{AccountID: accountID, Direction: models.EntryDebit, Amount: amount}
+}
+
+// Credit returns a credit entry for the account in the account's currency
+func Credit(accountID uuid.UUID, amount float64) models.LedgerEntry {
+	return models.LedgerEntry{AccountID: accountID, Direction: models.EntryCredit, Amount: amount}
+}
+
+// DebitIn returns a debit entry in an explicit currency, for transactions
+// that span several currencies
+func DebitIn(accountID uuid.UUID, money models.Money) models.LedgerEntry {
+	return models.LedgerEntry{AccountID: accountID, Direction: models.EntryDebit, Amount: money.Amount, Currency: money.Currency}
+}
+
+// CreditIn returns a credit entry in an explicit currency, for transactions
+// that span several currencies
+func CreditIn(accountID uuid.UUID, money models.Money) models.LedgerEntry {
+	return models.LedgerEntry{AccountID: accountID, Direction: models.EntryCredit, Amount: money.Amount, Currency: money.Currency}
+}
@@ -0,0 +1,101 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckAccountsEnforcesRestrictions(t *testing.T) {
+	now := time.Now()
+	account := &models.Account{ID: uuid.New(), AccountNumber: "1234567890", AccountType: models.AccountTypeSavings, Status: models.AccountStatusActive, Balance: 10000}
+	other := &models.Account{ID: uuid.New(), AccountNumber: "0987654321", AccountType: models.AccountTypeSavings, Status: models.AccountStatusActive, Balance: 100}
+	accounts := map[uuid.UUID]*models.Account{account.ID: account, other.ID: other}
+	transfer := func(from, to *models.Account, amount float64, txnType models.LedgerTransactionType) *models.LedgerTransaction {
+		return &models.LedgerTransaction{Type: txnType, Entries: []models.LedgerEntry{Debit(from.ID, amount), Credit(to.ID, amount)}}
+	}
+	restrict := func(a *models.Account, restrictionType models.RestrictionType, amount float64) {
+		a.Restrictions = []*models.Restriction{{
+			Type: restrictionType, Amount: amount, ReferenceNumber: "ORD-1", Status: models.RestrictionActive, ExpiresAt: now.Add(time.Hour),
+		}}
+	}
+	code := func(err error) string {
+		if restricted, ok := err.(*RestrictionError); ok {
+			return restricted.Code
+		}
+		return ""
+	}
+
+	restrict(account, models.RestrictionDebitFreeze, 0)
+	err := checkAccounts(accounts, transfer(account, other, 100, models.LedgerTransfer))
+	assert.ErrorIs(t, err, ErrAccountRestricted)
+	assert.Equal(t, models.ErrorCodeDebitFrozen, code(err))
+	assert.NoError(t, checkAccounts(accounts, transfer(other, account, 0.01, models.LedgerTransfer)), "credits are accepted")
+
+	restrict(account, models.RestrictionCreditFreeze, 0)
+	err = checkAccounts(accounts, transfer(other, account, 100, models.LedgerTransfer))
+	assert.Equal(t, models.ErrorCodeCreditFrozen, code(err))
+	assert.NoError(t, checkAccounts(accounts, transfer(account, other, 100, models.LedgerTransfer)))
+	interest := &models.LedgerTransaction{Type: models.LedgerInterestCapitalization, Entries: []models.LedgerEntry{Debit(other.ID, 10), Credit(account.ID, 10)}}
+	assert.NoError(t, checkAccounts(accounts, interest), "interest is still credited")
+
+	restrict(account, models.RestrictionFullFreeze, 0)
+	assert.Equal(t, models.ErrorCodeFrozen, code(checkAccounts(accounts, transfer(account, other, 1, models.LedgerTransfer))))
+	assert.Equal(t, models.ErrorCodeFrozen, code(checkAccounts(accounts, transfer(other, account, 1, models.LedgerTransfer))))
+
+	// 10,000 balance with 9,000 garnished leaves 1,000 to spend
+	restrict(account, models.RestrictionGarnishment, 9000)
+	assert.NoError(t, checkAccounts(accounts, transfer(account, other, 1000, models.LedgerTransfer)))
+	assert.Equal(t, models.ErrorCodeGarnished, code(checkAccounts(accounts, transfer(account, other, 1000.01, models.LedgerTransfer))))
+	assert.ErrorIs(t, checkAccounts(accounts, transfer(account, other, 10000.01, models.LedgerTransfer)), ErrInsufficientFunds)
+
+	// Expired and lifted restrictions no longer apply
+	account.Restrictions[0].ExpiresAt = now.Add(-time.Minute)
+	assert.NoError(t, checkAccounts(accounts, transfer(account, other, 5000, models.LedgerTransfer)))
+	account.Restrictions[0].ExpiresAt = now.Add(time.Hour)
+	account.Restrictions[0].Status = models.RestrictionLifted
+	assert.NoError(t, checkAccounts(accounts, transfer(account, other, 5000, models.LedgerTransfer)))
+}
+
+func TestCheckAccountsDormantAcceptsCreditsOnly(t *testing.T) {
+	dormant := &models.Account{ID: uuid.New(), AccountNumber: "1234567890", AccountType: models.AccountTypeSavings, Status: models.AccountStatusDormant, Balance: 500}
+	other := &models.Account{ID: uuid.New(), AccountNumber: "0987654321", AccountType: models.AccountTypeSavings, Status: models.AccountStatusActive, Balance: 500}
+	accounts := map[uuid.UUID]*models.Account{dormant.ID: dormant, other.ID: other}
+	transfer := func(from, to *models.Account, txnType models.LedgerTransactionType) *models.LedgerTransaction {
+		return &models.LedgerTransaction{Type: txnType, Entries: []models.LedgerEntry{Debit(from.ID, 100), Credit(to.ID, 100)}}
+	}
+
+	assert.NoError(t, checkAccounts(accounts, transfer(other, dormant, models.LedgerTransfer)))
+	assert.ErrorIs(t, checkAccounts(accounts, transfer(dormant, other, models.LedgerTransfer)), ErrAccountDormant)
+	assert.ErrorIs(t, checkAccounts(accounts, transfer(dormant, other, models.LedgerHoldCapture)), ErrAccountDormant)
+	assert.NoError(t, checkAccounts(accounts, transfer(dormant, other, models.LedgerClosureTransfer)))
+}
+
+func TestCheckAccountsAppliesWithdrawalRules(t *testing.T) {
+	account := &models.Account{ID: uuid.New(), AccountNumber: "1234567890", AccountType: models.AccountTypeSavings, Status: models.AccountStatusActive, Balance: 10000}
+	other := &models.Account{ID: uuid.New(), AccountNumber: "0987654321", AccountType: models.AccountTypeSavings, Status: models.AccountStatusActive, Balance: 100}
+	accounts := map[uuid.UUID]*models.Account{account.ID: account, other.ID: other}
+	transfer := func(amount float64, txnType models.LedgerTransactionType) *models.LedgerTransaction {
+		return &models.LedgerTransaction{Type: txnType, Entries: []models.LedgerEntry{Debit(account.ID, amount), Credit(other.ID, amount)}}
+	}
+
+	account.WithdrawalRules = &models.WithdrawalRules{MinBalance: 500}
+	assert.NoError(t, checkAccounts(accounts, transfer(9500, models.LedgerTransfer)))
+	assert.ErrorIs(t, checkAccounts(accounts, transfer(9500.01, models.LedgerWithdrawal)), ErrBelowMinimumBalance)
+	assert.NoError(t, checkAccounts(accounts, transfer(10000, models.LedgerClosureTransfer)), "closing the account empties it")
+
+	account.WithdrawalRules = &models.WithdrawalRules{
+		Limits:               models.WithdrawalLimits{MaxWithdrawalsPerMonth: 4, DailyWithdrawalLimit: 2000},
+		WithdrawalsThisMonth: 3,
+		WithdrawnToday:       1500,
+	}
+	assert.NoError(t, checkAccounts(accounts, transfer(500, models.LedgerTransfer)))
+	assert.ErrorIs(t, checkAccounts(accounts, transfer(500.01, models.LedgerTransfer)), ErrWithdrawalLimit)
+
+	account.WithdrawalRules.WithdrawalsThisMonth = 4
+	assert.ErrorIs(t, checkAccounts(accounts, transfer(1, models.LedgerWithdrawal)), ErrWithdrawalLimit)
+	assert.NoError(t, checkAccounts(accounts, transfer(1, models.LedgerHoldCapture)), "only withdrawals and transfers count")
+}
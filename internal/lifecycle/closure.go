@@ -0,0 +1,259 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrAccountNotFound is returned when the account to close does not exist
+	ErrAccountNotFound = errors.New("account not found")
+	// ErrAlreadyClosed is returned when the account is already closed
+	ErrAlreadyClosed = errors.New("account is already closed")
+	// ErrNotCustomerAccount is returned for internal ledger accounts
+	ErrNotCustomerAccount = errors.New("only customer accounts can be closed")
+	// ErrBalanceNotSettled is returned when money remains and no transfer account was given
+	ErrBalanceNotSettled = errors.New("account balance must be zero or transferred to another account")
+	// ErrRepaymentSource is returned when an active loan collects installments from the account
+	ErrRepaymentSource = errors.New("account is the repayment source of an active loan")
+	// ErrActiveHolds is returned when holds still reserve part of the balance
+	ErrActiveHolds = errors.New("account has active holds")
+	// ErrRestricted is returned when a freeze or garnishment is in force on the account
+	ErrRestricted = errors.New("account has restrictions in force")
+	// ErrOpenCards is returned when a card on the account is requested, issued, active or blocked
+	ErrOpenCards = errors.New("account still has cards linked to it")
+	// ErrActiveFixedDeposit is returned when a fixed deposit is still running its term
+	ErrActiveFixedDeposit = errors.New("fixed deposit is still active and must be withdrawn first")
+	// ErrInvalidTransferTarget is returned when the remaining balance cannot be sent to the given account
+	ErrInvalidTransferTarget = errors.New("transfer account must be a different active customer account")
+)
+
+// InterestPoster posts the interest accrued on an account outside the capitalization schedule
+type InterestPoster interface {
+	PostAccruedInterest(ctx context.Context, account *models.Account, businessDate time.Time) error
+}
+
+// ClosureService closes customer accounts after checking that nothing still depends on them
+type ClosureService struct {
+	accountRepo     repository.AccountRepository
+	productRepo     repository.ProductRepository
+	loanAccountRepo repository.LoanAccountRepository
+	depositRepo     repository.FixedDepositRepository
+	restrictionRepo repository.RestrictionRepository
+	cardRepo        repository.CardRepository
+	interest        InterestPoster
+	ledger          *ledger.Service
+}
+
+// NewClosureService creates a new ClosureService
+func NewClosureService(
+	accountRepo repository.AccountRepository,
+	productRepo repository.ProductRepository,
+	loanAccountRepo repository.LoanAccountRepository,
+	depositRepo repository.FixedDepositRepository,
+	restrictionRepo repository.RestrictionRepository,
+	cardRepo repository.CardRepository,
+	interest InterestPoster,
+	ledgerService *ledger.Service,
+) *ClosureService {
+	return &ClosureService{
+		accountRepo:     accountRepo,
+		productRepo:     productRepo,
+		loanAccountRepo: loanAccountRepo,
+		depositRepo:     depositRepo,
+		restrictionRepo: restrictionRepo,
+		cardRepo:        cardRepo,
+		interest:        interest,
+		ledger:          ledgerService,
+	}
+}
+
+// Close closes an account. Accrued savings interest is posted first and the
+// product's early closure fee is charged, then any remaining balance is moved
+// to request.TransferToAccountID. The account keeps
+// its history; once closed the ledger rejects every posting to it.
+func (s *ClosureService) Close(ctx context.Context, accountID uuid.UUID, request models.CloseAccountRequest, staffID uuid.UUID) (*models.AccountClosure, error) {
+	account, err := s.accountRepo.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+	if err := s.checkPreconditions(ctx, account); err != nil {
+		return nil, err
+	}
+
+	var target *models.Account
+	if request.TransferToAccountID != nil {
+		target, err = s.accountRepo.GetAccountByID(ctx, *request.TransferToAccountID)
+		if err != nil {
+			return nil, err
+		}
+		if target == nil || target.IsInternal() || target.ID == account.ID || target.Status != models.AccountStatusActive {
+			return nil, ErrInvalidTransferTarget
+		}
+	}
+
+	now := time.Now()
+	if account.AccountType == models.AccountTypeSavings {
+		if err := s.interest.PostAccruedInterest(ctx, account, now); err != nil {
+			return nil, fmt.Errorf("failed to post accrued interest: %w", err)
+		}
+		if account, err = s.accountRepo.GetAccountByID(ctx, account.ID); err != nil {
+			return nil, err
+		}
+	}
+	charged, err := s.chargeEarlyClosureFee(ctx, account, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to charge early closure fee: %w", err)
+	}
+	if charged {
+		if account, err = s.accountRepo.GetAccountByID(ctx, account.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	closure := &models.AccountClosure{
+		AccountID: account.ID,
+		Status:    models.AccountStatusClosed,
+		ClosedBy:  staffID,
+	}
+	if account.Balance > 0 {
+		if target == nil {
+			return nil, ErrBalanceNotSettled
+		}
+		// Every attempt sweeps the balance left at that moment, so a retry
+		// after a posting landed between the sweep and the close is not
+		// refused as a duplicate
+		err := s.ledger.Post(ctx, &models.LedgerTransaction{
+			Reference:   fmt.Sprintf("closure:%s:%s", account.ID, uuid.New()),
+			Type:        models.LedgerClosureTransfer,
+			Description: fmt.Sprintf("Closing balance of %s", account.AccountNumber),
+			Entries: []models.LedgerEntry{
+				ledger.Debit(account.ID, account.Balance),
+				ledger.Credit(target.ID, account.Balance),
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		closure.TransferredAmount = account.Balance
+		closure.TransferToAccountID = &target.ID
+	}
+
+	closed, err := s.accountRepo.CloseAccount(ctx, account.ID, staffID, request.Reason)
+	if err != nil {
+		return nil, err
+	}
+	if !closed {
+		// A posting landed after the balance was settled
+		return nil, ErrBalanceNotSettled
+	}
+
+	closure.ClosedAt = time.Now()
+	return closure, nil
+}
+
+// chargeEarlyClosureFee charges the product's early closure fee when the
+// account is closed within the product's early closure period, up to the
+// balance. Dormant accounts are not charged. It reports whether a fee was
+// posted.
+func (s *ClosureService) chargeEarlyClosureFee(ctx context.Context, account *models.Account, now time.Time) (bool, error) {
+	if account.ProductCode == "" || account.Status == models.AccountStatusDormant {
+		return false, nil
+	}
+	product, err := s.productRepo.GetProductVersion(ctx, account.ProductCode, account.ProductVersion)
+	if err != nil || product == nil {
+		return false, err
+	}
+	fees := product.Fees
+	if fees.EarlyClosureFee <= 0 || !now.Before(account.CreatedAt.AddDate(0, fees.EarlyClosureMonths, 0)) {
+		return false, nil
+	}
+	fee := account.Currency.Round(min(fees.EarlyClosureFee, account.Balance))
+	if fee <= 0 {
+		return false, nil
+	}
+
+	income, err := repository.GetInternalAccount(ctx, s.accountRepo, models.CurrencyAccount(models.GLFeeIncome, account.Currency))
+	if err != nil {
+		return false, err
+	}
+	err = s.ledger.Post(ctx, &models.LedgerTransaction{
+		Reference:   "closure-fee:" + account.ID.String(),
+		Type:        models.LedgerAccountFee,
+		Description: fmt.Sprintf("Early closure fee of %s", account.AccountNumber),
+		Entries: []models.LedgerEntry{
+			ledger.Debit(account.ID, fee),
+			ledger.Credit(income.ID, fee),
+		},
+	})
+	if errors.Is(err, repository.ErrDuplicateReference) {
+		// Charged by an earlier attempt to close the account
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// checkPreconditions returns the first reason the account cannot be closed
+func (s *ClosureService) checkPreconditions(ctx context.Context, account *models.Account) error {
+	if account.IsInternal() {
+		return ErrNotCustomerAccount
+	}
+	if account.Status == models.AccountStatusClosed {
+		return ErrAlreadyClosed
+	}
+	if account.Balance < 0 {
+		return ErrBalanceNotSettled
+	}
+	if account.HeldAmount > 0 {
+		return ErrActiveHolds
+	}
+
+	restrictions, err := s.restrictionRepo.GetAccountRestrictions(ctx, account.ID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, restriction := range restrictions {
+		if restriction.InForce(now) {
+			return ErrRestricted
+		}
+	}
+
+	repayment, err := s.loanAccountRepo.IsRepaymentAccount(ctx, account.ID)
+	if err != nil {
+		return err
+	}
+	if repayment {
+		return ErrRepaymentSource
+	}
+
+	cards, err := s.cardRepo.HasOpenCards(ctx, account.ID)
+	if err != nil {
+		return err
+	}
+	if cards {
+		return ErrOpenCards
+	}
+
+	if account.AccountType == models.AccountTypeFixedDeposit {
+		deposit, err := s.depositRepo.GetFixedDeposit(ctx, account.ID)
+		if err != nil {
+			return err
+		}
+		if deposit != nil && deposit.Status == models.FixedDepositActive {
+			return ErrActiveFixedDeposit
+		}
+	}
+
+	return nil
+}
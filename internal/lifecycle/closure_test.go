@@ -0,0 +1,304 @@
+package lifecycle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/repository/repotest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubClosureAccounts returns copies of the accounts in memory, as a
+// database would, and closes them once their balance is zero. A lateCredit
+// lands on the account just before the first close.
+type stubClosureAccounts struct {
+	repotest.Accounts
+	lateCredit float64
+}
+
+func (r *stubClosureAccounts) GetAccountByID(ctx context.Context, id uuid.UUID) (*models.Account, error) {
+	account, _ := r.Accounts.GetAccountByID(ctx, id)
+	if account == nil {
+		return nil, nil
+	}
+	stored := *account
+	return &stored, nil
+}
+
+func (r *stubClosureAccounts) CloseAccount(ctx context.Context, id uuid.UUID, closedBy uuid.UUID, reason string) (bool, error) {
+	account, _ := r.Accounts.GetAccountByID(ctx, id)
+	account.Balance += r.lateCredit
+	r.lateCredit = 0
+	if account.Balance != 0 || account.HeldAmount != 0 {
+		return false, nil
+	}
+	account.Status = models.AccountStatusClosed
+	return true, nil
+}
+
+// stubProductRepository returns the product every account was opened under
+type stubProductRepository struct {
+	repository.ProductRepository
+	product *models.AccountProduct
+}
+
+func (r *stubProductRepository) GetProductVersion(ctx context.Context, code string, version int) (*models.AccountProduct, error) {
+	return r.product, nil
+}
+
+// stubLoanAccountRepository reports whether the account repays a loan
+type stubLoanAccountRepository struct {
+	repository.LoanAccountRepository
+	repayment bool
+}
+
+func (r *stubLoanAccountRepository) IsRepaymentAccount(ctx context.Context, accountID uuid.UUID) (bool, error) {
+	return r.repayment, nil
+}
+
+// stubFixedDepositRepository returns a fixed deposit, or none
+type stubFixedDepositRepository struct {
+	repository.FixedDepositRepository
+	deposit *models.FixedDeposit
+}
+
+func (r *stubFixedDepositRepository) GetFixedDeposit(ctx context.Context, accountID uuid.UUID) (*models.FixedDeposit, error) {
+	return r.deposit, nil
+}
+
+// stubRestrictionRepository returns the restrictions of every account
+type stubRestrictionRepository struct {
+	repository.RestrictionRepository
+	restrictions []*models.Restriction
+}
+
+func (r *stubRestrictionRepository) GetAccountRestrictions(ctx context.Context, accountID uuid.UUID) ([]*models.Restriction, error) {
+	return r.restrictions, nil
+}
+
+// stubCardRepository reports whether the account has open cards
+type stubCardRepository struct {
+	repository.CardRepository
+	open bool
+}
+
+func (r *stubCardRepository) HasOpenCards(ctx context.Context, accountID uuid.UUID) (bool, error) {
+	return r.open, nil
+}
+
+// stubInterestPoster credits the accrued interest to the stored balance once
+type stubInterestPoster struct {
+	ledger  *stubLedgerRepository
+	accrued float64
+	posted  bool
+}
+
+func (p *stubInterestPoster) PostAccruedInterest(ctx context.Context, account *models.Account, businessDate time.Time) error {
+	if !p.posted {
+		p.ledger.accounts[account.ID].Balance += p.accrued
+		p.posted = true
+	}
+	return nil
+}
+
+// stubLedgerRepository applies postings to the accounts in memory
+type stubLedgerRepository struct {
+	repository.LedgerRepository
+	accounts map[uuid.UUID]*models.Account
+	posted   []*models.LedgerTransaction
+}
+
+func (r *stubLedgerRepository) PostTransaction(ctx context.Context, txn *models.LedgerTransaction, validate repository.PostingValidator) error {
+	if err := validate(r.accounts, txn); err != nil {
+		return err
+	}
+	for _, entry := range txn.Entries {
+		r.accounts[entry.AccountID].Balance += entry.SignedAmount()
+	}
+	r.posted = append(r.posted, txn)
+	return nil
+}
+
+type closureFixture struct {
+	service      *ClosureService
+	account      *models.Account
+	target       *models.Account
+	income       *models.Account
+	products     *stubProductRepository
+	loans        *stubLoanAccountRepository
+	deposits     *stubFixedDepositRepository
+	restrictions *stubRestrictionRepository
+	cards        *stubCardRepository
+	ledger       *stubLedgerRepository
+	accounts     *stubClosureAccounts
+}
+
+func newClosureFixture() *closureFixture {
+	f := &closureFixture{
+		account:      repotest.Account("1000000001", 500),
+		target:       repotest.Account("1000000002", 0),
+		income:       repotest.InternalAccount(models.GLFeeIncome),
+		products:     &stubProductRepository{product: &models.AccountProduct{Code: "SAVINGS", Version: 1}},
+		loans:        &stubLoanAccountRepository{},
+		deposits:     &stubFixedDepositRepository{},
+		restrictions: &stubRestrictionRepository{},
+		cards:        &stubCardRepository{},
+	}
+	f.account.ProductCode, f.account.ProductVersion, f.account.CreatedAt = "SAVINGS", 1, time.Now().AddDate(0, -2, 0)
+	f.ledger = &stubLedgerRepository{accounts: repotest.ByID(f.account, f.target, f.income)}
+	f.accounts = &stubClosureAccounts{Accounts: repotest.Accounts{Accounts: []*models.Account{f.account, f.target, f.income}}}
+	f.service = NewClosureService(f.accounts, f.products, f.loans, f.deposits, f.restrictions, f.cards, &stubInterestPoster{ledger: f.ledger, accrued: 1.25}, ledger.NewService(f.ledger))
+	return f
+}
+
+func (f *closureFixture) close() (*models.AccountClosure, error) {
+	return f.service.Close(context.Background(), f.account.ID, models.CloseAccountRequest{TransferToAccountID: &f.target.ID, Reason: "Customer request"}, uuid.New())
+}
+
+func TestCloseSweepsBalanceWithAccruedInterest(t *testing.T) {
+	f := newClosureFixture()
+
+	_, err := f.service.Close(context.Background(), f.account.ID, models.CloseAccountRequest{Reason: "Customer request"}, uuid.New())
+	assert.ErrorIs(t, err, ErrBalanceNotSettled, "money left without a transfer account")
+	assert.Equal(t, models.AccountStatusActive, f.account.Status)
+
+	closure, err := f.close()
+	require.NoError(t, err)
+	assert.Equal(t, 501.25, closure.TransferredAmount)
+	assert.Equal(t, f.target.ID, *closure.TransferToAccountID)
+	assert.Equal(t, 0.0, f.account.Balance)
+	assert.Equal(t, 501.25, f.target.Balance)
+	assert.Equal(t, models.AccountStatusClosed, f.account.Status)
+	require.Len(t, f.ledger.posted, 1)
+	assert.Equal(t, models.LedgerClosureTransfer, f.ledger.posted[0].Type)
+
+	_, err = f.close()
+	assert.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+func TestCloseRetriesAfterLatePosting(t *testing.T) {
+	f := newClosureFixture()
+	f.accounts.lateCredit = 20
+
+	_, err := f.close()
+	assert.ErrorIs(t, err, ErrBalanceNotSettled)
+	assert.Equal(t, 20.0, f.account.Balance)
+
+	closure, err := f.close()
+	require.NoError(t, err)
+	assert.Equal(t, 20.0, closure.TransferredAmount)
+	assert.Equal(t, 521.25, f.target.Balance)
+	assert.Equal(t, models.AccountStatusClosed, f.account.Status)
+}
+
+func TestCloseChargesEarlyClosureFee(t *testing.T) {
+	f := newClosureFixture()
+	f.products.product.Fees = models.ProductFees{EarlyClosureFee: 100, EarlyClosureMonths: 3}
+
+	closure, err := f.close()
+	require.NoError(t, err)
+	assert.Equal(t, 401.25, closure.TransferredAmount)
+	assert.Equal(t, 100.0, f.income.Balance)
+	require.Len(t, f.ledger.posted, 2)
+	assert.Equal(t, models.LedgerAccountFee, f.ledger.posted[0].Type)
+
+	// The fee is never more than the balance
+	f = newClosureFixture()
+	f.products.product.Fees = models.ProductFees{EarlyClosureFee: 1000, EarlyClosureMonths: 3}
+	closure, err = f.close()
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, closure.TransferredAmount)
+	assert.Equal(t, 501.25, f.income.Balance)
+	assert.Equal(t, models.AccountStatusClosed, f.account.Status)
+
+	// Accounts older than the early closure period are not charged
+	f = newClosureFixture()
+	f.products.product.Fees = models.ProductFees{EarlyClosureFee: 100, EarlyClosureMonths: 2}
+	closure, err = f.close()
+	require.NoError(t, err)
+	assert.Equal(t, 501.25, closure.TransferredAmount)
+	assert.Equal(t, 0.0, f.income.Balance)
+}
+
+func TestCloseMovesBalanceOutOfDormantAccount(t *testing.T) {
+	f := newClosureFixture()
+	f.account.Status = models.AccountStatusDormant
+
+	closure, err := f.close()
+	require.NoError(t, err)
+	assert.Equal(t, 501.25, closure.TransferredAmount)
+	assert.Equal(t, models.AccountStatusClosed, f.account.Status)
+}
+
+func TestCloseRejectsInvalidTransferTarget(t *testing.T) {
+	f := newClosureFixture()
+	f.target.Status = models.AccountStatusClosed
+
+	_, err := f.close()
+	assert.ErrorIs(t, err, ErrInvalidTransferTarget)
+
+	f.target = f.account
+	_, err = f.close()
+	assert.ErrorIs(t, err, ErrInvalidTransferTarget)
+	assert.Empty(t, f.ledger.posted)
+}
+
+func TestClosePreconditions(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(f *closureFixture)
+		err   error
+	}{
+		{"internal account", func(f *closureFixture) { f.account.AccountType = models.AccountTypeInternal }, ErrNotCustomerAccount},
+		{"overdrawn", func(f *closureFixture) { f.account.Balance = -10 }, ErrBalanceNotSettled},
+		{"active hold", func(f *closureFixture) { f.account.HeldAmount = 100 }, ErrActiveHolds},
+		{"restriction in force", func(f *closureFixture) {
+			f.restrictions.restrictions = []*models.Restriction{{Status: models.RestrictionLiftPending, ExpiresAt: time.Now().Add(time.Hour)}}
+		}, ErrRestricted},
+		{"repayment source", func(f *closureFixture) { f.loans.repayment = true }, ErrRepaymentSource},
+		{"open card", func(f *closureFixture) { f.cards.open = true }, ErrOpenCards},
+		{"active fixed deposit", func(f *closureFixture) {
+			f.account.AccountType = models.AccountTypeFixedDeposit
+			f.deposits.deposit = &models.FixedDeposit{AccountID: f.account.ID, Status: models.FixedDepositActive}
+		}, ErrActiveFixedDeposit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newClosureFixture()
+			tt.setup(f)
+
+			_, err := f.close()
+			assert.ErrorIs(t, err, tt.err)
+			assert.NotEqual(t, models.AccountStatusClosed, f.account.Status)
+			assert.Empty(t, f.ledger.posted)
+		})
+	}
+}
+
+func TestCloseMaturedFixedDeposit(t *testing.T) {
+	f := newClosureFixture()
+	f.account.AccountType = models.AccountTypeFixedDeposit
+	f.deposits.deposit = &models.FixedDeposit{AccountID: f.account.ID, Status: models.FixedDepositMatured}
+
+	closure, err := f.close()
+	require.NoError(t, err)
+	assert.Equal(t, 500.0, closure.TransferredAmount, "only savings accounts post accrued interest")
+}
+
+func TestCloseIgnoresLiftedAndExpiredRestrictions(t *testing.T) {
+	f := newClosureFixture()
+	f.restrictions.restrictions = []*models.Restriction{
+		{Status: models.RestrictionLifted, ExpiresAt: time.Now().Add(time.Hour)},
+		{Status: models.RestrictionActive, ExpiresAt: time.Now().Add(-time.Hour)},
+	}
+
+	_, err := f.close()
+	require.NoError(t, err)
+	assert.Equal(t, models.AccountStatusClosed, f.account.Status)
+}
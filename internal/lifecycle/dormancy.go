@@ -0,0 +1,178 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/identity"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotDormant is returned when reactivating an account that is not dormant
+	ErrNotDormant = errors.New("account is not dormant")
+	// ErrReasonRequired is returned when a staff reactivation has no reason
+	ErrReasonRequired = errors.New("reason is required")
+	// ErrVerificationFailed is returned when the re-entered identity details do not match
+	ErrVerificationFailed = errors.New("identity details do not match our records")
+	// ErrVerificationLocked is returned when too many re-verifications failed and staff must reactivate the account
+	ErrVerificationLocked = errors.New("too many failed verification attempts; please contact the bank to reactivate the account")
+)
+
+// DormancyService marks inactive accounts dormant and reactivates them
+type DormancyService struct {
+	repo     repository.DormancyRepository
+	identity *identity.Verifier
+	cfg      config.DormancyConfig
+	now      func() time.Time
+}
+
+// NewDormancyService creates a new DormancyService
+func NewDormancyService(repo repository.DormancyRepository, verifier *identity.Verifier, cfg config.DormancyConfig) *DormancyService {
+	return &DormancyService{
+		repo:     repo,
+		identity: verifier,
+		cfg:      cfg,
+		now:      time.Now,
+	}
+}
+
+// MarkDormant marks every account without customer-initiated activity for
+// the configured number of days before businessDate as dormant and returns
+// how many accounts changed. Running it again for the same date changes nothing.
+func (s *DormancyService) MarkDormant(ctx context.Context, businessDate time.Time) (int, error) {
+	ids, err := s.repo.MarkDormant(ctx, s.inactiveSince(businessDate), s.now())
+	return len(ids), err
+}
+
+// ReactivateByStaff makes a dormant account active again on a staff member's decision
+func (s *DormancyService) ReactivateByStaff(ctx context.Context, accountID uuid.UUID, staffID uuid.UUID, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ErrReasonRequired
+	}
+
+	return s.reactivate(ctx, &models.DormancyEvent{
+		AccountID: accountID,
+		Method:    models.ReactivationByStaff,
+		ActorID:   &staffID,
+		Reason:    reason,
+	})
+}
+
+// ReactivateByVerification makes a dormant account active again once one of
+// its holders re-enters the ID card and phone numbers on record. After
+// MaxVerificationAttempts failures since the account became dormant only
+// staff can reactivate it. Each attempt also counts against the customer's
+// identity checks.
+func (s *DormancyService) ReactivateByVerification(ctx context.Context, account *models.Account, customerID uuid.UUID, request models.IdentityVerificationRequest) error {
+	if account.Status != models.AccountStatusDormant {
+		return ErrNotDormant
+	}
+
+	since := account.CreatedAt
+	if account.DormantSince != nil {
+		since = *account.DormantSince
+	}
+	failures, err := s.repo.CountEvents(ctx, account.ID, models.DormancyVerificationFailed, since)
+	if err != nil {
+		return err
+	}
+	if failures >= s.cfg.MaxVerificationAttempts {
+		return ErrVerificationLocked
+	}
+
+	err = s.identity.Verify(ctx, customerID, request)
+	if errors.Is(err, identity.ErrMismatch) {
+		err := s.repo.RecordEvent(ctx, &models.DormancyEvent{
+			ID:        uuid.New(),
+			AccountID: account.ID,
+			Event:     models.DormancyVerificationFailed,
+			Method:    models.ReactivationByVerification,
+			ActorID:   &customerID,
+			CreatedAt: s.now(),
+		})
+		if err != nil {
+			return err
+		}
+		if failures+1 >= s.cfg.MaxVerificationAttempts {
+			return ErrVerificationLocked
+		}
+		return ErrVerificationFailed
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.reactivate(ctx, &models.DormancyEvent{
+		AccountID: account.ID,
+		Method:    models.ReactivationByVerification,
+		ActorID:   &customerID,
+	})
+}
+
+func (s *DormancyService) reactivate(ctx context.Context, event *models.DormancyEvent) error {
+	event.ID = uuid.New()
+	event.Event = models.DormancyReactivated
+	event.CreatedAt = s.now()
+
+	reactivated, err := s.repo.Reactivate(ctx, event)
+	if err != nil {
+		return err
+	}
+	if !reactivated {
+		return ErrNotDormant
+	}
+	return nil
+}
+
+// Approaching lists the accounts that become dormant within the configured
+// warning period after now unless the customer uses them
+func (s *DormancyService) Approaching(ctx context.Context, withinDays int) ([]*models.DormancyCandidate, error) {
+	if withinDays <= 0 {
+		withinDays = s.cfg.WarningDays
+	}
+
+	now := s.now()
+	from := s.inactiveSince(now)
+	accounts, err := s.repo.GetAccountsInactiveBetween(ctx, from, from.AddDate(0, 0, withinDays))
+	if err != nil {
+		return nil, err
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	candidates := make([]*models.DormancyCandidate, 0, len(accounts))
+	for _, account := range accounts {
+		dormantFrom := s.DormantFrom(account.LastActivityAt)
+		candidates = append(candidates, &models.DormancyCandidate{
+			AccountID:      account.ID,
+			AccountNumber:  account.AccountNumber,
+			AccountType:    account.AccountType,
+			CustomerID:     account.CustomerID,
+			Balance:        account.Balance,
+			LastActivityAt: account.LastActivityAt,
+			DormantFrom:    dormantFrom,
+			DaysRemaining:  int(dormantFrom.Sub(today).Hours() / 24),
+		})
+	}
+	return candidates, nil
+}
+
+// DormantFrom returns the first business date on which the dormancy job
+// marks an account last used at lastActivity dormant
+func (s *DormancyService) DormantFrom(lastActivity time.Time) time.Time {
+	day := time.Date(lastActivity.Year(), lastActivity.Month(), lastActivity.Day(), 0, 0, 0, 0, lastActivity.Location())
+	return day.AddDate(0, 0, s.cfg.InactiveDays+1)
+}
+
+// inactiveSince returns the activity cutoff for a date: accounts last used
+// before the start of the day InactiveDays earlier are dormant
+func (s *DormancyService) inactiveSince(date time.Time) time.Time {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	return day.AddDate(0, 0, -s.cfg.InactiveDays)
+}
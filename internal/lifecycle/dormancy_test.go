@@ -0,0 +1,125 @@
+package lifecycle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/identity"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/repository/repotest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestVerifier(customers ...*models.Customer) *identity.Verifier {
+	return identity.NewVerifier(&repotest.IdentityAttempts{}, repotest.NewCustomers(customers...), config.IdentityConfig{MaxAttempts: 5, LockoutMinutes: 60})
+}
+
+// stubDormancyRepository keeps dormancy events in memory
+type stubDormancyRepository struct {
+	repository.DormancyRepository
+	account *models.Account
+	events  []*models.DormancyEvent
+}
+
+func (r *stubDormancyRepository) RecordEvent(ctx context.Context, event *models.DormancyEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *stubDormancyRepository) CountEvents(ctx context.Context, accountID uuid.UUID, eventType models.DormancyEventType, since time.Time) (int, error) {
+	count := 0
+	for _, event := range r.events {
+		if event.Event == eventType && !event.CreatedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *stubDormancyRepository) Reactivate(ctx context.Context, event *models.DormancyEvent) (bool, error) {
+	if r.account.Status != models.AccountStatusDormant {
+		return false, nil
+	}
+	r.account.Status = models.AccountStatusActive
+	r.events = append(r.events, event)
+	return true, nil
+}
+
+func TestReactivateByVerification(t *testing.T) {
+	customerID := uuid.New()
+	dormantSince := time.Date(2026, 9, 1, 2, 0, 0, 0, time.UTC)
+	account := &models.Account{ID: uuid.New(), CustomerID: &customerID, Status: models.AccountStatusDormant, DormantSince: &dormantSince}
+	repo := &stubDormancyRepository{account: account}
+	customer := repotest.Customer(customerID, "1-1001-00123-45-6", "081-234-5678")
+	service := NewDormancyService(repo, newTestVerifier(customer), config.DormancyConfig{InactiveDays: 365, MaxVerificationAttempts: 2})
+	service.now = func() time.Time { return time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC) }
+	ctx := context.Background()
+
+	err := service.ReactivateByVerification(ctx, account, customerID, models.IdentityVerificationRequest{IDCardNumber: "1100100123456", PhoneNumber: "0810000000"})
+	assert.ErrorIs(t, err, ErrVerificationFailed)
+	assert.Equal(t, models.AccountStatusDormant, account.Status)
+
+	// Formatting of the numbers does not matter
+	require.NoError(t, service.ReactivateByVerification(ctx, account, customerID, models.IdentityVerificationRequest{IDCardNumber: "1100100123456", PhoneNumber: "0812345678"}))
+	assert.Equal(t, models.AccountStatusActive, account.Status)
+	assert.Equal(t, models.DormancyReactivated, repo.events[len(repo.events)-1].Event)
+
+	err = service.ReactivateByVerification(ctx, account, customerID, models.IdentityVerificationRequest{IDCardNumber: "1100100123456", PhoneNumber: "0812345678"})
+	assert.ErrorIs(t, err, ErrNotDormant)
+}
+
+func TestReactivateByVerificationLocksAfterFailures(t *testing.T) {
+	customerID := uuid.New()
+	account := &models.Account{ID: uuid.New(), CustomerID: &customerID, Status: models.AccountStatusDormant}
+	repo := &stubDormancyRepository{account: account}
+	customer := repotest.Customer(customerID, "1100100123456", "0812345678")
+	service := NewDormancyService(repo, newTestVerifier(customer), config.DormancyConfig{InactiveDays: 365, MaxVerificationAttempts: 2})
+	ctx := context.Background()
+	wrong := models.IdentityVerificationRequest{IDCardNumber: "1100100123456", PhoneNumber: "0899999999"}
+
+	assert.ErrorIs(t, service.ReactivateByVerification(ctx, account, customerID, wrong), ErrVerificationFailed)
+	assert.ErrorIs(t, service.ReactivateByVerification(ctx, account, customerID, wrong), ErrVerificationLocked)
+
+	// Once locked even the right details are refused until staff reactivate the account
+	right := models.IdentityVerificationRequest{IDCardNumber: "1100100123456", PhoneNumber: "0812345678"}
+	assert.ErrorIs(t, service.ReactivateByVerification(ctx, account, customerID, right), ErrVerificationLocked)
+	require.NoError(t, service.ReactivateByStaff(ctx, account.ID, uuid.New(), "Customer identified at branch"))
+	assert.Equal(t, models.AccountStatusActive, account.Status)
+}
+
+func TestDormantFromMatchesJobCutoff(t *testing.T) {
+	service := NewDormancyService(nil, nil, config.DormancyConfig{InactiveDays: 365})
+	lastActivity := time.Date(2025, 10, 1, 15, 30, 0, 0, time.UTC)
+
+	dormantFrom := service.DormantFrom(lastActivity)
+	assert.Equal(t, time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC), dormantFrom)
+	// The job marks the account on dormantFrom but not the day before
+	assert.True(t, lastActivity.Before(service.inactiveSince(dormantFrom)))
+	assert.False(t, lastActivity.Before(service.inactiveSince(dormantFrom.AddDate(0, 0, -1))))
+}
+
+func TestReactivateByVerificationSharesIdentityChecks(t *testing.T) {
+	customerID := uuid.New()
+	customers := repotest.NewCustomers(repotest.Customer(customerID, "1100100123456", "0812345678"))
+	verifier := identity.NewVerifier(&repotest.IdentityAttempts{}, customers, config.IdentityConfig{MaxAttempts: 2, LockoutMinutes: 60})
+	first := &models.Account{ID: uuid.New(), CustomerID: &customerID, Status: models.AccountStatusDormant}
+	second := &models.Account{ID: uuid.New(), CustomerID: &customerID, Status: models.AccountStatusDormant}
+	cfg := config.DormancyConfig{InactiveDays: 365, MaxVerificationAttempts: 2}
+	ctx := context.Background()
+	wrong := models.IdentityVerificationRequest{IDCardNumber: "1100100123456", PhoneNumber: "0899999999"}
+
+	// Moving to another dormant account does not give the customer more checks
+	firstService := NewDormancyService(&stubDormancyRepository{account: first}, verifier, cfg)
+	assert.ErrorIs(t, firstService.ReactivateByVerification(ctx, first, customerID, wrong), ErrVerificationFailed)
+	secondService := NewDormancyService(&stubDormancyRepository{account: second}, verifier, cfg)
+	assert.ErrorIs(t, secondService.ReactivateByVerification(ctx, second, customerID, wrong), ErrVerificationFailed)
+
+	right := models.IdentityVerificationRequest{IDCardNumber: "1100100123456", PhoneNumber: "0812345678"}
+	assert.ErrorIs(t, secondService.ReactivateByVerification(ctx, second, customerID, right), identity.ErrLocked)
+	assert.Equal(t, models.AccountStatusDormant, second.Status)
+}
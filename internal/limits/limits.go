@@ -0,0 +1,324 @@
+package limits
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/identity"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrLimitExceeded matches every LimitError
+	ErrLimitExceeded = errors.New("transaction limit exceeded")
+	// ErrInvalidChannel is returned for an unknown channel
+	ErrInvalidChannel = errors.New("unsupported channel")
+	// ErrInvalidType is returned for an unknown limit type
+	ErrInvalidType = errors.New("unsupported limit type")
+	// ErrInvalidTier is returned for an unknown customer tier
+	ErrInvalidTier = errors.New("unsupported customer tier")
+	// ErrInvalidLimit is returned when a limit is not positive or the per-transaction limit is above the daily limit
+	ErrInvalidLimit = errors.New("limits must be greater than zero and the per-transaction limit cannot exceed the daily limit")
+	// ErrNotLimited is returned when setting a limit for a channel and type the tier does not limit
+	ErrNotLimited = errors.New("no limit applies to this channel and type")
+	// ErrAboveTierLimit is returned when a customer sets a limit above the limit of their tier
+	ErrAboveTierLimit = errors.New("limit cannot be higher than your tier allows")
+	// ErrStepUpRequired is returned when a customer raises a limit without verifying their identity
+	ErrStepUpRequired = errors.New("raising a limit requires identity verification")
+	// ErrStepUpFailed is returned when the identity details re-entered to raise a limit do not match
+	ErrStepUpFailed = errors.New("identity details do not match our records")
+)
+
+// Codes of a LimitError
+const (
+	CodePerTransaction = "PER_TRANSACTION_LIMIT_EXCEEDED"
+	CodeDaily          = "DAILY_LIMIT_EXCEEDED"
+)
+
+// LimitError is returned for a payment refused by a transaction limit. Limit
+// carries the limit in force and the headroom left today.
+type LimitError struct {
+	Code  string
+	Limit models.TransactionLimit
+}
+
+func (e *LimitError) Error() string {
+	if e.Code == CodePerTransaction {
+		return fmt.Sprintf("amount exceeds the per-transaction limit of %.2f for %s %ss", e.Limit.PerTransaction, e.Limit.Channel, e.Limit.Type)
+	}
+	return fmt.Sprintf("amount exceeds the %.2f left of the daily limit for %s %ss", e.Limit.RemainingToday, e.Limit.Channel, e.Limit.Type)
+}
+
+// Is makes errors.Is(err, ErrLimitExceeded) match any LimitError
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// Valuer values an amount in the base currency, in which limits are set
+type Valuer interface {
+	BaseValue(ctx context.Context, money models.Money) (float64, error)
+}
+
+// Payment is a payment counted against the limits of the customer who made it
+type Payment struct {
+	CustomerID uuid.UUID
+	Channel    models.Channel
+	Type       models.LimitType
+	Amount     models.Money
+}
+
+// Reservation is the usage counted for a payment; it is released if the
+// payment is not posted
+type Reservation struct {
+	payment Payment
+	date    time.Time
+	amount  float64
+}
+
+// Service enforces per-transaction and daily limits keyed by customer tier,
+// channel and payment type. Customers may set their own limits below their
+// tier's; raising them again requires re-entering their identity details.
+type Service struct {
+	repo     repository.LimitRepository
+	identity *identity.Verifier
+	valuer   Valuer
+	cfg      config.LimitConfig
+	now      func() time.Time
+}
+
+// NewService creates a new limit Service
+func NewService(repo repository.LimitRepository, verifier *identity.Verifier, valuer Valuer, cfg config.LimitConfig) *Service {
+	return &Service{
+		repo:     repo,
+		identity: verifier,
+		valuer:   valuer,
+		cfg:      cfg,
+		now:      time.Now,
+	}
+}
+
+// Reserve counts a payment against the customer's limits, returning a
+// LimitError with the remaining headroom when it does not fit. Payments
+// without a customer or without a matching rule are not limited and
+// return a nil reservation.
+func (s *Service) Reserve(ctx context.Context, payment Payment) (*Reservation, error) {
+	if payment.CustomerID == uuid.Nil {
+		return nil, nil
+	}
+	if payment.Channel == "" {
+		payment.Channel = models.ChannelMobile
+	}
+
+	tier, err := s.repo.GetCustomerTier(ctx, payment.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := s.limit(ctx, payment.CustomerID, tier, payment.Channel, payment.Type)
+	if err != nil || limit == nil {
+		return nil, err
+	}
+
+	amount, err := s.valuer.BaseValue(ctx, payment.Amount)
+	if err != nil {
+		return nil, err
+	}
+	date := s.today()
+
+	if amount > limit.PerTransaction {
+		if err := s.fillUsage(ctx, payment.CustomerID, date, limit); err != nil {
+			return nil, err
+		}
+		return nil, &LimitError{Code: CodePerTransaction, Limit: *limit}
+	}
+
+	reserved, used, err := s.repo.ReserveUsage(ctx, payment.CustomerID, payment.Channel, payment.Type, date, amount, limit.Daily)
+	if err != nil {
+		return nil, err
+	}
+	setUsage(limit, used)
+	if !reserved {
+		return nil, &LimitError{Code: CodeDaily, Limit: *limit}
+	}
+
+	return &Reservation{payment: payment, date: date, amount: amount}, nil
+}
+
+// Release gives back the usage of a payment that was not posted
+func (s *Service) Release(ctx context.Context, reservation *Reservation) error {
+	if reservation == nil {
+		return nil
+	}
+	p := reservation.payment
+	return s.repo.ReleaseUsage(ctx, p.CustomerID, p.Channel, p.Type, reservation.date, reservation.amount)
+}
+
+// Limits returns the customer's tier and every limit that applies to them with today's usage
+func (s *Service) Limits(ctx context.Context, customerID uuid.UUID) (*models.CustomerLimitsResponse, error) {
+	tier, err := s.repo.GetCustomerTier(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.repo.GetUsage(ctx, customerID, s.today())
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.CustomerLimitsResponse{Tier: tier, Limits: []*models.TransactionLimit{}}
+	for _, channel := range models.Channels {
+		for _, limitType := range models.LimitTypes {
+			limit, err := s.limit(ctx, customerID, tier, channel, limitType)
+			if err != nil {
+				return nil, err
+			}
+			if limit == nil {
+				continue
+			}
+			setUsage(limit, usage[channel][limitType])
+			response.Limits = append(response.Limits, limit)
+		}
+	}
+	return response, nil
+}
+
+// SetLimit changes one of the customer's limits within their tier's limit.
+// Lowering a limit takes effect at once; raising it requires the customer
+// to re-enter the ID card and phone numbers on record, which counts against
+// the customer's identity checks.
+func (s *Service) SetLimit(ctx context.Context, customerID uuid.UUID, req models.LimitUpdateRequest) (*models.TransactionLimit, error) {
+	if !req.Channel.IsValid() {
+		return nil, ErrInvalidChannel
+	}
+	if !req.Type.IsValid() {
+		return nil, ErrInvalidType
+	}
+	if req.PerTransaction <= 0 || req.Daily <= 0 || req.PerTransaction > req.Daily {
+		return nil, ErrInvalidLimit
+	}
+
+	tier, err := s.repo.GetCustomerTier(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.limit(ctx, customerID, tier, req.Channel, req.Type)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, ErrNotLimited
+	}
+	if req.PerTransaction > current.TierPerTransaction || req.Daily > current.TierDaily {
+		return nil, ErrAboveTierLimit
+	}
+
+	if req.PerTransaction > current.PerTransaction || req.Daily > current.Daily {
+		if req.Verification == nil {
+			return nil, ErrStepUpRequired
+		}
+		err := s.identity.Verify(ctx, customerID, *req.Verification)
+		if errors.Is(err, identity.ErrMismatch) {
+			return nil, ErrStepUpFailed
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.repo.SaveCustomerLimit(ctx, &models.CustomerLimit{
+		CustomerID:     customerID,
+		Channel:        req.Channel,
+		Type:           req.Type,
+		PerTransaction: req.PerTransaction,
+		Daily:          req.Daily,
+		UpdatedAt:      s.now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	current.PerTransaction = req.PerTransaction
+	current.Daily = req.Daily
+	if err := s.fillUsage(ctx, customerID, s.today(), current); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// SetTier assigns a customer to a tier on a staff member's decision
+func (s *Service) SetTier(ctx context.Context, customerID uuid.UUID, tier models.CustomerTier, staffID uuid.UUID) error {
+	if !tier.IsValid() {
+		return ErrInvalidTier
+	}
+	return s.repo.SetCustomerTier(ctx, customerID, tier, staffID, s.now())
+}
+
+// limit returns the limit in force for a customer, which is the lower of
+// their tier's and their own, or nil when no rule applies
+func (s *Service) limit(ctx context.Context, customerID uuid.UUID, tier models.CustomerTier, channel models.Channel, limitType models.LimitType) (*models.TransactionLimit, error) {
+	rule := Rule(s.cfg, tier, channel, limitType)
+	if rule == nil {
+		return nil, nil
+	}
+	limit := &models.TransactionLimit{
+		Channel:            channel,
+		Type:               limitType,
+		PerTransaction:     rule.PerTransaction,
+		Daily:              rule.Daily,
+		TierPerTransaction: rule.PerTransaction,
+		TierDaily:          rule.Daily,
+	}
+
+	own, err := s.repo.GetCustomerLimits(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, custom := range own {
+		if custom.Channel == channel && custom.Type == limitType {
+			limit.PerTransaction = min(limit.PerTransaction, custom.PerTransaction)
+			limit.Daily = min(limit.Daily, custom.Daily)
+		}
+	}
+	return limit, nil
+}
+
+// Rule returns the configured rule for a tier, channel and type. A rule for
+// the tier wins over a rule for every tier.
+func Rule(cfg config.LimitConfig, tier models.CustomerTier, channel models.Channel, limitType models.LimitType) *config.LimitRule {
+	var fallback *config.LimitRule
+	for i, rule := range cfg.Rules {
+		if rule.Channel != string(channel) || rule.Type != string(limitType) {
+			continue
+		}
+		if rule.Tier == string(tier) {
+			return &cfg.Rules[i]
+		}
+		if rule.Tier == "" && fallback == nil {
+			fallback = &cfg.Rules[i]
+		}
+	}
+	return fallback
+}
+
+func (s *Service) fillUsage(ctx context.Context, customerID uuid.UUID, date time.Time, limit *models.TransactionLimit) error {
+	usage, err := s.repo.GetUsage(ctx, customerID, date)
+	if err != nil {
+		return err
+	}
+	setUsage(limit, usage[limit.Channel][limit.Type])
+	return nil
+}
+
+func setUsage(limit *models.TransactionLimit, used float64) {
+	limit.UsedToday = used
+	limit.RemainingToday = models.BaseCurrency.Round(max(limit.Daily-used, 0))
+}
+
+// today is the date daily usage is counted under
+func (s *Service) today() time.Time {
+	now := s.now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,238 @@
+package limits
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/identity"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/repository/repotest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type usageKey struct {
+	channel   models.Channel
+	limitType models.LimitType
+}
+
+// stubLimitRepository keeps tiers, limits and usage in memory. ReserveUsage
+// holds a lock like the row lock of the Postgres upsert.
+type stubLimitRepository struct {
+	repository.LimitRepository
+	mu     sync.Mutex
+	tier   models.CustomerTier
+	limits []*models.CustomerLimit
+	usage  map[usageKey]float64
+}
+
+func (r *stubLimitRepository) GetCustomerTier(ctx context.Context, customerID uuid.UUID) (models.CustomerTier, error) {
+	return r.tier, nil
+}
+
+func (r *stubLimitRepository) GetCustomerLimits(ctx context.Context, customerID uuid.UUID) ([]*models.CustomerLimit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*models.CustomerLimit(nil), r.limits...), nil
+}
+
+func (r *stubLimitRepository) SaveCustomerLimit(ctx context.Context, limit *models.CustomerLimit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.limits {
+		if existing.Channel == limit.Channel && existing.Type == limit.Type {
+			r.limits[i] = limit
+			return nil
+		}
+	}
+	r.limits = append(r.limits, limit)
+	return nil
+}
+
+func (r *stubLimitRepository) GetUsage(ctx context.Context, customerID uuid.UUID, date time.Time) (map[models.Channel]map[models.LimitType]float64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	usage := map[models.Channel]map[models.LimitType]float64{}
+	for key, amount := range r.usage {
+		if usage[key.channel] == nil {
+			usage[key.channel] = map[models.LimitType]float64{}
+		}
+		usage[key.channel][key.limitType] = amount
+	}
+	return usage, nil
+}
+
+func (r *stubLimitRepository) ReserveUsage(ctx context.Context, customerID uuid.UUID, channel models.Channel, limitType models.LimitType, date time.Time, amount, daily float64) (bool, float64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := usageKey{channel, limitType}
+	if r.usage[key]+amount > daily {
+		return false, r.usage[key], nil
+	}
+	r.usage[key] += amount
+	return true, r.usage[key], nil
+}
+
+func (r *stubLimitRepository) ReleaseUsage(ctx context.Context, customerID uuid.UUID, channel models.Channel, limitType models.LimitType, date time.Time, amount float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.usage[usageKey{channel, limitType}] -= amount
+	return nil
+}
+
+// fixedValuer values USD at 35 THB
+type fixedValuer struct{}
+
+func (fixedValuer) BaseValue(ctx context.Context, money models.Money) (float64, error) {
+	if money.Currency == models.CurrencyUSD {
+		return money.Amount * 35, nil
+	}
+	return money.Amount, nil
+}
+
+func testConfig() config.LimitConfig {
+	return config.LimitConfig{Rules: []config.LimitRule{
+		{Channel: "mobile", Type: "transfer", PerTransaction: 50000, Daily: 100000},
+		{Tier: "premium", Channel: "mobile", Type: "transfer", PerTransaction: 200000, Daily: 500000},
+	}}
+}
+
+func newTestService(repo *stubLimitRepository, customers ...*models.Customer) *Service {
+	verifier := identity.NewVerifier(&repotest.IdentityAttempts{}, repotest.NewCustomers(customers...), config.IdentityConfig{MaxAttempts: 3, LockoutMinutes: 60})
+	service := NewService(repo, verifier, fixedValuer{}, testConfig())
+	service.now = func() time.Time { return time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC) }
+	return service
+}
+
+func TestRuleForTier(t *testing.T) {
+	cfg := testConfig()
+	assert.Equal(t, 200000.0, Rule(cfg, models.TierPremium, models.ChannelMobile, models.LimitTransfer).PerTransaction)
+	assert.Equal(t, 50000.0, Rule(cfg, models.TierPrivate, models.ChannelMobile, models.LimitTransfer).PerTransaction)
+	assert.Nil(t, Rule(cfg, models.TierStandard, models.ChannelATM, models.LimitWithdrawal))
+}
+
+func TestReserveReportsHeadroom(t *testing.T) {
+	repo := &stubLimitRepository{tier: models.TierStandard, usage: map[usageKey]float64{}}
+	service := newTestService(repo)
+	ctx := context.Background()
+	payment := Payment{CustomerID: uuid.New(), Channel: models.ChannelMobile, Type: models.LimitTransfer}
+
+	payment.Amount = models.Money{Amount: 60000, Currency: models.CurrencyTHB}
+	_, err := service.Reserve(ctx, payment)
+	var exceeded *LimitError
+	require.True(t, errors.As(err, &exceeded))
+	assert.Equal(t, CodePerTransaction, exceeded.Code)
+
+	payment.Amount = models.Money{Amount: 1000, Currency: models.CurrencyUSD}
+	reservation, err := service.Reserve(ctx, payment)
+	require.NoError(t, err)
+	payment.Amount = models.Money{Amount: 40000, Currency: models.CurrencyTHB}
+	_, err = service.Reserve(ctx, payment)
+	require.NoError(t, err)
+
+	_, err = service.Reserve(ctx, payment)
+	require.True(t, errors.As(err, &exceeded))
+	assert.Equal(t, CodeDaily, exceeded.Code)
+	assert.Equal(t, 25000.0, exceeded.Limit.RemainingToday, "35,000 + 40,000 of 100,000 used")
+
+	require.NoError(t, service.Release(ctx, reservation))
+	_, err = service.Reserve(ctx, payment)
+	assert.NoError(t, err)
+
+	// Payments without a customer or a rule are not limited
+	reservation, err = service.Reserve(ctx, Payment{Type: models.LimitTransfer, Amount: models.Money{Amount: 1e9}})
+	assert.NoError(t, err)
+	assert.Nil(t, reservation)
+}
+
+func TestConcurrentReservationsStayWithinDailyLimit(t *testing.T) {
+	repo := &stubLimitRepository{tier: models.TierStandard, usage: map[usageKey]float64{}}
+	service := newTestService(repo)
+	payment := Payment{
+		CustomerID: uuid.New(),
+		Channel:    models.ChannelMobile,
+		Type:       models.LimitTransfer,
+		Amount:     models.Money{Amount: 30000, Currency: models.CurrencyTHB},
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	accepted := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := service.Reserve(context.Background(), payment); err == nil {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 3, accepted)
+	assert.Equal(t, 90000.0, repo.usage[usageKey{models.ChannelMobile, models.LimitTransfer}])
+}
+
+func TestRaisingALimitNeedsStepUp(t *testing.T) {
+	repo := &stubLimitRepository{tier: models.TierStandard, usage: map[usageKey]float64{}}
+	customerID := uuid.New()
+	service := newTestService(repo, repotest.Customer(customerID, "1-2345-67890-12-3", "081-234-5678"))
+	ctx := context.Background()
+	request := models.LimitUpdateRequest{Channel: models.ChannelMobile, Type: models.LimitTransfer, PerTransaction: 10000, Daily: 20000}
+
+	limit, err := service.SetLimit(ctx, customerID, request)
+	require.NoError(t, err, "lowering needs no verification")
+	assert.Equal(t, 20000.0, limit.Daily)
+	assert.Equal(t, 100000.0, limit.TierDaily)
+
+	request.Daily = 50000
+	_, err = service.SetLimit(ctx, customerID, request)
+	assert.ErrorIs(t, err, ErrStepUpRequired)
+
+	request.Verification = &models.IdentityVerificationRequest{IDCardNumber: "1234567890123", PhoneNumber: "0000000000"}
+	_, err = service.SetLimit(ctx, customerID, request)
+	assert.ErrorIs(t, err, ErrStepUpFailed)
+
+	request.Verification.PhoneNumber = "0812345678"
+	_, err = service.SetLimit(ctx, customerID, request)
+	require.NoError(t, err)
+	limits, err := service.Limits(ctx, customerID)
+	require.NoError(t, err)
+	require.Len(t, limits.Limits, 1)
+	assert.Equal(t, 50000.0, limits.Limits[0].Daily)
+
+	request.Daily = 150000
+	_, err = service.SetLimit(ctx, customerID, request)
+	assert.ErrorIs(t, err, ErrAboveTierLimit)
+}
+
+func TestStepUpLocksAfterFailedChecks(t *testing.T) {
+	repo := &stubLimitRepository{tier: models.TierStandard, usage: map[usageKey]float64{}}
+	customerID := uuid.New()
+	service := newTestService(repo, repotest.Customer(customerID, "1234567890123", "0812345678"))
+	ctx := context.Background()
+	request := models.LimitUpdateRequest{Channel: models.ChannelMobile, Type: models.LimitTransfer, PerTransaction: 10000, Daily: 20000}
+	_, err := service.SetLimit(ctx, customerID, request)
+	require.NoError(t, err)
+
+	request.Daily = 50000
+	request.Verification = &models.IdentityVerificationRequest{IDCardNumber: "1234567890123", PhoneNumber: "0000000000"}
+	for i := 0; i < 3; i++ {
+		_, err := service.SetLimit(ctx, customerID, request)
+		assert.ErrorIs(t, err, ErrStepUpFailed)
+	}
+
+	// Once the checks are used up even the right details are refused
+	request.Verification.PhoneNumber = "0812345678"
+	_, err = service.SetLimit(ctx, customerID, request)
+	assert.ErrorIs(t, err, identity.ErrLocked)
+	assert.Equal(t, 20000.0, repo.limits[0].Daily)
+}
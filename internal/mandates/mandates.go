@@ -0,0 +1,353 @@
+package mandates
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/transfers"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidRole is returned for an unknown role or when staff try to add a second primary holder
+	ErrInvalidRole = errors.New("role must be joint or authorized_signatory")
+	// ErrInvalidSigningRule is returned for an unknown signing rule
+	ErrInvalidSigningRule = errors.New("signing_rule must be either_or_survivor or all_must_sign")
+	// ErrAccountNotFound is returned when the account does not exist
+	ErrAccountNotFound = errors.New("account not found")
+	// ErrNotCustomerAccount is returned when the account is an internal ledger account or closed
+	ErrNotCustomerAccount = errors.New("only open customer accounts can have holders")
+	// ErrMandateExists is returned when the customer already holds the account
+	ErrMandateExists = errors.New("customer already holds a mandate on the account")
+	// ErrMandateNotFound is returned when the customer does not hold the account
+	ErrMandateNotFound = errors.New("customer does not hold a mandate on the account")
+	// ErrPrimaryHolder is returned when removing the primary holder
+	ErrPrimaryHolder = errors.New("the primary holder cannot be removed")
+	// ErrPendingNotFound is returned when a pending transfer does not exist on the account
+	ErrPendingNotFound = errors.New("pending transfer not found")
+	// ErrNotApprover is returned when the caller has no approval to give on the transfer
+	ErrNotApprover = errors.New("you have no approval outstanding on this transfer")
+	// ErrNotPending is returned when the transfer was already decided or expired
+	ErrNotPending = errors.New("transfer is no longer awaiting approval")
+	// ErrSoleSignatureNotAllowed is returned for a withdrawal from an all-must-sign account
+	ErrSoleSignatureNotAllowed = errors.New("all holders must sign for this account; withdrawals need every holder present at a branch")
+)
+
+// Transferrer posts a transfer between customer accounts
+type Transferrer interface {
+	Transfer(ctx context.Context, input transfers.Input) (*models.TransferResult, error)
+}
+
+// Service manages the holders of an account and the co-owner approval of
+// transfers from accounts where all holders must sign
+type Service struct {
+	accountRepo repository.AccountRepository
+	pendingRepo repository.PendingTransferRepository
+	transfers   Transferrer
+	cfg         config.JointAccountConfig
+	now         func() time.Time
+}
+
+// NewService creates a new mandate Service
+func NewService(accountRepo repository.AccountRepository, pendingRepo repository.PendingTransferRepository, transferrer Transferrer, cfg config.JointAccountConfig) *Service {
+	return &Service{
+		accountRepo: accountRepo,
+		pendingRepo: pendingRepo,
+		transfers:   transferrer,
+		cfg:         cfg,
+		now:         time.Now,
+	}
+}
+
+// AddMandate gives a customer a joint or signatory role on an account
+func (s *Service) AddMandate(ctx context.Context, accountID uuid.UUID, req models.MandateRequest, staffID uuid.UUID) (*models.AccountMandate, error) {
+	if !req.Role.IsValid() || req.Role == models.MandatePrimary {
+		return nil, ErrInvalidRole
+	}
+	if _, err := s.openAccount(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	mandate := &models.AccountMandate{
+		AccountID:  accountID,
+		CustomerID: req.CustomerID,
+		Role:       req.Role,
+		CreatedBy:  &staffID,
+		CreatedAt:  s.now(),
+	}
+	if err := s.accountRepo.AddMandate(ctx, mandate); err != nil {
+		if errors.Is(err, repository.ErrMandateExists) {
+			return nil, ErrMandateExists
+		}
+		return nil, err
+	}
+	return mandate, nil
+}
+
+// RemoveMandate removes a joint holder or signatory from an account
+func (s *Service) RemoveMandate(ctx context.Context, accountID, customerID uuid.UUID) error {
+	mandate, err := s.accountRepo.GetMandate(ctx, accountID, customerID)
+	if err != nil {
+		return err
+	}
+	if mandate == nil {
+		return ErrMandateNotFound
+	}
+	if mandate.Role == models.MandatePrimary {
+		return ErrPrimaryHolder
+	}
+
+	removed, err := s.accountRepo.RemoveMandate(ctx, accountID, customerID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrMandateNotFound
+	}
+	return nil
+}
+
+// SetSigningRule changes who must sign payments from an account
+func (s *Service) SetSigningRule(ctx context.Context, accountID uuid.UUID, rule models.SigningRule) (*models.Account, error) {
+	if !rule.IsValid() {
+		return nil, ErrInvalidSigningRule
+	}
+	account, err := s.openAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.accountRepo.UpdateSigningRule(ctx, accountID, rule); err != nil {
+		return nil, err
+	}
+	account.SigningRule = rule
+	return account, nil
+}
+
+func (s *Service) openAccount(ctx context.Context, accountID uuid.UUID) (*models.Account, error) {
+	account, err := s.accountRepo.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+	if account.IsInternal() || account.Status == models.AccountStatusClosed {
+		return nil, ErrNotCustomerAccount
+	}
+	return account, nil
+}
+
+// CoSigners returns the holders other than customerID who must confirm a
+// payment the customer makes from the account. It is empty unless the
+// account requires every holder to sign.
+func (s *Service) CoSigners(ctx context.Context, account *models.Account, customerID uuid.UUID) ([]uuid.UUID, error) {
+	if account.SigningRule != models.SigningAllMustSign {
+		return nil, nil
+	}
+
+	mandates, err := s.accountRepo.GetMandates(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	var others []uuid.UUID
+	for _, mandate := range mandates {
+		if mandate.CustomerID != customerID {
+			others = append(others, mandate.CustomerID)
+		}
+	}
+	return others, nil
+}
+
+// CheckSoleSignature returns ErrSoleSignatureNotAllowed when the customer
+// cannot move money out of the account on their own
+func (s *Service) CheckSoleSignature(ctx context.Context, account *models.Account, customerID uuid.UUID) error {
+	others, err := s.CoSigners(ctx, account, customerID)
+	if err != nil {
+		return err
+	}
+	if len(others) > 0 {
+		return ErrSoleSignatureNotAllowed
+	}
+	return nil
+}
+
+// RequestTransfer records a transfer from an all-must-sign account that
+// moves money only once every co-signer approved it
+func (s *Service) RequestTransfer(ctx context.Context, input transfers.Input, customerID uuid.UUID, coSigners []uuid.UUID) (*models.PendingTransfer, error) {
+	if err := transfers.Validate(input); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	pending := &models.PendingTransfer{
+		ID:              uuid.New(),
+		AccountID:       input.From.ID,
+		ToAccountID:     input.To.ID,
+		ToAccountNumber: input.To.AccountNumber,
+		Amount:          input.Amount,
+		Description:     strings.TrimSpace(input.Description),
+		InitiatedBy:     customerID,
+		Status:          models.PendingTransferAwaiting,
+		ExpiresAt:       now.Add(time.Duration(s.cfg.ApprovalTTLHours) * time.Hour),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	for _, id := range coSigners {
+		pending.Approvals = append(pending.Approvals, models.TransferApproval{
+			CustomerID: id,
+			Decision:   models.ApprovalPending,
+		})
+	}
+
+	if err := s.pendingRepo.CreatePendingTransfer(ctx, pending); err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
+
+// ListTransfers returns the transfers of an account that needed co-owner approval
+func (s *Service) ListTransfers(ctx context.Context, accountID uuid.UUID) ([]*models.PendingTransfer, error) {
+	list, err := s.pendingRepo.GetAccountPendingTransfers(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	for _, pending := range list {
+		if err := s.expireIfDue(ctx, pending); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// Approve records the customer's confirmation of a pending transfer. The
+// last confirmation posts the transfer; if posting fails the pending
+// transfer ends as failed with the reason and the error is returned.
+func (s *Service) Approve(ctx context.Context, account *models.Account, pendingID, customerID uuid.UUID) (*models.PendingTransfer, error) {
+	pending, err := s.decide(ctx, account, pendingID, customerID, models.ApprovalApproved)
+	if err != nil || pending.Status != models.PendingTransferExecuting {
+		return pending, err
+	}
+
+	return s.execute(ctx, pending)
+}
+
+// Reject records the customer's refusal, which cancels the pending transfer
+func (s *Service) Reject(ctx context.Context, account *models.Account, pendingID, customerID uuid.UUID) (*models.PendingTransfer, error) {
+	return s.decide(ctx, account, pendingID, customerID, models.ApprovalRejected)
+}
+
+func (s *Service) decide(ctx context.Context, account *models.Account, pendingID, customerID uuid.UUID, decision models.ApprovalDecision) (*models.PendingTransfer, error) {
+	pending, err := s.pendingRepo.GetPendingTransfer(ctx, pendingID)
+	if err != nil {
+		return nil, err
+	}
+	if pending == nil || pending.AccountID != account.ID {
+		return nil, ErrPendingNotFound
+	}
+	if err := s.expireIfDue(ctx, pending); err != nil {
+		return nil, err
+	}
+	if pending.Status != models.PendingTransferAwaiting {
+		return nil, ErrNotPending
+	}
+	if !awaits(pending, customerID) {
+		return nil, ErrNotApprover
+	}
+
+	pending, err = s.pendingRepo.RecordDecision(ctx, pendingID, customerID, decision, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if pending == nil {
+		return nil, ErrPendingNotFound
+	}
+	if !answered(pending, customerID, decision) {
+		// Another holder rejected or the transfer expired while the lock was awaited
+		return nil, ErrNotPending
+	}
+	return pending, nil
+}
+
+// execute posts an approved transfer. The pending transfer ID is the
+// idempotency reference, so it can never be posted twice. It counts
+// against the limits of the holder who asked for it.
+func (s *Service) execute(ctx context.Context, pending *models.PendingTransfer) (*models.PendingTransfer, error) {
+	from, err := s.accountRepo.GetAccountByID(ctx, pending.AccountID)
+	if err == nil && from == nil {
+		err = ErrAccountNotFound
+	}
+	var to *models.Account
+	if err == nil {
+		to, err = s.accountRepo.GetAccountByID(ctx, pending.ToAccountID)
+		if err == nil && to == nil {
+			err = transfers.ErrDestinationNotFound
+		}
+	}
+
+	var result *models.TransferResult
+	if err == nil {
+		result, err = s.transfers.Transfer(ctx, transfers.Input{
+			From:        from,
+			To:          to,
+			Amount:      pending.Amount,
+			Description: pending.Description,
+			Reference:   pending.ID.String(),
+			CustomerID:  pending.InitiatedBy,
+			Channel:     models.ChannelMobile,
+		})
+	}
+
+	pending.UpdatedAt = s.now()
+	if err != nil {
+		pending.Status = models.PendingTransferFailed
+		pending.FailureReason = err.Error()
+	} else {
+		pending.Status = models.PendingTransferExecuted
+		pending.TransactionID = &result.TransactionID
+	}
+	if saveErr := s.pendingRepo.CompletePendingTransfer(ctx, pending); saveErr != nil && err == nil {
+		return nil, saveErr
+	}
+	return pending, err
+}
+
+// expireIfDue marks a pending transfer expired once its approval window has passed
+func (s *Service) expireIfDue(ctx context.Context, pending *models.PendingTransfer) error {
+	now := s.now()
+	if pending.Status != models.PendingTransferAwaiting || pending.ExpiresAt.After(now) {
+		return nil
+	}
+
+	if _, err := s.pendingRepo.ExpirePendingTransfer(ctx, pending.ID, now); err != nil {
+		return err
+	}
+	pending.Status = models.PendingTransferExpired
+	pending.UpdatedAt = now
+	return nil
+}
+
+// answered reports whether the customer's decision was recorded on the transfer
+func answered(pending *models.PendingTransfer, customerID uuid.UUID, decision models.ApprovalDecision) bool {
+	for _, approval := range pending.Approvals {
+		if approval.CustomerID == customerID {
+			return approval.Decision == decision
+		}
+	}
+	return false
+}
+
+// awaits reports whether the customer still has to answer the transfer
+func awaits(pending *models.PendingTransfer, customerID uuid.UUID) bool {
+	for _, approval := range pending.Approvals {
+		if approval.CustomerID == customerID && approval.Decision == models.ApprovalPending {
+			return true
+		}
+	}
+	return false
+}
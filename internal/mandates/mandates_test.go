@@ -0,0 +1,201 @@
+package mandates
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/transfers"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubAccountRepository keeps accounts and mandates in memory
+type stubAccountRepository struct {
+	repository.AccountRepository
+	accounts map[uuid.UUID]*models.Account
+	mandates []*models.AccountMandate
+}
+
+func (r *stubAccountRepository) GetAccountByID(ctx context.Context, id uuid.UUID) (*models.Account, error) {
+	return r.accounts[id], nil
+}
+
+func (r *stubAccountRepository) GetMandates(ctx context.Context, accountID uuid.UUID) ([]*models.AccountMandate, error) {
+	var list []*models.AccountMandate
+	for _, mandate := range r.mandates {
+		if mandate.AccountID == accountID {
+			list = append(list, mandate)
+		}
+	}
+	return list, nil
+}
+
+// stubPendingRepository keeps pending transfers in memory
+type stubPendingRepository struct {
+	repository.PendingTransferRepository
+	transfers map[uuid.UUID]models.PendingTransfer
+}
+
+func (r *stubPendingRepository) CreatePendingTransfer(ctx context.Context, transfer *models.PendingTransfer) error {
+	r.transfers[transfer.ID] = copyTransfer(*transfer)
+	return nil
+}
+
+func (r *stubPendingRepository) GetPendingTransfer(ctx context.Context, id uuid.UUID) (*models.PendingTransfer, error) {
+	transfer, ok := r.transfers[id]
+	if !ok {
+		return nil, nil
+	}
+	transfer = copyTransfer(transfer)
+	return &transfer, nil
+}
+
+func (r *stubPendingRepository) RecordDecision(ctx context.Context, id, customerID uuid.UUID, decision models.ApprovalDecision, now time.Time) (*models.PendingTransfer, error) {
+	transfer := copyTransfer(r.transfers[id])
+	if transfer.Status == models.PendingTransferAwaiting {
+		for i := range transfer.Approvals {
+			if transfer.Approvals[i].CustomerID == customerID && transfer.Approvals[i].Decision == models.ApprovalPending {
+				transfer.Approvals[i].Decision = decision
+				transfer.Approvals[i].DecidedAt = &now
+			}
+		}
+		switch {
+		case decision == models.ApprovalRejected:
+			transfer.Status = models.PendingTransferRejected
+		case !transfer.Outstanding():
+			transfer.Status = models.PendingTransferExecuting
+		}
+		r.transfers[id] = copyTransfer(transfer)
+	}
+	return &transfer, nil
+}
+
+func (r *stubPendingRepository) CompletePendingTransfer(ctx context.Context, transfer *models.PendingTransfer) error {
+	r.transfers[transfer.ID] = copyTransfer(*transfer)
+	return nil
+}
+
+func (r *stubPendingRepository) ExpirePendingTransfer(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	transfer := r.transfers[id]
+	transfer.Status = models.PendingTransferExpired
+	r.transfers[id] = transfer
+	return true, nil
+}
+
+func copyTransfer(transfer models.PendingTransfer) models.PendingTransfer {
+	transfer.Approvals = append([]models.TransferApproval(nil), transfer.Approvals...)
+	return transfer
+}
+
+// stubTransferrer records the transfers it is asked to post
+type stubTransferrer struct {
+	inputs []transfers.Input
+}
+
+func (t *stubTransferrer) Transfer(ctx context.Context, input transfers.Input) (*models.TransferResult, error) {
+	t.inputs = append(t.inputs, input)
+	return &models.TransferResult{TransactionID: uuid.New(), Amount: input.Amount}, nil
+}
+
+func newJointAccount(rule models.SigningRule, holders ...uuid.UUID) (*models.Account, *models.Account, *stubAccountRepository) {
+	account := &models.Account{ID: uuid.New(), AccountNumber: "100-1-00001-1", Status: models.AccountStatusActive, SigningRule: rule}
+	destination := &models.Account{ID: uuid.New(), AccountNumber: "100-1-00002-1", Status: models.AccountStatusActive}
+	repo := &stubAccountRepository{accounts: map[uuid.UUID]*models.Account{account.ID: account, destination.ID: destination}}
+	for i, holder := range holders {
+		role := models.MandateJoint
+		if i == 0 {
+			role = models.MandatePrimary
+		}
+		repo.mandates = append(repo.mandates, &models.AccountMandate{AccountID: account.ID, CustomerID: holder, Role: role})
+	}
+	return account, destination, repo
+}
+
+func TestAllMustSignTransferWaitsForEveryHolder(t *testing.T) {
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+	account, destination, accountRepo := newJointAccount(models.SigningAllMustSign, alice, bob, carol)
+	pendingRepo := &stubPendingRepository{transfers: map[uuid.UUID]models.PendingTransfer{}}
+	transferrer := &stubTransferrer{}
+	service := NewService(accountRepo, pendingRepo, transferrer, config.JointAccountConfig{ApprovalTTLHours: 72})
+	ctx := context.Background()
+
+	coSigners, err := service.CoSigners(ctx, account, alice)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{bob, carol}, coSigners)
+	assert.ErrorIs(t, service.CheckSoleSignature(ctx, account, alice), ErrSoleSignatureNotAllowed)
+
+	pending, err := service.RequestTransfer(ctx, transfers.Input{From: account, To: destination, Amount: 500}, alice, coSigners)
+	require.NoError(t, err)
+	assert.Equal(t, models.PendingTransferAwaiting, pending.Status)
+
+	// The initiator has nothing to approve
+	_, err = service.Approve(ctx, account, pending.ID, alice)
+	assert.ErrorIs(t, err, ErrNotApprover)
+
+	pending, err = service.Approve(ctx, account, pending.ID, bob)
+	require.NoError(t, err)
+	assert.Equal(t, models.PendingTransferAwaiting, pending.Status)
+	assert.Empty(t, transferrer.inputs, "no money moves before every holder approved")
+
+	// Approving twice is refused
+	_, err = service.Approve(ctx, account, pending.ID, bob)
+	assert.ErrorIs(t, err, ErrNotApprover)
+
+	pending, err = service.Approve(ctx, account, pending.ID, carol)
+	require.NoError(t, err)
+	assert.Equal(t, models.PendingTransferExecuted, pending.Status)
+	require.NotNil(t, pending.TransactionID)
+	require.Len(t, transferrer.inputs, 1)
+	assert.Equal(t, pending.ID.String(), transferrer.inputs[0].Reference)
+	assert.Equal(t, 500.0, transferrer.inputs[0].Amount)
+
+	_, err = service.Reject(ctx, account, pending.ID, bob)
+	assert.ErrorIs(t, err, ErrNotPending)
+}
+
+func TestRejectedOrExpiredTransferNeverMoves(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	account, destination, accountRepo := newJointAccount(models.SigningAllMustSign, alice, bob)
+	pendingRepo := &stubPendingRepository{transfers: map[uuid.UUID]models.PendingTransfer{}}
+	transferrer := &stubTransferrer{}
+	service := NewService(accountRepo, pendingRepo, transferrer, config.JointAccountConfig{ApprovalTTLHours: 72})
+	now := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	rejected, err := service.RequestTransfer(ctx, transfers.Input{From: account, To: destination, Amount: 100}, alice, []uuid.UUID{bob})
+	require.NoError(t, err)
+	rejected, err = service.Reject(ctx, account, rejected.ID, bob)
+	require.NoError(t, err)
+	assert.Equal(t, models.PendingTransferRejected, rejected.Status)
+
+	expired, err := service.RequestTransfer(ctx, transfers.Input{From: account, To: destination, Amount: 100}, alice, []uuid.UUID{bob})
+	require.NoError(t, err)
+	now = now.Add(73 * time.Hour)
+	_, err = service.Approve(ctx, account, expired.ID, bob)
+	assert.ErrorIs(t, err, ErrNotPending)
+	assert.Equal(t, models.PendingTransferExpired, pendingRepo.transfers[expired.ID].Status)
+
+	// A transfer of another account is not visible through this one
+	other, _, _ := newJointAccount(models.SigningAllMustSign, bob)
+	_, err = service.Approve(ctx, other, rejected.ID, bob)
+	assert.ErrorIs(t, err, ErrPendingNotFound)
+
+	assert.Empty(t, transferrer.inputs)
+}
+
+func TestEitherOrSurvivorNeedsNoCoSigners(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	account, _, accountRepo := newJointAccount(models.SigningEitherOrSurvivor, alice, bob)
+	service := NewService(accountRepo, nil, nil, config.JointAccountConfig{})
+
+	coSigners, err := service.CoSigners(context.Background(), account, bob)
+	require.NoError(t, err)
+	assert.Empty(t, coSigners)
+	assert.NoError(t, service.CheckSoleSignature(context.Background(), account, bob))
+}
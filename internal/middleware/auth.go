@@ -103,3 +103,12 @@ func GetCustomerIDFromContext(c *fiber.Ctx) (string, error) {
 	}
 	return id, nil
 }
+
+// GetStaffIDFromContext retrieves the staff ID set by StaffAuthMiddleware.
+func GetStaffIDFromContext(c *fiber.Ctx) (uuid.UUID, error) {
+	id, ok := c.Locals("staffID").(uuid.UUID)
+	if !ok || id == uuid.Nil {
+		return uuid.Nil, errors.New("staff ID not found in context")
+	}
+	return id, nil
+}
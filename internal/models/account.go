@@ -0,0 +1,140 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountType represents the kind of account
+type AccountType string
+
+const (
+	// AccountTypeSavings is a customer savings account
+	AccountTypeSavings AccountType = "savings"
+	// AccountTypeCurrent is a customer current (checking) account
+	AccountTypeCurrent AccountType = "current"
+	// AccountTypeFixedDeposit is a customer fixed (term) deposit account
+	AccountTypeFixedDeposit AccountType = "fixed_deposit"
+	// AccountTypeInternal is a bank-owned general ledger account
+	AccountTypeInternal AccountType = "internal"
+)
+
+// AccountStatus represents the lifecycle status of an account
+type AccountStatus string
+
+const (
+	// AccountStatusActive indicates the account can be used normally
+	AccountStatusActive AccountStatus = "active"
+	// AccountStatusDormant indicates the customer has not used the account for a long
+	// time; it still receives credits but cannot make payments until reactivated
+	AccountStatusDormant AccountStatus = "dormant"
+	// AccountStatusClosed indicates the account has been closed
+	AccountStatusClosed AccountStatus = "closed"
+)
+
+// Internal general ledger account numbers used as the other side of postings
+const (
+	// GLInterestExpense receives the debit for interest paid to customers
+	GLInterestExpense = "GL-INTEREST-EXPENSE"
+	// GLWithholdingTaxPayable receives the withholding tax deducted from interest
+	GLWithholdingTaxPayable = "GL-WHT-PAYABLE"
+	// GLCash is the cash the bank holds for deposits received at branches and ATMs
+	GLCash = "GL-CASH"
+	// GLSuspense receives captured holds that have no other destination
+	GLSuspense = "GL-SUSPENSE"
+	// GLFXPosition is the bank's open position in a currency from customer conversions
+	GLFXPosition = "GL-FX-POSITION"
+	// GLFXGainLoss receives the spread earned, or lost to rounding, on conversions
+	GLFXGainLoss = "GL-FX-GAIN-LOSS"
+	// GLInterbankSettlement is what the bank owes the switch for transfers sent to other banks
+	GLInterbankSettlement = "GL-INTERBANK-SETTLEMENT"
+	// GLInterbankClearing holds interbank transfers sent to the switch until they are settled or returned
+	GLInterbankClearing = "GL-INTERBANK-CLEARING"
+	// GLCardSettlement is what the bank owes the card network for cleared card transactions
+	GLCardSettlement = "GL-CARD-SETTLEMENT"
+	// GLFeeIncome receives the account fees charged to customers
+	GLFeeIncome = "GL-FEE-INCOME"
+)
+
+// CurrencyGLAccounts are the internal accounts kept once per currency; see CurrencyAccount
+var CurrencyGLAccounts = []string{GLInterestExpense, GLWithholdingTaxPayable, GLCash, GLSuspense, GLFXPosition, GLFeeIncome}
+
+// Account represents a customer deposit account or an internal ledger account
+type Account struct {
+	ID               uuid.UUID     `json:"id" db:"id"`
+	AccountNumber    string        `json:"account_number" db:"account_number"`
+	CustomerID       *uuid.UUID    `json:"customer_id,omitempty" db:"customer_id"`
+	AccountType      AccountType   `json:"account_type" db:"account_type"`
+	Currency         Currency      `json:"currency" db:"currency"`
+	ProductCode      string        `json:"product_code,omitempty" db:"product_code"`
+	ProductVersion   int           `json:"product_version,omitempty" db:"product_version"`
+	Balance          float64       `json:"balance" db:"balance"`
+	HeldAmount       float64       `json:"held_amount" db:"held_amount"`
+	AvailableBalance float64       `json:"available_balance" db:"-"`
+	Status           AccountStatus `json:"status" db:"status"`
+	LastActivityAt   time.Time     `json:"last_activity_at" db:"last_activity_at"`
+	DormantSince     *time.Time    `json:"dormant_since,omitempty" db:"dormant_since"`
+	SigningRule      SigningRule   `json:"signing_rule" db:"signing_rule"`
+	CreatedAt        time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at" db:"updated_at"`
+	// Restrictions in force, loaded only when the account is locked for posting
+	Restrictions []*Restriction `json:"-"`
+	// WithdrawalRules of the account product, loaded only when the account
+	// is locked for a posting that withdraws from it
+	WithdrawalRules *WithdrawalRules `json:"-"`
+}
+
+// Available returns the balance that can be spent: the ledger balance minus active holds
+func (a *Account) Available() float64 {
+	return a.Balance - a.HeldAmount
+}
+
+// GarnishedAmount returns the total ring-fenced by garnishments in force at time now
+func (a *Account) GarnishedAmount(now time.Time) float64 {
+	total := 0.0
+	for _, restriction := range a.Restrictions {
+		if restriction.Type == RestrictionGarnishment && restriction.InForce(now) {
+			total += restriction.Amount
+		}
+	}
+	return total
+}
+
+// CanBecomeDormant reports whether the account type is subject to dormancy.
+// Fixed deposits are excluded because they run without customer activity.
+func (a *Account) CanBecomeDormant() bool {
+	return a.AccountType == AccountTypeSavings || a.AccountType == AccountTypeCurrent
+}
+
+// CurrencyOrBase returns the currency of the account, which is the base
+// currency for accounts loaded without one
+func (a *Account) CurrencyOrBase() Currency {
+	if a.Currency == "" {
+		return BaseCurrency
+	}
+	return a.Currency
+}
+
+// IsInternal reports whether the account is a bank-owned ledger account
+func (a *Account) IsInternal() bool {
+	return a.AccountType == AccountTypeInternal
+}
+
+// CloseAccountRequest represents the staff request to close an account
+type CloseAccountRequest struct {
+	// TransferToAccountID receives the remaining balance. Without it the
+	// balance must already be zero.
+	TransferToAccountID *uuid.UUID `json:"transfer_to_account_id,omitempty"`
+	Reason              string     `json:"reason"`
+}
+
+// AccountClosure is the outcome of closing an account
+type AccountClosure struct {
+	AccountID           uuid.UUID     `json:"account_id"`
+	Status              AccountStatus `json:"status"`
+	TransferredAmount   float64       `json:"transferred_amount"`
+	TransferToAccountID *uuid.UUID    `json:"transfer_to_account_id,omitempty"`
+	ClosedBy            uuid.UUID     `json:"closed_by"`
+	ClosedAt            time.Time     `json:"closed_at"`
+}
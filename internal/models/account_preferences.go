@@ -0,0 +1,74 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// MaxNicknameLength is the maximum number of characters in an account
+// nickname. Characters are counted as Unicode code points so Thai
+// combining vowels and tone marks count towards the limit.
+const MaxNicknameLength = 30
+
+// ErrInvalidNickname is returned when a nickname is not valid UTF-8 or contains control characters
+var ErrInvalidNickname = errors.New("nickname contains invalid characters")
+
+// AccountPreferences holds how a customer wants an account presented in the app
+type AccountPreferences struct {
+	AccountID         uuid.UUID `json:"account_id" db:"account_id"`
+	Nickname          string    `json:"nickname" db:"nickname"`
+	DisplayOrder      *int      `json:"display_order,omitempty" db:"display_order"`
+	HideFromDashboard bool      `json:"hide_from_dashboard" db:"hide_from_dashboard"`
+	DefaultIncoming   bool      `json:"default_incoming" db:"default_incoming"`
+	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CustomerAccount is an account as listed to one of its holders, with their
+// role and preferences applied
+type CustomerAccount struct {
+	Account
+	Role              MandateRole `json:"role"`
+	Nickname          string      `json:"nickname,omitempty"`
+	DisplayOrder      *int        `json:"display_order,omitempty"`
+	HideFromDashboard bool        `json:"hide_from_dashboard"`
+	DefaultIncoming   bool        `json:"default_incoming"`
+}
+
+// NicknameRequest represents the request body for renaming an account
+type NicknameRequest struct {
+	Nickname string `json:"nickname"`
+}
+
+// AccountPreferencesRequest represents a partial update of account
+// preferences. Fields that are not sent are left unchanged.
+type AccountPreferencesRequest struct {
+	Nickname          *string `json:"nickname"`
+	DisplayOrder      *int    `json:"display_order"`
+	HideFromDashboard *bool   `json:"hide_from_dashboard"`
+	DefaultIncoming   *bool   `json:"default_incoming"`
+}
+
+// NormalizeNickname trims surrounding white space and validates the
+// nickname. An empty result clears the nickname.
+func NormalizeNickname(nickname string) (string, error) {
+	if !utf8.ValidString(nickname) {
+		return "", ErrInvalidNickname
+	}
+
+	nickname = strings.TrimSpace(nickname)
+	for _, r := range nickname {
+		if unicode.IsControl(r) {
+			return "", ErrInvalidNickname
+		}
+	}
+	if utf8.RuneCountInString(nickname) > MaxNicknameLength {
+		return "", fmt.Errorf("nickname must be at most %d characters", MaxNicknameLength)
+	}
+	return nickname, nil
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeNickname(t *testing.T) {
+	nickname, err := NormalizeNickname("  บัญชีเงินเก็บ 🏠 ")
+	assert.NoError(t, err)
+	assert.Equal(t, "บัญชีเงินเก็บ 🏠", nickname)
+
+	// Thai tone marks and vowels are counted as characters, not bytes
+	_, err = NormalizeNickname(strings.Repeat("ก่", MaxNicknameLength/2))
+	assert.NoError(t, err)
+	_, err = NormalizeNickname(strings.Repeat("ก่", MaxNicknameLength/2+1))
+	assert.Error(t, err)
+
+	_, err = NormalizeNickname("tab\there")
+	assert.ErrorIs(t, err, ErrInvalidNickname)
+	_, err = NormalizeNickname("\xff")
+	assert.ErrorIs(t, err, ErrInvalidNickname)
+
+	nickname, err = NormalizeNickname("   ")
+	assert.NoError(t, err)
+	assert.Empty(t, nickname)
+}
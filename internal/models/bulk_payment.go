@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BulkPaymentStatus represents the state of a bulk payment file
+type BulkPaymentStatus string
+
+const (
+	// BulkPaymentAwaitingConfirmation indicates the file was validated and is waiting for the customer to confirm it
+	BulkPaymentAwaitingConfirmation BulkPaymentStatus = "awaiting_confirmation"
+	// BulkPaymentQueued indicates the customer confirmed the file and it waits to be executed
+	BulkPaymentQueued BulkPaymentStatus = "queued"
+	// BulkPaymentProcessing indicates the rows are being paid
+	BulkPaymentProcessing BulkPaymentStatus = "processing"
+	// BulkPaymentCompleted indicates every valid row was paid or failed
+	BulkPaymentCompleted BulkPaymentStatus = "completed"
+	// BulkPaymentCancelled indicates the customer cancelled the file before confirming it
+	BulkPaymentCancelled BulkPaymentStatus = "cancelled"
+)
+
+// BulkPaymentRowStatus represents the state of one payment in a file
+type BulkPaymentRowStatus string
+
+const (
+	// BulkRowValid indicates the row passed validation and will be paid once the file is confirmed
+	BulkRowValid BulkPaymentRowStatus = "valid"
+	// BulkRowInvalid indicates the row failed validation and is never paid
+	BulkRowInvalid BulkPaymentRowStatus = "invalid"
+	// BulkRowPaid indicates the transfer was posted
+	BulkRowPaid BulkPaymentRowStatus = "paid"
+	// BulkRowFailed indicates the transfer was refused when the file was executed
+	BulkRowFailed BulkPaymentRowStatus = "failed"
+)
+
+// BulkPayment is a file of payments from one account, such as a payroll
+type BulkPayment struct {
+	ID           uuid.UUID         `json:"id" db:"id"`
+	AccountID    uuid.UUID         `json:"account_id" db:"account_id"`
+	CustomerID   uuid.UUID         `json:"customer_id" db:"customer_id"`
+	FileName     string            `json:"file_name" db:"file_name"`
+	Status       BulkPaymentStatus `json:"status" db:"status"`
+	RowCount     int               `json:"row_count" db:"row_count"`
+	ValidCount   int               `json:"valid_count" db:"valid_count"`
+	InvalidCount int               `json:"invalid_count" db:"invalid_count"`
+	// TotalAmount is the sum of the valid rows, which is what confirming the file pays out
+	TotalAmount float64 `json:"total_amount" db:"total_amount"`
+	PaidCount   int     `json:"paid_count" db:"paid_count"`
+	FailedCount int     `json:"failed_count" db:"failed_count"`
+	PaidAmount  float64 `json:"paid_amount" db:"paid_amount"`
+	// AvailableBalance is the available balance of the account when the
+	// file was validated, for the customer to compare with the total
+	AvailableBalance float64    `json:"available_balance" db:"available_balance"`
+	ConfirmedAt      *time.Time `json:"confirmed_at,omitempty" db:"confirmed_at"`
+	CompletedAt      *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// BulkPaymentRow is one payment in a bulk payment file
+type BulkPaymentRow struct {
+	ID              uuid.UUID            `json:"id" db:"id"`
+	BulkPaymentID   uuid.UUID            `json:"bulk_payment_id" db:"bulk_payment_id"`
+	LineNumber      int                  `json:"line_number" db:"line_number"`
+	ToAccountNumber string               `json:"to_account_number" db:"to_account_number"`
+	ToAccountID     *uuid.UUID           `json:"-" db:"to_account_id"`
+	Amount          float64              `json:"amount" db:"amount"`
+	Reference       string               `json:"reference" db:"reference"`
+	Description     string               `json:"description,omitempty" db:"description"`
+	Status          BulkPaymentRowStatus `json:"status" db:"status"`
+	// Error explains why the row is invalid or failed
+	Error         string     `json:"error,omitempty" db:"error"`
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty" db:"transaction_id"`
+	ProcessedAt   *time.Time `json:"processed_at,omitempty" db:"processed_at"`
+}
+
+// BulkPaymentDetails is a bulk payment file with its rows
+type BulkPaymentDetails struct {
+	BulkPayment
+	Rows []*BulkPaymentRow `json:"rows"`
+}
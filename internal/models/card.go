@@ -0,0 +1,182 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CardType is the kind of payment card
+type CardType string
+
+const (
+	// CardTypeDebit is a card that spends from the balance of a deposit account
+	CardTypeDebit CardType = "debit"
+)
+
+// CardStatus represents the lifecycle status of a card
+type CardStatus string
+
+const (
+	// CardRequested indicates the card was asked for, or offered as a replacement, and was not sent out yet
+	CardRequested CardStatus = "requested"
+	// CardIssued indicates the card was produced and sent to the customer, who has not activated it yet
+	CardIssued CardStatus = "issued"
+	// CardActive indicates the customer activated the card and it can be used
+	CardActive CardStatus = "active"
+	// CardBlocked indicates the customer blocked the card for now; they can unblock it
+	CardBlocked CardStatus = "blocked"
+	// CardLostStolen indicates the card was reported lost or stolen; it can never be used again
+	CardLostStolen CardStatus = "lost_stolen"
+)
+
+// cardTransitions lists the statuses a card can move to from each status
+var cardTransitions = map[CardStatus][]CardStatus{
+	CardRequested: {CardIssued},
+	CardIssued:    {CardActive, CardLostStolen},
+	CardActive:    {CardBlocked, CardLostStolen},
+	CardBlocked:   {CardActive, CardLostStolen},
+}
+
+// CanTransition reports whether a card in status s can move to status to
+func (s CardStatus) CanTransition(to CardStatus) bool {
+	for _, next := range cardTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Card is a payment card linked to an account. The full card number is
+// only stored encrypted; the last four digits are kept in clear to find
+// the card.
+type Card struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	AccountID  uuid.UUID  `json:"account_id" db:"account_id"`
+	CustomerID uuid.UUID  `json:"customer_id" db:"customer_id"`
+	CardType   CardType   `json:"card_type" db:"card_type"`
+	Last4      string     `json:"last4" db:"last4"`
+	Status     CardStatus `json:"status" db:"status"`
+	// PANEncrypted is the card number sealed by the card vault
+	PANEncrypted []byte `json:"-" db:"pan_encrypted"`
+	// PANHash is a keyed hash of the card number, to find the card from a full number
+	PANHash     string `json:"-" db:"pan_hash"`
+	ExpiryMonth int    `json:"expiry_month" db:"expiry_month"`
+	ExpiryYear  int    `json:"expiry_year" db:"expiry_year"`
+	// CVVHash and ActivationCodeHash are keyed hashes; the values themselves are never stored
+	CVVHash            string `json:"-" db:"cvv_hash"`
+	ActivationCodeHash string `json:"-" db:"activation_code_hash"`
+	// ActivationAttempts counts the activation codes tried
+	ActivationAttempts int        `json:"activation_attempts" db:"activation_attempts"`
+	IssuedAt           *time.Time `json:"issued_at,omitempty" db:"issued_at"`
+	ActivatedAt        *time.Time `json:"activated_at,omitempty" db:"activated_at"`
+	LostStolenAt       *time.Time `json:"lost_stolen_at,omitempty" db:"lost_stolen_at"`
+	// LostStolenBy is the staff member who took the report
+	LostStolenBy *uuid.UUID `json:"lost_stolen_by,omitempty" db:"lost_stolen_by"`
+	// ReplacesCardID is the lost or stolen card this card replaces
+	ReplacesCardID *uuid.UUID `json:"replaces_card_id,omitempty" db:"replaces_card_id"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Expired reports whether the card is past the last day of its expiry month
+func (c *Card) Expired(now time.Time) bool {
+	firstAfter := time.Date(c.ExpiryYear, time.Month(c.ExpiryMonth)+1, 1, 0, 0, 0, 0, now.Location())
+	return !now.Before(firstAfter)
+}
+
+// CardIssue is a newly issued card with its full number. The number is
+// returned only once and cannot be read back later; the CVV and activation
+// code are only mailed to the holder.
+type CardIssue struct {
+	*Card
+	PAN string `json:"pan"`
+}
+
+// CardActivationRequest represents the customer's request to activate a card
+type CardActivationRequest struct {
+	ActivationCode string `json:"activation_code"`
+}
+
+// CardLostStolenReport is a card reported lost or stolen with the
+// replacement offered to the customer in its place
+type CardLostStolenReport struct {
+	Card        *Card `json:"card"`
+	Replacement *Card `json:"replacement"`
+}
+
+// CardSearchFilter narrows a staff search for cards by their last four
+// digits. IssuedFrom and IssuedTo are dates, both included.
+type CardSearchFilter struct {
+	PANLast4   string
+	CustomerID *uuid.UUID
+	Status     CardStatus
+	IssuedFrom *time.Time
+	IssuedTo   *time.Time
+}
+
+// CardSearchResult is a card found by staff, with only what is needed to
+// tell it apart from other cards ending in the same digits
+type CardSearchResult struct {
+	CardID        uuid.UUID  `json:"card_id"`
+	CardType      CardType   `json:"card_type"`
+	Last4         string     `json:"last4"`
+	Status        CardStatus `json:"status"`
+	AccountID     uuid.UUID  `json:"account_id"`
+	AccountNumber string     `json:"account_number"`
+	// OwnerName shows the first name and the initial of the last name
+	OwnerName string `json:"owner_name"`
+	// PartialExpiry shows the year of expiry only, as **/YY
+	PartialExpiry string     `json:"partial_expiry"`
+	IssuedAt      *time.Time `json:"issued_at,omitempty"`
+}
+
+// CardAuditEntry records a sensitive action on cards, such as a staff
+// search. CardID is empty for actions on no single card.
+type CardAuditEntry struct {
+	ID      uuid.UUID  `json:"id" db:"id"`
+	CardID  *uuid.UUID `json:"card_id,omitempty" db:"card_id"`
+	Action  string     `json:"action" db:"action"`
+	ActorID uuid.UUID  `json:"actor_id" db:"actor_id"`
+	// Details describes the action, such as the search filters and the number of results
+	Details   string    `json:"details" db:"details"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// CardControls are the limits and switches the customer sets on a card.
+// They can only narrow what the bank allows: the daily limits cannot be set
+// above the bank's limits, which are also their defaults.
+type CardControls struct {
+	CardID               uuid.UUID `json:"card_id" db:"card_id"`
+	ATMDailyLimit        float64   `json:"atm_daily_limit" db:"atm_daily_limit"`
+	POSDailyLimit        float64   `json:"pos_daily_limit" db:"pos_daily_limit"`
+	EcommerceEnabled     bool      `json:"ecommerce_enabled" db:"ecommerce_enabled"`
+	ContactlessEnabled   bool      `json:"contactless_enabled" db:"contactless_enabled"`
+	InternationalEnabled bool      `json:"international_enabled" db:"international_enabled"`
+	// BlockedMerchantCategories lists the four-digit merchant category codes the card is refused at
+	BlockedMerchantCategories []string  `json:"blocked_merchant_categories" db:"blocked_merchant_categories"`
+	UpdatedAt                 time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// BlocksMerchantCategory reports whether the card is refused at merchants of the category
+func (c *CardControls) BlocksMerchantCategory(category string) bool {
+	for _, blocked := range c.BlockedMerchantCategories {
+		if blocked == category {
+			return true
+		}
+	}
+	return false
+}
+
+// CardControlsRequest represents a partial update of card controls. Fields
+// that are not sent are left unchanged; blocked_merchant_categories
+// replaces the whole list.
+type CardControlsRequest struct {
+	ATMDailyLimit             *float64  `json:"atm_daily_limit"`
+	POSDailyLimit             *float64  `json:"pos_daily_limit"`
+	EcommerceEnabled          *bool     `json:"ecommerce_enabled"`
+	ContactlessEnabled        *bool     `json:"contactless_enabled"`
+	InternationalEnabled      *bool     `json:"international_enabled"`
+	BlockedMerchantCategories *[]string `json:"blocked_merchant_categories"`
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CardChannel is where a card transaction is made
+type CardChannel string
+
+const (
+	// CardChannelPOS is a purchase at a merchant's card terminal
+	CardChannelPOS CardChannel = "pos"
+	// CardChannelATM is a cash withdrawal at an ATM
+	CardChannelATM CardChannel = "atm"
+	// CardChannelEcommerce is a purchase online, without the card present
+	CardChannelEcommerce CardChannel = "ecommerce"
+)
+
+// Valid reports whether c is a known channel
+func (c CardChannel) Valid() bool {
+	return c == CardChannelPOS || c == CardChannelATM || c == CardChannelEcommerce
+}
+
+// CardAuthorizationStatus represents the lifecycle status of a card authorization
+type CardAuthorizationStatus string
+
+const (
+	// CardAuthorizationApproved indicates the amount is held on the account until the transaction clears
+	CardAuthorizationApproved CardAuthorizationStatus = "approved"
+	// CardAuthorizationDeclined indicates the transaction was refused; nothing is held
+	CardAuthorizationDeclined CardAuthorizationStatus = "declined"
+	// CardAuthorizationCleared indicates the clearing message was received and the hold captured
+	CardAuthorizationCleared CardAuthorizationStatus = "cleared"
+)
+
+// PINResult is the outcome of the PIN check made by the terminal or the
+// network, as carried in an authorization request
+type PINResult string
+
+const (
+	// PINNotEntered indicates no PIN was asked for
+	PINNotEntered PINResult = ""
+	// PINVerified indicates the cardholder entered the right PIN
+	PINVerified PINResult = "verified"
+	// PINFailed indicates the cardholder entered a wrong PIN
+	PINFailed PINResult = "failed"
+)
+
+// CardAuthorizationRequest is an authorization request from the card
+// network, modelled on an ISO 8583 0100 message. Amounts are in the
+// currency of the card's account.
+type CardAuthorizationRequest struct {
+	// RRN is the retrieval reference number that ties the clearing message to the authorization
+	RRN    string  `json:"rrn"`
+	PAN    string  `json:"pan"`
+	Amount float64 `json:"amount"`
+	// Expiry is the expiry date read from the card, as MMYY, when present
+	Expiry           string      `json:"expiry,omitempty"`
+	MerchantCategory string      `json:"merchant_category"`
+	MerchantName     string      `json:"merchant_name,omitempty"`
+	Country          string      `json:"country"`
+	Channel          CardChannel `json:"channel"`
+	// Contactless is set for a purchase made by tapping the card on a terminal
+	Contactless bool      `json:"contactless,omitempty"`
+	PINResult   PINResult `json:"pin_result,omitempty"`
+	CVV         string    `json:"cvv,omitempty"`
+}
+
+// CardClearingRequest is the clearing message of an approved authorization,
+// modelled on an ISO 8583 1240 message. The amount may differ from the
+// authorized amount, for example after a tip.
+type CardClearingRequest struct {
+	RRN    string  `json:"rrn"`
+	Amount float64 `json:"amount"`
+}
+
+// CardAuthorization is the bank's answer to an authorization request and
+// its clearing. CardID and AccountID are empty when the card number is unknown.
+type CardAuthorization struct {
+	ID               uuid.UUID               `json:"id" db:"id"`
+	RRN              string                  `json:"rrn" db:"rrn"`
+	CardID           *uuid.UUID              `json:"card_id,omitempty" db:"card_id"`
+	AccountID        *uuid.UUID              `json:"account_id,omitempty" db:"account_id"`
+	Amount           float64                 `json:"amount" db:"amount"`
+	MerchantCategory string                  `json:"merchant_category" db:"merchant_category"`
+	MerchantName     string                  `json:"merchant_name,omitempty" db:"merchant_name"`
+	Country          string                  `json:"country" db:"country"`
+	Channel          CardChannel             `json:"channel" db:"channel"`
+	Status           CardAuthorizationStatus `json:"status" db:"status"`
+	// ResponseCode is the ISO 8583 response code; 00 is approved
+	ResponseCode  string     `json:"response_code" db:"response_code"`
+	DeclineReason string     `json:"decline_reason,omitempty" db:"decline_reason"`
+	ApprovalCode  string     `json:"approval_code,omitempty" db:"approval_code"`
+	HoldID        *uuid.UUID `json:"hold_id,omitempty" db:"hold_id"`
+	ClearedAmount *float64   `json:"cleared_amount,omitempty" db:"cleared_amount"`
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty" db:"transaction_id"`
+	ClearedAt     *time.Time `json:"cleared_at,omitempty" db:"cleared_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// RiskGrade represents the credit risk grade assigned by the scoring engine
+type RiskGrade string
+
+// CreditAssessment is the result of the automated credit pre-assessment
+// that is stored together with a loan application
+type CreditAssessment struct {
+	PolicyVersion        string    `json:"policy_version"`
+	RiskGrade            RiskGrade `json:"risk_grade"`
+	Score                int       `json:"score"`
+	MonthlyIncome        float64   `json:"monthly_income"`
+	ExistingObligations  float64   `json:"existing_obligations"`
+	ProposedInstallment  float64   `json:"proposed_installment"`
+	DebtServiceRatio     float64   `json:"debt_service_ratio"`
+	RecommendedMaxAmount float64   `json:"recommended_max_amount"`
+	Reasons              []string  `json:"reasons"`
+	AssessedAt           time.Time `json:"assessed_at"`
+}
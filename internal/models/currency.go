@@ -0,0 +1,90 @@
+package models
+
+import (
+	"math"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code
+type Currency string
+
+const (
+	// CurrencyTHB is the Thai baht, the bank's base currency
+	CurrencyTHB Currency = "THB"
+	// CurrencyUSD is the US dollar
+	CurrencyUSD Currency = "USD"
+	// CurrencyEUR is the euro
+	CurrencyEUR Currency = "EUR"
+	// CurrencyJPY is the Japanese yen
+	CurrencyJPY Currency = "JPY"
+	// CurrencyGBP is the pound sterling
+	CurrencyGBP Currency = "GBP"
+	// CurrencySGD is the Singapore dollar
+	CurrencySGD Currency = "SGD"
+	// CurrencyCNY is the Chinese yuan
+	CurrencyCNY Currency = "CNY"
+)
+
+// BaseCurrency is the currency the bank reports in and FX gains and losses are booked in
+const BaseCurrency = CurrencyTHB
+
+// Currencies lists the supported currencies, base currency first
+var Currencies = []Currency{CurrencyTHB, CurrencyUSD, CurrencyEUR, CurrencyJPY, CurrencyGBP, CurrencySGD, CurrencyCNY}
+
+// ParseCurrency returns the currency for a code in any case, and the base
+// currency for an empty code
+func ParseCurrency(code string) (Currency, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return BaseCurrency, true
+	}
+	currency := Currency(code)
+	return currency, currency.IsValid()
+}
+
+// IsValid reports whether c is a supported currency
+func (c Currency) IsValid() bool {
+	for _, currency := range Currencies {
+		if c == currency {
+			return true
+		}
+	}
+	return false
+}
+
+// Decimals returns the number of minor unit digits of the currency
+func (c Currency) Decimals() int {
+	if c == CurrencyJPY {
+		return 0
+	}
+	return 2
+}
+
+// Round rounds an amount to the minor unit of the currency
+func (c Currency) Round(amount float64) float64 {
+	scale := math.Pow10(c.Decimals())
+	return math.Round(amount*scale) / scale
+}
+
+// ValidAmount reports whether amount is positive and has no more decimals
+// than the currency's minor unit
+func (c Currency) ValidAmount(amount float64) bool {
+	scale := math.Pow10(c.Decimals())
+	return amount > 0 && math.Abs(amount*scale-math.Round(amount*scale)) <= 0.000001
+}
+
+// Money is an amount in a currency
+type Money struct {
+	Amount   float64  `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// CurrencyAccount returns the account number of the internal ledger account
+// for a currency. Base currency accounts keep their plain number; the others
+// carry the currency code as a suffix, e.g. GL-CASH-USD.
+func CurrencyAccount(accountNumber string, currency Currency) string {
+	if currency == "" || currency == BaseCurrency {
+		return accountNumber
+	}
+	return accountNumber + "-" + string(currency)
+}
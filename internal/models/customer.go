@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"crypto/subtle"
+	"strings"
+	"time"
+)
 
 // Customer represents a bank customer
 type Customer struct {
@@ -39,3 +43,32 @@ func (c *Customer) ToResponse() CustomerResponse {
 		CreatedAt:   c.CreatedAt,
 	}
 }
+
+// MatchesIdentity checks the ID card and phone numbers a customer re-entered
+// against the ones on record, ignoring spaces, dashes and other formatting
+func (c *Customer) MatchesIdentity(request IdentityVerificationRequest) bool {
+	return sameDigits(c.IDCardNumber, request.IDCardNumber) && sameDigits(c.PhoneNumber, request.PhoneNumber)
+}
+
+func sameDigits(onRecord, entered string) bool {
+	a, b := digits(onRecord), digits(entered)
+	if a == "" || b == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func digits(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// IsDigits reports whether value is not empty and made of ASCII digits only
+func IsDigits(value string) bool {
+	return value != "" && strings.Trim(value, "0123456789") == ""
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoanDocumentType represents the kind of supporting document attached to a loan application
+type LoanDocumentType string
+
+const (
+	// LoanDocumentPayslip is a salary slip
+	LoanDocumentPayslip LoanDocumentType = "payslip"
+	// LoanDocumentBankStatement is a bank account statement
+	LoanDocumentBankStatement LoanDocumentType = "bank_statement"
+	// LoanDocumentIDCopy is a copy of the customer's national ID card
+	LoanDocumentIDCopy LoanDocumentType = "id_copy"
+)
+
+// IsValid reports whether t is a supported document type
+func (t LoanDocumentType) IsValid() bool {
+	switch t {
+	case LoanDocumentPayslip, LoanDocumentBankStatement, LoanDocumentIDCopy:
+		return true
+	}
+	return false
+}
+
+// LoanDocument holds the metadata of a file uploaded for a loan application.
+// The file content itself lives in blob storage under StorageKey.
+type LoanDocument struct {
+	ID             uuid.UUID        `json:"id" db:"id"`
+	ApplicationID  uuid.UUID        `json:"application_id" db:"application_id"`
+	CustomerID     uuid.UUID        `json:"customer_id" db:"customer_id"`
+	DocumentType   LoanDocumentType `json:"document_type" db:"document_type"`
+	FileName       string           `json:"file_name" db:"file_name"`
+	ContentType    string           `json:"content_type" db:"content_type"`
+	SizeBytes      int64            `json:"size_bytes" db:"size_bytes"`
+	ChecksumSHA256 string           `json:"checksum_sha256" db:"checksum_sha256"`
+	StorageKey     string           `json:"-" db:"storage_key"`
+	UploadedAt     time.Time        `json:"uploaded_at" db:"uploaded_at"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DormancyEventType is a change in the dormancy of an account
+type DormancyEventType string
+
+const (
+	// DormancyMarked records that the dormancy job marked the account dormant
+	DormancyMarked DormancyEventType = "marked_dormant"
+	// DormancyReactivated records that the account was made active again
+	DormancyReactivated DormancyEventType = "reactivated"
+	// DormancyVerificationFailed records a failed in-app identity re-verification
+	DormancyVerificationFailed DormancyEventType = "verification_failed"
+)
+
+// ReactivationMethod is how a dormancy event came about
+type ReactivationMethod string
+
+const (
+	// ReactivationByJob is used for accounts marked dormant by the batch job
+	ReactivationByJob ReactivationMethod = "job"
+	// ReactivationByStaff is used when a staff member reactivated the account
+	ReactivationByStaff ReactivationMethod = "staff"
+	// ReactivationByVerification is used when the customer re-verified their identity in the app
+	ReactivationByVerification ReactivationMethod = "identity_verification"
+)
+
+// DormancyEvent records a dormancy change of an account
+type DormancyEvent struct {
+	ID        uuid.UUID          `json:"id" db:"id"`
+	AccountID uuid.UUID          `json:"account_id" db:"account_id"`
+	Event     DormancyEventType  `json:"event" db:"event"`
+	Method    ReactivationMethod `json:"method" db:"method"`
+	ActorID   *uuid.UUID         `json:"actor_id,omitempty" db:"actor_id"`
+	Reason    string             `json:"reason,omitempty" db:"reason"`
+	CreatedAt time.Time          `json:"created_at" db:"created_at"`
+}
+
+// StaffReactivationRequest represents the staff request to reactivate a dormant account
+type StaffReactivationRequest struct {
+	Reason string `json:"reason"`
+}
+
+// IdentityVerificationRequest carries the details a customer re-enters to reactivate a dormant account
+type IdentityVerificationRequest struct {
+	IDCardNumber string `json:"id_card_number"`
+	PhoneNumber  string `json:"phone_number"`
+}
+
+// DormancyCandidate is an account that becomes dormant soon unless the customer uses it
+type DormancyCandidate struct {
+	AccountID      uuid.UUID   `json:"account_id"`
+	AccountNumber  string      `json:"account_number"`
+	AccountType    AccountType `json:"account_type"`
+	CustomerID     *uuid.UUID  `json:"customer_id"`
+	Balance        float64     `json:"balance"`
+	LastActivityAt time.Time   `json:"last_activity_at"`
+	DormantFrom    time.Time   `json:"dormant_from"`
+	DaysRemaining  int         `json:"days_remaining"`
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FixedDepositTerms lists the terms, in months, a fixed deposit can be placed for
+var FixedDepositTerms = []int{3, 6, 12, 24}
+
+// IsValidFixedDepositTerm reports whether months is a supported fixed deposit term
+func IsValidFixedDepositTerm(months int) bool {
+	for _, term := range FixedDepositTerms {
+		if term == months {
+			return true
+		}
+	}
+	return false
+}
+
+// MaturityInstruction tells the bank what to do when a fixed deposit matures
+type MaturityInstruction string
+
+const (
+	// MaturityAutoRenew renews principal plus interest for another term
+	MaturityAutoRenew MaturityInstruction = "auto_renew"
+	// MaturityTransferToSavings transfers principal plus interest to the linked account
+	MaturityTransferToSavings MaturityInstruction = "transfer_to_savings"
+	// MaturityPayoutInterest pays the interest to the linked account and renews the principal
+	MaturityPayoutInterest MaturityInstruction = "payout_interest"
+)
+
+// IsValid reports whether i is a supported maturity instruction
+func (i MaturityInstruction) IsValid() bool {
+	switch i {
+	case MaturityAutoRenew, MaturityTransferToSavings, MaturityPayoutInterest:
+		return true
+	}
+	return false
+}
+
+// FixedDepositStatus represents the status of a fixed deposit placement
+type FixedDepositStatus string
+
+const (
+	// FixedDepositActive indicates the deposit is running its term
+	FixedDepositActive FixedDepositStatus = "active"
+	// FixedDepositMatured indicates the deposit matured and was paid out
+	FixedDepositMatured FixedDepositStatus = "matured"
+	// FixedDepositWithdrawn indicates the deposit was withdrawn before maturity
+	FixedDepositWithdrawn FixedDepositStatus = "withdrawn"
+)
+
+// FixedDeposit holds the term details of a fixed deposit account.
+// The rate is locked when the term starts.
+type FixedDeposit struct {
+	AccountID           uuid.UUID           `json:"account_id" db:"account_id"`
+	Principal           float64             `json:"principal" db:"principal"`
+	TermMonths          int                 `json:"term_months" db:"term_months"`
+	AnnualRate          float64             `json:"annual_rate" db:"annual_rate"`
+	StartDate           time.Time           `json:"start_date" db:"start_date"`
+	MaturityDate        time.Time           `json:"maturity_date" db:"maturity_date"`
+	MaturityInstruction MaturityInstruction `json:"maturity_instruction" db:"maturity_instruction"`
+	LinkedAccountID     uuid.UUID           `json:"linked_account_id" db:"linked_account_id"`
+	Status              FixedDepositStatus  `json:"status" db:"status"`
+	Renewals            int                 `json:"renewals" db:"renewals"`
+	UpdatedAt           time.Time           `json:"updated_at" db:"updated_at"`
+}
+
+// OpenFixedDepositRequest represents the customer request to place a fixed deposit
+type OpenFixedDepositRequest struct {
+	ProductCode         string              `json:"product_code"`
+	Amount              float64             `json:"amount"`
+	TermMonths          int                 `json:"term_months"`
+	MaturityInstruction MaturityInstruction `json:"maturity_instruction"`
+	FundingAccountID    uuid.UUID           `json:"funding_account_id"`
+	LinkedAccountID     *uuid.UUID          `json:"linked_account_id,omitempty"`
+}
+
+// FixedDepositResponse combines a fixed deposit account with its term details
+type FixedDepositResponse struct {
+	Account      *Account      `json:"account"`
+	FixedDeposit *FixedDeposit `json:"fixed_deposit"`
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FXRate is the bank's rate for one unit of a foreign currency in the base
+// currency. The bank buys the currency from customers below the mid rate and
+// sells it to them above.
+type FXRate struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Currency    Currency  `json:"currency" db:"currency"`
+	MidRate     float64   `json:"mid_rate" db:"mid_rate"`
+	BuySpread   float64   `json:"buy_spread" db:"buy_spread"`
+	SellSpread  float64   `json:"sell_spread" db:"sell_spread"`
+	BuyRate     float64   `json:"buy_rate" db:"-"`
+	SellRate    float64   `json:"sell_rate" db:"-"`
+	EffectiveAt time.Time `json:"effective_at" db:"effective_at"`
+	UploadedBy  uuid.UUID `json:"uploaded_by" db:"uploaded_by"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// ApplySpreads sets the buy and sell rates from the mid rate and spreads
+func (r *FXRate) ApplySpreads() {
+	r.BuyRate = r.MidRate * (1 - r.BuySpread)
+	r.SellRate = r.MidRate * (1 + r.SellSpread)
+}
+
+// FXRateInput is one row of a staff rate upload
+type FXRateInput struct {
+	Currency   string  `json:"currency"`
+	MidRate    float64 `json:"mid_rate"`
+	BuySpread  float64 `json:"buy_spread"`
+	SellSpread float64 `json:"sell_spread"`
+}
+
+// FXRateUploadRequest represents a staff upload of the rate table
+type FXRateUploadRequest struct {
+	// EffectiveAt is when the rates apply from; now when empty
+	EffectiveAt *time.Time    `json:"effective_at,omitempty"`
+	Rates       []FXRateInput `json:"rates"`
+}
+
+// FXQuoteStatus represents the state of a conversion quote
+type FXQuoteStatus string
+
+const (
+	// FXQuoteOpen indicates the quote can still be executed
+	FXQuoteOpen FXQuoteStatus = "open"
+	// FXQuoteExecuted indicates the conversion was posted
+	FXQuoteExecuted FXQuoteStatus = "executed"
+	// FXQuoteExpired indicates the quote was not executed in time
+	FXQuoteExpired FXQuoteStatus = "expired"
+)
+
+// FXQuote is a firm price for converting an amount from one account into
+// another account held in a different currency
+type FXQuote struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	CustomerID    uuid.UUID `json:"customer_id" db:"customer_id"`
+	FromAccountID uuid.UUID `json:"from_account_id" db:"from_account_id"`
+	ToAccountID   uuid.UUID `json:"to_account_id" db:"to_account_id"`
+	From          Money     `json:"from"`
+	To            Money     `json:"to"`
+	// Rate is how many units of the target currency one unit of the source buys
+	Rate float64 `json:"rate" db:"rate"`
+	// FromBaseValue and ToBaseValue are both legs at the mid rate in the base
+	// currency; the difference is the bank's FX gain or loss
+	FromBaseValue float64       `json:"-" db:"from_base_value"`
+	ToBaseValue   float64       `json:"-" db:"to_base_value"`
+	Description   string        `json:"description,omitempty" db:"description"`
+	Status        FXQuoteStatus `json:"status" db:"status"`
+	TransactionID *uuid.UUID    `json:"transaction_id,omitempty" db:"transaction_id"`
+	ExpiresAt     time.Time     `json:"expires_at" db:"expires_at"`
+	CreatedAt     time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// FXQuoteRequest represents the customer's request for a conversion quote
+type FXQuoteRequest struct {
+	ToAccountNumber string  `json:"to_account_number"`
+	Amount          float64 `json:"amount"`
+	Description     string  `json:"description,omitempty"`
+}
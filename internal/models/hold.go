@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HoldStatus represents the lifecycle status of a hold
+type HoldStatus string
+
+const (
+	// HoldActive indicates the hold is reserving funds
+	HoldActive HoldStatus = "active"
+	// HoldReleased indicates the hold was released without posting
+	HoldReleased HoldStatus = "released"
+	// HoldCaptured indicates the held funds were posted to the ledger
+	HoldCaptured HoldStatus = "captured"
+	// HoldExpired indicates the hold was released by the sweeper after its expiry
+	HoldExpired HoldStatus = "expired"
+)
+
+// HoldSource identifies what placed a hold
+type HoldSource string
+
+const (
+	// HoldSourceStaff is a hold placed manually by staff
+	HoldSourceStaff HoldSource = "staff"
+	// HoldSourceCardAuthorization reserves an approved card authorization until clearing
+	HoldSourceCardAuthorization HoldSource = "card_authorization"
+	// HoldSourceTransfer reserves funds for a transfer that is not yet posted
+	HoldSourceTransfer HoldSource = "transfer"
+)
+
+// Hold reserves part of an account balance without posting it. Active holds
+// reduce the available balance until they are released, captured or expire.
+type Hold struct {
+	ID                    uuid.UUID  `json:"id" db:"id"`
+	AccountID             uuid.UUID  `json:"account_id" db:"account_id"`
+	Amount                float64    `json:"amount" db:"amount"`
+	Reason                string     `json:"reason" db:"reason"`
+	Source                HoldSource `json:"source" db:"source"`
+	Reference             string     `json:"reference,omitempty" db:"reference"`
+	Status                HoldStatus `json:"status" db:"status"`
+	ExpiresAt             time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedBy             *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CapturedTransactionID *uuid.UUID `json:"captured_transaction_id,omitempty" db:"captured_transaction_id"`
+	CreatedAt             time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// PlaceHoldRequest represents the staff request to place a hold
+type PlaceHoldRequest struct {
+	Amount    float64   `json:"amount"`
+	Reason    string    `json:"reason"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CaptureHoldRequest represents the staff request to post held funds. The
+// amount defaults to the held amount and the funds go to the suspense account
+// unless another account is given.
+type CaptureHoldRequest struct {
+	Amount          float64    `json:"amount"`
+	CreditAccountID *uuid.UUID `json:"credit_account_id,omitempty"`
+	Description     string     `json:"description"`
+}
+
+// AccountBalance is the balance breakdown of an account
+type AccountBalance struct {
+	AccountID        uuid.UUID `json:"account_id"`
+	LedgerBalance    float64   `json:"ledger_balance"`
+	HeldAmount       float64   `json:"held_amount"`
+	AvailableBalance float64   `json:"available_balance"`
+}
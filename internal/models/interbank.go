@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InterbankTransfer is an outbound transfer to an account at another bank.
+// The customer is debited into the clearing account when it is made; the
+// switch answers later, and the transfer is then settled or returned.
+type InterbankTransfer struct {
+	ID              uuid.UUID `json:"id" db:"id"`
+	CustomerID      uuid.UUID `json:"customer_id" db:"customer_id"`
+	FromAccountID   uuid.UUID `json:"from_account_id" db:"from_account_id"`
+	ToBankCode      string    `json:"to_bank_code" db:"to_bank_code"`
+	ToAccountNumber string    `json:"to_account_number" db:"to_account_number"`
+	Amount          float64   `json:"amount" db:"amount"`
+	Description     string    `json:"description,omitempty" db:"description"`
+	// Status is pending until the switch settles or returns the transfer
+	Status LedgerTransactionStatus `json:"status" db:"status"`
+	// TransactionID is the debit of the customer account
+	TransactionID uuid.UUID `json:"transaction_id" db:"transaction_id"`
+	// ResolutionTransactionID moves the funds out of the clearing account,
+	// to the settlement account or back to the customer
+	ResolutionTransactionID *uuid.UUID `json:"resolution_transaction_id,omitempty" db:"resolution_transaction_id"`
+	ReturnReason            string     `json:"return_reason,omitempty" db:"return_reason"`
+	SubmittedAt             *time.Time `json:"submitted_at,omitempty" db:"submitted_at"`
+	ResolvedAt              *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+	CreatedAt               time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt               time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// InterbankTransferRequest represents the customer's request to pay an account at another bank
+type InterbankTransferRequest struct {
+	ToBankCode      string  `json:"to_bank_code"`
+	ToAccountNumber string  `json:"to_account_number"`
+	Amount          float64 `json:"amount"`
+	Description     string  `json:"description,omitempty"`
+	// Reference is the client key for the request; a key already used on the account is refused
+	Reference string `json:"reference,omitempty"`
+}
+
+// TransactionDetails is a ledger transaction as a customer sees it: the
+// entries on their own accounts and, for an interbank transfer, its progress
+type TransactionDetails struct {
+	ID           uuid.UUID               `json:"id"`
+	Reference    string                  `json:"reference"`
+	Type         LedgerTransactionType   `json:"type"`
+	Description  string                  `json:"description"`
+	Status       LedgerTransactionStatus `json:"status"`
+	BusinessDate time.Time               `json:"business_date"`
+	CreatedAt    time.Time               `json:"created_at"`
+	Entries      []LedgerEntry           `json:"entries"`
+	Interbank    *InterbankTransfer      `json:"interbank,omitempty"`
+}
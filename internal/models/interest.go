@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InterestTier is a band of an interest rate table. The rate applies to the
+// part of the balance at or above MinBalance and below the next tier.
+type InterestTier struct {
+	MinBalance float64 `json:"min_balance"`
+	AnnualRate float64 `json:"annual_rate"`
+}
+
+// InterestAccrual is the interest earned by an account for one day.
+// Amount is kept unrounded; rounding happens when accruals are posted.
+type InterestAccrual struct {
+	ID              uuid.UUID `json:"id" db:"id"`
+	AccountID       uuid.UUID `json:"account_id" db:"account_id"`
+	AccrualDate     time.Time `json:"accrual_date" db:"accrual_date"`
+	Balance         float64   `json:"balance" db:"balance"`
+	Amount          float64   `json:"amount" db:"amount"`
+	PostedReference *string   `json:"posted_reference,omitempty" db:"posted_reference"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// BatchReplayRequest represents the staff request to replay a batch job over a date range
+type BatchReplayRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
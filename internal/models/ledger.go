@@ -0,0 +1,158 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EntryDirection is the side of a ledger entry
+type EntryDirection string
+
+const (
+	// EntryDebit decreases the balance of a deposit account
+	EntryDebit EntryDirection = "debit"
+	// EntryCredit increases the balance of a deposit account
+	EntryCredit EntryDirection = "credit"
+)
+
+// LedgerTransactionType classifies a ledger transaction
+type LedgerTransactionType string
+
+const (
+	// LedgerInterestCapitalization posts accrued savings interest net of withholding tax
+	LedgerInterestCapitalization LedgerTransactionType = "interest_capitalization"
+	// LedgerCashDeposit is cash paid into an account at a branch counter
+	LedgerCashDeposit LedgerTransactionType = "cash_deposit"
+	// LedgerFixedDepositPlacement moves funds from a savings account into a fixed deposit
+	LedgerFixedDepositPlacement LedgerTransactionType = "fixed_deposit_placement"
+	// LedgerFixedDepositInterest posts fixed deposit interest net of withholding tax
+	LedgerFixedDepositInterest LedgerTransactionType = "fixed_deposit_interest"
+	// LedgerFixedDepositPayout moves matured or withdrawn funds to the linked account
+	LedgerFixedDepositPayout LedgerTransactionType = "fixed_deposit_payout"
+	// LedgerClosureTransfer moves the remaining balance of an account being closed
+	LedgerClosureTransfer LedgerTransactionType = "closure_transfer"
+	// LedgerTransfer moves funds between two customer accounts
+	LedgerTransfer LedgerTransactionType = "transfer"
+	// LedgerWithdrawal pays out cash from a customer account
+	LedgerWithdrawal LedgerTransactionType = "withdrawal"
+	// LedgerHoldCapture posts funds that were reserved by a hold
+	LedgerHoldCapture LedgerTransactionType = "hold_capture"
+	// LedgerFXConversion converts funds between accounts held in different currencies
+	LedgerFXConversion LedgerTransactionType = "fx_conversion"
+	// LedgerInterbankTransfer sends funds from a customer account to another bank through the switch
+	LedgerInterbankTransfer LedgerTransactionType = "interbank_transfer"
+	// LedgerInterbankRefund gives back an interbank transfer the switch did not complete
+	LedgerInterbankRefund LedgerTransactionType = "interbank_refund"
+	// LedgerInterbankSettlement moves a settled interbank transfer out of the clearing account
+	LedgerInterbankSettlement LedgerTransactionType = "interbank_settlement"
+	// LedgerCardTransaction posts a cleared card purchase or withdrawal to the card settlement account
+	LedgerCardTransaction LedgerTransactionType = "card_transaction"
+	// LedgerAccountFee charges a product fee to a customer account
+	LedgerAccountFee LedgerTransactionType = "account_fee"
+)
+
+// LedgerTransactionStatus is the lifecycle state of a ledger transaction.
+// Almost every transaction is final when posted; an interbank transfer stays
+// pending in the clearing account until the switch settles or returns it.
+type LedgerTransactionStatus string
+
+const (
+	// LedgerPending indicates the funds left the customer account but have not reached the other bank
+	LedgerPending LedgerTransactionStatus = "pending"
+	// LedgerSettled indicates the transaction is final
+	LedgerSettled LedgerTransactionStatus = "settled"
+	// LedgerReturned indicates the transaction failed downstream and was given back by a later transaction
+	LedgerReturned LedgerTransactionStatus = "returned"
+)
+
+// CustomerInitiated reports whether transactions of this type are made by the
+// customer, which counts as activity on the accounts they debit
+func (t LedgerTransactionType) CustomerInitiated() bool {
+	switch t {
+	case LedgerTransfer, LedgerWithdrawal, LedgerFixedDepositPlacement, LedgerHoldCapture, LedgerFXConversion, LedgerInterbankTransfer,
+		LedgerCardTransaction:
+		return true
+	}
+	return false
+}
+
+// WithdrawalTypes are the transaction types to which the withdrawal rules
+// of the product apply on the accounts they debit
+var WithdrawalTypes = []LedgerTransactionType{LedgerTransfer, LedgerWithdrawal, LedgerFXConversion, LedgerInterbankTransfer}
+
+// Withdraws reports whether the type is one of WithdrawalTypes
+func (t LedgerTransactionType) Withdraws() bool {
+	for _, withdrawal := range WithdrawalTypes {
+		if t == withdrawal {
+			return true
+		}
+	}
+	return false
+}
+
+// LedgerTransaction is a balanced set of ledger entries posted atomically.
+// Reference is unique, so posting the same reference twice is rejected.
+type LedgerTransaction struct {
+	ID           uuid.UUID             `json:"id" db:"id"`
+	Reference    string                `json:"reference" db:"reference"`
+	Type         LedgerTransactionType `json:"type" db:"type"`
+	Description  string                `json:"description" db:"description"`
+	BusinessDate time.Time             `json:"business_date" db:"business_date"`
+	CreatedAt    time.Time             `json:"created_at" db:"created_at"`
+	Entries      []LedgerEntry         `json:"entries"`
+	// Status is where the transaction is in its lifecycle; the ledger posts
+	// it as settled when empty
+	Status LedgerTransactionStatus `json:"status" db:"status"`
+	// CapturesHoldID is the hold this transaction consumes. The hold is
+	// captured in the same database transaction, so its amount no longer
+	// reduces the available balance when the debit is checked.
+	CapturesHoldID *uuid.UUID `json:"-"`
+	// OpensFixedDeposit is the fixed deposit a placement funds. It is
+	// created in the same database transaction, so the placement is never
+	// posted without its deposit.
+	OpensFixedDeposit *FixedDeposit `json:"-"`
+}
+
+// LedgerEntry is one side of a ledger transaction on a single account, in
+// the account's currency. The ledger fills in Currency when it is empty.
+type LedgerEntry struct {
+	ID            uuid.UUID      `json:"id" db:"id"`
+	TransactionID uuid.UUID      `json:"transaction_id" db:"transaction_id"`
+	AccountID     uuid.UUID      `json:"account_id" db:"account_id"`
+	Direction     EntryDirection `json:"direction" db:"direction"`
+	Amount        float64        `json:"amount" db:"amount"`
+	Currency      Currency       `json:"currency" db:"currency"`
+	BalanceAfter  float64        `json:"balance_after" db:"balance_after"`
+	CreatedAt     time.Time      `json:"created_at" db:"created_at"`
+}
+
+// SignedAmount returns the effect of the entry on the account balance
+func (e *LedgerEntry) SignedAmount() float64 {
+	if e.Direction == EntryDebit {
+		return -e.Amount
+	}
+	return e.Amount
+}
+
+// AccountTransaction is a ledger entry on an account together with the
+// transaction it belongs to, as shown in the account's transaction history
+type AccountTransaction struct {
+	TransactionID uuid.UUID               `json:"transaction_id" db:"transaction_id"`
+	Reference     string                  `json:"reference" db:"reference"`
+	Type          LedgerTransactionType   `json:"type" db:"type"`
+	Description   string                  `json:"description" db:"description"`
+	Status        LedgerTransactionStatus `json:"status" db:"status"`
+	BusinessDate  time.Time               `json:"business_date" db:"business_date"`
+	Direction     EntryDirection          `json:"direction" db:"direction"`
+	Amount        float64                 `json:"amount" db:"amount"`
+	CreatedAt     time.Time               `json:"created_at" db:"created_at"`
+}
+
+// SignedAmount returns the effect of the transaction on the account balance
+func (t *AccountTransaction) SignedAmount() float64 {
+	if t.Direction == EntryDebit {
+		return -t.Amount
+	}
+	return t.Amount
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CustomerTier is the service level of a customer, which sets their transaction limits
+type CustomerTier string
+
+const (
+	// TierStandard is the tier of every customer not assigned another one
+	TierStandard CustomerTier = "standard"
+	// TierPremium is the tier of premium banking customers
+	TierPremium CustomerTier = "premium"
+	// TierPrivate is the tier of private banking customers
+	TierPrivate CustomerTier = "private"
+)
+
+// IsValid checks if the tier is one of the supported tiers
+func (t CustomerTier) IsValid() bool {
+	switch t {
+	case TierStandard, TierPremium, TierPrivate:
+		return true
+	}
+	return false
+}
+
+// Channel is where a customer initiated a payment
+type Channel string
+
+const (
+	// ChannelMobile is the mobile banking app
+	ChannelMobile Channel = "mobile"
+	// ChannelATM is an ATM
+	ChannelATM Channel = "atm"
+	// ChannelBranch is a branch counter
+	ChannelBranch Channel = "branch"
+)
+
+// Channels lists the supported channels
+var Channels = []Channel{ChannelMobile, ChannelATM, ChannelBranch}
+
+// IsValid checks if the channel is one of the supported channels
+func (c Channel) IsValid() bool {
+	for _, channel := range Channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// LimitType is the kind of payment a transaction limit applies to
+type LimitType string
+
+const (
+	// LimitTransfer applies to transfers to other accounts
+	LimitTransfer LimitType = "transfer"
+	// LimitWithdrawal applies to cash withdrawals
+	LimitWithdrawal LimitType = "withdrawal"
+)
+
+// LimitTypes lists the supported limit types
+var LimitTypes = []LimitType{LimitTransfer, LimitWithdrawal}
+
+// IsValid checks if the limit type is one of the supported types
+func (t LimitType) IsValid() bool {
+	return t == LimitTransfer || t == LimitWithdrawal
+}
+
+// CustomerLimit is a limit a customer set below the limit of their tier
+type CustomerLimit struct {
+	CustomerID     uuid.UUID `json:"customer_id" db:"customer_id"`
+	Channel        Channel   `json:"channel" db:"channel"`
+	Type           LimitType `json:"type" db:"limit_type"`
+	PerTransaction float64   `json:"per_transaction" db:"per_transaction"`
+	Daily          float64   `json:"daily" db:"daily"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// TransactionLimit is the limit in force for a customer, channel and type,
+// with today's usage. Amounts are in the base currency.
+type TransactionLimit struct {
+	Channel            Channel   `json:"channel"`
+	Type               LimitType `json:"type"`
+	PerTransaction     float64   `json:"per_transaction"`
+	Daily              float64   `json:"daily"`
+	TierPerTransaction float64   `json:"tier_per_transaction"`
+	TierDaily          float64   `json:"tier_daily"`
+	UsedToday          float64   `json:"used_today"`
+	RemainingToday     float64   `json:"remaining_today"`
+}
+
+// CustomerLimitsResponse lists a customer's limits
+type CustomerLimitsResponse struct {
+	Tier   CustomerTier        `json:"tier"`
+	Limits []*TransactionLimit `json:"limits"`
+}
+
+// LimitUpdateRequest represents a customer's change to one of their limits.
+// Raising a limit requires the customer to verify their identity again.
+type LimitUpdateRequest struct {
+	Channel        Channel                      `json:"channel"`
+	Type           LimitType                    `json:"type"`
+	PerTransaction float64                      `json:"per_transaction"`
+	Daily          float64                      `json:"daily"`
+	Verification   *IdentityVerificationRequest `json:"verification,omitempty"`
+}
+
+// CustomerTierRequest represents the staff request to change a customer's tier
+type CustomerTierRequest struct {
+	Tier CustomerTier `json:"tier"`
+}
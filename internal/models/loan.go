@@ -22,15 +22,16 @@ const (
 
 // LoanApplication represents a personal loan application
 type LoanApplication struct {
-	ID              uuid.UUID             `json:"id" db:"id"`
-	CustomerID      uuid.UUID             `json:"customer_id" db:"customer_id"`
-	AmountRequested float64               `json:"amount_requested" db:"amount_requested"`
-	Purpose         string                `json:"purpose" db:"purpose"`
-	IncomeDetails   IncomeDetails         `json:"income_details" db:"income_details"`
-	Status          LoanApplicationStatus `json:"status" db:"status"`
-	CreatedAt       time.Time             `json:"created_at" db:"created_at"`
-	UpdatedAt       time.Time             `json:"updated_at" db:"updated_at"`
-	StatusReason    string                `json:"status_reason,omitempty" db:"status_reason"`
+	ID               uuid.UUID             `json:"id" db:"id"`
+	CustomerID       uuid.UUID             `json:"customer_id" db:"customer_id"`
+	AmountRequested  float64               `json:"amount_requested" db:"amount_requested"`
+	Purpose          string                `json:"purpose" db:"purpose"`
+	IncomeDetails    IncomeDetails         `json:"income_details" db:"income_details"`
+	Status           LoanApplicationStatus `json:"status" db:"status"`
+	CreatedAt        time.Time             `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time             `json:"updated_at" db:"updated_at"`
+	StatusReason     string                `json:"status_reason,omitempty" db:"status_reason"`
+	CreditAssessment *CreditAssessment     `json:"credit_assessment,omitempty" db:"credit_assessment"`
 }
 
 // IncomeDetails contains information about the customer's income
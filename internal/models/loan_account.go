@@ -0,0 +1,149 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoanStatus represents the status of a booked loan
+type LoanStatus string
+
+const (
+	// LoanActive indicates the loan is being repaid
+	LoanActive LoanStatus = "active"
+	// LoanClosed indicates the loan has been fully repaid
+	LoanClosed LoanStatus = "closed"
+)
+
+// InstallmentStatus represents the repayment status of a loan installment
+type InstallmentStatus string
+
+const (
+	// InstallmentPending indicates the installment is not yet due or not yet overdue
+	InstallmentPending InstallmentStatus = "pending"
+	// InstallmentOverdue indicates the due date has passed without full payment
+	InstallmentOverdue InstallmentStatus = "overdue"
+	// InstallmentPaid indicates the installment has been fully paid
+	InstallmentPaid InstallmentStatus = "paid"
+)
+
+// AgingBucket classifies a loan by its days past due (DPD)
+type AgingBucket string
+
+const (
+	// AgingCurrent indicates no installment is past due
+	AgingCurrent AgingBucket = "current"
+	// Aging1To30 indicates 1 to 30 days past due
+	Aging1To30 AgingBucket = "1-30"
+	// Aging31To60 indicates 31 to 60 days past due
+	Aging31To60 AgingBucket = "31-60"
+	// Aging61To90 indicates 61 to 90 days past due
+	Aging61To90 AgingBucket = "61-90"
+	// AgingOver90 indicates more than 90 days past due
+	AgingOver90 AgingBucket = "90+"
+)
+
+// AgingBuckets lists the buckets in reporting order
+var AgingBuckets = []AgingBucket{AgingCurrent, Aging1To30, Aging31To60, Aging61To90, AgingOver90}
+
+// AgingBucketFor returns the bucket for the given days past due
+func AgingBucketFor(daysPastDue int) AgingBucket {
+	switch {
+	case daysPastDue <= 0:
+		return AgingCurrent
+	case daysPastDue <= 30:
+		return Aging1To30
+	case daysPastDue <= 60:
+		return Aging31To60
+	case daysPastDue <= 90:
+		return Aging61To90
+	default:
+		return AgingOver90
+	}
+}
+
+// Loan represents a loan booked from an approved application
+type Loan struct {
+	ID                   uuid.UUID   `json:"id" db:"id"`
+	ApplicationID        uuid.UUID   `json:"application_id" db:"application_id"`
+	CustomerID           uuid.UUID   `json:"customer_id" db:"customer_id"`
+	Principal            float64     `json:"principal" db:"principal"`
+	AnnualRate           float64     `json:"annual_rate" db:"annual_rate"`
+	TenorMonths          int         `json:"tenor_months" db:"tenor_months"`
+	OutstandingPrincipal float64     `json:"outstanding_principal" db:"outstanding_principal"`
+	Status               LoanStatus  `json:"status" db:"status"`
+	DaysPastDue          int         `json:"days_past_due" db:"days_past_due"`
+	AgingBucket          AgingBucket `json:"aging_bucket" db:"aging_bucket"`
+	RepaymentAccountID   *uuid.UUID  `json:"repayment_account_id,omitempty" db:"repayment_account_id"`
+	DisbursedAt          time.Time   `json:"disbursed_at" db:"disbursed_at"`
+	CreatedAt            time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// LoanInstallment is one scheduled repayment of a loan
+type LoanInstallment struct {
+	ID                uuid.UUID         `json:"id" db:"id"`
+	LoanID            uuid.UUID         `json:"loan_id" db:"loan_id"`
+	InstallmentNumber int               `json:"installment_number" db:"installment_number"`
+	DueDate           time.Time         `json:"due_date" db:"due_date"`
+	PrincipalDue      float64           `json:"principal_due" db:"principal_due"`
+	InterestDue       float64           `json:"interest_due" db:"interest_due"`
+	FeesDue           float64           `json:"fees_due" db:"fees_due"`
+	PenaltyDue        float64           `json:"penalty_due" db:"penalty_due"`
+	AmountPaid        float64           `json:"amount_paid" db:"amount_paid"`
+	Status            InstallmentStatus `json:"status" db:"status"`
+	DaysPastDue       int               `json:"days_past_due" db:"days_past_due"`
+}
+
+// OverdueAmount returns the unpaid scheduled principal and interest,
+// which is the base for penalty interest
+func (i *LoanInstallment) OverdueAmount() float64 {
+	unpaid := i.PrincipalDue + i.InterestDue - i.AmountPaid
+	if unpaid < 0 {
+		return 0
+	}
+	return unpaid
+}
+
+// LoanChargeType represents the kind of charge applied to an overdue installment
+type LoanChargeType string
+
+const (
+	// LoanChargeLateFee is the one-off late-payment fee
+	LoanChargeLateFee LoanChargeType = "late_fee"
+	// LoanChargePenaltyInterest is the daily penalty interest
+	LoanChargePenaltyInterest LoanChargeType = "penalty_interest"
+)
+
+// LoanCharge is a fee or penalty applied to an installment on a business date
+type LoanCharge struct {
+	ID            uuid.UUID      `json:"id" db:"id"`
+	LoanID        uuid.UUID      `json:"loan_id" db:"loan_id"`
+	InstallmentID uuid.UUID      `json:"installment_id" db:"installment_id"`
+	ChargeType    LoanChargeType `json:"charge_type" db:"charge_type"`
+	Amount        float64        `json:"amount" db:"amount"`
+	BusinessDate  time.Time      `json:"business_date" db:"business_date"`
+	CreatedAt     time.Time      `json:"created_at" db:"created_at"`
+}
+
+// AgingBucketSummary contains the totals of one aging bucket
+type AgingBucketSummary struct {
+	Bucket           AgingBucket `json:"bucket"`
+	LoanCount        int         `json:"loan_count"`
+	OutstandingTotal float64     `json:"outstanding_total"`
+}
+
+// DelinquencyReport is the staff report of active loans by aging bucket
+type DelinquencyReport struct {
+	AsOf    *time.Time           `json:"as_of,omitempty"`
+	Buckets []AgingBucketSummary `json:"buckets"`
+}
+
+// LoanStatusUpdateRequest represents the staff request to decide on a loan application
+type LoanStatusUpdateRequest struct {
+	Status LoanApplicationStatus `json:"status"`
+	Reason string                `json:"reason"`
+	// RepaymentAccountID is the customer's account installments are collected from
+	RepaymentAccountID *uuid.UUID `json:"repayment_account_id,omitempty"`
+}
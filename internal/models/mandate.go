@@ -0,0 +1,125 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MandateRole is the capacity in which a customer holds an account
+type MandateRole string
+
+const (
+	// MandatePrimary is the customer who opened the account
+	MandatePrimary MandateRole = "primary"
+	// MandateJoint is a co-owner of the account
+	MandateJoint MandateRole = "joint"
+	// MandateSignatory may operate the account without owning it
+	MandateSignatory MandateRole = "authorized_signatory"
+)
+
+// IsValid reports whether r is a supported mandate role
+func (r MandateRole) IsValid() bool {
+	return r == MandatePrimary || r == MandateJoint || r == MandateSignatory
+}
+
+// SigningRule decides who must sign a payment from an account with several holders
+type SigningRule string
+
+const (
+	// SigningEitherOrSurvivor lets any holder make payments alone
+	SigningEitherOrSurvivor SigningRule = "either_or_survivor"
+	// SigningAllMustSign requires every holder to confirm a payment
+	SigningAllMustSign SigningRule = "all_must_sign"
+)
+
+// IsValid reports whether r is a supported signing rule
+func (r SigningRule) IsValid() bool {
+	return r == SigningEitherOrSurvivor || r == SigningAllMustSign
+}
+
+// AccountMandate gives a customer access to an account in a role
+type AccountMandate struct {
+	AccountID  uuid.UUID   `json:"account_id" db:"account_id"`
+	CustomerID uuid.UUID   `json:"customer_id" db:"customer_id"`
+	Role       MandateRole `json:"role" db:"role"`
+	CreatedBy  *uuid.UUID  `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt  time.Time   `json:"created_at" db:"created_at"`
+}
+
+// MandateRequest represents the staff request to add a holder to an account
+type MandateRequest struct {
+	CustomerID uuid.UUID   `json:"customer_id"`
+	Role       MandateRole `json:"role"`
+}
+
+// SigningRuleRequest represents the staff request to change the signing rule of an account
+type SigningRuleRequest struct {
+	SigningRule SigningRule `json:"signing_rule"`
+}
+
+// PendingTransferStatus represents the state of a transfer awaiting co-owner approval
+type PendingTransferStatus string
+
+const (
+	// PendingTransferAwaiting indicates some holders have not confirmed yet
+	PendingTransferAwaiting PendingTransferStatus = "pending"
+	// PendingTransferExecuted indicates every holder confirmed and the money moved
+	PendingTransferExecuted PendingTransferStatus = "executed"
+	// PendingTransferRejected indicates a holder declined the transfer
+	PendingTransferRejected PendingTransferStatus = "rejected"
+	// PendingTransferExpired indicates the holders did not all confirm in time
+	PendingTransferExpired PendingTransferStatus = "expired"
+	// PendingTransferFailed indicates the transfer was confirmed but could not be posted
+	PendingTransferFailed PendingTransferStatus = "failed"
+	// PendingTransferExecuting indicates the last confirmation arrived and the transfer is being posted
+	PendingTransferExecuting PendingTransferStatus = "executing"
+)
+
+// ApprovalDecision is a holder's answer to a pending transfer
+type ApprovalDecision string
+
+const (
+	// ApprovalPending indicates the holder has not answered yet
+	ApprovalPending ApprovalDecision = "pending"
+	// ApprovalApproved indicates the holder confirmed the transfer
+	ApprovalApproved ApprovalDecision = "approved"
+	// ApprovalRejected indicates the holder declined the transfer
+	ApprovalRejected ApprovalDecision = "rejected"
+)
+
+// PendingTransfer is a transfer from an all-must-sign account that waits for
+// every other holder to confirm it
+type PendingTransfer struct {
+	ID              uuid.UUID             `json:"id" db:"id"`
+	AccountID       uuid.UUID             `json:"account_id" db:"account_id"`
+	ToAccountID     uuid.UUID             `json:"to_account_id" db:"to_account_id"`
+	ToAccountNumber string                `json:"to_account_number" db:"to_account_number"`
+	Amount          float64               `json:"amount" db:"amount"`
+	Description     string                `json:"description,omitempty" db:"description"`
+	InitiatedBy     uuid.UUID             `json:"initiated_by" db:"initiated_by"`
+	Status          PendingTransferStatus `json:"status" db:"status"`
+	TransactionID   *uuid.UUID            `json:"transaction_id,omitempty" db:"transaction_id"`
+	FailureReason   string                `json:"failure_reason,omitempty" db:"failure_reason"`
+	ExpiresAt       time.Time             `json:"expires_at" db:"expires_at"`
+	CreatedAt       time.Time             `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time             `json:"updated_at" db:"updated_at"`
+	Approvals       []TransferApproval    `json:"approvals"`
+}
+
+// TransferApproval is one holder's decision on a pending transfer
+type TransferApproval struct {
+	CustomerID uuid.UUID        `json:"customer_id" db:"customer_id"`
+	Decision   ApprovalDecision `json:"decision" db:"decision"`
+	DecidedAt  *time.Time       `json:"decided_at,omitempty" db:"decided_at"`
+}
+
+// Outstanding reports whether any holder still has to answer
+func (p *PendingTransfer) Outstanding() bool {
+	for _, approval := range p.Approvals {
+		if approval.Decision == ApprovalPending {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationType identifies what a customer notification is about
+type NotificationType string
+
+const (
+	// NotificationStandingOrderFailed tells the customer a standing order could not be paid
+	NotificationStandingOrderFailed NotificationType = "standing_order_failed"
+	// NotificationStandingOrderRetrying tells the customer a standing order will be tried again
+	NotificationStandingOrderRetrying NotificationType = "standing_order_retrying"
+	// NotificationBulkPaymentCompleted tells the customer a bulk payment file was executed
+	NotificationBulkPaymentCompleted NotificationType = "bulk_payment_completed"
+	// NotificationCardReplacementOffered tells the customer a replacement is waiting for a card reported lost or stolen
+	NotificationCardReplacementOffered NotificationType = "card_replacement_offered"
+)
+
+// Notification is a message shown in the customer's in-app inbox
+type Notification struct {
+	ID         uuid.UUID        `json:"id" db:"id"`
+	CustomerID uuid.UUID        `json:"customer_id" db:"customer_id"`
+	Type       NotificationType `json:"type" db:"type"`
+	Title      string           `json:"title" db:"title"`
+	Message    string           `json:"message" db:"message"`
+	CreatedAt  time.Time        `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProductStatus represents whether a product can be used to open new accounts
+type ProductStatus string
+
+const (
+	// ProductStatusActive indicates new accounts can be opened on the product
+	ProductStatusActive ProductStatus = "active"
+	// ProductStatusRetired indicates the product is closed to new accounts.
+	// Existing accounts keep the terms of the version they opened under.
+	ProductStatusRetired ProductStatus = "retired"
+)
+
+// ProductFees lists the fees charged on accounts of a product
+type ProductFees struct {
+	// MonthlyMaintenanceFee is charged at the end of every month
+	MonthlyMaintenanceFee float64 `json:"monthly_maintenance_fee"`
+	// BelowMinimumBalanceFee is charged at the end of a month in which the
+	// balance ended below the product's minimum balance
+	BelowMinimumBalanceFee float64 `json:"below_minimum_balance_fee"`
+	// EarlyClosureFee is charged when an account is closed within
+	// EarlyClosureMonths of being opened
+	EarlyClosureFee    float64 `json:"early_closure_fee"`
+	EarlyClosureMonths int     `json:"early_closure_months"`
+}
+
+// WithdrawalLimits restricts withdrawals and outgoing transfers from
+// accounts of a product. Zero means no limit.
+type WithdrawalLimits struct {
+	MaxWithdrawalsPerMonth int     `json:"max_withdrawals_per_month"`
+	DailyWithdrawalLimit   float64 `json:"daily_withdrawal_limit"`
+}
+
+// WithdrawalRules are the product rules on withdrawals from one account,
+// with the withdrawals already made under them
+type WithdrawalRules struct {
+	MinBalance float64
+	Limits     WithdrawalLimits
+	// WithdrawalsThisMonth counts the withdrawals posted in the calendar
+	// month of the business date
+	WithdrawalsThisMonth int
+	// WithdrawnToday is the amount withdrawn on the business date
+	WithdrawnToday float64
+}
+
+// ProductEligibility defines who may open an account on a product.
+// Zero means no restriction.
+type ProductEligibility struct {
+	MaxAccountsPerCustomer int `json:"max_accounts_per_customer"`
+}
+
+// TermRate is the annual interest rate offered for a fixed deposit term
+type TermRate struct {
+	TermMonths int     `json:"term_months"`
+	AnnualRate float64 `json:"annual_rate"`
+}
+
+// AccountProduct is one version of a product in the account catalog.
+// Every change creates a new version; accounts record the version they opened under.
+type AccountProduct struct {
+	ID                  uuid.UUID          `json:"id" db:"id"`
+	Code                string             `json:"code" db:"code"`
+	Version             int                `json:"version" db:"version"`
+	Name                string             `json:"name" db:"name"`
+	Category            AccountType        `json:"category" db:"category"`
+	MinOpeningBalance   float64            `json:"min_opening_balance" db:"min_opening_balance"`
+	MinBalance          float64            `json:"min_balance" db:"min_balance"`
+	Fees                ProductFees        `json:"fees" db:"fees"`
+	InterestTiers       []InterestTier     `json:"interest_tiers" db:"interest_tiers"`
+	WithdrawalLimits    WithdrawalLimits   `json:"withdrawal_limits" db:"withdrawal_limits"`
+	Eligibility         ProductEligibility `json:"eligibility" db:"eligibility"`
+	TermRates           []TermRate         `json:"term_rates,omitempty" db:"term_rates"`
+	EarlyWithdrawalRate float64            `json:"early_withdrawal_rate,omitempty" db:"early_withdrawal_rate"`
+	Status              ProductStatus      `json:"status" db:"status"`
+	CreatedBy           *uuid.UUID         `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt           time.Time          `json:"created_at" db:"created_at"`
+}
+
+// ErrProductNotAvailable is returned when a product cannot be used to open accounts
+var ErrProductNotAvailable = errors.New("product is not available for new accounts")
+
+// CheckOpening validates an account opening against the product rules.
+// existingAccounts is the number of open accounts the customer already holds on the product.
+func (p *AccountProduct) CheckOpening(category AccountType, existingAccounts int) error {
+	if p.Status != ProductStatusActive || p.Category != category {
+		return ErrProductNotAvailable
+	}
+	if p.Eligibility.MaxAccountsPerCustomer > 0 && existingAccounts >= p.Eligibility.MaxAccountsPerCustomer {
+		return fmt.Errorf("a customer may hold at most %d accounts on this product", p.Eligibility.MaxAccountsPerCustomer)
+	}
+	return nil
+}
+
+// ErrBelowOpeningBalance is returned when the deposit that funds an account is below the product's minimum opening balance
+var ErrBelowOpeningBalance = errors.New("first deposit is below the minimum opening balance")
+
+// CheckFirstDeposit validates the deposit that funds an account, which must
+// be at least the minimum opening balance of the product
+func (p *AccountProduct) CheckFirstDeposit(amount float64) error {
+	if amount < p.MinOpeningBalance {
+		return fmt.Errorf("%w of %.2f", ErrBelowOpeningBalance, p.MinOpeningBalance)
+	}
+	return nil
+}
+
+// RateForTerm returns the annual rate the product offers for a fixed deposit term
+func (p *AccountProduct) RateForTerm(termMonths int) (float64, bool) {
+	for _, rate := range p.TermRates {
+		if rate.TermMonths == termMonths {
+			return rate.AnnualRate, true
+		}
+	}
+	return 0, false
+}
+
+// AccountProductRequest represents the staff request to create or change a product
+type AccountProductRequest struct {
+	Code                string             `json:"code"`
+	Name                string             `json:"name"`
+	Category            AccountType        `json:"category"`
+	MinOpeningBalance   float64            `json:"min_opening_balance"`
+	MinBalance          float64            `json:"min_balance"`
+	Fees                ProductFees        `json:"fees"`
+	InterestTiers       []InterestTier     `json:"interest_tiers"`
+	WithdrawalLimits    WithdrawalLimits   `json:"withdrawal_limits"`
+	Eligibility         ProductEligibility `json:"eligibility"`
+	TermRates           []TermRate         `json:"term_rates"`
+	EarlyWithdrawalRate float64            `json:"early_withdrawal_rate"`
+}
+
+// OpenAccountRequest represents the customer request to open an account.
+// Accounts open with a zero balance and are funded by a transfer from
+// another account or a cash deposit at a branch.
+type OpenAccountRequest struct {
+	ProductCode string `json:"product_code"`
+	// Currency of the account; THB when empty
+	Currency string `json:"currency,omitempty"`
+}
+
+// CashDepositRequest represents a cash deposit taken by staff at a branch
+type CashDepositRequest struct {
+	Amount float64 `json:"amount"`
+	// Reference is the teller's key for the deposit; posting the same
+	// reference twice is refused
+	Reference string `json:"reference"`
+}
@@ -0,0 +1,168 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProxyType is the kind of identifier a proxy maps to an account
+type ProxyType string
+
+const (
+	// ProxyMobile is a Thai mobile number, stored as ten digits starting with 0
+	ProxyMobile ProxyType = "mobile"
+	// ProxyNationalID is a 13-digit Thai national ID number
+	ProxyNationalID ProxyType = "national_id"
+)
+
+// IsValid reports whether t is a known proxy type
+func (t ProxyType) IsValid() bool {
+	return t == ProxyMobile || t == ProxyNationalID
+}
+
+// NormalizeProxy strips formatting from a proxy value and checks it is a
+// well-formed mobile number or national ID. Mobile numbers written with the
+// 66 country code are turned into their 0 form.
+func NormalizeProxy(proxyType ProxyType, value string) (string, bool) {
+	value = digits(value)
+	switch proxyType {
+	case ProxyMobile:
+		if len(value) == 11 && strings.HasPrefix(value, "66") {
+			value = "0" + value[2:]
+		}
+		if len(value) != 10 || value[0] != '0' || value[1] == '0' {
+			return "", false
+		}
+		return value, true
+	case ProxyNationalID:
+		if len(value) != 13 {
+			return "", false
+		}
+		// The last digit is a check digit over the first twelve
+		sum := 0
+		for i := 0; i < 12; i++ {
+			sum += int(value[i]-'0') * (13 - i)
+		}
+		if (11-sum%11)%10 != int(value[12]-'0') {
+			return "", false
+		}
+		return value, true
+	}
+	return "", false
+}
+
+// MaskProxy hides all but the last four digits of a proxy value
+func MaskProxy(value string) string {
+	if len(value) <= 4 {
+		return value
+	}
+	return strings.Repeat("x", len(value)-4) + value[len(value)-4:]
+}
+
+// MaskName shows a first name and only the initial of the last name, as a
+// payer sees the recipient before confirming a payment
+func MaskName(firstName, lastName string) string {
+	firstName, lastName = strings.TrimSpace(firstName), strings.TrimSpace(lastName)
+	if lastName == "" {
+		return firstName
+	}
+	initial := []rune(lastName)[0]
+	return strings.TrimSpace(firstName + " " + string(initial) + "***")
+}
+
+// ProxyStatus represents the state of a proxy registration
+type ProxyStatus string
+
+const (
+	// ProxyActive indicates payments to the proxy go to the linked account
+	ProxyActive ProxyStatus = "active"
+	// ProxyDeregistered indicates the customer removed the proxy
+	ProxyDeregistered ProxyStatus = "deregistered"
+)
+
+// ProxyRegistration links a customer's mobile number or national ID to one
+// of their accounts so others can pay them without the account number
+type ProxyRegistration struct {
+	ID             uuid.UUID   `json:"id" db:"id"`
+	ProxyType      ProxyType   `json:"proxy_type" db:"proxy_type"`
+	ProxyValue     string      `json:"proxy_value" db:"proxy_value"`
+	AccountID      uuid.UUID   `json:"account_id" db:"account_id"`
+	CustomerID     uuid.UUID   `json:"customer_id" db:"customer_id"`
+	Status         ProxyStatus `json:"status" db:"status"`
+	ConsentedAt    time.Time   `json:"consented_at" db:"consented_at"`
+	DeregisteredAt *time.Time  `json:"deregistered_at,omitempty" db:"deregistered_at"`
+	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// ProxyRegistrationRequest represents the customer's request to link a proxy to an account
+type ProxyRegistrationRequest struct {
+	ProxyType  ProxyType `json:"proxy_type"`
+	ProxyValue string    `json:"proxy_value"`
+	// Consent must be true: the customer agrees to their masked name being
+	// shown to anyone who pays the proxy
+	Consent bool `json:"consent"`
+}
+
+// ProxyDeregistrationRequest represents the customer's request to remove a proxy
+type ProxyDeregistrationRequest struct {
+	Consent bool `json:"consent"`
+}
+
+// ProxyTransferStatus represents the state of a transfer to a proxy
+type ProxyTransferStatus string
+
+const (
+	// ProxyTransferAwaitingConfirmation indicates the recipient was resolved and the payer has not confirmed yet
+	ProxyTransferAwaitingConfirmation ProxyTransferStatus = "awaiting_confirmation"
+	// ProxyTransferCompleted indicates the money reached the recipient
+	ProxyTransferCompleted ProxyTransferStatus = "completed"
+	// ProxyTransferRejected indicates the recipient bank refused the payment and the payer was refunded
+	ProxyTransferRejected ProxyTransferStatus = "rejected"
+	// ProxyTransferPending indicates the switch did not answer, so whether the
+	// other bank was credited is not known yet. The payer stays debited while
+	// the switch is asked again.
+	ProxyTransferPending ProxyTransferStatus = "pending"
+	// ProxyTransferExpired indicates the payer did not confirm in time
+	ProxyTransferExpired ProxyTransferStatus = "expired"
+)
+
+// ProxyTransfer is a payment to a mobile number or national ID. The proxy is
+// resolved first so the payer can check the masked recipient name, and the
+// money only moves once they confirm.
+type ProxyTransfer struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	CustomerID    uuid.UUID `json:"customer_id" db:"customer_id"`
+	FromAccountID uuid.UUID `json:"from_account_id" db:"from_account_id"`
+	ProxyType     ProxyType `json:"proxy_type" db:"proxy_type"`
+	ProxyValue    string    `json:"proxy_value" db:"proxy_value"`
+	// BankCode is the recipient's bank; it is our own bank code for on-us payments
+	BankCode      string `json:"bank_code" db:"bank_code"`
+	RecipientName string `json:"recipient_name" db:"recipient_name"`
+	// ToAccountID is set when the recipient banks with us
+	ToAccountID     *uuid.UUID          `json:"-" db:"to_account_id"`
+	ToAccountNumber string              `json:"-" db:"to_account_number"`
+	Amount          float64             `json:"amount" db:"amount"`
+	Description     string              `json:"description,omitempty" db:"description"`
+	Status          ProxyTransferStatus `json:"status" db:"status"`
+	TransactionID   *uuid.UUID          `json:"transaction_id,omitempty" db:"transaction_id"`
+	FailureReason   string              `json:"failure_reason,omitempty" db:"failure_reason"`
+	ExpiresAt       time.Time           `json:"expires_at" db:"expires_at"`
+	CreatedAt       time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at" db:"updated_at"`
+}
+
+// OnUs reports whether the recipient's account is held with us
+func (t *ProxyTransfer) OnUs() bool {
+	return t.ToAccountID != nil
+}
+
+// ProxyTransferRequest represents the customer's request to pay a proxy
+type ProxyTransferRequest struct {
+	ProxyType   ProxyType `json:"proxy_type"`
+	ProxyValue  string    `json:"proxy_value"`
+	Amount      float64   `json:"amount"`
+	Description string    `json:"description,omitempty"`
+}
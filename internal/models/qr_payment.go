@@ -0,0 +1,30 @@
+package models
+
+// QRCodeRequest represents the customer's request for a Thai QR code to be paid into an account
+type QRCodeRequest struct {
+	// Amount is fixed in the code when set; otherwise the payer enters it
+	Amount float64 `json:"amount"`
+	// Reference is shown to the payer and sent with the payment, such as an invoice number
+	Reference string `json:"reference"`
+}
+
+// QRCode is a Thai QR code that pays an account
+type QRCode struct {
+	AccountNumber string  `json:"account_number"`
+	Amount        float64 `json:"amount,omitempty"`
+	Reference     string  `json:"reference,omitempty"`
+	// Payload is the EMVCo string encoded in the image
+	Payload string `json:"payload"`
+	// Image is the QR code as a PNG, base64 encoded in JSON
+	Image []byte `json:"image_png"`
+}
+
+// QRPaymentRequest represents the customer's request to pay a scanned Thai QR code
+type QRPaymentRequest struct {
+	Payload string `json:"payload"`
+	// Amount is required when the code does not fix one and must match it when it does
+	Amount      float64 `json:"amount"`
+	Description string  `json:"description"`
+	// Reference is the client's idempotency key, as for transfers
+	Reference string `json:"reference"`
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RestrictionType is the kind of legal or operational restriction on an account
+type RestrictionType string
+
+const (
+	// RestrictionDebitFreeze blocks money leaving the account
+	RestrictionDebitFreeze RestrictionType = "debit_freeze"
+	// RestrictionCreditFreeze blocks money entering the account
+	RestrictionCreditFreeze RestrictionType = "credit_freeze"
+	// RestrictionFullFreeze blocks all postings to the account
+	RestrictionFullFreeze RestrictionType = "full_freeze"
+	// RestrictionGarnishment ring-fences an amount of the balance for a garnishment order
+	RestrictionGarnishment RestrictionType = "garnishment"
+)
+
+// IsValid reports whether t is a supported restriction type
+func (t RestrictionType) IsValid() bool {
+	switch t {
+	case RestrictionDebitFreeze, RestrictionCreditFreeze, RestrictionFullFreeze, RestrictionGarnishment:
+		return true
+	}
+	return false
+}
+
+// BlocksDebits reports whether the restriction stops money leaving the account
+func (t RestrictionType) BlocksDebits() bool {
+	return t == RestrictionDebitFreeze || t == RestrictionFullFreeze
+}
+
+// BlocksCredits reports whether the restriction stops money entering the account
+func (t RestrictionType) BlocksCredits() bool {
+	return t == RestrictionCreditFreeze || t == RestrictionFullFreeze
+}
+
+// Error codes returned when a posting is refused because of a restriction
+const (
+	ErrorCodeDebitFrozen  = "ACCOUNT_DEBIT_FROZEN"
+	ErrorCodeCreditFrozen = "ACCOUNT_CREDIT_FROZEN"
+	ErrorCodeFrozen       = "ACCOUNT_FROZEN"
+	ErrorCodeGarnished    = "ACCOUNT_GARNISHED"
+)
+
+// ErrorCode returns the code reported when the restriction refuses a posting
+func (t RestrictionType) ErrorCode() string {
+	switch t {
+	case RestrictionDebitFreeze:
+		return ErrorCodeDebitFrozen
+	case RestrictionCreditFreeze:
+		return ErrorCodeCreditFrozen
+	case RestrictionGarnishment:
+		return ErrorCodeGarnished
+	}
+	return ErrorCodeFrozen
+}
+
+// RestrictionStatus represents the approval lifecycle of a restriction
+type RestrictionStatus string
+
+const (
+	// RestrictionPendingApproval indicates a restriction waiting for a second staff member
+	RestrictionPendingApproval RestrictionStatus = "pending_approval"
+	// RestrictionActive indicates the restriction is in force
+	RestrictionActive RestrictionStatus = "active"
+	// RestrictionRejected indicates the checker refused to apply the restriction
+	RestrictionRejected RestrictionStatus = "rejected"
+	// RestrictionLiftPending indicates a lift was requested; the restriction stays in force until approved
+	RestrictionLiftPending RestrictionStatus = "lift_pending"
+	// RestrictionLifted indicates the restriction was removed after approval
+	RestrictionLifted RestrictionStatus = "lifted"
+	// RestrictionExpired indicates the restriction reached its expiry
+	RestrictionExpired RestrictionStatus = "expired"
+)
+
+// Restriction is a freeze or garnishment placed on an account by staff.
+// Every change goes through maker-checker approval.
+type Restriction struct {
+	ID              uuid.UUID         `json:"id" db:"id"`
+	AccountID       uuid.UUID         `json:"account_id" db:"account_id"`
+	Type            RestrictionType   `json:"type" db:"type"`
+	Amount          float64           `json:"amount,omitempty" db:"amount"`
+	ReferenceNumber string            `json:"reference_number" db:"reference_number"`
+	Reason          string            `json:"reason" db:"reason"`
+	ExpiresAt       time.Time         `json:"expires_at" db:"expires_at"`
+	Status          RestrictionStatus `json:"status" db:"status"`
+	RequestedBy     uuid.UUID         `json:"requested_by" db:"requested_by"`
+	ApprovedBy      *uuid.UUID        `json:"approved_by,omitempty" db:"approved_by"`
+	LiftRequestedBy *uuid.UUID        `json:"lift_requested_by,omitempty" db:"lift_requested_by"`
+	LiftReason      string            `json:"lift_reason,omitempty" db:"lift_reason"`
+	CreatedAt       time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// InForce reports whether the restriction applies to postings at time now.
+// A restriction whose lift is awaiting approval is still in force.
+func (r *Restriction) InForce(now time.Time) bool {
+	return (r.Status == RestrictionActive || r.Status == RestrictionLiftPending) && r.ExpiresAt.After(now)
+}
+
+// RestrictionAuditEntry records one change to a restriction
+type RestrictionAuditEntry struct {
+	ID            uuid.UUID         `json:"id" db:"id"`
+	RestrictionID uuid.UUID         `json:"restriction_id" db:"restriction_id"`
+	Action        string            `json:"action" db:"action"`
+	FromStatus    RestrictionStatus `json:"from_status,omitempty" db:"from_status"`
+	ToStatus      RestrictionStatus `json:"to_status" db:"to_status"`
+	ActorID       *uuid.UUID        `json:"actor_id,omitempty" db:"actor_id"`
+	Comment       string            `json:"comment,omitempty" db:"comment"`
+	CreatedAt     time.Time         `json:"created_at" db:"created_at"`
+}
+
+// RestrictionRequest represents the staff request to restrict an account
+type RestrictionRequest struct {
+	Type            RestrictionType `json:"type"`
+	Amount          float64         `json:"amount"`
+	ReferenceNumber string          `json:"reference_number"`
+	Reason          string          `json:"reason"`
+	ExpiresAt       time.Time       `json:"expires_at"`
+}
+
+// RestrictionDecisionRequest carries the comment of a checker or the reason for a lift
+type RestrictionDecisionRequest struct {
+	Reason string `json:"reason"`
+}
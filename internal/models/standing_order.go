@@ -0,0 +1,111 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StandingOrderFrequency is how often a standing order runs
+type StandingOrderFrequency string
+
+const (
+	// FrequencyOnce is a single transfer on a future date
+	FrequencyOnce StandingOrderFrequency = "once"
+	// FrequencyDaily runs every day
+	FrequencyDaily StandingOrderFrequency = "daily"
+	// FrequencyWeekly runs every week on the weekday of the start date
+	FrequencyWeekly StandingOrderFrequency = "weekly"
+	// FrequencyMonthly runs every month on DayOfMonth, or on the last day of shorter months
+	FrequencyMonthly StandingOrderFrequency = "monthly"
+	// FrequencyEndOfMonth runs on the last day of every month
+	FrequencyEndOfMonth StandingOrderFrequency = "end_of_month"
+)
+
+// IsValid checks if the frequency is one of the supported frequencies
+func (f StandingOrderFrequency) IsValid() bool {
+	switch f {
+	case FrequencyOnce, FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyEndOfMonth:
+		return true
+	}
+	return false
+}
+
+// StandingOrderStatus represents the state of a standing order
+type StandingOrderStatus string
+
+const (
+	// StandingOrderActive indicates the order runs on its schedule
+	StandingOrderActive StandingOrderStatus = "active"
+	// StandingOrderPaused indicates the customer paused the order
+	StandingOrderPaused StandingOrderStatus = "paused"
+	// StandingOrderCancelled indicates the customer cancelled the order
+	StandingOrderCancelled StandingOrderStatus = "cancelled"
+	// StandingOrderCompleted indicates the order has no runs left
+	StandingOrderCompleted StandingOrderStatus = "completed"
+)
+
+// StandingOrder is a future-dated or recurring transfer from an account
+type StandingOrder struct {
+	ID              uuid.UUID              `json:"id" db:"id"`
+	AccountID       uuid.UUID              `json:"account_id" db:"account_id"`
+	CustomerID      uuid.UUID              `json:"customer_id" db:"customer_id"`
+	ToAccountID     uuid.UUID              `json:"to_account_id" db:"to_account_id"`
+	ToAccountNumber string                 `json:"to_account_number" db:"to_account_number"`
+	Amount          float64                `json:"amount" db:"amount"`
+	Description     string                 `json:"description,omitempty" db:"description"`
+	Frequency       StandingOrderFrequency `json:"frequency" db:"frequency"`
+	DayOfMonth      int                    `json:"day_of_month,omitempty" db:"day_of_month"`
+	StartDate       time.Time              `json:"start_date" db:"start_date"`
+	EndDate         *time.Time             `json:"end_date,omitempty" db:"end_date"`
+	NextRunDate     *time.Time             `json:"next_run_date,omitempty" db:"next_run_date"`
+	// FailedAttempts counts the failed attempts of the next run, which is
+	// retried at RetryAt
+	FailedAttempts int                 `json:"failed_attempts" db:"failed_attempts"`
+	RetryAt        *time.Time          `json:"retry_at,omitempty" db:"retry_at"`
+	Status         StandingOrderStatus `json:"status" db:"status"`
+	CreatedAt      time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at" db:"updated_at"`
+}
+
+// StandingOrderExecutionStatus is the outcome of one attempt of a run
+type StandingOrderExecutionStatus string
+
+const (
+	// ExecutionSucceeded indicates the transfer was posted
+	ExecutionSucceeded StandingOrderExecutionStatus = "succeeded"
+	// ExecutionRetrying indicates the transfer failed and will be tried again
+	ExecutionRetrying StandingOrderExecutionStatus = "retrying"
+	// ExecutionFailed indicates the transfer failed and the run was skipped
+	ExecutionFailed StandingOrderExecutionStatus = "failed"
+)
+
+// StandingOrderExecution records one attempt to run a standing order
+type StandingOrderExecution struct {
+	ID            uuid.UUID                    `json:"id" db:"id"`
+	OrderID       uuid.UUID                    `json:"order_id" db:"order_id"`
+	RunDate       time.Time                    `json:"run_date" db:"run_date"`
+	Attempt       int                          `json:"attempt" db:"attempt"`
+	Status        StandingOrderExecutionStatus `json:"status" db:"status"`
+	TransactionID *uuid.UUID                   `json:"transaction_id,omitempty" db:"transaction_id"`
+	FailureReason string                       `json:"failure_reason,omitempty" db:"failure_reason"`
+	CreatedAt     time.Time                    `json:"created_at" db:"created_at"`
+}
+
+// StandingOrderDetails is a standing order with its execution history
+type StandingOrderDetails struct {
+	StandingOrder
+	Executions []*StandingOrderExecution `json:"executions"`
+}
+
+// StandingOrderRequest represents the customer's request to schedule a
+// transfer. Dates are "2006-01-02"; DayOfMonth is required for monthly orders.
+type StandingOrderRequest struct {
+	ToAccountNumber string                 `json:"to_account_number"`
+	Amount          float64                `json:"amount"`
+	Description     string                 `json:"description"`
+	Frequency       StandingOrderFrequency `json:"frequency"`
+	DayOfMonth      int                    `json:"day_of_month"`
+	StartDate       string                 `json:"start_date"`
+	EndDate         string                 `json:"end_date"`
+}
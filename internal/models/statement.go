@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StatementStatus represents the progress of a statement request
+type StatementStatus string
+
+const (
+	// StatementPending indicates the statement is waiting to be rendered
+	StatementPending StatementStatus = "pending"
+	// StatementProcessing indicates the statement is being rendered
+	StatementProcessing StatementStatus = "processing"
+	// StatementReady indicates the statement files can be downloaded
+	StatementReady StatementStatus = "ready"
+	// StatementFailed indicates the statement could not be rendered
+	StatementFailed StatementStatus = "failed"
+)
+
+// StatementFormat is a file format a statement is rendered in
+type StatementFormat string
+
+const (
+	// StatementFormatPDF is the printable statement
+	StatementFormatPDF StatementFormat = "pdf"
+	// StatementFormatCSV is the spreadsheet statement
+	StatementFormatCSV StatementFormat = "csv"
+)
+
+// IsValid reports whether f is a supported statement format
+func (f StatementFormat) IsValid() bool {
+	return f == StatementFormatPDF || f == StatementFormatCSV
+}
+
+// Statement is a customer's request for an account statement over a date range
+type Statement struct {
+	ID             uuid.UUID       `json:"id" db:"id"`
+	AccountID      uuid.UUID       `json:"account_id" db:"account_id"`
+	CustomerID     uuid.UUID       `json:"customer_id" db:"customer_id"`
+	FromDate       time.Time       `json:"from_date" db:"from_date"`
+	ToDate         time.Time       `json:"to_date" db:"to_date"`
+	Status         StatementStatus `json:"status" db:"status"`
+	OpeningBalance *float64        `json:"opening_balance,omitempty" db:"opening_balance"`
+	ClosingBalance *float64        `json:"closing_balance,omitempty" db:"closing_balance"`
+	PDFKey         string          `json:"-" db:"pdf_key"`
+	CSVKey         string          `json:"-" db:"csv_key"`
+	FailureReason  string          `json:"failure_reason,omitempty" db:"failure_reason"`
+	RequestedAt    time.Time       `json:"requested_at" db:"requested_at"`
+	UpdatedAt      time.Time       `json:"updated_at" db:"updated_at"`
+	CompletedAt    *time.Time      `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// StatementRequest represents the request body for requesting a statement
+type StatementRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// StatementDownload is a short-lived link to a rendered statement file
+type StatementDownload struct {
+	Format    StatementFormat `json:"format"`
+	URL       string          `json:"url"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
+
+// StatementResponse is a statement with download links once it is ready
+type StatementResponse struct {
+	*Statement
+	Downloads []StatementDownload `json:"downloads,omitempty"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TransferRequest represents a customer request to move funds to another account.
+// Reference is an optional client key that makes retries safe.
+type TransferRequest struct {
+	ToAccountNumber string  `json:"to_account_number"`
+	Amount          float64 `json:"amount"`
+	Description     string  `json:"description"`
+	Reference       string  `json:"reference"`
+}
+
+// WithdrawalRequest represents a customer request to withdraw cash
+type WithdrawalRequest struct {
+	Amount    float64 `json:"amount"`
+	Reference string  `json:"reference"`
+}
+
+// TransferResult is the outcome of a posted transfer or withdrawal
+type TransferResult struct {
+	TransactionID    uuid.UUID `json:"transaction_id"`
+	Reference        string    `json:"reference"`
+	FromAccountID    uuid.UUID `json:"from_account_id"`
+	ToAccountID      uuid.UUID `json:"to_account_id"`
+	Amount           float64   `json:"amount"`
+	AvailableBalance float64   `json:"available_balance"`
+	PostedAt         time.Time `json:"posted_at"`
+}
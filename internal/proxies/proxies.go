@@ -0,0 +1,435 @@
+package proxies
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/database"
+	"example.com/m/internal/interbank"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/transfers"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrConsentRequired is returned when the customer did not consent to the registration change
+	ErrConsentRequired = errors.New("consent is required to register or deregister a proxy")
+	// ErrInvalidProxyType is returned for an unknown proxy type
+	ErrInvalidProxyType = errors.New("proxy_type must be mobile or national_id")
+	// ErrInvalidProxyValue is returned for a malformed mobile number or national ID
+	ErrInvalidProxyValue = errors.New("proxy_value is not a valid mobile number or national ID")
+	// ErrNotOwnProxy is returned when the proxy is not the customer's mobile number or national ID on record
+	ErrNotOwnProxy = errors.New("proxy must be your own mobile number or national ID on record")
+	// ErrAccountNotEligible is returned when the account is not an active base currency account
+	ErrAccountNotEligible = errors.New("only active THB accounts can receive payments to a proxy")
+	// ErrProxyTaken is returned when the proxy is already registered to an account
+	ErrProxyTaken = errors.New("proxy is already registered; deregister it first")
+	// ErrProxyNotFound is returned when the registration does not exist for the account or no bank has the proxy
+	ErrProxyNotFound = errors.New("proxy not found")
+	// ErrTransferNotFound is returned when the transfer does not exist for the account
+	ErrTransferNotFound = errors.New("proxy transfer not found")
+	// ErrAlreadyConfirmed is returned when the transfer was already confirmed
+	ErrAlreadyConfirmed = errors.New("proxy transfer was already confirmed")
+	// ErrConfirmationExpired is returned when the transfer was not confirmed in time
+	ErrConfirmationExpired = errors.New("confirmation has expired; look up the proxy again")
+)
+
+// Transferrer posts transfers between accounts
+type Transferrer interface {
+	Transfer(ctx context.Context, input transfers.Input) (*models.TransferResult, error)
+}
+
+// Service keeps the proxy registry and pays proxies. A proxy registered with
+// us is paid by an internal transfer. Any other proxy is resolved through
+// the interbank switch; the payer is debited into the interbank settlement
+// account before the switch is asked to credit the other bank, and is
+// refunded if the other bank rejects the transfer. A transfer the switch did
+// not answer stays pending until Inquire learns its outcome.
+type Service struct {
+	repo         repository.ProxyRepository
+	accountRepo  repository.AccountRepository
+	customerRepo database.CustomerRepositoryInterface
+	transfers    Transferrer
+	switcher     interbank.Switch
+	cfg          config.ProxyConfig
+	bankCode     string
+	now          func() time.Time
+}
+
+// NewService creates a new proxy Service
+func NewService(repo repository.ProxyRepository, accountRepo repository.AccountRepository, customerRepo database.CustomerRepositoryInterface, transferrer Transferrer, switcher interbank.Switch, cfg config.ProxyConfig, bankCode string) *Service {
+	return &Service{
+		repo:         repo,
+		accountRepo:  accountRepo,
+		customerRepo: customerRepo,
+		transfers:    transferrer,
+		switcher:     switcher,
+		cfg:          cfg,
+		bankCode:     bankCode,
+		now:          time.Now,
+	}
+}
+
+// Register links the customer's own mobile number or national ID to the account
+func (s *Service) Register(ctx context.Context, account *models.Account, customerID uuid.UUID, req models.ProxyRegistrationRequest) (*models.ProxyRegistration, error) {
+	if !req.Consent {
+		return nil, ErrConsentRequired
+	}
+	value, err := normalize(req.ProxyType, req.ProxyValue)
+	if err != nil {
+		return nil, err
+	}
+	if account.Status != models.AccountStatusActive || account.IsInternal() ||
+		(account.Currency != "" && account.Currency != models.BaseCurrency) {
+		return nil, ErrAccountNotEligible
+	}
+
+	customer, err := s.customerRepo.GetByID(customerID.String())
+	if err != nil {
+		return nil, err
+	}
+	onRecord := customer.PhoneNumber
+	if req.ProxyType == models.ProxyNationalID {
+		onRecord = customer.IDCardNumber
+	}
+	if own, ok := models.NormalizeProxy(req.ProxyType, onRecord); !ok || own != value {
+		return nil, ErrNotOwnProxy
+	}
+
+	now := s.now()
+	proxy := &models.ProxyRegistration{
+		ID:          uuid.New(),
+		ProxyType:   req.ProxyType,
+		ProxyValue:  value,
+		AccountID:   account.ID,
+		CustomerID:  customerID,
+		Status:      models.ProxyActive,
+		ConsentedAt: now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.CreateProxy(ctx, proxy); err != nil {
+		if errors.Is(err, repository.ErrProxyTaken) {
+			return nil, ErrProxyTaken
+		}
+		return nil, err
+	}
+	return proxy, nil
+}
+
+// Deregister removes a proxy from the account
+func (s *Service) Deregister(ctx context.Context, accountID, proxyID uuid.UUID, req models.ProxyDeregistrationRequest) (*models.ProxyRegistration, error) {
+	if !req.Consent {
+		return nil, ErrConsentRequired
+	}
+	proxy, err := s.repo.GetProxy(ctx, proxyID)
+	if err != nil {
+		return nil, err
+	}
+	if proxy == nil || proxy.AccountID != accountID || proxy.Status != models.ProxyActive {
+		return nil, ErrProxyNotFound
+	}
+
+	now := s.now()
+	deregistered, err := s.repo.DeregisterProxy(ctx, proxy.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !deregistered {
+		return nil, ErrProxyNotFound
+	}
+	proxy.Status = models.ProxyDeregistered
+	proxy.DeregisteredAt = &now
+	proxy.UpdatedAt = now
+	return proxy, nil
+}
+
+// List returns the proxy registrations of an account
+func (s *Service) List(ctx context.Context, accountID uuid.UUID) ([]*models.ProxyRegistration, error) {
+	return s.repo.GetAccountProxies(ctx, accountID)
+}
+
+// Resolve looks up the recipient of a proxy, with us first and then through
+// the switch, and records a transfer for the payer to confirm. A proxy
+// registered with us pays the recipient's default account for incoming
+// transfers, if they chose one. Only the masked recipient name is returned.
+func (s *Service) Resolve(ctx context.Context, from *models.Account, customerID uuid.UUID, req models.ProxyTransferRequest) (*models.ProxyTransfer, error) {
+	value, err := normalize(req.ProxyType, req.ProxyValue)
+	if err != nil {
+		return nil, err
+	}
+	if from.Currency != "" && from.Currency != models.BaseCurrency {
+		return nil, transfers.ErrCurrencyMismatch
+	}
+	if !models.BaseCurrency.ValidAmount(req.Amount) {
+		return nil, transfers.ErrInvalidAmount
+	}
+
+	now := s.now()
+	transfer := &models.ProxyTransfer{
+		ID:            uuid.New(),
+		CustomerID:    customerID,
+		FromAccountID: from.ID,
+		ProxyType:     req.ProxyType,
+		ProxyValue:    value,
+		Amount:        req.Amount,
+		Description:   strings.TrimSpace(req.Description),
+		Status:        models.ProxyTransferAwaitingConfirmation,
+		ExpiresAt:     now.Add(time.Duration(s.cfg.ConfirmTTLSeconds) * time.Second),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	proxy, err := s.repo.GetActiveProxy(ctx, req.ProxyType, value)
+	if err != nil {
+		return nil, err
+	}
+	if proxy != nil {
+		to, err := repository.GetProxyAccount(ctx, s.accountRepo, proxy)
+		if err != nil {
+			return nil, err
+		}
+		if to == nil {
+			return nil, ErrProxyNotFound
+		}
+		if to.ID == from.ID {
+			return nil, transfers.ErrSameAccount
+		}
+		customer, err := s.customerRepo.GetByID(proxy.CustomerID.String())
+		if err != nil {
+			return nil, err
+		}
+		transfer.BankCode = s.bankCode
+		transfer.RecipientName = models.MaskName(customer.FirstName, customer.LastName)
+		transfer.ToAccountID = &to.ID
+		transfer.ToAccountNumber = to.AccountNumber
+	} else {
+		recipient, err := s.switcher.Lookup(ctx, req.ProxyType, value)
+		if err != nil {
+			if errors.Is(err, interbank.ErrProxyNotFound) {
+				return nil, ErrProxyNotFound
+			}
+			return nil, err
+		}
+		transfer.BankCode = recipient.BankCode
+		transfer.RecipientName = models.MaskName(recipient.FirstName, recipient.LastName)
+		transfer.ToAccountNumber = recipient.AccountNumber
+	}
+
+	if err := s.repo.CreateProxyTransfer(ctx, transfer); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// Confirm moves the money of a resolved transfer. The transfer ID is the
+// ledger reference, so a transfer is paid only once.
+func (s *Service) Confirm(ctx context.Context, from *models.Account, transferID uuid.UUID) (*models.ProxyTransfer, error) {
+	transfer, err := s.repo.GetProxyTransfer(ctx, transferID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer == nil || transfer.FromAccountID != from.ID {
+		return nil, ErrTransferNotFound
+	}
+	if transfer.Status != models.ProxyTransferAwaitingConfirmation {
+		return nil, ErrAlreadyConfirmed
+	}
+
+	now := s.now()
+	if !transfer.ExpiresAt.After(now) {
+		transfer.Status = models.ProxyTransferExpired
+		transfer.UpdatedAt = now
+		if _, err := s.repo.UpdateProxyTransfer(ctx, transfer, models.ProxyTransferAwaitingConfirmation); err != nil {
+			return nil, err
+		}
+		return nil, ErrConfirmationExpired
+	}
+
+	if transfer.OnUs() {
+		err = s.payOnUs(ctx, from, transfer)
+	} else {
+		err = s.payInterbank(ctx, from, transfer)
+	}
+	if err != nil {
+		if errors.Is(err, transfers.ErrAlreadyProcessed) {
+			return nil, ErrAlreadyConfirmed
+		}
+		return nil, err
+	}
+
+	transfer.UpdatedAt = s.now()
+	if _, err := s.repo.UpdateProxyTransfer(ctx, transfer, models.ProxyTransferAwaitingConfirmation); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// payOnUs transfers to the account the proxy is registered to with us
+func (s *Service) payOnUs(ctx context.Context, from *models.Account, transfer *models.ProxyTransfer) error {
+	to, err := s.accountRepo.GetAccountByID(ctx, *transfer.ToAccountID)
+	if err != nil {
+		return err
+	}
+	if to == nil {
+		return transfers.ErrDestinationNotFound
+	}
+
+	result, err := s.transfers.Transfer(ctx, transfers.Input{
+		From:        from,
+		To:          to,
+		Amount:      transfer.Amount,
+		Description: s.description(transfer),
+		Reference:   "proxy:" + transfer.ID.String(),
+		CustomerID:  transfer.CustomerID,
+		Channel:     models.ChannelMobile,
+	})
+	if err != nil {
+		return err
+	}
+	transfer.Status = models.ProxyTransferCompleted
+	transfer.TransactionID = &result.TransactionID
+	return nil
+}
+
+// payInterbank debits the payer into the settlement account and asks the
+// switch to credit the other bank. The payer is refunded only when the other
+// bank rejects the transfer; when the switch does not answer, the transfer is
+// left pending for Inquire.
+func (s *Service) payInterbank(ctx context.Context, from *models.Account, transfer *models.ProxyTransfer) error {
+	settlement, err := s.settlementAccount(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.transfers.Transfer(ctx, transfers.Input{
+		From:        from,
+		To:          settlement,
+		Amount:      transfer.Amount,
+		Description: s.description(transfer),
+		Reference:   "proxy:" + transfer.ID.String(),
+		Type:        models.LedgerInterbankTransfer,
+		CustomerID:  transfer.CustomerID,
+		Channel:     models.ChannelMobile,
+	})
+	if err != nil {
+		return err
+	}
+	transfer.TransactionID = &result.TransactionID
+
+	s.send(ctx, from, settlement, transfer)
+	return nil
+}
+
+// Inquire asks the switch again about the transfers it did not answer. A
+// credit is sent with the transfer ID as its reference and the switch
+// credits a reference once, so sending it again is how the outcome is
+// learned. It returns the number of transfers resolved.
+func (s *Service) Inquire(ctx context.Context) (int, error) {
+	pending, err := s.repo.GetPendingProxyTransfers(ctx)
+	if err != nil || len(pending) == 0 {
+		return 0, err
+	}
+	settlement, err := s.settlementAccount(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	resolved := 0
+	for _, transfer := range pending {
+		from, err := s.accountRepo.GetAccountByID(ctx, transfer.FromAccountID)
+		if err != nil {
+			return resolved, err
+		}
+		if from == nil {
+			log.Printf("account of pending proxy transfer %s not found", transfer.ID)
+			continue
+		}
+
+		s.send(ctx, from, settlement, transfer)
+		if transfer.Status == models.ProxyTransferPending {
+			continue
+		}
+		transfer.UpdatedAt = s.now()
+		updated, err := s.repo.UpdateProxyTransfer(ctx, transfer, models.ProxyTransferPending)
+		if err != nil {
+			return resolved, err
+		}
+		if updated {
+			resolved++
+		}
+	}
+	return resolved, nil
+}
+
+// send asks the switch to credit the other bank and sets the status of the
+// transfer from its answer, refunding the payer when the other bank rejects
+// it
+func (s *Service) send(ctx context.Context, from, settlement *models.Account, transfer *models.ProxyTransfer) {
+	err := s.switcher.Send(ctx, interbank.Credit{
+		Reference:         transfer.ID.String(),
+		FromBankCode:      s.bankCode,
+		FromAccountNumber: from.AccountNumber,
+		ToBankCode:        transfer.BankCode,
+		ToAccountNumber:   transfer.ToAccountNumber,
+		Amount:            transfer.Amount,
+	})
+	if err == nil {
+		transfer.Status = models.ProxyTransferCompleted
+		transfer.FailureReason = ""
+		return
+	}
+
+	transfer.FailureReason = err.Error()
+	var rejected *interbank.RejectError
+	if !errors.As(err, &rejected) {
+		// A timeout or a failure to reach the switch says nothing about
+		// whether the other bank was credited
+		transfer.Status = models.ProxyTransferPending
+		return
+	}
+
+	transfer.Status = models.ProxyTransferRejected
+	_, refundErr := s.transfers.Transfer(ctx, transfers.Input{
+		From:        settlement,
+		To:          from,
+		Amount:      transfer.Amount,
+		Description: fmt.Sprintf("Refund of transfer to %s", models.MaskProxy(transfer.ProxyValue)),
+		Reference:   "proxy-refund:" + transfer.ID.String(),
+		Type:        models.LedgerInterbankRefund,
+	})
+	if refundErr != nil && !errors.Is(refundErr, transfers.ErrAlreadyProcessed) {
+		// The funds stay in the settlement account for operations to return
+		log.Printf("failed to refund proxy transfer %s: %v", transfer.ID, refundErr)
+	}
+}
+
+// settlementAccount returns the account the payer is debited into for transfers to other banks
+func (s *Service) settlementAccount(ctx context.Context) (*models.Account, error) {
+	return repository.GetInternalAccount(ctx, s.accountRepo, models.GLInterbankSettlement)
+}
+
+func (s *Service) description(transfer *models.ProxyTransfer) string {
+	if transfer.Description != "" {
+		return transfer.Description
+	}
+	return fmt.Sprintf("Transfer to %s (%s)", models.MaskProxy(transfer.ProxyValue), transfer.RecipientName)
+}
+
+// normalize validates the type and value of a proxy
+func normalize(proxyType models.ProxyType, value string) (string, error) {
+	if !proxyType.IsValid() {
+		return "", ErrInvalidProxyType
+	}
+	normalized, ok := models.NormalizeProxy(proxyType, value)
+	if !ok {
+		return "", ErrInvalidProxyValue
+	}
+	return normalized, nil
+}
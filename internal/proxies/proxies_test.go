@@ -0,0 +1,298 @@
+package proxies
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/interbank"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/repository/repotest"
+	"example.com/m/internal/transfers"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubProxyRepository keeps registrations and transfers in memory
+type stubProxyRepository struct {
+	repository.ProxyRepository
+	proxies   []*models.ProxyRegistration
+	transfers map[uuid.UUID]models.ProxyTransfer
+}
+
+func (r *stubProxyRepository) CreateProxy(ctx context.Context, proxy *models.ProxyRegistration) error {
+	for _, existing := range r.proxies {
+		if existing.Status == models.ProxyActive && existing.ProxyType == proxy.ProxyType && existing.ProxyValue == proxy.ProxyValue {
+			return repository.ErrProxyTaken
+		}
+	}
+	r.proxies = append(r.proxies, proxy)
+	return nil
+}
+
+func (r *stubProxyRepository) GetActiveProxy(ctx context.Context, proxyType models.ProxyType, value string) (*models.ProxyRegistration, error) {
+	for _, proxy := range r.proxies {
+		if proxy.Status == models.ProxyActive && proxy.ProxyType == proxyType && proxy.ProxyValue == value {
+			return proxy, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *stubProxyRepository) CreateProxyTransfer(ctx context.Context, transfer *models.ProxyTransfer) error {
+	r.transfers[transfer.ID] = *transfer
+	return nil
+}
+
+func (r *stubProxyRepository) GetProxyTransfer(ctx context.Context, id uuid.UUID) (*models.ProxyTransfer, error) {
+	transfer, ok := r.transfers[id]
+	if !ok {
+		return nil, nil
+	}
+	return &transfer, nil
+}
+
+func (r *stubProxyRepository) GetPendingProxyTransfers(ctx context.Context) ([]*models.ProxyTransfer, error) {
+	pending := []*models.ProxyTransfer{}
+	for _, transfer := range r.transfers {
+		if transfer.Status == models.ProxyTransferPending {
+			transfer := transfer
+			pending = append(pending, &transfer)
+		}
+	}
+	return pending, nil
+}
+
+func (r *stubProxyRepository) UpdateProxyTransfer(ctx context.Context, transfer *models.ProxyTransfer, from models.ProxyTransferStatus) (bool, error) {
+	if r.transfers[transfer.ID].Status != from {
+		return false, nil
+	}
+	r.transfers[transfer.ID] = *transfer
+	return true, nil
+}
+
+// stubTransferrer records the transfers it is asked to post
+type stubTransferrer struct {
+	inputs []transfers.Input
+}
+
+func (t *stubTransferrer) Transfer(ctx context.Context, input transfers.Input) (*models.TransferResult, error) {
+	t.inputs = append(t.inputs, input)
+	return &models.TransferResult{TransactionID: uuid.New(), Amount: input.Amount}, nil
+}
+
+// stubSwitch gives every credit the same answer; it cannot look up proxies
+type stubSwitch struct {
+	interbank.Switch
+	err error
+}
+
+func (s *stubSwitch) Send(ctx context.Context, credit interbank.Credit) error {
+	return s.err
+}
+
+type fixture struct {
+	service     *Service
+	repo        *stubProxyRepository
+	transferrer *stubTransferrer
+	payer       *models.Account
+	payee       *models.Account
+	settlement  *models.Account
+	payeeID     uuid.UUID
+}
+
+func newFixture() *fixture {
+	payerID, payeeID := uuid.New(), uuid.New()
+	f := &fixture{
+		repo:        &stubProxyRepository{transfers: map[uuid.UUID]models.ProxyTransfer{}},
+		transferrer: &stubTransferrer{},
+		payer:       repotest.CustomerAccount(payerID, "100-1-00001-1", 0),
+		payee:       repotest.CustomerAccount(payeeID, "100-1-00002-1", 0),
+		settlement:  repotest.InternalAccount(models.GLInterbankSettlement),
+		payeeID:     payeeID,
+	}
+	payee := repotest.Customer(payeeID, "1-1017-00203-45-0", "081-234-5678")
+	payee.FirstName, payee.LastName = "Malee", "Srisuk"
+	switcher := interbank.NewSimulator(config.Default().Interbank)
+	accounts := &repotest.Accounts{Accounts: []*models.Account{f.payer, f.payee, f.settlement}}
+	f.service = NewService(f.repo, accounts, repotest.NewCustomers(payee), f.transferrer, switcher, config.ProxyConfig{ConfirmTTLSeconds: 60}, "099")
+	return f
+}
+
+func TestNormalizeProxy(t *testing.T) {
+	value, ok := models.NormalizeProxy(models.ProxyMobile, "+66 81 234 5678")
+	assert.True(t, ok)
+	assert.Equal(t, "0812345678", value)
+
+	_, ok = models.NormalizeProxy(models.ProxyMobile, "12345")
+	assert.False(t, ok)
+
+	value, ok = models.NormalizeProxy(models.ProxyNationalID, "1-1017-00203-45-0")
+	assert.True(t, ok)
+	assert.Equal(t, "1101700203450", value)
+
+	// Wrong check digit
+	_, ok = models.NormalizeProxy(models.ProxyNationalID, "1101700203451")
+	assert.False(t, ok)
+
+	assert.Equal(t, "Malee S***", models.MaskName("Malee", "Srisuk"))
+	assert.Equal(t, "สมชาย ใ***", models.MaskName("สมชาย", "ใจดี"))
+}
+
+func TestRegisterNeedsConsentAndOwnProxy(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+
+	_, err := f.service.Register(ctx, f.payee, f.payeeID, models.ProxyRegistrationRequest{ProxyType: models.ProxyMobile, ProxyValue: "0812345678"})
+	assert.ErrorIs(t, err, ErrConsentRequired)
+
+	_, err = f.service.Register(ctx, f.payee, f.payeeID, models.ProxyRegistrationRequest{ProxyType: models.ProxyMobile, ProxyValue: "0899999999", Consent: true})
+	assert.ErrorIs(t, err, ErrNotOwnProxy)
+
+	proxy, err := f.service.Register(ctx, f.payee, f.payeeID, models.ProxyRegistrationRequest{ProxyType: models.ProxyMobile, ProxyValue: "081 234 5678", Consent: true})
+	require.NoError(t, err)
+	assert.Equal(t, "0812345678", proxy.ProxyValue)
+	assert.Equal(t, models.ProxyActive, proxy.Status)
+
+	_, err = f.service.Register(ctx, f.payee, f.payeeID, models.ProxyRegistrationRequest{ProxyType: models.ProxyMobile, ProxyValue: "0812345678", Consent: true})
+	assert.ErrorIs(t, err, ErrProxyTaken)
+}
+
+func TestTransferToProxyRegisteredWithUs(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+	_, err := f.service.Register(ctx, f.payee, f.payeeID, models.ProxyRegistrationRequest{ProxyType: models.ProxyNationalID, ProxyValue: "1101700203450", Consent: true})
+	require.NoError(t, err)
+
+	transfer, err := f.service.Resolve(ctx, f.payer, *f.payer.CustomerID, models.ProxyTransferRequest{ProxyType: models.ProxyNationalID, ProxyValue: "1101700203450", Amount: 250})
+	require.NoError(t, err)
+	assert.Equal(t, "Malee S***", transfer.RecipientName)
+	assert.Equal(t, "099", transfer.BankCode)
+	assert.Empty(t, f.transferrer.inputs, "nothing moves before the payer confirms")
+
+	confirmed, err := f.service.Confirm(ctx, f.payer, transfer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ProxyTransferCompleted, confirmed.Status)
+	require.Len(t, f.transferrer.inputs, 1)
+	assert.Equal(t, f.payee.ID, f.transferrer.inputs[0].To.ID)
+
+	_, err = f.service.Confirm(ctx, f.payer, transfer.ID)
+	assert.ErrorIs(t, err, ErrAlreadyConfirmed)
+}
+
+func TestTransferToProxyCreditsDefaultIncomingAccount(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+	_, err := f.service.Register(ctx, f.payee, f.payeeID, models.ProxyRegistrationRequest{ProxyType: models.ProxyMobile, ProxyValue: "0812345678", Consent: true})
+	require.NoError(t, err)
+	current := &models.Account{ID: uuid.New(), AccountNumber: "100-2-00003-1", CustomerID: &f.payeeID, AccountType: models.AccountTypeCurrent, Status: models.AccountStatusActive}
+	accounts := f.service.accountRepo.(*repotest.Accounts)
+	accounts.Accounts = append(accounts.Accounts, current)
+	accounts.DefaultIncoming = map[uuid.UUID]*models.Account{f.payeeID: current}
+
+	transfer, err := f.service.Resolve(ctx, f.payer, *f.payer.CustomerID, models.ProxyTransferRequest{ProxyType: models.ProxyMobile, ProxyValue: "0812345678", Amount: 250})
+	require.NoError(t, err)
+	assert.Equal(t, current.ID, *transfer.ToAccountID)
+
+	_, err = f.service.Resolve(ctx, current, f.payeeID, models.ProxyTransferRequest{ProxyType: models.ProxyMobile, ProxyValue: "0812345678", Amount: 250})
+	assert.ErrorIs(t, err, transfers.ErrSameAccount)
+}
+
+func TestTransferToOtherBankOutcomes(t *testing.T) {
+	tests := []struct {
+		proxyType models.ProxyType
+		value     string
+		status    models.ProxyTransferStatus
+		refunded  bool
+	}{
+		{models.ProxyMobile, "0811111111", models.ProxyTransferCompleted, false},
+		{models.ProxyMobile, "0822222222", models.ProxyTransferPending, false},
+		{models.ProxyNationalID, "1101700203450", models.ProxyTransferRejected, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			f := newFixture()
+			ctx := context.Background()
+
+			transfer, err := f.service.Resolve(ctx, f.payer, *f.payer.CustomerID, models.ProxyTransferRequest{ProxyType: tt.proxyType, ProxyValue: tt.value, Amount: 1000})
+			require.NoError(t, err)
+			assert.NotEqual(t, "099", transfer.BankCode)
+
+			confirmed, err := f.service.Confirm(ctx, f.payer, transfer.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, confirmed.Status)
+
+			require.NotEmpty(t, f.transferrer.inputs)
+			debit := f.transferrer.inputs[0]
+			assert.Equal(t, f.settlement.ID, debit.To.ID)
+			assert.Equal(t, models.LedgerInterbankTransfer, debit.Type)
+			if tt.refunded {
+				require.Len(t, f.transferrer.inputs, 2)
+				refund := f.transferrer.inputs[1]
+				assert.Equal(t, f.payer.ID, refund.To.ID)
+				assert.Equal(t, models.LedgerInterbankRefund, refund.Type)
+				assert.NotEmpty(t, confirmed.FailureReason)
+			} else {
+				assert.Len(t, f.transferrer.inputs, 1)
+			}
+		})
+	}
+}
+
+func TestInquireResolvesPendingTransfers(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+	pending := func() *models.ProxyTransfer {
+		f.service.switcher = interbank.NewSimulator(config.Default().Interbank)
+		transfer, err := f.service.Resolve(ctx, f.payer, *f.payer.CustomerID, models.ProxyTransferRequest{ProxyType: models.ProxyMobile, ProxyValue: "0822222222", Amount: 1000})
+		require.NoError(t, err)
+		confirmed, err := f.service.Confirm(ctx, f.payer, transfer.ID)
+		require.NoError(t, err)
+		require.Equal(t, models.ProxyTransferPending, confirmed.Status)
+		return confirmed
+	}
+
+	settled := pending()
+	resolved, err := f.service.Inquire(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, resolved, "the switch still does not answer")
+	require.Len(t, f.transferrer.inputs, 1, "the payer is not refunded while the outcome is unknown")
+
+	f.service.switcher = &stubSwitch{}
+	resolved, err = f.service.Inquire(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, resolved)
+	assert.Equal(t, models.ProxyTransferCompleted, f.repo.transfers[settled.ID].Status)
+	assert.Len(t, f.transferrer.inputs, 1)
+
+	rejected := pending()
+	f.service.switcher = &stubSwitch{err: &interbank.RejectError{Reason: "account closed"}}
+	resolved, err = f.service.Inquire(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, resolved)
+	assert.Equal(t, models.ProxyTransferRejected, f.repo.transfers[rejected.ID].Status)
+	require.Len(t, f.transferrer.inputs, 3)
+	refund := f.transferrer.inputs[2]
+	assert.Equal(t, f.payer.ID, refund.To.ID)
+	assert.Equal(t, models.LedgerInterbankRefund, refund.Type)
+}
+
+func TestResolveUnknownProxyAndExpiredConfirmation(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+
+	_, err := f.service.Resolve(ctx, f.payer, *f.payer.CustomerID, models.ProxyTransferRequest{ProxyType: models.ProxyMobile, ProxyValue: "0899999999", Amount: 100})
+	assert.ErrorIs(t, err, ErrProxyNotFound)
+
+	transfer, err := f.service.Resolve(ctx, f.payer, *f.payer.CustomerID, models.ProxyTransferRequest{ProxyType: models.ProxyMobile, ProxyValue: "0811111111", Amount: 100})
+	require.NoError(t, err)
+
+	f.service.now = func() time.Time { return transfer.ExpiresAt.Add(time.Second) }
+	_, err = f.service.Confirm(ctx, f.payer, transfer.ID)
+	assert.ErrorIs(t, err, ErrConfirmationExpired)
+	assert.Equal(t, models.ProxyTransferExpired, f.repo.transfers[transfer.ID].Status)
+	assert.Empty(t, f.transferrer.inputs)
+}
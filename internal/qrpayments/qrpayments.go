@@ -0,0 +1,200 @@
+package qrpayments
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/thaiqr"
+	"example.com/m/internal/transfers"
+	"github.com/google/uuid"
+)
+
+// imageSize is the width and height in pixels of generated QR code images
+const imageSize = 320
+
+// maxReferenceLength is the longest reference a Thai QR bill payment carries
+const maxReferenceLength = 20
+
+var (
+	// ErrAccountNotEligible is returned when the account is not an active base currency account
+	ErrAccountNotEligible = errors.New("only active THB accounts can receive QR payments")
+	// ErrInvalidReference is returned for a reference that does not fit in a QR code
+	ErrInvalidReference = errors.New("reference must be up to 20 letters or digits")
+	// ErrAmountRequired is returned when neither the code nor the request has an amount
+	ErrAmountRequired = errors.New("the QR code has no amount; enter the amount to pay")
+	// ErrAmountMismatch is returned when the request changes the amount fixed by the code
+	ErrAmountMismatch = errors.New("the amount is fixed by the QR code and cannot be changed")
+	// ErrOtherBank is returned for codes that pay an account at another bank
+	ErrOtherBank = errors.New("the QR code pays another bank; use an interbank or proxy transfer")
+	// ErrRecipientNotFound is returned when no account here matches the code
+	ErrRecipientNotFound = errors.New("the recipient of the QR code was not found")
+)
+
+// Destinations finds the account a payment goes to
+type Destinations interface {
+	FindDestination(ctx context.Context, accountNumber string) (*models.Account, error)
+}
+
+// Service generates Thai QR codes for accounts and turns scanned codes into
+// transfers. A code without a reference is a credit transfer (tag 29) to
+// the bank code and account number; with a reference it is a bill payment
+// (tag 30) whose biller ID is the bank code and account number and whose
+// first reference is the customer's reference.
+type Service struct {
+	destinations Destinations
+	proxyRepo    repository.ProxyRepository
+	accountRepo  repository.AccountRepository
+	bankCode     string
+}
+
+// NewService creates a new QR payment Service
+func NewService(destinations Destinations, proxyRepo repository.ProxyRepository, accountRepo repository.AccountRepository, bankCode string) *Service {
+	return &Service{
+		destinations: destinations,
+		proxyRepo:    proxyRepo,
+		accountRepo:  accountRepo,
+		bankCode:     bankCode,
+	}
+}
+
+// Generate returns a QR code that pays the account, with the amount fixed
+// when the request has one
+func (s *Service) Generate(ctx context.Context, account *models.Account, req models.QRCodeRequest) (*models.QRCode, error) {
+	if account.Status != models.AccountStatusActive || account.IsInternal() ||
+		(account.Currency != "" && account.Currency != models.BaseCurrency) {
+		return nil, ErrAccountNotEligible
+	}
+	if req.Amount < 0 || (req.Amount > 0 && !models.BaseCurrency.ValidAmount(req.Amount)) {
+		return nil, transfers.ErrInvalidAmount
+	}
+	reference, err := normalizeReference(req.Reference)
+	if err != nil {
+		return nil, err
+	}
+
+	code := thaiqr.Payload{
+		Target: thaiqr.TargetBankAccount,
+		Value:  s.bankCode + account.AccountNumber,
+		Amount: req.Amount,
+	}
+	if reference != "" {
+		code.Target = thaiqr.TargetBiller
+		code.Reference = reference
+	}
+	payload, err := thaiqr.Encode(code)
+	if err != nil {
+		return nil, err
+	}
+	image, err := thaiqr.PNG(payload, imageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.QRCode{
+		AccountNumber: account.AccountNumber,
+		Amount:        req.Amount,
+		Reference:     reference,
+		Payload:       payload,
+		Image:         image,
+	}, nil
+}
+
+// Resolve checks a scanned code and returns the validated transfer that
+// pays it. Codes for a mobile number or national ID are paid when the
+// proxy is registered with us, into the account a proxy transfer would credit.
+func (s *Service) Resolve(ctx context.Context, from *models.Account, customerID uuid.UUID, req models.QRPaymentRequest) (transfers.Input, error) {
+	code, err := thaiqr.Decode(req.Payload)
+	if err != nil {
+		return transfers.Input{}, err
+	}
+
+	amount := req.Amount
+	if code.Amount > 0 {
+		if amount != 0 && amount != code.Amount {
+			return transfers.Input{}, ErrAmountMismatch
+		}
+		amount = code.Amount
+	} else if amount <= 0 {
+		return transfers.Input{}, ErrAmountRequired
+	}
+
+	to, err := s.recipient(ctx, code)
+	if err != nil {
+		return transfers.Input{}, err
+	}
+
+	description := strings.TrimSpace(req.Description)
+	if description == "" {
+		description = "QR payment"
+		if code.Reference != "" {
+			description += " " + code.Reference
+		}
+	}
+	input := transfers.Input{
+		From:        from,
+		To:          to,
+		Amount:      amount,
+		Description: description,
+		Reference:   req.Reference,
+		CustomerID:  customerID,
+		Channel:     models.ChannelMobile,
+	}
+	return input, transfers.Validate(input)
+}
+
+// recipient finds the account here that a code pays
+func (s *Service) recipient(ctx context.Context, code *thaiqr.Payload) (*models.Account, error) {
+	var accountNumber string
+	switch code.Target {
+	case thaiqr.TargetBankAccount, thaiqr.TargetBiller:
+		if !strings.HasPrefix(code.Value, s.bankCode) {
+			return nil, ErrOtherBank
+		}
+		accountNumber = strings.TrimPrefix(code.Value, s.bankCode)
+	case thaiqr.TargetMobile, thaiqr.TargetNationalID:
+		proxyType := models.ProxyMobile
+		if code.Target == thaiqr.TargetNationalID {
+			proxyType = models.ProxyNationalID
+		}
+		proxy, err := s.proxyRepo.GetActiveProxy(ctx, proxyType, code.Value)
+		if err != nil {
+			return nil, err
+		}
+		if proxy == nil {
+			return nil, ErrOtherBank
+		}
+		account, err := repository.GetProxyAccount(ctx, s.accountRepo, proxy)
+		if err != nil {
+			return nil, err
+		}
+		if account == nil {
+			return nil, ErrRecipientNotFound
+		}
+		accountNumber = account.AccountNumber
+	default:
+		return nil, thaiqr.ErrUnsupported
+	}
+
+	to, err := s.destinations.FindDestination(ctx, accountNumber)
+	if errors.Is(err, transfers.ErrDestinationNotFound) {
+		return nil, ErrRecipientNotFound
+	}
+	return to, err
+}
+
+// normalizeReference upper-cases a reference and checks it fits in a code
+func normalizeReference(reference string) (string, error) {
+	reference = strings.ToUpper(strings.TrimSpace(reference))
+	if len(reference) > maxReferenceLength {
+		return "", ErrInvalidReference
+	}
+	for _, r := range reference {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return "", ErrInvalidReference
+		}
+	}
+	return reference, nil
+}
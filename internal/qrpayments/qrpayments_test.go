@@ -0,0 +1,147 @@
+package qrpayments
+
+import (
+	"context"
+	"testing"
+
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/repository/repotest"
+	"example.com/m/internal/thaiqr"
+	"example.com/m/internal/transfers"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubDestinations stands in for the transfer service
+type stubDestinations struct {
+	accounts *repotest.Accounts
+}
+
+func (d stubDestinations) FindDestination(ctx context.Context, accountNumber string) (*models.Account, error) {
+	account, err := d.accounts.GetAccountByNumber(ctx, accountNumber)
+	if err != nil || account == nil || account.IsInternal() {
+		return nil, transfers.ErrDestinationNotFound
+	}
+	return account, nil
+}
+
+// stubProxyRepository returns registrations from memory
+type stubProxyRepository struct {
+	repository.ProxyRepository
+	proxies []*models.ProxyRegistration
+}
+
+func (r *stubProxyRepository) GetActiveProxy(ctx context.Context, proxyType models.ProxyType, value string) (*models.ProxyRegistration, error) {
+	for _, proxy := range r.proxies {
+		if proxy.ProxyType == proxyType && proxy.ProxyValue == value {
+			return proxy, nil
+		}
+	}
+	return nil, nil
+}
+
+type fixture struct {
+	service *Service
+	payer   *models.Account
+	payee   *models.Account
+}
+
+func newFixture() *fixture {
+	payeeID := uuid.New()
+	f := &fixture{
+		payer: repotest.Account("0000000001", 0),
+		payee: repotest.CustomerAccount(payeeID, "0000000002", 0),
+	}
+	f.payer.Currency, f.payee.Currency = models.BaseCurrency, models.BaseCurrency
+	accounts := &repotest.Accounts{Accounts: []*models.Account{f.payer, f.payee}}
+	proxies := &stubProxyRepository{proxies: []*models.ProxyRegistration{
+		{ProxyType: models.ProxyMobile, ProxyValue: "0812345678", AccountID: f.payee.ID, CustomerID: payeeID, Status: models.ProxyActive},
+	}}
+	f.service = NewService(stubDestinations{accounts}, proxies, accounts, "099")
+	return f
+}
+
+func TestGenerateAndPayCode(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+
+	code, err := f.service.Generate(ctx, f.payee, models.QRCodeRequest{Amount: 350, Reference: "inv 7"})
+	assert.ErrorIs(t, err, ErrInvalidReference)
+
+	code, err = f.service.Generate(ctx, f.payee, models.QRCodeRequest{Amount: 350, Reference: "inv7"})
+	require.NoError(t, err)
+	assert.Equal(t, "INV7", code.Reference)
+	assert.NotEmpty(t, code.Image)
+
+	input, err := f.service.Resolve(ctx, f.payer, uuid.New(), models.QRPaymentRequest{Payload: code.Payload, Reference: "scan-1"})
+	require.NoError(t, err)
+	assert.Equal(t, f.payee.ID, input.To.ID)
+	assert.Equal(t, 350.0, input.Amount)
+	assert.Equal(t, "QR payment INV7", input.Description)
+	assert.Equal(t, "scan-1", input.Reference)
+
+	_, err = f.service.Resolve(ctx, f.payer, uuid.New(), models.QRPaymentRequest{Payload: code.Payload, Amount: 10})
+	assert.ErrorIs(t, err, ErrAmountMismatch)
+}
+
+func TestProxyCodesCreditDefaultIncomingAccount(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+	current := &models.Account{ID: uuid.New(), AccountNumber: "0000000003", CustomerID: f.payee.CustomerID, AccountType: models.AccountTypeCurrent, Status: models.AccountStatusActive, Currency: models.BaseCurrency}
+	accounts := f.service.accountRepo.(*repotest.Accounts)
+	accounts.Accounts = append(accounts.Accounts, current)
+	accounts.DefaultIncoming = map[uuid.UUID]*models.Account{*f.payee.CustomerID: current}
+
+	mobile, err := thaiqr.Encode(thaiqr.Payload{Target: thaiqr.TargetMobile, Value: "0812345678"})
+	require.NoError(t, err)
+	input, err := f.service.Resolve(ctx, f.payer, uuid.New(), models.QRPaymentRequest{Payload: mobile, Amount: 80})
+	require.NoError(t, err)
+	assert.Equal(t, current.ID, input.To.ID)
+
+	code, err := f.service.Generate(ctx, f.payee, models.QRCodeRequest{Amount: 80})
+	require.NoError(t, err)
+	input, err = f.service.Resolve(ctx, f.payer, uuid.New(), models.QRPaymentRequest{Payload: code.Payload})
+	require.NoError(t, err)
+	assert.Equal(t, f.payee.ID, input.To.ID, "a code for an account number pays that account")
+}
+
+func TestResolveStaticAndProxyCodes(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+
+	code, err := f.service.Generate(ctx, f.payee, models.QRCodeRequest{})
+	require.NoError(t, err)
+	_, err = f.service.Resolve(ctx, f.payer, uuid.New(), models.QRPaymentRequest{Payload: code.Payload})
+	assert.ErrorIs(t, err, ErrAmountRequired)
+	input, err := f.service.Resolve(ctx, f.payer, uuid.New(), models.QRPaymentRequest{Payload: code.Payload, Amount: 45.25})
+	require.NoError(t, err)
+	assert.Equal(t, 45.25, input.Amount)
+
+	mobile, err := thaiqr.Encode(thaiqr.Payload{Target: thaiqr.TargetMobile, Value: "0812345678"})
+	require.NoError(t, err)
+	input, err = f.service.Resolve(ctx, f.payer, uuid.New(), models.QRPaymentRequest{Payload: mobile, Amount: 80})
+	require.NoError(t, err)
+	assert.Equal(t, f.payee.ID, input.To.ID)
+
+	unknown, err := thaiqr.Encode(thaiqr.Payload{Target: thaiqr.TargetMobile, Value: "0899999999"})
+	require.NoError(t, err)
+	_, err = f.service.Resolve(ctx, f.payer, uuid.New(), models.QRPaymentRequest{Payload: unknown, Amount: 80})
+	assert.ErrorIs(t, err, ErrOtherBank)
+
+	otherBank, err := thaiqr.Encode(thaiqr.Payload{Target: thaiqr.TargetBankAccount, Value: "0041234567890", Amount: 5})
+	require.NoError(t, err)
+	_, err = f.service.Resolve(ctx, f.payer, uuid.New(), models.QRPaymentRequest{Payload: otherBank})
+	assert.ErrorIs(t, err, ErrOtherBank)
+
+	missing, err := thaiqr.Encode(thaiqr.Payload{Target: thaiqr.TargetBankAccount, Value: "0999999999999", Amount: 5})
+	require.NoError(t, err)
+	_, err = f.service.Resolve(ctx, f.payer, uuid.New(), models.QRPaymentRequest{Payload: missing})
+	assert.ErrorIs(t, err, ErrRecipientNotFound)
+
+	own, err := f.service.Generate(ctx, f.payer, models.QRCodeRequest{Amount: 5})
+	require.NoError(t, err)
+	_, err = f.service.Resolve(ctx, f.payer, uuid.New(), models.QRPaymentRequest{Payload: own.Payload})
+	assert.ErrorIs(t, err, transfers.ErrSameAccount)
+}
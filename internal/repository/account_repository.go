@@ -0,0 +1,467 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrDuplicateAccountNumber is returned when a generated account number is already taken
+var ErrDuplicateAccountNumber = errors.New("account number already exists")
+
+// AccountRepository defines operations for accounts
+type AccountRepository interface {
+	CreateAccount(ctx context.Context, account *models.Account) error
+	CountCustomerAccounts(ctx context.Context, customerID uuid.UUID, productCode string) (int, error)
+	GetAccountByID(ctx context.Context, id uuid.UUID) (*models.Account, error)
+	GetAccountByNumber(ctx context.Context, accountNumber string) (*models.Account, error)
+	GetAccountsByType(ctx context.Context, accountType models.AccountType, status models.AccountStatus) ([]*models.Account, error)
+	UpdateAccountStatus(ctx context.Context, id uuid.UUID, status models.AccountStatus) error
+	CloseAccount(ctx context.Context, id uuid.UUID, closedBy uuid.UUID, reason string) (bool, error)
+	GetCustomerAccounts(ctx context.Context, customerID uuid.UUID) ([]*models.CustomerAccount, error)
+	GetAccountPreferences(ctx context.Context, accountID, customerID uuid.UUID) (*models.AccountPreferences, error)
+	SaveAccountPreferences(ctx context.Context, customerID uuid.UUID, preferences *models.AccountPreferences) error
+	GetDefaultIncomingAccount(ctx context.Context, customerID uuid.UUID) (*models.Account, error)
+	GetMandate(ctx context.Context, accountID, customerID uuid.UUID) (*models.AccountMandate, error)
+	GetMandates(ctx context.Context, accountID uuid.UUID) ([]*models.AccountMandate, error)
+	AddMandate(ctx context.Context, mandate *models.AccountMandate) error
+	RemoveMandate(ctx context.Context, accountID, customerID uuid.UUID) (bool, error)
+	UpdateSigningRule(ctx context.Context, accountID uuid.UUID, rule models.SigningRule) error
+}
+
+// PostgresAccountRepository implements AccountRepository for PostgreSQL
+type PostgresAccountRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresAccountRepository creates a new PostgresAccountRepository
+func NewPostgresAccountRepository(db *sql.DB) *PostgresAccountRepository {
+	return &PostgresAccountRepository{
+		db: db,
+	}
+}
+
+// accountColumns lists the columns read by scanAccount, in order
+const accountColumns = `id, account_number, customer_id, account_type, product_code, product_version,
+		       balance, status, created_at, updated_at, held_amount, last_activity_at, dormant_since, signing_rule, currency`
+
+func scanAccount(row rowScanner) (*models.Account, error) {
+	var account models.Account
+	var customerID uuid.NullUUID
+	var productCode sql.NullString
+	var productVersion sql.NullInt64
+	var dormantSince sql.NullTime
+
+	err := row.Scan(
+		&account.ID,
+		&account.AccountNumber,
+		&customerID,
+		&account.AccountType,
+		&productCode,
+		&productVersion,
+		&account.Balance,
+		&account.Status,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+		&account.HeldAmount,
+		&account.LastActivityAt,
+		&dormantSince,
+		&account.SigningRule,
+		&account.Currency,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if customerID.Valid {
+		account.CustomerID = &customerID.UUID
+	}
+	account.ProductCode = productCode.String
+	account.ProductVersion = int(productVersion.Int64)
+	if dormantSince.Valid {
+		account.DormantSince = &dormantSince.Time
+	}
+	account.AvailableBalance = account.Available()
+	return &account, nil
+}
+
+// CreateAccount inserts a new account. Opening the account counts as its
+// first activity, and the customer who opens it becomes its primary holder.
+func (r *PostgresAccountRepository) CreateAccount(ctx context.Context, account *models.Account) error {
+	query := `
+		INSERT INTO accounts (` + accountColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`
+
+	if account.LastActivityAt.IsZero() {
+		account.LastActivityAt = account.CreatedAt
+	}
+	if account.SigningRule == "" {
+		account.SigningRule = models.SigningEitherOrSurvivor
+	}
+	if account.Currency == "" {
+		account.Currency = models.BaseCurrency
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(
+		ctx,
+		query,
+		account.ID,
+		account.AccountNumber,
+		account.CustomerID,
+		account.AccountType,
+		account.ProductCode,
+		account.ProductVersion,
+		account.Balance,
+		account.Status,
+		account.CreatedAt,
+		account.UpdatedAt,
+		account.HeldAmount,
+		account.LastActivityAt,
+		account.DormantSince,
+		account.SigningRule,
+		account.Currency,
+	)
+	if isUniqueViolation(err) {
+		return ErrDuplicateAccountNumber
+	}
+	if err != nil {
+		return err
+	}
+
+	if account.CustomerID != nil {
+		err = insertMandate(ctx, tx, &models.AccountMandate{
+			AccountID:  account.ID,
+			CustomerID: *account.CustomerID,
+			Role:       models.MandatePrimary,
+			CreatedAt:  account.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CountCustomerAccounts returns how many accounts that are not closed the customer holds on a product
+func (r *PostgresAccountRepository) CountCustomerAccounts(ctx context.Context, customerID uuid.UUID, productCode string) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM accounts
+		WHERE customer_id = $1 AND product_code = $2 AND status <> $3
+	`
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, customerID, productCode, models.AccountStatusClosed).Scan(&count)
+	return count, err
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// GetAccountByID retrieves an account by ID
+func (r *PostgresAccountRepository) GetAccountByID(ctx context.Context, id uuid.UUID) (*models.Account, error) {
+	query := `SELECT ` + accountColumns + ` FROM accounts WHERE id = $1`
+
+	account, err := scanAccount(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	return account, nil
+}
+
+// GetAccountByNumber retrieves an account by its account number
+func (r *PostgresAccountRepository) GetAccountByNumber(ctx context.Context, accountNumber string) (*models.Account, error) {
+	query := `SELECT ` + accountColumns + ` FROM accounts WHERE account_number = $1`
+
+	account, err := scanAccount(r.db.QueryRowContext(ctx, query, accountNumber))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	return account, nil
+}
+
+// GetInternalAccount retrieves an internal ledger account by its number. The
+// schema seeds every internal account, so a missing one is an error.
+func GetInternalAccount(ctx context.Context, repo AccountRepository, accountNumber string) (*models.Account, error) {
+	account, err := repo.GetAccountByNumber(ctx, accountNumber)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, fmt.Errorf("internal account %s is missing", accountNumber)
+	}
+	return account, nil
+}
+
+// GetProxyAccount retrieves the account a proxy credits: the default account
+// for incoming transfers of the customer who registered it, when they chose
+// one, otherwise the account the proxy is linked to.
+func GetProxyAccount(ctx context.Context, repo AccountRepository, proxy *models.ProxyRegistration) (*models.Account, error) {
+	account, err := repo.GetDefaultIncomingAccount(ctx, proxy.CustomerID)
+	if err != nil || account != nil {
+		return account, err
+	}
+	return repo.GetAccountByID(ctx, proxy.AccountID)
+}
+
+// GetAccountsByType retrieves all accounts of a type in the given status
+func (r *PostgresAccountRepository) GetAccountsByType(ctx context.Context, accountType models.AccountType, status models.AccountStatus) ([]*models.Account, error) {
+	query := `
+		SELECT ` + accountColumns + `
+		FROM accounts
+		WHERE account_type = $1 AND status = $2
+		ORDER BY account_number
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, accountType, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return collectAccounts(rows)
+}
+
+// UpdateAccountStatus changes the status of an account
+func (r *PostgresAccountRepository) UpdateAccountStatus(ctx context.Context, id uuid.UUID, status models.AccountStatus) error {
+	query := `UPDATE accounts SET status = $1, updated_at = $2 WHERE id = $3`
+
+	_, err := r.db.ExecContext(ctx, query, status, time.Now(), id)
+	return err
+}
+
+// CloseAccount marks an account closed if it is not closed already and its
+// balance is zero. The balance is checked in the same statement so a posting
+// that lands between the caller's checks and the update keeps it open. It
+// reports whether the account was closed.
+func (r *PostgresAccountRepository) CloseAccount(ctx context.Context, id uuid.UUID, closedBy uuid.UUID, reason string) (bool, error) {
+	query := `
+		UPDATE accounts
+		SET status = $1, closed_at = $2, closed_by = $3, closure_reason = $4, updated_at = $2
+		WHERE id = $5 AND status <> $1 AND balance = 0
+	`
+
+	result, err := r.db.ExecContext(ctx, query, models.AccountStatusClosed, time.Now(), closedBy, reason, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
+
+// GetCustomerAccounts retrieves the accounts that are not closed on which
+// the customer holds a mandate, with their role and preferences, in the
+// customer's display order. Accounts without a display order follow in the
+// order they were opened.
+func (r *PostgresAccountRepository) GetCustomerAccounts(ctx context.Context, customerID uuid.UUID) ([]*models.CustomerAccount, error) {
+	query := `
+		SELECT a.id, a.account_number, a.customer_id, a.account_type, a.product_code, a.product_version,
+		       a.balance, a.status, a.created_at, a.updated_at, a.held_amount, a.signing_rule, a.currency, m.role,
+		       COALESCE(p.nickname, ''), p.display_order,
+		       COALESCE(p.hide_from_dashboard, FALSE), COALESCE(p.default_incoming, FALSE)
+		FROM account_mandates m
+		JOIN accounts a ON a.id = m.account_id
+		LEFT JOIN account_preferences p ON p.account_id = a.id AND p.customer_id = m.customer_id
+		WHERE m.customer_id = $1 AND a.status <> $2
+		ORDER BY p.display_order NULLS LAST, a.created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, customerID, models.AccountStatusClosed)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []*models.CustomerAccount{}
+	for rows.Next() {
+		var account models.CustomerAccount
+		var owner uuid.NullUUID
+		var productCode sql.NullString
+		var productVersion, displayOrder sql.NullInt64
+
+		err := rows.Scan(
+			&account.ID,
+			&account.AccountNumber,
+			&owner,
+			&account.AccountType,
+			&productCode,
+			&productVersion,
+			&account.Balance,
+			&account.Status,
+			&account.CreatedAt,
+			&account.UpdatedAt,
+			&account.HeldAmount,
+			&account.SigningRule,
+			&account.Currency,
+			&account.Role,
+			&account.Nickname,
+			&displayOrder,
+			&account.HideFromDashboard,
+			&account.DefaultIncoming,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if owner.Valid {
+			account.CustomerID = &owner.UUID
+		}
+		account.ProductCode = productCode.String
+		account.ProductVersion = int(productVersion.Int64)
+		account.AvailableBalance = account.Available()
+		if displayOrder.Valid {
+			order := int(displayOrder.Int64)
+			account.DisplayOrder = &order
+		}
+		accounts = append(accounts, &account)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+// GetAccountPreferences retrieves the preferences a holder saved for an account
+func (r *PostgresAccountRepository) GetAccountPreferences(ctx context.Context, accountID, customerID uuid.UUID) (*models.AccountPreferences, error) {
+	query := `
+		SELECT account_id, nickname, display_order, hide_from_dashboard, default_incoming, updated_at
+		FROM account_preferences
+		WHERE account_id = $1 AND customer_id = $2
+	`
+
+	var preferences models.AccountPreferences
+	var displayOrder sql.NullInt64
+	err := r.db.QueryRowContext(ctx, query, accountID, customerID).Scan(
+		&preferences.AccountID,
+		&preferences.Nickname,
+		&displayOrder,
+		&preferences.HideFromDashboard,
+		&preferences.DefaultIncoming,
+		&preferences.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+
+	if displayOrder.Valid {
+		order := int(displayOrder.Int64)
+		preferences.DisplayOrder = &order
+	}
+	return &preferences, nil
+}
+
+// SaveAccountPreferences creates or replaces a holder's preferences for an account.
+// Making an account the default for incoming transfers clears the flag on
+// the customer's other accounts in the same transaction.
+func (r *PostgresAccountRepository) SaveAccountPreferences(ctx context.Context, customerID uuid.UUID, preferences *models.AccountPreferences) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if preferences.DefaultIncoming {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE account_preferences
+			SET default_incoming = FALSE, updated_at = $1
+			WHERE customer_id = $2 AND account_id <> $3 AND default_incoming
+		`, preferences.UpdatedAt, customerID, preferences.AccountID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO account_preferences (
+			account_id, customer_id, nickname, display_order, hide_from_dashboard, default_incoming, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (account_id, customer_id) DO UPDATE SET
+			nickname = EXCLUDED.nickname,
+			display_order = EXCLUDED.display_order,
+			hide_from_dashboard = EXCLUDED.hide_from_dashboard,
+			default_incoming = EXCLUDED.default_incoming,
+			updated_at = EXCLUDED.updated_at
+	`,
+		preferences.AccountID,
+		customerID,
+		preferences.Nickname,
+		preferences.DisplayOrder,
+		preferences.HideFromDashboard,
+		preferences.DefaultIncoming,
+		preferences.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetDefaultIncomingAccount retrieves the active account a customer chose to
+// receive incoming transfers, provided they still hold a mandate on it
+func (r *PostgresAccountRepository) GetDefaultIncomingAccount(ctx context.Context, customerID uuid.UUID) (*models.Account, error) {
+	query := `
+		SELECT ` + accountColumns + `
+		FROM accounts
+		WHERE status = $2 AND id = (
+			SELECT p.account_id
+			FROM account_preferences p
+			JOIN account_mandates m ON m.account_id = p.account_id AND m.customer_id = p.customer_id
+			WHERE p.customer_id = $1 AND p.default_incoming
+		)
+	`
+
+	account, err := scanAccount(r.db.QueryRowContext(ctx, query, customerID, models.AccountStatusActive))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	return account, nil
+}
+
+func collectAccounts(rows *sql.Rows) ([]*models.Account, error) {
+	accounts := []*models.Account{}
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
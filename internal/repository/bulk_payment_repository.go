@@ -0,0 +1,295 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+)
+
+// BulkPaymentRepository defines operations for bulk payment files and their rows
+type BulkPaymentRepository interface {
+	CreateBulkPayment(ctx context.Context, batch *models.BulkPayment, rows []*models.BulkPaymentRow) error
+	GetBulkPayment(ctx context.Context, id uuid.UUID) (*models.BulkPayment, error)
+	GetBulkPaymentRows(ctx context.Context, bulkPaymentID uuid.UUID) ([]*models.BulkPaymentRow, error)
+	UpdateBulkPaymentStatus(ctx context.Context, batch *models.BulkPayment, from models.BulkPaymentStatus) (bool, error)
+	GetRunnableBulkPayments(ctx context.Context) ([]*models.BulkPayment, error)
+	UpdateBulkPaymentRow(ctx context.Context, row *models.BulkPaymentRow) error
+	CompleteBulkPayment(ctx context.Context, batch *models.BulkPayment) error
+}
+
+// PostgresBulkPaymentRepository implements BulkPaymentRepository for PostgreSQL
+type PostgresBulkPaymentRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresBulkPaymentRepository creates a new PostgresBulkPaymentRepository
+func NewPostgresBulkPaymentRepository(db *sql.DB) *PostgresBulkPaymentRepository {
+	return &PostgresBulkPaymentRepository{
+		db: db,
+	}
+}
+
+// bulkPaymentColumns lists the columns read by scanBulkPayment, in order
+const bulkPaymentColumns = `id, account_id, customer_id, file_name, status, row_count, valid_count, invalid_count,
+		       total_amount, paid_count, failed_count, paid_amount, available_balance, confirmed_at, completed_at,
+		       created_at, updated_at`
+
+func scanBulkPayment(row rowScanner) (*models.BulkPayment, error) {
+	var batch models.BulkPayment
+	var confirmedAt, completedAt sql.NullTime
+
+	err := row.Scan(
+		&batch.ID,
+		&batch.AccountID,
+		&batch.CustomerID,
+		&batch.FileName,
+		&batch.Status,
+		&batch.RowCount,
+		&batch.ValidCount,
+		&batch.InvalidCount,
+		&batch.TotalAmount,
+		&batch.PaidCount,
+		&batch.FailedCount,
+		&batch.PaidAmount,
+		&batch.AvailableBalance,
+		&confirmedAt,
+		&completedAt,
+		&batch.CreatedAt,
+		&batch.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if confirmedAt.Valid {
+		batch.ConfirmedAt = &confirmedAt.Time
+	}
+	if completedAt.Valid {
+		batch.CompletedAt = &completedAt.Time
+	}
+	return &batch, nil
+}
+
+// CreateBulkPayment inserts a bulk payment file with its rows
+func (r *PostgresBulkPaymentRepository) CreateBulkPayment(ctx context.Context, batch *models.BulkPayment, rows []*models.BulkPaymentRow) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO bulk_payments (
+			id, account_id, customer_id, file_name, status, row_count, valid_count, invalid_count,
+			total_amount, paid_count, failed_count, paid_amount, available_balance, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`,
+		batch.ID,
+		batch.AccountID,
+		batch.CustomerID,
+		batch.FileName,
+		batch.Status,
+		batch.RowCount,
+		batch.ValidCount,
+		batch.InvalidCount,
+		batch.TotalAmount,
+		batch.PaidCount,
+		batch.FailedCount,
+		batch.PaidAmount,
+		batch.AvailableBalance,
+		batch.CreatedAt,
+		batch.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO bulk_payment_rows (
+				id, bulk_payment_id, line_number, to_account_number, to_account_id, amount, reference,
+				description, status, error
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		`,
+			row.ID,
+			row.BulkPaymentID,
+			row.LineNumber,
+			row.ToAccountNumber,
+			row.ToAccountID,
+			row.Amount,
+			row.Reference,
+			row.Description,
+			row.Status,
+			row.Error,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetBulkPayment retrieves a bulk payment file by ID
+func (r *PostgresBulkPaymentRepository) GetBulkPayment(ctx context.Context, id uuid.UUID) (*models.BulkPayment, error) {
+	query := `SELECT ` + bulkPaymentColumns + ` FROM bulk_payments WHERE id = $1`
+
+	batch, err := scanBulkPayment(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	return batch, nil
+}
+
+// GetBulkPaymentRows retrieves the rows of a bulk payment file in file order
+func (r *PostgresBulkPaymentRepository) GetBulkPaymentRows(ctx context.Context, bulkPaymentID uuid.UUID) ([]*models.BulkPaymentRow, error) {
+	query := `
+		SELECT id, bulk_payment_id, line_number, to_account_number, to_account_id, amount, reference,
+		       description, status, error, transaction_id, processed_at
+		FROM bulk_payment_rows
+		WHERE bulk_payment_id = $1
+		ORDER BY line_number
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, bulkPaymentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*models.BulkPaymentRow{}
+	for rows.Next() {
+		var row models.BulkPaymentRow
+		var toAccountID, transactionID uuid.NullUUID
+		var processedAt sql.NullTime
+		err := rows.Scan(
+			&row.ID,
+			&row.BulkPaymentID,
+			&row.LineNumber,
+			&row.ToAccountNumber,
+			&toAccountID,
+			&row.Amount,
+			&row.Reference,
+			&row.Description,
+			&row.Status,
+			&row.Error,
+			&transactionID,
+			&processedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if toAccountID.Valid {
+			row.ToAccountID = &toAccountID.UUID
+		}
+		if transactionID.Valid {
+			row.TransactionID = &transactionID.UUID
+		}
+		if processedAt.Valid {
+			row.ProcessedAt = &processedAt.Time
+		}
+		list = append(list, &row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// UpdateBulkPaymentStatus saves the status of a file if it is still in
+// status from, and reports whether it changed
+func (r *PostgresBulkPaymentRepository) UpdateBulkPaymentStatus(ctx context.Context, batch *models.BulkPayment, from models.BulkPaymentStatus) (bool, error) {
+	query := `
+		UPDATE bulk_payments
+		SET status = $1, confirmed_at = $2, updated_at = $3
+		WHERE id = $4 AND status = $5
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		batch.Status,
+		batch.ConfirmedAt,
+		batch.UpdatedAt,
+		batch.ID,
+		from,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
+
+// GetRunnableBulkPayments retrieves the confirmed files that are not completed, oldest first
+func (r *PostgresBulkPaymentRepository) GetRunnableBulkPayments(ctx context.Context) ([]*models.BulkPayment, error) {
+	query := `
+		SELECT ` + bulkPaymentColumns + `
+		FROM bulk_payments
+		WHERE status IN ('queued', 'processing')
+		ORDER BY confirmed_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := []*models.BulkPayment{}
+	for rows.Next() {
+		batch, err := scanBulkPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return batches, nil
+}
+
+// UpdateBulkPaymentRow saves the outcome of a row
+func (r *PostgresBulkPaymentRepository) UpdateBulkPaymentRow(ctx context.Context, row *models.BulkPaymentRow) error {
+	query := `
+		UPDATE bulk_payment_rows
+		SET status = $1, error = $2, transaction_id = $3, processed_at = $4
+		WHERE id = $5
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		row.Status,
+		row.Error,
+		row.TransactionID,
+		row.ProcessedAt,
+		row.ID,
+	)
+	return err
+}
+
+// CompleteBulkPayment saves the totals of an executed file and marks it completed
+func (r *PostgresBulkPaymentRepository) CompleteBulkPayment(ctx context.Context, batch *models.BulkPayment) error {
+	query := `
+		UPDATE bulk_payments
+		SET status = 'completed', paid_count = $1, failed_count = $2, paid_amount = $3, completed_at = $4, updated_at = $5
+		WHERE id = $6
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		batch.PaidCount,
+		batch.FailedCount,
+		batch.PaidAmount,
+		batch.CompletedAt,
+		batch.UpdatedAt,
+		batch.ID,
+	)
+	return err
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrDuplicateRRN is returned when an authorization with the same retrieval reference number exists
+var ErrDuplicateRRN = errors.New("an authorization with this retrieval reference number already exists")
+
+// CardAuthorizationRepository defines operations for card authorizations
+type CardAuthorizationRepository interface {
+	CreateCardAuthorization(ctx context.Context, authorization *models.CardAuthorization) error
+	GetCardAuthorizationByRRN(ctx context.Context, rrn string) (*models.CardAuthorization, error)
+	ReserveCardUsage(ctx context.Context, cardID uuid.UUID, atm bool, date time.Time, amount, limit float64) (bool, float64, error)
+	ReleaseCardUsage(ctx context.Context, cardID uuid.UUID, atm bool, date time.Time, amount float64) error
+	ClearCardAuthorization(ctx context.Context, authorization *models.CardAuthorization) (bool, error)
+}
+
+// PostgresCardAuthorizationRepository implements CardAuthorizationRepository for PostgreSQL
+type PostgresCardAuthorizationRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresCardAuthorizationRepository creates a new PostgresCardAuthorizationRepository
+func NewPostgresCardAuthorizationRepository(db *sql.DB) *PostgresCardAuthorizationRepository {
+	return &PostgresCardAuthorizationRepository{
+		db: db,
+	}
+}
+
+// cardAuthorizationColumns lists the columns read by scanCardAuthorization, in order
+const cardAuthorizationColumns = `id, rrn, card_id, account_id, amount, merchant_category, merchant_name, country,
+		       channel, status, response_code, decline_reason, approval_code, hold_id, cleared_amount,
+		       transaction_id, cleared_at, created_at, updated_at`
+
+func scanCardAuthorization(row rowScanner) (*models.CardAuthorization, error) {
+	var authorization models.CardAuthorization
+	var cardID, accountID, holdID, transactionID uuid.NullUUID
+	var clearedAmount sql.NullFloat64
+	var clearedAt sql.NullTime
+
+	err := row.Scan(
+		&authorization.ID,
+		&authorization.RRN,
+		&cardID,
+		&accountID,
+		&authorization.Amount,
+		&authorization.MerchantCategory,
+		&authorization.MerchantName,
+		&authorization.Country,
+		&authorization.Channel,
+		&authorization.Status,
+		&authorization.ResponseCode,
+		&authorization.DeclineReason,
+		&authorization.ApprovalCode,
+		&holdID,
+		&clearedAmount,
+		&transactionID,
+		&clearedAt,
+		&authorization.CreatedAt,
+		&authorization.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if cardID.Valid {
+		authorization.CardID = &cardID.UUID
+	}
+	if accountID.Valid {
+		authorization.AccountID = &accountID.UUID
+	}
+	if holdID.Valid {
+		authorization.HoldID = &holdID.UUID
+	}
+	if clearedAmount.Valid {
+		authorization.ClearedAmount = &clearedAmount.Float64
+	}
+	if transactionID.Valid {
+		authorization.TransactionID = &transactionID.UUID
+	}
+	if clearedAt.Valid {
+		authorization.ClearedAt = &clearedAt.Time
+	}
+	return &authorization, nil
+}
+
+// CreateCardAuthorization inserts an approved or declined authorization. A
+// retrieval reference number already in use returns ErrDuplicateRRN.
+func (r *PostgresCardAuthorizationRepository) CreateCardAuthorization(ctx context.Context, authorization *models.CardAuthorization) error {
+	query := `
+		INSERT INTO card_authorizations (
+			id, rrn, card_id, account_id, amount, merchant_category, merchant_name, country,
+			channel, status, response_code, decline_reason, approval_code, hold_id, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		authorization.ID,
+		authorization.RRN,
+		authorization.CardID,
+		authorization.AccountID,
+		authorization.Amount,
+		authorization.MerchantCategory,
+		authorization.MerchantName,
+		authorization.Country,
+		authorization.Channel,
+		authorization.Status,
+		authorization.ResponseCode,
+		authorization.DeclineReason,
+		authorization.ApprovalCode,
+		authorization.HoldID,
+		authorization.CreatedAt,
+		authorization.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return ErrDuplicateRRN
+	}
+	return err
+}
+
+// GetCardAuthorizationByRRN retrieves an authorization by its retrieval reference number
+func (r *PostgresCardAuthorizationRepository) GetCardAuthorizationByRRN(ctx context.Context, rrn string) (*models.CardAuthorization, error) {
+	query := `SELECT ` + cardAuthorizationColumns + ` FROM card_authorizations WHERE rrn = $1`
+
+	authorization, err := scanCardAuthorization(r.db.QueryRowContext(ctx, query, rrn))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	return authorization, nil
+}
+
+// ReserveCardUsage adds amount to the card's usage on the date, of ATM
+// withdrawals when atm is set and of purchases otherwise, if the total
+// stays within limit. It reports whether the amount was reserved and
+// returns the usage after the reservation, or as it stands when refused.
+// The upsert locks the usage row, so concurrent authorizations cannot
+// pass the limit together.
+func (r *PostgresCardAuthorizationRepository) ReserveCardUsage(ctx context.Context, cardID uuid.UUID, atm bool, date time.Time, amount, limit float64) (bool, float64, error) {
+	query := `
+		INSERT INTO card_limit_usage (card_id, atm, usage_date, amount, updated_at)
+		SELECT $1, $2, $3, $4, NOW()
+		WHERE $4 <= $5
+		ON CONFLICT (card_id, atm, usage_date) DO UPDATE
+		SET amount = card_limit_usage.amount + EXCLUDED.amount, updated_at = EXCLUDED.updated_at
+		WHERE card_limit_usage.amount + EXCLUDED.amount <= $5
+		RETURNING amount
+	`
+
+	var used float64
+	err := r.db.QueryRowContext(ctx, query, cardID, atm, date, amount, limit).Scan(&used)
+	if err == nil {
+		return true, used, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, 0, err
+	}
+
+	err = r.db.QueryRowContext(ctx, `
+		SELECT amount FROM card_limit_usage
+		WHERE card_id = $1 AND atm = $2 AND usage_date = $3
+	`, cardID, atm, date).Scan(&used)
+	if err != nil && err != sql.ErrNoRows {
+		return false, 0, err
+	}
+	return false, used, nil
+}
+
+// ReleaseCardUsage gives back usage reserved for an authorization that was not approved
+func (r *PostgresCardAuthorizationRepository) ReleaseCardUsage(ctx context.Context, cardID uuid.UUID, atm bool, date time.Time, amount float64) error {
+	query := `
+		UPDATE card_limit_usage
+		SET amount = GREATEST(amount - $4, 0), updated_at = NOW()
+		WHERE card_id = $1 AND atm = $2 AND usage_date = $3
+	`
+
+	_, err := r.db.ExecContext(ctx, query, cardID, atm, date, amount)
+	return err
+}
+
+// ClearCardAuthorization saves the clearing of an authorization if it is
+// still approved, and reports whether it was
+func (r *PostgresCardAuthorizationRepository) ClearCardAuthorization(ctx context.Context, authorization *models.CardAuthorization) (bool, error) {
+	query := `
+		UPDATE card_authorizations
+		SET status = $1, cleared_amount = $2, transaction_id = $3, cleared_at = $4, updated_at = $5
+		WHERE id = $6 AND status = 'approved'
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		authorization.Status,
+		authorization.ClearedAmount,
+		authorization.TransactionID,
+		authorization.ClearedAt,
+		authorization.UpdatedAt,
+		authorization.ID,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
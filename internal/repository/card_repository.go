@@ -0,0 +1,431 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrDuplicateCardNumber is returned when a generated card number is already in use
+var ErrDuplicateCardNumber = errors.New("card number already exists")
+
+// CardRepository defines operations for payment cards
+type CardRepository interface {
+	CreateCard(ctx context.Context, card *models.Card) error
+	GetCard(ctx context.Context, id uuid.UUID) (*models.Card, error)
+	GetCardByPANHash(ctx context.Context, panHash string) (*models.Card, error)
+	FindCardsByLast4(ctx context.Context, last4 string, customerID *uuid.UUID) ([]*models.Card, error)
+	GetRequestedCard(ctx context.Context, accountID, customerID uuid.UUID) (*models.Card, error)
+	HasOpenCards(ctx context.Context, accountID uuid.UUID) (bool, error)
+	UpdateCard(ctx context.Context, card *models.Card, from models.CardStatus) (bool, error)
+	UseActivationAttempt(ctx context.Context, cardID uuid.UUID, max int) (int, bool, error)
+	SearchCards(ctx context.Context, filter models.CardSearchFilter, limit int) ([]*models.Card, error)
+	CreateCardAudit(ctx context.Context, entry *models.CardAuditEntry) error
+	GetCardControls(ctx context.Context, cardID uuid.UUID) (*models.CardControls, error)
+	SaveCardControls(ctx context.Context, controls *models.CardControls, audit *models.CardAuditEntry) error
+}
+
+// PostgresCardRepository implements CardRepository for PostgreSQL
+type PostgresCardRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresCardRepository creates a new PostgresCardRepository
+func NewPostgresCardRepository(db *sql.DB) *PostgresCardRepository {
+	return &PostgresCardRepository{
+		db: db,
+	}
+}
+
+// cardColumns lists the columns read by scanCard, in order
+const cardColumns = `id, account_id, customer_id, card_type, last4, status, pan_encrypted, pan_hash,
+		       expiry_month, expiry_year, cvv_hash, activation_code_hash, activation_attempts, issued_at,
+		       activated_at, lost_stolen_at, lost_stolen_by, replaces_card_id, created_at, updated_at`
+
+func scanCard(row rowScanner) (*models.Card, error) {
+	var card models.Card
+	var issuedAt, activatedAt, lostStolenAt sql.NullTime
+	var lostStolenBy, replacesCardID uuid.NullUUID
+
+	err := row.Scan(
+		&card.ID,
+		&card.AccountID,
+		&card.CustomerID,
+		&card.CardType,
+		&card.Last4,
+		&card.Status,
+		&card.PANEncrypted,
+		&card.PANHash,
+		&card.ExpiryMonth,
+		&card.ExpiryYear,
+		&card.CVVHash,
+		&card.ActivationCodeHash,
+		&card.ActivationAttempts,
+		&issuedAt,
+		&activatedAt,
+		&lostStolenAt,
+		&lostStolenBy,
+		&replacesCardID,
+		&card.CreatedAt,
+		&card.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if issuedAt.Valid {
+		card.IssuedAt = &issuedAt.Time
+	}
+	if activatedAt.Valid {
+		card.ActivatedAt = &activatedAt.Time
+	}
+	if lostStolenAt.Valid {
+		card.LostStolenAt = &lostStolenAt.Time
+	}
+	if lostStolenBy.Valid {
+		card.LostStolenBy = &lostStolenBy.UUID
+	}
+	if replacesCardID.Valid {
+		card.ReplacesCardID = &replacesCardID.UUID
+	}
+	return &card, nil
+}
+
+// CreateCard inserts a card. A card number already in use returns ErrDuplicateCardNumber.
+func (r *PostgresCardRepository) CreateCard(ctx context.Context, card *models.Card) error {
+	query := `
+		INSERT INTO cards (
+			id, account_id, customer_id, card_type, last4, status, pan_encrypted, pan_hash,
+			expiry_month, expiry_year, cvv_hash, activation_code_hash, issued_at, replaces_card_id,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		card.ID,
+		card.AccountID,
+		card.CustomerID,
+		card.CardType,
+		card.Last4,
+		card.Status,
+		card.PANEncrypted,
+		card.PANHash,
+		card.ExpiryMonth,
+		card.ExpiryYear,
+		card.CVVHash,
+		card.ActivationCodeHash,
+		card.IssuedAt,
+		card.ReplacesCardID,
+		card.CreatedAt,
+		card.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return ErrDuplicateCardNumber
+	}
+	return err
+}
+
+// GetCard retrieves a card by ID
+func (r *PostgresCardRepository) GetCard(ctx context.Context, id uuid.UUID) (*models.Card, error) {
+	query := `SELECT ` + cardColumns + ` FROM cards WHERE id = $1`
+	return r.queryCard(ctx, query, id)
+}
+
+// GetCardByPANHash retrieves the card whose number has the hash
+func (r *PostgresCardRepository) GetCardByPANHash(ctx context.Context, panHash string) (*models.Card, error) {
+	query := `SELECT ` + cardColumns + ` FROM cards WHERE pan_hash = $1`
+	return r.queryCard(ctx, query, panHash)
+}
+
+func (r *PostgresCardRepository) queryCard(ctx context.Context, query string, args ...interface{}) (*models.Card, error) {
+	card, err := scanCard(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	return card, nil
+}
+
+// FindCardsByLast4 retrieves the cards ending in last4, of one customer when
+// customerID is set. Replacements not sent out yet are left out.
+func (r *PostgresCardRepository) FindCardsByLast4(ctx context.Context, last4 string, customerID *uuid.UUID) ([]*models.Card, error) {
+	query := `
+		SELECT ` + cardColumns + `
+		FROM cards
+		WHERE last4 = $1 AND status <> 'requested' AND ($2::uuid IS NULL OR customer_id = $2)
+		ORDER BY created_at DESC
+	`
+
+	var customer uuid.NullUUID
+	if customerID != nil {
+		customer = uuid.NullUUID{UUID: *customerID, Valid: true}
+	}
+	rows, err := r.db.QueryContext(ctx, query, last4, customer)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cards := []*models.Card{}
+	for rows.Next() {
+		card, err := scanCard(rows)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return cards, nil
+}
+
+// GetRequestedCard retrieves the oldest card of the customer on the account that was not sent out yet
+func (r *PostgresCardRepository) GetRequestedCard(ctx context.Context, accountID, customerID uuid.UUID) (*models.Card, error) {
+	query := `
+		SELECT ` + cardColumns + `
+		FROM cards
+		WHERE account_id = $1 AND customer_id = $2 AND status = 'requested'
+		ORDER BY created_at
+		LIMIT 1
+	`
+	return r.queryCard(ctx, query, accountID, customerID)
+}
+
+// HasOpenCards reports whether the account has a card that is requested,
+// issued, active or blocked, that is any card not reported lost or stolen
+func (r *PostgresCardRepository) HasOpenCards(ctx context.Context, accountID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM cards WHERE account_id = $1 AND status = ANY($2))`
+	open := []string{
+		string(models.CardRequested),
+		string(models.CardIssued),
+		string(models.CardActive),
+		string(models.CardBlocked),
+	}
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, accountID, pq.Array(open)).Scan(&exists)
+	return exists, err
+}
+
+// UpdateCard saves the status, secrets and activation details of a card if
+// it is still in status from, and reports whether it did
+func (r *PostgresCardRepository) UpdateCard(ctx context.Context, card *models.Card, from models.CardStatus) (bool, error) {
+	query := `
+		UPDATE cards SET
+			status = $1, cvv_hash = $2, activation_code_hash = $3, activation_attempts = $4, issued_at = $5,
+			activated_at = $6, lost_stolen_at = $7, lost_stolen_by = $8, updated_at = $9
+		WHERE id = $10 AND status = $11
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		card.Status,
+		card.CVVHash,
+		card.ActivationCodeHash,
+		card.ActivationAttempts,
+		card.IssuedAt,
+		card.ActivatedAt,
+		card.LostStolenAt,
+		card.LostStolenBy,
+		card.UpdatedAt,
+		card.ID,
+		from,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
+
+// UseActivationAttempt counts one activation attempt on an issued card with
+// fewer than max attempts and returns the attempts made, this one included.
+// It reports false when the card is no longer issued or has no attempts
+// left. The count is incremented in the database, so concurrent attempts
+// cannot exceed max.
+func (r *PostgresCardRepository) UseActivationAttempt(ctx context.Context, cardID uuid.UUID, max int) (int, bool, error) {
+	query := `
+		UPDATE cards SET activation_attempts = activation_attempts + 1, updated_at = $1
+		WHERE id = $2 AND status = $3 AND activation_attempts < $4
+		RETURNING activation_attempts
+	`
+
+	var attempts int
+	err := r.db.QueryRowContext(ctx, query, time.Now(), cardID, models.CardIssued, max).Scan(&attempts)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return attempts, true, nil
+}
+
+// SearchCards retrieves up to limit cards ending in filter.PANLast4 that
+// match the other filters, newest first
+func (r *PostgresCardRepository) SearchCards(ctx context.Context, filter models.CardSearchFilter, limit int) ([]*models.Card, error) {
+	query := `
+		SELECT ` + cardColumns + `
+		FROM cards
+		WHERE last4 = $1
+		  AND ($2::uuid IS NULL OR customer_id = $2)
+		  AND ($3 = '' OR status = $3)
+		  AND ($4::timestamp IS NULL OR issued_at >= $4)
+		  AND ($5::timestamp IS NULL OR issued_at < $5)
+		ORDER BY created_at DESC
+		LIMIT $6
+	`
+
+	var customer uuid.NullUUID
+	if filter.CustomerID != nil {
+		customer = uuid.NullUUID{UUID: *filter.CustomerID, Valid: true}
+	}
+	var issuedFrom, issuedBefore sql.NullTime
+	if filter.IssuedFrom != nil {
+		issuedFrom = sql.NullTime{Time: *filter.IssuedFrom, Valid: true}
+	}
+	if filter.IssuedTo != nil {
+		issuedBefore = sql.NullTime{Time: filter.IssuedTo.AddDate(0, 0, 1), Valid: true}
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, filter.PANLast4, customer, filter.Status, issuedFrom, issuedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cards := []*models.Card{}
+	for rows.Next() {
+		card, err := scanCard(rows)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return cards, nil
+}
+
+// insertCardAudit is the statement that adds an entry to the card audit trail
+const insertCardAudit = `
+		INSERT INTO card_audit (id, card_id, action, actor_id, details, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+// CreateCardAudit inserts an entry into the card audit trail
+func (r *PostgresCardRepository) CreateCardAudit(ctx context.Context, entry *models.CardAuditEntry) error {
+	_, err := r.db.ExecContext(ctx, insertCardAudit,
+		entry.ID,
+		entry.CardID,
+		entry.Action,
+		entry.ActorID,
+		entry.Details,
+		entry.CreatedAt,
+	)
+	return err
+}
+
+// GetCardControls retrieves the controls saved for a card, or nil if the
+// customer never changed them
+func (r *PostgresCardRepository) GetCardControls(ctx context.Context, cardID uuid.UUID) (*models.CardControls, error) {
+	query := `
+		SELECT card_id, atm_daily_limit, pos_daily_limit, ecommerce_enabled, contactless_enabled,
+		       international_enabled, blocked_merchant_categories, updated_at
+		FROM card_controls
+		WHERE card_id = $1
+	`
+
+	var controls models.CardControls
+	var blocked []byte
+	err := r.db.QueryRowContext(ctx, query, cardID).Scan(
+		&controls.CardID,
+		&controls.ATMDailyLimit,
+		&controls.POSDailyLimit,
+		&controls.EcommerceEnabled,
+		&controls.ContactlessEnabled,
+		&controls.InternationalEnabled,
+		&blocked,
+		&controls.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(blocked, &controls.BlockedMerchantCategories); err != nil {
+		return nil, err
+	}
+	return &controls, nil
+}
+
+// SaveCardControls creates or replaces the controls of a card and records
+// the change in the card audit trail, in one transaction
+func (r *PostgresCardRepository) SaveCardControls(ctx context.Context, controls *models.CardControls, audit *models.CardAuditEntry) error {
+	blocked, err := json.Marshal(controls.BlockedMerchantCategories)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO card_controls (
+			card_id, atm_daily_limit, pos_daily_limit, ecommerce_enabled, contactless_enabled,
+			international_enabled, blocked_merchant_categories, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (card_id) DO UPDATE SET
+			atm_daily_limit = EXCLUDED.atm_daily_limit,
+			pos_daily_limit = EXCLUDED.pos_daily_limit,
+			ecommerce_enabled = EXCLUDED.ecommerce_enabled,
+			contactless_enabled = EXCLUDED.contactless_enabled,
+			international_enabled = EXCLUDED.international_enabled,
+			blocked_merchant_categories = EXCLUDED.blocked_merchant_categories,
+			updated_at = EXCLUDED.updated_at
+	`,
+		controls.CardID,
+		controls.ATMDailyLimit,
+		controls.POSDailyLimit,
+		controls.EcommerceEnabled,
+		controls.ContactlessEnabled,
+		controls.InternationalEnabled,
+		blocked,
+		controls.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, insertCardAudit,
+		audit.ID,
+		audit.CardID,
+		audit.Action,
+		audit.ActorID,
+		audit.Details,
+		audit.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+)
+
+// DormancyRepository defines operations for marking accounts dormant and reactivating them
+type DormancyRepository interface {
+	MarkDormant(ctx context.Context, inactiveSince time.Time, now time.Time) ([]uuid.UUID, error)
+	Reactivate(ctx context.Context, event *models.DormancyEvent) (bool, error)
+	RecordEvent(ctx context.Context, event *models.DormancyEvent) error
+	CountEvents(ctx context.Context, accountID uuid.UUID, eventType models.DormancyEventType, since time.Time) (int, error)
+	GetAccountsInactiveBetween(ctx context.Context, from, to time.Time) ([]*models.Account, error)
+	GetDormancyEvents(ctx context.Context, accountID uuid.UUID) ([]*models.DormancyEvent, error)
+}
+
+// PostgresDormancyRepository implements DormancyRepository for PostgreSQL
+type PostgresDormancyRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresDormancyRepository creates a new PostgresDormancyRepository
+func NewPostgresDormancyRepository(db *sql.DB) *PostgresDormancyRepository {
+	return &PostgresDormancyRepository{
+		db: db,
+	}
+}
+
+// MarkDormant marks active savings and current accounts whose last activity
+// is before inactiveSince as dormant and records an event for each. It
+// returns the IDs of the accounts changed.
+func (r *PostgresDormancyRepository) MarkDormant(ctx context.Context, inactiveSince time.Time, now time.Time) ([]uuid.UUID, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE accounts
+		SET status = $1, dormant_since = $2, updated_at = $2
+		WHERE status = $3 AND account_type IN ($4, $5) AND last_activity_at < $6
+		RETURNING id
+	`, models.AccountStatusDormant, now, models.AccountStatusActive,
+		models.AccountTypeSavings, models.AccountTypeCurrent, inactiveSince)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		err := insertDormancyEvent(ctx, tx, &models.DormancyEvent{
+			ID:        uuid.New(),
+			AccountID: id,
+			Event:     models.DormancyMarked,
+			Method:    models.ReactivationByJob,
+			CreatedAt: now,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ids, tx.Commit()
+}
+
+// Reactivate makes a dormant account active again, counting the
+// reactivation as activity, and records the event. It returns false when the
+// account is not dormant.
+func (r *PostgresDormancyRepository) Reactivate(ctx context.Context, event *models.DormancyEvent) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE accounts
+		SET status = $1, dormant_since = NULL, last_activity_at = $2, updated_at = $2
+		WHERE id = $3 AND status = $4
+	`, models.AccountStatusActive, event.CreatedAt, event.AccountID, models.AccountStatusDormant)
+	if err != nil {
+		return false, err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if updated == 0 {
+		return false, nil
+	}
+
+	if err := insertDormancyEvent(ctx, tx, event); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// RecordEvent stores a dormancy event that does not change the account
+func (r *PostgresDormancyRepository) RecordEvent(ctx context.Context, event *models.DormancyEvent) error {
+	_, err := r.db.ExecContext(ctx, insertDormancyEventQuery,
+		event.ID, event.AccountID, event.Event, event.Method, event.ActorID, event.Reason, event.CreatedAt)
+	return err
+}
+
+// CountEvents returns how many events of a type were recorded for the account since a time
+func (r *PostgresDormancyRepository) CountEvents(ctx context.Context, accountID uuid.UUID, eventType models.DormancyEventType, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM account_dormancy_events WHERE account_id = $1 AND event = $2 AND created_at >= $3`
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, accountID, eventType, since).Scan(&count)
+	return count, err
+}
+
+// GetAccountsInactiveBetween retrieves active savings and current accounts
+// whose last activity is in [from, to), least recently used first
+func (r *PostgresDormancyRepository) GetAccountsInactiveBetween(ctx context.Context, from, to time.Time) ([]*models.Account, error) {
+	query := `
+		SELECT ` + accountColumns + `
+		FROM accounts
+		WHERE status = $1 AND account_type IN ($2, $3) AND last_activity_at >= $4 AND last_activity_at < $5
+		ORDER BY last_activity_at, account_number
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, models.AccountStatusActive,
+		models.AccountTypeSavings, models.AccountTypeCurrent, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return collectAccounts(rows)
+}
+
+// GetDormancyEvents retrieves the dormancy history of an account, oldest first
+func (r *PostgresDormancyRepository) GetDormancyEvents(ctx context.Context, accountID uuid.UUID) ([]*models.DormancyEvent, error) {
+	query := `
+		SELECT id, account_id, event, method, actor_id, reason, created_at
+		FROM account_dormancy_events
+		WHERE account_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*models.DormancyEvent{}
+	for rows.Next() {
+		var event models.DormancyEvent
+		var actorID uuid.NullUUID
+		err := rows.Scan(
+			&event.ID,
+			&event.AccountID,
+			&event.Event,
+			&event.Method,
+			&actorID,
+			&event.Reason,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if actorID.Valid {
+			event.ActorID = &actorID.UUID
+		}
+		events = append(events, &event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+const insertDormancyEventQuery = `
+	INSERT INTO account_dormancy_events (id, account_id, event, method, actor_id, reason, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+`
+
+func insertDormancyEvent(ctx context.Context, tx *sql.Tx, event *models.DormancyEvent) error {
+	_, err := tx.ExecContext(ctx, insertDormancyEventQuery,
+		event.ID, event.AccountID, event.Event, event.Method, event.ActorID, event.Reason, event.CreatedAt)
+	return err
+}
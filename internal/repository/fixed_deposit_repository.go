@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+)
+
+// FixedDepositRepository defines operations for fixed deposit term details
+type FixedDepositRepository interface {
+	GetFixedDeposit(ctx context.Context, accountID uuid.UUID) (*models.FixedDeposit, error)
+	GetDueFixedDeposits(ctx context.Context, businessDate time.Time) ([]*models.FixedDeposit, error)
+	UpdateFixedDeposit(ctx context.Context, deposit *models.FixedDeposit) error
+}
+
+// PostgresFixedDepositRepository implements FixedDepositRepository for PostgreSQL
+type PostgresFixedDepositRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresFixedDepositRepository creates a new PostgresFixedDepositRepository
+func NewPostgresFixedDepositRepository(db *sql.DB) *PostgresFixedDepositRepository {
+	return &PostgresFixedDepositRepository{
+		db: db,
+	}
+}
+
+// fixedDepositColumns lists the columns read by scanFixedDeposit, in order
+const fixedDepositColumns = `account_id, principal, term_months, annual_rate, start_date, maturity_date,
+		       maturity_instruction, linked_account_id, status, renewals, updated_at`
+
+func scanFixedDeposit(row rowScanner) (*models.FixedDeposit, error) {
+	var deposit models.FixedDeposit
+	err := row.Scan(
+		&deposit.AccountID,
+		&deposit.Principal,
+		&deposit.TermMonths,
+		&deposit.AnnualRate,
+		&deposit.StartDate,
+		&deposit.MaturityDate,
+		&deposit.MaturityInstruction,
+		&deposit.LinkedAccountID,
+		&deposit.Status,
+		&deposit.Renewals,
+		&deposit.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &deposit, nil
+}
+
+// createFixedDeposit inserts the term details of a new fixed deposit as part
+// of the ledger transaction that funds it
+func createFixedDeposit(ctx context.Context, tx *sql.Tx, deposit *models.FixedDeposit) error {
+	query := `
+		INSERT INTO fixed_deposits (` + fixedDepositColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		deposit.AccountID,
+		deposit.Principal,
+		deposit.TermMonths,
+		deposit.AnnualRate,
+		deposit.StartDate,
+		deposit.MaturityDate,
+		deposit.MaturityInstruction,
+		deposit.LinkedAccountID,
+		deposit.Status,
+		deposit.Renewals,
+		deposit.UpdatedAt,
+	)
+	return err
+}
+
+// GetFixedDeposit retrieves the term details of a fixed deposit account
+func (r *PostgresFixedDepositRepository) GetFixedDeposit(ctx context.Context, accountID uuid.UUID) (*models.FixedDeposit, error) {
+	query := `SELECT ` + fixedDepositColumns + ` FROM fixed_deposits WHERE account_id = $1`
+
+	deposit, err := scanFixedDeposit(r.db.QueryRowContext(ctx, query, accountID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	return deposit, nil
+}
+
+// GetDueFixedDeposits retrieves active deposits maturing on or before businessDate
+func (r *PostgresFixedDepositRepository) GetDueFixedDeposits(ctx context.Context, businessDate time.Time) ([]*models.FixedDeposit, error) {
+	query := `
+		SELECT ` + fixedDepositColumns + `
+		FROM fixed_deposits
+		WHERE status = $1 AND maturity_date <= $2
+		ORDER BY maturity_date
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, models.FixedDepositActive, businessDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deposits := []*models.FixedDeposit{}
+	for rows.Next() {
+		deposit, err := scanFixedDeposit(rows)
+		if err != nil {
+			return nil, err
+		}
+		deposits = append(deposits, deposit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deposits, nil
+}
+
+// UpdateFixedDeposit saves the term details after a renewal, maturity or withdrawal
+func (r *PostgresFixedDepositRepository) UpdateFixedDeposit(ctx context.Context, deposit *models.FixedDeposit) error {
+	query := `
+		UPDATE fixed_deposits
+		SET principal = $1, term_months = $2, annual_rate = $3, start_date = $4, maturity_date = $5,
+		    status = $6, renewals = $7, updated_at = $8
+		WHERE account_id = $9
+	`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		deposit.Principal,
+		deposit.TermMonths,
+		deposit.AnnualRate,
+		deposit.StartDate,
+		deposit.MaturityDate,
+		deposit.Status,
+		deposit.Renewals,
+		deposit.UpdatedAt,
+		deposit.AccountID,
+	)
+	return err
+}
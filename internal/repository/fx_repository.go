@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+)
+
+// FXRepository defines operations for exchange rates and conversion quotes
+type FXRepository interface {
+	SaveRates(ctx context.Context, rates []*models.FXRate) error
+	GetCurrentRates(ctx context.Context, at time.Time) ([]*models.FXRate, error)
+	CreateQuote(ctx context.Context, quote *models.FXQuote) error
+	GetQuote(ctx context.Context, id uuid.UUID) (*models.FXQuote, error)
+	UpdateQuoteStatus(ctx context.Context, quote *models.FXQuote, from models.FXQuoteStatus) (bool, error)
+}
+
+// PostgresFXRepository implements FXRepository for PostgreSQL
+type PostgresFXRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresFXRepository creates a new PostgresFXRepository
+func NewPostgresFXRepository(db *sql.DB) *PostgresFXRepository {
+	return &PostgresFXRepository{
+		db: db,
+	}
+}
+
+// SaveRates inserts a rate table upload in one transaction
+func (r *PostgresFXRepository) SaveRates(ctx context.Context, rates []*models.FXRate) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, rate := range rates {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO fx_rates (id, currency, mid_rate, buy_spread, sell_spread, effective_at, uploaded_by, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`,
+			rate.ID,
+			rate.Currency,
+			rate.MidRate,
+			rate.BuySpread,
+			rate.SellSpread,
+			rate.EffectiveAt,
+			rate.UploadedBy,
+			rate.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetCurrentRates retrieves the latest rate of every currency in effect at time at
+func (r *PostgresFXRepository) GetCurrentRates(ctx context.Context, at time.Time) ([]*models.FXRate, error) {
+	query := `
+		SELECT DISTINCT ON (currency)
+		       id, currency, mid_rate, buy_spread, sell_spread, effective_at, uploaded_by, created_at
+		FROM fx_rates
+		WHERE effective_at <= $1
+		ORDER BY currency, effective_at DESC, created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []*models.FXRate{}
+	for rows.Next() {
+		var rate models.FXRate
+		err := rows.Scan(
+			&rate.ID,
+			&rate.Currency,
+			&rate.MidRate,
+			&rate.BuySpread,
+			&rate.SellSpread,
+			&rate.EffectiveAt,
+			&rate.UploadedBy,
+			&rate.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		rate.ApplySpreads()
+		rates = append(rates, &rate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// CreateQuote inserts a conversion quote
+func (r *PostgresFXRepository) CreateQuote(ctx context.Context, quote *models.FXQuote) error {
+	query := `
+		INSERT INTO fx_quotes (
+			id, customer_id, from_account_id, to_account_id, from_amount, from_currency, to_amount, to_currency,
+			rate, from_base_value, to_base_value, description, status, expires_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		quote.ID,
+		quote.CustomerID,
+		quote.FromAccountID,
+		quote.ToAccountID,
+		quote.From.Amount,
+		quote.From.Currency,
+		quote.To.Amount,
+		quote.To.Currency,
+		quote.Rate,
+		quote.FromBaseValue,
+		quote.ToBaseValue,
+		quote.Description,
+		quote.Status,
+		quote.ExpiresAt,
+		quote.CreatedAt,
+		quote.UpdatedAt,
+	)
+	return err
+}
+
+// GetQuote retrieves a conversion quote by ID
+func (r *PostgresFXRepository) GetQuote(ctx context.Context, id uuid.UUID) (*models.FXQuote, error) {
+	query := `
+		SELECT id, customer_id, from_account_id, to_account_id, from_amount, from_currency, to_amount, to_currency,
+		       rate, from_base_value, to_base_value, description, status, transaction_id, expires_at, created_at, updated_at
+		FROM fx_quotes
+		WHERE id = $1
+	`
+
+	var quote models.FXQuote
+	var transactionID uuid.NullUUID
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&quote.ID,
+		&quote.CustomerID,
+		&quote.FromAccountID,
+		&quote.ToAccountID,
+		&quote.From.Amount,
+		&quote.From.Currency,
+		&quote.To.Amount,
+		&quote.To.Currency,
+		&quote.Rate,
+		&quote.FromBaseValue,
+		&quote.ToBaseValue,
+		&quote.Description,
+		&quote.Status,
+		&transactionID,
+		&quote.ExpiresAt,
+		&quote.CreatedAt,
+		&quote.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+
+	if transactionID.Valid {
+		quote.TransactionID = &transactionID.UUID
+	}
+	return &quote, nil
+}
+
+// UpdateQuoteStatus saves the status and transaction of a quote if it is
+// still in status from, and reports whether it changed
+func (r *PostgresFXRepository) UpdateQuoteStatus(ctx context.Context, quote *models.FXQuote, from models.FXQuoteStatus) (bool, error) {
+	query := `
+		UPDATE fx_quotes SET status = $1, transaction_id = $2, updated_at = $3
+		WHERE id = $4 AND status = $5
+	`
+
+	result, err := r.db.ExecContext(ctx, query, quote.Status, quote.TransactionID, quote.UpdatedAt, quote.ID, from)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrHoldNotActive is returned when a hold was already released, captured or expired
+var ErrHoldNotActive = errors.New("hold is not active")
+
+// HoldValidator checks the locked account before a hold is placed on it
+type HoldValidator func(account *models.Account, hold *models.Hold) error
+
+// HoldRepository defines operations for account holds
+type HoldRepository interface {
+	PlaceHold(ctx context.Context, hold *models.Hold, validate HoldValidator) error
+	GetHold(ctx context.Context, id uuid.UUID) (*models.Hold, error)
+	GetHoldByReference(ctx context.Context, source models.HoldSource, reference string) (*models.Hold, error)
+	GetAccountHolds(ctx context.Context, accountID uuid.UUID, status models.HoldStatus) ([]*models.Hold, error)
+	GetExpiredHoldIDs(ctx context.Context, now time.Time) ([]uuid.UUID, error)
+	ReleaseHold(ctx context.Context, id uuid.UUID, status models.HoldStatus) (*models.Hold, error)
+}
+
+// PostgresHoldRepository implements HoldRepository for PostgreSQL
+type PostgresHoldRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresHoldRepository creates a new PostgresHoldRepository
+func NewPostgresHoldRepository(db *sql.DB) *PostgresHoldRepository {
+	return &PostgresHoldRepository{
+		db: db,
+	}
+}
+
+// holdColumns lists the columns read by scanHold, in order
+const holdColumns = `id, account_id, amount, reason, source, reference, status, expires_at,
+		       created_by, captured_transaction_id, created_at, updated_at`
+
+func scanHold(row rowScanner) (*models.Hold, error) {
+	var hold models.Hold
+	var reference sql.NullString
+	var createdBy, capturedTransactionID uuid.NullUUID
+
+	err := row.Scan(
+		&hold.ID,
+		&hold.AccountID,
+		&hold.Amount,
+		&hold.Reason,
+		&hold.Source,
+		&reference,
+		&hold.Status,
+		&hold.ExpiresAt,
+		&createdBy,
+		&capturedTransactionID,
+		&hold.CreatedAt,
+		&hold.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	hold.Reference = reference.String
+	if createdBy.Valid {
+		hold.CreatedBy = &createdBy.UUID
+	}
+	if capturedTransactionID.Valid {
+		hold.CapturedTransactionID = &capturedTransactionID.UUID
+	}
+	return &hold, nil
+}
+
+// PlaceHold locks the account, runs validate against it and records the hold
+// together with the increase of the account's held amount
+func (r *PostgresHoldRepository) PlaceHold(ctx context.Context, hold *models.Hold, validate HoldValidator) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	accounts, err := lockAccounts(ctx, tx, []uuid.UUID{hold.AccountID})
+	if err != nil {
+		return err
+	}
+	if validate != nil {
+		if err := validate(accounts[hold.AccountID], hold); err != nil {
+			return err
+		}
+	}
+
+	var reference sql.NullString
+	if hold.Reference != "" {
+		reference = sql.NullString{String: hold.Reference, Valid: true}
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO account_holds (
+			id, account_id, amount, reason, source, reference, status, expires_at, created_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`,
+		hold.ID, hold.AccountID, hold.Amount, hold.Reason, hold.Source, reference, hold.Status,
+		hold.ExpiresAt, hold.CreatedBy, hold.CreatedAt, hold.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE accounts SET held_amount = held_amount + $1, updated_at = $2 WHERE id = $3`,
+		hold.Amount, hold.UpdatedAt, hold.AccountID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetHold retrieves a hold by ID
+func (r *PostgresHoldRepository) GetHold(ctx context.Context, id uuid.UUID) (*models.Hold, error) {
+	query := `SELECT ` + holdColumns + ` FROM account_holds WHERE id = $1`
+
+	hold, err := scanHold(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	return hold, nil
+}
+
+// GetHoldByReference retrieves the latest hold placed by a source for a reference
+func (r *PostgresHoldRepository) GetHoldByReference(ctx context.Context, source models.HoldSource, reference string) (*models.Hold, error) {
+	query := `
+		SELECT ` + holdColumns + `
+		FROM account_holds
+		WHERE source = $1 AND reference = $2
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	hold, err := scanHold(r.db.QueryRowContext(ctx, query, source, reference))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	return hold, nil
+}
+
+// GetAccountHolds retrieves the holds of an account, optionally filtered by status
+func (r *PostgresHoldRepository) GetAccountHolds(ctx context.Context, accountID uuid.UUID, status models.HoldStatus) ([]*models.Hold, error) {
+	query := `
+		SELECT ` + holdColumns + `
+		FROM account_holds
+		WHERE account_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, accountID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	holds := []*models.Hold{}
+	for rows.Next() {
+		hold, err := scanHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		holds = append(holds, hold)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return holds, nil
+}
+
+// GetExpiredHoldIDs returns the active holds whose expiry has passed
+func (r *PostgresHoldRepository) GetExpiredHoldIDs(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	query := `SELECT id FROM account_holds WHERE status = $1 AND expires_at <= $2 ORDER BY expires_at`
+
+	rows, err := r.db.QueryContext(ctx, query, models.HoldActive, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// ReleaseHold ends an active hold with the given status and gives its amount
+// back to the available balance. It returns ErrHoldNotActive when the hold
+// was already released, captured or expired.
+func (r *PostgresHoldRepository) ReleaseHold(ctx context.Context, id uuid.UUID, status models.HoldStatus) (*models.Hold, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the account before the hold, in the same order as postings that
+	// capture holds, so a release and a capture cannot deadlock
+	var accountID uuid.UUID
+	err = tx.QueryRowContext(ctx, `SELECT account_id FROM account_holds WHERE id = $1`, id).Scan(&accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrHoldNotActive
+		}
+		return nil, err
+	}
+	accounts, err := lockAccounts(ctx, tx, []uuid.UUID{accountID})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	hold, err := scanHold(tx.QueryRowContext(ctx, `
+		UPDATE account_holds
+		SET status = $1, updated_at = $2
+		WHERE id = $3 AND status = $4
+		RETURNING `+holdColumns,
+		status, now, id, models.HoldActive,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrHoldNotActive
+		}
+		return nil, err
+	}
+
+	held := math.Max(accounts[hold.AccountID].HeldAmount-hold.Amount, 0)
+	_, err = tx.ExecContext(ctx, `UPDATE accounts SET held_amount = $1, updated_at = $2 WHERE id = $3`, held, now, hold.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, tx.Commit()
+}
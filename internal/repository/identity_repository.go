@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdentityRepository counts the identity checks a customer attempts when
+// re-entering their identity details
+type IdentityRepository interface {
+	UseVerificationAttempt(ctx context.Context, customerID uuid.UUID, now, windowStart time.Time, max int) (bool, error)
+	ClearVerificationAttempts(ctx context.Context, customerID uuid.UUID) error
+}
+
+// PostgresIdentityRepository implements IdentityRepository for PostgreSQL
+type PostgresIdentityRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresIdentityRepository creates a new PostgresIdentityRepository
+func NewPostgresIdentityRepository(db *sql.DB) *PostgresIdentityRepository {
+	return &PostgresIdentityRepository{
+		db: db,
+	}
+}
+
+// UseVerificationAttempt counts one identity check for the customer and
+// reports whether it is allowed: fewer than max checks were counted since
+// the customer's attempt window started. A window that started before
+// windowStart is over, and the check starts a new one at now. The count is
+// incremented in the database, so concurrent checks cannot exceed max.
+func (r *PostgresIdentityRepository) UseVerificationAttempt(ctx context.Context, customerID uuid.UUID, now, windowStart time.Time, max int) (bool, error) {
+	query := `
+		INSERT INTO identity_verification_attempts (customer_id, attempts, window_started_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (customer_id) DO UPDATE
+		SET attempts = CASE WHEN identity_verification_attempts.window_started_at < $3
+		                    THEN 1 ELSE identity_verification_attempts.attempts + 1 END,
+		    window_started_at = CASE WHEN identity_verification_attempts.window_started_at < $3
+		                             THEN EXCLUDED.window_started_at ELSE identity_verification_attempts.window_started_at END
+		WHERE identity_verification_attempts.window_started_at < $3 OR identity_verification_attempts.attempts < $4
+		RETURNING attempts
+	`
+
+	var attempts int
+	err := r.db.QueryRowContext(ctx, query, customerID, now, windowStart, max).Scan(&attempts)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ClearVerificationAttempts forgets the customer's checks after a successful one
+func (r *PostgresIdentityRepository) ClearVerificationAttempts(ctx context.Context, customerID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM identity_verification_attempts WHERE customer_id = $1`, customerID)
+	return err
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+)
+
+// InterbankRepository defines operations for outbound interbank transfers
+type InterbankRepository interface {
+	CreateInterbankTransfer(ctx context.Context, transfer *models.InterbankTransfer) error
+	GetInterbankTransfer(ctx context.Context, id uuid.UUID) (*models.InterbankTransfer, error)
+	GetInterbankTransferByTransaction(ctx context.Context, transactionID uuid.UUID) (*models.InterbankTransfer, error)
+	GetUnsubmittedInterbankTransfers(ctx context.Context) ([]*models.InterbankTransfer, error)
+	MarkInterbankTransferSubmitted(ctx context.Context, id uuid.UUID, at time.Time) error
+	ResolveInterbankTransfer(ctx context.Context, transfer *models.InterbankTransfer) (bool, error)
+}
+
+// PostgresInterbankRepository implements InterbankRepository for PostgreSQL
+type PostgresInterbankRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresInterbankRepository creates a new PostgresInterbankRepository
+func NewPostgresInterbankRepository(db *sql.DB) *PostgresInterbankRepository {
+	return &PostgresInterbankRepository{
+		db: db,
+	}
+}
+
+// interbankTransferColumns lists the columns read by scanInterbankTransfer, in order
+const interbankTransferColumns = `id, customer_id, from_account_id, to_bank_code, to_account_number, amount, description,
+		       status, transaction_id, resolution_transaction_id, return_reason, submitted_at, resolved_at,
+		       created_at, updated_at`
+
+func scanInterbankTransfer(row rowScanner) (*models.InterbankTransfer, error) {
+	var transfer models.InterbankTransfer
+	var resolutionID uuid.NullUUID
+	var submittedAt, resolvedAt sql.NullTime
+
+	err := row.Scan(
+		&transfer.ID,
+		&transfer.CustomerID,
+		&transfer.FromAccountID,
+		&transfer.ToBankCode,
+		&transfer.ToAccountNumber,
+		&transfer.Amount,
+		&transfer.Description,
+		&transfer.Status,
+		&transfer.TransactionID,
+		&resolutionID,
+		&transfer.ReturnReason,
+		&submittedAt,
+		&resolvedAt,
+		&transfer.CreatedAt,
+		&transfer.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if resolutionID.Valid {
+		transfer.ResolutionTransactionID = &resolutionID.UUID
+	}
+	if submittedAt.Valid {
+		transfer.SubmittedAt = &submittedAt.Time
+	}
+	if resolvedAt.Valid {
+		transfer.ResolvedAt = &resolvedAt.Time
+	}
+	return &transfer, nil
+}
+
+// CreateInterbankTransfer inserts an outbound interbank transfer
+func (r *PostgresInterbankRepository) CreateInterbankTransfer(ctx context.Context, transfer *models.InterbankTransfer) error {
+	query := `
+		INSERT INTO interbank_transfers (
+			id, customer_id, from_account_id, to_bank_code, to_account_number, amount, description,
+			status, transaction_id, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		transfer.ID,
+		transfer.CustomerID,
+		transfer.FromAccountID,
+		transfer.ToBankCode,
+		transfer.ToAccountNumber,
+		transfer.Amount,
+		transfer.Description,
+		transfer.Status,
+		transfer.TransactionID,
+		transfer.CreatedAt,
+		transfer.UpdatedAt,
+	)
+	return err
+}
+
+// GetInterbankTransfer retrieves an interbank transfer by ID
+func (r *PostgresInterbankRepository) GetInterbankTransfer(ctx context.Context, id uuid.UUID) (*models.InterbankTransfer, error) {
+	query := `SELECT ` + interbankTransferColumns + ` FROM interbank_transfers WHERE id = $1`
+	return r.queryInterbankTransfer(ctx, query, id)
+}
+
+// GetInterbankTransferByTransaction retrieves the interbank transfer whose debit is the ledger transaction
+func (r *PostgresInterbankRepository) GetInterbankTransferByTransaction(ctx context.Context, transactionID uuid.UUID) (*models.InterbankTransfer, error) {
+	query := `SELECT ` + interbankTransferColumns + ` FROM interbank_transfers WHERE transaction_id = $1`
+	return r.queryInterbankTransfer(ctx, query, transactionID)
+}
+
+func (r *PostgresInterbankRepository) queryInterbankTransfer(ctx context.Context, query string, args ...interface{}) (*models.InterbankTransfer, error) {
+	transfer, err := scanInterbankTransfer(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// GetUnsubmittedInterbankTransfers retrieves the pending transfers not yet sent to the switch, oldest first
+func (r *PostgresInterbankRepository) GetUnsubmittedInterbankTransfers(ctx context.Context) ([]*models.InterbankTransfer, error) {
+	query := `
+		SELECT ` + interbankTransferColumns + `
+		FROM interbank_transfers
+		WHERE status = 'pending' AND submitted_at IS NULL
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := []*models.InterbankTransfer{}
+	for rows.Next() {
+		transfer, err := scanInterbankTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return transfers, nil
+}
+
+// MarkInterbankTransferSubmitted records when the transfer was sent to the switch
+func (r *PostgresInterbankRepository) MarkInterbankTransferSubmitted(ctx context.Context, id uuid.UUID, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE interbank_transfers SET submitted_at = $1, updated_at = $1 WHERE id = $2`, at, id)
+	return err
+}
+
+// ResolveInterbankTransfer saves the outcome of a pending transfer and
+// reports whether it was still pending
+func (r *PostgresInterbankRepository) ResolveInterbankTransfer(ctx context.Context, transfer *models.InterbankTransfer) (bool, error) {
+	query := `
+		UPDATE interbank_transfers
+		SET status = $1, resolution_transaction_id = $2, return_reason = $3, resolved_at = $4, updated_at = $5
+		WHERE id = $6 AND status = 'pending'
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		transfer.Status,
+		transfer.ResolutionTransactionID,
+		transfer.ReturnReason,
+		transfer.ResolvedAt,
+		transfer.UpdatedAt,
+		transfer.ID,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+)
+
+// InterestRepository defines operations for daily interest accruals
+type InterestRepository interface {
+	SaveAccrual(ctx context.Context, accrual *models.InterestAccrual) error
+	SumUnpostedAccruals(ctx context.Context, accountID uuid.UUID, upTo time.Time) (float64, error)
+	MarkAccrualsPosted(ctx context.Context, accountID uuid.UUID, upTo time.Time, reference string) error
+}
+
+// PostgresInterestRepository implements InterestRepository for PostgreSQL
+type PostgresInterestRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresInterestRepository creates a new PostgresInterestRepository
+func NewPostgresInterestRepository(db *sql.DB) *PostgresInterestRepository {
+	return &PostgresInterestRepository{
+		db: db,
+	}
+}
+
+// SaveAccrual stores the accrual of an account for a day. Replaying a day
+// replaces the earlier accrual unless it has already been posted.
+func (r *PostgresInterestRepository) SaveAccrual(ctx context.Context, accrual *models.InterestAccrual) error {
+	query := `
+		INSERT INTO interest_accruals (id, account_id, accrual_date, balance, amount, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (account_id, accrual_date) DO UPDATE
+		SET balance = EXCLUDED.balance, amount = EXCLUDED.amount, created_at = EXCLUDED.created_at
+		WHERE interest_accruals.posted_reference IS NULL
+	`
+
+	_, err := r.db.ExecContext(ctx, query, accrual.ID, accrual.AccountID, accrual.AccrualDate, accrual.Balance, accrual.Amount, accrual.CreatedAt)
+	return err
+}
+
+// SumUnpostedAccruals returns the total accrued and not yet posted up to and including upTo
+func (r *PostgresInterestRepository) SumUnpostedAccruals(ctx context.Context, accountID uuid.UUID, upTo time.Time) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM interest_accruals
+		WHERE account_id = $1 AND accrual_date <= $2 AND posted_reference IS NULL
+	`
+
+	var total float64
+	err := r.db.QueryRowContext(ctx, query, accountID, upTo).Scan(&total)
+	return total, err
+}
+
+// MarkAccrualsPosted links the unposted accruals up to upTo to the ledger reference they were posted under
+func (r *PostgresInterestRepository) MarkAccrualsPosted(ctx context.Context, accountID uuid.UUID, upTo time.Time, reference string) error {
+	query := `
+		UPDATE interest_accruals
+		SET posted_reference = $1
+		WHERE account_id = $2 AND accrual_date <= $3 AND posted_reference IS NULL
+	`
+
+	_, err := r.db.ExecContext(ctx, query, reference, accountID, upTo)
+	return err
+}
@@ -0,0 +1,409 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrDuplicateReference is returned when a ledger transaction with the same reference was already posted
+var ErrDuplicateReference = errors.New("ledger transaction reference already posted")
+
+// ErrAccountNotFound is returned when a posting refers to an unknown account
+var ErrAccountNotFound = errors.New("account not found")
+
+// PostingValidator checks a ledger transaction against the locked accounts it
+// touches before any balance changes. Returning an error aborts the posting.
+type PostingValidator func(accounts map[uuid.UUID]*models.Account, txn *models.LedgerTransaction) error
+
+// LedgerRepository defines operations for posting and reading ledger entries
+type LedgerRepository interface {
+	PostTransaction(ctx context.Context, txn *models.LedgerTransaction, validate PostingValidator) error
+	GetBalanceAsOf(ctx context.Context, accountID uuid.UUID, businessDate time.Time) (float64, error)
+	HasEntries(ctx context.Context, accountID uuid.UUID) (bool, error)
+	GetAccountHistory(ctx context.Context, accountID uuid.UUID, from, to time.Time) ([]*models.AccountTransaction, error)
+	GetTransaction(ctx context.Context, id uuid.UUID) (*models.LedgerTransaction, error)
+	GetPendingTransactions(ctx context.Context, accountID uuid.UUID, before time.Time) ([]*models.LedgerTransaction, error)
+	UpdateTransactionStatus(ctx context.Context, id uuid.UUID, from, to models.LedgerTransactionStatus) (bool, error)
+}
+
+// PostgresLedgerRepository implements LedgerRepository for PostgreSQL
+type PostgresLedgerRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresLedgerRepository creates a new PostgresLedgerRepository
+func NewPostgresLedgerRepository(db *sql.DB) *PostgresLedgerRepository {
+	return &PostgresLedgerRepository{
+		db: db,
+	}
+}
+
+// PostTransaction writes a ledger transaction and its entries and updates the
+// balances of the affected accounts in a single database transaction. The
+// accounts are locked in a fixed order so concurrent postings cannot deadlock.
+// Customer-initiated transactions also record activity on the accounts they debit.
+func (r *PostgresLedgerRepository) PostTransaction(ctx context.Context, txn *models.LedgerTransaction, validate PostingValidator) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO ledger_transactions (id, reference, type, description, status, business_date, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (reference) DO NOTHING
+	`, txn.ID, txn.Reference, txn.Type, txn.Description, txn.Status, txn.BusinessDate, txn.CreatedAt)
+	if err != nil {
+		return err
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if inserted == 0 {
+		return ErrDuplicateReference
+	}
+
+	ids := []uuid.UUID{}
+	seen := map[uuid.UUID]bool{}
+	for _, entry := range txn.Entries {
+		if !seen[entry.AccountID] {
+			seen[entry.AccountID] = true
+			ids = append(ids, entry.AccountID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+
+	accounts, err := lockAccounts(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+	if txn.Type.Withdraws() {
+		for _, entry := range txn.Entries {
+			account := accounts[entry.AccountID]
+			if entry.Direction == models.EntryDebit && !account.IsInternal() && account.WithdrawalRules == nil {
+				if err := loadWithdrawalRules(ctx, tx, account, txn.BusinessDate); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if txn.CapturesHoldID != nil {
+		if err := captureHold(ctx, tx, *txn.CapturesHoldID, txn.ID, accounts); err != nil {
+			return err
+		}
+	}
+
+	if validate != nil {
+		if err := validate(accounts, txn); err != nil {
+			return err
+		}
+	}
+
+	if txn.OpensFixedDeposit != nil {
+		if err := createFixedDeposit(ctx, tx, txn.OpensFixedDeposit); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for i := range txn.Entries {
+		entry := &txn.Entries[i]
+		account := accounts[entry.AccountID]
+		account.Balance += entry.SignedAmount()
+		if entry.Direction == models.EntryDebit && txn.Type.CustomerInitiated() && !account.IsInternal() {
+			account.LastActivityAt = now
+		}
+		entry.TransactionID = txn.ID
+		entry.BalanceAfter = account.Balance
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO ledger_entries (id, transaction_id, account_id, direction, amount, currency, balance_after, business_date, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`, entry.ID, entry.TransactionID, entry.AccountID, entry.Direction, entry.Amount, entry.Currency, entry.BalanceAfter, txn.BusinessDate, entry.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, id := range ids {
+		_, err = tx.ExecContext(ctx, `UPDATE accounts SET balance = $1, held_amount = $2, last_activity_at = $3, updated_at = $4 WHERE id = $5`,
+			accounts[id].Balance, accounts[id].HeldAmount, accounts[id].LastActivityAt, now, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// captureHold marks an active hold captured by the transaction and removes its
+// amount from the held amount of its account, which must be one of the locked
+// accounts of the posting
+func captureHold(ctx context.Context, tx *sql.Tx, holdID, transactionID uuid.UUID, accounts map[uuid.UUID]*models.Account) error {
+	var accountID uuid.UUID
+	var amount float64
+	err := tx.QueryRowContext(ctx, `
+		UPDATE account_holds
+		SET status = $1, captured_transaction_id = $2, updated_at = $3
+		WHERE id = $4 AND status = $5
+		RETURNING account_id, amount
+	`, models.HoldCaptured, transactionID, time.Now(), holdID, models.HoldActive).Scan(&accountID, &amount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrHoldNotActive
+		}
+		return err
+	}
+
+	account, ok := accounts[accountID]
+	if !ok {
+		return fmt.Errorf("hold %s does not belong to an account of the transaction", holdID)
+	}
+	account.HeldAmount = math.Max(account.HeldAmount-amount, 0)
+	return nil
+}
+
+// lockAccounts selects the accounts FOR UPDATE in the order given, together
+// with the restrictions in force on them
+func lockAccounts(ctx context.Context, tx *sql.Tx, ids []uuid.UUID) (map[uuid.UUID]*models.Account, error) {
+	accounts := map[uuid.UUID]*models.Account{}
+	for _, id := range ids {
+		query := `SELECT ` + accountColumns + ` FROM accounts WHERE id = $1 FOR UPDATE`
+		account, err := scanAccount(tx.QueryRowContext(ctx, query, id))
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, ErrAccountNotFound
+			}
+			return nil, err
+		}
+		if err := loadRestrictions(ctx, tx, account); err != nil {
+			return nil, err
+		}
+		accounts[id] = account
+	}
+	return accounts, nil
+}
+
+// GetBalanceAsOf returns the balance of an account at the end of a business date.
+// It sums the entries instead of reading balance_after so that entries posted
+// later for an earlier business date are counted correctly.
+func (r *PostgresLedgerRepository) GetBalanceAsOf(ctx context.Context, accountID uuid.UUID, businessDate time.Time) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END), 0)
+		FROM ledger_entries
+		WHERE account_id = $1 AND business_date <= $2
+	`
+
+	var balance float64
+	err := r.db.QueryRowContext(ctx, query, accountID, businessDate).Scan(&balance)
+	return balance, err
+}
+
+// HasEntries reports whether anything was ever posted to the account
+func (r *PostgresLedgerRepository) HasEntries(ctx context.Context, accountID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM ledger_entries WHERE account_id = $1)`
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, accountID).Scan(&exists)
+	return exists, err
+}
+
+// GetAccountHistory returns the transactions on an account with a business
+// date between from and to, inclusive, in business date and posting order
+func (r *PostgresLedgerRepository) GetAccountHistory(ctx context.Context, accountID uuid.UUID, from, to time.Time) ([]*models.AccountTransaction, error) {
+	query := `
+		SELECT t.id, t.reference, t.type, COALESCE(t.description, ''), t.status, e.business_date,
+		       e.direction, e.amount, e.created_at
+		FROM ledger_entries e
+		JOIN ledger_transactions t ON t.id = e.transaction_id
+		WHERE e.account_id = $1 AND e.business_date BETWEEN $2 AND $3
+		ORDER BY e.business_date, e.seq
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, accountID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []*models.AccountTransaction{}
+	for rows.Next() {
+		var item models.AccountTransaction
+		err := rows.Scan(
+			&item.TransactionID,
+			&item.Reference,
+			&item.Type,
+			&item.Description,
+			&item.Status,
+			&item.BusinessDate,
+			&item.Direction,
+			&item.Amount,
+			&item.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// GetTransaction retrieves a ledger transaction with its entries
+func (r *PostgresLedgerRepository) GetTransaction(ctx context.Context, id uuid.UUID) (*models.LedgerTransaction, error) {
+	query := `
+		SELECT id, reference, type, COALESCE(description, ''), status, business_date, created_at
+		FROM ledger_transactions
+		WHERE id = $1
+	`
+
+	var txn models.LedgerTransaction
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&txn.ID,
+		&txn.Reference,
+		&txn.Type,
+		&txn.Description,
+		&txn.Status,
+		&txn.BusinessDate,
+		&txn.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+
+	entries, err := r.getEntries(ctx, []uuid.UUID{txn.ID})
+	if err != nil {
+		return nil, err
+	}
+	txn.Entries = entries[txn.ID]
+	return &txn, nil
+}
+
+// GetPendingTransactions retrieves the pending transactions with an entry on
+// the account that were posted before the given time, oldest first
+func (r *PostgresLedgerRepository) GetPendingTransactions(ctx context.Context, accountID uuid.UUID, before time.Time) ([]*models.LedgerTransaction, error) {
+	query := `
+		SELECT DISTINCT t.id, t.reference, t.type, COALESCE(t.description, ''), t.status, t.business_date, t.created_at
+		FROM ledger_transactions t
+		JOIN ledger_entries e ON e.transaction_id = t.id
+		WHERE e.account_id = $1 AND t.status = 'pending' AND t.created_at < $2
+		ORDER BY t.created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, accountID, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []*models.LedgerTransaction{}
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var txn models.LedgerTransaction
+		err := rows.Scan(
+			&txn.ID,
+			&txn.Reference,
+			&txn.Type,
+			&txn.Description,
+			&txn.Status,
+			&txn.BusinessDate,
+			&txn.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, &txn)
+		ids = append(ids, txn.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	entries, err := r.getEntries(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, txn := range transactions {
+		txn.Entries = entries[txn.ID]
+	}
+	return transactions, nil
+}
+
+// getEntries retrieves the entries of the transactions, keyed by transaction
+func (r *PostgresLedgerRepository) getEntries(ctx context.Context, transactionIDs []uuid.UUID) (map[uuid.UUID][]models.LedgerEntry, error) {
+	entries := map[uuid.UUID][]models.LedgerEntry{}
+	if len(transactionIDs) == 0 {
+		return entries, nil
+	}
+
+	query := `
+		SELECT id, transaction_id, account_id, direction, amount, currency, balance_after, created_at
+		FROM ledger_entries
+		WHERE transaction_id = ANY($1::uuid[])
+		ORDER BY seq
+	`
+
+	ids := make([]string, len(transactionIDs))
+	for i, id := range transactionIDs {
+		ids[i] = id.String()
+	}
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.LedgerEntry
+		err := rows.Scan(
+			&entry.ID,
+			&entry.TransactionID,
+			&entry.AccountID,
+			&entry.Direction,
+			&entry.Amount,
+			&entry.Currency,
+			&entry.BalanceAfter,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries[entry.TransactionID] = append(entries[entry.TransactionID], entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// UpdateTransactionStatus moves a transaction from one lifecycle state to
+// another and reports whether it was still in status from
+func (r *PostgresLedgerRepository) UpdateTransactionStatus(ctx context.Context, id uuid.UUID, from, to models.LedgerTransactionStatus) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE ledger_transactions SET status = $1 WHERE id = $2 AND status = $3`, to, id, from)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
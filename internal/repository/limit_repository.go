@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+)
+
+// LimitRepository defines operations for customer tiers, customer-set
+// limits and the daily usage counted against them
+type LimitRepository interface {
+	GetCustomerTier(ctx context.Context, customerID uuid.UUID) (models.CustomerTier, error)
+	SetCustomerTier(ctx context.Context, customerID uuid.UUID, tier models.CustomerTier, staffID uuid.UUID, at time.Time) error
+	GetCustomerLimits(ctx context.Context, customerID uuid.UUID) ([]*models.CustomerLimit, error)
+	SaveCustomerLimit(ctx context.Context, limit *models.CustomerLimit) error
+	GetUsage(ctx context.Context, customerID uuid.UUID, date time.Time) (map[models.Channel]map[models.LimitType]float64, error)
+	ReserveUsage(ctx context.Context, customerID uuid.UUID, channel models.Channel, limitType models.LimitType, date time.Time, amount, daily float64) (bool, float64, error)
+	ReleaseUsage(ctx context.Context, customerID uuid.UUID, channel models.Channel, limitType models.LimitType, date time.Time, amount float64) error
+}
+
+// PostgresLimitRepository implements LimitRepository for PostgreSQL
+type PostgresLimitRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresLimitRepository creates a new PostgresLimitRepository
+func NewPostgresLimitRepository(db *sql.DB) *PostgresLimitRepository {
+	return &PostgresLimitRepository{
+		db: db,
+	}
+}
+
+// GetCustomerTier retrieves a customer's tier, which is standard unless staff assigned another
+func (r *PostgresLimitRepository) GetCustomerTier(ctx context.Context, customerID uuid.UUID) (models.CustomerTier, error) {
+	var tier models.CustomerTier
+	err := r.db.QueryRowContext(ctx, `SELECT tier FROM customer_tiers WHERE customer_id = $1`, customerID).Scan(&tier)
+	if err == sql.ErrNoRows {
+		return models.TierStandard, nil
+	}
+	return tier, err
+}
+
+// SetCustomerTier assigns a tier to a customer
+func (r *PostgresLimitRepository) SetCustomerTier(ctx context.Context, customerID uuid.UUID, tier models.CustomerTier, staffID uuid.UUID, at time.Time) error {
+	query := `
+		INSERT INTO customer_tiers (customer_id, tier, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (customer_id) DO UPDATE
+		SET tier = EXCLUDED.tier, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query, customerID, tier, staffID, at)
+	return err
+}
+
+// GetCustomerLimits retrieves the limits a customer set for themselves
+func (r *PostgresLimitRepository) GetCustomerLimits(ctx context.Context, customerID uuid.UUID) ([]*models.CustomerLimit, error) {
+	query := `
+		SELECT customer_id, channel, limit_type, per_transaction, daily, updated_at
+		FROM customer_limits
+		WHERE customer_id = $1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	limits := []*models.CustomerLimit{}
+	for rows.Next() {
+		var limit models.CustomerLimit
+		err := rows.Scan(
+			&limit.CustomerID,
+			&limit.Channel,
+			&limit.Type,
+			&limit.PerTransaction,
+			&limit.Daily,
+			&limit.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, &limit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return limits, nil
+}
+
+// SaveCustomerLimit inserts or replaces a limit a customer set for themselves
+func (r *PostgresLimitRepository) SaveCustomerLimit(ctx context.Context, limit *models.CustomerLimit) error {
+	query := `
+		INSERT INTO customer_limits (customer_id, channel, limit_type, per_transaction, daily, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (customer_id, channel, limit_type) DO UPDATE
+		SET per_transaction = EXCLUDED.per_transaction, daily = EXCLUDED.daily, updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		limit.CustomerID,
+		limit.Channel,
+		limit.Type,
+		limit.PerTransaction,
+		limit.Daily,
+		limit.UpdatedAt,
+	)
+	return err
+}
+
+// GetUsage retrieves how much of each daily limit a customer used on a date
+func (r *PostgresLimitRepository) GetUsage(ctx context.Context, customerID uuid.UUID, date time.Time) (map[models.Channel]map[models.LimitType]float64, error) {
+	query := `
+		SELECT channel, limit_type, amount
+		FROM transaction_limit_usage
+		WHERE customer_id = $1 AND usage_date = $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, customerID, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := map[models.Channel]map[models.LimitType]float64{}
+	for rows.Next() {
+		var channel models.Channel
+		var limitType models.LimitType
+		var amount float64
+		if err := rows.Scan(&channel, &limitType, &amount); err != nil {
+			return nil, err
+		}
+		if usage[channel] == nil {
+			usage[channel] = map[models.LimitType]float64{}
+		}
+		usage[channel][limitType] = amount
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+// ReserveUsage adds amount to a customer's usage for the day if the total
+// stays within daily. The upsert locks the usage row, so concurrent
+// payments cannot both pass the check. It reports whether the amount was
+// reserved and the usage for the day afterwards.
+func (r *PostgresLimitRepository) ReserveUsage(ctx context.Context, customerID uuid.UUID, channel models.Channel, limitType models.LimitType, date time.Time, amount, daily float64) (bool, float64, error) {
+	query := `
+		INSERT INTO transaction_limit_usage (customer_id, channel, limit_type, usage_date, amount, updated_at)
+		SELECT $1, $2, $3, $4, $5, NOW()
+		WHERE $5 <= $6
+		ON CONFLICT (customer_id, channel, limit_type, usage_date) DO UPDATE
+		SET amount = transaction_limit_usage.amount + EXCLUDED.amount, updated_at = EXCLUDED.updated_at
+		WHERE transaction_limit_usage.amount + EXCLUDED.amount <= $6
+		RETURNING amount
+	`
+
+	var used float64
+	err := r.db.QueryRowContext(ctx, query, customerID, channel, limitType, date, amount, daily).Scan(&used)
+	if err == nil {
+		return true, used, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, 0, err
+	}
+
+	err = r.db.QueryRowContext(ctx, `
+		SELECT amount FROM transaction_limit_usage
+		WHERE customer_id = $1 AND channel = $2 AND limit_type = $3 AND usage_date = $4
+	`, customerID, channel, limitType, date).Scan(&used)
+	if err != nil && err != sql.ErrNoRows {
+		return false, 0, err
+	}
+	return false, used, nil
+}
+
+// ReleaseUsage gives back usage reserved for a payment that was not posted
+func (r *PostgresLimitRepository) ReleaseUsage(ctx context.Context, customerID uuid.UUID, channel models.Channel, limitType models.LimitType, date time.Time, amount float64) error {
+	query := `
+		UPDATE transaction_limit_usage
+		SET amount = GREATEST(amount - $5, 0), updated_at = NOW()
+		WHERE customer_id = $1 AND channel = $2 AND limit_type = $3 AND usage_date = $4
+	`
+
+	_, err := r.db.ExecContext(ctx, query, customerID, channel, limitType, date, amount)
+	return err
+}
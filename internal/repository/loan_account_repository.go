@@ -0,0 +1,313 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrApplicationNotPending is returned when a loan is booked for an application that was already decided
+var ErrApplicationNotPending = errors.New("loan application is no longer pending")
+
+// LoanAccountRepository defines operations for booked loans, their installments and charges
+type LoanAccountRepository interface {
+	CreateLoan(ctx context.Context, loan *models.Loan, installments []*models.LoanInstallment, reason string) error
+	GetOverdueInstallments(ctx context.Context, businessDate time.Time) ([]*models.LoanInstallment, error)
+	ApplyInstallmentDelinquency(ctx context.Context, installment *models.LoanInstallment, charges []*models.LoanCharge) error
+	GetActiveLoanDaysPastDue(ctx context.Context) (map[uuid.UUID]int, error)
+	UpdateLoanAging(ctx context.Context, loanID uuid.UUID, daysPastDue int, bucket models.AgingBucket) error
+	RecordDelinquencyRun(ctx context.Context, businessDate time.Time, installmentsProcessed int) error
+	GetDelinquencyReport(ctx context.Context) (*models.DelinquencyReport, error)
+	IsRepaymentAccount(ctx context.Context, accountID uuid.UUID) (bool, error)
+}
+
+// PostgresLoanAccountRepository implements LoanAccountRepository for PostgreSQL
+type PostgresLoanAccountRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresLoanAccountRepository creates a new PostgresLoanAccountRepository
+func NewPostgresLoanAccountRepository(db *sql.DB) *PostgresLoanAccountRepository {
+	return &PostgresLoanAccountRepository{
+		db: db,
+	}
+}
+
+// CreateLoan inserts a loan together with its repayment schedule and marks
+// its application approved, in one transaction. An application that is no
+// longer pending returns ErrApplicationNotPending and nothing is booked.
+func (r *PostgresLoanAccountRepository) CreateLoan(ctx context.Context, loan *models.Loan, installments []*models.LoanInstallment, reason string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE loan_applications
+		SET status = $1, status_reason = $2, updated_at = $3
+		WHERE id = $4 AND status = $5
+	`, models.LoanStatusApproved, reason, loan.CreatedAt, loan.ApplicationID, models.LoanStatusPending)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return ErrApplicationNotPending
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO loans (
+			id, application_id, customer_id, principal, annual_rate, tenor_months,
+			outstanding_principal, status, days_past_due, aging_bucket, repayment_account_id,
+			disbursed_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`,
+		loan.ID, loan.ApplicationID, loan.CustomerID, loan.Principal, loan.AnnualRate, loan.TenorMonths,
+		loan.OutstandingPrincipal, loan.Status, loan.DaysPastDue, loan.AgingBucket, loan.RepaymentAccountID,
+		loan.DisbursedAt, loan.CreatedAt, loan.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, installment := range installments {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO loan_installments (
+				id, loan_id, installment_number, due_date, principal_due, interest_due,
+				fees_due, penalty_due, amount_paid, status, days_past_due
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		`,
+			installment.ID, installment.LoanID, installment.InstallmentNumber, installment.DueDate,
+			installment.PrincipalDue, installment.InterestDue, installment.FeesDue, installment.PenaltyDue,
+			installment.AmountPaid, installment.Status, installment.DaysPastDue,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetOverdueInstallments retrieves unpaid installments of active loans that were due before businessDate
+func (r *PostgresLoanAccountRepository) GetOverdueInstallments(ctx context.Context, businessDate time.Time) ([]*models.LoanInstallment, error) {
+	query := `
+		SELECT i.id, i.loan_id, i.installment_number, i.due_date, i.principal_due, i.interest_due,
+		       i.fees_due, i.penalty_due, i.amount_paid, i.status, i.days_past_due
+		FROM loan_installments i
+		JOIN loans l ON l.id = i.loan_id
+		WHERE l.status = $1 AND i.status <> $2 AND i.due_date < $3
+		ORDER BY i.due_date
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, models.LoanActive, models.InstallmentPaid, businessDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	installments := []*models.LoanInstallment{}
+	for rows.Next() {
+		var installment models.LoanInstallment
+		err := rows.Scan(
+			&installment.ID,
+			&installment.LoanID,
+			&installment.InstallmentNumber,
+			&installment.DueDate,
+			&installment.PrincipalDue,
+			&installment.InterestDue,
+			&installment.FeesDue,
+			&installment.PenaltyDue,
+			&installment.AmountPaid,
+			&installment.Status,
+			&installment.DaysPastDue,
+		)
+		if err != nil {
+			return nil, err
+		}
+		installments = append(installments, &installment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return installments, nil
+}
+
+// ApplyInstallmentDelinquency marks an installment overdue and applies the given charges.
+// Charges are keyed by installment, type and business date, so a charge that was
+// already applied by an earlier run is skipped instead of being charged twice.
+func (r *PostgresLoanAccountRepository) ApplyInstallmentDelinquency(ctx context.Context, installment *models.LoanInstallment, charges []*models.LoanCharge) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE loan_installments
+		SET status = $1, days_past_due = $2
+		WHERE id = $3
+	`, models.InstallmentOverdue, installment.DaysPastDue, installment.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, charge := range charges {
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO loan_charges (id, loan_id, installment_id, charge_type, amount, business_date, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT DO NOTHING
+		`, charge.ID, charge.LoanID, charge.InstallmentID, charge.ChargeType, charge.Amount, charge.BusinessDate, charge.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		inserted, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if inserted == 0 {
+			continue
+		}
+
+		column := "fees_due"
+		if charge.ChargeType == models.LoanChargePenaltyInterest {
+			column = "penalty_due"
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE loan_installments SET `+column+` = `+column+` + $1 WHERE id = $2`, charge.Amount, charge.InstallmentID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetActiveLoanDaysPastDue returns the days past due of every active loan,
+// taken from its oldest overdue installment
+func (r *PostgresLoanAccountRepository) GetActiveLoanDaysPastDue(ctx context.Context) (map[uuid.UUID]int, error) {
+	query := `
+		SELECT l.id, COALESCE(MAX(i.days_past_due) FILTER (WHERE i.status = $2), 0)
+		FROM loans l
+		LEFT JOIN loan_installments i ON i.loan_id = l.id
+		WHERE l.status = $1
+		GROUP BY l.id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, models.LoanActive, models.InstallmentOverdue)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := map[uuid.UUID]int{}
+	for rows.Next() {
+		var id uuid.UUID
+		var dpd int
+		if err := rows.Scan(&id, &dpd); err != nil {
+			return nil, err
+		}
+		result[id] = dpd
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UpdateLoanAging stores the days past due and aging bucket of a loan
+func (r *PostgresLoanAccountRepository) UpdateLoanAging(ctx context.Context, loanID uuid.UUID, daysPastDue int, bucket models.AgingBucket) error {
+	query := `
+		UPDATE loans
+		SET days_past_due = $1, aging_bucket = $2, updated_at = $3
+		WHERE id = $4
+	`
+
+	_, err := r.db.ExecContext(ctx, query, daysPastDue, bucket, time.Now(), loanID)
+	return err
+}
+
+// RecordDelinquencyRun records that the delinquency job completed for a business date
+func (r *PostgresLoanAccountRepository) RecordDelinquencyRun(ctx context.Context, businessDate time.Time, installmentsProcessed int) error {
+	query := `
+		INSERT INTO delinquency_runs (business_date, installments_processed, completed_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (business_date) DO UPDATE
+		SET installments_processed = EXCLUDED.installments_processed, completed_at = EXCLUDED.completed_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query, businessDate, installmentsProcessed, time.Now())
+	return err
+}
+
+// GetDelinquencyReport returns the number of active loans and the amount
+// outstanding on their unpaid installments per aging bucket
+func (r *PostgresLoanAccountRepository) GetDelinquencyReport(ctx context.Context) (*models.DelinquencyReport, error) {
+	query := `
+		SELECT l.aging_bucket, COUNT(DISTINCT l.id),
+		       COALESCE(SUM(i.principal_due + i.interest_due + i.fees_due + i.penalty_due - i.amount_paid), 0)
+		FROM loans l
+		LEFT JOIN loan_installments i ON i.loan_id = l.id AND i.status <> $2
+		WHERE l.status = $1
+		GROUP BY l.aging_bucket
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, models.LoanActive, models.InstallmentPaid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := map[models.AgingBucket]models.AgingBucketSummary{}
+	for rows.Next() {
+		var summary models.AgingBucketSummary
+		if err := rows.Scan(&summary.Bucket, &summary.LoanCount, &summary.OutstandingTotal); err != nil {
+			return nil, err
+		}
+		totals[summary.Bucket] = summary
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report := &models.DelinquencyReport{Buckets: []models.AgingBucketSummary{}}
+	for _, bucket := range models.AgingBuckets {
+		summary, ok := totals[bucket]
+		if !ok {
+			summary = models.AgingBucketSummary{Bucket: bucket}
+		}
+		report.Buckets = append(report.Buckets, summary)
+	}
+
+	var asOf sql.NullTime
+	err = r.db.QueryRowContext(ctx, `SELECT MAX(business_date) FROM delinquency_runs`).Scan(&asOf)
+	if err != nil {
+		return nil, err
+	}
+	if asOf.Valid {
+		report.AsOf = &asOf.Time
+	}
+
+	return report, nil
+}
+
+// IsRepaymentAccount reports whether the account is the repayment source of an active loan
+func (r *PostgresLoanAccountRepository) IsRepaymentAccount(ctx context.Context, accountID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM loans WHERE repayment_account_id = $1 AND status = $2)`
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, accountID, models.LoanActive).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+)
+
+// LoanDocumentRepository defines operations for loan document metadata persistence
+type LoanDocumentRepository interface {
+	CreateLoanDocument(ctx context.Context, document *models.LoanDocument) error
+	GetLoanDocumentByID(ctx context.Context, id uuid.UUID) (*models.LoanDocument, error)
+	GetApplicationDocuments(ctx context.Context, applicationID uuid.UUID) ([]*models.LoanDocument, error)
+}
+
+// PostgresLoanDocumentRepository implements LoanDocumentRepository for PostgreSQL
+type PostgresLoanDocumentRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresLoanDocumentRepository creates a new PostgresLoanDocumentRepository
+func NewPostgresLoanDocumentRepository(db *sql.DB) *PostgresLoanDocumentRepository {
+	return &PostgresLoanDocumentRepository{
+		db: db,
+	}
+}
+
+const loanDocumentColumns = `id, application_id, customer_id, document_type, file_name, content_type,
+		       size_bytes, checksum_sha256, storage_key, uploaded_at`
+
+func scanLoanDocument(row rowScanner) (*models.LoanDocument, error) {
+	var document models.LoanDocument
+	err := row.Scan(
+		&document.ID,
+		&document.ApplicationID,
+		&document.CustomerID,
+		&document.DocumentType,
+		&document.FileName,
+		&document.ContentType,
+		&document.SizeBytes,
+		&document.ChecksumSHA256,
+		&document.StorageKey,
+		&document.UploadedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+// CreateLoanDocument inserts the metadata of an uploaded document
+func (r *PostgresLoanDocumentRepository) CreateLoanDocument(ctx context.Context, document *models.LoanDocument) error {
+	query := `
+		INSERT INTO loan_documents (` + loanDocumentColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		document.ID,
+		document.ApplicationID,
+		document.CustomerID,
+		document.DocumentType,
+		document.FileName,
+		document.ContentType,
+		document.SizeBytes,
+		document.ChecksumSHA256,
+		document.StorageKey,
+		document.UploadedAt,
+	)
+	return err
+}
+
+// GetLoanDocumentByID retrieves a document's metadata by ID
+func (r *PostgresLoanDocumentRepository) GetLoanDocumentByID(ctx context.Context, id uuid.UUID) (*models.LoanDocument, error) {
+	query := `
+		SELECT ` + loanDocumentColumns + `
+		FROM loan_documents
+		WHERE id = $1
+	`
+
+	document, err := scanLoanDocument(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	return document, nil
+}
+
+// GetApplicationDocuments retrieves all documents attached to a loan application
+func (r *PostgresLoanDocumentRepository) GetApplicationDocuments(ctx context.Context, applicationID uuid.UUID) ([]*models.LoanDocument, error) {
+	query := `
+		SELECT ` + loanDocumentColumns + `
+		FROM loan_documents
+		WHERE application_id = $1
+		ORDER BY uploaded_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, applicationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	documents := []*models.LoanDocument{}
+	for rows.Next() {
+		document, err := scanLoanDocument(rows)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
@@ -18,6 +18,54 @@ type LoanRepository interface {
 	UpdateLoanApplicationStatus(ctx context.Context, id uuid.UUID, status models.LoanApplicationStatus, reason string) error
 }
 
+// loanApplicationColumns lists the columns read by scanLoanApplication, in order
+const loanApplicationColumns = `id, customer_id, amount_requested, purpose, income_details, status,
+		       created_at, updated_at, status_reason, credit_assessment`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanLoanApplication reads a loan application selected with loanApplicationColumns
+func scanLoanApplication(row rowScanner) (*models.LoanApplication, error) {
+	var application models.LoanApplication
+	var incomeDetailsJSON []byte
+	var statusReason sql.NullString
+	var assessmentJSON []byte
+
+	err := row.Scan(
+		&application.ID,
+		&application.CustomerID,
+		&application.AmountRequested,
+		&application.Purpose,
+		&incomeDetailsJSON,
+		&application.Status,
+		&application.CreatedAt,
+		&application.UpdatedAt,
+		&statusReason,
+		&assessmentJSON,
+	)
+	if err != nil {
+		return nil, err
+	}
+	application.StatusReason = statusReason.String
+
+	// Unmarshal the JSON income details
+	if err := json.Unmarshal(incomeDetailsJSON, &application.IncomeDetails); err != nil {
+		return nil, err
+	}
+
+	if len(assessmentJSON) > 0 {
+		application.CreditAssessment = &models.CreditAssessment{}
+		if err := json.Unmarshal(assessmentJSON, application.CreditAssessment); err != nil {
+			return nil, err
+		}
+	}
+
+	return &application, nil
+}
+
 // PostgresLoanRepository implements LoanRepository for PostgreSQL
 type PostgresLoanRepository struct {
 	db *sql.DB
@@ -37,10 +85,19 @@ func (r *PostgresLoanRepository) CreateLoanApplication(ctx context.Context, appl
 		return err
 	}
 
+	var assessmentJSON []byte
+	if application.CreditAssessment != nil {
+		assessmentJSON, err = json.Marshal(application.CreditAssessment)
+		if err != nil {
+			return err
+		}
+	}
+
 	query := `
 		INSERT INTO loan_applications (
-			id, customer_id, amount_requested, purpose, income_details, status, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			id, customer_id, amount_requested, purpose, income_details, status, created_at, updated_at,
+			credit_assessment
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
 
 	_, err = r.db.ExecContext(
@@ -54,6 +111,7 @@ func (r *PostgresLoanRepository) CreateLoanApplication(ctx context.Context, appl
 		application.Status,
 		application.CreatedAt,
 		application.UpdatedAt,
+		assessmentJSON,
 	)
 
 	return err
@@ -62,27 +120,12 @@ func (r *PostgresLoanRepository) CreateLoanApplication(ctx context.Context, appl
 // GetLoanApplicationByID retrieves a loan application by ID
 func (r *PostgresLoanRepository) GetLoanApplicationByID(ctx context.Context, id uuid.UUID) (*models.LoanApplication, error) {
 	query := `
-		SELECT id, customer_id, amount_requested, purpose, income_details, status, 
-		       created_at, updated_at, status_reason
+		SELECT ` + loanApplicationColumns + `
 		FROM loan_applications 
 		WHERE id = $1
 	`
 
-	var application models.LoanApplication
-	var incomeDetailsJSON []byte
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&application.ID,
-		&application.CustomerID,
-		&application.AmountRequested,
-		&application.Purpose,
-		&incomeDetailsJSON,
-		&application.Status,
-		&application.CreatedAt,
-		&application.UpdatedAt,
-		&application.StatusReason,
-	)
-
+	application, err := scanLoanApplication(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // Not found
@@ -90,19 +133,13 @@ func (r *PostgresLoanRepository) GetLoanApplicationByID(ctx context.Context, id
 		return nil, err
 	}
 
-	// Unmarshal the JSON income details
-	if err := json.Unmarshal(incomeDetailsJSON, &application.IncomeDetails); err != nil {
-		return nil, err
-	}
-
-	return &application, nil
+	return application, nil
 }
 
 // GetCustomerLoanApplications retrieves all loan applications for a customer
 func (r *PostgresLoanRepository) GetCustomerLoanApplications(ctx context.Context, customerID uuid.UUID) ([]*models.LoanApplication, error) {
 	query := `
-		SELECT id, customer_id, amount_requested, purpose, income_details, status, 
-		       created_at, updated_at, status_reason
+		SELECT ` + loanApplicationColumns + `
 		FROM loan_applications 
 		WHERE customer_id = $1
 		ORDER BY created_at DESC
@@ -117,31 +154,12 @@ func (r *PostgresLoanRepository) GetCustomerLoanApplications(ctx context.Context
 	applications := []*models.LoanApplication{}
 
 	for rows.Next() {
-		var application models.LoanApplication
-		var incomeDetailsJSON []byte
-
-		err := rows.Scan(
-			&application.ID,
-			&application.CustomerID,
-			&application.AmountRequested,
-			&application.Purpose,
-			&incomeDetailsJSON,
-			&application.Status,
-			&application.CreatedAt,
-			&application.UpdatedAt,
-			&application.StatusReason,
-		)
-
+		application, err := scanLoanApplication(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		// Unmarshal the JSON income details
-		if err := json.Unmarshal(incomeDetailsJSON, &application.IncomeDetails); err != nil {
-			return nil, err
-		}
-
-		applications = append(applications, &application)
+		applications = append(applications, application)
 	}
 
 	if err := rows.Err(); err != nil {
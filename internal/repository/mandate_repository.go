@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrMandateExists is returned when the customer already holds a mandate on the account
+var ErrMandateExists = errors.New("customer already holds a mandate on the account")
+
+// GetMandate retrieves the mandate of a customer on an account
+func (r *PostgresAccountRepository) GetMandate(ctx context.Context, accountID, customerID uuid.UUID) (*models.AccountMandate, error) {
+	query := `
+		SELECT account_id, customer_id, role, created_by, created_at
+		FROM account_mandates
+		WHERE account_id = $1 AND customer_id = $2
+	`
+
+	mandate, err := scanMandate(r.db.QueryRowContext(ctx, query, accountID, customerID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	return mandate, nil
+}
+
+// GetMandates retrieves every holder of an account, primary holder first
+func (r *PostgresAccountRepository) GetMandates(ctx context.Context, accountID uuid.UUID) ([]*models.AccountMandate, error) {
+	query := `
+		SELECT account_id, customer_id, role, created_by, created_at
+		FROM account_mandates
+		WHERE account_id = $1
+		ORDER BY role = 'primary' DESC, created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mandates := []*models.AccountMandate{}
+	for rows.Next() {
+		mandate, err := scanMandate(rows)
+		if err != nil {
+			return nil, err
+		}
+		mandates = append(mandates, mandate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return mandates, nil
+}
+
+// AddMandate gives a customer a role on an account
+func (r *PostgresAccountRepository) AddMandate(ctx context.Context, mandate *models.AccountMandate) error {
+	_, err := r.db.ExecContext(ctx, insertMandateQuery,
+		mandate.AccountID, mandate.CustomerID, mandate.Role, mandate.CreatedBy, mandate.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrMandateExists
+	}
+	return err
+}
+
+// RemoveMandate removes a holder other than the primary holder from an
+// account and reports whether a mandate was removed
+func (r *PostgresAccountRepository) RemoveMandate(ctx context.Context, accountID, customerID uuid.UUID) (bool, error) {
+	query := `DELETE FROM account_mandates WHERE account_id = $1 AND customer_id = $2 AND role <> $3`
+
+	result, err := r.db.ExecContext(ctx, query, accountID, customerID, models.MandatePrimary)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
+
+// UpdateSigningRule changes the signing rule of an account
+func (r *PostgresAccountRepository) UpdateSigningRule(ctx context.Context, accountID uuid.UUID, rule models.SigningRule) error {
+	query := `UPDATE accounts SET signing_rule = $1, updated_at = NOW() WHERE id = $2`
+
+	_, err := r.db.ExecContext(ctx, query, rule, accountID)
+	return err
+}
+
+func scanMandate(row rowScanner) (*models.AccountMandate, error) {
+	var mandate models.AccountMandate
+	var createdBy uuid.NullUUID
+
+	err := row.Scan(
+		&mandate.AccountID,
+		&mandate.CustomerID,
+		&mandate.Role,
+		&createdBy,
+		&mandate.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if createdBy.Valid {
+		mandate.CreatedBy = &createdBy.UUID
+	}
+	return &mandate, nil
+}
+
+const insertMandateQuery = `
+	INSERT INTO account_mandates (account_id, customer_id, role, created_by, created_at)
+	VALUES ($1, $2, $3, $4, $5)
+`
+
+func insertMandate(ctx context.Context, tx *sql.Tx, mandate *models.AccountMandate) error {
+	_, err := tx.ExecContext(ctx, insertMandateQuery,
+		mandate.AccountID, mandate.CustomerID, mandate.Role, mandate.CreatedBy, mandate.CreatedAt)
+	return err
+}
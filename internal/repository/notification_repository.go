@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+)
+
+// NotificationRepository defines operations for the customer in-app inbox
+type NotificationRepository interface {
+	CreateNotification(ctx context.Context, notification *models.Notification) error
+	GetCustomerNotifications(ctx context.Context, customerID uuid.UUID, limit int) ([]*models.Notification, error)
+}
+
+// PostgresNotificationRepository implements NotificationRepository for PostgreSQL
+type PostgresNotificationRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresNotificationRepository creates a new PostgresNotificationRepository
+func NewPostgresNotificationRepository(db *sql.DB) *PostgresNotificationRepository {
+	return &PostgresNotificationRepository{
+		db: db,
+	}
+}
+
+// CreateNotification inserts a notification into the customer's inbox
+func (r *PostgresNotificationRepository) CreateNotification(ctx context.Context, notification *models.Notification) error {
+	query := `
+		INSERT INTO customer_notifications (id, customer_id, type, title, message, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		notification.ID,
+		notification.CustomerID,
+		notification.Type,
+		notification.Title,
+		notification.Message,
+		notification.CreatedAt,
+	)
+	return err
+}
+
+// GetCustomerNotifications retrieves a customer's latest notifications, newest first
+func (r *PostgresNotificationRepository) GetCustomerNotifications(ctx context.Context, customerID uuid.UUID, limit int) ([]*models.Notification, error) {
+	query := `
+		SELECT id, customer_id, type, title, message, created_at
+		FROM customer_notifications
+		WHERE customer_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, customerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []*models.Notification{}
+	for rows.Next() {
+		var notification models.Notification
+		err := rows.Scan(
+			&notification.ID,
+			&notification.CustomerID,
+			&notification.Type,
+			&notification.Title,
+			&notification.Message,
+			&notification.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, &notification)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
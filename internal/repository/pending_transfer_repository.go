@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+)
+
+// PendingTransferRepository defines operations for transfers awaiting co-owner approval
+type PendingTransferRepository interface {
+	CreatePendingTransfer(ctx context.Context, transfer *models.PendingTransfer) error
+	GetPendingTransfer(ctx context.Context, id uuid.UUID) (*models.PendingTransfer, error)
+	GetAccountPendingTransfers(ctx context.Context, accountID uuid.UUID) ([]*models.PendingTransfer, error)
+	RecordDecision(ctx context.Context, id, customerID uuid.UUID, decision models.ApprovalDecision, now time.Time) (*models.PendingTransfer, error)
+	CompletePendingTransfer(ctx context.Context, transfer *models.PendingTransfer) error
+	ExpirePendingTransfer(ctx context.Context, id uuid.UUID, now time.Time) (bool, error)
+}
+
+// PostgresPendingTransferRepository implements PendingTransferRepository for PostgreSQL
+type PostgresPendingTransferRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresPendingTransferRepository creates a new PostgresPendingTransferRepository
+func NewPostgresPendingTransferRepository(db *sql.DB) *PostgresPendingTransferRepository {
+	return &PostgresPendingTransferRepository{
+		db: db,
+	}
+}
+
+// pendingTransferColumns lists the columns read by scanPendingTransfer, in order
+const pendingTransferColumns = `id, account_id, to_account_id, to_account_number, amount, description, initiated_by,
+		       status, transaction_id, failure_reason, expires_at, created_at, updated_at`
+
+func scanPendingTransfer(row rowScanner) (*models.PendingTransfer, error) {
+	var transfer models.PendingTransfer
+	var transactionID uuid.NullUUID
+
+	err := row.Scan(
+		&transfer.ID,
+		&transfer.AccountID,
+		&transfer.ToAccountID,
+		&transfer.ToAccountNumber,
+		&transfer.Amount,
+		&transfer.Description,
+		&transfer.InitiatedBy,
+		&transfer.Status,
+		&transactionID,
+		&transfer.FailureReason,
+		&transfer.ExpiresAt,
+		&transfer.CreatedAt,
+		&transfer.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if transactionID.Valid {
+		transfer.TransactionID = &transactionID.UUID
+	}
+	return &transfer, nil
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func loadApprovals(ctx context.Context, q queryer, transfer *models.PendingTransfer) error {
+	rows, err := q.QueryContext(ctx, `
+		SELECT customer_id, decision, decided_at
+		FROM transfer_approvals
+		WHERE pending_transfer_id = $1
+		ORDER BY customer_id
+	`, transfer.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	transfer.Approvals = []models.TransferApproval{}
+	for rows.Next() {
+		var approval models.TransferApproval
+		var decidedAt sql.NullTime
+		if err := rows.Scan(&approval.CustomerID, &approval.Decision, &decidedAt); err != nil {
+			return err
+		}
+		if decidedAt.Valid {
+			approval.DecidedAt = &decidedAt.Time
+		}
+		transfer.Approvals = append(transfer.Approvals, approval)
+	}
+	return rows.Err()
+}
+
+// CreatePendingTransfer inserts a pending transfer together with one approval
+// row per holder listed in transfer.Approvals
+func (r *PostgresPendingTransferRepository) CreatePendingTransfer(ctx context.Context, transfer *models.PendingTransfer) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO pending_transfers (
+			id, account_id, to_account_id, to_account_number, amount, description, initiated_by,
+			status, failure_reason, expires_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`,
+		transfer.ID,
+		transfer.AccountID,
+		transfer.ToAccountID,
+		transfer.ToAccountNumber,
+		transfer.Amount,
+		transfer.Description,
+		transfer.InitiatedBy,
+		transfer.Status,
+		transfer.FailureReason,
+		transfer.ExpiresAt,
+		transfer.CreatedAt,
+		transfer.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, approval := range transfer.Approvals {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO transfer_approvals (pending_transfer_id, customer_id, decision, decided_at)
+			VALUES ($1, $2, $3, $4)
+		`, transfer.ID, approval.CustomerID, approval.Decision, approval.DecidedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetPendingTransfer retrieves a pending transfer with its approvals by ID
+func (r *PostgresPendingTransferRepository) GetPendingTransfer(ctx context.Context, id uuid.UUID) (*models.PendingTransfer, error) {
+	query := `SELECT ` + pendingTransferColumns + ` FROM pending_transfers WHERE id = $1`
+
+	transfer, err := scanPendingTransfer(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	if err := loadApprovals(ctx, r.db, transfer); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// GetAccountPendingTransfers retrieves the transfers of an account that
+// needed co-owner approval, newest first
+func (r *PostgresPendingTransferRepository) GetAccountPendingTransfers(ctx context.Context, accountID uuid.UUID) ([]*models.PendingTransfer, error) {
+	query := `SELECT ` + pendingTransferColumns + ` FROM pending_transfers WHERE account_id = $1 ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := []*models.PendingTransfer{}
+	for rows.Next() {
+		transfer, err := scanPendingTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, transfer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, transfer := range transfers {
+		if err := loadApprovals(ctx, r.db, transfer); err != nil {
+			return nil, err
+		}
+	}
+	return transfers, nil
+}
+
+// RecordDecision saves a holder's answer to a transfer that is still
+// pending. The transfer row is locked, so when the last approval arrives
+// exactly one caller sees the status move to executing and posts the
+// transfer; a rejection moves it to rejected. It returns nil when the
+// transfer does not exist, and the transfer unchanged when it is no longer
+// pending or the holder already answered.
+func (r *PostgresPendingTransferRepository) RecordDecision(ctx context.Context, id, customerID uuid.UUID, decision models.ApprovalDecision, now time.Time) (*models.PendingTransfer, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT ` + pendingTransferColumns + ` FROM pending_transfers WHERE id = $1 FOR UPDATE`
+	transfer, err := scanPendingTransfer(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	if transfer.Status != models.PendingTransferAwaiting || !transfer.ExpiresAt.After(now) {
+		return transfer, loadApprovals(ctx, tx, transfer)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE transfer_approvals SET decision = $1, decided_at = $2
+		WHERE pending_transfer_id = $3 AND customer_id = $4 AND decision = $5
+	`, decision, now, id, customerID, models.ApprovalPending)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadApprovals(ctx, tx, transfer); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case decision == models.ApprovalRejected:
+		transfer.Status = models.PendingTransferRejected
+	case !transfer.Outstanding():
+		transfer.Status = models.PendingTransferExecuting
+	}
+	if transfer.Status != models.PendingTransferAwaiting {
+		transfer.UpdatedAt = now
+		_, err = tx.ExecContext(ctx, `UPDATE pending_transfers SET status = $1, updated_at = $2 WHERE id = $3`,
+			transfer.Status, transfer.UpdatedAt, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return transfer, tx.Commit()
+}
+
+// CompletePendingTransfer saves the outcome of posting an approved transfer
+func (r *PostgresPendingTransferRepository) CompletePendingTransfer(ctx context.Context, transfer *models.PendingTransfer) error {
+	query := `
+		UPDATE pending_transfers
+		SET status = $1, transaction_id = $2, failure_reason = $3, updated_at = $4
+		WHERE id = $5 AND status = $6
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		transfer.Status, transfer.TransactionID, transfer.FailureReason, transfer.UpdatedAt,
+		transfer.ID, models.PendingTransferExecuting)
+	return err
+}
+
+// ExpirePendingTransfer marks a transfer that is still pending after its
+// expiry as expired and reports whether it changed
+func (r *PostgresPendingTransferRepository) ExpirePendingTransfer(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	query := `UPDATE pending_transfers SET status = $1, updated_at = $2 WHERE id = $3 AND status = $4 AND expires_at <= $2`
+
+	result, err := r.db.ExecContext(ctx, query, models.PendingTransferExpired, now, id, models.PendingTransferAwaiting)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrProductExists is returned when creating a product whose code is already used
+var ErrProductExists = errors.New("product code already exists")
+
+// ErrProductVersionConflict is returned when another version was created concurrently
+var ErrProductVersionConflict = errors.New("product was changed concurrently")
+
+// ProductRepository defines operations for the account product catalog
+type ProductRepository interface {
+	CreateProductVersion(ctx context.Context, product *models.AccountProduct) error
+	GetLatestProduct(ctx context.Context, code string) (*models.AccountProduct, error)
+	GetProductVersion(ctx context.Context, code string, version int) (*models.AccountProduct, error)
+	GetProductVersions(ctx context.Context, code string) ([]*models.AccountProduct, error)
+	ListLatestProducts(ctx context.Context) ([]*models.AccountProduct, error)
+}
+
+// PostgresProductRepository implements ProductRepository for PostgreSQL
+type PostgresProductRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresProductRepository creates a new PostgresProductRepository
+func NewPostgresProductRepository(db *sql.DB) *PostgresProductRepository {
+	return &PostgresProductRepository{
+		db: db,
+	}
+}
+
+// productColumns lists the columns read by scanProduct, in order
+const productColumns = `id, code, version, name, category, min_opening_balance, min_balance, fees,
+		       interest_tiers, withdrawal_limits, eligibility, term_rates, early_withdrawal_rate,
+		       status, created_by, created_at`
+
+func scanProduct(row rowScanner) (*models.AccountProduct, error) {
+	var product models.AccountProduct
+	var fees, tiers, limits, eligibility, termRates []byte
+	var createdBy uuid.NullUUID
+
+	err := row.Scan(
+		&product.ID,
+		&product.Code,
+		&product.Version,
+		&product.Name,
+		&product.Category,
+		&product.MinOpeningBalance,
+		&product.MinBalance,
+		&fees,
+		&tiers,
+		&limits,
+		&eligibility,
+		&termRates,
+		&product.EarlyWithdrawalRate,
+		&product.Status,
+		&createdBy,
+		&product.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if createdBy.Valid {
+		product.CreatedBy = &createdBy.UUID
+	}
+	for _, field := range []struct {
+		data []byte
+		dest interface{}
+	}{
+		{fees, &product.Fees},
+		{tiers, &product.InterestTiers},
+		{limits, &product.WithdrawalLimits},
+		{eligibility, &product.Eligibility},
+		{termRates, &product.TermRates},
+	} {
+		if err := json.Unmarshal(field.data, field.dest); err != nil {
+			return nil, err
+		}
+	}
+
+	return &product, nil
+}
+
+// CreateProductVersion inserts a product version. Version 1 of an existing
+// code returns ErrProductExists; any other duplicate version returns
+// ErrProductVersionConflict.
+func (r *PostgresProductRepository) CreateProductVersion(ctx context.Context, product *models.AccountProduct) error {
+	fees, err := json.Marshal(product.Fees)
+	if err != nil {
+		return err
+	}
+	tiers, err := json.Marshal(product.InterestTiers)
+	if err != nil {
+		return err
+	}
+	limits, err := json.Marshal(product.WithdrawalLimits)
+	if err != nil {
+		return err
+	}
+	eligibility, err := json.Marshal(product.Eligibility)
+	if err != nil {
+		return err
+	}
+	termRates, err := json.Marshal(product.TermRates)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO account_products (` + productColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`
+
+	_, err = r.db.ExecContext(
+		ctx,
+		query,
+		product.ID,
+		product.Code,
+		product.Version,
+		product.Name,
+		product.Category,
+		product.MinOpeningBalance,
+		product.MinBalance,
+		fees,
+		tiers,
+		limits,
+		eligibility,
+		termRates,
+		product.EarlyWithdrawalRate,
+		product.Status,
+		product.CreatedBy,
+		product.CreatedAt,
+	)
+	if isUniqueViolation(err) {
+		if product.Version == 1 {
+			return ErrProductExists
+		}
+		return ErrProductVersionConflict
+	}
+	return err
+}
+
+// GetLatestProduct retrieves the current version of a product
+func (r *PostgresProductRepository) GetLatestProduct(ctx context.Context, code string) (*models.AccountProduct, error) {
+	query := `
+		SELECT ` + productColumns + `
+		FROM account_products
+		WHERE code = $1
+		ORDER BY version DESC
+		LIMIT 1
+	`
+
+	product, err := scanProduct(r.db.QueryRowContext(ctx, query, code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	return product, nil
+}
+
+// GetProductVersion retrieves a specific version of a product
+func (r *PostgresProductRepository) GetProductVersion(ctx context.Context, code string, version int) (*models.AccountProduct, error) {
+	query := `
+		SELECT ` + productColumns + `
+		FROM account_products
+		WHERE code = $1 AND version = $2
+	`
+
+	product, err := scanProduct(r.db.QueryRowContext(ctx, query, code, version))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	return product, nil
+}
+
+// GetProductVersions retrieves every version of a product, newest first
+func (r *PostgresProductRepository) GetProductVersions(ctx context.Context, code string) ([]*models.AccountProduct, error) {
+	query := `
+		SELECT ` + productColumns + `
+		FROM account_products
+		WHERE code = $1
+		ORDER BY version DESC
+	`
+
+	return r.queryProducts(ctx, query, code)
+}
+
+// ListLatestProducts retrieves the current version of every product
+func (r *PostgresProductRepository) ListLatestProducts(ctx context.Context) ([]*models.AccountProduct, error) {
+	query := `
+		SELECT DISTINCT ON (code) ` + productColumns + `
+		FROM account_products
+		ORDER BY code, version DESC
+	`
+
+	return r.queryProducts(ctx, query)
+}
+
+func (r *PostgresProductRepository) queryProducts(ctx context.Context, query string, args ...interface{}) ([]*models.AccountProduct, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []*models.AccountProduct{}
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// loadWithdrawalRules loads the minimum balance and withdrawal limits of the
+// product version of a locked account, with the withdrawals posted from it in
+// the month and on the day of businessDate. Accounts without a product are
+// left without rules.
+func loadWithdrawalRules(ctx context.Context, tx *sql.Tx, account *models.Account, businessDate time.Time) error {
+	if account.ProductCode == "" {
+		return nil
+	}
+
+	var rules models.WithdrawalRules
+	var limits []byte
+	err := tx.QueryRowContext(ctx, `
+		SELECT min_balance, withdrawal_limits
+		FROM account_products
+		WHERE code = $1 AND version = $2
+	`, account.ProductCode, account.ProductVersion).Scan(&rules.MinBalance, &limits)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(limits, &rules.Limits); err != nil {
+		return err
+	}
+
+	day := time.Date(businessDate.Year(), businessDate.Month(), businessDate.Day(), 0, 0, 0, 0, businessDate.Location())
+	month := day.AddDate(0, 0, 1-day.Day())
+	types := []string{}
+	for _, withdrawal := range models.WithdrawalTypes {
+		types = append(types, string(withdrawal))
+	}
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN t.business_date >= $3 THEN e.amount ELSE 0 END), 0)
+		FROM ledger_entries e
+		JOIN ledger_transactions t ON t.id = e.transaction_id
+		WHERE e.account_id = $1 AND e.direction = $2 AND t.business_date >= $4
+		  AND t.type = ANY($5) AND t.status <> $6
+	`, account.ID, models.EntryDebit, day, month, pq.Array(types), models.LedgerReturned).Scan(&rules.WithdrawalsThisMonth, &rules.WithdrawnToday)
+	if err != nil {
+		return err
+	}
+
+	account.WithdrawalRules = &rules
+	return nil
+}
@@ -0,0 +1,287 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrProxyTaken is returned when the proxy is already registered to an account
+var ErrProxyTaken = errors.New("proxy is already registered")
+
+// ProxyRepository defines operations for the proxy registry and transfers to proxies
+type ProxyRepository interface {
+	CreateProxy(ctx context.Context, proxy *models.ProxyRegistration) error
+	GetProxy(ctx context.Context, id uuid.UUID) (*models.ProxyRegistration, error)
+	GetActiveProxy(ctx context.Context, proxyType models.ProxyType, value string) (*models.ProxyRegistration, error)
+	GetAccountProxies(ctx context.Context, accountID uuid.UUID) ([]*models.ProxyRegistration, error)
+	DeregisterProxy(ctx context.Context, id uuid.UUID, now time.Time) (bool, error)
+	CreateProxyTransfer(ctx context.Context, transfer *models.ProxyTransfer) error
+	GetProxyTransfer(ctx context.Context, id uuid.UUID) (*models.ProxyTransfer, error)
+	GetPendingProxyTransfers(ctx context.Context) ([]*models.ProxyTransfer, error)
+	UpdateProxyTransfer(ctx context.Context, transfer *models.ProxyTransfer, from models.ProxyTransferStatus) (bool, error)
+}
+
+// PostgresProxyRepository implements ProxyRepository for PostgreSQL
+type PostgresProxyRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresProxyRepository creates a new PostgresProxyRepository
+func NewPostgresProxyRepository(db *sql.DB) *PostgresProxyRepository {
+	return &PostgresProxyRepository{
+		db: db,
+	}
+}
+
+// proxyColumns lists the columns read by scanProxy, in order
+const proxyColumns = `id, proxy_type, proxy_value, account_id, customer_id, status, consented_at,
+		       deregistered_at, created_at, updated_at`
+
+func scanProxy(row rowScanner) (*models.ProxyRegistration, error) {
+	var proxy models.ProxyRegistration
+	var deregisteredAt sql.NullTime
+
+	err := row.Scan(
+		&proxy.ID,
+		&proxy.ProxyType,
+		&proxy.ProxyValue,
+		&proxy.AccountID,
+		&proxy.CustomerID,
+		&proxy.Status,
+		&proxy.ConsentedAt,
+		&deregisteredAt,
+		&proxy.CreatedAt,
+		&proxy.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if deregisteredAt.Valid {
+		proxy.DeregisteredAt = &deregisteredAt.Time
+	}
+	return &proxy, nil
+}
+
+// CreateProxy inserts a proxy registration. Only one active registration may
+// exist per proxy; a second one returns ErrProxyTaken.
+func (r *PostgresProxyRepository) CreateProxy(ctx context.Context, proxy *models.ProxyRegistration) error {
+	query := `
+		INSERT INTO proxy_registrations (
+			id, proxy_type, proxy_value, account_id, customer_id, status, consented_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		proxy.ID,
+		proxy.ProxyType,
+		proxy.ProxyValue,
+		proxy.AccountID,
+		proxy.CustomerID,
+		proxy.Status,
+		proxy.ConsentedAt,
+		proxy.CreatedAt,
+		proxy.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return ErrProxyTaken
+	}
+	return err
+}
+
+// GetProxy retrieves a proxy registration by ID
+func (r *PostgresProxyRepository) GetProxy(ctx context.Context, id uuid.UUID) (*models.ProxyRegistration, error) {
+	query := `SELECT ` + proxyColumns + ` FROM proxy_registrations WHERE id = $1`
+	return r.queryProxy(ctx, query, id)
+}
+
+// GetActiveProxy retrieves the active registration of a proxy
+func (r *PostgresProxyRepository) GetActiveProxy(ctx context.Context, proxyType models.ProxyType, value string) (*models.ProxyRegistration, error) {
+	query := `SELECT ` + proxyColumns + ` FROM proxy_registrations WHERE proxy_type = $1 AND proxy_value = $2 AND status = 'active'`
+	return r.queryProxy(ctx, query, proxyType, value)
+}
+
+func (r *PostgresProxyRepository) queryProxy(ctx context.Context, query string, args ...interface{}) (*models.ProxyRegistration, error) {
+	proxy, err := scanProxy(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	return proxy, nil
+}
+
+// GetAccountProxies retrieves the proxy registrations of an account, newest first
+func (r *PostgresProxyRepository) GetAccountProxies(ctx context.Context, accountID uuid.UUID) ([]*models.ProxyRegistration, error) {
+	query := `SELECT ` + proxyColumns + ` FROM proxy_registrations WHERE account_id = $1 ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	proxies := []*models.ProxyRegistration{}
+	for rows.Next() {
+		proxy, err := scanProxy(rows)
+		if err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, proxy)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return proxies, nil
+}
+
+// DeregisterProxy marks an active registration deregistered and reports whether it did
+func (r *PostgresProxyRepository) DeregisterProxy(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	query := `
+		UPDATE proxy_registrations SET status = 'deregistered', deregistered_at = $1, updated_at = $1
+		WHERE id = $2 AND status = 'active'
+	`
+
+	result, err := r.db.ExecContext(ctx, query, now, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
+
+// CreateProxyTransfer inserts a transfer to a proxy
+func (r *PostgresProxyRepository) CreateProxyTransfer(ctx context.Context, transfer *models.ProxyTransfer) error {
+	query := `
+		INSERT INTO proxy_transfers (
+			id, customer_id, from_account_id, proxy_type, proxy_value, bank_code, recipient_name,
+			to_account_id, to_account_number, amount, description, status, expires_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		transfer.ID,
+		transfer.CustomerID,
+		transfer.FromAccountID,
+		transfer.ProxyType,
+		transfer.ProxyValue,
+		transfer.BankCode,
+		transfer.RecipientName,
+		transfer.ToAccountID,
+		transfer.ToAccountNumber,
+		transfer.Amount,
+		transfer.Description,
+		transfer.Status,
+		transfer.ExpiresAt,
+		transfer.CreatedAt,
+		transfer.UpdatedAt,
+	)
+	return err
+}
+
+// proxyTransferColumns lists the columns read by scanProxyTransfer, in order
+const proxyTransferColumns = `id, customer_id, from_account_id, proxy_type, proxy_value, bank_code, recipient_name,
+		       to_account_id, to_account_number, amount, description, status, transaction_id, failure_reason,
+		       expires_at, created_at, updated_at`
+
+func scanProxyTransfer(row rowScanner) (*models.ProxyTransfer, error) {
+	var transfer models.ProxyTransfer
+	var toAccountID, transactionID uuid.NullUUID
+	err := row.Scan(
+		&transfer.ID,
+		&transfer.CustomerID,
+		&transfer.FromAccountID,
+		&transfer.ProxyType,
+		&transfer.ProxyValue,
+		&transfer.BankCode,
+		&transfer.RecipientName,
+		&toAccountID,
+		&transfer.ToAccountNumber,
+		&transfer.Amount,
+		&transfer.Description,
+		&transfer.Status,
+		&transactionID,
+		&transfer.FailureReason,
+		&transfer.ExpiresAt,
+		&transfer.CreatedAt,
+		&transfer.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if toAccountID.Valid {
+		transfer.ToAccountID = &toAccountID.UUID
+	}
+	if transactionID.Valid {
+		transfer.TransactionID = &transactionID.UUID
+	}
+	return &transfer, nil
+}
+
+// GetProxyTransfer retrieves a transfer to a proxy by ID
+func (r *PostgresProxyRepository) GetProxyTransfer(ctx context.Context, id uuid.UUID) (*models.ProxyTransfer, error) {
+	query := `SELECT ` + proxyTransferColumns + ` FROM proxy_transfers WHERE id = $1`
+
+	transfer, err := scanProxyTransfer(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// GetPendingProxyTransfers retrieves the transfers still waiting for the
+// switch to answer, oldest first
+func (r *PostgresProxyRepository) GetPendingProxyTransfers(ctx context.Context) ([]*models.ProxyTransfer, error) {
+	query := `SELECT ` + proxyTransferColumns + ` FROM proxy_transfers WHERE status = $1 ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, models.ProxyTransferPending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := []*models.ProxyTransfer{}
+	for rows.Next() {
+		transfer, err := scanProxyTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, transfer)
+	}
+	return transfers, rows.Err()
+}
+
+// UpdateProxyTransfer saves the status and outcome of a transfer if it is
+// still in status from, and reports whether it changed
+func (r *PostgresProxyRepository) UpdateProxyTransfer(ctx context.Context, transfer *models.ProxyTransfer, from models.ProxyTransferStatus) (bool, error) {
+	query := `
+		UPDATE proxy_transfers SET status = $1, transaction_id = $2, failure_reason = $3, updated_at = $4
+		WHERE id = $5 AND status = $6
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		transfer.Status,
+		transfer.TransactionID,
+		transfer.FailureReason,
+		transfer.UpdatedAt,
+		transfer.ID,
+		from,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
@@ -0,0 +1,77 @@
+package repotest
+
+import (
+	"example.com/m/internal/database"
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+)
+
+// Account returns an active savings account with the number and balance. A
+// test sets the fields its case depends on, such as the type or currency,
+// on the account returned.
+func Account(number string, balance float64) *models.Account {
+	return &models.Account{
+		ID:            uuid.New(),
+		AccountNumber: number,
+		AccountType:   models.AccountTypeSavings,
+		Status:        models.AccountStatusActive,
+		Balance:       balance,
+	}
+}
+
+// CustomerAccount returns an active savings account held by the customer
+func CustomerAccount(customerID uuid.UUID, number string, balance float64) *models.Account {
+	account := Account(number, balance)
+	account.CustomerID = &customerID
+	return account
+}
+
+// InternalAccount returns the bank's active internal account with the
+// number, usually one of the models.GL accounts
+func InternalAccount(number string) *models.Account {
+	account := Account(number, 0)
+	account.AccountType = models.AccountTypeInternal
+	return account
+}
+
+// ByID maps the accounts by ID, as the ledger stubs hold them
+func ByID(accounts ...*models.Account) map[uuid.UUID]*models.Account {
+	byID := make(map[uuid.UUID]*models.Account, len(accounts))
+	for _, account := range accounts {
+		byID[account.ID] = account
+	}
+	return byID
+}
+
+// Customer returns a customer with the ID card and phone numbers on record
+func Customer(id uuid.UUID, idCardNumber, phoneNumber string) *models.Customer {
+	return &models.Customer{
+		ID:           id.String(),
+		IDCardNumber: idCardNumber,
+		PhoneNumber:  phoneNumber,
+	}
+}
+
+// Customers finds customers in memory by ID. Like the database it returns
+// database.ErrCustomerNotFound for an unknown ID.
+type Customers struct {
+	Customers map[string]*models.Customer
+}
+
+// NewCustomers creates a Customers holding the customers
+func NewCustomers(customers ...*models.Customer) *Customers {
+	r := &Customers{Customers: map[string]*models.Customer{}}
+	for _, customer := range customers {
+		r.Customers[customer.ID] = customer
+	}
+	return r
+}
+
+// GetByID returns the customer with the ID
+func (r *Customers) GetByID(id string) (*models.Customer, error) {
+	customer, ok := r.Customers[id]
+	if !ok {
+		return nil, database.ErrCustomerNotFound
+	}
+	return customer, nil
+}
@@ -0,0 +1,110 @@
+// Package repotest provides in-memory repositories for service tests. Each
+// embeds its repository interface, so methods a test does not need panic
+// when called.
+package repotest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Accounts finds accounts in memory by ID and by number. The accounts are
+// returned as stored, so a test sees the changes a service makes to them.
+// DefaultIncoming holds each customer's default account for incoming
+// transfers.
+type Accounts struct {
+	repository.AccountRepository
+	Accounts        []*models.Account
+	DefaultIncoming map[uuid.UUID]*models.Account
+}
+
+// GetAccountByID returns the account with the ID, or nil
+func (r *Accounts) GetAccountByID(ctx context.Context, id uuid.UUID) (*models.Account, error) {
+	for _, account := range r.Accounts {
+		if account.ID == id {
+			return account, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetAccountByNumber returns the account with the number, or nil
+func (r *Accounts) GetAccountByNumber(ctx context.Context, accountNumber string) (*models.Account, error) {
+	for _, account := range r.Accounts {
+		if account.AccountNumber == accountNumber {
+			return account, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetDefaultIncomingAccount returns the customer's default account, or nil
+func (r *Accounts) GetDefaultIncomingAccount(ctx context.Context, customerID uuid.UUID) (*models.Account, error) {
+	return r.DefaultIncoming[customerID], nil
+}
+
+// Notifications records the notifications sent to customers
+type Notifications struct {
+	repository.NotificationRepository
+	Notifications []*models.Notification
+}
+
+// CreateNotification records the notification
+func (r *Notifications) CreateNotification(ctx context.Context, notification *models.Notification) error {
+	r.Notifications = append(r.Notifications, notification)
+	return nil
+}
+
+// GetCustomerNotifications returns the customer's notifications, newest first
+func (r *Notifications) GetCustomerNotifications(ctx context.Context, customerID uuid.UUID, limit int) ([]*models.Notification, error) {
+	notifications := []*models.Notification{}
+	for i := len(r.Notifications) - 1; i >= 0 && len(notifications) < limit; i-- {
+		if r.Notifications[i].CustomerID == customerID {
+			notifications = append(notifications, r.Notifications[i])
+		}
+	}
+	return notifications, nil
+}
+
+// IdentityAttempts counts customers' identity checks in memory, with a lock
+// standing in for the row lock of the Postgres upsert
+type IdentityAttempts struct {
+	mu       sync.Mutex
+	attempts map[uuid.UUID]int
+	started  map[uuid.UUID]time.Time
+}
+
+// UseVerificationAttempt counts one check and reports whether it is allowed
+func (r *IdentityAttempts) UseVerificationAttempt(ctx context.Context, customerID uuid.UUID, now, windowStart time.Time, max int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.attempts == nil {
+		r.attempts = map[uuid.UUID]int{}
+		r.started = map[uuid.UUID]time.Time{}
+	}
+	started, ok := r.started[customerID]
+	if !ok || started.Before(windowStart) {
+		r.attempts[customerID] = 1
+		r.started[customerID] = now
+		return true, nil
+	}
+	if r.attempts[customerID] >= max {
+		return false, nil
+	}
+	r.attempts[customerID]++
+	return true, nil
+}
+
+// ClearVerificationAttempts forgets the customer's checks
+func (r *IdentityAttempts) ClearVerificationAttempts(ctx context.Context, customerID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.attempts, customerID)
+	delete(r.started, customerID)
+	return nil
+}
@@ -0,0 +1,301 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// RestrictionRepository defines operations for account restrictions and their audit trail
+type RestrictionRepository interface {
+	CreateRestriction(ctx context.Context, restriction *models.Restriction, entry *models.RestrictionAuditEntry) error
+	GetRestriction(ctx context.Context, id uuid.UUID) (*models.Restriction, error)
+	GetAccountRestrictions(ctx context.Context, accountID uuid.UUID) ([]*models.Restriction, error)
+	GetRestrictionsByStatus(ctx context.Context, statuses ...models.RestrictionStatus) ([]*models.Restriction, error)
+	UpdateRestrictionStatus(ctx context.Context, restriction *models.Restriction, from models.RestrictionStatus, entry *models.RestrictionAuditEntry) (bool, error)
+	GetRestrictionAudit(ctx context.Context, restrictionID uuid.UUID) ([]*models.RestrictionAuditEntry, error)
+	GetExpiredRestrictions(ctx context.Context, now time.Time) ([]*models.Restriction, error)
+}
+
+// PostgresRestrictionRepository implements RestrictionRepository for PostgreSQL
+type PostgresRestrictionRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresRestrictionRepository creates a new PostgresRestrictionRepository
+func NewPostgresRestrictionRepository(db *sql.DB) *PostgresRestrictionRepository {
+	return &PostgresRestrictionRepository{
+		db: db,
+	}
+}
+
+// restrictionColumns lists the columns read by scanRestriction, in order
+const restrictionColumns = `id, account_id, type, amount, reference_number, reason, expires_at, status,
+		       requested_by, approved_by, lift_requested_by, lift_reason, created_at, updated_at`
+
+func scanRestriction(row rowScanner) (*models.Restriction, error) {
+	var restriction models.Restriction
+	var approvedBy, liftRequestedBy uuid.NullUUID
+
+	err := row.Scan(
+		&restriction.ID,
+		&restriction.AccountID,
+		&restriction.Type,
+		&restriction.Amount,
+		&restriction.ReferenceNumber,
+		&restriction.Reason,
+		&restriction.ExpiresAt,
+		&restriction.Status,
+		&restriction.RequestedBy,
+		&approvedBy,
+		&liftRequestedBy,
+		&restriction.LiftReason,
+		&restriction.CreatedAt,
+		&restriction.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if approvedBy.Valid {
+		restriction.ApprovedBy = &approvedBy.UUID
+	}
+	if liftRequestedBy.Valid {
+		restriction.LiftRequestedBy = &liftRequestedBy.UUID
+	}
+	return &restriction, nil
+}
+
+func (r *PostgresRestrictionRepository) queryRestrictions(ctx context.Context, query string, args ...interface{}) ([]*models.Restriction, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	restrictions := []*models.Restriction{}
+	for rows.Next() {
+		restriction, err := scanRestriction(rows)
+		if err != nil {
+			return nil, err
+		}
+		restrictions = append(restrictions, restriction)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return restrictions, nil
+}
+
+// CreateRestriction inserts a restriction together with its first audit entry
+func (r *PostgresRestrictionRepository) CreateRestriction(ctx context.Context, restriction *models.Restriction, entry *models.RestrictionAuditEntry) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO account_restrictions (
+			id, account_id, type, amount, reference_number, reason, expires_at, status,
+			requested_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`,
+		restriction.ID,
+		restriction.AccountID,
+		restriction.Type,
+		restriction.Amount,
+		restriction.ReferenceNumber,
+		restriction.Reason,
+		restriction.ExpiresAt,
+		restriction.Status,
+		restriction.RequestedBy,
+		restriction.CreatedAt,
+		restriction.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := insertRestrictionAudit(ctx, tx, entry); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetRestriction retrieves a restriction by ID
+func (r *PostgresRestrictionRepository) GetRestriction(ctx context.Context, id uuid.UUID) (*models.Restriction, error) {
+	query := `SELECT ` + restrictionColumns + ` FROM account_restrictions WHERE id = $1`
+
+	restriction, err := scanRestriction(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return restriction, nil
+}
+
+// GetAccountRestrictions retrieves every restriction of an account, newest first
+func (r *PostgresRestrictionRepository) GetAccountRestrictions(ctx context.Context, accountID uuid.UUID) ([]*models.Restriction, error) {
+	query := `SELECT ` + restrictionColumns + ` FROM account_restrictions WHERE account_id = $1 ORDER BY created_at DESC`
+	return r.queryRestrictions(ctx, query, accountID)
+}
+
+// GetRestrictionsByStatus retrieves the restrictions in any of the statuses, oldest first
+func (r *PostgresRestrictionRepository) GetRestrictionsByStatus(ctx context.Context, statuses ...models.RestrictionStatus) ([]*models.Restriction, error) {
+	values := make([]string, len(statuses))
+	for i, status := range statuses {
+		values[i] = string(status)
+	}
+
+	query := `SELECT ` + restrictionColumns + ` FROM account_restrictions WHERE status = ANY($1) ORDER BY updated_at`
+	return r.queryRestrictions(ctx, query, pq.Array(values))
+}
+
+// UpdateRestrictionStatus saves the status and decision fields of a
+// restriction if it is still in status from, and records the audit entry in
+// the same transaction. It returns false when the restriction was changed
+// by someone else in the meantime.
+func (r *PostgresRestrictionRepository) UpdateRestrictionStatus(ctx context.Context, restriction *models.Restriction, from models.RestrictionStatus, entry *models.RestrictionAuditEntry) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE account_restrictions
+		SET status = $1, approved_by = $2, lift_requested_by = $3, lift_reason = $4, updated_at = $5
+		WHERE id = $6 AND status = $7
+	`,
+		restriction.Status,
+		restriction.ApprovedBy,
+		restriction.LiftRequestedBy,
+		restriction.LiftReason,
+		restriction.UpdatedAt,
+		restriction.ID,
+		from,
+	)
+	if err != nil {
+		return false, err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if updated == 0 {
+		return false, nil
+	}
+
+	if err := insertRestrictionAudit(ctx, tx, entry); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// GetRestrictionAudit retrieves the audit trail of a restriction, oldest first
+func (r *PostgresRestrictionRepository) GetRestrictionAudit(ctx context.Context, restrictionID uuid.UUID) ([]*models.RestrictionAuditEntry, error) {
+	query := `
+		SELECT id, restriction_id, action, from_status, to_status, actor_id, comment, created_at
+		FROM restriction_audit
+		WHERE restriction_id = $1
+		ORDER BY created_at, seq
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, restrictionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*models.RestrictionAuditEntry{}
+	for rows.Next() {
+		var entry models.RestrictionAuditEntry
+		var actorID uuid.NullUUID
+		err := rows.Scan(
+			&entry.ID,
+			&entry.RestrictionID,
+			&entry.Action,
+			&entry.FromStatus,
+			&entry.ToStatus,
+			&actorID,
+			&entry.Comment,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if actorID.Valid {
+			entry.ActorID = &actorID.UUID
+		}
+		entries = append(entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GetExpiredRestrictions retrieves restrictions that are still marked active
+// or awaiting approval although their expiry is not after now
+func (r *PostgresRestrictionRepository) GetExpiredRestrictions(ctx context.Context, now time.Time) ([]*models.Restriction, error) {
+	query := `
+		SELECT ` + restrictionColumns + `
+		FROM account_restrictions
+		WHERE status IN ($1, $2, $3) AND expires_at <= $4
+		ORDER BY expires_at
+	`
+	return r.queryRestrictions(ctx, query, models.RestrictionPendingApproval, models.RestrictionActive, models.RestrictionLiftPending, now)
+}
+
+func insertRestrictionAudit(ctx context.Context, tx *sql.Tx, entry *models.RestrictionAuditEntry) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO restriction_audit (id, restriction_id, action, from_status, to_status, actor_id, comment, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`,
+		entry.ID,
+		entry.RestrictionID,
+		entry.Action,
+		entry.FromStatus,
+		entry.ToStatus,
+		entry.ActorID,
+		entry.Comment,
+		entry.CreatedAt,
+	)
+	return err
+}
+
+// loadRestrictions reads the restrictions in force on a locked account
+func loadRestrictions(ctx context.Context, tx *sql.Tx, account *models.Account) error {
+	query := `
+		SELECT ` + restrictionColumns + `
+		FROM account_restrictions
+		WHERE account_id = $1 AND status IN ($2, $3) AND expires_at > $4
+	`
+
+	rows, err := tx.QueryContext(ctx, query, account.ID, models.RestrictionActive, models.RestrictionLiftPending, time.Now())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	account.Restrictions = nil
+	for rows.Next() {
+		restriction, err := scanRestriction(rows)
+		if err != nil {
+			return err
+		}
+		account.Restrictions = append(account.Restrictions, restriction)
+	}
+	return rows.Err()
+}
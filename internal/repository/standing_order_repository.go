@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+)
+
+// StandingOrderRepository defines operations for standing orders and their executions
+type StandingOrderRepository interface {
+	CreateStandingOrder(ctx context.Context, order *models.StandingOrder) error
+	GetStandingOrder(ctx context.Context, id uuid.UUID) (*models.StandingOrder, error)
+	GetAccountStandingOrders(ctx context.Context, accountID uuid.UUID) ([]*models.StandingOrder, error)
+	GetDueStandingOrders(ctx context.Context, today, now time.Time) ([]*models.StandingOrder, error)
+	UpdateStandingOrderStatus(ctx context.Context, order *models.StandingOrder, from models.StandingOrderStatus) (bool, error)
+	RecordExecution(ctx context.Context, order *models.StandingOrder, execution *models.StandingOrderExecution, runDate time.Time, attempts int) (bool, error)
+	GetExecutions(ctx context.Context, orderID uuid.UUID) ([]*models.StandingOrderExecution, error)
+}
+
+// PostgresStandingOrderRepository implements StandingOrderRepository for PostgreSQL
+type PostgresStandingOrderRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresStandingOrderRepository creates a new PostgresStandingOrderRepository
+func NewPostgresStandingOrderRepository(db *sql.DB) *PostgresStandingOrderRepository {
+	return &PostgresStandingOrderRepository{
+		db: db,
+	}
+}
+
+// standingOrderColumns lists the columns read by scanStandingOrder, in order
+const standingOrderColumns = `id, account_id, customer_id, to_account_id, to_account_number, amount, description,
+		       frequency, day_of_month, start_date, end_date, next_run_date, failed_attempts, retry_at,
+		       status, created_at, updated_at`
+
+func scanStandingOrder(row rowScanner) (*models.StandingOrder, error) {
+	var order models.StandingOrder
+	var endDate, nextRunDate, retryAt sql.NullTime
+
+	err := row.Scan(
+		&order.ID,
+		&order.AccountID,
+		&order.CustomerID,
+		&order.ToAccountID,
+		&order.ToAccountNumber,
+		&order.Amount,
+		&order.Description,
+		&order.Frequency,
+		&order.DayOfMonth,
+		&order.StartDate,
+		&endDate,
+		&nextRunDate,
+		&order.FailedAttempts,
+		&retryAt,
+		&order.Status,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if endDate.Valid {
+		order.EndDate = &endDate.Time
+	}
+	if nextRunDate.Valid {
+		order.NextRunDate = &nextRunDate.Time
+	}
+	if retryAt.Valid {
+		order.RetryAt = &retryAt.Time
+	}
+	return &order, nil
+}
+
+// CreateStandingOrder inserts a standing order
+func (r *PostgresStandingOrderRepository) CreateStandingOrder(ctx context.Context, order *models.StandingOrder) error {
+	query := `
+		INSERT INTO standing_orders (
+			id, account_id, customer_id, to_account_id, to_account_number, amount, description,
+			frequency, day_of_month, start_date, end_date, next_run_date, failed_attempts, retry_at,
+			status, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		order.ID,
+		order.AccountID,
+		order.CustomerID,
+		order.ToAccountID,
+		order.ToAccountNumber,
+		order.Amount,
+		order.Description,
+		order.Frequency,
+		order.DayOfMonth,
+		order.StartDate,
+		order.EndDate,
+		order.NextRunDate,
+		order.FailedAttempts,
+		order.RetryAt,
+		order.Status,
+		order.CreatedAt,
+		order.UpdatedAt,
+	)
+	return err
+}
+
+// GetStandingOrder retrieves a standing order by ID
+func (r *PostgresStandingOrderRepository) GetStandingOrder(ctx context.Context, id uuid.UUID) (*models.StandingOrder, error) {
+	query := `SELECT ` + standingOrderColumns + ` FROM standing_orders WHERE id = $1`
+
+	order, err := scanStandingOrder(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	return order, nil
+}
+
+// GetAccountStandingOrders retrieves the standing orders of an account, newest first
+func (r *PostgresStandingOrderRepository) GetAccountStandingOrders(ctx context.Context, accountID uuid.UUID) ([]*models.StandingOrder, error) {
+	query := `SELECT ` + standingOrderColumns + ` FROM standing_orders WHERE account_id = $1 ORDER BY created_at DESC`
+	return r.queryStandingOrders(ctx, query, accountID)
+}
+
+// GetDueStandingOrders retrieves the active orders with a run on or before
+// today that are not waiting for a retry after now
+func (r *PostgresStandingOrderRepository) GetDueStandingOrders(ctx context.Context, today, now time.Time) ([]*models.StandingOrder, error) {
+	query := `
+		SELECT ` + standingOrderColumns + `
+		FROM standing_orders
+		WHERE status = 'active' AND next_run_date <= $1 AND (retry_at IS NULL OR retry_at <= $2)
+		ORDER BY next_run_date, created_at
+	`
+	return r.queryStandingOrders(ctx, query, today, now)
+}
+
+func (r *PostgresStandingOrderRepository) queryStandingOrders(ctx context.Context, query string, args ...interface{}) ([]*models.StandingOrder, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []*models.StandingOrder{}
+	for rows.Next() {
+		order, err := scanStandingOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// UpdateStandingOrderStatus saves the status and schedule of an order if it
+// is still in status from, and reports whether it changed
+func (r *PostgresStandingOrderRepository) UpdateStandingOrderStatus(ctx context.Context, order *models.StandingOrder, from models.StandingOrderStatus) (bool, error) {
+	query := `
+		UPDATE standing_orders
+		SET status = $1, next_run_date = $2, failed_attempts = $3, retry_at = $4, updated_at = $5
+		WHERE id = $6 AND status = $7
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		order.Status,
+		order.NextRunDate,
+		order.FailedAttempts,
+		order.RetryAt,
+		order.UpdatedAt,
+		order.ID,
+		from,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
+
+// RecordExecution saves the outcome of an attempt and the order's new
+// schedule in one transaction. The order is only updated while it is still
+// active with the run date and attempt count the scheduler read, so two
+// schedulers cannot record the same attempt; it reports whether it did.
+func (r *PostgresStandingOrderRepository) RecordExecution(ctx context.Context, order *models.StandingOrder, execution *models.StandingOrderExecution, runDate time.Time, attempts int) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE standing_orders
+		SET status = $1, next_run_date = $2, failed_attempts = $3, retry_at = $4, updated_at = $5
+		WHERE id = $6 AND status = 'active' AND next_run_date = $7 AND failed_attempts = $8
+	`,
+		order.Status,
+		order.NextRunDate,
+		order.FailedAttempts,
+		order.RetryAt,
+		order.UpdatedAt,
+		order.ID,
+		runDate,
+		attempts,
+	)
+	if err != nil {
+		return false, err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows != 1 {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO standing_order_executions (id, order_id, run_date, attempt, status, transaction_id, failure_reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`,
+		execution.ID,
+		execution.OrderID,
+		execution.RunDate,
+		execution.Attempt,
+		execution.Status,
+		execution.TransactionID,
+		execution.FailureReason,
+		execution.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// GetExecutions retrieves the attempts of a standing order, newest first
+func (r *PostgresStandingOrderRepository) GetExecutions(ctx context.Context, orderID uuid.UUID) ([]*models.StandingOrderExecution, error) {
+	query := `
+		SELECT id, order_id, run_date, attempt, status, transaction_id, failure_reason, created_at
+		FROM standing_order_executions
+		WHERE order_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	executions := []*models.StandingOrderExecution{}
+	for rows.Next() {
+		var execution models.StandingOrderExecution
+		var transactionID uuid.NullUUID
+		err := rows.Scan(
+			&execution.ID,
+			&execution.OrderID,
+			&execution.RunDate,
+			&execution.Attempt,
+			&execution.Status,
+			&transactionID,
+			&execution.FailureReason,
+			&execution.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if transactionID.Valid {
+			execution.TransactionID = &transactionID.UUID
+		}
+		executions = append(executions, &execution)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return executions, nil
+}
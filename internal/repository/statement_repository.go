@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+)
+
+// StatementRepository defines operations for account statement requests
+type StatementRepository interface {
+	CreateStatement(ctx context.Context, statement *models.Statement) error
+	GetStatement(ctx context.Context, id uuid.UUID) (*models.Statement, error)
+	GetQueuedStatementIDs(ctx context.Context, staleBefore time.Time) ([]uuid.UUID, error)
+	ClaimStatement(ctx context.Context, id uuid.UUID, staleBefore time.Time) (*models.Statement, error)
+	UpdateStatement(ctx context.Context, statement *models.Statement) error
+}
+
+// PostgresStatementRepository implements StatementRepository for PostgreSQL
+type PostgresStatementRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresStatementRepository creates a new PostgresStatementRepository
+func NewPostgresStatementRepository(db *sql.DB) *PostgresStatementRepository {
+	return &PostgresStatementRepository{
+		db: db,
+	}
+}
+
+// statementColumns lists the columns read by scanStatement, in order
+const statementColumns = `id, account_id, customer_id, from_date, to_date, status, opening_balance, closing_balance,
+		       pdf_key, csv_key, failure_reason, requested_at, updated_at, completed_at`
+
+func scanStatement(row rowScanner) (*models.Statement, error) {
+	var statement models.Statement
+	var opening, closing sql.NullFloat64
+	var completedAt sql.NullTime
+
+	err := row.Scan(
+		&statement.ID,
+		&statement.AccountID,
+		&statement.CustomerID,
+		&statement.FromDate,
+		&statement.ToDate,
+		&statement.Status,
+		&opening,
+		&closing,
+		&statement.PDFKey,
+		&statement.CSVKey,
+		&statement.FailureReason,
+		&statement.RequestedAt,
+		&statement.UpdatedAt,
+		&completedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if opening.Valid {
+		statement.OpeningBalance = &opening.Float64
+	}
+	if closing.Valid {
+		statement.ClosingBalance = &closing.Float64
+	}
+	if completedAt.Valid {
+		statement.CompletedAt = &completedAt.Time
+	}
+	return &statement, nil
+}
+
+// CreateStatement inserts a new statement request
+func (r *PostgresStatementRepository) CreateStatement(ctx context.Context, statement *models.Statement) error {
+	query := `
+		INSERT INTO account_statements (
+			id, account_id, customer_id, from_date, to_date, status, requested_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		statement.ID,
+		statement.AccountID,
+		statement.CustomerID,
+		statement.FromDate,
+		statement.ToDate,
+		statement.Status,
+		statement.RequestedAt,
+		statement.UpdatedAt,
+	)
+	return err
+}
+
+// GetStatement retrieves a statement request by ID
+func (r *PostgresStatementRepository) GetStatement(ctx context.Context, id uuid.UUID) (*models.Statement, error) {
+	query := `SELECT ` + statementColumns + ` FROM account_statements WHERE id = $1`
+
+	statement, err := scanStatement(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not found
+		}
+		return nil, err
+	}
+	return statement, nil
+}
+
+// GetQueuedStatementIDs returns the statements waiting to be rendered,
+// including ones whose rendering started before staleBefore and never finished
+func (r *PostgresStatementRepository) GetQueuedStatementIDs(ctx context.Context, staleBefore time.Time) ([]uuid.UUID, error) {
+	query := `
+		SELECT id
+		FROM account_statements
+		WHERE status = $1 OR (status = $2 AND updated_at < $3)
+		ORDER BY requested_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, models.StatementPending, models.StatementProcessing, staleBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// ClaimStatement marks a queued statement as processing and returns it. It
+// returns nil when another worker already claimed or finished the statement.
+func (r *PostgresStatementRepository) ClaimStatement(ctx context.Context, id uuid.UUID, staleBefore time.Time) (*models.Statement, error) {
+	query := `
+		UPDATE account_statements
+		SET status = $1, updated_at = $2
+		WHERE id = $3 AND (status = $4 OR (status = $1 AND updated_at < $5))
+		RETURNING ` + statementColumns
+
+	statement, err := scanStatement(r.db.QueryRowContext(
+		ctx, query, models.StatementProcessing, time.Now(), id, models.StatementPending, staleBefore,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Already claimed
+		}
+		return nil, err
+	}
+	return statement, nil
+}
+
+// UpdateStatement saves the outcome of rendering a statement
+func (r *PostgresStatementRepository) UpdateStatement(ctx context.Context, statement *models.Statement) error {
+	query := `
+		UPDATE account_statements
+		SET status = $1, opening_balance = $2, closing_balance = $3, pdf_key = $4, csv_key = $5,
+		    failure_reason = $6, updated_at = $7, completed_at = $8
+		WHERE id = $9
+	`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		statement.Status,
+		statement.OpeningBalance,
+		statement.ClosingBalance,
+		statement.PDFKey,
+		statement.CSVKey,
+		statement.FailureReason,
+		statement.UpdatedAt,
+		statement.CompletedAt,
+		statement.ID,
+	)
+	return err
+}
@@ -0,0 +1,294 @@
+package restrictions
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidType is returned for an unknown restriction type
+	ErrInvalidType = errors.New("type must be debit_freeze, credit_freeze, full_freeze or garnishment")
+	// ErrInvalidAmount is returned when a garnishment amount is missing or an amount is given for a freeze
+	ErrInvalidAmount = errors.New("garnishments need a positive amount with at most two decimals; freezes take no amount")
+	// ErrReferenceRequired is returned when the order reference number is missing
+	ErrReferenceRequired = errors.New("reference_number is required")
+	// ErrReasonRequired is returned when a request or decision has no reason
+	ErrReasonRequired = errors.New("reason is required")
+	// ErrInvalidExpiry is returned when the restriction would already be expired
+	ErrInvalidExpiry = errors.New("expires_at must be in the future")
+	// ErrAccountNotFound is returned when the account to restrict does not exist
+	ErrAccountNotFound = errors.New("account not found")
+	// ErrNotCustomerAccount is returned when the account is an internal ledger account or closed
+	ErrNotCustomerAccount = errors.New("only open customer accounts can be restricted")
+	// ErrNotFound is returned when a restriction does not exist
+	ErrNotFound = errors.New("restriction not found")
+	// ErrNothingToApprove is returned when the restriction has no change awaiting a decision
+	ErrNothingToApprove = errors.New("restriction has no change awaiting approval")
+	// ErrNotLiftable is returned when a lift is requested for a restriction that is not active
+	ErrNotLiftable = errors.New("only active restrictions can be lifted")
+	// ErrSameStaff is returned when the checker is the staff member who made the change
+	ErrSameStaff = errors.New("a change must be approved by a different staff member")
+	// ErrConflict is returned when the restriction was changed by someone else at the same time
+	ErrConflict = errors.New("restriction was changed by another request; reload and retry")
+)
+
+// Audit actions recorded for restriction changes
+const (
+	ActionRequested    = "requested"
+	ActionApproved     = "approved"
+	ActionRejected     = "rejected"
+	ActionLiftRequest  = "lift_requested"
+	ActionLiftApproved = "lift_approved"
+	ActionLiftRejected = "lift_rejected"
+	ActionExpired      = "expired"
+)
+
+// Service applies and lifts account restrictions. Every change is made by
+// one staff member and takes effect only once a second one approves it.
+type Service struct {
+	repo        repository.RestrictionRepository
+	accountRepo repository.AccountRepository
+	now         func() time.Time
+}
+
+// NewService creates a new restriction Service
+func NewService(repo repository.RestrictionRepository, accountRepo repository.AccountRepository) *Service {
+	return &Service{
+		repo:        repo,
+		accountRepo: accountRepo,
+		now:         time.Now,
+	}
+}
+
+// Request records a restriction on the account awaiting approval
+func (s *Service) Request(ctx context.Context, accountID uuid.UUID, req models.RestrictionRequest, staffID uuid.UUID) (*models.Restriction, error) {
+	now := s.now()
+	if err := validateRequest(req, now); err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepo.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+	if account.IsInternal() || account.Status == models.AccountStatusClosed {
+		return nil, ErrNotCustomerAccount
+	}
+
+	restriction := &models.Restriction{
+		ID:              uuid.New(),
+		AccountID:       accountID,
+		Type:            req.Type,
+		Amount:          req.Amount,
+		ReferenceNumber: strings.TrimSpace(req.ReferenceNumber),
+		Reason:          strings.TrimSpace(req.Reason),
+		ExpiresAt:       req.ExpiresAt,
+		Status:          models.RestrictionPendingApproval,
+		RequestedBy:     staffID,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	entry := auditEntry(restriction, ActionRequested, "", &staffID, restriction.Reason, now)
+	if err := s.repo.CreateRestriction(ctx, restriction, entry); err != nil {
+		return nil, err
+	}
+	return restriction, nil
+}
+
+func validateRequest(req models.RestrictionRequest, now time.Time) error {
+	if !req.Type.IsValid() {
+		return ErrInvalidType
+	}
+	if req.Type == models.RestrictionGarnishment {
+		if req.Amount <= 0 || math.Abs(req.Amount*100-math.Round(req.Amount*100)) > 0.000001 {
+			return ErrInvalidAmount
+		}
+	} else if req.Amount != 0 {
+		return ErrInvalidAmount
+	}
+	if strings.TrimSpace(req.ReferenceNumber) == "" {
+		return ErrReferenceRequired
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return ErrReasonRequired
+	}
+	if !req.ExpiresAt.After(now) {
+		return ErrInvalidExpiry
+	}
+	return nil
+}
+
+// RequestLift asks for an active restriction to be removed. The restriction
+// stays in force until the lift is approved.
+func (s *Service) RequestLift(ctx context.Context, id uuid.UUID, staffID uuid.UUID, reason string) (*models.Restriction, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrReasonRequired
+	}
+
+	restriction, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if restriction.Status != models.RestrictionActive || !restriction.InForce(s.now()) {
+		return nil, ErrNotLiftable
+	}
+
+	restriction.LiftRequestedBy = &staffID
+	restriction.LiftReason = reason
+	return s.transition(ctx, restriction, models.RestrictionLiftPending, ActionLiftRequest, &staffID, reason)
+}
+
+// Approve puts the pending change of a restriction into effect: a requested
+// restriction becomes active and a requested lift removes it. The checker
+// must not be the staff member who made the change.
+func (s *Service) Approve(ctx context.Context, id uuid.UUID, checkerID uuid.UUID, comment string) (*models.Restriction, error) {
+	restriction, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch restriction.Status {
+	case models.RestrictionPendingApproval:
+		if restriction.RequestedBy == checkerID {
+			return nil, ErrSameStaff
+		}
+		if !restriction.ExpiresAt.After(s.now()) {
+			return nil, ErrInvalidExpiry
+		}
+		restriction.ApprovedBy = &checkerID
+		return s.transition(ctx, restriction, models.RestrictionActive, ActionApproved, &checkerID, comment)
+	case models.RestrictionLiftPending:
+		if restriction.LiftRequestedBy != nil && *restriction.LiftRequestedBy == checkerID {
+			return nil, ErrSameStaff
+		}
+		return s.transition(ctx, restriction, models.RestrictionLifted, ActionLiftApproved, &checkerID, comment)
+	}
+	return nil, ErrNothingToApprove
+}
+
+// Reject turns down the pending change of a restriction: a requested
+// restriction is never applied and a requested lift leaves it active
+func (s *Service) Reject(ctx context.Context, id uuid.UUID, checkerID uuid.UUID, reason string) (*models.Restriction, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrReasonRequired
+	}
+
+	restriction, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch restriction.Status {
+	case models.RestrictionPendingApproval:
+		if restriction.RequestedBy == checkerID {
+			return nil, ErrSameStaff
+		}
+		return s.transition(ctx, restriction, models.RestrictionRejected, ActionRejected, &checkerID, reason)
+	case models.RestrictionLiftPending:
+		if restriction.LiftRequestedBy != nil && *restriction.LiftRequestedBy == checkerID {
+			return nil, ErrSameStaff
+		}
+		restriction.LiftRequestedBy = nil
+		restriction.LiftReason = ""
+		return s.transition(ctx, restriction, models.RestrictionActive, ActionLiftRejected, &checkerID, reason)
+	}
+	return nil, ErrNothingToApprove
+}
+
+// ExpireDue marks restrictions whose expiry is not after now as expired and
+// returns how many were changed. Postings already ignore them; this keeps
+// the status and the audit trail accurate.
+func (s *Service) ExpireDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.repo.GetExpiredRestrictions(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, restriction := range due {
+		from := restriction.Status
+		restriction.Status = models.RestrictionExpired
+		restriction.UpdatedAt = now
+		entry := auditEntry(restriction, ActionExpired, from, nil, "", now)
+		ok, err := s.repo.UpdateRestrictionStatus(ctx, restriction, from, entry)
+		if err != nil {
+			return expired, err
+		}
+		if ok {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// InForce returns the restrictions on the account that currently apply to postings
+func (s *Service) InForce(ctx context.Context, accountID uuid.UUID) ([]*models.Restriction, error) {
+	all, err := s.repo.GetAccountRestrictions(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	inForce := []*models.Restriction{}
+	for _, restriction := range all {
+		if restriction.InForce(now) {
+			inForce = append(inForce, restriction)
+		}
+	}
+	return inForce, nil
+}
+
+func (s *Service) get(ctx context.Context, id uuid.UUID) (*models.Restriction, error) {
+	restriction, err := s.repo.GetRestriction(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if restriction == nil {
+		return nil, ErrNotFound
+	}
+	return restriction, nil
+}
+
+// transition moves the restriction to status and records the audit entry,
+// failing with ErrConflict if the restriction changed since it was read
+func (s *Service) transition(ctx context.Context, restriction *models.Restriction, status models.RestrictionStatus, action string, actorID *uuid.UUID, comment string) (*models.Restriction, error) {
+	now := s.now()
+	from := restriction.Status
+	restriction.Status = status
+	restriction.UpdatedAt = now
+
+	entry := auditEntry(restriction, action, from, actorID, strings.TrimSpace(comment), now)
+	ok, err := s.repo.UpdateRestrictionStatus(ctx, restriction, from, entry)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrConflict
+	}
+	return restriction, nil
+}
+
+func auditEntry(restriction *models.Restriction, action string, from models.RestrictionStatus, actorID *uuid.UUID, comment string, now time.Time) *models.RestrictionAuditEntry {
+	return &models.RestrictionAuditEntry{
+		ID:            uuid.New(),
+		RestrictionID: restriction.ID,
+		Action:        action,
+		FromStatus:    from,
+		ToStatus:      restriction.Status,
+		ActorID:       actorID,
+		Comment:       comment,
+		CreatedAt:     now,
+	}
+}
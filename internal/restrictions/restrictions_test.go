@@ -0,0 +1,152 @@
+package restrictions
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubAccountRepository returns a fixed account
+type stubAccountRepository struct {
+	repository.AccountRepository
+	account *models.Account
+}
+
+func (r *stubAccountRepository) GetAccountByID(ctx context.Context, id uuid.UUID) (*models.Account, error) {
+	return r.account, nil
+}
+
+// stubRestrictionRepository keeps restrictions and audit entries in memory
+type stubRestrictionRepository struct {
+	repository.RestrictionRepository
+	restrictions map[uuid.UUID]models.Restriction
+	audit        []*models.RestrictionAuditEntry
+}
+
+func (r *stubRestrictionRepository) CreateRestriction(ctx context.Context, restriction *models.Restriction, entry *models.RestrictionAuditEntry) error {
+	r.restrictions[restriction.ID] = *restriction
+	r.audit = append(r.audit, entry)
+	return nil
+}
+
+func (r *stubRestrictionRepository) GetRestriction(ctx context.Context, id uuid.UUID) (*models.Restriction, error) {
+	restriction, ok := r.restrictions[id]
+	if !ok {
+		return nil, nil
+	}
+	return &restriction, nil
+}
+
+func (r *stubRestrictionRepository) UpdateRestrictionStatus(ctx context.Context, restriction *models.Restriction, from models.RestrictionStatus, entry *models.RestrictionAuditEntry) (bool, error) {
+	if r.restrictions[restriction.ID].Status != from {
+		return false, nil
+	}
+	r.restrictions[restriction.ID] = *restriction
+	r.audit = append(r.audit, entry)
+	return true, nil
+}
+
+func TestRestrictionMakerChecker(t *testing.T) {
+	account := &models.Account{ID: uuid.New(), AccountType: models.AccountTypeSavings, Status: models.AccountStatusActive}
+	repo := &stubRestrictionRepository{restrictions: map[uuid.UUID]models.Restriction{}}
+	service := NewService(repo, &stubAccountRepository{account: account})
+	now := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	ctx := context.Background()
+	maker, checker := uuid.New(), uuid.New()
+
+	request := models.RestrictionRequest{
+		Type:            models.RestrictionGarnishment,
+		Amount:          5000,
+		ReferenceNumber: "CIV-123/2569",
+		Reason:          "Court garnishment order",
+		ExpiresAt:       now.AddDate(1, 0, 0),
+	}
+	restriction, err := service.Request(ctx, account.ID, request, maker)
+	require.NoError(t, err)
+	assert.Equal(t, models.RestrictionPendingApproval, restriction.Status)
+	assert.False(t, restriction.InForce(now))
+
+	// The maker cannot approve their own change
+	_, err = service.Approve(ctx, restriction.ID, maker, "")
+	assert.ErrorIs(t, err, ErrSameStaff)
+
+	restriction, err = service.Approve(ctx, restriction.ID, checker, "Order verified")
+	require.NoError(t, err)
+	assert.Equal(t, models.RestrictionActive, restriction.Status)
+	assert.True(t, restriction.InForce(now))
+
+	// A lift stays pending, and the restriction in force, until someone else approves it
+	restriction, err = service.RequestLift(ctx, restriction.ID, checker, "Order satisfied")
+	require.NoError(t, err)
+	assert.Equal(t, models.RestrictionLiftPending, restriction.Status)
+	assert.True(t, restriction.InForce(now))
+	_, err = service.Approve(ctx, restriction.ID, checker, "")
+	assert.ErrorIs(t, err, ErrSameStaff)
+
+	restriction, err = service.Reject(ctx, restriction.ID, maker, "Payment not received yet")
+	require.NoError(t, err)
+	assert.Equal(t, models.RestrictionActive, restriction.Status)
+	assert.Nil(t, restriction.LiftRequestedBy)
+
+	_, err = service.RequestLift(ctx, restriction.ID, checker, "Order satisfied")
+	require.NoError(t, err)
+	restriction, err = service.Approve(ctx, restriction.ID, maker, "")
+	require.NoError(t, err)
+	assert.Equal(t, models.RestrictionLifted, restriction.Status)
+
+	_, err = service.Approve(ctx, restriction.ID, checker, "")
+	assert.ErrorIs(t, err, ErrNothingToApprove)
+
+	actions := []string{}
+	for _, entry := range repo.audit {
+		actions = append(actions, entry.Action)
+	}
+	assert.Equal(t, []string{
+		ActionRequested, ActionApproved, ActionLiftRequest, ActionLiftRejected, ActionLiftRequest, ActionLiftApproved,
+	}, actions)
+}
+
+func TestRestrictionRequestValidation(t *testing.T) {
+	account := &models.Account{ID: uuid.New(), AccountType: models.AccountTypeSavings, Status: models.AccountStatusActive}
+	service := NewService(&stubRestrictionRepository{restrictions: map[uuid.UUID]models.Restriction{}}, &stubAccountRepository{account: account})
+	now := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	valid := models.RestrictionRequest{
+		Type:            models.RestrictionDebitFreeze,
+		ReferenceNumber: "AMLO-77",
+		Reason:          "Freeze order",
+		ExpiresAt:       now.Add(24 * time.Hour),
+	}
+	tests := []struct {
+		name   string
+		modify func(r *models.RestrictionRequest)
+		err    error
+	}{
+		{"unknown type", func(r *models.RestrictionRequest) { r.Type = "partial" }, ErrInvalidType},
+		{"freeze with amount", func(r *models.RestrictionRequest) { r.Amount = 10 }, ErrInvalidAmount},
+		{"garnishment without amount", func(r *models.RestrictionRequest) { r.Type = models.RestrictionGarnishment }, ErrInvalidAmount},
+		{"missing reference", func(r *models.RestrictionRequest) { r.ReferenceNumber = " " }, ErrReferenceRequired},
+		{"missing reason", func(r *models.RestrictionRequest) { r.Reason = "" }, ErrReasonRequired},
+		{"past expiry", func(r *models.RestrictionRequest) { r.ExpiresAt = now }, ErrInvalidExpiry},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := valid
+			tt.modify(&request)
+			_, err := service.Request(context.Background(), account.ID, request, uuid.New())
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	account.Status = models.AccountStatusClosed
+	_, err := service.Request(context.Background(), account.ID, valid, uuid.New())
+	assert.ErrorIs(t, err, ErrNotCustomerAccount)
+}
@@ -0,0 +1,417 @@
+package standingorders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/transfers"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidFrequency is returned for an unknown frequency
+	ErrInvalidFrequency = errors.New("frequency must be once, daily, weekly, monthly or end_of_month")
+	// ErrInvalidDayOfMonth is returned when a monthly order has no day of the month between 1 and 31
+	ErrInvalidDayOfMonth = errors.New("day_of_month must be between 1 and 31 for monthly orders")
+	// ErrInvalidDate is returned for a date that is not in YYYY-MM-DD format
+	ErrInvalidDate = errors.New("dates must be in YYYY-MM-DD format")
+	// ErrStartInPast is returned when the first run would be before today
+	ErrStartInPast = errors.New("start date cannot be in the past")
+	// ErrNoRuns is returned when the end date is before the first run
+	ErrNoRuns = errors.New("the order has no run before its end date")
+	// ErrOrderNotFound is returned when the order does not exist for the account
+	ErrOrderNotFound = errors.New("standing order not found")
+	// ErrNotActive is returned when pausing an order that is not active
+	ErrNotActive = errors.New("standing order is not active")
+	// ErrNotPaused is returned when resuming an order that is not paused
+	ErrNotPaused = errors.New("standing order is not paused")
+	// ErrFinished is returned when cancelling an order that is already cancelled or completed
+	ErrFinished = errors.New("standing order is already cancelled or completed")
+	// ErrAccountNotFound is returned when the account of an order no longer exists
+	ErrAccountNotFound = errors.New("account not found")
+)
+
+// Transferrer finds destination accounts and posts transfers between them
+type Transferrer interface {
+	FindDestination(ctx context.Context, accountNumber string) (*models.Account, error)
+	Transfer(ctx context.Context, input transfers.Input) (*models.TransferResult, error)
+}
+
+// Service schedules future-dated and recurring transfers and runs them when
+// they fall due. Every run posts with a reference made of the order and its
+// run date, so a run is never debited twice, even across scheduler restarts.
+type Service struct {
+	repo          repository.StandingOrderRepository
+	accountRepo   repository.AccountRepository
+	notifications repository.NotificationRepository
+	transfers     Transferrer
+	cfg           config.StandingOrderConfig
+	now           func() time.Time
+}
+
+// NewService creates a new standing order Service
+func NewService(repo repository.StandingOrderRepository, accountRepo repository.AccountRepository, notifications repository.NotificationRepository, transferrer Transferrer, cfg config.StandingOrderConfig) *Service {
+	return &Service{
+		repo:          repo,
+		accountRepo:   accountRepo,
+		notifications: notifications,
+		transfers:     transferrer,
+		cfg:           cfg,
+		now:           time.Now,
+	}
+}
+
+// Create schedules transfers from the account on behalf of customerID
+func (s *Service) Create(ctx context.Context, account *models.Account, customerID uuid.UUID, req models.StandingOrderRequest) (*models.StandingOrder, error) {
+	if !req.Frequency.IsValid() {
+		return nil, ErrInvalidFrequency
+	}
+	dayOfMonth := 0
+	if req.Frequency == models.FrequencyMonthly {
+		if req.DayOfMonth < 1 || req.DayOfMonth > 31 {
+			return nil, ErrInvalidDayOfMonth
+		}
+		dayOfMonth = req.DayOfMonth
+	}
+
+	start, err := time.Parse("2006-01-02", strings.TrimSpace(req.StartDate))
+	if err != nil {
+		return nil, ErrInvalidDate
+	}
+	var end *time.Time
+	if strings.TrimSpace(req.EndDate) != "" {
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(req.EndDate))
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		end = &date
+	}
+
+	now := s.now()
+	if start.Before(today(now)) {
+		return nil, ErrStartInPast
+	}
+	first := FirstRunDate(req.Frequency, dayOfMonth, start)
+	if end != nil && first.After(*end) {
+		return nil, ErrNoRuns
+	}
+
+	destination, err := s.transfers.FindDestination(ctx, req.ToAccountNumber)
+	if err != nil {
+		return nil, err
+	}
+	if err := transfers.Validate(transfers.Input{From: account, To: destination, Amount: req.Amount}); err != nil {
+		return nil, err
+	}
+
+	order := &models.StandingOrder{
+		ID:              uuid.New(),
+		AccountID:       account.ID,
+		CustomerID:      customerID,
+		ToAccountID:     destination.ID,
+		ToAccountNumber: destination.AccountNumber,
+		Amount:          req.Amount,
+		Description:     strings.TrimSpace(req.Description),
+		Frequency:       req.Frequency,
+		DayOfMonth:      dayOfMonth,
+		StartDate:       start,
+		EndDate:         end,
+		NextRunDate:     &first,
+		Status:          models.StandingOrderActive,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := s.repo.CreateStandingOrder(ctx, order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// List returns the standing orders of an account
+func (s *Service) List(ctx context.Context, accountID uuid.UUID) ([]*models.StandingOrder, error) {
+	return s.repo.GetAccountStandingOrders(ctx, accountID)
+}
+
+// Get returns a standing order of the account with its executions
+func (s *Service) Get(ctx context.Context, accountID, orderID uuid.UUID) (*models.StandingOrderDetails, error) {
+	order, err := s.order(ctx, accountID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	executions, err := s.repo.GetExecutions(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.StandingOrderDetails{StandingOrder: *order, Executions: executions}, nil
+}
+
+// Pause stops an active order from running until it is resumed
+func (s *Service) Pause(ctx context.Context, accountID, orderID uuid.UUID) (*models.StandingOrder, error) {
+	order, err := s.order(ctx, accountID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.StandingOrderActive {
+		return nil, ErrNotActive
+	}
+
+	order.Status = models.StandingOrderPaused
+	order.FailedAttempts = 0
+	order.RetryAt = nil
+	return s.save(ctx, order, models.StandingOrderActive, ErrNotActive)
+}
+
+// Resume makes a paused order run again. Runs that fell due while it was
+// paused are skipped.
+func (s *Service) Resume(ctx context.Context, accountID, orderID uuid.UUID) (*models.StandingOrder, error) {
+	order, err := s.order(ctx, accountID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.StandingOrderPaused {
+		return nil, ErrNotPaused
+	}
+
+	order.Status = models.StandingOrderActive
+	date := today(s.now())
+	for order.NextRunDate != nil && order.NextRunDate.Before(date) {
+		order.NextRunDate = NextRunDate(order, *order.NextRunDate)
+	}
+	if order.NextRunDate == nil {
+		order.Status = models.StandingOrderCompleted
+	}
+	return s.save(ctx, order, models.StandingOrderPaused, ErrNotPaused)
+}
+
+// Cancel stops an order for good
+func (s *Service) Cancel(ctx context.Context, accountID, orderID uuid.UUID) (*models.StandingOrder, error) {
+	order, err := s.order(ctx, accountID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	from := order.Status
+	if from != models.StandingOrderActive && from != models.StandingOrderPaused {
+		return nil, ErrFinished
+	}
+
+	order.Status = models.StandingOrderCancelled
+	order.NextRunDate = nil
+	order.RetryAt = nil
+	return s.save(ctx, order, from, ErrFinished)
+}
+
+// RunDue runs every active order due at now and returns how many attempts
+// were recorded. A run that fails for insufficient funds is retried up to
+// MaxAttempts times; any other failure skips the run. The customer is
+// notified of every failure. An order that cannot be run is logged and
+// left for the next tick; the other orders still run, and the errors are
+// returned together.
+func (s *Service) RunDue(ctx context.Context, now time.Time) (int, error) {
+	orders, err := s.repo.GetDueStandingOrders(ctx, today(now), now)
+	if err != nil {
+		return 0, err
+	}
+
+	recorded := 0
+	var errs []error
+	for _, order := range orders {
+		ok, err := s.run(ctx, order, now)
+		if err != nil {
+			log.Printf("failed to run standing order %s: %v", order.ID, err)
+			errs = append(errs, fmt.Errorf("standing order %s: %w", order.ID, err))
+			continue
+		}
+		if ok {
+			recorded++
+		}
+	}
+	return recorded, errors.Join(errs...)
+}
+
+func (s *Service) run(ctx context.Context, order *models.StandingOrder, now time.Time) (bool, error) {
+	runDate, attempts := *order.NextRunDate, order.FailedAttempts
+	result, err := s.transfer(ctx, order, runDate)
+	if err != nil && !transfers.IsRefused(err) && !errors.Is(err, ErrAccountNotFound) {
+		// The run is tried again on the next tick
+		return false, err
+	}
+
+	execution := &models.StandingOrderExecution{
+		ID:        uuid.New(),
+		OrderID:   order.ID,
+		RunDate:   runDate,
+		Attempt:   attempts + 1,
+		CreatedAt: now,
+	}
+	retry := errors.Is(err, ledger.ErrInsufficientFunds) && attempts+1 < s.cfg.MaxAttempts
+	switch {
+	case err == nil || errors.Is(err, transfers.ErrAlreadyProcessed):
+		// An already processed reference was posted before a restart
+		execution.Status = models.ExecutionSucceeded
+		if result != nil {
+			execution.TransactionID = &result.TransactionID
+		}
+	case retry:
+		execution.Status = models.ExecutionRetrying
+		execution.FailureReason = err.Error()
+		retryAt := now.Add(time.Duration(s.cfg.RetryIntervalMinutes) * time.Minute)
+		order.FailedAttempts = attempts + 1
+		order.RetryAt = &retryAt
+	default:
+		execution.Status = models.ExecutionFailed
+		execution.FailureReason = err.Error()
+	}
+	if !retry {
+		order.FailedAttempts = 0
+		order.RetryAt = nil
+		order.NextRunDate = NextRunDate(order, runDate)
+		if order.NextRunDate == nil {
+			order.Status = models.StandingOrderCompleted
+		}
+	}
+	order.UpdatedAt = now
+
+	ok, saveErr := s.repo.RecordExecution(ctx, order, execution, runDate, attempts)
+	if saveErr != nil || !ok {
+		// Not recorded means another scheduler recorded this attempt first
+		return false, saveErr
+	}
+
+	switch execution.Status {
+	case models.ExecutionRetrying:
+		s.notify(ctx, order, models.NotificationStandingOrderRetrying, "Standing order will be retried",
+			fmt.Sprintf("Your standing order of %.2f to %s due on %s could not be paid: %s. We will try again after %s.",
+				order.Amount, order.ToAccountNumber, runDate.Format("2006-01-02"), err, order.RetryAt.Format("2006-01-02 15:04")))
+	case models.ExecutionFailed:
+		s.notify(ctx, order, models.NotificationStandingOrderFailed, "Standing order not paid",
+			fmt.Sprintf("Your standing order of %.2f to %s due on %s was not paid: %s.",
+				order.Amount, order.ToAccountNumber, runDate.Format("2006-01-02"), err))
+	}
+	return true, nil
+}
+
+// transfer posts one run of an order. The reference makes the run idempotent.
+func (s *Service) transfer(ctx context.Context, order *models.StandingOrder, runDate time.Time) (*models.TransferResult, error) {
+	from, err := s.accountRepo.GetAccountByID(ctx, order.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if from == nil {
+		return nil, ErrAccountNotFound
+	}
+	to, err := s.accountRepo.GetAccountByID(ctx, order.ToAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if to == nil {
+		return nil, transfers.ErrDestinationNotFound
+	}
+
+	description := order.Description
+	if description == "" {
+		description = fmt.Sprintf("Standing order to %s", order.ToAccountNumber)
+	}
+	return s.transfers.Transfer(ctx, transfers.Input{
+		From:        from,
+		To:          to,
+		Amount:      order.Amount,
+		Description: description,
+		Reference:   fmt.Sprintf("standing-order:%s:%s", order.ID, runDate.Format("2006-01-02")),
+		CustomerID:  order.CustomerID,
+		Channel:     models.ChannelMobile,
+	})
+}
+
+func (s *Service) notify(ctx context.Context, order *models.StandingOrder, notificationType models.NotificationType, title, message string) {
+	err := s.notifications.CreateNotification(ctx, &models.Notification{
+		ID:         uuid.New(),
+		CustomerID: order.CustomerID,
+		Type:       notificationType,
+		Title:      title,
+		Message:    message,
+		CreatedAt:  s.now(),
+	})
+	if err != nil {
+		log.Printf("failed to notify customer %s about standing order %s: %v", order.CustomerID, order.ID, err)
+	}
+}
+
+func (s *Service) order(ctx context.Context, accountID, orderID uuid.UUID) (*models.StandingOrder, error) {
+	order, err := s.repo.GetStandingOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil || order.AccountID != accountID {
+		return nil, ErrOrderNotFound
+	}
+	return order, nil
+}
+
+func (s *Service) save(ctx context.Context, order *models.StandingOrder, from models.StandingOrderStatus, conflict error) (*models.StandingOrder, error) {
+	order.UpdatedAt = s.now()
+	updated, err := s.repo.UpdateStandingOrderStatus(ctx, order, from)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, conflict
+	}
+	return order, nil
+}
+
+// FirstRunDate returns the first run date of a schedule starting on start
+func FirstRunDate(frequency models.StandingOrderFrequency, dayOfMonth int, start time.Time) time.Time {
+	switch frequency {
+	case models.FrequencyMonthly:
+		date := dayInMonth(start.Year(), start.Month(), dayOfMonth)
+		if date.Before(start) {
+			date = dayInMonth(start.Year(), start.Month()+1, dayOfMonth)
+		}
+		return date
+	case models.FrequencyEndOfMonth:
+		return dayInMonth(start.Year(), start.Month(), 31)
+	}
+	return start
+}
+
+// NextRunDate returns the run date of an order after runDate, or nil when
+// the order is one-off or the next run would be after its end date
+func NextRunDate(order *models.StandingOrder, runDate time.Time) *time.Time {
+	var next time.Time
+	switch order.Frequency {
+	case models.FrequencyDaily:
+		next = runDate.AddDate(0, 0, 1)
+	case models.FrequencyWeekly:
+		next = runDate.AddDate(0, 0, 7)
+	case models.FrequencyMonthly:
+		next = dayInMonth(runDate.Year(), runDate.Month()+1, order.DayOfMonth)
+	case models.FrequencyEndOfMonth:
+		next = dayInMonth(runDate.Year(), runDate.Month()+1, 31)
+	default:
+		return nil
+	}
+	if order.EndDate != nil && next.After(*order.EndDate) {
+		return nil
+	}
+	return &next
+}
+
+// dayInMonth returns day of the month, or the last day of a shorter month
+func dayInMonth(year int, month time.Month, day int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(day, last)-1)
+}
+
+// today returns the calendar date of now, as DATE columns are read back
+func today(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
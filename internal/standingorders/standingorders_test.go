@@ -0,0 +1,214 @@
+package standingorders
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/repository/repotest"
+	"example.com/m/internal/transfers"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubOrderRepository keeps standing orders and executions in memory
+type stubOrderRepository struct {
+	repository.StandingOrderRepository
+	orders     map[uuid.UUID]models.StandingOrder
+	executions []*models.StandingOrderExecution
+}
+
+func (r *stubOrderRepository) GetDueStandingOrders(ctx context.Context, today, now time.Time) ([]*models.StandingOrder, error) {
+	var due []*models.StandingOrder
+	for _, order := range r.orders {
+		if order.Status != models.StandingOrderActive || order.NextRunDate.After(today) {
+			continue
+		}
+		if order.RetryAt != nil && order.RetryAt.After(now) {
+			continue
+		}
+		order := order
+		due = append(due, &order)
+	}
+	return due, nil
+}
+
+func (r *stubOrderRepository) RecordExecution(ctx context.Context, order *models.StandingOrder, execution *models.StandingOrderExecution, runDate time.Time, attempts int) (bool, error) {
+	stored := r.orders[order.ID]
+	if stored.Status != models.StandingOrderActive || !stored.NextRunDate.Equal(runDate) || stored.FailedAttempts != attempts {
+		return false, nil
+	}
+	r.orders[order.ID] = *order
+	r.executions = append(r.executions, execution)
+	return true, nil
+}
+
+// stubTransferrer fails with err, or with failFrom for transfers from that
+// account, and records the references it is asked to post
+type stubTransferrer struct {
+	err        error
+	failFrom   map[uuid.UUID]error
+	references []string
+}
+
+func (t *stubTransferrer) FindDestination(ctx context.Context, accountNumber string) (*models.Account, error) {
+	return nil, transfers.ErrDestinationNotFound
+}
+
+func (t *stubTransferrer) Transfer(ctx context.Context, input transfers.Input) (*models.TransferResult, error) {
+	t.references = append(t.references, input.Reference)
+	if err := t.failFrom[input.From.ID]; err != nil {
+		return nil, err
+	}
+	if t.err != nil {
+		return nil, t.err
+	}
+	return &models.TransferResult{TransactionID: uuid.New(), Amount: input.Amount}, nil
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func newTestService(order models.StandingOrder, transferrer *stubTransferrer) (*Service, *stubOrderRepository, *repotest.Notifications) {
+	from := &models.Account{ID: order.AccountID, Status: models.AccountStatusActive}
+	to := &models.Account{ID: order.ToAccountID, Status: models.AccountStatusActive}
+	repo := &stubOrderRepository{orders: map[uuid.UUID]models.StandingOrder{order.ID: order}}
+	accountRepo := &repotest.Accounts{Accounts: []*models.Account{from, to}}
+	notifications := &repotest.Notifications{}
+	cfg := config.StandingOrderConfig{MaxAttempts: 3, RetryIntervalMinutes: 60}
+	return NewService(repo, accountRepo, notifications, transferrer, cfg), repo, notifications
+}
+
+func newOrder(frequency models.StandingOrderFrequency, next time.Time) models.StandingOrder {
+	return models.StandingOrder{
+		ID:              uuid.New(),
+		AccountID:       uuid.New(),
+		CustomerID:      uuid.New(),
+		ToAccountID:     uuid.New(),
+		ToAccountNumber: "100-1-00002-1",
+		Amount:          500,
+		Frequency:       frequency,
+		DayOfMonth:      31,
+		StartDate:       next,
+		NextRunDate:     &next,
+		Status:          models.StandingOrderActive,
+	}
+}
+
+func TestRunDates(t *testing.T) {
+	assert.Equal(t, date(2024, 1, 31), FirstRunDate(models.FrequencyMonthly, 31, date(2024, 1, 15)))
+	assert.Equal(t, date(2024, 2, 10), FirstRunDate(models.FrequencyMonthly, 10, date(2024, 1, 15)))
+	assert.Equal(t, date(2024, 2, 29), FirstRunDate(models.FrequencyEndOfMonth, 0, date(2024, 2, 1)))
+	assert.Equal(t, date(2024, 1, 15), FirstRunDate(models.FrequencyWeekly, 0, date(2024, 1, 15)))
+
+	monthly := &models.StandingOrder{Frequency: models.FrequencyMonthly, DayOfMonth: 31}
+	assert.Equal(t, date(2024, 2, 29), *NextRunDate(monthly, date(2024, 1, 31)))
+	// A short month does not move later runs off the chosen day
+	assert.Equal(t, date(2024, 3, 31), *NextRunDate(monthly, date(2024, 2, 29)))
+
+	weekly := &models.StandingOrder{Frequency: models.FrequencyWeekly}
+	assert.Equal(t, date(2024, 1, 22), *NextRunDate(weekly, date(2024, 1, 15)))
+
+	end := date(2024, 1, 20)
+	weekly.EndDate = &end
+	assert.Nil(t, NextRunDate(weekly, date(2024, 1, 15)))
+	assert.Nil(t, NextRunDate(&models.StandingOrder{Frequency: models.FrequencyOnce}, date(2024, 1, 15)))
+}
+
+func TestRunDueRetriesInsufficientFundsThenFails(t *testing.T) {
+	order := newOrder(models.FrequencyMonthly, date(2024, 1, 31))
+	transferrer := &stubTransferrer{err: ledger.ErrInsufficientFunds}
+	service, repo, notifications := newTestService(order, transferrer)
+
+	now := time.Date(2024, 1, 31, 8, 0, 0, 0, time.UTC)
+	for attempt := 1; attempt <= 3; attempt++ {
+		recorded, err := service.RunDue(context.Background(), now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, recorded)
+
+		// Not due again until the retry interval has passed
+		recorded, err = service.RunDue(context.Background(), now.Add(30*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, 0, recorded)
+		now = now.Add(time.Hour)
+	}
+
+	require.Len(t, repo.executions, 3)
+	assert.Equal(t, models.ExecutionRetrying, repo.executions[0].Status)
+	assert.Equal(t, models.ExecutionRetrying, repo.executions[1].Status)
+	assert.Equal(t, models.ExecutionFailed, repo.executions[2].Status)
+	assert.Equal(t, 3, repo.executions[2].Attempt)
+
+	// Every attempt of a run posts with the same reference
+	assert.Equal(t, transferrer.references[0], transferrer.references[2])
+
+	stored := repo.orders[order.ID]
+	assert.Equal(t, models.StandingOrderActive, stored.Status)
+	assert.Equal(t, date(2024, 2, 29), *stored.NextRunDate)
+	assert.Zero(t, stored.FailedAttempts)
+	assert.Nil(t, stored.RetryAt)
+
+	require.Len(t, notifications.Notifications, 3)
+	assert.Equal(t, models.NotificationStandingOrderRetrying, notifications.Notifications[0].Type)
+	assert.Equal(t, models.NotificationStandingOrderFailed, notifications.Notifications[2].Type)
+	assert.Equal(t, order.CustomerID, notifications.Notifications[2].CustomerID)
+}
+
+func TestRunDueCompletesOneOffOrder(t *testing.T) {
+	order := newOrder(models.FrequencyOnce, date(2024, 1, 15))
+	service, repo, notifications := newTestService(order, &stubTransferrer{})
+
+	recorded, err := service.RunDue(context.Background(), time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 1, recorded)
+
+	stored := repo.orders[order.ID]
+	assert.Equal(t, models.StandingOrderCompleted, stored.Status)
+	assert.Nil(t, stored.NextRunDate)
+	require.Len(t, repo.executions, 1)
+	assert.Equal(t, models.ExecutionSucceeded, repo.executions[0].Status)
+	assert.NotNil(t, repo.executions[0].TransactionID)
+	assert.Empty(t, notifications.Notifications)
+}
+
+func TestRunDueTreatsAlreadyProcessedRunAsPaid(t *testing.T) {
+	order := newOrder(models.FrequencyDaily, date(2024, 1, 15))
+	service, repo, notifications := newTestService(order, &stubTransferrer{err: transfers.ErrAlreadyProcessed})
+
+	_, err := service.RunDue(context.Background(), time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	require.Len(t, repo.executions, 1)
+	assert.Equal(t, models.ExecutionSucceeded, repo.executions[0].Status)
+	assert.Equal(t, date(2024, 1, 16), *repo.orders[order.ID].NextRunDate)
+	assert.Empty(t, notifications.Notifications)
+}
+
+func TestRunDueContinuesPastAFailingOrder(t *testing.T) {
+	broken := newOrder(models.FrequencyDaily, date(2024, 1, 15))
+	outage := errors.New("connection reset")
+	transferrer := &stubTransferrer{failFrom: map[uuid.UUID]error{broken.AccountID: outage}}
+	service, repo, _ := newTestService(broken, transferrer)
+	order := newOrder(models.FrequencyDaily, date(2024, 1, 15))
+	repo.orders[order.ID] = order
+	accounts := service.accountRepo.(*repotest.Accounts)
+	accounts.Accounts = append(accounts.Accounts,
+		&models.Account{ID: order.AccountID, Status: models.AccountStatusActive},
+		&models.Account{ID: order.ToAccountID, Status: models.AccountStatusActive})
+
+	recorded, err := service.RunDue(context.Background(), time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, outage)
+	assert.Contains(t, err.Error(), broken.ID.String())
+	assert.Equal(t, 1, recorded, "the other order still runs")
+
+	require.Len(t, repo.executions, 1)
+	assert.Equal(t, order.ID, repo.executions[0].OrderID)
+	assert.Equal(t, date(2024, 1, 15), *repo.orders[broken.ID].NextRunDate, "the failing order is tried again on the next tick")
+}
@@ -0,0 +1,126 @@
+package statements
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"example.com/m/internal/models"
+)
+
+// utf8BOM makes spreadsheet applications read the CSV as UTF-8 so Thai text displays correctly
+const utf8BOM = "\ufeff"
+
+// Line is one transaction on a statement with the balance after it
+type Line struct {
+	Date        time.Time
+	Reference   string
+	Description string
+	Debit       float64
+	Credit      float64
+	Balance     float64
+}
+
+// Document is the content of a statement, independent of its file format
+type Document struct {
+	AccountNumber  string
+	AccountType    models.AccountType
+	Nickname       string
+	From           time.Time
+	To             time.Time
+	OpeningBalance float64
+	ClosingBalance float64
+	TotalDebits    float64
+	TotalCredits   float64
+	Lines          []Line
+	GeneratedAt    time.Time
+}
+
+// BuildDocument computes the running balance of the history starting from
+// the balance at the end of the day before the statement period
+func BuildDocument(account *models.Account, nickname string, from, to time.Time, opening float64, history []*models.AccountTransaction) *Document {
+	doc := &Document{
+		AccountNumber:  account.AccountNumber,
+		AccountType:    account.AccountType,
+		Nickname:       nickname,
+		From:           from,
+		To:             to,
+		OpeningBalance: round2(opening),
+		Lines:          make([]Line, 0, len(history)),
+		GeneratedAt:    time.Now(),
+	}
+
+	balance := opening
+	for _, item := range history {
+		balance += item.SignedAmount()
+		line := Line{
+			Date:        item.BusinessDate,
+			Reference:   item.Reference,
+			Description: item.Description,
+			Balance:     round2(balance),
+		}
+		if item.Direction == models.EntryDebit {
+			line.Debit = item.Amount
+			doc.TotalDebits += item.Amount
+		} else {
+			line.Credit = item.Amount
+			doc.TotalCredits += item.Amount
+		}
+		doc.Lines = append(doc.Lines, line)
+	}
+
+	doc.ClosingBalance = round2(balance)
+	doc.TotalDebits = round2(doc.TotalDebits)
+	doc.TotalCredits = round2(doc.TotalCredits)
+	return doc
+}
+
+// RenderCSV writes the statement as UTF-8 CSV with the opening balance as
+// the first row and the closing balance as the last
+func RenderCSV(doc *Document, w io.Writer) error {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return err
+	}
+
+	out := csv.NewWriter(w)
+	rows := [][]string{
+		{"date", "reference", "description", "debit", "credit", "balance"},
+		{doc.From.Format("2006-01-02"), "", "Opening balance", "", "", amount(doc.OpeningBalance)},
+	}
+	for _, line := range doc.Lines {
+		rows = append(rows, []string{
+			line.Date.Format("2006-01-02"),
+			line.Reference,
+			line.Description,
+			optionalAmount(line.Debit),
+			optionalAmount(line.Credit),
+			amount(line.Balance),
+		})
+	}
+	rows = append(rows, []string{
+		doc.To.Format("2006-01-02"), "", "Closing balance",
+		amount(doc.TotalDebits), amount(doc.TotalCredits), amount(doc.ClosingBalance),
+	})
+
+	if err := out.WriteAll(rows); err != nil {
+		return err
+	}
+	return out.Error()
+}
+
+func amount(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
+
+func optionalAmount(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return amount(v)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
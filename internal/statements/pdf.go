@@ -0,0 +1,116 @@
+package statements
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// fontFamily is the name the statement font is registered under in the PDF
+const fontFamily = "statement"
+
+// PDFRenderer renders statements as PDF using a TrueType font that covers
+// Thai, such as TH Sarabun New or Sarabun. The built-in PDF fonts only
+// cover Latin text.
+type PDFRenderer struct {
+	font []byte
+}
+
+// NewPDFRenderer loads the TrueType font at fontPath
+func NewPDFRenderer(fontPath string) (*PDFRenderer, error) {
+	font, err := os.ReadFile(fontPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load statement font: %w", err)
+	}
+	return &PDFRenderer{font: font}, nil
+}
+
+// Render writes the statement to w as an A4 PDF
+func (r *PDFRenderer) Render(doc *Document, w io.Writer) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(fontFamily, "", r.font)
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont(fontFamily, "", 9)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Page %d/{nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+
+	widths := []float64{22, 38, 58, 20, 20, 22}
+	header := func() {
+		pdf.SetFont(fontFamily, "", 10)
+		pdf.SetFillColor(230, 230, 230)
+		for i, title := range []string{"Date", "Reference", "Description", "Debit", "Credit", "Balance"} {
+			align := "L"
+			if i >= 3 {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], 7, title, "B", 0, align, true, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.SetHeaderFuncMode(func() {
+		if pdf.PageNo() > 1 {
+			header()
+		}
+	}, true)
+
+	pdf.AddPage()
+	pdf.SetFont(fontFamily, "", 16)
+	pdf.CellFormat(0, 9, "Account Statement", "", 1, "L", false, 0, "")
+	pdf.SetFont(fontFamily, "", 11)
+	account := doc.AccountNumber
+	if doc.Nickname != "" {
+		account = fmt.Sprintf("%s (%s)", doc.AccountNumber, doc.Nickname)
+	}
+	pdf.CellFormat(0, 6, "Account: "+account, "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, fmt.Sprintf("Period: %s to %s", doc.From.Format("2006-01-02"), doc.To.Format("2006-01-02")), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, "Generated: "+doc.GeneratedAt.Format("2006-01-02 15:04"), "", 1, "L", false, 0, "")
+	pdf.Ln(3)
+
+	header()
+	pdf.SetFont(fontFamily, "", 10)
+	row := func(cells []string) {
+		for i, text := range cells {
+			align := "L"
+			if i >= 3 {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], 6, text, "", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	row([]string{doc.From.Format("2006-01-02"), "", "Opening balance", "", "", amount(doc.OpeningBalance)})
+	for _, line := range doc.Lines {
+		row([]string{
+			line.Date.Format("2006-01-02"),
+			truncate(pdf, line.Reference, widths[1]),
+			truncate(pdf, line.Description, widths[2]),
+			optionalAmount(line.Debit),
+			optionalAmount(line.Credit),
+			amount(line.Balance),
+		})
+	}
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.Line(15, pdf.GetY(), 195, pdf.GetY())
+	row([]string{doc.To.Format("2006-01-02"), "", "Closing balance", amount(doc.TotalDebits), amount(doc.TotalCredits), amount(doc.ClosingBalance)})
+
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(w)
+}
+
+// truncate shortens text so it fits within width, leaving a small margin
+func truncate(pdf *fpdf.Fpdf, text string, width float64) string {
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)) > width-2 {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes)
+}
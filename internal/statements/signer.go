@@ -0,0 +1,76 @@
+package statements
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"example.com/m/internal/models"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrLinkExpired is returned when a signed download link is past its expiry
+	ErrLinkExpired = errors.New("download link has expired")
+	// ErrInvalidSignature is returned when a download link was not issued by this server
+	ErrInvalidSignature = errors.New("invalid download link signature")
+)
+
+// URLSigner issues and verifies short-lived statement download links. The
+// link carries its own expiry and an HMAC over the statement, format and
+// expiry, so it can be used without a session.
+type URLSigner struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewURLSigner creates a URLSigner whose links are valid for ttl
+func NewURLSigner(secret []byte, ttl time.Duration) *URLSigner {
+	return &URLSigner{
+		secret: secret,
+		ttl:    ttl,
+	}
+}
+
+// Sign returns a download link for a statement file
+func (s *URLSigner) Sign(statementID uuid.UUID, format models.StatementFormat, now time.Time) models.StatementDownload {
+	expiresAt := now.Add(s.ttl).Truncate(time.Second)
+	query := url.Values{}
+	query.Set("format", string(format))
+	query.Set("expires", strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set("signature", s.signature(statementID, format, expiresAt.Unix()))
+
+	return models.StatementDownload{
+		Format:    format,
+		URL:       fmt.Sprintf("/api/v1/statements/%s/download?%s", statementID, query.Encode()),
+		ExpiresAt: expiresAt,
+	}
+}
+
+// Verify checks the expiry and signature of a download link
+func (s *URLSigner) Verify(statementID uuid.UUID, format models.StatementFormat, expires, signature string, now time.Time) error {
+	expiresUnix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	expected := s.signature(statementID, format, expiresUnix)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	if now.Unix() > expiresUnix {
+		return ErrLinkExpired
+	}
+	return nil
+}
+
+func (s *URLSigner) signature(statementID uuid.UUID, format models.StatementFormat, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s:%s:%d", statementID, format, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
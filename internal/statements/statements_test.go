@@ -0,0 +1,112 @@
+package statements
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/repository/repotest"
+	"example.com/m/internal/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildDocumentRunningBalance(t *testing.T) {
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)
+	account := &models.Account{AccountNumber: "1234567890", AccountType: models.AccountTypeSavings}
+	history := []*models.AccountTransaction{
+		{Reference: "opening:1", Description: "ฝากเงินเปิดบัญชี", BusinessDate: from, Direction: models.EntryCredit, Amount: 500},
+		{Reference: "fd-placement:2", Description: "Fixed deposit placement", BusinessDate: to, Direction: models.EntryDebit, Amount: 200.25},
+	}
+
+	doc := BuildDocument(account, "เงินเก็บ", from, to, 1000, history)
+
+	assert.Equal(t, 1000.0, doc.OpeningBalance)
+	assert.Equal(t, 1299.75, doc.ClosingBalance)
+	assert.Equal(t, 500.0, doc.TotalCredits)
+	assert.Equal(t, 200.25, doc.TotalDebits)
+	require.Len(t, doc.Lines, 2)
+	assert.Equal(t, 1500.0, doc.Lines[0].Balance)
+	assert.Equal(t, 200.25, doc.Lines[1].Debit)
+	assert.Equal(t, 1299.75, doc.Lines[1].Balance)
+
+	var out bytes.Buffer
+	require.NoError(t, RenderCSV(doc, &out))
+	lines := strings.Split(strings.TrimSpace(strings.TrimPrefix(out.String(), utf8BOM)), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, "2026-09-01,,Opening balance,,,1000.00", lines[1])
+	assert.Equal(t, "2026-09-01,opening:1,ฝากเงินเปิดบัญชี,,500.00,1500.00", lines[2])
+	assert.Equal(t, "2026-09-30,,Closing balance,200.25,500.00,1299.75", lines[4])
+}
+
+func TestURLSigner(t *testing.T) {
+	signer := NewURLSigner([]byte("secret"), 5*time.Minute)
+	id := uuid.New()
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	link := signer.Sign(id, models.StatementFormatPDF, now)
+	assert.Equal(t, now.Add(5*time.Minute), link.ExpiresAt)
+	assert.Contains(t, link.URL, "/api/v1/statements/"+id.String()+"/download?")
+
+	expires := "1790856300" // now + 5 minutes
+	signature := signer.signature(id, models.StatementFormatPDF, 1790856300)
+	assert.NoError(t, signer.Verify(id, models.StatementFormatPDF, expires, signature, now))
+	assert.ErrorIs(t, signer.Verify(id, models.StatementFormatCSV, expires, signature, now), ErrInvalidSignature)
+	assert.ErrorIs(t, signer.Verify(uuid.New(), models.StatementFormatPDF, expires, signature, now), ErrInvalidSignature)
+	assert.ErrorIs(t, signer.Verify(id, models.StatementFormatPDF, expires, signature, now.Add(6*time.Minute)), ErrLinkExpired)
+}
+
+// stubAccountRepository adds the default preferences to the in-memory accounts
+type stubAccountRepository struct {
+	repotest.Accounts
+}
+
+func (r *stubAccountRepository) GetAccountPreferences(ctx context.Context, accountID, customerID uuid.UUID) (*models.AccountPreferences, error) {
+	return nil, nil
+}
+
+type stubLedgerRepository struct {
+	repository.LedgerRepository
+	history []*models.AccountTransaction
+}
+
+func (r *stubLedgerRepository) GetBalanceAsOf(ctx context.Context, accountID uuid.UUID, businessDate time.Time) (float64, error) {
+	return 1000, nil
+}
+
+func (r *stubLedgerRepository) GetAccountHistory(ctx context.Context, accountID uuid.UUID, from, to time.Time) ([]*models.AccountTransaction, error) {
+	return r.history, nil
+}
+
+func TestGenerateWithoutPDFRenderer(t *testing.T) {
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)
+	account := &models.Account{ID: uuid.New(), AccountNumber: "1234567890", AccountType: models.AccountTypeSavings}
+	ledger := &stubLedgerRepository{history: []*models.AccountTransaction{
+		{Reference: "deposit:1", BusinessDate: from, Direction: models.EntryCredit, Amount: 500},
+	}}
+	store := storage.NewLocalStorage(t.TempDir())
+	worker := NewWorker(&stubAccountRepository{repotest.Accounts{Accounts: []*models.Account{account}}}, ledger, nil, store, nil)
+
+	statement := &models.Statement{ID: uuid.New(), AccountID: account.ID, FromDate: from, ToDate: to}
+	require.NoError(t, worker.Generate(context.Background(), statement))
+
+	assert.Equal(t, models.StatementReady, statement.Status)
+	assert.Empty(t, statement.PDFKey)
+	require.NotEmpty(t, statement.CSVKey)
+	assert.Equal(t, 1500.0, *statement.ClosingBalance)
+
+	file, err := store.Open(context.Background(), statement.CSVKey)
+	require.NoError(t, err)
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "deposit:1")
+}
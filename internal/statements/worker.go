@@ -0,0 +1,180 @@
+package statements
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	// pollInterval is how often the worker looks for statements it was not notified about
+	pollInterval = time.Minute
+	// staleAfter is how long a statement may stay processing before it is picked up again
+	staleAfter = 10 * time.Minute
+)
+
+// Worker renders requested statements in the background. Requests are
+// persisted before they are queued, so statements that were still waiting
+// when the server stopped are rendered after it starts again.
+type Worker struct {
+	accountRepo   repository.AccountRepository
+	ledgerRepo    repository.LedgerRepository
+	statementRepo repository.StatementRepository
+	storage       storage.BlobStorage
+	pdf           *PDFRenderer
+	queue         chan uuid.UUID
+}
+
+// NewWorker creates a new statement Worker. pdf may be nil when no Thai font
+// is configured; statements are then rendered as CSV only.
+func NewWorker(
+	accountRepo repository.AccountRepository,
+	ledgerRepo repository.LedgerRepository,
+	statementRepo repository.StatementRepository,
+	blobStorage storage.BlobStorage,
+	pdf *PDFRenderer,
+) *Worker {
+	return &Worker{
+		accountRepo:   accountRepo,
+		ledgerRepo:    ledgerRepo,
+		statementRepo: statementRepo,
+		storage:       blobStorage,
+		pdf:           pdf,
+		queue:         make(chan uuid.UUID, 100),
+	}
+}
+
+// Enqueue asks the worker to render a statement. When the queue is full the
+// statement is left pending and picked up by the next poll.
+func (w *Worker) Enqueue(id uuid.UUID) {
+	select {
+	case w.queue <- id:
+	default:
+		log.Printf("statement queue is full, statement %s will be picked up later", id)
+	}
+}
+
+// Start runs the worker until ctx is cancelled
+func (w *Worker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		w.poll(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case id := <-w.queue:
+				w.process(ctx, id)
+			case <-ticker.C:
+				w.poll(ctx)
+			}
+		}
+	}()
+}
+
+func (w *Worker) poll(ctx context.Context) {
+	ids, err := w.statementRepo.GetQueuedStatementIDs(ctx, time.Now().Add(-staleAfter))
+	if err != nil {
+		log.Printf("failed to load queued statements: %v", err)
+		return
+	}
+	for _, id := range ids {
+		w.process(ctx, id)
+	}
+}
+
+// process claims and renders one statement and records the outcome
+func (w *Worker) process(ctx context.Context, id uuid.UUID) {
+	statement, err := w.statementRepo.ClaimStatement(ctx, id, time.Now().Add(-staleAfter))
+	if err != nil {
+		log.Printf("failed to claim statement %s: %v", id, err)
+		return
+	}
+	if statement == nil {
+		return
+	}
+
+	if err := w.Generate(ctx, statement); err != nil {
+		log.Printf("statement %s failed: %v", id, err)
+		statement.Status = models.StatementFailed
+		statement.FailureReason = err.Error()
+	}
+
+	now := time.Now()
+	statement.UpdatedAt = now
+	statement.CompletedAt = &now
+	if err := w.statementRepo.UpdateStatement(ctx, statement); err != nil {
+		log.Printf("failed to save statement %s: %v", id, err)
+	}
+}
+
+// Generate renders the statement as CSV, and as PDF when a renderer is
+// configured, and stores the files. On success the statement is marked
+// ready with its balances and file keys; PDFKey stays empty without PDF.
+func (w *Worker) Generate(ctx context.Context, statement *models.Statement) error {
+	account, err := w.accountRepo.GetAccountByID(ctx, statement.AccountID)
+	if err != nil {
+		return err
+	}
+	if account == nil {
+		return repository.ErrAccountNotFound
+	}
+
+	nickname := ""
+	preferences, err := w.accountRepo.GetAccountPreferences(ctx, account.ID, statement.CustomerID)
+	if err != nil {
+		return err
+	}
+	if preferences != nil {
+		nickname = preferences.Nickname
+	}
+
+	opening, err := w.ledgerRepo.GetBalanceAsOf(ctx, account.ID, statement.FromDate.AddDate(0, 0, -1))
+	if err != nil {
+		return fmt.Errorf("failed to load opening balance: %w", err)
+	}
+	history, err := w.ledgerRepo.GetAccountHistory(ctx, account.ID, statement.FromDate, statement.ToDate)
+	if err != nil {
+		return fmt.Errorf("failed to load transactions: %w", err)
+	}
+	doc := BuildDocument(account, nickname, statement.FromDate, statement.ToDate, opening, history)
+
+	prefix := fmt.Sprintf("statements/%s/%s", account.ID, statement.ID)
+
+	var csvFile bytes.Buffer
+	if err := RenderCSV(doc, &csvFile); err != nil {
+		return fmt.Errorf("failed to render CSV: %w", err)
+	}
+	if _, err := w.storage.Put(ctx, prefix+".csv", &csvFile); err != nil {
+		return fmt.Errorf("failed to store CSV: %w", err)
+	}
+
+	pdfKey := ""
+	if w.pdf != nil {
+		var pdfFile bytes.Buffer
+		if err := w.pdf.Render(doc, &pdfFile); err != nil {
+			return fmt.Errorf("failed to render PDF: %w", err)
+		}
+		if _, err := w.storage.Put(ctx, prefix+".pdf", &pdfFile); err != nil {
+			return fmt.Errorf("failed to store PDF: %w", err)
+		}
+		pdfKey = prefix + ".pdf"
+	}
+
+	statement.Status = models.StatementReady
+	statement.OpeningBalance = &doc.OpeningBalance
+	statement.ClosingBalance = &doc.ClosingBalance
+	statement.PDFKey = pdfKey
+	statement.CSVKey = prefix + ".csv"
+	statement.FailureReason = ""
+	return nil
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func read(t *testing.T, s *LocalStorage, key string) string {
+	t.Helper()
+	r, err := s.Open(context.Background(), key)
+	require.NoError(t, err)
+	defer r.Close()
+	content, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestPutOpenAndDelete(t *testing.T) {
+	s := NewLocalStorage(t.TempDir())
+	ctx := context.Background()
+
+	n, err := s.Put(ctx, "loan-documents/a/b", strings.NewReader("payslip"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), n)
+	assert.Equal(t, "payslip", read(t, s, "loan-documents/a/b"))
+
+	require.NoError(t, s.Delete(ctx, "loan-documents/a/b"))
+	_, err = s.Open(ctx, "loan-documents/a/b")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, s.Delete(ctx, "loan-documents/a/b"), "deleting a missing blob is not an error")
+}
+
+func TestPutReplacesExistingBlob(t *testing.T) {
+	root := t.TempDir()
+	s := NewLocalStorage(root)
+	ctx := context.Background()
+
+	_, err := s.Put(ctx, "statements/1.csv", strings.NewReader("a much longer first version"))
+	require.NoError(t, err)
+	_, err = s.Put(ctx, "statements/1.csv", strings.NewReader("second"))
+	require.NoError(t, err)
+	assert.Equal(t, "second", read(t, s, "statements/1.csv"))
+
+	entries, err := os.ReadDir(filepath.Join(root, "statements"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary files are left behind")
+}
+
+func TestKeysCannotEscapeRoot(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "blobs")
+	s := NewLocalStorage(root)
+	ctx := context.Background()
+
+	for _, key := range []string{"../outside", "a/../../outside", "..", "", "/"} {
+		_, err := s.Put(ctx, key, strings.NewReader("x"))
+		assert.Error(t, err, key)
+		_, err = s.Open(ctx, key)
+		assert.Error(t, err, key)
+		assert.Error(t, s.Delete(ctx, key), key)
+	}
+	_, err := os.Stat(filepath.Join(parent, "outside"))
+	assert.True(t, os.IsNotExist(err))
+
+	// A leading slash is read relative to the root
+	_, err = s.Put(ctx, "/abs/key", strings.NewReader("inside"))
+	require.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(root, "abs", "key"))
+	require.NoError(t, err)
+	assert.Equal(t, "inside", string(content))
+}
@@ -0,0 +1,247 @@
+// Package thaiqr encodes and decodes Thai QR payment payloads, the EMVCo
+// merchant-presented QR format used by PromptPay. A payload is a list of
+// tag-length-value fields ending with a CRC-16 checksum.
+package thaiqr
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Top-level tags of a Thai QR payload
+const (
+	tagPayloadFormat   = "00"
+	tagPointOfInit     = "01"
+	tagCreditTransfer  = "29"
+	tagBillPayment     = "30"
+	tagCurrency        = "53"
+	tagAmount          = "54"
+	tagCountry         = "58"
+	tagAdditionalData  = "62"
+	tagCRC             = "63"
+	subTagAID          = "00"
+	subTagMobile       = "01"
+	subTagNationalID   = "02"
+	subTagBankAccount  = "04"
+	subTagBillerID     = "01"
+	subTagReference1   = "02"
+	subTagReference2   = "03"
+	subTagReferenceTag = "05"
+)
+
+const (
+	// aidCreditTransfer identifies a PromptPay credit transfer in tag 29
+	aidCreditTransfer = "A000000677010111"
+	// aidBillPayment identifies a PromptPay bill payment in tag 30
+	aidBillPayment = "A000000677010112"
+	// currencyTHB is the ISO 4217 numeric code of the baht
+	currencyTHB = "764"
+	// staticCode and dynamicCode are the point of initiation of codes without and with an amount
+	staticCode  = "11"
+	dynamicCode = "12"
+)
+
+var (
+	// ErrInvalidPayload is returned when the payload is not a well-formed list of fields
+	ErrInvalidPayload = errors.New("the QR code is not a valid Thai QR payment code")
+	// ErrChecksum is returned when the CRC of the payload does not match its content
+	ErrChecksum = errors.New("the QR code checksum does not match; scan it again")
+	// ErrUnsupported is returned for valid codes this bank cannot pay, such as foreign currency codes
+	ErrUnsupported = errors.New("this QR code type is not supported")
+)
+
+// Target is what a payload pays
+type Target string
+
+const (
+	// TargetMobile pays the account a mobile number is registered to
+	TargetMobile Target = "mobile"
+	// TargetNationalID pays the account a national ID is registered to
+	TargetNationalID Target = "national_id"
+	// TargetBankAccount pays an account number; the value is the bank code followed by the account number
+	TargetBankAccount Target = "bank_account"
+	// TargetBiller pays a biller; the value is the biller ID
+	TargetBiller Target = "biller"
+)
+
+// Payload is the payment a Thai QR code asks for. Credit transfers (tag 29)
+// pay a mobile number, national ID or account; bill payments (tag 30) pay
+// a biller with references.
+type Payload struct {
+	Target Target
+	// Value is the mobile number in domestic form (0XXXXXXXXX), the national
+	// ID, the bank code and account number, or the biller ID
+	Value string
+	// Amount is fixed by the code when set; static codes leave it to the payer
+	Amount     float64
+	Reference  string
+	Reference2 string
+}
+
+// Encode returns the payload string of p with its checksum
+func Encode(p Payload) (string, error) {
+	var account string
+	switch p.Target {
+	case TargetMobile:
+		if len(p.Value) != 10 || !strings.HasPrefix(p.Value, "0") {
+			return "", fmt.Errorf("mobile number must be 10 digits starting with 0")
+		}
+		account = field(subTagAID, aidCreditTransfer) + field(subTagMobile, "0066"+p.Value[1:])
+	case TargetNationalID:
+		account = field(subTagAID, aidCreditTransfer) + field(subTagNationalID, p.Value)
+	case TargetBankAccount:
+		account = field(subTagAID, aidCreditTransfer) + field(subTagBankAccount, p.Value)
+	case TargetBiller:
+		account = field(subTagAID, aidBillPayment) + field(subTagBillerID, p.Value) + field(subTagReference1, p.Reference)
+		if p.Reference2 != "" {
+			account += field(subTagReference2, p.Reference2)
+		}
+	default:
+		return "", fmt.Errorf("unknown target %q", p.Target)
+	}
+
+	var b strings.Builder
+	b.WriteString(field(tagPayloadFormat, "01"))
+	if p.Amount > 0 {
+		b.WriteString(field(tagPointOfInit, dynamicCode))
+	} else {
+		b.WriteString(field(tagPointOfInit, staticCode))
+	}
+	if p.Target == TargetBiller {
+		b.WriteString(field(tagBillPayment, account))
+	} else {
+		b.WriteString(field(tagCreditTransfer, account))
+	}
+	b.WriteString(field(tagCurrency, currencyTHB))
+	if p.Amount > 0 {
+		b.WriteString(field(tagAmount, strconv.FormatFloat(p.Amount, 'f', 2, 64)))
+	}
+	b.WriteString(field(tagCountry, "TH"))
+	if p.Target != TargetBiller && p.Reference != "" {
+		b.WriteString(field(tagAdditionalData, field(subTagReferenceTag, p.Reference)))
+	}
+	b.WriteString(tagCRC + "04")
+	return b.String() + checksum(b.String()), nil
+}
+
+// Decode checks the checksum of a scanned payload and returns the payment it asks for
+func Decode(payload string) (*Payload, error) {
+	payload = strings.TrimSpace(payload)
+	if len(payload) < 8 || payload[len(payload)-8:len(payload)-4] != tagCRC+"04" {
+		return nil, ErrInvalidPayload
+	}
+	if !strings.EqualFold(checksum(payload[:len(payload)-4]), payload[len(payload)-4:]) {
+		return nil, ErrChecksum
+	}
+
+	fields, err := parseFields(payload[:len(payload)-8])
+	if err != nil {
+		return nil, err
+	}
+	if fields[tagPayloadFormat] != "01" {
+		return nil, ErrInvalidPayload
+	}
+	if currency, ok := fields[tagCurrency]; ok && currency != currencyTHB {
+		return nil, ErrUnsupported
+	}
+
+	var p Payload
+	if amount, ok := fields[tagAmount]; ok {
+		p.Amount, err = strconv.ParseFloat(amount, 64)
+		if err != nil || p.Amount <= 0 {
+			return nil, ErrInvalidPayload
+		}
+	}
+
+	switch {
+	case fields[tagCreditTransfer] != "":
+		account, err := parseFields(fields[tagCreditTransfer])
+		if err != nil {
+			return nil, err
+		}
+		if account[subTagAID] != aidCreditTransfer {
+			return nil, ErrUnsupported
+		}
+		switch {
+		case account[subTagMobile] != "":
+			mobile := account[subTagMobile]
+			if len(mobile) != 13 || !strings.HasPrefix(mobile, "0066") {
+				return nil, ErrInvalidPayload
+			}
+			p.Target, p.Value = TargetMobile, "0"+mobile[4:]
+		case account[subTagNationalID] != "":
+			p.Target, p.Value = TargetNationalID, account[subTagNationalID]
+		case account[subTagBankAccount] != "":
+			p.Target, p.Value = TargetBankAccount, account[subTagBankAccount]
+		default:
+			return nil, ErrUnsupported
+		}
+		if additional, ok := fields[tagAdditionalData]; ok {
+			data, err := parseFields(additional)
+			if err != nil {
+				return nil, err
+			}
+			p.Reference = data[subTagReferenceTag]
+		}
+	case fields[tagBillPayment] != "":
+		bill, err := parseFields(fields[tagBillPayment])
+		if err != nil {
+			return nil, err
+		}
+		if bill[subTagAID] != aidBillPayment || bill[subTagBillerID] == "" {
+			return nil, ErrUnsupported
+		}
+		p.Target, p.Value = TargetBiller, bill[subTagBillerID]
+		p.Reference, p.Reference2 = bill[subTagReference1], bill[subTagReference2]
+	default:
+		return nil, ErrUnsupported
+	}
+	return &p, nil
+}
+
+// PNG renders a payload as a QR code image of size by size pixels
+func PNG(payload string, size int) ([]byte, error) {
+	return qrcode.Encode(payload, qrcode.Medium, size)
+}
+
+// field encodes one tag-length-value field
+func field(tag, value string) string {
+	return fmt.Sprintf("%s%02d%s", tag, len(value), value)
+}
+
+// parseFields splits a list of tag-length-value fields
+func parseFields(data string) (map[string]string, error) {
+	fields := map[string]string{}
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, ErrInvalidPayload
+		}
+		length, err := strconv.Atoi(data[2:4])
+		if err != nil || len(data) < 4+length {
+			return nil, ErrInvalidPayload
+		}
+		fields[data[:2]] = data[4 : 4+length]
+		data = data[4+length:]
+	}
+	return fields, nil
+}
+
+// checksum returns the CRC-16/CCITT-FALSE of data as four uppercase hex digits
+func checksum(data string) string {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return fmt.Sprintf("%04X", crc)
+}
@@ -0,0 +1,62 @@
+package thaiqr
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksum(t *testing.T) {
+	// The standard check value of CRC-16/CCITT-FALSE
+	assert.Equal(t, "29B1", checksum("123456789"))
+}
+
+func TestDecodePromptPayMobileCode(t *testing.T) {
+	// A static PromptPay code for 081-234-5678 with the fields in another order
+	code, err := Decode("00020101021129370016A000000677010111011300668123456785802TH530376463045D82")
+	require.NoError(t, err)
+	assert.Equal(t, TargetMobile, code.Target)
+	assert.Equal(t, "0812345678", code.Value)
+	assert.Zero(t, code.Amount)
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	tests := []Payload{
+		{Target: TargetBankAccount, Value: "0990000012345"},
+		{Target: TargetBankAccount, Value: "0990000012345", Amount: 150.5, Reference: "INV42"},
+		{Target: TargetNationalID, Value: "1101700203450", Amount: 20},
+		{Target: TargetBiller, Value: "0990000012345", Amount: 1999.99, Reference: "ORDER123", Reference2: "A1"},
+	}
+	for _, want := range tests {
+		payload, err := Encode(want)
+		require.NoError(t, err)
+		got, err := Decode(payload)
+		require.NoError(t, err)
+		assert.Equal(t, want, *got)
+	}
+}
+
+func TestDecodeRejectsBadCodes(t *testing.T) {
+	payload, err := Encode(Payload{Target: TargetBankAccount, Value: "0990000012345", Amount: 100})
+	require.NoError(t, err)
+
+	tampered := []byte(payload)
+	tampered[bytes.Index(tampered, []byte("100.00"))] = '9'
+	_, err = Decode(string(tampered))
+	assert.ErrorIs(t, err, ErrChecksum)
+
+	_, err = Decode("hello")
+	assert.ErrorIs(t, err, ErrInvalidPayload)
+
+	usd := "000201010211" + field(tagCreditTransfer, field(subTagAID, aidCreditTransfer)+field(subTagBankAccount, "0991234567890")) + field(tagCurrency, "840") + "6304"
+	_, err = Decode(usd + checksum(usd))
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
+
+func TestPNG(t *testing.T) {
+	image, err := PNG("00020101021129370016A000000677010111011300668123456785802TH530376463045D82", 256)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(image, []byte("\x89PNG")))
+}
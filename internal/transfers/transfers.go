@@ -0,0 +1,250 @@
+package transfers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/limits"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidAmount is returned when the amount is not positive or has more decimals than its currency
+	ErrInvalidAmount = errors.New("amount must be greater than zero with no more decimals than the currency allows")
+	// ErrDestinationNotFound is returned when the destination account does not exist
+	ErrDestinationNotFound = errors.New("destination account not found")
+	// ErrSameAccount is returned when the source and destination are the same account
+	ErrSameAccount = errors.New("cannot transfer to the same account")
+	// ErrCurrencyMismatch is returned when the accounts are in different currencies, which needs an FX quote
+	ErrCurrencyMismatch = errors.New("accounts are in different currencies; request an FX quote to convert")
+	// ErrAlreadyProcessed is returned when a request with the same reference was already posted
+	ErrAlreadyProcessed = errors.New("a request with this reference was already processed")
+)
+
+// Input describes a transfer between two customer accounts
+type Input struct {
+	From        *models.Account
+	To          *models.Account
+	Amount      float64
+	Description string
+	// Reference is the client key for the request; a new one is generated when empty
+	Reference string
+	Type      models.LedgerTransactionType
+	// CustomerID is the customer who asked for the transfer; their
+	// transaction limits apply unless it is empty
+	CustomerID uuid.UUID
+	// Channel is where the customer asked for it; mobile when empty
+	Channel models.Channel
+	// Status is the lifecycle state the transaction is posted in; settled when empty
+	Status models.LedgerTransactionStatus
+}
+
+// Withdrawal describes a cash withdrawal from a customer account
+type Withdrawal struct {
+	From   *models.Account
+	Amount float64
+	// Reference is the client key for the request; a new one is generated when empty
+	Reference  string
+	CustomerID uuid.UUID
+	Channel    models.Channel
+}
+
+// Service posts transfers and withdrawals. The ledger checks the available
+// balance of the source account, so funds reserved by holds cannot be moved,
+// and the customer's transaction limits are reserved before posting.
+type Service struct {
+	accountRepo repository.AccountRepository
+	productRepo repository.ProductRepository
+	ledgerRepo  repository.LedgerRepository
+	ledger      *ledger.Service
+	limits      *limits.Service
+}
+
+// NewService creates a new transfer Service
+func NewService(
+	accountRepo repository.AccountRepository,
+	productRepo repository.ProductRepository,
+	ledgerRepo repository.LedgerRepository,
+	ledgerService *ledger.Service,
+	limitService *limits.Service,
+) *Service {
+	return &Service{
+		accountRepo: accountRepo,
+		productRepo: productRepo,
+		ledgerRepo:  ledgerRepo,
+		ledger:      ledgerService,
+		limits:      limitService,
+	}
+}
+
+// FindDestination looks up a customer account by account number
+func (s *Service) FindDestination(ctx context.Context, accountNumber string) (*models.Account, error) {
+	account, err := s.accountRepo.GetAccountByNumber(ctx, strings.TrimSpace(accountNumber))
+	if err != nil {
+		return nil, err
+	}
+	if account == nil || account.IsInternal() {
+		return nil, ErrDestinationNotFound
+	}
+	return account, nil
+}
+
+// Validate checks the amount and accounts of a transfer without posting it
+func Validate(input Input) error {
+	if err := validAmount(input.From, input.Amount); err != nil {
+		return err
+	}
+	if input.From.ID == input.To.ID {
+		return ErrSameAccount
+	}
+	if input.From.CurrencyOrBase() != input.To.CurrencyOrBase() {
+		return ErrCurrencyMismatch
+	}
+	return nil
+}
+
+// Transfer moves funds from one customer account to another. The first
+// transfer into an account that was never funded must meet the minimum
+// opening balance of its product.
+func (s *Service) Transfer(ctx context.Context, input Input) (*models.TransferResult, error) {
+	if err := Validate(input); err != nil {
+		return nil, err
+	}
+	if err := s.checkOpeningDeposit(ctx, input.From, input.To, input.Amount); err != nil {
+		return nil, err
+	}
+	if input.Type == "" {
+		input.Type = models.LedgerTransfer
+	}
+	description := input.Description
+	if description == "" {
+		description = fmt.Sprintf("Transfer to %s", input.To.AccountNumber)
+	}
+
+	payment := limits.Payment{CustomerID: input.CustomerID, Channel: input.Channel, Type: models.LimitTransfer}
+	return s.post(ctx, input.From, input.To, input.Amount, payment, &models.LedgerTransaction{
+		Reference:   reference("transfer", input.From.ID, input.Reference),
+		Type:        input.Type,
+		Description: description,
+		Status:      input.Status,
+	})
+}
+
+// Withdraw pays out cash from a customer account
+func (s *Service) Withdraw(ctx context.Context, withdrawal Withdrawal) (*models.TransferResult, error) {
+	from, amount := withdrawal.From, withdrawal.Amount
+	if err := validAmount(from, amount); err != nil {
+		return nil, err
+	}
+
+	cash, err := repository.GetInternalAccount(ctx, s.accountRepo, models.CurrencyAccount(models.GLCash, from.Currency))
+	if err != nil {
+		return nil, err
+	}
+
+	payment := limits.Payment{CustomerID: withdrawal.CustomerID, Channel: withdrawal.Channel, Type: models.LimitWithdrawal}
+	return s.post(ctx, from, cash, amount, payment, &models.LedgerTransaction{
+		Reference:   reference("withdrawal", from.ID, withdrawal.Reference),
+		Type:        models.LedgerWithdrawal,
+		Description: "Cash withdrawal",
+	})
+}
+
+// checkOpeningDeposit applies the product's minimum opening balance to a
+// transfer between customer accounts into an account nothing was ever
+// posted to, as a cash deposit at a branch is checked. Credits from internal
+// accounts, such as refunds and returns, are never refused.
+func (s *Service) checkOpeningDeposit(ctx context.Context, from, to *models.Account, amount float64) error {
+	if from.IsInternal() || to.IsInternal() || to.ProductCode == "" || to.Balance != 0 {
+		return nil
+	}
+	funded, err := s.ledgerRepo.HasEntries(ctx, to.ID)
+	if err != nil || funded {
+		return err
+	}
+	product, err := s.productRepo.GetProductVersion(ctx, to.ProductCode, to.ProductVersion)
+	if err != nil || product == nil {
+		return err
+	}
+	return product.CheckFirstDeposit(amount)
+}
+
+// post reserves the payment against the customer's limits and posts it,
+// giving the reservation back if the ledger refuses the posting
+func (s *Service) post(ctx context.Context, from, to *models.Account, amount float64, payment limits.Payment, txn *models.LedgerTransaction) (*models.TransferResult, error) {
+	payment.Amount = models.Money{Amount: amount, Currency: from.CurrencyOrBase()}
+	reservation, err := s.limits.Reserve(ctx, payment)
+	if err != nil {
+		return nil, err
+	}
+
+	txn.Entries = []models.LedgerEntry{
+		ledger.Debit(from.ID, amount),
+		ledger.Credit(to.ID, amount),
+	}
+	if err := s.ledger.Post(ctx, txn); err != nil {
+		if releaseErr := s.limits.Release(ctx, reservation); releaseErr != nil {
+			log.Printf("failed to release limit usage for %s: %v", txn.Reference, releaseErr)
+		}
+		if errors.Is(err, repository.ErrDuplicateReference) {
+			return nil, ErrAlreadyProcessed
+		}
+		return nil, err
+	}
+
+	result := &models.TransferResult{
+		TransactionID: txn.ID,
+		Reference:     txn.Reference,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        amount,
+		PostedAt:      txn.CreatedAt,
+	}
+	if updated, err := s.accountRepo.GetAccountByID(ctx, from.ID); err == nil && updated != nil {
+		result.AvailableBalance = updated.Available()
+	}
+	return result, nil
+}
+
+// reference scopes a client key to the source account so two customers can
+// use the same key. Without a client key every request is unique.
+func reference(kind string, accountID uuid.UUID, clientReference string) string {
+	clientReference = strings.TrimSpace(clientReference)
+	if clientReference == "" {
+		clientReference = uuid.NewString()
+	}
+	return fmt.Sprintf("%s:%s:%s", kind, accountID, clientReference)
+}
+
+func validAmount(account *models.Account, amount float64) error {
+	if !account.CurrencyOrBase().ValidAmount(amount) {
+		return ErrInvalidAmount
+	}
+	return nil
+}
+
+// IsRefused reports whether a transfer failed because the payment itself
+// was refused, as opposed to an outage after which it can be tried again.
+// A reference that was already processed counts as refused.
+func IsRefused(err error) bool {
+	return errors.Is(err, ErrAlreadyProcessed) ||
+		errors.Is(err, ErrInvalidAmount) ||
+		errors.Is(err, ErrDestinationNotFound) ||
+		errors.Is(err, ErrSameAccount) ||
+		errors.Is(err, ErrCurrencyMismatch) ||
+		errors.Is(err, models.ErrBelowOpeningBalance) ||
+		errors.Is(err, ledger.ErrInsufficientFunds) ||
+		errors.Is(err, ledger.ErrBelowMinimumBalance) ||
+		errors.Is(err, ledger.ErrWithdrawalLimit) ||
+		errors.Is(err, ledger.ErrAccountNotActive) ||
+		errors.Is(err, ledger.ErrAccountDormant) ||
+		errors.Is(err, ledger.ErrAccountRestricted) ||
+		errors.Is(err, ledger.ErrCurrencyMismatch) ||
+		errors.Is(err, limits.ErrLimitExceeded)
+}
@@ -0,0 +1,82 @@
+package transfers
+
+import (
+	"context"
+	"testing"
+
+	"example.com/m/internal/config"
+	"example.com/m/internal/ledger"
+	"example.com/m/internal/limits"
+	"example.com/m/internal/models"
+	"example.com/m/internal/repository"
+	"example.com/m/internal/repository/repotest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubProductRepository returns the product every account was opened under
+type stubProductRepository struct {
+	repository.ProductRepository
+	product *models.AccountProduct
+}
+
+func (r *stubProductRepository) GetProductVersion(ctx context.Context, code string, version int) (*models.AccountProduct, error) {
+	return r.product, nil
+}
+
+// stubLedgerRepository applies postings to the accounts in memory and
+// remembers which accounts were posted to
+type stubLedgerRepository struct {
+	repository.LedgerRepository
+	accounts map[uuid.UUID]*models.Account
+	posted   map[uuid.UUID]bool
+}
+
+func (r *stubLedgerRepository) PostTransaction(ctx context.Context, txn *models.LedgerTransaction, validate repository.PostingValidator) error {
+	if err := validate(r.accounts, txn); err != nil {
+		return err
+	}
+	for _, entry := range txn.Entries {
+		r.accounts[entry.AccountID].Balance += entry.SignedAmount()
+		r.posted[entry.AccountID] = true
+	}
+	return nil
+}
+
+func (r *stubLedgerRepository) HasEntries(ctx context.Context, accountID uuid.UUID) (bool, error) {
+	return r.posted[accountID], nil
+}
+
+func TestTransferFundingNewAccountNeedsOpeningBalance(t *testing.T) {
+	source := repotest.Account("1000000001", 10000)
+	opened := repotest.Account("1000000002", 0)
+	opened.ProductCode, opened.ProductVersion = "SAVINGS", 1
+	clearing := repotest.InternalAccount(models.GLInterbankClearing)
+	ledgerRepo := &stubLedgerRepository{accounts: repotest.ByID(source, opened, clearing), posted: map[uuid.UUID]bool{source.ID: true}}
+	products := &stubProductRepository{product: &models.AccountProduct{Code: "SAVINGS", Version: 1, MinOpeningBalance: 500}}
+	accounts := &repotest.Accounts{Accounts: []*models.Account{source, opened, clearing}}
+	service := NewService(accounts, products, ledgerRepo, ledger.NewService(ledgerRepo), limits.NewService(nil, nil, nil, config.LimitConfig{}))
+	ctx := context.Background()
+
+	_, err := service.Transfer(ctx, Input{From: source, To: opened, Amount: 499})
+	assert.ErrorIs(t, err, models.ErrBelowOpeningBalance)
+	assert.True(t, IsRefused(err))
+	assert.Equal(t, 0.0, opened.Balance)
+
+	// Credits from internal accounts are not held to the opening balance
+	_, err = service.Transfer(ctx, Input{From: clearing, To: opened, Amount: 100, Type: models.LedgerInterbankRefund})
+	require.NoError(t, err)
+
+	// Once funded, the account can be drained and credited with any amount
+	funded := repotest.Account("1000000003", 0)
+	funded.ProductCode, funded.ProductVersion = "SAVINGS", 1
+	ledgerRepo.accounts[funded.ID] = funded
+	accounts.Accounts = append(accounts.Accounts, funded)
+	_, err = service.Transfer(ctx, Input{From: source, To: funded, Amount: 500})
+	require.NoError(t, err)
+	funded.Balance = 0
+	_, err = service.Transfer(ctx, Input{From: source, To: funded, Amount: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, funded.Balance)
+}